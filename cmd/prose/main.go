@@ -1,18 +1,80 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/JackWReid/prose/internal/editor"
+	"github.com/JackWReid/prose/internal/terminal"
 )
 
 var Version = "dev"
 
 func main() {
-	filenames := os.Args[1:]
+	// prose wc, prose spell and prose lint print what they find and exit:
+	// 1 if there is anything to fix, 2 on errors.
+	if editor.IsSubcommand(os.Args[1:], terminal.IsTerminal(os.Stdin)) {
+		found, err := editor.RunSubcommand(os.Args[1], os.Args[2:], os.Stdin, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "prose: %v\n", err)
+			os.Exit(2)
+		}
+		if found {
+			os.Exit(1)
+		}
+		return
+	}
+
+	debugFile := flag.String("debug", "", "write a debug log of input, commands and render timings to `logfile`")
+	screenReader := flag.Bool("screen-reader", false, "draw for terminal screen readers: plain overlays, fewer redraws")
+	lowBandwidth := flag.Bool("low-bandwidth", false, "send only what changed each frame, for slow connections")
+	readOnly := flag.Bool("readonly", false, "open the files read-only, refusing edits")
+	today := flag.Bool("today", false, "open today's journal note, creating it if need be")
+	tutor := flag.Bool("tutor", false, "open the interactive tutorial")
+	template := flag.String("template", "", "fill a new file from the template `name`")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: prose [--debug logfile] [--screen-reader] [--low-bandwidth] [--readonly] [--template name] [--today] [--tutor] [file ...]\n")
+		fmt.Fprintf(os.Stderr, "       prose wc|spell|lint [file ...]\n")
+		fmt.Fprintf(os.Stderr, "To open a file named wc, spell or lint, write prose -- wc or prose ./wc.\n")
+		fmt.Fprintf(os.Stderr, "A file named - is read from standard input, and written to standard output on quitting if that is redirected.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	filenames := flag.Args()
 
 	app := editor.NewApp(filenames)
+	if *screenReader {
+		app.SetScreenReader(true)
+	}
+	if *lowBandwidth {
+		app.SetLowBandwidth(true)
+	}
+	if *readOnly {
+		app.SetReadOnly(true)
+	}
+	if *template != "" {
+		app.SetTemplate(*template)
+	}
+	if *today {
+		app.SetToday(true)
+	}
+	if *tutor {
+		app.SetTutor(true)
+	}
+
+	if *debugFile != "" {
+		f, err := os.OpenFile(*debugFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "prose: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		logger := editor.NewDebugLogger(f)
+		logger.Info("prose starting", "version", Version, "files", filenames)
+		app.SetLogger(logger)
+	}
+
 	if err := app.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "prose: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,136 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Abbreviations are expanded as they are typed in Edit mode: typing a space,
+// punctuation or Enter after one replaces it with its expansion. Unlike
+// templates they need no command, so they suit short phrases.
+
+// abbreviationsPath returns the location of the abbreviations file.
+func abbreviationsPath() string {
+	return filepath.Join(configDir(), "abbreviations")
+}
+
+// loadAbbreviations reads the abbreviations file. A missing file yields
+// none. On errors the valid lines are still used and the problems returned.
+func loadAbbreviations() (map[string]string, error) {
+	return loadExpansions(abbreviationsPath(), "abbreviation")
+}
+
+// loadExpansions reads a file of "name = expansion" lines, such as the
+// abbreviations or snippets file, naming the kind of line in errors.
+func loadExpansions(path, kind string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return parseExpansions(f, kind)
+}
+
+// parseAbbreviations reads "abbreviation = expansion" lines.
+func parseAbbreviations(r io.Reader) (map[string]string, error) {
+	return parseExpansions(r, "abbreviation")
+}
+
+// parseExpansions reads "name = expansion" lines. Blank lines and lines
+// starting with '#' are ignored, and \n in an expansion starts a new line.
+func parseExpansions(r io.Reader, kind string) (map[string]string, error) {
+	expansions := make(map[string]string)
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("line %d: expected %s = expansion", n, kind))
+		case key == "" || strings.ContainsFunc(key, unicode.IsSpace):
+			errs = append(errs, fmt.Errorf("line %d: %ss must be one word", n, kind))
+		case value == "":
+			errs = append(errs, fmt.Errorf("line %d: %s: missing expansion", n, key))
+		default:
+			expansions[key] = strings.ReplaceAll(value, `\n`, "\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return expansions, errors.Join(errs...)
+}
+
+// endsAbbreviation reports whether typing ch expands an abbreviation before
+// the cursor: whitespace and punctuation other than hyphens, underscores and
+// apostrophes, which may be part of words.
+func endsAbbreviation(ch rune) bool {
+	switch ch {
+	case '-', '_', '\'', '’':
+		return false
+	}
+	return unicode.IsSpace(ch) || unicode.IsPunct(ch) || unicode.IsSymbol(ch)
+}
+
+// lookupAbbreviation finds the expansion of word. A capitalized word expands
+// a lowercase abbreviation with its first letter capitalized.
+func lookupAbbreviation(abbrevs map[string]string, word string) (string, bool) {
+	if exp, ok := abbrevs[word]; ok {
+		return exp, true
+	}
+	r, size := utf8.DecodeRuneInString(word)
+	if !unicode.IsUpper(r) {
+		return "", false
+	}
+	if exp, ok := abbrevs[string(unicode.ToLower(r))+word[size:]]; ok {
+		return capitalize(exp), true
+	}
+	return "", false
+}
+
+// expandAbbreviation replaces the abbreviation ending at the cursor, if
+// there is one, with its expansion. The word runs back to whitespace, not
+// counting opening brackets and quotes.
+func (a *App) expandAbbreviation() {
+	eb := a.currentBuf()
+	if !a.config.Abbreviations || len(a.abbrevs) == 0 || eb.trackChanges {
+		return
+	}
+	runes := []rune(eb.buf.Lines[eb.cursorLine])
+	end := min(eb.cursorCol, len(runes))
+	start := end
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	for ; start < end; start++ {
+		word := string(runes[start:end])
+		if exp, ok := lookupAbbreviation(a.abbrevs, word); ok {
+			// One undo step takes back just the expansion.
+			lines := strings.Split(string(runes[:start])+exp+string(runes[end:]), "\n")
+			last := lines[len(lines)-1]
+			eb.undo.PushReplaceLines(eb.cursorLine, []string{string(runes)}, lines, eb.cursorLine, eb.cursorCol)
+			replaceLines(eb.buf, eb.cursorLine, 1, lines)
+			eb.cursorLine += len(lines) - 1
+			eb.cursorCol = len([]rune(last)) - (len(runes) - end)
+			return
+		}
+		if !strings.ContainsRune("([{\"'“‘", runes[start]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestParseAbbreviations(t *testing.T) {
+	input := `# Everyday
+btw = by the way
+--sig = Best wishes,\nAda
+
+two words = no
+empty =
+`
+	abbrevs, err := parseAbbreviations(strings.NewReader(input))
+	want := map[string]string{"btw": "by the way", "--sig": "Best wishes,\nAda"}
+	if !reflect.DeepEqual(abbrevs, want) {
+		t.Errorf("parseAbbreviations = %q, want %q", abbrevs, want)
+	}
+	for _, msg := range []string{"line 5: abbreviations must be one word", "line 6: empty: missing expansion"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("error %v should mention %q", err, msg)
+		}
+	}
+}
+
+func TestExpandAbbreviation(t *testing.T) {
+	tests := []struct {
+		name  string
+		typed string
+		want  []string
+	}{
+		{"space", "btw it works", []string{"by the way it works"}},
+		{"punctuation", "(btw, yes)", []string{"(by the way, yes)"}},
+		{"capitalized", "Btw.", []string{"By the way."}},
+		{"inside a word", "btwx ", []string{"btwx "}},
+		{"hyphenated", "btw-ish ", []string{"btw-ish "}},
+		{"multi-line", "--sig\n", []string{"Best wishes,", "Ada", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp("draft.txt")
+			a.config.Abbreviations = true
+			a.abbrevs = map[string]string{"btw": "by the way", "--sig": "Best wishes,\nAda"}
+			sendKeys(a, "i")
+			for _, r := range tt.typed {
+				if r == '\n' {
+					sendKey(a, terminal.KeyEnter)
+				} else {
+					sendKeys(a, string(r))
+				}
+			}
+			if got := a.currentBuf().buf.Lines; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("typed %q, got %q, want %q", tt.typed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbbreviationsOff(t *testing.T) {
+	a := newTestApp("draft.txt")
+	a.config.Abbreviations = true
+	a.abbrevs = map[string]string{"btw": "by the way"}
+	a.executeCommand("set noabbrev")
+	sendKeys(a, "ibtw ")
+	if got := a.currentBuf().buf.Lines[0]; got != "btw " {
+		t.Errorf(":set noabbrev should stop expansion, got %q", got)
+	}
+
+	a.executeCommand("set abbrev")
+	sendKeys(a, "btw \x1buu")
+	if got := a.currentBuf().buf.Lines[0]; got != "btw btw" {
+		t.Errorf("undo should take back the expansion on its own, got %q", got)
+	}
+}
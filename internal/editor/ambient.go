@@ -0,0 +1,132 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// With ambient_status set, the left of the status bar takes turns showing
+// the file name and how the session is going: the words written, the time
+// spent writing and progress towards the word goal. Messages and prompts
+// still take the bar at once.
+
+// writingPause is the longest gap between keys in Edit mode that still
+// counts as writing.
+const writingPause = time.Minute
+
+// noteWriting adds the time since the last key typed in Edit mode to the
+// session's writing time, unless the writer paused.
+func (a *App) noteWriting(now time.Time) {
+	if gap := now.Sub(a.lastTyped); gap < writingPause {
+		a.writing += gap
+	}
+	a.lastTyped = now
+}
+
+// sessionWords returns the words written this session: the change in the
+// open buffers' counts since they were opened, and in buffers since closed.
+func (a *App) sessionWords() int {
+	words := a.closedWords
+	for _, eb := range a.buffers {
+		if !eb.isScratch {
+			words += eb.WordCount() - eb.startWords
+		}
+	}
+	return words
+}
+
+// ambientItems returns the session's ambient status lines.
+func (a *App) ambientItems() []string {
+	words := a.sessionWords()
+	written := plural(words, "word") + " written this session"
+	if words < 0 {
+		written = plural(-words, "word") + " cut this session"
+	}
+	items := []string{written, "Writing for " + formatWritingTime(a.writing)}
+	if goal := a.config.WordGoal; goal > 0 {
+		if words >= goal {
+			items = append(items, fmt.Sprintf("Goal reached: %d of %d words", words, goal))
+		} else {
+			items = append(items, fmt.Sprintf("Goal: %d of %d words (%d%%)", max(words, 0), goal, max(words, 0)*100/goal))
+		}
+	}
+	return items
+}
+
+// formatWritingTime formats d in hours and minutes, e.g. "1h 05m" or "12m".
+func formatWritingTime(d time.Duration) string {
+	m := int(d.Minutes())
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %02dm", m/60, m%60)
+}
+
+// ambientSlot returns which item the status bar shows at now: 0 for the
+// file name, or 1 on for an ambient item. It is always 0 with ambient status
+// off, and in screen-reader mode, where a changing bar would be read out.
+func (a *App) ambientSlot(now time.Time) int {
+	interval := time.Duration(a.config.AmbientStatus) * time.Second
+	if interval <= 0 || a.statusBar.Concise {
+		return 0
+	}
+	slots := 3 // The file name, words written and writing time
+	if a.config.WordGoal > 0 {
+		slots++
+	}
+	return int(now.Sub(a.started)/interval) % slots
+}
+
+// ambientText returns the ambient item to show in place of the file name at
+// now, or "" for the file name.
+func (a *App) ambientText(now time.Time) string {
+	slot := a.ambientSlot(now)
+	a.ambientShown = slot
+	if slot == 0 {
+		return ""
+	}
+	return a.ambientItems()[slot-1]
+}
+
+// ambientDue reports whether the status bar should turn to its next item.
+func (a *App) ambientDue(now time.Time) bool {
+	return a.ambientSlot(now) != a.ambientShown
+}
+
+// nextAmbient returns when the status bar next turns, for the main loop to
+// wake, and false with ambient status off.
+func (a *App) nextAmbient(now time.Time) (time.Time, bool) {
+	interval := time.Duration(a.config.AmbientStatus) * time.Second
+	if interval <= 0 || a.statusBar.Concise {
+		return time.Time{}, false
+	}
+	turns := now.Sub(a.started)/interval + 1
+	return a.started.Add(turns * interval), true
+}
+
+// goalCommand runs :goal [words|off], setting the session's word goal or
+// showing progress towards it.
+func (a *App) goalCommand(arg string) {
+	arg = strings.TrimSpace(arg)
+	switch arg {
+	case "":
+		if a.config.WordGoal == 0 {
+			a.statusBar.SetMessage("No word goal; :goal 500 sets one")
+			return
+		}
+		a.statusBar.SetMessage(a.ambientItems()[2])
+	case "off":
+		a.config.WordGoal = 0
+		a.statusBar.SetMessage("Word goal off")
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			a.statusBar.SetMessage("Usage: :goal [words|off]")
+			return
+		}
+		a.config.WordGoal = n
+		a.statusBar.SetMessage(fmt.Sprintf("Goal: %d words this session", n))
+	}
+}
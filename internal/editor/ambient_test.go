@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNoteWriting(t *testing.T) {
+	a := newTestApp("draft.md")
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	a.noteWriting(t0)
+	a.noteWriting(t0.Add(10 * time.Second))
+	a.noteWriting(t0.Add(40 * time.Second))
+	a.noteWriting(t0.Add(10 * time.Minute)) // After a break
+	a.noteWriting(t0.Add(10*time.Minute + 5*time.Second))
+	if want := 45 * time.Second; a.writing != want {
+		t.Errorf("writing = %v, want %v", a.writing, want)
+	}
+}
+
+func TestSessionWords(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Four words already here."}
+	eb.startWords = eb.WordCount()
+	eb.buf.Lines = append(eb.buf.Lines, "And three more.")
+	a.closedWords = 2
+	if got := a.sessionWords(); got != 5 {
+		t.Errorf("sessionWords = %d, want 5", got)
+	}
+
+	a.config.WordGoal = 10
+	want := []string{"5 words written this session", "Writing for 0m", "Goal: 5 of 10 words (50%)"}
+	if got := a.ambientItems(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ambientItems = %q, want %q", got, want)
+	}
+
+	eb.buf.Lines = []string{"Cut."}
+	a.closedWords = 0
+	if got := a.ambientItems(); got[0] != "3 words cut this session" || got[2] != "Goal: 0 of 10 words (0%)" {
+		t.Errorf("after cutting, ambientItems = %q", got)
+	}
+}
+
+func TestFormatWritingTime(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                               "0m",
+		12*time.Minute + 30*time.Second: "12m",
+		65 * time.Minute:                "1h 05m",
+	}
+	for d, want := range tests {
+		if got := formatWritingTime(d); got != want {
+			t.Errorf("formatWritingTime(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestAmbientRotation(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.started = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return a.started.Add(time.Duration(s) * time.Second) }
+
+	if a.ambientSlot(at(15)) != 0 {
+		t.Error("ambient status should be off by default")
+	}
+	if _, ok := a.nextAmbient(at(15)); ok {
+		t.Error("the loop should not wake with ambient status off")
+	}
+
+	a.config.AmbientStatus = 10
+	for s, want := range map[int]int{5: 0, 15: 1, 25: 2, 35: 0} {
+		if got := a.ambientSlot(at(s)); got != want {
+			t.Errorf("slot at %ds = %d, want %d", s, got, want)
+		}
+	}
+	a.config.WordGoal = 500
+	if got := a.ambientSlot(at(35)); got != 3 {
+		t.Errorf("with a goal, slot at 35s = %d, want 3", got)
+	}
+	if next, ok := a.nextAmbient(at(35)); !ok || !next.Equal(at(40)) {
+		t.Errorf("nextAmbient = %v, %v, want %v", next, ok, at(40))
+	}
+
+	if text := a.ambientText(at(15)); text != "0 words written this session" {
+		t.Errorf("ambientText = %q", text)
+	}
+	if a.ambientDue(at(18)) || !a.ambientDue(at(21)) {
+		t.Error("the bar should be redrawn only when the item changes")
+	}
+
+	a.statusBar.Concise = true
+	if a.ambientSlot(at(15)) != 0 {
+		t.Error("screen-reader mode should keep the file name")
+	}
+}
+
+func TestFormatLeftAmbient(t *testing.T) {
+	sb := NewStatusBar()
+	sb.Ambient = "Writing for 12m"
+	if got := sb.FormatLeft("draft.md", true, "[1/2]", 3, false); got != " Writing for 12m" {
+		t.Errorf("FormatLeft = %q", got)
+	}
+	sb.SetMessage("Saved")
+	if got := sb.FormatLeft("draft.md", true, "", 0, false); got != " Saved" {
+		t.Errorf("a message should show over ambient status, got %q", got)
+	}
+}
+
+func TestGoalCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	tests := []struct {
+		cmd, msg string
+		goal     int
+	}{
+		{"goal", "No word goal; :goal 500 sets one", 0},
+		{"goal 500", "Goal: 500 words this session", 500},
+		{"goal", "Goal: 0 of 500 words (0%)", 500},
+		{"goal lots", "Usage: :goal [words|off]", 500},
+		{"goal off", "Word goal off", 0},
+	}
+	for _, tt := range tests {
+		a.executeCommand(tt.cmd)
+		if a.statusBar.StatusMessage != tt.msg || a.config.WordGoal != tt.goal {
+			t.Errorf(":%s: message %q, goal %d; want %q, %d", tt.cmd, a.statusBar.StatusMessage, a.config.WordGoal, tt.msg, tt.goal)
+		}
+	}
+}
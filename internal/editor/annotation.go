@@ -0,0 +1,236 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// Annotation is a review note kept inline in the text as an HTML comment,
+// <!-- note: ... -->, so it survives any markdown tool and never renders.
+type Annotation struct {
+	Line  int    // Buffer line holding the comment
+	Start int    // Rune offset of "<!--"
+	End   int    // Rune offset just past "-->"
+	Text  string // The note itself
+}
+
+var reAnnotation = regexp.MustCompile(`<!--\s*note:\s*(.*?)\s*-->`)
+
+// noteMarker is shown in place of an annotation on lines without the cursor.
+const noteMarker = "\x1b[33m✎\x1b[39m"
+
+// formatAnnotation returns the comment that stores a note.
+func formatAnnotation(text string) string {
+	return "<!-- note: " + text + " -->"
+}
+
+// ExtractAnnotations returns every annotation in lines, in document order.
+// Comments inside fenced code blocks are not annotations.
+func ExtractAnnotations(lines []string) []Annotation {
+	var anns []Annotation
+	var blocks []BlockState
+	for i, line := range lines {
+		if !strings.Contains(line, "<!--") {
+			continue
+		}
+		if blocks == nil {
+			blocks = ComputeBlockStates(lines)
+		}
+		if blocks[i].InCode {
+			continue
+		}
+		for _, m := range reAnnotation.FindAllStringSubmatchIndex(line, -1) {
+			anns = append(anns, Annotation{
+				Line:  i,
+				Start: len([]rune(line[:m[0]])),
+				End:   len([]rune(line[:m[1]])),
+				Text:  line[m[2]:m[3]],
+			})
+		}
+	}
+	return anns
+}
+
+// concealAnnotations collapses each annotation to noteMarker in the display
+// lines, except on the reveal line so the note can be read and edited where
+// the cursor is.
+func concealAnnotations(dls []DisplayLine, anns []Annotation, reveal int) {
+	ai := 0
+	for i := range dls {
+		dl := &dls[i]
+		if dl.Folded > 0 || dl.BufferLine == reveal {
+			continue
+		}
+		for ai < len(anns) && anns[ai].Line < dl.BufferLine {
+			ai++
+		}
+		for _, ann := range anns[ai:] {
+			if ann.Line != dl.BufferLine {
+				break
+			}
+			start, end, ok := dl.span(ann.Start, ann.End)
+			if !ok {
+				continue
+			}
+			span := ConcealSpan{Start: start, End: end}
+			// A note wrapped over two display lines shows one marker.
+			if ann.Start >= dl.Offset {
+				span.Replacement = noteMarker
+			}
+			dl.Conceal = append(dl.Conceal, span)
+		}
+	}
+}
+
+// noteInsertCol returns where a new note goes: just after the word under
+// col, so the marker sits against the text it comments on.
+func noteInsertCol(line string, col int) int {
+	runes := []rune(line)
+	if col >= len(runes) {
+		return len(runes)
+	}
+	for col < len(runes) && !unicode.IsSpace(runes[col]) {
+		col++
+	}
+	return col
+}
+
+// addAnnotation inserts a note after the word under the cursor.
+func (a *App) addAnnotation(text string) {
+	if a.guardReadOnly() {
+		return
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	eb := a.currentBuf()
+	runes := []rune(eb.buf.Lines[eb.cursorLine])
+	col := noteInsertCol(string(runes), eb.cursorCol)
+	line := string(runes[:col]) + formatAnnotation(text) + string(runes[col:])
+	a.replaceLines(eb.cursorLine, 1, []string{line})
+	a.statusBar.SetMessage("Note added")
+}
+
+// replaceAnnotation swaps an annotation's comment for replacement, which is
+// empty to delete it.
+func (a *App) replaceAnnotation(ann Annotation, replacement string) {
+	eb := a.currentBuf()
+	if ann.Line >= eb.buf.LineCount() {
+		return
+	}
+	runes := []rune(eb.buf.Lines[ann.Line])
+	if ann.End > len(runes) {
+		return
+	}
+	line := string(runes[:ann.Start]) + replacement + string(runes[ann.End:])
+	a.replaceLines(ann.Line, 1, []string{line})
+	if eb.cursorLine == ann.Line && eb.cursorCol > len([]rune(line)) {
+		eb.cursorCol = len([]rune(line))
+	}
+}
+
+// startNotePrompt opens the note prompt. With a target, the prompt edits that
+// note; otherwise a new note is added at the cursor.
+func (a *App) startNotePrompt(target *Annotation) {
+	a.noteTarget = target
+	a.statusBar.StartPrompt(PromptNote)
+	if target != nil {
+		a.statusBar.PromptText = target.Text
+	}
+}
+
+// finishNotePrompt applies the text entered at the note prompt.
+func (a *App) finishNotePrompt(text string) {
+	target := a.noteTarget
+	a.noteTarget = nil
+	if target == nil {
+		a.addAnnotation(text)
+		return
+	}
+	if a.guardReadOnly() {
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		a.replaceAnnotation(*target, "")
+		a.statusBar.SetMessage("Note deleted")
+		return
+	}
+	a.replaceAnnotation(*target, formatAnnotation(strings.TrimSpace(text)))
+	a.statusBar.SetMessage("Note updated")
+}
+
+// NoteList manages the annotations overlay.
+type NoteList struct {
+	ListView[Annotation]
+}
+
+// noteLabel formats an annotation for the overlay, e.g. "12: check date".
+func noteLabel(ann Annotation) string {
+	return fmt.Sprintf("%d: %s", ann.Line+1, ann.Text)
+}
+
+// showNotes opens the annotations overlay for the current buffer.
+func (a *App) showNotes() {
+	anns := ExtractAnnotations(a.currentBuf().buf.Lines)
+	if len(anns) == 0 {
+		a.statusBar.SetMessage("No notes. Add one with :note text")
+		return
+	}
+	a.notes.Show(anns)
+}
+
+// refreshNotes re-reads the annotations after one was changed, keeping the
+// selection in place and closing the overlay once none are left.
+func (a *App) refreshNotes() {
+	selected := a.notes.Selected
+	anns := ExtractAnnotations(a.currentBuf().buf.Lines)
+	if len(anns) == 0 {
+		a.notes.Hide()
+		return
+	}
+	a.notes.Items = anns
+	a.notes.Selected = min(selected, len(anns)-1)
+}
+
+func (a *App) handleNotesKey(key terminal.Key) {
+	if a.notes.Selected >= len(a.notes.Items) {
+		a.notes.Hide()
+		return
+	}
+	ann := a.notes.Items[a.notes.Selected]
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.notes.Hide()
+	case terminal.KeyUp:
+		a.notes.MoveUp()
+	case terminal.KeyDown:
+		a.notes.MoveDown()
+	case terminal.KeyEnter:
+		eb := a.currentBuf()
+		eb.cursorLine = ann.Line
+		eb.cursorCol = ann.Start
+		a.notes.Hide()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.notes.MoveUp()
+		case 'j':
+			a.notes.MoveDown()
+		case 'e':
+			a.notes.Hide()
+			a.startNotePrompt(&ann)
+		case 'd':
+			if a.guardReadOnly() {
+				return
+			}
+			a.replaceAnnotation(ann, "")
+			a.statusBar.SetMessage("Note deleted")
+			a.refreshNotes()
+		}
+	}
+}
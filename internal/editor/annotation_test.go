@@ -0,0 +1,199 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestExtractAnnotations(t *testing.T) {
+	lines := []string{
+		"Café<!-- note: check spelling --> and more <!--note:two-->",
+		"```",
+		"<!-- note: not a note -->",
+		"```",
+		"<!-- just a comment -->",
+	}
+	got := ExtractAnnotations(lines)
+	want := []Annotation{
+		{Line: 0, Start: 4, End: 33, Text: "check spelling"},
+		{Line: 0, Start: 43, End: 58, Text: "two"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ExtractAnnotations = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("annotation %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestConcealAnnotations(t *testing.T) {
+	buf := &Buffer{Lines: []string{
+		"short<!-- note: x -->",
+		"one two three four<!-- note: a long note that wraps --> end",
+	}}
+	anns := ExtractAnnotations(buf.Lines)
+
+	dls := WrapBuffer(buf, 30)
+	concealAnnotations(dls, anns, 0)
+	if len(dls[0].Conceal) != 0 {
+		t.Errorf("reveal line should not be concealed: %+v", dls[0].Conceal)
+	}
+	// Line 1 wraps, splitting the note; only the first part gets a marker.
+	var markers, spans int
+	for _, dl := range dls[1:] {
+		for _, span := range dl.Conceal {
+			spans++
+			if span.Replacement != "" {
+				markers++
+			}
+		}
+	}
+	if spans < 2 || markers != 1 {
+		t.Errorf("got %d spans with %d markers, want a split note with one marker", spans, markers)
+	}
+
+	dls = WrapBuffer(buf, 30)
+	concealAnnotations(dls, anns, -1)
+	want := []ConcealSpan{{Start: 5, End: 21, Replacement: noteMarker}}
+	if len(dls[0].Conceal) != 1 || dls[0].Conceal[0] != want[0] {
+		t.Errorf("Conceal = %+v, want %+v", dls[0].Conceal, want)
+	}
+}
+
+func TestApplyConceal(t *testing.T) {
+	spans := []ConcealSpan{{Start: 2, End: 5, Replacement: "*"}}
+	if got := applyConceal("abXYZcd", spans); got != "ab*cd" {
+		t.Errorf("applyConceal plain = %q", got)
+	}
+	// Escape codes inside the span are kept.
+	styled := "ab\x1b[1mXYZ\x1b[0mcd"
+	if got := applyConceal(styled, spans); got != "ab\x1b[1m*\x1b[0mcd" {
+		t.Errorf("applyConceal styled = %q", got)
+	}
+}
+
+func TestDisplayLineTextCol(t *testing.T) {
+	dl := DisplayLine{
+		Text:    "ab<!-- note: x -->cd",
+		Conceal: []ConcealSpan{{Start: 2, End: 18, Replacement: noteMarker}},
+	}
+	tests := []struct{ col, want int }{
+		{0, 0},
+		{1, 1},
+		{2, 2},  // On the marker
+		{3, 18}, // 'c'
+		{4, 19},
+	}
+	for _, tt := range tests {
+		if got := dl.TextCol(tt.col); got != tt.want {
+			t.Errorf("TextCol(%d) = %d, want %d", tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestNoteInsertCol(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want int
+	}{
+		{"hello world", 1, 5},
+		{"hello world", 5, 5},
+		{"hello world", 8, 11},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := noteInsertCol(tt.line, tt.col); got != tt.want {
+			t.Errorf("noteInsertCol(%q, %d) = %d, want %d", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestNoteCommandAddsAnnotation(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The rain fell."}
+	eb.cursorCol = 5
+
+	a.executeCommand("note too plain?")
+	want := "The rain<!-- note: too plain? --> fell."
+	if eb.buf.Lines[0] != want {
+		t.Fatalf("after :note: %q, want %q", eb.buf.Lines[0], want)
+	}
+
+	a.undoAction()
+	if eb.buf.Lines[0] != "The rain fell." {
+		t.Errorf("after undo: %q", eb.buf.Lines[0])
+	}
+}
+
+func TestNotePromptAddsAnnotation(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Hello"}
+
+	a.executeCommand("note")
+	if a.statusBar.Prompt != PromptNote {
+		t.Fatal("expected the note prompt")
+	}
+	for _, r := range "hi" {
+		a.handlePromptKey(terminal.Key{Type: terminal.KeyRune, Rune: r})
+	}
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	if eb.buf.Lines[0] != "Hello<!-- note: hi -->" {
+		t.Errorf("after prompt: %q", eb.buf.Lines[0])
+	}
+}
+
+func TestNotesOverlayEditAndDelete(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{
+		"One<!-- note: first -->",
+		"Two<!-- note: second -->",
+	}
+
+	a.executeCommand("notes")
+	if !a.notes.Active || len(a.notes.Items) != 2 {
+		t.Fatalf("expected two notes, got %+v", a.notes.Items)
+	}
+
+	// Edit the second note through the prompt.
+	a.handleNotesKey(terminal.Key{Type: terminal.KeyRune, Rune: 'j'})
+	a.handleNotesKey(terminal.Key{Type: terminal.KeyRune, Rune: 'e'})
+	if a.statusBar.Prompt != PromptNote || a.statusBar.PromptText != "second" {
+		t.Fatalf("expected prompt prefilled with the note, got %q", a.statusBar.PromptText)
+	}
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyRune, Rune: '!'})
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	if eb.buf.Lines[1] != "Two<!-- note: second! -->" {
+		t.Errorf("after edit: %q", eb.buf.Lines[1])
+	}
+
+	// Delete the first note.
+	a.executeCommand("notes")
+	a.handleNotesKey(terminal.Key{Type: terminal.KeyRune, Rune: 'd'})
+	if eb.buf.Lines[0] != "One" {
+		t.Errorf("after delete: %q", eb.buf.Lines[0])
+	}
+	if len(a.notes.Items) != 1 {
+		t.Errorf("overlay should refresh to one note, got %d", len(a.notes.Items))
+	}
+
+	// Enter jumps to the note.
+	a.handleNotesKey(terminal.Key{Type: terminal.KeyEnter})
+	if eb.cursorLine != 1 || eb.cursorCol != 3 {
+		t.Errorf("cursor = %d:%d, want 1:3", eb.cursorLine, eb.cursorCol)
+	}
+}
+
+func TestNotesCommandWithoutNotes(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.executeCommand("notes")
+	if a.notes.Active {
+		t.Error("overlay should not open without notes")
+	}
+}
@@ -1,10 +1,16 @@
 package editor
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/JackWReid/prose/internal/spell"
 	"github.com/JackWReid/prose/internal/terminal"
@@ -17,34 +23,124 @@ const (
 	ModeDefault Mode = iota
 	ModeEdit
 	ModeLineSelect
+	ModeVisual
+	ModeReplace
 )
 
+// String returns the mode name shown in the status bar.
+func (m Mode) String() string {
+	switch m {
+	case ModeDefault:
+		return "DEFAULT"
+	case ModeEdit:
+		return "EDIT"
+	case ModeLineSelect:
+		return "LINE-SELECT"
+	case ModeVisual:
+		return "VISUAL"
+	case ModeReplace:
+		return "REPLACE"
+	}
+	return ""
+}
+
 // App is the top-level editor state.
 type App struct {
 	buffers       []*EditorBuffer
 	currentBuffer int
+	split         Split // Two-window split, if any
 
 	viewport          *Viewport
+	termWidth         int // Terminal size; the viewport may cover only part of it
+	termHeight        int
 	renderer          *Renderer
 	statusBar         *StatusBar
 	terminal          *terminal.Terminal
 	picker            *Picker
 	outline           *Outline
+	nameCheck         *NameCheck
+	dupes             *DuplicateList
+	completion        *Completion
+	undoTree          *UndoTreeView
+	notes             *NoteList
+	journal           *JournalList
+	stats             *StatsView
+	grepList          *GrepList
+	quitSummary       *QuitSummary
+	recent            *RecentList
+	palette           *CommandPalette
+	merge             *MergeView
+	gitDiff           *GitDiffView
+	help              *HelpView
+	messages          *MessageLog
+	noteTarget        *Annotation   // Note being edited at the note prompt
+	templateFill      *templateFill // Template waiting on variable prompts
+	newTemplate       string        // Template for the first file if new, from --template
+	today             bool          // Open today's journal note on starting, from --today
+	tutor             bool          // Open the tutorial on starting, from --tutor
+	stdin             *EditorBuffer // The buffer for a file named -, read from standard input
+	stdout            io.Writer     // Where the stdin buffer's text goes on quitting, if not the terminal
+	stdoutLines       []string      // The text to write there, as last kept by :w
+	replaceRun        *replaceRun   // Text overwritten in Replace mode since the cursor moved
 	browser           *Browser
 	columnAdjust      *ColumnAdjust
+	keymap            *Keymap // Default mode and leader key bindings
+	leaderMenu        *LeaderMenu
 	spellChecker      *spell.SpellChecker
 	spellCheckEnabled bool // Global toggle for spell checking (default: false).
 	mode              Mode
 
-	leaderPending    bool   // Space was pressed, awaiting second key.
-	dPending         bool   // 'd' was pressed, awaiting second 'd' for dd.
-	gPending         bool   // 'g' was pressed, awaiting second 'g' for gg.
-	yPending         bool   // 'y' was pressed, awaiting second 'y' for yy.
-	sPending         bool   // 's' was pressed, awaiting second 's' for ss.
-	lineSelectAnchor int    // Line where Shift-V was pressed (for line-select mode).
-	yankBuffer       string // Shared yank buffer for yy/dd/p/P operations.
+	leaderPending    bool      // Space was pressed, awaiting second key.
+	leaderAt         time.Time // When the leader key was pressed, to time the leader menu.
+	dPending         bool      // 'd' was pressed, awaiting second 'd' for dd.
+	cPending         bool      // 'c' was pressed, awaiting a target (cc, cw).
+	rPending         bool      // 'r' was pressed, awaiting the replacement character.
+	gPending         bool      // 'g' was pressed, awaiting second 'g' for gg.
+	yPending         bool      // 'y' was pressed, awaiting second 'y' for yy.
+	sPending         bool      // 's' was pressed, awaiting second 's' for ss.
+	zPending         bool      // 'z' was pressed, awaiting a fold command.
+	bracketPending   rune      // ']' or '[' was pressed, awaiting a motion.
+	markPending      rune      // 'm' or '`' was pressed, awaiting a mark name.
+	flashLine        int       // Heading highlighted after ]] or [[.
+	flashUntil       time.Time // When the heading flash ends; zero when none.
+	lineSelectAnchor int       // Line where Shift-V was pressed (for line-select mode).
+	visualAnchorLine int       // Line where v was pressed (for visual mode).
+	visualAnchorCol  int       // Column where v was pressed.
+	yankBuffer       string    // Shared yank buffer for yy/dd/p/P operations.
+	yankCharwise     bool      // yankBuffer holds a visual-mode selection, pasted inline.
 	quit             bool
-	quitAfterSave    bool // Set by :wq on unnamed buffers.
+	quitAfterSave    bool            // Set by :wq on unnamed buffers.
+	saveAsQueue      []*EditorBuffer // Unnamed buffers :wqa is asking names for, in turn
+
+	config   Config            // User settings from the config file
+	abbrevs  map[string]string // From the abbreviations file, expanded in Edit mode
+	snippets map[string]string // Defaults and the snippets file, expanded by Tab
+	snippet  *snippetStops     // Stops left in the snippet last expanded
+	project  *Project          // From the nearest .prose-project file; nil if none
+	logger   *slog.Logger      // Debug log (--debug); nil when disabled.
+
+	wordCache map[string]fileWordCount // Word counts of project files on disk, by path
+
+	jumps        []jump // Positions left by jumps, oldest first, for Ctrl-O/Ctrl-I
+	jumpIdx      int    // Position in jumps while walking it; len(jumps) otherwise
+	walkingJumps bool   // Set by Ctrl-O/Ctrl-I so their buffer switches aren't recorded
+
+	dirty     dirty        // What has changed since the last frame
+	display   displayCache // The last frame's display lines
+	lastFrame time.Time
+
+	started      time.Time     // When the session began
+	writing      time.Duration // Time spent typing in Edit mode this session
+	lastTyped    time.Time     // When a key was last typed in Edit mode
+	closedWords  int           // Words written this session in buffers since closed
+	ambientShown int           // Ambient status item last drawn; 0 for the file name
+
+	pdfExport *pdfExport // The PDF export running; nil if none
+
+	spellLoading <-chan spellLoad // Receives the spell checker as it loads; nil once loaded
+
+	diskPrompt    *EditorBuffer // Buffer whose file changed on disk, while asking what to do
+	lastDiskCheck time.Time     // When the current buffer's file was last checked
 }
 
 // currentBuf returns the active EditorBuffer.
@@ -58,44 +154,138 @@ func NewApp(filenames []string) *App {
 		statusBar:         NewStatusBar(),
 		picker:            &Picker{},
 		outline:           &Outline{},
+		nameCheck:         &NameCheck{},
+		dupes:             &DuplicateList{},
+		completion:        &Completion{},
+		undoTree:          &UndoTreeView{},
+		notes:             &NoteList{},
+		journal:           &JournalList{},
+		stats:             &StatsView{},
+		grepList:          &GrepList{},
+		quitSummary:       &QuitSummary{},
+		merge:             &MergeView{},
+		gitDiff:           &GitDiffView{},
+		help:              &HelpView{},
+		messages:          &MessageLog{},
+		recent:            &RecentList{},
+		palette:           &CommandPalette{},
 		browser:           &Browser{},
 		columnAdjust:      &ColumnAdjust{},
+		keymap:            DefaultKeymap(),
+		leaderMenu:        &LeaderMenu{},
 		mode:              ModeDefault,
 		spellCheckEnabled: false, // Spellcheck is off by default.
+		config:            DefaultConfig(),
+		started:           time.Now(),
 	}
 	if len(filenames) == 0 {
 		app.buffers = []*EditorBuffer{NewEditorBuffer("")}
 	} else {
 		for _, f := range filenames {
-			app.buffers = append(app.buffers, NewEditorBuffer(f))
+			if f != stdinName {
+				app.buffers = append(app.buffers, NewEditorBuffer(f))
+				continue
+			}
+			if app.stdin != nil {
+				continue
+			}
+			app.stdin = NewEditorBuffer("")
+			app.buffers = append(app.buffers, app.stdin)
 		}
 	}
 	return app
 }
 
-func (a *App) Run() error {
+func (a *App) Run() (err error) {
+	// On panic, put the terminal back and save unsaved work before exiting.
+	defer func() {
+		if r := recover(); r != nil {
+			err = a.handlePanic(r)
+		}
+	}()
+
+	defer a.releaseAllLocks()
+	defer a.cancelExport()
+
+	// Read user settings; problems are reported but don't stop startup.
+	cfg, cfgErr := LoadConfig()
+	if cfgErr != nil {
+		a.errorLog("config", cfgErr)
+		a.statusBar.SetError("Config: " + strings.ReplaceAll(cfgErr.Error(), "\n", "; "))
+	}
+	a.config = cfg
+	if cfg.Keys != nil {
+		a.keymap = cfg.Keys
+	}
+	theme := cfg.theme().forDepth(cfg.colorDepth())
+	a.renderer.theme = &theme
+	a.statusBar.Theme = &theme
+	a.renderer.cursorLine = cfg.CursorLine
+	a.renderer.lineNumbers = cfg.Numbers
+	if cfg.ScreenReader {
+		a.SetScreenReader(true)
+	}
+	if cfg.LowBandwidth {
+		a.SetLowBandwidth(true)
+	}
+	if a.abbrevs, err = loadAbbreviations(); err != nil {
+		a.errorLog("abbreviations", err)
+		a.statusBar.SetError("Abbreviations: " + strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+	if a.snippets, err = loadSnippets(); err != nil {
+		a.errorLog("snippets", err)
+		a.statusBar.SetError("Snippets: " + strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
 	// Load all buffers.
 	for _, eb := range a.buffers {
-		if err := eb.buf.Load(); err != nil {
+		if err = eb.buf.Load(); err != nil {
+			a.errorLog("load failed", err, "file", eb.buf.Filename)
+			return err
+		}
+		eb.loadUndoHistory()
+		eb.startWords = eb.WordCount()
+		a.debugLog("loaded buffer", "file", eb.buf.Filename, "lines", eb.buf.LineCount())
+		a.lockBuffer(eb)
+		a.recordRecent(eb)
+	}
+	if a.stdin != nil {
+		if err = a.openStdio(os.Stdin, os.Stdout); err != nil {
 			return err
 		}
 	}
+	first := a.buffers[0]
+	a.applySession(loadSession())
+	h := loadHistory()
+	a.statusBar.CommandHistory, a.statusBar.SearchHistory = h.Commands, h.Searches
 
-	// Initialize spell checker.
-	spellChecker, err := spell.NewSpellChecker()
-	if err != nil {
-		return fmt.Errorf("failed to initialize spell checker: %v", err)
+	// Load the spell checker in the background; the buffers are checked
+	// when it is ready.
+	a.startSpellLoad()
+
+	// Find the project file, which may add words to the spell checker.
+	if err := a.loadProject(); err != nil {
+		a.errorLog("project", err)
+		a.statusBar.SetError("Project: " + strings.ReplaceAll(err.Error(), "\n", "; "))
 	}
-	a.spellChecker = spellChecker
 
-	// Run initial spell check on all buffers that should be checked (if enabled).
-	for _, eb := range a.buffers {
-		if a.spellCheckEnabled && eb.ShouldSpellCheck() {
-			eb.spellErrors = nil
-			for i := 0; i < len(eb.buf.Lines); i++ {
-				lineErrors := spellChecker.CheckLine(i, eb.buf.Lines[i])
-				eb.spellErrors = append(eb.spellErrors, lineErrors...)
-			}
+	if a.newTemplate != "" {
+		a.currentBuffer = a.bufferIndex(first)
+		a.applyNewFileTemplate(a.newTemplate)
+	}
+	if a.today && a.openToday() {
+		// On its own, --today opens just the note.
+		if eb := a.buffers[0]; len(a.buffers) == 2 && eb.buf.Filename == "" && !eb.buf.Dirty && eb != a.stdin {
+			a.buffers = a.buffers[1:]
+			a.currentBuffer = 0
+		}
+	}
+	if a.tutor {
+		a.openTutor()
+		// As with --today, the tutorial replaces the empty buffer.
+		if eb := a.buffers[0]; len(a.buffers) == 2 && eb.buf.Filename == "" && !eb.buf.Dirty && eb != a.stdin {
+			a.buffers = a.buffers[1:]
+			a.currentBuffer = 0
 		}
 	}
 
@@ -106,68 +296,168 @@ func (a *App) Run() error {
 	}
 	a.terminal = t
 	defer t.Restore()
+	if style := cursorStyles[a.config.CursorStyle]; style != terminal.CursorDefault {
+		t.SetCursorStyle(style)
+	}
 
 	a.viewport = NewViewport(t.Width(), t.Height())
+	a.config.ApplyTo(a.viewport)
+	a.termWidth, a.termHeight = t.Width(), t.Height()
+	a.debugLog("terminal ready", "width", t.Width(), "height", t.Height())
 
 	// Initial render.
-	a.render()
+	a.dirty = dirtyText
+	a.draw(time.Now())
 
-	// Main event loop.
+	// Main event loop. It wakes for input, and for work that is due when
+	// there is none: a frame held back or a spell check.
 	for !a.quit {
-		// Perform debounced spell checking (if enabled).
-		if a.spellCheckEnabled {
-			a.currentBuf().PerformSpellCheck(a.spellChecker)
-		}
-
-		event, err := t.ReadEvent()
+		event, ok, err := t.ReadEventTimeout(a.wait(time.Now()))
 		if err != nil {
+			a.errorLog("read input failed", err)
 			return err
 		}
+		if ok {
+			a.logInput(event)
+
+			switch {
+			case event.Type == terminal.EventTerminate:
+				return a.handleTerminate(event.Signal)
+			case event.Type == terminal.EventSuspend:
+				a.suspend()
+			case event.Type == terminal.EventResize:
+				t.Resize()
+				a.debugLog("resize", "width", t.Width(), "height", t.Height())
+				a.relayout(t.Width(), t.Height())
+				a.dirty |= dirtyText
+			case a.tooSmall():
+				a.handleTooSmallInput(event)
+				a.dirty |= dirtyText
+			default:
+				a.handleInput(event)
+				a.drainInput(t.PendingEvent)
+			}
+		}
 
-		if event.Type == terminal.EventResize {
-			t.Resize()
-			a.viewport.Resize(t.Width(), t.Height())
-			a.render()
-			continue
+		// Perform debounced spell checking (if enabled).
+		if a.pollSpellLoad() {
+			a.dirty |= dirtyText
+		}
+		if eb := a.currentBuf(); a.spellCheckEnabled && a.spellChecker != nil && eb.spellCheckDue() {
+			a.loadBibliography(eb)
+			if eb.PerformSpellCheck(a.spellChecker) {
+				a.dirty |= dirtyText
+			}
 		}
 
-		a.handleInput(event)
+		// List the leader combinations if the leader key is still waiting.
+		if a.leaderMenuDue(time.Now()) {
+			a.leaderMenu.Show(a.keymap.Leader)
+			a.dirty |= dirtyText
+		}
+		if a.ambientDue(time.Now()) {
+			a.dirty |= dirtyCursor
+		}
+		if a.pollExport(time.Now()) {
+			a.dirty |= dirtyCursor
+		}
+		if a.checkDisk(time.Now()) {
+			a.dirty |= dirtyCursor
+		}
+		if a.flashDone(time.Now()) {
+			a.dirty |= dirtyText
+		}
 		if !a.quit {
-			a.render()
+			a.draw(time.Now())
 		}
 	}
 
-	return nil
+	a.saveSessionState()
+	return a.writeStdout()
+}
+
+// drainInput handles the input events that arrived while the last one was
+// being handled (the rest of a paste, key repeats, a mouse drag) so that a
+// burst of input is drawn in one frame rather than one per event.
+func (a *App) drainInput(pending func() (terminal.InputEvent, bool)) {
+	for !a.quit {
+		event, ok := pending()
+		if !ok {
+			return
+		}
+		a.logInput(event)
+		a.handleInput(event)
+	}
 }
 
 func (a *App) handleInput(event terminal.InputEvent) {
-	// Clear any temporary status message on input.
-	a.statusBar.ClearMessage()
+	// Clear any temporary status message on input. An error stays until
+	// Esc dismisses it.
+	if event.Type == terminal.EventKey && event.Key.Type == terminal.KeyEscape {
+		a.statusBar.DismissMessage()
+	} else {
+		a.statusBar.ClearMessage()
+	}
+
+	// Keep folds, marks, jumps and any hoist attached to their lines when
+	// lines are added or removed, and the cursor inside a hoist.
+	eb := a.currentBuf()
+	lineCount, cursorLine, cursorText := eb.buf.LineCount(), eb.cursorLine, ""
+	if cursorLine < lineCount {
+		cursorText = eb.buf.Lines[cursorLine]
+	}
+	defer func() {
+		if delta := eb.buf.LineCount() - lineCount; delta != 0 {
+			line := changedLine(eb.buf.Lines, cursorLine, eb.cursorLine, delta, cursorText)
+			eb.shiftFolds(line, delta)
+			eb.shiftMarks(line, delta)
+			eb.shiftHoist(line, delta)
+			a.shiftJumps(eb, line, delta)
+		}
+		a.currentBuf().keepInHoist()
+	}()
+
+	// Switching buffers is a jump, whatever did it.
+	from := jump{buffer: eb, line: eb.cursorLine, col: eb.cursorCol}
+	defer func() {
+		if a.currentBuf() != from.buffer && !a.walkingJumps && a.bufferIndex(from.buffer) >= 0 {
+			a.recordJump(from)
+		}
+		a.walkingJumps = false
+	}()
+
+	a.markDirty(event)
+	if event.Type == terminal.EventKey && a.mode == ModeEdit {
+		a.noteWriting(time.Now())
+	}
 
 	// Handle mouse events.
 	if event.Type == terminal.EventMouse {
 		a.handleMouse(event.Mouse)
 		return
 	}
+	a.handleKey(event.Key)
+}
 
-	// Handle keyboard events.
-	key := event.Key
-
-	// If column adjuster is active, handle it first.
-	if a.columnAdjust.Active {
-		a.handleColumnAdjustKey(key)
+// handleKey passes a key to the active overlay or prompt, or else to the
+// current mode. Mouse clicks on overlays and the mode indicator act through
+// it too.
+func (a *App) handleKey(key terminal.Key) {
+	if o := a.activeOverlay(); o != nil {
+		o.key(key)
 		return
 	}
 
-	// If outline is active, handle it first.
-	if a.outline.Active {
-		a.handleOutlineKey(key)
+	// If the file changed on disk, ask what to do first.
+	if a.diskPrompt != nil {
+		a.handleDiskPromptKey(key)
 		return
 	}
 
-	// If picker is active, handle it first.
-	if a.picker.Active {
-		a.handlePickerKey(key)
+	// If a prompt is active, handle it first. The browser prompts for
+	// file names while it stays open.
+	if a.statusBar.Prompt != PromptNone {
+		a.handlePromptKey(key)
 		return
 	}
 
@@ -177,9 +467,7 @@ func (a *App) handleInput(event terminal.InputEvent) {
 		return
 	}
 
-	// If a prompt is active, handle it first.
-	if a.statusBar.Prompt != PromptNone {
-		a.handlePromptKey(key)
+	if a.handleModifiedKey(key) {
 		return
 	}
 
@@ -190,12 +478,43 @@ func (a *App) handleInput(event terminal.InputEvent) {
 		a.handleEditKey(key)
 	case ModeLineSelect:
 		a.handleLineSelectKey(key)
+	case ModeVisual:
+		a.handleVisualKey(key)
+	case ModeReplace:
+		a.handleReplaceKey(key)
+	}
+}
+
+// handleModifiedKey handles keys that only terminals reporting modifiers
+// can send: Ctrl-S saves, and Shift with an arrow, Home or End selects
+// text in Visual mode. Other modified keys act as if unmodified.
+func (a *App) handleModifiedKey(key terminal.Key) bool {
+	switch {
+	case key.Type == terminal.KeyCombo && key.Mod == terminal.ModCtrl && key.Rune == 's':
+		a.executeCommand("w")
+		return true
+	case key.Mod != terminal.ModShift || a.mode == ModeLineSelect:
+		return false
+	}
+	switch key.Type {
+	case terminal.KeyUp, terminal.KeyDown, terminal.KeyLeft, terminal.KeyRight, terminal.KeyHome, terminal.KeyEnd:
+	default:
+		return false
+	}
+	if a.mode == ModeEdit || a.mode == ModeReplace {
+		// Leave as Esc would, closing the undo step.
+		a.handleKey(terminal.Key{Type: terminal.KeyEscape})
 	}
+	if a.mode != ModeVisual {
+		a.startVisual()
+	}
+	a.handleVisualKey(terminal.Key{Type: key.Type})
+	return true
 }
 
 func (a *App) handleMouse(mouse terminal.MouseEvent) {
-	// Ignore mouse events when overlay or prompt is active.
-	if a.columnAdjust.Active || a.outline.Active || a.picker.Active || a.browser.Active || a.statusBar.Prompt != PromptNone {
+	// Ignore mouse events while a prompt is active.
+	if a.statusBar.Prompt != PromptNone {
 		return
 	}
 
@@ -203,9 +522,28 @@ func (a *App) handleMouse(mouse terminal.MouseEvent) {
 	if mouse.Button != terminal.MouseLeft || !mouse.Press {
 		return
 	}
+	a.dirty |= dirtyText
+
+	if a.overlayActive() {
+		a.clickOverlay(mouse.Row, mouse.Col)
+		return
+	}
+	if a.clickModeIndicator(mouse.Row, mouse.Col) {
+		return
+	}
+
+	// Clicking the other window of a split focuses it, and clicking the
+	// preview moves to the line clicked.
+	if a.inOtherWindow(mouse.Row, mouse.Col) {
+		if a.split.Preview {
+			a.clickPreview(mouse.Row)
+			return
+		}
+		a.switchWindow()
+	}
 
 	// Convert mouse coordinates to buffer position.
-	line, col := a.mouseToBufferPos(mouse.Row, mouse.Col)
+	line, col := a.mouseToBufferPos(mouse.Row-a.viewport.Top, mouse.Col-a.viewport.Left)
 	if line >= 0 && col >= 0 {
 		eb := a.currentBuf()
 		eb.cursorLine = line
@@ -225,26 +563,11 @@ func (a *App) handleDefaultKey(key terminal.Key) {
 		return
 	}
 
-	// Leader key sequence: Space followed by a second key.
+	// Leader key sequence: the leader followed by a second key.
 	if a.leaderPending {
 		a.leaderPending = false
-		if key.Type == terminal.KeyRune {
-			switch key.Rune {
-			case 'b', 't':
-				a.picker.Show(a.currentBuffer)
-				return
-			case 'h', 'H':
-				a.showOutline()
-				return
-			case 'o', 'O':
-				a.showBrowser()
-				return
-			case '-':
-				a.showColumnAdjust()
-				return
-			}
-		}
-		// Unknown leader combo — ignore.
+		a.leaderMenu.Hide()
+		a.runBinding(a.keymap.Leader, key)
 		return
 	}
 
@@ -252,21 +575,107 @@ func (a *App) handleDefaultKey(key terminal.Key) {
 	if a.dPending {
 		a.dPending = false
 		if key.Type == terminal.KeyRune && key.Rune == 'd' {
-			a.deleteWholeLine()
+			if a.currentBuf().trackChanges {
+				a.trackedDeleteLine()
+			} else {
+				a.deleteWholeLine()
+			}
 			return
 		}
 		// Not 'dd' — consume the key and cancel.
 		return
 	}
 
-	// gg operator: 'g' followed by 'g'.
+	// Change operator: 'c' followed by 'c' (line) or 'w' (word).
+	if a.cPending {
+		a.cPending = false
+		if key.Type == terminal.KeyRune {
+			switch key.Rune {
+			case 'c':
+				a.changeLine()
+			case 'w':
+				a.changeWord()
+			}
+		}
+		return
+	}
+
+	// Replace character: 'r' followed by the new character.
+	if a.rPending {
+		a.rPending = false
+		if key.Type == terminal.KeyRune {
+			a.replaceChar(key.Rune)
+		}
+		return
+	}
+
+	// gg operator: 'g' followed by 'g'; gx follows a link.
 	if a.gPending {
 		a.gPending = false
 		if key.Type == terminal.KeyRune && key.Rune == 'g' {
 			a.jumpToTop()
 			return
 		}
-		// Not 'gg' — consume the key and cancel.
+		if key.Type == terminal.KeyRune && key.Rune == 'x' {
+			a.followLink()
+			return
+		}
+		// Not 'gg' or 'gx' — consume the key and cancel.
+		return
+	}
+
+	// Bracket motions: ]s and [s move between scenes, ]] and [[ between
+	// headings.
+	if a.bracketPending != 0 {
+		forward := a.bracketPending == ']'
+		a.bracketPending = 0
+		if key.Type != terminal.KeyRune {
+			return
+		}
+		switch {
+		case key.Rune == 's' && forward:
+			a.jumpToNextScene()
+		case key.Rune == 's':
+			a.jumpToPrevScene()
+		case key.Rune == ']' && forward:
+			a.jumpToNextHeading()
+		case key.Rune == '[' && !forward:
+			a.jumpToPrevHeading()
+		}
+		return
+	}
+
+	// Fold commands: za, zc, zo, zM, zR.
+	if a.zPending {
+		a.zPending = false
+		if key.Type == terminal.KeyRune {
+			switch key.Rune {
+			case 'a':
+				a.toggleFold()
+			case 'c':
+				a.closeFold()
+			case 'o':
+				a.openFold()
+			case 'M':
+				a.foldAll()
+			case 'R':
+				a.unfoldAll()
+			}
+		}
+		return
+	}
+
+	// Marks: m<letter> sets one, `<letter> jumps to it.
+	if a.markPending != 0 {
+		set := a.markPending == 'm'
+		a.markPending = 0
+		if key.Type == terminal.KeyRune {
+			if set {
+				a.setMark(key.Rune)
+			} else {
+				a.jumpToMark(key.Rune)
+			}
+		}
 		return
 	}
 
@@ -281,136 +690,84 @@ func (a *App) handleDefaultKey(key terminal.Key) {
 		return
 	}
 
-	eb := a.currentBuf()
-	switch key.Type {
-	case terminal.KeyRune:
-		switch key.Rune {
-		case ' ':
-			a.leaderPending = true
-		case 'i':
-			a.mode = ModeEdit
-		case ':':
-			a.statusBar.StartPrompt(PromptCommand)
-		case '/':
-			a.statusBar.StartPrompt(PromptSearch)
-		case 'n':
-			// Jump to next search match if search is active
-			if eb.searchActive {
-				a.jumpToNextMatch()
-			}
-		case 'N':
-			// Jump to previous search match if search is active
-			if eb.searchActive {
-				a.jumpToPrevMatch()
-			}
-		case 'h':
-			a.moveCursor(terminal.KeyLeft)
-		case 'j':
-			a.moveCursor(terminal.KeyDown)
-		case 'k':
-			a.moveCursor(terminal.KeyUp)
-		case 'l':
-			a.moveCursor(terminal.KeyRight)
-		case 'o':
-			eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
-			a.insertNewline()
-			a.mode = ModeEdit
-		case 'O':
-			eb.buf.InsertLine(eb.cursorLine, "")
-			eb.undo.PushInsertWholeLine(eb.cursorLine)
-			eb.cursorCol = 0
-			a.mode = ModeEdit
-		case 'd':
-			a.dPending = true
-		case 'y':
-			a.yPending = true
-		case 's':
-			a.sPending = true
-		case 'p':
-			a.pasteBelow()
-		case 'P':
-			a.pasteAbove()
-		case 'u':
-			a.undoAction()
-		case 'g':
-			a.gPending = true
-		case 'G':
-			a.jumpToBottom()
-		case 'A':
-			eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
-			a.mode = ModeEdit
-		case '^':
-			// Jump to first non-whitespace character.
-			runes := []rune(eb.buf.Lines[eb.cursorLine])
-			for i, r := range runes {
-				if r != ' ' && r != '\t' {
-					eb.cursorCol = i
-					return
-				}
-			}
-			eb.cursorCol = 0
-		case '$':
-			eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
-		case 'x':
-			a.jumpToNextSpellError()
-		case 'X':
-			a.jumpToPrevSpellError()
-		case 'w':
-			a.jumpToNextWord()
-		case 'b':
-			a.jumpToPrevWord()
-		case 'S':
-			a.jumpToScratch()
-		case 'V':
-			a.mode = ModeLineSelect
-			a.lineSelectAnchor = eb.cursorLine
-		}
-	case terminal.KeyUp, terminal.KeyDown, terminal.KeyLeft, terminal.KeyRight:
-		a.moveCursor(key.Type)
-	case terminal.KeyHome:
-		eb.cursorCol = 0
-	case terminal.KeyEnd:
-		eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
-	case terminal.KeyCtrlD:
-		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
-		a.scrollDown(visibleLines / 2)
-	case terminal.KeyCtrlU:
-		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
-		a.scrollUp(visibleLines / 2)
-	case terminal.KeyPgDn:
-		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
-		a.scrollDown(visibleLines)
-	case terminal.KeyPgUp:
-		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
-		a.scrollUp(visibleLines)
-	case terminal.KeyCtrlZ:
-		a.undoAction()
-	case terminal.KeyCtrlY:
-		a.redoAction()
-	case terminal.KeyCtrlR:
-		a.redoAction()
-	}
+	a.runBinding(a.keymap.Default, key)
 }
 
 func (a *App) handleEditKey(key terminal.Key) {
 	// Clear any pending operators from Default mode.
 	a.dPending = false
+	a.cPending = false
+	a.rPending = false
 	a.gPending = false
 	a.yPending = false
 	a.sPending = false
+	a.zPending = false
+	a.bracketPending = 0
+	a.markPending = 0
 
 	eb := a.currentBuf()
 	switch key.Type {
 	case terminal.KeyEscape:
+		if IsMarkdownFile(eb.buf.Filename) {
+			a.alignTableAtCursor()
+		}
+		eb.undo.EndGroup()
+		a.snippet = nil
 		a.mode = ModeDefault
+	case terminal.KeyTab:
+		if a.expandSnippet() || a.nextSnippetStop() || a.completeAtCursor() {
+			return
+		}
+		if IsMarkdownFile(eb.buf.Filename) {
+			if IsTableLine(eb.buf.Lines[eb.cursorLine]) {
+				a.moveTableCell(1)
+			} else {
+				a.indentListItem(1)
+			}
+		}
+	case terminal.KeyShiftTab:
+		if IsMarkdownFile(eb.buf.Filename) {
+			if IsTableLine(eb.buf.Lines[eb.cursorLine]) {
+				a.moveTableCell(-1)
+			} else {
+				a.indentListItem(-1)
+			}
+		}
 	case terminal.KeyRune:
-		a.insertChar(key.Rune)
+		a.tidyTyped(key.Rune)
+		if endsAbbreviation(key.Rune) {
+			a.expandAbbreviation()
+		}
+		if eb.trackChanges {
+			a.trackedInsertChar(key.Rune)
+		} else {
+			a.insertChar(key.Rune)
+			a.smartenTyped()
+		}
+		// Typing [[ offers the notes to link to.
+		if key.Rune == '[' && IsMarkdownFile(eb.buf.Filename) && !a.completion.Active {
+			a.completeWikiLink(true)
+		}
 	case terminal.KeyEnter:
-		a.insertNewline()
+		a.tidyTyped('\n')
+		a.expandAbbreviation()
+		if IsMarkdownFile(eb.buf.Filename) {
+			a.insertNewlineContinuingList()
+		} else {
+			a.insertNewline()
+		}
 	case terminal.KeyBackspace:
-		a.deleteChar()
+		if eb.trackChanges {
+			a.trackedDeleteChar()
+		} else {
+			a.deleteChar()
+		}
 	case terminal.KeyDelete:
-		a.deleteCharForward()
+		if eb.trackChanges {
+			a.trackedDeleteCharForward()
+		} else {
+			a.deleteCharForward()
+		}
 	case terminal.KeyUp, terminal.KeyDown, terminal.KeyLeft, terminal.KeyRight:
 		a.moveCursor(key.Type)
 	case terminal.KeyHome:
@@ -430,12 +787,37 @@ func (a *App) handleEditKey(key terminal.Key) {
 		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
 		a.scrollUp(visibleLines)
 	case terminal.KeyCtrlZ:
-		a.undoAction()
+		a.suspend()
 	case terminal.KeyCtrlY:
 		a.redoAction()
 	case terminal.KeyCtrlR:
 		a.redoAction()
+	case terminal.KeyCombo:
+		if isEditUndoKey(key) {
+			a.undoAction()
+			return
+		}
+		// Ctrl-N and Ctrl-P complete the word, starting from the first
+		// match or the last.
+		if key.Mod == terminal.ModCtrl && (key.Rune == 'n' || key.Rune == 'p') {
+			if a.completeWord(false) && key.Rune == 'p' && a.completion.Active {
+				a.completion.MoveUp()
+			}
+		}
+	}
+}
+
+// isEditUndoKey reports whether key is Ctrl-_, which undoes in Edit mode
+// since Ctrl-Z suspends. Terminals send it for Ctrl-/ too, and under the
+// kitty keyboard protocol as Ctrl-Shift-minus or Ctrl-/.
+func isEditUndoKey(key terminal.Key) bool {
+	switch {
+	case key.Mod == terminal.ModCtrl:
+		return key.Rune == '_' || key.Rune == '/'
+	case key.Mod == terminal.ModCtrl|terminal.ModShift:
+		return key.Rune == '-' || key.Rune == '_'
 	}
+	return false
 }
 
 func (a *App) handleLineSelectKey(key terminal.Key) {
@@ -444,6 +826,13 @@ func (a *App) handleLineSelectKey(key terminal.Key) {
 	case terminal.KeyEscape:
 		a.mode = ModeDefault
 	case terminal.KeyRune:
+		if close, ok := a.surroundWith(key.Rune); ok {
+			if !a.guardReadOnly() {
+				a.surroundSelectedLines(key.Rune, close)
+			}
+			a.mode = ModeDefault
+			return
+		}
 		switch key.Rune {
 		case 'h':
 			a.moveCursor(terminal.KeyLeft)
@@ -453,15 +842,29 @@ func (a *App) handleLineSelectKey(key terminal.Key) {
 			a.moveCursor(terminal.KeyUp)
 		case 'l':
 			a.moveCursor(terminal.KeyRight)
+		case '}':
+			a.jumpToNextParagraph()
+		case '{':
+			a.jumpToPrevParagraph()
 		case 'y':
 			a.yankSelectedLines()
 			a.mode = ModeDefault
 		case 'd':
-			a.deleteSelectedLines()
+			if !a.guardReadOnly() {
+				a.deleteSelectedLines()
+			}
 			a.mode = ModeDefault
+		case 'c':
+			if a.guardReadOnly() {
+				a.mode = ModeDefault
+				return
+			}
+			a.changeSelectedLines()
 		case 's':
 			a.sendSelectedLinesToScratch()
 			a.mode = ModeDefault
+		case ':':
+			a.filterSelectedLines()
 		case 'g':
 			a.gPending = true
 		case 'G':
@@ -509,27 +912,60 @@ func (a *App) handleLineSelectKey(key terminal.Key) {
 }
 
 func (a *App) handlePickerKey(key terminal.Key) {
+	count := a.picker.Count(len(a.buffers))
+	if used, changed := a.picker.Filter.HandleKey(key); used {
+		if changed {
+			selected := a.currentBuffer
+			if a.picker.Selected < count {
+				selected = a.picker.BufferAt(a.picker.Selected)
+			}
+			a.picker.ApplyFilter(a.buffers, selected)
+		}
+		return
+	}
+	if count == 0 {
+		if key.Type == terminal.KeyEscape {
+			a.picker.Hide()
+		}
+		return
+	}
+	idx := a.picker.BufferAt(a.picker.Selected)
 	switch key.Type {
 	case terminal.KeyEscape:
 		a.picker.Hide()
 	case terminal.KeyUp:
 		a.picker.MoveUp()
 	case terminal.KeyDown:
-		a.picker.MoveDown(len(a.buffers))
+		a.picker.MoveDown(count)
 	case terminal.KeyRune:
 		switch key.Rune {
 		case 'k':
 			a.picker.MoveUp()
 		case 'j':
-			a.picker.MoveDown(len(a.buffers))
+			a.picker.MoveDown(count)
+		case 'K':
+			a.picker.ApplyFilter(a.buffers, a.shiftBuffer(idx, -1))
+		case 'J':
+			a.picker.ApplyFilter(a.buffers, a.shiftBuffer(idx, 1))
+		case 'p':
+			a.picker.ApplyFilter(a.buffers, a.togglePin(idx))
 		}
 	case terminal.KeyEnter:
-		a.currentBuffer = a.picker.Selected
+		if idx < 0 || idx >= len(a.buffers) {
+			return
+		}
+		a.currentBuffer = idx
 		a.picker.Hide()
 	}
 }
 
 func (a *App) handleOutlineKey(key terminal.Key) {
+	if used, changed := a.outline.Filter.HandleKey(key); used {
+		if changed {
+			a.outline.ApplyFilter()
+		}
+		return
+	}
 	switch key.Type {
 	case terminal.KeyEscape:
 		a.outline.Hide()
@@ -537,12 +973,37 @@ func (a *App) handleOutlineKey(key terminal.Key) {
 		a.outline.MoveUp()
 	case terminal.KeyDown:
 		a.outline.MoveDown()
+	case terminal.KeyLeft:
+		a.outline.Collapse()
+	case terminal.KeyRight:
+		a.outline.Expand()
 	case terminal.KeyRune:
 		switch key.Rune {
 		case 'k':
 			a.outline.MoveUp()
 		case 'j':
 			a.outline.MoveDown()
+		case 'h':
+			a.outline.Collapse()
+		case 'l':
+			a.outline.Expand()
+		case ' ':
+			a.outline.Toggle()
+		case 'K':
+			a.moveOutlineSection(false)
+		case 'J':
+			a.moveOutlineSection(true)
+		case '<':
+			a.relevelOutlineSection(-1)
+		case '>':
+			a.relevelOutlineSection(1)
+		case 'p':
+			if a.outline.Project {
+				a.outline.Hide()
+				a.showOutline()
+			} else {
+				a.showProjectOutline()
+			}
 		}
 	case terminal.KeyEnter:
 		a.jumpToOutlineItem()
@@ -559,14 +1020,51 @@ func (a *App) showOutline() {
 		return
 	}
 
-	// Extract headings.
-	items := ExtractHeadings(eb.buf)
+	// Extract headings and scene separators.
+	items := ExtractOutline(eb.buf)
 	if len(items) == 0 {
-		a.statusBar.SetMessage("No headings found")
+		a.statusBar.SetMessage("No headings or scenes found")
+		return
+	}
+
+	a.outline.Show(items)
+}
+
+// showProjectOutline opens the outline of every file in the project, or of
+// the Markdown files beside the current file when there is no project, with
+// the current file selected.
+func (a *App) showProjectOutline() {
+	files, root, err := a.projectFiles()
+	if err != nil {
+		a.statusBar.SetError("Project outline: " + err.Error())
+		return
+	}
+	if len(files) == 0 {
+		a.statusBar.SetMessage("No project files found")
 		return
 	}
+	names := make([]string, len(files))
+	texts := make([][]string, len(files))
+	for i, path := range files {
+		if names[i], err = filepath.Rel(root, path); err != nil {
+			names[i] = path
+		}
+		if texts[i], err = a.chapterLines(path); err != nil {
+			texts[i] = nil
+			names[i] += " (missing)"
+		}
+	}
 
+	items := ProjectOutline(files, names, texts)
 	a.outline.Show(items)
+	a.outline.Project = true
+	current := absPath(a.currentBuf().buf.Filename)
+	for i, item := range items {
+		if item.IsFile && item.File == current {
+			a.outline.Selected = i
+			break
+		}
+	}
 }
 
 func (a *App) jumpToOutlineItem() {
@@ -575,8 +1073,12 @@ func (a *App) jumpToOutlineItem() {
 	}
 
 	item := a.outline.Items[a.outline.Selected]
+	a.pushJump()
+	if item.File != "" {
+		a.currentBuffer = a.openBuffer(item.File)
+	}
 	eb := a.currentBuf()
-	eb.cursorLine = item.BufferLine
+	eb.cursorLine = min(item.BufferLine, eb.buf.LineCount()-1)
 	eb.cursorCol = 0
 }
 
@@ -591,12 +1093,12 @@ func (a *App) showBrowser() {
 
 	// Show browser.
 	if err := a.browser.Show(dir); err != nil {
-		a.statusBar.SetMessage("Error opening directory: " + err.Error())
+		a.statusBar.SetError("Error opening directory: " + err.Error())
 		return
 	}
 
 	// Show message if directory is empty.
-	if len(a.browser.Items) == 0 {
+	if len(a.browser.Items) == 0 && a.browser.Filtered == 0 {
 		a.statusBar.SetMessage("Directory is empty")
 		a.browser.Hide()
 	}
@@ -639,6 +1141,9 @@ func (a *App) handleColumnAdjustKey(key terminal.Key) {
 }
 
 func (a *App) handleBrowserKey(key terminal.Key) {
+	if a.handleBrowserFileKey(key) {
+		return
+	}
 	switch key.Type {
 	case terminal.KeyEscape:
 		a.browser.Hide()
@@ -660,12 +1165,43 @@ func (a *App) handleBrowserKey(key terminal.Key) {
 			// Open in new buffer.
 			a.openBrowserItemNewBuffer()
 			a.browser.Hide()
+		case '.', 'i', 'f':
+			a.toggleBrowserFilter(key.Rune)
 		}
 	case terminal.KeyEnter:
 		a.openBrowserItem()
 	}
 }
 
+// toggleBrowserFilter flips one of the browser's filters: . for dotfiles,
+// i for files ignored by git and f for files that aren't prose.
+func (a *App) toggleBrowserFilter(key rune) {
+	b := a.browser
+	var shown bool
+	var what string
+	switch key {
+	case '.':
+		b.ShowHidden = !b.ShowHidden
+		shown, what = b.ShowHidden, "hidden files"
+	case 'i':
+		b.ShowIgnored = !b.ShowIgnored
+		shown, what = b.ShowIgnored, "files ignored by git"
+	case 'f':
+		b.ShowAllFiles = !b.ShowAllFiles
+		shown, what = b.ShowAllFiles, "files that aren't prose"
+	}
+	var selected string
+	if item := b.SelectedItem(); item != nil {
+		selected = item.Path
+	}
+	a.refreshBrowser(selected)
+	if shown {
+		a.statusBar.SetMessage("Showing " + what)
+	} else {
+		a.statusBar.SetMessage("Hiding " + what)
+	}
+}
+
 func (a *App) navigateToParentDirectory() {
 	if a.browser.CurrentDir == "" {
 		return
@@ -681,7 +1217,7 @@ func (a *App) navigateToParentDirectory() {
 
 	// Navigate to parent.
 	if err := a.browser.Show(parentDir); err != nil {
-		a.statusBar.SetMessage("Error opening parent directory: " + err.Error())
+		a.statusBar.SetError("Error opening parent directory: " + err.Error())
 		a.browser.Hide()
 	}
 }
@@ -695,9 +1231,9 @@ func (a *App) openBrowserItem() {
 	if item.IsDir {
 		// Navigate into subdirectory.
 		if err := a.browser.Show(item.Path); err != nil {
-			a.statusBar.SetMessage("Error opening directory: " + err.Error())
+			a.statusBar.SetError("Error opening directory: " + err.Error())
 			a.browser.Hide()
-		} else if len(a.browser.Items) == 0 {
+		} else if len(a.browser.Items) == 0 && a.browser.Filtered == 0 {
 			a.statusBar.SetMessage("Directory is empty")
 			a.browser.Hide()
 		}
@@ -729,14 +1265,19 @@ func (a *App) handlePromptKey(key terminal.Key) {
 	eb := a.currentBuf()
 	switch a.statusBar.Prompt {
 	case PromptSaveNew:
+		if len(a.saveAsQueue) > 0 {
+			a.handleQuitSaveAsKey(key)
+			return
+		}
 		text, done, cancelled := a.statusBar.HandlePromptKey(key)
 		if cancelled {
 			a.quitAfterSave = false
 			return
 		}
 		if done && text != "" {
-			eb.buf.Save(text)
+			eb.Save(text)
 			eb.highlighter = DetectHighlighter(eb.buf.Filename)
+			a.relockBuffer(eb)
 			if a.quitAfterSave {
 				a.closeCurrentBuffer()
 				a.quitAfterSave = false
@@ -749,9 +1290,41 @@ func (a *App) handlePromptKey(key terminal.Key) {
 			return
 		}
 		if done {
+			a.recordHistory(PromptCommand, text)
 			a.executeCommand(text)
 		}
 
+	case PromptNote:
+		text, done, cancelled := a.statusBar.HandlePromptKey(key)
+		if cancelled {
+			a.noteTarget = nil
+			return
+		}
+		if done {
+			a.finishNotePrompt(text)
+		}
+
+	case PromptTemplateVar:
+		text, done, cancelled := a.statusBar.HandlePromptKey(key)
+		if cancelled {
+			a.templateFill = nil
+			a.statusBar.SetMessage("Template cancelled")
+			return
+		}
+		if done {
+			a.finishTemplatePrompt(text)
+		}
+
+	case PromptNewFile:
+		if text, done, _ := a.statusBar.HandlePromptKey(key); done {
+			a.createBrowserFile(text)
+		}
+
+	case PromptRenameFile:
+		if text, done, _ := a.statusBar.HandlePromptKey(key); done {
+			a.renameBrowserItem(text)
+		}
+
 	case PromptSearch:
 		text, done, cancelled := a.statusBar.HandlePromptKey(key)
 		if cancelled {
@@ -761,6 +1334,7 @@ func (a *App) handlePromptKey(key terminal.Key) {
 		}
 		if done {
 			if text != "" {
+				a.recordHistory(PromptSearch, text)
 				a.activateSearch(text)
 			}
 		}
@@ -770,8 +1344,13 @@ func (a *App) handlePromptKey(key terminal.Key) {
 func (a *App) executeCommand(cmd string) {
 	eb := a.currentBuf()
 	cmd = strings.TrimSpace(cmd)
+	a.debugLog("command", "cmd", cmd)
 
 	switch {
+	case (cmd == "q" || cmd == "q!") && a.split.Layout != SplitNone:
+		// In a split, :q closes the focused window; the buffer stays open.
+		a.closeWindow()
+
 	case cmd == "q":
 		if eb.buf.Dirty {
 			a.statusBar.SetMessage("Unsaved changes. Use :q! to discard, or :w to save.")
@@ -785,8 +1364,41 @@ func (a *App) executeCommand(cmd string) {
 	case cmd == "w":
 		if eb.isScratch {
 			a.statusBar.SetMessage("Cannot save scratch buffer")
-		} else {
+		} else if !a.guardReadOnly() {
+			a.save()
+		}
+
+	case cmd == "w!":
+		// Write even if the buffer is read-only (e.g. locked by another
+		// prose) or the file has changed on disk.
+		if eb.isScratch {
+			a.statusBar.SetMessage("Cannot save scratch buffer")
+		} else if eb.buf.Filename == "" {
 			a.save()
+		} else {
+			a.writeBuffer(eb)
+		}
+
+	case cmd == "split" || cmd == "sp" || strings.HasPrefix(cmd, "split ") || strings.HasPrefix(cmd, "sp "):
+		_, filename, _ := strings.Cut(cmd, " ")
+		a.splitWindow(SplitStacked, strings.TrimSpace(filename))
+
+	case cmd == "vsplit" || cmd == "vs" || strings.HasPrefix(cmd, "vsplit ") || strings.HasPrefix(cmd, "vs "):
+		_, filename, _ := strings.Cut(cmd, " ")
+		a.splitWindow(SplitSideBySide, strings.TrimSpace(filename))
+
+	case cmd == "preview":
+		a.togglePreview()
+
+	case cmd == "only":
+		a.closeOtherWindow()
+
+	case cmd == "ro":
+		eb.readOnly = !eb.readOnly
+		if eb.readOnly {
+			a.statusBar.SetMessage("Buffer is read-only")
+		} else {
+			a.statusBar.SetMessage("Buffer is editable")
 		}
 
 	case strings.HasPrefix(cmd, "w "):
@@ -795,19 +1407,24 @@ func (a *App) executeCommand(cmd string) {
 		} else {
 			filename := strings.TrimSpace(cmd[2:])
 			if filename != "" {
-				eb.buf.Save(filename)
+				eb.Save(filename)
 				eb.highlighter = DetectHighlighter(eb.buf.Filename)
+				a.relockBuffer(eb)
 			}
 		}
 
 	case cmd == "wq":
 		if eb.isScratch {
 			a.statusBar.SetMessage("Cannot save scratch buffer")
+		} else if a.guardReadOnly() {
+			return
+		} else if a.writeToStdout(eb) {
+			a.closeCurrentBuffer()
 		} else if eb.buf.Filename == "" {
 			a.quitAfterSave = true
 			a.statusBar.StartPrompt(PromptSaveNew)
-		} else {
-			eb.buf.Save("")
+		} else if !eb.buf.ChangedOnDisk() || !a.showMerge(eb) {
+			eb.Save("")
 			a.closeCurrentBuffer()
 		}
 
@@ -831,66 +1448,172 @@ func (a *App) executeCommand(cmd string) {
 		oldName := eb.buf.Filename
 		if oldName == "" {
 			// Unnamed buffer — behaves like :w <filename>.
-			eb.buf.Save(newName)
+			eb.Save(newName)
 			eb.highlighter = DetectHighlighter(eb.buf.Filename)
 		} else {
 			if err := os.Rename(oldName, newName); err != nil {
-				a.statusBar.SetMessage("Rename failed: " + err.Error())
+				a.statusBar.SetError("Rename failed: " + err.Error())
 				return
 			}
+			moveUndoHistory(oldName, newName)
 			eb.buf.Filename = newName
 			eb.highlighter = DetectHighlighter(eb.buf.Filename)
 		}
+		a.relockBuffer(eb)
 
 	case cmd == "qa":
-		// Quit all buffers — fail if any have unsaved changes.
-		var dirtyBuffers []string
-		for _, buf := range a.buffers {
-			if buf.buf.Dirty {
-				name := buf.Filename()
-				if name == "" {
-					name = "[unnamed]"
-				}
-				dirtyBuffers = append(dirtyBuffers, name)
-			}
-		}
-		if len(dirtyBuffers) > 0 {
-			a.statusBar.SetMessage(fmt.Sprintf("Unsaved changes in %d buffer(s): %s. Use :qa! to discard.",
-				len(dirtyBuffers), strings.Join(dirtyBuffers, ", ")))
-		} else {
-			a.quit = true
-		}
+		// Quit all buffers, asking what to do with unsaved changes.
+		a.quitAll()
 
 	case cmd == "qa!" || cmd == "!qa":
 		// Force quit all buffers, discarding any unsaved changes.
 		a.quit = true
 
+	case cmd == "wa":
+		// Write all dirty buffers that have names.
+		a.writeAll()
+
 	case cmd == "wqa" || cmd == "qwa":
-		// Write all dirty buffers, then quit — fail if any unnamed buffer is dirty.
-		var unnamedDirty int
-		var saveFailures []string
-		for _, buf := range a.buffers {
-			if buf.buf.Dirty {
-				if buf.buf.Filename == "" {
-					unnamedDirty++
-				} else {
-					if err := buf.buf.Save(""); err != nil {
-						saveFailures = append(saveFailures, buf.Filename()+": "+err.Error())
-					}
-				}
-			}
-		}
-		if unnamedDirty > 0 {
-			a.statusBar.SetMessage(fmt.Sprintf("Cannot save %d unnamed buffer(s). Use :qa! to discard, or save them first.", unnamedDirty))
-		} else if len(saveFailures) > 0 {
-			a.statusBar.SetMessage(fmt.Sprintf("Save failed: %s", strings.Join(saveFailures, "; ")))
-		} else {
-			a.quit = true
-		}
+		// Write all dirty buffers, asking for names for unnamed ones, then quit.
+		a.writeQuitAll()
 
 	case cmd == "spell":
 		a.toggleSpellCheck()
 
+	case cmd == "names":
+		a.showNameCheck()
+
+	case cmd == "dupes":
+		a.showDuplicates()
+
+	case cmd == "compile" || strings.HasPrefix(cmd, "compile "):
+		a.compile(strings.TrimPrefix(cmd, "compile"))
+
+	case cmd == "stats":
+		a.showStats()
+
+	case cmd == "project":
+		a.projectCommand("")
+
+	case strings.HasPrefix(cmd, "project "):
+		a.projectCommand(strings.TrimSpace(strings.TrimPrefix(cmd, "project ")))
+
+	case cmd == "undotree":
+		a.showUndoTree()
+
+	case cmd == "note":
+		if !a.guardReadOnly() {
+			a.startNotePrompt(nil)
+		}
+
+	case strings.HasPrefix(cmd, "note "):
+		a.addAnnotation(strings.TrimPrefix(cmd, "note "))
+
+	case cmd == "notes":
+		a.showNotes()
+
+	case cmd == "today":
+		a.openToday()
+
+	case cmd == "tutor":
+		a.openTutor()
+
+	case cmd == "journal list":
+		a.showJournalList()
+
+	case cmd == "new":
+		a.statusBar.SetMessage("Usage: :new <template> [filename]")
+
+	case strings.HasPrefix(cmd, "new "):
+		name, filename, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(cmd, "new ")), " ")
+		a.newFromTemplate(name, strings.TrimSpace(filename))
+
+	case strings.HasPrefix(cmd, "template "):
+		a.startTemplate(strings.TrimSpace(strings.TrimPrefix(cmd, "template ")))
+
+	case cmd == "track":
+		a.toggleTrackChanges()
+
+	case cmd == "hoist":
+		a.toggleHoist()
+
+	case (cmd == "accept" || cmd == "reject" || cmd == "accept all" || cmd == "reject all") && a.guardReadOnly():
+		return
+
+	case cmd == "accept":
+		a.resolveChangeAtCursor(true)
+
+	case cmd == "reject":
+		a.resolveChangeAtCursor(false)
+
+	case cmd == "accept all":
+		a.resolveAllChanges(true)
+
+	case cmd == "reject all":
+		a.resolveAllChanges(false)
+
+	case strings.HasPrefix(cmd, "table") && a.guardReadOnly():
+		return
+
+	case cmd == "table":
+		if !a.alignTableAtCursor() {
+			a.statusBar.SetMessage("Not in a table")
+		}
+
+	case cmd == "table row":
+		a.insertTableRow()
+
+	case cmd == "table col":
+		a.insertTableColumn()
+
+	case cmd == "grep" || strings.HasPrefix(cmd, "grep "):
+		a.grep(strings.TrimSpace(strings.TrimPrefix(cmd, "grep")))
+
+	case cmd == "export" || strings.HasPrefix(cmd, "export "):
+		a.exportCommand(strings.TrimPrefix(cmd, "export"))
+	case cmd == "goal" || strings.HasPrefix(cmd, "goal "):
+		a.goalCommand(strings.TrimPrefix(cmd, "goal"))
+	case cmd == "tidy":
+		a.tidyBuffer()
+	case cmd == "smartpunct" || strings.HasPrefix(cmd, "smartpunct "):
+		a.smartPunctCommand(strings.TrimPrefix(cmd, "smartpunct"))
+	case cmd == "headcase" || strings.HasPrefix(cmd, "headcase "):
+		a.headCaseCommand(strings.TrimPrefix(cmd, "headcase"))
+
+	case cmd == "messages":
+		a.showMessages()
+
+	case cmd == "help":
+		a.showHelp()
+
+	case cmd == "gitdiff":
+		a.showGitDiff()
+
+	case cmd == "merge":
+		a.mergeCommand()
+
+	case cmd == "recent":
+		a.showRecent()
+
+	case cmd == "bench":
+		a.bench()
+
+	case cmd == "set" || strings.HasPrefix(cmd, "set "):
+		a.setOption(strings.TrimSpace(strings.TrimPrefix(cmd, "set")))
+
+	case cmd == "speak" || strings.HasPrefix(cmd, "speak "):
+		a.speak(strings.TrimSpace(strings.TrimPrefix(cmd, "speak")))
+
+	case isFilter(cmd):
+		rng, command, _ := cutFilter(cmd)
+		a.filterLines(rng, command)
+
+	case isLineNumber(cmd):
+		a.goToLine(cmd)
+
+	case cmd == "goto" || strings.HasPrefix(cmd, "goto "):
+		a.goToLine(strings.TrimSpace(strings.TrimPrefix(cmd, "goto")))
+
 	default:
 		a.statusBar.SetMessage("Unknown command: " + cmd)
 	}
@@ -918,16 +1641,32 @@ func (a *App) openBuffer(filename string) int {
 	// Create new buffer.
 	eb := NewEditorBuffer(filename)
 	eb.buf.Load()
+	eb.loadUndoHistory()
+	eb.startWords = eb.WordCount()
+	a.lockBuffer(eb)
+	a.recordRecent(eb)
 	a.buffers = append(a.buffers, eb)
+
+	// Files pinned in the session join the pinned group at the top.
+	if indexOf(loadSession().Pinned, absPath) >= 0 {
+		eb.pinned = true
+		a.moveBuffer(len(a.buffers)-1, a.pinnedCount()-1)
+		return a.bufferIndex(eb)
+	}
 	return len(a.buffers) - 1
 }
 
 // closeCurrentBuffer removes the current buffer. If it's the last one, quit.
 func (a *App) closeCurrentBuffer() {
+	a.unlockBuffer(a.currentBuf())
 	if len(a.buffers) == 1 {
 		a.quit = true
 		return
 	}
+	a.forgetJumps(a.currentBuf())
+	if eb := a.currentBuf(); !eb.isScratch {
+		a.closedWords += eb.WordCount() - eb.startWords
+	}
 	a.buffers = append(a.buffers[:a.currentBuffer], a.buffers[a.currentBuffer+1:]...)
 	if a.currentBuffer >= len(a.buffers) {
 		a.currentBuffer = len(a.buffers) - 1
@@ -936,11 +1675,31 @@ func (a *App) closeCurrentBuffer() {
 
 func (a *App) save() {
 	eb := a.currentBuf()
+	if a.writeToStdout(eb) {
+		return
+	}
 	if eb.buf.Filename == "" {
 		a.statusBar.StartPrompt(PromptSaveNew)
 		return
 	}
-	eb.buf.Save("")
+	if eb.buf.ChangedOnDisk() && a.showMerge(eb) {
+		return
+	}
+	a.writeBuffer(eb)
+}
+
+// writeBuffer saves a named buffer over its file.
+func (a *App) writeBuffer(eb *EditorBuffer) {
+	if err := eb.Save(""); err != nil {
+		a.errorLog("save failed", err, "file", eb.buf.Filename)
+		if errors.Is(err, fs.ErrPermission) && eb == a.currentBuf() {
+			a.promptSaveCopy()
+			return
+		}
+		a.statusBar.SetError("Save failed: " + err.Error())
+		return
+	}
+	a.recordProjectWords(time.Now())
 }
 
 // insertChar inserts a character at the cursor and advances the cursor.
@@ -970,7 +1729,16 @@ func (a *App) deleteChar() {
 	}
 
 	if eb.cursorCol > 0 {
-		// Delete character within the line.
+		// Delete the character within the line, with any marks or joined
+		// code points that make it up.
+		start := prevGrapheme([]rune(eb.buf.Lines[eb.cursorLine]), eb.cursorCol)
+		if start < eb.cursorCol-1 {
+			text := eb.buf.DeleteText(eb.cursorLine, start, eb.cursorLine, eb.cursorCol)
+			eb.undo.PushDeleteText(eb.cursorLine, start, text, eb.cursorLine, eb.cursorCol)
+			eb.cursorCol = start
+			eb.ScheduleSpellCheck()
+			return
+		}
 		ch, _ := eb.buf.DeleteChar(eb.cursorLine, eb.cursorCol)
 		if ch == 0 {
 			return
@@ -999,17 +1767,19 @@ func (a *App) moveCursor(dir int) {
 	switch dir {
 	case terminal.KeyLeft:
 		if eb.cursorCol > 0 {
-			eb.cursorCol--
+			eb.cursorCol = prevGrapheme([]rune(eb.buf.Lines[eb.cursorLine]), eb.cursorCol)
 		} else if eb.cursorLine > 0 {
 			eb.cursorLine--
 			eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+			a.skipFold(false)
 		}
 	case terminal.KeyRight:
 		if eb.cursorCol < eb.buf.LineLen(eb.cursorLine) {
-			eb.cursorCol++
+			eb.cursorCol = nextGrapheme([]rune(eb.buf.Lines[eb.cursorLine]), eb.cursorCol)
 		} else if eb.cursorLine < eb.buf.LineCount()-1 {
 			eb.cursorLine++
 			eb.cursorCol = 0
+			a.skipFold(true)
 		}
 	case terminal.KeyUp:
 		if eb.cursorLine > 0 {
@@ -1017,6 +1787,7 @@ func (a *App) moveCursor(dir int) {
 			if eb.cursorCol > eb.buf.LineLen(eb.cursorLine) {
 				eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
 			}
+			a.skipFold(false)
 		}
 	case terminal.KeyDown:
 		if eb.cursorLine < eb.buf.LineCount()-1 {
@@ -1024,17 +1795,20 @@ func (a *App) moveCursor(dir int) {
 			if eb.cursorCol > eb.buf.LineLen(eb.cursorLine) {
 				eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
 			}
+			a.skipFold(true)
 		}
 	}
 }
 
 func (a *App) jumpToTop() {
+	a.pushJump()
 	eb := a.currentBuf()
 	eb.cursorLine = 0
 	eb.cursorCol = 0
 }
 
 func (a *App) jumpToBottom() {
+	a.pushJump()
 	eb := a.currentBuf()
 	eb.cursorLine = eb.buf.LineCount() - 1
 	eb.cursorCol = 0
@@ -1043,6 +1817,7 @@ func (a *App) jumpToBottom() {
 func (a *App) yankLine() {
 	eb := a.currentBuf()
 	a.yankBuffer = eb.buf.Lines[eb.cursorLine]
+	a.yankCharwise = false
 	a.statusBar.SetMessage("Yanked line")
 }
 
@@ -1050,22 +1825,22 @@ func (a *App) pasteBelow() {
 	if a.yankBuffer == "" {
 		return
 	}
+	if a.yankCharwise {
+		a.pasteText(true)
+		return
+	}
 	eb := a.currentBuf()
 
 	// Check if yankBuffer contains multiple lines
 	if strings.Contains(a.yankBuffer, "\n") {
 		lines := strings.Split(a.yankBuffer, "\n")
-		insertPos := eb.cursorLine + 1
+		insertPos := min(eb.cursorLine+1, len(eb.buf.Lines))
 
 		// Push undo operation for multi-line insert
 		eb.undo.PushInsertMultipleLines(insertPos, lines, eb.cursorLine, eb.cursorCol)
 
 		// Insert all lines at once
-		newLines := make([]string, len(eb.buf.Lines)+len(lines))
-		copy(newLines, eb.buf.Lines[:insertPos])
-		copy(newLines[insertPos:], lines)
-		copy(newLines[insertPos+len(lines):], eb.buf.Lines[insertPos:])
-		eb.buf.Lines = newLines
+		eb.buf.Lines = slices.Insert(eb.buf.Lines, insertPos, lines...)
 		eb.buf.Dirty = true
 
 		eb.cursorLine = insertPos
@@ -1085,6 +1860,10 @@ func (a *App) pasteAbove() {
 	if a.yankBuffer == "" {
 		return
 	}
+	if a.yankCharwise {
+		a.pasteText(false)
+		return
+	}
 	eb := a.currentBuf()
 
 	// Check if yankBuffer contains multiple lines
@@ -1096,11 +1875,7 @@ func (a *App) pasteAbove() {
 		eb.undo.PushInsertMultipleLines(insertPos, lines, eb.cursorLine, eb.cursorCol)
 
 		// Insert all lines at once
-		newLines := make([]string, len(eb.buf.Lines)+len(lines))
-		copy(newLines, eb.buf.Lines[:insertPos])
-		copy(newLines[insertPos:], lines)
-		copy(newLines[insertPos+len(lines):], eb.buf.Lines[insertPos:])
-		eb.buf.Lines = newLines
+		eb.buf.Lines = slices.Insert(eb.buf.Lines, insertPos, lines...)
 		eb.buf.Dirty = true
 
 		eb.cursorLine = insertPos
@@ -1116,21 +1891,29 @@ func (a *App) pasteAbove() {
 }
 
 func (a *App) undoAction() {
+	if a.guardReadOnly() {
+		return
+	}
 	eb := a.currentBuf()
 	line, col, ok := eb.undo.Undo(eb.buf)
 	if ok {
 		eb.cursorLine = line
 		eb.cursorCol = col
+		eb.clampCursor()
 		eb.ScheduleSpellCheck()
 	}
 }
 
 func (a *App) redoAction() {
+	if a.guardReadOnly() {
+		return
+	}
 	eb := a.currentBuf()
 	line, col, ok := eb.undo.Redo(eb.buf)
 	if ok {
 		eb.cursorLine = line
 		eb.cursorCol = col
+		eb.clampCursor()
 		eb.ScheduleSpellCheck()
 	}
 }
@@ -1140,6 +1923,7 @@ func (a *App) deleteWholeLine() {
 	eb := a.currentBuf()
 	content := eb.buf.DeleteLine(eb.cursorLine)
 	a.yankBuffer = content // Populate yank buffer for cut semantics.
+	a.yankCharwise = false
 	eb.undo.PushDeleteWholeLine(eb.cursorLine, content, eb.cursorLine, eb.cursorCol)
 
 	// Clamp cursor position after deletion.
@@ -1158,7 +1942,14 @@ func (a *App) deleteCharForward() {
 	lineLen := eb.buf.LineLen(eb.cursorLine)
 
 	if eb.cursorCol < lineLen {
-		// Delete character at cursor position.
+		// Delete the character at the cursor, with any marks or joined code
+		// points that make it up.
+		if end := nextGrapheme([]rune(eb.buf.Lines[eb.cursorLine]), eb.cursorCol); end > eb.cursorCol+1 {
+			text := eb.buf.DeleteText(eb.cursorLine, eb.cursorCol, eb.cursorLine, end)
+			eb.undo.PushDeleteText(eb.cursorLine, eb.cursorCol, text, eb.cursorLine, eb.cursorCol)
+			eb.ScheduleSpellCheck()
+			return
+		}
 		ch := eb.buf.DeleteCharForward(eb.cursorLine, eb.cursorCol)
 		if ch != 0 {
 			eb.undo.PushDeleteChar(eb.cursorLine, eb.cursorCol, ch, eb.cursorLine, eb.cursorCol)
@@ -1178,6 +1969,7 @@ func (a *App) scrollDown(n int) {
 	if eb.cursorLine >= eb.buf.LineCount() {
 		eb.cursorLine = eb.buf.LineCount() - 1
 	}
+	a.skipFold(true)
 	// Clamp column to new line length.
 	if eb.cursorCol > eb.buf.LineLen(eb.cursorLine) {
 		eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
@@ -1191,6 +1983,7 @@ func (a *App) scrollUp(n int) {
 	if eb.cursorLine < 0 {
 		eb.cursorLine = 0
 	}
+	a.skipFold(false)
 	// Clamp column to new line length.
 	if eb.cursorCol > eb.buf.LineLen(eb.cursorLine) {
 		eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
@@ -1203,22 +1996,19 @@ func (a *App) mouseToBufferPos(termRow, termCol int) (int, int) {
 	eb := a.currentBuf()
 	vp := a.viewport
 
-	// Account for top padding (1 line when scrollOffset == 0).
-	topPadding := 0
-	if eb.scrollOffset == 0 {
-		topPadding = 1
-	}
+	// Account for top padding (only when scrollOffset == 0).
+	topPadding, _ := vp.Padding(eb.scrollOffset)
 
-	// Click on status bar or above text area — ignore.
-	if termRow == vp.Height || termRow < 1+topPadding {
+	// Click on status bar, padding or outside the text area — ignore.
+	if termRow < 1+topPadding || termRow > topPadding+vp.VisibleLines(eb.scrollOffset) {
 		return -1, -1
 	}
 
 	// Convert terminal row to display line index.
 	displayLineIdx := eb.scrollOffset + (termRow - 1 - topPadding)
 
-	// Generate wrapped display lines.
-	displayLines := WrapBuffer(eb.buf, vp.ColWidth)
+	// Take the display lines as drawn, with whatever they conceal.
+	displayLines := a.displayLines(eb)
 
 	// Check if click is beyond the last display line.
 	if displayLineIdx >= len(displayLines) {
@@ -1243,7 +2033,7 @@ func (a *App) mouseToBufferPos(termRow, termCol int) (int, int) {
 
 	// Map display column to buffer column.
 	// The display line shows text starting at dl.Offset in the buffer line.
-	bufferCol := dl.Offset + clickCol
+	bufferCol := dl.Offset + dl.TextCol(clickCol)
 
 	// Clamp to actual line length.
 	lineLen := eb.buf.LineLen(bufferLine)
@@ -1265,15 +2055,13 @@ func (a *App) jumpToNextSpellError() {
 	// Find the next error after the current cursor position.
 	for _, err := range eb.spellErrors {
 		if err.Line > eb.cursorLine || (err.Line == eb.cursorLine && err.StartCol > eb.cursorCol) {
-			eb.cursorLine = err.Line
-			eb.cursorCol = err.StartCol
+			a.moveToSpellError(err)
 			return
 		}
 	}
 
 	// Wrap around to the first error.
-	eb.cursorLine = eb.spellErrors[0].Line
-	eb.cursorCol = eb.spellErrors[0].StartCol
+	a.moveToSpellError(eb.spellErrors[0])
 }
 
 // jumpToPrevSpellError moves the cursor to the previous spelling error, wrapping around if needed.
@@ -1288,16 +2076,28 @@ func (a *App) jumpToPrevSpellError() {
 	for i := len(eb.spellErrors) - 1; i >= 0; i-- {
 		err := eb.spellErrors[i]
 		if err.Line < eb.cursorLine || (err.Line == eb.cursorLine && err.StartCol < eb.cursorCol) {
-			eb.cursorLine = err.Line
-			eb.cursorCol = err.StartCol
+			a.moveToSpellError(err)
 			return
 		}
 	}
 
 	// Wrap around to the last error.
-	lastErr := eb.spellErrors[len(eb.spellErrors)-1]
-	eb.cursorLine = lastErr.Line
-	eb.cursorCol = lastErr.StartCol
+	a.moveToSpellError(eb.spellErrors[len(eb.spellErrors)-1])
+}
+
+// moveToSpellError puts the cursor on err. Unknown citation keys and
+// unmatched delimiters, which are listed with the spelling errors, are
+// named in the status bar.
+func (a *App) moveToSpellError(err spell.SpellError) {
+	eb := a.currentBuf()
+	eb.cursorLine = err.Line
+	eb.cursorCol = err.StartCol
+	switch {
+	case strings.HasPrefix(err.Word, "@"):
+		a.statusBar.SetMessage("Not in the bibliography: " + err.Word)
+	case isDelimiterWord(err.Word):
+		a.statusBar.SetMessage("Unmatched " + err.Word)
+	}
 }
 
 // jumpToNextWord moves the cursor to the start of the next word, wrapping around if needed.
@@ -1360,23 +2160,34 @@ func (a *App) activateSearch(query string) {
 		return
 	}
 
-	// Clear previous search state
-	eb.searchMatches = nil
+	eb.searchMatches = findMatches(eb.buf.Lines, query)
 	eb.searchQuery = query
 	eb.searchCurrentIdx = -1
 
+	// If no matches found, show message and return
+	if len(eb.searchMatches) == 0 {
+		a.statusBar.SetMessage("No matches found")
+		eb.searchActive = false
+		return
+	}
+
+	// Activate search and jump to nearest match
+	eb.searchActive = true
+	a.jumpToNearestMatch(true)
+}
+
+// findMatches returns every case-insensitive occurrence of query in lines.
+func findMatches(lines []string, query string) []SearchMatch {
+	var matches []SearchMatch
+
 	// Convert query to lowercase for case-insensitive matching
-	queryLower := strings.ToLower(query)
-	queryRunes := []rune(queryLower)
+	queryRunes := []rune(strings.ToLower(query))
 
-	// Search all lines for matches
-	for lineIdx := 0; lineIdx < len(eb.buf.Lines); lineIdx++ {
-		line := eb.buf.Lines[lineIdx]
-		lineRunes := []rune(line)
+	for lineIdx, line := range lines {
 		lineLower := []rune(strings.ToLower(line))
 
 		// Check for substring match at each position
-		for col := 0; col <= len(lineRunes)-len(queryRunes); col++ {
+		for col := 0; col <= len(lineLower)-len(queryRunes); col++ {
 			match := true
 			for i := 0; i < len(queryRunes); i++ {
 				if lineLower[col+i] != queryRunes[i] {
@@ -1385,7 +2196,7 @@ func (a *App) activateSearch(query string) {
 				}
 			}
 			if match {
-				eb.searchMatches = append(eb.searchMatches, SearchMatch{
+				matches = append(matches, SearchMatch{
 					Line:     lineIdx,
 					StartCol: col,
 					EndCol:   col + len(queryRunes),
@@ -1393,17 +2204,7 @@ func (a *App) activateSearch(query string) {
 			}
 		}
 	}
-
-	// If no matches found, show message and return
-	if len(eb.searchMatches) == 0 {
-		a.statusBar.SetMessage("No matches found")
-		eb.searchActive = false
-		return
-	}
-
-	// Activate search and jump to nearest match
-	eb.searchActive = true
-	a.jumpToNearestMatch(true)
+	return matches
 }
 
 // clearSearch clears the search state and highlighting.
@@ -1429,6 +2230,7 @@ func (a *App) jumpToNextMatch() {
 	}
 
 	// Jump to the match
+	a.pushJump()
 	match := eb.searchMatches[eb.searchCurrentIdx]
 	eb.cursorLine = match.Line
 	eb.cursorCol = match.StartCol
@@ -1448,6 +2250,7 @@ func (a *App) jumpToPrevMatch() {
 	}
 
 	// Jump to the match
+	a.pushJump()
 	match := eb.searchMatches[eb.searchCurrentIdx]
 	eb.cursorLine = match.Line
 	eb.cursorCol = match.StartCol
@@ -1460,6 +2263,7 @@ func (a *App) jumpToNearestMatch(forward bool) {
 	if len(eb.searchMatches) == 0 {
 		return
 	}
+	a.pushJump()
 
 	if forward {
 		// Find first match at or after cursor
@@ -1556,6 +2360,7 @@ func (a *App) yankSelectedLines() {
 	start, end := a.getSelectionRange()
 	lines := eb.buf.Lines[start : end+1]
 	a.yankBuffer = strings.Join(lines, "\n")
+	a.yankCharwise = false
 	a.statusBar.SetMessage(fmt.Sprintf("Yanked %d line(s)", end-start+1))
 }
 
@@ -1566,6 +2371,7 @@ func (a *App) deleteSelectedLines() {
 	lines := make([]string, end-start+1)
 	copy(lines, eb.buf.Lines[start:end+1])
 	a.yankBuffer = strings.Join(lines, "\n") // Cut semantics
+	a.yankCharwise = false
 
 	// Push undo operation before modifying buffer
 	eb.undo.PushDeleteMultipleLines(start, end, lines, eb.cursorLine, eb.cursorCol)
@@ -1599,17 +2405,63 @@ func (a *App) sendSelectedLinesToScratch() {
 	a.statusBar.SetMessage(fmt.Sprintf("Sent %d line(s) to scratch", end-start+1))
 }
 
+// relayout recomputes layout for a new terminal size. Every buffer keeps the
+// same buffer line at the top of the screen after rewrapping; split panes,
+// overlays and prompts take their geometry from the terminal size when they
+// are drawn, so they simply follow.
+func (a *App) relayout(width, height int) {
+	tops := make([]int, len(a.buffers))
+	for i, eb := range a.buffers {
+		dls := eb.DisplayLines(a.viewport.ColWidth)
+		if eb.scrollOffset < len(dls) {
+			tops[i] = dls[eb.scrollOffset].BufferLine
+		}
+	}
+
+	a.termWidth, a.termHeight = width, height
+	a.renderer.Invalidate()
+	a.applyLayout()
+
+	for i, eb := range a.buffers {
+		if eb.scrollOffset == 0 {
+			continue
+		}
+		eb.scrollOffset, _ = CursorToDisplayLine(eb.DisplayLines(a.viewport.ColWidth), tops[i], 0)
+	}
+}
+
 func (a *App) render() {
+	if a.logger != nil {
+		start := time.Now()
+		defer func() { a.debugLog("render", "duration", time.Since(start)) }()
+	}
+	if a.tooSmall() {
+		status := ""
+		if a.statusBar.Prompt != PromptNone || a.statusBar.StatusMessage != "" {
+			status = a.statusBar.FormatLeft("", false, "", 0, false)
+		}
+		a.terminal.WriteString(a.renderer.RenderTooSmall(a.termWidth, a.termHeight, status))
+		return
+	}
 	eb := a.currentBuf()
-	displayLines := WrapBuffer(eb.buf, a.viewport.ColWidth)
+	eb.openFoldsAt(eb.cursorLine)
+	a.renderer.gitSigns = a.gitSigns(eb)
+	a.viewport.SetGutter(gutterWidth(a.renderer.lineNumbers, eb.buf.LineCount(), a.renderer.gitSigns != nil))
+	displayLines := a.displayLines(eb)
 	cursorDL, cursorDC := CursorToDisplayLine(displayLines, eb.cursorLine, eb.cursorCol)
+	if cursorDL < len(displayLines) {
+		cursorDC = displayLines[cursorDL].screenCol(cursorDC)
+		if displayLines[cursorDL].Folded > 0 {
+			cursorDC = min(cursorDC+foldMarkerWidth, a.viewport.ColWidth-1)
+		}
+	}
 
 	a.viewport.EnsureCursorVisible(cursorDL, &eb.scrollOffset)
 
 	// When the cursor is on the last buffer line, ensure the end of the file
 	// is visible. Without this, a long last line that wraps to multiple display
 	// lines would leave the wrapped parts hidden below the viewport.
-	if eb.cursorLine == eb.buf.LineCount()-1 {
+	if _, last := eb.hoistBounds(); eb.cursorLine == last {
 		a.viewport.EnsureEndOfFileVisible(len(displayLines), cursorDL, &eb.scrollOffset)
 	}
 
@@ -1618,53 +2470,110 @@ func (a *App) render() {
 		bufferInfo = formatBufferInfo(a.currentBuffer+1, len(a.buffers))
 	}
 
+	a.statusBar.Ambient = a.ambientText(time.Now())
+	a.statusBar.ReadOnly = eb.readOnly
 	statusLeft := a.statusBar.FormatLeft(eb.Filename(), eb.IsDirty(), bufferInfo, eb.SpellErrorCount(), eb.isScratch)
-	statusRight := a.statusBar.FormatRight(a.mode, eb.WordCount(), eb.SpellErrorCount(), eb.searchActive, eb.searchCurrentIdx, len(eb.searchMatches))
+	if a.statusBar.Prompt != PromptNone {
+		statusLeft = fitPrompt(statusLeft, a.viewport.Width)
+	}
+	statusRight := a.statusBar.FormatRight(a.mode, eb.WordCount(), eb.SpellErrorCount(), eb.SpellErrorIndex(), eb.searchActive, eb.searchCurrentIdx, len(eb.searchMatches))
+	if eb.trackChanges && statusRight != "" {
+		statusRight = "TRACK  " + statusRight
+	}
+	if eb.hoist != nil && statusRight != "" {
+		statusRight = "HOIST  " + statusRight
+	}
+	if statusRight != "" {
+		statusRight = formatTag(eb.buf) + statusRight
+	}
+	if a.mode == ModeLineSelect && a.renderer.screenReader {
+		// A screen reader follows the status bar, not the corner box.
+		statusRight = a.selectionStats().String() + "  " + statusRight
+	}
 
 	// Get selection range for line-select mode
 	selectionStart, selectionEnd := -1, -1
 	if a.mode == ModeLineSelect {
 		selectionStart, selectionEnd = a.getSelectionRange()
 	}
+	if a.mode == ModeVisual {
+		sl, sc, el, ec := a.getVisualRange()
+		styleSelection(displayLines, a.renderer.theme.selectionSpan(), sl, sc, el, ec)
+	}
+	a.styleFlash(displayLines, eb.buf.Lines)
 
-	frame := a.renderer.RenderFrame(displayLines, a.viewport, eb.scrollOffset, cursorDL, cursorDC, statusLeft, statusRight, eb.highlighter, eb.spellErrors, a.mode, selectionStart, selectionEnd, eb.searchActive, eb.searchMatches, eb.searchCurrentIdx)
+	// In a split, draw the unfocused window first so the focused one leaves
+	// the cursor in place.
+	frame := ""
+	if a.split.Layout != SplitNone {
+		frame += a.renderOtherWindow()
+		if a.split.Layout == SplitSideBySide {
+			frame += a.renderDivider()
+		}
+	}
+	frame += a.renderer.RenderFrame(displayLines, a.viewport, eb.scrollOffset, cursorDL, cursorDC, statusLeft, statusRight, eb.highlighter, eb.spellErrors, a.mode, selectionStart, selectionEnd, eb.searchActive, eb.searchMatches, eb.searchCurrentIdx)
 
-	// Render picker overlay if active.
-	if a.picker.Active {
-		frame += a.renderer.RenderPicker(a.buffers, a.picker, a.currentBuffer, a.viewport)
+	// The selection's counts sit in the focused window's corner.
+	if a.mode == ModeLineSelect && !a.overlayActive() && !a.renderer.screenReader {
+		frame += a.renderer.RenderSelectionStats(a.selectionStats(), a.viewport)
 	}
 
-	// Render outline overlay if active.
-	if a.outline.Active {
-		frame += a.renderer.RenderOutline(a.outline, a.viewport)
+	// Overlays are centred on the whole screen.
+	screen := a.screenViewport()
+
+	// Render completions if active. Words are listed by the cursor.
+	if a.completion.Active && a.completion.Words {
+		top, _ := a.viewport.Padding(eb.scrollOffset)
+		row := a.viewport.Top + cursorDL - eb.scrollOffset + 1 + top
+		col := a.viewport.Left + a.viewport.LeftMargin + cursorDC + 1
+		wordCol := col - max(eb.cursorCol-a.completion.Start, 0)
+		frame += a.renderer.RenderWordCompletion(a.completion, row, col, wordCol, screen)
+	} else if a.completion.Active {
+		frame += a.renderer.RenderCompletion(a.completion, screen)
+	}
+
+	for _, o := range a.overlays() {
+		if *o.active && o.render != nil {
+			frame += o.render(screen)
+		}
 	}
 
 	// Render browser overlay if active.
 	if a.browser.Active {
-		frame += a.renderer.RenderBrowser(a.browser, a.viewport)
+		frame += a.renderer.RenderBrowser(a.browser, screen)
 	}
 
-	// Render column adjuster overlay if active.
-	if a.columnAdjust.Active {
-		frame += a.renderer.RenderColumnAdjust(a.columnAdjust, a.viewport)
+	// Render the leader menu if active.
+	if a.leaderMenu.Active {
+		frame += a.renderer.RenderLeaderMenu(a.leaderMenu, screen)
 	}
 
-	os.Stdout.WriteString("\x1b[?2026h" + frame + "\x1b[?2026l")
+	if a.renderer.lowBandwidth {
+		// Without synchronized output a frame can tear, but it is a few rows
+		// at most and the bracketing is sent on every keystroke.
+		a.terminal.WriteString(a.renderer.FlushRows() + frame)
+		return
+	}
+	a.terminal.WriteString("\x1b[?2026h" + a.renderer.FlushRows() + frame + "\x1b[?2026l")
 }
 
 // toggleSpellCheck toggles spell checking on/off globally.
 func (a *App) toggleSpellCheck() {
+	if !a.spellCheckEnabled && a.spellChecker == nil && a.spellLoading == nil {
+		a.statusBar.SetMessage("Spell check unavailable")
+		return
+	}
 	a.spellCheckEnabled = !a.spellCheckEnabled
 
-	if a.spellCheckEnabled {
+	if a.spellCheckEnabled && a.spellChecker == nil {
+		// The buffers are checked when the spell checker has loaded.
+		a.statusBar.SetMessage("Spell check enabled; loading the dictionary")
+	} else if a.spellCheckEnabled {
 		// Turning on: run spell check on all appropriate buffers.
 		for _, eb := range a.buffers {
 			if eb.ShouldSpellCheck() {
-				eb.spellErrors = nil
-				for i := 0; i < len(eb.buf.Lines); i++ {
-					lineErrors := a.spellChecker.CheckLine(i, eb.buf.Lines[i])
-					eb.spellErrors = append(eb.spellErrors, lineErrors...)
-				}
+				a.loadBibliography(eb)
+				eb.spellErrors = eb.checkLines(a.spellChecker)
 			}
 		}
 		a.statusBar.SetMessage("Spell check enabled")
@@ -1680,3 +2589,117 @@ func (a *App) toggleSpellCheck() {
 func formatBufferInfo(current, total int) string {
 	return fmt.Sprintf("[%d/%d]", current, total)
 }
+
+// replaceLines swaps count buffer lines starting at start for newLines,
+// recording a single undo operation.
+func (a *App) replaceLines(start, count int, newLines []string) {
+	eb := a.currentBuf()
+	oldLines := make([]string, count)
+	copy(oldLines, eb.buf.Lines[start:start+count])
+	eb.undo.PushReplaceLines(start, oldLines, newLines, eb.cursorLine, eb.cursorCol)
+	replaceLines(eb.buf, start, count, newLines)
+	eb.ScheduleSpellCheck()
+}
+
+// replaceTable swaps the table spanning start..end for newLines, unless the
+// text is unchanged, and places the cursor at the start of the given cell.
+func (a *App) replaceTable(start, end int, newLines []string, cursorLine, cell int) {
+	eb := a.currentBuf()
+	changed := len(newLines) != end-start+1
+	for i := 0; !changed && i < len(newLines); i++ {
+		changed = newLines[i] != eb.buf.Lines[start+i]
+	}
+	if changed {
+		a.replaceLines(start, end-start+1, newLines)
+	}
+	eb.cursorLine = cursorLine
+	if col := TableCellStart(eb.buf.Lines[cursorLine], cell); col >= 0 {
+		eb.cursorCol = col
+	}
+}
+
+// alignTableAtCursor pads the columns of the table under the cursor, keeping
+// the cursor in the same cell. Returns false if the cursor is not in a table.
+func (a *App) alignTableAtCursor() bool {
+	eb := a.currentBuf()
+	start, end, ok := TableBounds(eb.buf, eb.cursorLine)
+	if !ok {
+		return false
+	}
+	lines := eb.buf.Lines[start : end+1]
+	cell := TableCellAt(eb.buf.Lines[eb.cursorLine], eb.cursorCol)
+	if cols := TableColumnCount(lines); cell >= cols {
+		cell = cols - 1
+	}
+	a.replaceTable(start, end, AlignTable(lines), eb.cursorLine, cell)
+	return true
+}
+
+// moveTableCell aligns the table and moves the cursor delta cells along,
+// wrapping between rows and skipping the separator row. Moving past the last
+// cell appends a new row.
+func (a *App) moveTableCell(delta int) {
+	eb := a.currentBuf()
+	start, end, ok := TableBounds(eb.buf, eb.cursorLine)
+	if !ok {
+		return
+	}
+	lines := eb.buf.Lines[start : end+1]
+	cols := TableColumnCount(lines)
+	line := eb.cursorLine
+	cell := TableCellAt(eb.buf.Lines[line], eb.cursorCol) + delta
+
+	aligned := AlignTable(lines)
+	for {
+		if cell >= cols {
+			cell = 0
+			line++
+		} else if cell < 0 {
+			cell = cols - 1
+			line--
+		}
+		if line < start {
+			line, cell = start, 0
+			break
+		}
+		if line > end {
+			aligned = InsertTableRow(aligned, end-start)
+			end++
+			break
+		}
+		if !isSeparatorRow(SplitTableRow(aligned[line-start])) {
+			break
+		}
+		line += delta
+	}
+	a.replaceTable(start, start+len(lines)-1, aligned, line, cell)
+}
+
+// insertTableRow adds an empty row below the cursor's row.
+func (a *App) insertTableRow() {
+	eb := a.currentBuf()
+	start, end, ok := TableBounds(eb.buf, eb.cursorLine)
+	if !ok {
+		a.statusBar.SetMessage("Not in a table")
+		return
+	}
+	lines := InsertTableRow(eb.buf.Lines[start:end+1], eb.cursorLine-start)
+	newLine := eb.cursorLine + 1
+	if newLine-start < len(lines) && isSeparatorRow(SplitTableRow(lines[newLine-start])) {
+		newLine++
+	}
+	a.replaceTable(start, end, lines, newLine, 0)
+}
+
+// insertTableColumn adds an empty column to the right of the cursor's cell.
+func (a *App) insertTableColumn() {
+	eb := a.currentBuf()
+	start, end, ok := TableBounds(eb.buf, eb.cursorLine)
+	if !ok {
+		a.statusBar.SetMessage("Not in a table")
+		return
+	}
+	cell := TableCellAt(eb.buf.Lines[eb.cursorLine], eb.cursorCol)
+	lines := InsertTableColumn(eb.buf.Lines[start:end+1], cell)
+	a.replaceTable(start, end, lines, eb.cursorLine, cell+1)
+}
@@ -0,0 +1,49 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// queuedInput returns a pending-event source that yields events in order.
+func queuedInput(events ...terminal.InputEvent) func() (terminal.InputEvent, bool) {
+	return func() (terminal.InputEvent, bool) {
+		if len(events) == 0 {
+			return terminal.InputEvent{}, false
+		}
+		e := events[0]
+		events = events[1:]
+		return e, true
+	}
+}
+
+func runeEvents(s string) []terminal.InputEvent {
+	var events []terminal.InputEvent
+	for _, r := range s {
+		events = append(events, terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyRune, Rune: r}})
+	}
+	return events
+}
+
+func TestDrainInput(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.mode = ModeEdit
+	a.drainInput(queuedInput(runeEvents("pasted text")...))
+	if got := a.currentBuf().buf.Lines[0]; got != "pasted text" {
+		t.Errorf("every pending event should be handled, got %q", got)
+	}
+}
+
+func TestDrainInputStopsOnQuit(t *testing.T) {
+	a := newTestApp("draft.md")
+	events := append(runeEvents(":q"), terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyEnter}})
+	events = append(events, runeEvents("ihello")...)
+	a.drainInput(queuedInput(events...))
+	if !a.quit {
+		t.Fatal(":q should quit")
+	}
+	if got := a.currentBuf().buf.Lines[0]; got != "" {
+		t.Errorf("events after quitting should not be handled, got %q", got)
+	}
+}
@@ -0,0 +1,136 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+// The operations that run on every keystroke or search, timed by the Go
+// benchmarks in bench_test.go and by the hidden :bench command, so there is
+// a baseline to measure performance work against.
+
+// benchParagraph is the text synthetic documents are built from. It has a
+// couple of misspellings so spell checking finds something.
+const benchParagraph = "The harbour lay quiet under a grey sky, and the boats that had come " +
+	"in before dawn rocked against their moorings. Nobody on the quay spoke " +
+	"of the storm, though everyone had heard it in the night; the wind had " +
+	"torn slates from the chandler's roof and left the recieved wisdom about " +
+	"safe anchorage looking forlorn. Somewhere a gull complaned."
+
+// benchDocument returns a synthetic Markdown document of about n lines:
+// a heading every ten lines, long paragraphs that wrap, and list items.
+func benchDocument(n int) []string {
+	lines := make([]string, 0, n)
+	for i := 0; len(lines) < n; i++ {
+		switch {
+		case i%10 == 0:
+			lines = append(lines, fmt.Sprintf("## Section %d", i/10+1))
+		case i%10 == 7:
+			lines = append(lines, "- An item with **bold** and _emphasis_ in it")
+		case i%2 == 0:
+			lines = append(lines, "")
+		default:
+			lines = append(lines, benchParagraph)
+		}
+	}
+	return lines
+}
+
+// benchSetup is what the timed operations work on.
+type benchSetup struct {
+	eb       *EditorBuffer
+	vp       *Viewport
+	renderer *Renderer
+	checker  *spell.SpellChecker // nil skips spell checking
+	dls      []DisplayLine
+}
+
+// newBenchSetup prepares a copy of lines in a buffer, so timing never
+// touches the document being edited.
+func newBenchSetup(lines []string, checker *spell.SpellChecker) *benchSetup {
+	eb := NewEditorBuffer("bench.md")
+	eb.buf.Lines = append([]string(nil), lines...)
+	vp := NewViewport(120, 40)
+	return &benchSetup{
+		eb:       eb,
+		vp:       vp,
+		renderer: NewRenderer(),
+		checker:  checker,
+		dls:      WrapBuffer(eb.buf, vp.ColWidth),
+	}
+}
+
+// benchOp is one timed operation. i counts the runs, so operations on a
+// single line can move through the document.
+type benchOp struct {
+	name string
+	run  func(s *benchSetup, i int)
+}
+
+var benchOps = []benchOp{
+	{"wrap", func(s *benchSetup, i int) {
+		WrapBuffer(s.eb.buf, s.vp.ColWidth)
+	}},
+	{"rewrap", func(s *benchSetup, i int) {
+		// Wrapping again after typing on one line, as each keystroke does.
+		line := i % len(s.eb.buf.Lines)
+		text := s.eb.buf.Lines[line]
+		s.eb.buf.Lines[line] = "x" + text
+		s.eb.wraps.wrap(s.eb.buf, s.vp.ColWidth)
+		s.eb.buf.Lines[line] = text
+	}},
+	{"render", func(s *benchSetup, i int) {
+		scroll := len(s.dls) / 2
+		s.renderer.RenderFrame(s.dls, s.vp, scroll, scroll, 0, " bench.md", "DEFAULT ", s.eb.highlighter, nil, ModeDefault, -1, -1, false, nil, 0)
+	}},
+	{"spell", func(s *benchSetup, i int) {
+		if s.checker != nil {
+			line := i % len(s.eb.buf.Lines)
+			s.checker.CheckLine(line, s.eb.buf.Lines[line])
+		}
+	}},
+	{"newline", func(s *benchSetup, i int) {
+		// Splitting a line and joining it again, which move every line
+		// after it.
+		line := i % len(s.eb.buf.Lines)
+		s.eb.buf.InsertNewline(line, 0)
+		s.eb.buf.JoinLines(line)
+	}},
+	{"search", func(s *benchSetup, i int) {
+		findMatches(s.eb.buf.Lines, "the")
+	}},
+	{"undo", func(s *benchSetup, i int) {
+		line := i % len(s.eb.buf.Lines)
+		s.eb.buf.InsertChar(line, 0, 'x')
+		s.eb.undo.PushInsertChar(line, 0, 'x')
+		s.eb.undo.Undo(s.eb.buf)
+	}},
+}
+
+// timeOp runs op repeatedly for about d and returns the mean time per run.
+func timeOp(op benchOp, s *benchSetup, d time.Duration) time.Duration {
+	start := time.Now()
+	n := 0
+	for time.Since(start) < d {
+		op.run(s, n)
+		n++
+	}
+	return time.Since(start) / time.Duration(n)
+}
+
+// bench runs :bench, timing each operation on a copy of the current buffer
+// and showing the mean per run. Spell checking and undo are per line.
+func (a *App) bench() {
+	s := newBenchSetup(a.currentBuf().buf.Lines, a.spellChecker)
+	parts := make([]string, 0, len(benchOps))
+	for _, op := range benchOps {
+		if op.name == "spell" && s.checker == nil {
+			continue
+		}
+		parts = append(parts, op.name+" "+timeOp(op, s, 100*time.Millisecond).Round(time.Microsecond/10).String())
+	}
+	a.statusBar.SetMessage(strings.Join(parts, "  "))
+}
@@ -0,0 +1,55 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+func TestBenchDocument(t *testing.T) {
+	lines := benchDocument(100)
+	if len(lines) != 100 {
+		t.Fatalf("got %d lines, want 100", len(lines))
+	}
+	if lines[0] != "## Section 1" || lines[10] != "## Section 2" {
+		t.Errorf("headings should start each ten lines: %q, %q", lines[0], lines[10])
+	}
+	if dls := WrapBuffer(&Buffer{Lines: lines}, 60); len(dls) <= len(lines) {
+		t.Error("paragraphs should be long enough to wrap")
+	}
+}
+
+func TestBenchCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.currentBuf().buf.Lines = []string{"Some text to time."}
+	a.executeCommand("bench")
+	for _, name := range []string{"wrap ", "rewrap ", "render ", "newline ", "search ", "undo "} {
+		if !strings.Contains(a.statusBar.StatusMessage, name) {
+			t.Errorf("missing %q timing: %q", name, a.statusBar.StatusMessage)
+		}
+	}
+	if lines := a.currentBuf().buf.Lines; len(lines) != 1 || lines[0] != "Some text to time." {
+		t.Errorf(":bench should not change the buffer: %q", lines)
+	}
+}
+
+// BenchmarkOps times each of benchOps on synthetic documents of increasing
+// length, e.g. go test -bench Ops/search ./internal/editor.
+func BenchmarkOps(b *testing.B) {
+	checker, err := spell.NewSpellChecker()
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, n := range []int{1000, 10000, 50000} {
+		s := newBenchSetup(benchDocument(n), checker)
+		for _, op := range benchOps {
+			b.Run(fmt.Sprintf("%s/%d", op.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					op.run(s, i)
+				}
+			})
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 )
 
 // Browser manages the directory browser overlay state.
@@ -13,6 +14,29 @@ type Browser struct {
 	Selected     int
 	ScrollOffset int
 	CurrentDir   string
+
+	DeletePending bool // D was pressed, awaiting y to delete the selection
+
+	// Filters, kept while prose runs. By default dotfiles, files ignored
+	// by git and files that aren't prose are left out.
+	ShowHidden   bool // List dotfiles
+	ShowIgnored  bool // List files matched by .gitignore
+	ShowAllFiles bool // List files of every type, not just prose
+	Filtered     int  // Entries the filters left out of Items
+}
+
+// proseExtensions are the file types the browser lists by default.
+var proseExtensions = []string{".md", ".markdown", ".txt"}
+
+// isProseFile reports whether name has one of proseExtensions.
+func isProseFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range proseExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
 }
 
 // BrowserItem represents a file or directory entry.
@@ -36,14 +60,24 @@ func (b *Browser) Show(directory string) error {
 		return err
 	}
 
-	// Convert to BrowserItems.
+	// Convert to BrowserItems, leaving out what the filters hide.
+	var rules []ignoreRule
+	if !b.ShowIgnored {
+		rules = gitignoreRules(absDir)
+	}
 	items := make([]BrowserItem, 0, len(entries))
+	b.Filtered = 0
 	for _, entry := range entries {
-		items = append(items, BrowserItem{
+		item := BrowserItem{
 			Name:  entry.Name(),
 			Path:  filepath.Join(absDir, entry.Name()),
 			IsDir: entry.IsDir(),
-		})
+		}
+		if !b.shows(item, rules) {
+			b.Filtered++
+			continue
+		}
+		items = append(items, item)
 	}
 
 	// Sort: directories first (alphabetically), then files (alphabetically).
@@ -63,6 +97,17 @@ func (b *Browser) Show(directory string) error {
 	return nil
 }
 
+// shows reports whether the filters let item be listed.
+func (b *Browser) shows(item BrowserItem, rules []ignoreRule) bool {
+	if !b.ShowHidden && strings.HasPrefix(item.Name, ".") {
+		return false
+	}
+	if !b.ShowAllFiles && !item.IsDir && !isProseFile(item.Name) {
+		return false
+	}
+	return !gitignored(rules, item.Path, item.IsDir)
+}
+
 // Hide deactivates the browser.
 func (b *Browser) Hide() {
 	b.Active = false
@@ -70,6 +115,7 @@ func (b *Browser) Hide() {
 	b.Selected = 0
 	b.ScrollOffset = 0
 	b.CurrentDir = ""
+	b.DeletePending = false
 }
 
 // MoveUp moves the selection up, adjusting scroll offset if needed.
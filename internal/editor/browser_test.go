@@ -3,6 +3,7 @@ package editor
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -314,3 +315,48 @@ func TestBrowserNavigateToParent(t *testing.T) {
 		t.Errorf("expected 2 items in parent directory, got %d", len(b.Items))
 	}
 }
+
+func TestBrowserFilters(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"chapter.md", "notes.txt", "cover.png", ".draft.md", "build/out.md", "keep.log"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("content"), 0644)
+	}
+	os.Mkdir(filepath.Join(dir, ".git"), 0755)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("# Output\nbuild/\n*.log\n!keep.log\n"), 0644)
+
+	names := func(b *Browser) []string {
+		var got []string
+		for _, item := range b.Items {
+			got = append(got, item.Name)
+		}
+		return got
+	}
+	tests := []struct {
+		name     string
+		set      func(b *Browser)
+		want     []string
+		filtered int
+	}{
+		{"default", func(b *Browser) {}, []string{"chapter.md", "notes.txt"}, 6},
+		{"hidden", func(b *Browser) { b.ShowHidden = true }, []string{".git", ".draft.md", "chapter.md", "notes.txt"}, 4},
+		{"ignored", func(b *Browser) { b.ShowIgnored = true }, []string{"build", "chapter.md", "notes.txt"}, 5},
+		{"all files", func(b *Browser) { b.ShowAllFiles = true }, []string{"chapter.md", "cover.png", "keep.log", "notes.txt"}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Browser{}
+			tt.set(b)
+			if err := b.Show(dir); err != nil {
+				t.Fatal(err)
+			}
+			if got := names(b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listed %v, want %v", got, tt.want)
+			}
+			if b.Filtered != tt.filtered {
+				t.Errorf("Filtered = %d, want %d", b.Filtered, tt.filtered)
+			}
+		})
+	}
+}
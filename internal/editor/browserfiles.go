@@ -0,0 +1,185 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// File management from the directory browser: a creates a file (or a
+// directory, given a name ending in /), r renames the selection and D
+// deletes it after asking.
+
+// browserTarget resolves a name typed in the browser against its
+// directory. Names may not leave the directory.
+func (a *App) browserTarget(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("no name given")
+	}
+	path := filepath.Join(a.browser.CurrentDir, name)
+	if rel, err := filepath.Rel(a.browser.CurrentDir, path); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside this directory", name)
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", name)
+	}
+	return path, nil
+}
+
+// refreshBrowser reads the browser's directory again, selecting the entry
+// at path if it is listed.
+func (a *App) refreshBrowser(path string) {
+	if err := a.browser.Show(a.browser.CurrentDir); err != nil {
+		a.statusBar.SetError("Error opening directory: " + err.Error())
+		a.browser.Hide()
+		return
+	}
+	for i, item := range a.browser.Items {
+		if item.Path == path {
+			a.browser.Selected = i
+			return
+		}
+	}
+}
+
+// createBrowserFile creates an empty file, or a directory if name ends in
+// a slash, in the browser's directory.
+func (a *App) createBrowserFile(name string) {
+	name = strings.TrimSpace(name)
+	path, err := a.browserTarget(name)
+	if err != nil {
+		a.statusBar.SetError("Create failed: " + err.Error())
+		return
+	}
+	if strings.HasSuffix(name, "/") {
+		err = os.MkdirAll(path, 0755)
+	} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err == nil {
+			err = f.Close()
+		}
+	}
+	if err != nil {
+		a.statusBar.SetError("Create failed: " + err.Error())
+		return
+	}
+	// A file in a new subdirectory is listed by its directory.
+	rel, _ := filepath.Rel(a.browser.CurrentDir, path)
+	top, _, _ := strings.Cut(rel, string(filepath.Separator))
+	a.refreshBrowser(filepath.Join(a.browser.CurrentDir, top))
+	a.statusBar.SetMessage("Created " + name)
+}
+
+// renameBrowserItem renames the browser's selection, moving any open
+// buffers of the file, or of files inside the directory, along with it.
+func (a *App) renameBrowserItem(name string) {
+	item := a.browser.SelectedItem()
+	if item == nil {
+		return
+	}
+	path, err := a.browserTarget(name)
+	if err == nil {
+		err = os.Rename(item.Path, path)
+	}
+	if err != nil {
+		a.statusBar.SetError("Rename failed: " + err.Error())
+		return
+	}
+	a.fileMoved(item.Path, path)
+	a.refreshBrowser(path)
+	a.statusBar.SetMessage("Renamed " + item.Name + " to " + filepath.Base(path))
+}
+
+// fileMoved points buffers open on oldPath, or on files under it if it was
+// a directory, at the new location, as :rename does.
+func (a *App) fileMoved(oldPath, newPath string) {
+	for _, eb := range a.buffers {
+		if eb.buf.Filename == "" || eb.isScratch {
+			continue
+		}
+		abs := absPath(eb.buf.Filename)
+		var moved string
+		switch {
+		case abs == oldPath:
+			moved = newPath
+		case strings.HasPrefix(abs, oldPath+string(filepath.Separator)):
+			moved = newPath + abs[len(oldPath):]
+		default:
+			continue
+		}
+		moveUndoHistory(eb.buf.Filename, moved)
+		eb.buf.Filename = moved
+		eb.highlighter = DetectHighlighter(eb.buf.Filename)
+		a.relockBuffer(eb)
+	}
+}
+
+// deleteBrowserItem deletes the browser's selection. Directories must be
+// empty. Buffers open on a deleted file keep its text, marked unsaved.
+func (a *App) deleteBrowserItem() {
+	item := a.browser.SelectedItem()
+	if item == nil {
+		return
+	}
+	if err := os.Remove(item.Path); err != nil {
+		if item.IsDir {
+			a.statusBar.SetError("Delete failed: only empty directories can be deleted")
+		} else {
+			a.statusBar.SetError("Delete failed: " + err.Error())
+		}
+		return
+	}
+	for _, eb := range a.buffers {
+		if eb.buf.Filename != "" && !eb.isScratch && absPath(eb.buf.Filename) == item.Path {
+			eb.buf.Dirty = true
+		}
+	}
+	name, selected := item.Name, a.browser.Selected
+	a.refreshBrowser("")
+	if a.browser.Active && len(a.browser.Items) == 0 && a.browser.Filtered == 0 {
+		a.browser.Hide()
+		a.statusBar.SetMessage("Deleted " + name + "; directory is empty")
+		return
+	}
+	a.browser.Selected = min(selected, len(a.browser.Items)-1)
+	a.statusBar.SetMessage("Deleted " + name)
+}
+
+// handleBrowserFileKey handles the browser's file management keys,
+// returning false for any other key.
+func (a *App) handleBrowserFileKey(key terminal.Key) bool {
+	if a.browser.DeletePending {
+		a.browser.DeletePending = false
+		if key.Type == terminal.KeyRune && key.Rune == 'y' {
+			a.deleteBrowserItem()
+		} else {
+			a.statusBar.SetMessage("Delete cancelled")
+		}
+		return true
+	}
+	if key.Type != terminal.KeyRune {
+		return false
+	}
+	switch key.Rune {
+	case 'a':
+		a.statusBar.StartPrompt(PromptNewFile)
+	case 'r':
+		if item := a.browser.SelectedItem(); item != nil {
+			a.statusBar.StartPrompt(PromptRenameFile)
+			a.statusBar.PromptText = item.Name
+		}
+	case 'D':
+		if item := a.browser.SelectedItem(); item != nil {
+			a.browser.DeletePending = true
+			a.statusBar.SetMessage("Delete " + item.Name + "? (y/n)")
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// newBrowserTestApp writes files into a temporary directory and opens the
+// browser on it.
+func newBrowserTestApp(t *testing.T, files ...string) (*App, string) {
+	t.Helper()
+	a, dir := newTempTestApp(t, files[0])
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("text\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.showBrowser()
+	return a, dir
+}
+
+func selectBrowserItem(t *testing.T, a *App, name string) {
+	t.Helper()
+	for i, item := range a.browser.Items {
+		if item.Name == name {
+			a.browser.Selected = i
+			return
+		}
+	}
+	t.Fatalf("%s is not listed", name)
+}
+
+func TestBrowserCreateFile(t *testing.T) {
+	a, dir := newBrowserTestApp(t, "one.md")
+	sendKeys(a, "atwo.md")
+	if a.statusBar.Prompt != PromptNewFile || !a.browser.Active {
+		t.Fatal("a should prompt for a name with the browser open")
+	}
+	sendKey(a, terminal.KeyEnter)
+	if _, err := os.Stat(filepath.Join(dir, "two.md")); err != nil {
+		t.Fatalf("two.md should be created: %v", err)
+	}
+	if item := a.browser.SelectedItem(); item == nil || item.Name != "two.md" {
+		t.Errorf("the new file should be listed and selected, got %+v", item)
+	}
+
+	sendKeys(a, "adrafts/")
+	sendKey(a, terminal.KeyEnter)
+	if info, err := os.Stat(filepath.Join(dir, "drafts")); err != nil || !info.IsDir() {
+		t.Errorf("a name ending in / should create a directory: %v", err)
+	}
+
+	sendKeys(a, "aone.md")
+	sendKey(a, terminal.KeyEnter)
+	if a.statusBar.StatusMessage != "Create failed: one.md already exists" {
+		t.Errorf("existing files should be kept, got %q", a.statusBar.StatusMessage)
+	}
+	sendKeys(a, "a../escape.md")
+	sendKey(a, terminal.KeyEnter)
+	if !strings.Contains(a.statusBar.StatusMessage, "outside this directory") {
+		t.Errorf("names should stay in the directory, got %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestBrowserRenameUpdatesBuffers(t *testing.T) {
+	a, dir := newBrowserTestApp(t, "one.md", "part/two.md")
+	a.currentBuffer = a.openBuffer(filepath.Join(dir, "part", "two.md"))
+	a.showBrowser()
+	a.navigateToParentDirectory()
+
+	selectBrowserItem(t, a, "one.md")
+	sendKeys(a, "r")
+	if a.statusBar.PromptText != "one.md" {
+		t.Errorf("the rename prompt should start with the name, got %q", a.statusBar.PromptText)
+	}
+	a.statusBar.PromptText = "first.md"
+	sendKey(a, terminal.KeyEnter)
+	if got := a.buffers[0].buf.Filename; got != filepath.Join(dir, "first.md") {
+		t.Errorf("the open buffer should follow the file, got %q", got)
+	}
+
+	selectBrowserItem(t, a, "part")
+	sendKeys(a, "r")
+	a.statusBar.PromptText = "chapters"
+	sendKey(a, terminal.KeyEnter)
+	if got := a.buffers[1].buf.Filename; got != filepath.Join(dir, "chapters", "two.md") {
+		t.Errorf("buffers inside a renamed directory should follow it, got %q", got)
+	}
+	if item := a.browser.SelectedItem(); item == nil || item.Name != "chapters" {
+		t.Errorf("the renamed directory should be selected, got %+v", item)
+	}
+}
+
+func TestBrowserDelete(t *testing.T) {
+	a, dir := newBrowserTestApp(t, "one.md", "two.md", "full/three.md")
+	selectBrowserItem(t, a, "two.md")
+	sendKeys(a, "Dn")
+	if _, err := os.Stat(filepath.Join(dir, "two.md")); err != nil || a.statusBar.StatusMessage != "Delete cancelled" {
+		t.Fatalf("any key but y should cancel: %v, %q", err, a.statusBar.StatusMessage)
+	}
+
+	sendKeys(a, "Dy")
+	if _, err := os.Stat(filepath.Join(dir, "two.md")); !os.IsNotExist(err) {
+		t.Errorf("two.md should be deleted: %v", err)
+	}
+
+	selectBrowserItem(t, a, "one.md")
+	sendKeys(a, "Dy")
+	if !a.buffers[0].buf.Dirty {
+		t.Error("the buffer of a deleted file should be marked unsaved")
+	}
+
+	selectBrowserItem(t, a, "full")
+	sendKeys(a, "Dy")
+	if _, err := os.Stat(filepath.Join(dir, "full")); err != nil || !strings.Contains(a.statusBar.StatusMessage, "only empty directories") {
+		t.Errorf("a directory with files should be kept: %v, %q", err, a.statusBar.StatusMessage)
+	}
+}
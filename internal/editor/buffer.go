@@ -2,7 +2,9 @@ package editor
 
 import (
 	"os"
+	"slices"
 	"strings"
+	"time"
 )
 
 // Buffer holds the text content as a slice of lines (hard lines, split on \n).
@@ -10,6 +12,19 @@ type Buffer struct {
 	Lines    []string
 	Dirty    bool
 	Filename string
+	CRLF     bool // Lines end in \r\n on disk, as in Windows files
+	BOM      bool // The file starts with a UTF-8 byte order mark
+
+	disk diskSnapshot // The file as last loaded or saved
+	kept diskSnapshot // The stat of a change on disk the buffer was kept over
+}
+
+// diskSnapshot records a file's text and stat as prose last saw it, so
+// changes made by other programs can be noticed and merged.
+type diskSnapshot struct {
+	lines   []string
+	modTime time.Time
+	size    int64
 }
 
 func NewBuffer(filename string) *Buffer {
@@ -33,18 +48,49 @@ func (b *Buffer) Load() error {
 		}
 		return err
 	}
-	text := string(data)
-	// Strip trailing newline to avoid a phantom empty line.
-	text = strings.TrimSuffix(text, "\n")
-	if text == "" {
-		b.Lines = []string{""}
-	} else {
-		b.Lines = strings.Split(text, "\n")
-	}
+	b.Lines, b.CRLF, b.BOM = decodeFileText(data)
 	b.Dirty = false
+	b.recordDisk()
 	return nil
 }
 
+// splitFileText splits a file's contents into lines.
+func splitFileText(data []byte) []string {
+	lines, _, _ := decodeFileText(data)
+	return lines
+}
+
+// recordDisk notes the file as it now is on disk, matching the buffer.
+func (b *Buffer) recordDisk() {
+	b.disk = diskSnapshot{lines: slices.Clone(b.Lines)}
+	if info, err := os.Stat(b.Filename); err == nil {
+		b.disk.modTime = info.ModTime()
+		b.disk.size = info.Size()
+	}
+}
+
+// ChangedOnDisk reports whether another program has written the file since
+// it was last loaded or saved. A file that has been deleted has not changed.
+func (b *Buffer) ChangedOnDisk() bool {
+	if b.Filename == "" {
+		return false
+	}
+	info, err := os.Stat(b.Filename)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Equal(b.disk.modTime) || info.Size() != b.disk.size
+}
+
+// ReadDisk returns the file's current lines without changing the buffer.
+func (b *Buffer) ReadDisk() ([]string, error) {
+	data, err := os.ReadFile(b.Filename)
+	if err != nil {
+		return nil, err
+	}
+	return splitFileText(data), nil
+}
+
 // Save writes the buffer to the given filename (or current filename).
 func (b *Buffer) Save(filename string) error {
 	if filename != "" {
@@ -53,12 +99,12 @@ func (b *Buffer) Save(filename string) error {
 	if b.Filename == "" {
 		return nil // Caller should prompt for a name.
 	}
-	content := strings.Join(b.Lines, "\n") + "\n"
-	err := os.WriteFile(b.Filename, []byte(content), 0644)
+	err := os.WriteFile(b.Filename, encodeFileText(b.Lines, b.CRLF, b.BOM), 0644)
 	if err != nil {
 		return err
 	}
 	b.Dirty = false
+	b.recordDisk()
 	return nil
 }
 
@@ -125,12 +171,7 @@ func (b *Buffer) InsertNewline(line, col int) {
 	before := string(runes[:col])
 	after := string(runes[col:])
 	b.Lines[line] = before
-	// Insert new line after.
-	newLines := make([]string, 0, len(b.Lines)+1)
-	newLines = append(newLines, b.Lines[:line+1]...)
-	newLines = append(newLines, after)
-	newLines = append(newLines, b.Lines[line+1:]...)
-	b.Lines = newLines
+	b.Lines = slices.Insert(b.Lines, line+1, after)
 	b.Dirty = true
 }
 
@@ -144,6 +185,66 @@ func (b *Buffer) JoinLines(idx int) {
 	b.Dirty = true
 }
 
+// InsertText inserts text, which may span several lines, at the given
+// position. Returns the position just after the inserted text.
+func (b *Buffer) InsertText(line, col int, text string) (int, int) {
+	if line < 0 || line >= len(b.Lines) {
+		return line, col
+	}
+	runes := []rune(b.Lines[line])
+	col = max(0, min(col, len(runes)))
+	parts := strings.Split(text, "\n")
+	after := string(runes[col:])
+	parts[0] = string(runes[:col]) + parts[0]
+	endLine := line + len(parts) - 1
+	endCol := len([]rune(parts[len(parts)-1]))
+	parts[len(parts)-1] += after
+	b.Lines = slices.Replace(b.Lines, line, line+1, parts...)
+	b.Dirty = true
+	return endLine, endCol
+}
+
+// DeleteText removes the text from (startLine, startCol) up to, but not
+// including, (endLine, endCol), joining lines as needed. Returns the removed
+// text with line breaks as "\n".
+func (b *Buffer) DeleteText(startLine, startCol, endLine, endCol int) string {
+	if startLine < 0 || endLine >= len(b.Lines) || startLine > endLine {
+		return ""
+	}
+	first := []rune(b.Lines[startLine])
+	last := []rune(b.Lines[endLine])
+	startCol = max(0, min(startCol, len(first)))
+	endCol = max(0, min(endCol, len(last)))
+	if startLine == endLine && startCol >= endCol {
+		return ""
+	}
+
+	var removed string
+	if startLine == endLine {
+		removed = string(first[startCol:endCol])
+	} else {
+		parts := []string{string(first[startCol:])}
+		parts = append(parts, b.Lines[startLine+1:endLine]...)
+		parts = append(parts, string(last[:endCol]))
+		removed = strings.Join(parts, "\n")
+	}
+
+	joined := string(first[:startCol]) + string(last[endCol:])
+	b.Lines = append(b.Lines[:startLine+1], b.Lines[endLine+1:]...)
+	b.Lines[startLine] = joined
+	b.Dirty = true
+	return removed
+}
+
+// textEnd returns the position just after text when it starts at (line, col).
+func textEnd(line, col int, text string) (int, int) {
+	parts := strings.Split(text, "\n")
+	if len(parts) == 1 {
+		return line, col + len([]rune(text))
+	}
+	return line + len(parts) - 1, len([]rune(parts[len(parts)-1]))
+}
+
 // LineLen returns the rune-length of a given line.
 func (b *Buffer) LineLen(line int) int {
 	if line < 0 || line >= len(b.Lines) {
@@ -157,11 +258,13 @@ func (b *Buffer) LineCount() int {
 	return len(b.Lines)
 }
 
-// WordCount returns the total number of words across all lines.
+// WordCount returns the total number of words across all lines. Text with
+// CriticMarkup revision marks is counted as if every change were accepted,
+// and review comments are not counted.
 func (b *Buffer) WordCount() int {
 	count := 0
 	for _, line := range b.Lines {
-		count += len(strings.Fields(line))
+		count += len(strings.Fields(stripCriticMarkup(line)))
 	}
 	return count
 }
@@ -190,11 +293,7 @@ func (b *Buffer) InsertLine(line int, content string) {
 	if line > len(b.Lines) {
 		line = len(b.Lines)
 	}
-	newLines := make([]string, 0, len(b.Lines)+1)
-	newLines = append(newLines, b.Lines[:line]...)
-	newLines = append(newLines, content)
-	newLines = append(newLines, b.Lines[line:]...)
-	b.Lines = newLines
+	b.Lines = slices.Insert(b.Lines, line, content)
 	b.Dirty = true
 }
 
@@ -219,6 +219,50 @@ func TestWordCount(t *testing.T) {
 	if got := buf.WordCount(); got != 1 {
 		t.Errorf("single word: expected 1, got %d", got)
 	}
+	buf.Lines = []string{"The {--old--}{++new++} cat{>>really? check<<} sat."}
+	if got := buf.WordCount(); got != 4 {
+		t.Errorf("critic markup: expected 4, got %d", got)
+	}
+}
+
+func TestInsertAndDeleteText(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		line, col  int
+		text       string
+		want       []string
+		wantEndLn  int
+		wantEndCol int
+	}{
+		{"inline", []string{"hello world"}, 0, 6, "big ", []string{"hello big world"}, 0, 10},
+		{"multi-line", []string{"ab", "z"}, 0, 1, "1\n2\n3", []string{"a1", "2", "3b", "z"}, 2, 1},
+		{"trailing newline", []string{"ab"}, 0, 2, "c\n", []string{"abc", ""}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer("")
+			buf.Lines = append([]string(nil), tt.lines...)
+			endLine, endCol := buf.InsertText(tt.line, tt.col, tt.text)
+			if strings.Join(buf.Lines, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("InsertText: lines = %q, want %q", buf.Lines, tt.want)
+			}
+			if endLine != tt.wantEndLn || endCol != tt.wantEndCol {
+				t.Errorf("InsertText end = %d,%d, want %d,%d", endLine, endCol, tt.wantEndLn, tt.wantEndCol)
+			}
+			if el, ec := textEnd(tt.line, tt.col, tt.text); el != endLine || ec != endCol {
+				t.Errorf("textEnd = %d,%d, want %d,%d", el, ec, endLine, endCol)
+			}
+
+			removed := buf.DeleteText(tt.line, tt.col, endLine, endCol)
+			if removed != tt.text {
+				t.Errorf("DeleteText removed %q, want %q", removed, tt.text)
+			}
+			if strings.Join(buf.Lines, "|") != strings.Join(tt.lines, "|") {
+				t.Errorf("DeleteText: lines = %q, want %q", buf.Lines, tt.lines)
+			}
+		})
+	}
 }
 
 func TestSaveAddsTrailingNewline(t *testing.T) {
@@ -0,0 +1,118 @@
+package editor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The change operator deletes its target and enters Edit mode. The deletion
+// and the text typed in its place form one undo step, closed when Edit mode
+// is left.
+
+// changeWordEnd returns the end (exclusive) of what cw changes from col: the
+// rest of the word under the cursor, or the run of spaces or punctuation the
+// cursor is on.
+func changeWordEnd(line string, col int) int {
+	runes := []rune(line)
+	if col >= len(runes) {
+		return len(runes)
+	}
+	class := func(r rune) int {
+		switch {
+		case unicode.IsSpace(r):
+			return 0
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		}
+		return 2
+	}
+	c := class(runes[col])
+	end := col
+	for end < len(runes) && (class(runes[end]) == c || extendsGrapheme(runes[end-1], runes[end])) {
+		end++
+	}
+	return end
+}
+
+// beginChange opens the undo group for a change and enters Edit mode.
+func (a *App) beginChange() {
+	a.currentBuf().undo.BeginGroup()
+	a.mode = ModeEdit
+}
+
+// changeLine empties the current line and starts editing it (cc). With
+// track changes on, the line is marked as deleted and typing goes after it.
+func (a *App) changeLine() {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	a.beginChange()
+	if eb.trackChanges {
+		a.trackedDeleteLine()
+		eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+		return
+	}
+	a.yankBuffer = line
+	a.yankCharwise = false
+	if line != "" {
+		a.replaceLines(eb.cursorLine, 1, []string{""})
+	}
+	eb.cursorCol = 0
+}
+
+// changeWord deletes to the end of the word under the cursor and starts
+// editing there (cw).
+func (a *App) changeWord() {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	start := min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+	end := changeWordEnd(line, start)
+	a.beginChange()
+	if start == end {
+		return
+	}
+	text := string([]rune(line)[start:end])
+	a.yankBuffer = text
+	a.yankCharwise = true
+	if eb.trackChanges {
+		a.trackedDeleteRange(eb.cursorLine, start, eb.cursorLine, end)
+		eb.cursorCol = end + 2*criticDelimLen
+		return
+	}
+	eb.undo.PushDeleteText(eb.cursorLine, start, text, eb.cursorLine, start)
+	eb.buf.DeleteText(eb.cursorLine, start, eb.cursorLine, end)
+	eb.cursorCol = start
+	eb.ScheduleSpellCheck()
+}
+
+// changeSelection deletes the visual selection and starts editing in its
+// place.
+func (a *App) changeSelection() {
+	a.currentBuf().undo.BeginGroup()
+	a.deleteSelection()
+	a.mode = ModeEdit
+}
+
+// changeSelectedLines replaces the selected lines with one empty line and
+// starts editing it.
+func (a *App) changeSelectedLines() {
+	eb := a.currentBuf()
+	start, end := a.getSelectionRange()
+	a.yankBuffer = strings.Join(eb.buf.Lines[start:end+1], "\n")
+	a.yankCharwise = false
+	a.beginChange()
+	if eb.trackChanges {
+		lines := make([]string, 0, end-start+2)
+		for _, line := range eb.buf.Lines[start : end+1] {
+			if line != "" {
+				line = "{--" + line + "--}"
+			}
+			lines = append(lines, line)
+		}
+		a.replaceLines(start, end-start+1, append(lines, ""))
+		eb.cursorLine = end + 1
+	} else {
+		a.replaceLines(start, end-start+1, []string{""})
+		eb.cursorLine = start
+	}
+	eb.cursorCol = 0
+}
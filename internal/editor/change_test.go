@@ -0,0 +1,135 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangeWordEnd(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want int
+	}{
+		{"hello world", 0, 5},
+		{"hello world", 2, 5},
+		{"hello world", 5, 6},
+		{"hello   world", 5, 8},
+		{"end.", 3, 4},
+		{"don't", 0, 3},
+		{"word", 4, 4},
+	}
+	for _, tt := range tests {
+		if got := changeWordEnd(tt.line, tt.col); got != tt.want {
+			t.Errorf("changeWordEnd(%q, %d) = %d, want %d", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestChangeWord(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The quick fox"}
+	eb.cursorCol = 4
+
+	sendKeys(a, "cwslow\x1b")
+	if got := eb.buf.Lines[0]; got != "The slow fox" {
+		t.Fatalf("after cw: %q", got)
+	}
+	if a.yankBuffer != "quick" || !a.yankCharwise {
+		t.Errorf("cw should yank the word, got %q", a.yankBuffer)
+	}
+
+	// The deletion and the typing undo together.
+	a.undoAction()
+	if got := eb.buf.Lines[0]; got != "The quick fox" {
+		t.Errorf("after one undo: %q", got)
+	}
+	a.redoAction()
+	if got := eb.buf.Lines[0]; got != "The slow fox" {
+		t.Errorf("after redo: %q", got)
+	}
+}
+
+func TestChangeLine(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"first", "second draft", "third"}
+	eb.cursorLine, eb.cursorCol = 1, 3
+
+	sendKeys(a, "ccnew\x1b")
+	if got := strings.Join(eb.buf.Lines, "|"); got != "first|new|third" {
+		t.Fatalf("after cc: %q", got)
+	}
+	if a.yankBuffer != "second draft" || a.yankCharwise {
+		t.Errorf("cc should yank the line, got %q", a.yankBuffer)
+	}
+	a.undoAction()
+	if got := strings.Join(eb.buf.Lines, "|"); got != "first|second draft|third" {
+		t.Errorf("after one undo: %q", got)
+	}
+}
+
+func TestChangeCancelledByOtherKey(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"text"}
+	sendKeys(a, "cx")
+	if a.mode != ModeDefault || eb.buf.Lines[0] != "text" {
+		t.Errorf("c then x should do nothing: mode %v, %q", a.mode, eb.buf.Lines[0])
+	}
+}
+
+func TestChangeSelection(t *testing.T) {
+	a, eb := visualApp([]string{"one two three"}, 0, 4, 0, 6)
+	visualKey(a, 'c')
+	if a.mode != ModeEdit {
+		t.Fatalf("mode = %v, want EDIT", a.mode)
+	}
+	sendKeys(a, "2\x1b")
+	if got := eb.buf.Lines[0]; got != "one 2 three" {
+		t.Errorf("after visual c: %q", got)
+	}
+	a.undoAction()
+	if got := eb.buf.Lines[0]; got != "one two three" {
+		t.Errorf("after one undo: %q", got)
+	}
+}
+
+func TestChangeSelectedLines(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"a", "b", "c", "d"}
+	eb.cursorLine = 1
+	sendKeys(a, "Vjcx\x1b")
+	if got := strings.Join(eb.buf.Lines, "|"); got != "a|x|d" {
+		t.Errorf("after line-select c: %q", got)
+	}
+	a.undoAction()
+	if got := strings.Join(eb.buf.Lines, "|"); got != "a|b|c|d" {
+		t.Errorf("after one undo: %q", got)
+	}
+}
+
+func TestChangeWordTracked(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The quick fox"}
+	eb.cursorCol = 4
+	eb.trackChanges = true
+	sendKeys(a, "cwslow\x1b")
+	if got := eb.buf.Lines[0]; got != "The {--quick--}{++slow++} fox" {
+		t.Errorf("tracked cw: %q", got)
+	}
+}
+
+func TestChangeReadOnly(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"text"}
+	eb.readOnly = true
+	sendKeys(a, "cc")
+	if a.mode != ModeDefault || eb.buf.Lines[0] != "text" {
+		t.Errorf("cc on a read-only buffer: mode %v, %q", a.mode, eb.buf.Lines[0])
+	}
+}
@@ -0,0 +1,463 @@
+package editor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+// Citations are Pandoc's: @key, or [@key, p. 4; @other] in brackets. The
+// keys are checked against a bibliography named in the document's front
+// matter ("bibliography: refs.bib") or the project's bibliography setting.
+
+// Citation is one entry of a bibliography.
+type Citation struct {
+	Key    string
+	Author string // Surnames, e.g. "Smith and Jones"
+	Year   string
+	Title  string
+}
+
+// Detail describes the entry for the completion list, e.g.
+// "Smith 2004, A Title".
+func (c Citation) Detail() string {
+	detail := strings.TrimSpace(c.Author + " " + c.Year)
+	if c.Title != "" {
+		if detail != "" {
+			detail += ", "
+		}
+		detail += c.Title
+	}
+	return detail
+}
+
+// Bibliography is a BibTeX or CSL-JSON file's entries.
+type Bibliography struct {
+	Path    string
+	Entries map[string]Citation
+	Keys    []string // Sorted
+	modTime time.Time
+}
+
+// LoadBibliography reads a bibliography: CSL-JSON if the file ends in
+// .json, else BibTeX.
+func LoadBibliography(path string) (*Bibliography, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Citation
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		entries, err = parseCSLJSON(data)
+	} else {
+		entries = parseBibTeX(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	bib := &Bibliography{Path: path, Entries: make(map[string]Citation), modTime: info.ModTime()}
+	for _, c := range entries {
+		if _, ok := bib.Entries[c.Key]; !ok {
+			bib.Keys = append(bib.Keys, c.Key)
+		}
+		bib.Entries[c.Key] = c
+	}
+	sort.Strings(bib.Keys)
+	return bib, nil
+}
+
+// parseBibTeX reads the entries of a BibTeX file. @string, @preamble and
+// @comment blocks are skipped, and only the author, year and title fields
+// are kept.
+func parseBibTeX(text string) []Citation {
+	var entries []Citation
+	for {
+		at := strings.IndexByte(text, '@')
+		if at < 0 {
+			return entries
+		}
+		text = text[at+1:]
+		open := strings.IndexAny(text, "{(")
+		if open < 0 {
+			return entries
+		}
+		kind := strings.ToLower(strings.TrimSpace(text[:open]))
+		if kind == "" || strings.ContainsFunc(kind, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			continue
+		}
+		body, rest := bibBlock(text[open:])
+		text = rest
+		if kind == "string" || kind == "preamble" || kind == "comment" {
+			continue
+		}
+		key, fields, _ := strings.Cut(body, ",")
+		c := Citation{Key: strings.TrimSpace(key)}
+		if c.Key == "" {
+			continue
+		}
+		for name, value := range bibFields(fields) {
+			switch name {
+			case "author", "editor":
+				if c.Author == "" || name == "author" {
+					c.Author = surnames(strings.Split(value, " and "), func(name string) string {
+						if last, _, ok := strings.Cut(name, ","); ok {
+							return last
+						}
+						words := strings.Fields(name)
+						return words[len(words)-1]
+					})
+				}
+			case "year":
+				c.Year = value
+			case "date":
+				if c.Year == "" {
+					c.Year, _, _ = strings.Cut(value, "-")
+				}
+			case "title":
+				c.Title = value
+			}
+		}
+		entries = append(entries, c)
+	}
+}
+
+// bibBlock returns the text inside the braces or parentheses that text
+// opens with, and the text after them.
+func bibBlock(text string) (body, rest string) {
+	closing := byte('}')
+	if text[0] == '(' {
+		closing = ')'
+	}
+	braces := 0
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == closing && braces == 0:
+			return text[1:i], text[i+1:]
+		case c == '{':
+			braces++
+		case c == '}':
+			braces--
+		}
+	}
+	return text[1:], ""
+}
+
+// bibFields reads "name = value" fields separated by commas. Values are
+// in braces, in quotes or bare, and lose their braces and extra spaces.
+func bibFields(text string) map[string]string {
+	fields := make(map[string]string)
+	for {
+		eq := strings.IndexByte(text, '=')
+		if eq < 0 {
+			return fields
+		}
+		name := strings.ToLower(strings.TrimSpace(strings.TrimLeft(text[:eq], ", \t\r\n")))
+		text = strings.TrimLeft(text[eq+1:], " \t\r\n")
+		var value string
+		switch {
+		case text == "":
+			return fields
+		case text[0] == '{':
+			value, text = bibBlock(text)
+		case text[0] == '"':
+			end := strings.IndexByte(text[1:], '"')
+			if end < 0 {
+				end = len(text) - 1
+			}
+			value, text = text[1:end+1], text[min(end+2, len(text)):]
+		default:
+			end := strings.IndexByte(text, ',')
+			if end < 0 {
+				end = len(text)
+			}
+			value, text = text[:end], text[end:]
+		}
+		value = strings.NewReplacer("{", "", "}", "").Replace(value)
+		fields[name] = strings.Join(strings.Fields(value), " ")
+	}
+}
+
+// cslEntry is the part of a CSL-JSON item the completion list shows.
+type cslEntry struct {
+	ID     any    `json:"id"`
+	Title  string `json:"title"`
+	Author []struct {
+		Family  string `json:"family"`
+		Literal string `json:"literal"`
+	} `json:"author"`
+	Issued struct {
+		DateParts [][]any `json:"date-parts"`
+	} `json:"issued"`
+}
+
+// parseCSLJSON reads a CSL-JSON bibliography: an array of items.
+func parseCSLJSON(data []byte) ([]Citation, error) {
+	var items []cslEntry
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	entries := make([]Citation, 0, len(items))
+	for _, item := range items {
+		if item.ID == nil {
+			continue
+		}
+		c := Citation{Key: fmt.Sprint(item.ID), Title: item.Title}
+		names := make([]string, len(item.Author))
+		for i, a := range item.Author {
+			names[i] = a.Family + a.Literal
+		}
+		c.Author = surnames(names, func(name string) string { return name })
+		if parts := item.Issued.DateParts; len(parts) > 0 && len(parts[0]) > 0 {
+			c.Year = fmt.Sprint(parts[0][0])
+		}
+		entries = append(entries, c)
+	}
+	return entries, nil
+}
+
+// surnames joins the authors' surnames, taken from each name by surname,
+// as "Smith", "Smith and Jones" or "Smith et al.".
+func surnames(names []string, surname func(string) string) string {
+	var last []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			last = append(last, strings.TrimSpace(surname(name)))
+		}
+	}
+	switch len(last) {
+	case 0:
+		return ""
+	case 1:
+		return last[0]
+	case 2:
+		return last[0] + " and " + last[1]
+	}
+	return last[0] + " et al."
+}
+
+// citeSpan is a citation key in a line: the key, and the rune columns
+// from its @ to its end.
+type citeSpan struct {
+	Key        string
+	Start, End int
+}
+
+// isCiteKeyRune reports whether r may appear in a citation key. Of the
+// punctuation, only a letter, digit or underscore may end one.
+func isCiteKeyRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || strings.ContainsRune(":.#$%&-+?<>~/", r)
+}
+
+// isCiteKeyEnd reports whether r may end a citation key.
+func isCiteKeyEnd(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// citesAt reports whether the @ at runes[i] can start a citation. Bar the
+// - of [-@key], which leaves out the author, it must not follow anything
+// that could be part of a key, as in an email address.
+func citesAt(runes []rune, i int) bool {
+	return i == 0 || !isCiteKeyRune(runes[i-1]) || runes[i-1] == '-'
+}
+
+// citationKeys finds the citation keys in a line.
+func citationKeys(line string) []citeSpan {
+	var spans []citeSpan
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || !citesAt(runes, i) {
+			continue
+		}
+		end := i + 1
+		for j := i + 1; j < len(runes) && isCiteKeyRune(runes[j]); j++ {
+			if isCiteKeyEnd(runes[j]) {
+				end = j + 1
+			}
+		}
+		if end > i+1 {
+			spans = append(spans, citeSpan{Key: string(runes[i+1 : end]), Start: i, End: end})
+		}
+		i = end - 1
+	}
+	return spans
+}
+
+// citationPrefix returns the start of the citation key being typed before
+// col, at its @, and what has been typed of it.
+func citationPrefix(line string, col int) (start int, prefix string, ok bool) {
+	runes := []rune(line)
+	col = min(col, len(runes))
+	start = col
+	for start > 0 && isCiteKeyRune(runes[start-1]) {
+		start--
+	}
+	if start == 0 || runes[start-1] != '@' || !citesAt(runes, start-1) {
+		return 0, "", false
+	}
+	return start - 1, string(runes[start:col]), true
+}
+
+// frontMatterValue returns the value of key in the document's YAML front
+// matter, or the first item when the value is a list.
+func frontMatterValue(lines []string, key string) string {
+	end := frontMatterEnd(lines)
+	for i := 1; i < end; i++ {
+		name, value, ok := strings.Cut(lines[i], ":")
+		if !ok || strings.TrimSpace(name) != key || strings.HasPrefix(name, " ") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" && i+1 < end {
+			value = strings.TrimPrefix(strings.TrimSpace(lines[i+1]), "- ")
+		}
+		value = strings.TrimPrefix(strings.TrimSuffix(value, "]"), "[")
+		value, _, _ = strings.Cut(value, ",")
+		return strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return ""
+}
+
+// bibliographyPath returns the bibliography the buffer's citations refer
+// to: the one its front matter names, else the project's.
+func (a *App) bibliographyPath(eb *EditorBuffer) string {
+	if name := frontMatterValue(eb.buf.Lines, "bibliography"); name != "" {
+		name = expandHome(name)
+		if !filepath.IsAbs(name) && eb.buf.Filename != "" {
+			name = filepath.Join(filepath.Dir(absPath(eb.buf.Filename)), name)
+		}
+		return name
+	}
+	if a.project != nil {
+		return a.project.Bibliography
+	}
+	return ""
+}
+
+// loadBibliography sets the buffer's bibliography, reading the file again
+// only if it changed. Without one, citations aren't checked.
+func (a *App) loadBibliography(eb *EditorBuffer) error {
+	path := a.bibliographyPath(eb)
+	if path == "" {
+		eb.bib = nil
+		return nil
+	}
+	if eb.bib != nil && eb.bib.Path == path {
+		if info, err := os.Stat(path); err == nil && info.ModTime().Equal(eb.bib.modTime) {
+			return nil
+		}
+	}
+	bib, err := LoadBibliography(path)
+	eb.bib = bib
+	return err
+}
+
+// checkLines spell checks the buffer. Citation keys are not words; with a
+// bibliography, those it lacks are reported as errors instead. In Markdown,
+// math is not checked, and unmatched emphasis and math delimiters are
+// reported with the errors.
+func (eb *EditorBuffer) checkLines(sc *spell.SpellChecker) []spell.SpellError {
+	var errs []spell.SpellError
+	blocks := ComputeBlockStates(eb.buf.Lines)
+	frontMatter := frontMatterEnd(eb.buf.Lines)
+	skip := make(map[int][]lineRange)
+	if IsMarkdownFile(eb.buf.Filename) {
+		math, unmatched := ScanMarkup(eb.buf.Lines)
+		for _, m := range math {
+			skip[m.Line] = append(skip[m.Line], m)
+		}
+		for _, d := range unmatched {
+			errs = append(errs, spell.SpellError{Line: d.Line, StartCol: d.Col, EndCol: d.Col + len(d.Text), Word: d.Text})
+		}
+	}
+	for i, line := range eb.buf.Lines {
+		var spans []citeSpan
+		if i > frontMatter && !blocks[i].InCode && strings.Contains(line, "@") {
+			spans = citationKeys(line)
+			for _, s := range spans {
+				skip[i] = append(skip[i], lineRange{Line: i, Start: s.Start, End: s.End})
+			}
+		}
+		for _, err := range sc.CheckLine(i, line) {
+			skipped := false
+			for _, s := range skip[i] {
+				skipped = skipped || err.StartCol < s.End && err.EndCol > s.Start
+			}
+			if !skipped {
+				errs = append(errs, err)
+			}
+		}
+		if eb.bib == nil {
+			continue
+		}
+		for _, s := range spans {
+			if _, ok := eb.bib.Entries[s.Key]; !ok {
+				errs = append(errs, spell.SpellError{Line: i, StartCol: s.Start, EndCol: s.End, Word: "@" + s.Key})
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line || errs[i].Line == errs[j].Line && errs[i].StartCol < errs[j].StartCol
+	})
+	return errs
+}
+
+// completeCitation lists the bibliography's keys matching the citation
+// being typed before the cursor. A single match is inserted at once. When
+// quiet, a failure closes the list without a message.
+func (a *App) completeCitation(quiet bool) bool {
+	eb := a.currentBuf()
+	start, prefix, ok := citationPrefix(eb.buf.Lines[eb.cursorLine], eb.cursorCol)
+	if !ok {
+		return false
+	}
+	if err := a.loadBibliography(eb); err != nil && !quiet {
+		a.statusBar.SetError("Bibliography: " + err.Error())
+		return true
+	}
+	if eb.bib == nil {
+		if !quiet {
+			a.statusBar.SetMessage("No bibliography; name one in front matter or .prose-project")
+		}
+		return true
+	}
+	var items []CompletionItem
+	lower := strings.ToLower(prefix)
+	for _, key := range eb.bib.Keys {
+		c := eb.bib.Entries[key]
+		if strings.HasPrefix(strings.ToLower(key), lower) {
+			items = append(items, CompletionItem{Text: key, Detail: c.Detail()})
+		}
+	}
+	// Without a key that starts so, look in the authors and titles too.
+	if len(items) == 0 {
+		for _, key := range eb.bib.Keys {
+			c := eb.bib.Entries[key]
+			if _, ok := fuzzyMatch(lower, strings.ToLower(key+" "+c.Detail())); ok {
+				items = append(items, CompletionItem{Text: key, Detail: c.Detail()})
+			}
+		}
+	}
+	switch {
+	case len(items) == 0:
+		a.completion.Hide()
+		if !quiet {
+			a.statusBar.SetMessage("No citation keys match @" + prefix)
+		}
+	case len(items) == 1 && !a.completion.Active:
+		a.insertCompletion(start+1, items[0].Text)
+	default:
+		a.completion.Show("Citations", eb.cursorLine, start+1, items)
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/spell"
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+const testBibTeX = `% References
+@string{jgr = "Journal of Geography"}
+@article{smith2004,
+  author  = {Smith, Jane and Jones, Tom},
+  title   = {On {Rivers}},
+  journal = jgr,
+  year    = 2004,
+}
+@book(doe:book,
+  author = "John Doe",
+  title = "A Book",
+  date = {1999-05-01})
+@comment{@misc{ignored, title = {No}}}
+`
+
+func TestParseBibTeX(t *testing.T) {
+	entries := parseBibTeX(testBibTeX)
+	want := []Citation{
+		{Key: "smith2004", Author: "Smith and Jones", Year: "2004", Title: "On Rivers"},
+		{Key: "doe:book", Author: "Doe", Year: "1999", Title: "A Book"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("parseBibTeX = %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestParseCSLJSON(t *testing.T) {
+	entries, err := parseCSLJSON([]byte(`[
+		{"id": "lee2010", "title": "Tides", "author": [{"family": "Lee", "given": "A."}, {"family": "Kim"}, {"literal": "NASA"}],
+		 "issued": {"date-parts": [[2010, 3]]}},
+		{"id": 42, "title": "Untitled"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Citation{
+		{Key: "lee2010", Author: "Lee et al.", Year: "2010", Title: "Tides"},
+		{Key: "42", Title: "Untitled"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("parseCSLJSON = %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+	if _, err := parseCSLJSON([]byte(`{`)); err == nil {
+		t.Error("invalid JSON should be an error")
+	}
+}
+
+func TestCitationKeys(t *testing.T) {
+	tests := []struct {
+		line string
+		want []citeSpan
+	}{
+		{"As @smith2004 shows.", []citeSpan{{Key: "smith2004", Start: 3, End: 13}}},
+		{"[@doe:book, p. 4; -@lee2010]", []citeSpan{{Key: "doe:book", Start: 1, End: 10}, {Key: "lee2010", Start: 19, End: 27}}},
+		{"Mail me@example.com or @", nil},
+		{"(@a_b.)", []citeSpan{{Key: "a_b", Start: 1, End: 5}}},
+	}
+	for _, tt := range tests {
+		got := citationKeys(tt.line)
+		if len(got) != len(tt.want) {
+			t.Errorf("citationKeys(%q) = %+v, want %+v", tt.line, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("citationKeys(%q)[%d] = %+v, want %+v", tt.line, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestCitationPrefix(t *testing.T) {
+	tests := []struct {
+		line   string
+		col    int
+		start  int
+		prefix string
+		ok     bool
+	}{
+		{"See [@smi", 9, 5, "smi", true},
+		{"See [@", 6, 5, "", true},
+		{"me@exa", 6, 0, "", false},
+		{"See smith", 9, 0, "", false},
+	}
+	for _, tt := range tests {
+		start, prefix, ok := citationPrefix(tt.line, tt.col)
+		if start != tt.start || prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("citationPrefix(%q, %d) = %d, %q, %v; want %d, %q, %v", tt.line, tt.col, start, prefix, ok, tt.start, tt.prefix, tt.ok)
+		}
+	}
+}
+
+func TestFrontMatterValue(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"---", "title: X", "bibliography: refs.bib", "---"}, "refs.bib"},
+		{[]string{"---", `bibliography: "my refs.json"`, "---"}, "my refs.json"},
+		{[]string{"---", "bibliography:", "  - one.bib", "  - two.bib", "---"}, "one.bib"},
+		{[]string{"---", "bibliography: [one.bib, two.bib]", "---"}, "one.bib"},
+		{[]string{"bibliography: refs.bib"}, ""},
+	}
+	for _, tt := range tests {
+		if got := frontMatterValue(tt.lines, "bibliography"); got != tt.want {
+			t.Errorf("frontMatterValue(%q) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+// newCitationTestApp opens a document citing refs.bib.
+func newCitationTestApp(t *testing.T, lines ...string) *App {
+	t.Helper()
+	a, dir := newTempTestApp(t, "paper.md")
+	if err := os.WriteFile(filepath.Join(dir, "refs.bib"), []byte(testBibTeX), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a.currentBuf().buf.Lines = append([]string{"---", "bibliography: refs.bib", "---"}, lines...)
+	return a
+}
+
+func TestCheckLinesFlagsUnknownCitations(t *testing.T) {
+	sc, err := spell.NewSpellChecker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newCitationTestApp(t, "Rivers [@smith2004; @smyth2004] flow, says @doe:book.")
+	eb := a.currentBuf()
+	if err := a.loadBibliography(eb); err != nil {
+		t.Fatal(err)
+	}
+	errs := eb.checkLines(sc)
+	if len(errs) != 1 || errs[0].Word != "@smyth2004" || errs[0].Line != 3 || errs[0].StartCol != 20 {
+		t.Errorf("only the unknown key should be flagged, got %+v", errs)
+	}
+
+	eb.bib = nil
+	if errs := eb.checkLines(sc); len(errs) != 0 {
+		t.Errorf("without a bibliography keys should not be flagged, got %+v", errs)
+	}
+}
+
+func TestTabCompletesCitation(t *testing.T) {
+	a := newCitationTestApp(t, "See [")
+	eb := a.currentBuf()
+	eb.cursorLine, eb.cursorCol = 3, 5
+	sendKeys(a, "i@s")
+	sendKey(a, terminal.KeyTab)
+	if got := eb.buf.Lines[3]; got != "See [@smith2004" || a.completion.Active {
+		t.Errorf("a single match should be inserted at once, got %q", got)
+	}
+
+	sendKeys(a, "; @")
+	sendKey(a, terminal.KeyTab)
+	if !a.completion.Active || len(a.completion.Items) != 2 {
+		t.Fatalf("every key should be listed, got %+v", a.completion.Items)
+	}
+	sendKeys(a, "d")
+	if len(a.completion.Items) != 1 || a.completion.Items[0].Text != "doe:book" {
+		t.Errorf("typing should narrow the list, got %+v", a.completion.Items)
+	}
+	sendKey(a, terminal.KeyEnter)
+	if got := eb.buf.Lines[3]; got != "See [@smith2004; @doe:book" || a.completion.Active {
+		t.Errorf("Enter should insert the key, got %q", got)
+	}
+
+	sendKeys(a, "; @rivers")
+	sendKey(a, terminal.KeyTab)
+	if got := eb.buf.Lines[3]; got != "See [@smith2004; @doe:book; @smith2004" {
+		t.Errorf("titles should match when no key does, got %q", got)
+	}
+
+	sendKeys(a, " @zz")
+	sendKey(a, terminal.KeyTab)
+	if a.statusBar.StatusMessage != "No citation keys match @zz" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestTabWithoutBibliography(t *testing.T) {
+	a := newTestApp(filepath.Join(t.TempDir(), "notes.md"))
+	a.currentBuf().buf.Lines = []string{""}
+	sendKeys(a, "i@x")
+	sendKey(a, terminal.KeyTab)
+	if a.completion.Active || a.statusBar.StatusMessage != "No bibliography; name one in front matter or .prose-project" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
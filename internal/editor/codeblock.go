@@ -0,0 +1,171 @@
+package editor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BlockState carries multi-line markdown context for a buffer line.
+type BlockState struct {
+	InCode bool   // Inside a fenced code block (including its fences)
+	Fence  bool   // The line is an opening or closing fence
+	Lang   string // Info string of the enclosing fence, lower-cased (e.g. "go")
+}
+
+// BlockHighlighter is implemented by highlighters that need multi-line
+// context, such as whether a line sits inside a fenced code block.
+type BlockHighlighter interface {
+	Highlighter
+	HighlightBlock(line string, state BlockState) string
+}
+
+// Opening or closing code fence: up to three spaces, then ``` or ~~~ (or
+// longer), then an optional info string.
+var reCodeFence = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+
+// matchFence matches reCodeFence against line, first ruling out most lines
+// without running the pattern, since every line is checked on every frame.
+func matchFence(line string) []string {
+	rest := line
+	for i := 0; i < 3 && strings.HasPrefix(rest, " "); i++ {
+		rest = rest[1:]
+	}
+	if !strings.HasPrefix(rest, "```") && !strings.HasPrefix(rest, "~~~") {
+		return nil
+	}
+	return reCodeFence.FindStringSubmatch(line)
+}
+
+// ComputeBlockStates scans lines for fenced code blocks and returns the
+// block state of each line. An unclosed fence runs to the end of the buffer.
+func ComputeBlockStates(lines []string) []BlockState {
+	states := make([]BlockState, len(lines))
+	open := ""
+	lang := ""
+	for i, line := range lines {
+		m := matchFence(line)
+		if open == "" {
+			if m != nil {
+				open = m[1]
+				lang = strings.ToLower(m[2])
+				states[i] = BlockState{InCode: true, Fence: true, Lang: lang}
+			}
+			continue
+		}
+		// A closing fence uses the same character, is at least as long, and
+		// has no info string.
+		if m != nil && m[1][0] == open[0] && len(m[1]) >= len(open) && m[2] == "" {
+			states[i] = BlockState{InCode: true, Fence: true, Lang: lang}
+			open = ""
+			lang = ""
+			continue
+		}
+		states[i] = BlockState{InCode: true, Lang: lang}
+	}
+	return states
+}
+
+// codeLexer holds the few rules needed to colour a language's source.
+type codeLexer struct {
+	keywords map[string]bool
+	comment  string // Line comment prefix
+}
+
+func keywordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+var codeLexers = map[string]codeLexer{
+	"go":         {keywordSet("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false"), "//"},
+	"python":     {keywordSet("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False"), "#"},
+	"javascript": {keywordSet("async await break case catch class const continue default delete do else export extends finally for function if import in instanceof let new return switch this throw try typeof var void while yield null undefined true false"), "//"},
+	"rust":       {keywordSet("as async await break const continue crate else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while true false"), "//"},
+	"sh":         {keywordSet("if then else elif fi for while until do done case esac in function return export local"), "#"},
+}
+
+// Aliases for common info strings.
+var codeLexerAliases = map[string]string{
+	"golang": "go", "py": "python", "js": "javascript", "ts": "javascript",
+	"typescript": "javascript", "rs": "rust", "bash": "sh", "shell": "sh", "zsh": "sh",
+}
+
+func lookupLexer(lang string) (codeLexer, bool) {
+	if alias, ok := codeLexerAliases[lang]; ok {
+		lang = alias
+	}
+	lx, ok := codeLexers[lang]
+	return lx, ok
+}
+
+// highlightCode colours a line of source inside a fenced block in t's
+// colours. Languages without a lexer get a single distinct colour.
+func highlightCode(line string, lang string, t *Theme) string {
+	lx, ok := lookupLexer(lang)
+	if !ok {
+		return t.Code + line + "\x1b[0m"
+	}
+
+	// Back to the code colour after a keyword or string.
+	restore := colorOff(t.Keyword+t.String) + t.Code
+	var b strings.Builder
+	b.WriteString(t.Code)
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case lx.comment != "" && strings.HasPrefix(string(runes[i:]), lx.comment):
+			b.WriteString(t.Muted + string(runes[i:]))
+			i = len(runes)
+		case r == '"' || r == '\'' || r == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				j = len(runes) - 1
+			}
+			b.WriteString(t.String + string(runes[i:j+1]) + restore)
+			i = j + 1
+		case isIdentRune(r):
+			j := i
+			for j < len(runes) && isIdentRune(runes[j]) {
+				j++
+			}
+			word := string(runes[i:j])
+			if lx.keywords[word] {
+				b.WriteString(t.Keyword + word + restore)
+			} else {
+				b.WriteString(word)
+			}
+			i = j
+		default:
+			b.WriteRune(r)
+			i++
+		}
+	}
+	b.WriteString("\x1b[0m")
+	return b.String()
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// HighlightBlock colours fenced code blocks and defers to Highlight elsewhere.
+func (h MarkdownHighlighter) HighlightBlock(line string, state BlockState) string {
+	t := h.Theme.orDefault()
+	switch {
+	case state.Fence:
+		return t.Muted + line + "\x1b[0m"
+	case state.InCode:
+		return highlightCode(line, state.Lang, t)
+	}
+	return h.Highlight(line)
+}
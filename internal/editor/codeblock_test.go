@@ -0,0 +1,98 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeBlockStates(t *testing.T) {
+	lines := []string{
+		"prose",
+		"```go",
+		"func main() {}",
+		"```",
+		"after",
+		"~~~",
+		"unclosed",
+	}
+	states := ComputeBlockStates(lines)
+
+	if states[0].InCode || states[4].InCode {
+		t.Error("prose lines should not be in code")
+	}
+	if !states[1].Fence || states[1].Lang != "go" {
+		t.Errorf("opening fence: %+v", states[1])
+	}
+	if !states[2].InCode || states[2].Fence || states[2].Lang != "go" {
+		t.Errorf("code line: %+v", states[2])
+	}
+	if !states[3].Fence {
+		t.Errorf("closing fence: %+v", states[3])
+	}
+	if !states[6].InCode {
+		t.Error("unclosed fence should run to end of buffer")
+	}
+}
+
+func TestComputeBlockStatesMismatchedFence(t *testing.T) {
+	// A ~~~ line does not close a ``` block, and a shorter fence does not close a longer one.
+	states := ComputeBlockStates([]string{"````", "~~~", "```", "code", "````", "out"})
+	for i := 1; i <= 3; i++ {
+		if !states[i].InCode || states[i].Fence {
+			t.Errorf("line %d should be code content: %+v", i, states[i])
+		}
+	}
+	if !states[4].Fence || states[5].InCode {
+		t.Errorf("matching fence should close the block: %+v %+v", states[4], states[5])
+	}
+}
+
+func TestWrapBufferCarriesBlockState(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{"```", strings.Repeat("x ", 40), "```"}
+	dls := WrapBuffer(buf, 20)
+	for _, dl := range dls {
+		if !dl.Block.InCode {
+			t.Errorf("display line %+v should carry code block state", dl)
+		}
+	}
+}
+
+func TestHighlightBlockCodeContent(t *testing.T) {
+	h := MarkdownHighlighter{}
+
+	// Markdown syntax inside a code block is not interpreted.
+	got := h.HighlightBlock("# not a heading", BlockState{InCode: true})
+	if strings.Contains(got, "\x1b[1;34m") {
+		t.Errorf("heading colour applied inside code block: %q", got)
+	}
+	if !strings.HasPrefix(got, darkTheme.Code) {
+		t.Errorf("code block should use code colour: %q", got)
+	}
+
+	// Fences are dimmed.
+	if got := h.HighlightBlock("```go", BlockState{InCode: true, Fence: true}); !strings.HasPrefix(got, darkTheme.Muted) {
+		t.Errorf("fence colour: %q", got)
+	}
+
+	// Outside code, normal markdown rules apply.
+	if got := h.HighlightBlock("# heading", BlockState{}); !strings.Contains(got, "\x1b[1;34m") {
+		t.Errorf("heading outside code: %q", got)
+	}
+}
+
+func TestHighlightCodeLexer(t *testing.T) {
+	got := highlightCode(`return "hi" // done`, "golang", &darkTheme)
+	if !strings.Contains(got, darkTheme.Keyword+"return") {
+		t.Errorf("keyword not highlighted: %q", got)
+	}
+	if !strings.Contains(got, darkTheme.String+`"hi"`) {
+		t.Errorf("string not highlighted: %q", got)
+	}
+	if !strings.Contains(got, darkTheme.Muted+"// done") {
+		t.Errorf("comment not highlighted: %q", got)
+	}
+	if visibleLen(got) != len(`return "hi" // done`) {
+		t.Errorf("highlighting changed visible text: %q", got)
+	}
+}
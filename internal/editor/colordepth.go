@@ -0,0 +1,186 @@
+package editor
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// Colours are given in 24-bit where it matters and brought down to what the
+// terminal can draw when the theme is chosen: the nearest of the 256-colour
+// palette, or of the 16 ANSI colours by hue, so highlights stay red, yellow
+// or blue rather than all turning white.
+
+// colorDepths are the colors setting's values.
+var colorDepths = map[string]terminal.ColorDepth{
+	"truecolor": terminal.ColorsTrue,
+	"256":       terminal.Colors256,
+	"16":        terminal.Colors16,
+	"8":         terminal.Colors16,
+}
+
+// forDepth returns t with every colour drawable at depth.
+func (t Theme) forDepth(depth terminal.ColorDepth) Theme {
+	for _, field := range themeColors {
+		*field(&t) = reduceColor(*field(&t), depth)
+	}
+	return t
+}
+
+// reduceColor rewrites the 24-bit and 256-colour parameters of the SGR
+// sequences in code for depth, leaving the rest alone.
+func reduceColor(code string, depth terminal.ColorDepth) string {
+	if depth == terminal.ColorsTrue || !strings.Contains(code, "8;") {
+		return code
+	}
+	seqs := strings.Split(code, "\x1b[")
+	for i, seq := range seqs {
+		body, ok := strings.CutSuffix(seq, "m")
+		if !ok {
+			continue
+		}
+		params := strings.Split(body, ";")
+		var out []string
+		for j := 0; j < len(params); j++ {
+			p := params[j]
+			if (p != "38" && p != "48") || j+2 >= len(params) {
+				out = append(out, p)
+				continue
+			}
+			var r, g, b int
+			switch params[j+1] {
+			case "5":
+				n, _ := strconv.Atoi(params[j+2])
+				if depth == terminal.Colors256 {
+					out = append(out, p, "5", strconv.Itoa(n))
+					j += 2
+					continue
+				}
+				if n < 16 {
+					out = append(out, ansiParam(n, p == "48"))
+					j += 2
+					continue
+				}
+				r, g, b = xtermRGB(n)
+				j += 2
+			case "2":
+				if j+4 >= len(params) {
+					out = append(out, p)
+					continue
+				}
+				r, _ = strconv.Atoi(params[j+2])
+				g, _ = strconv.Atoi(params[j+3])
+				b, _ = strconv.Atoi(params[j+4])
+				j += 4
+				if depth == terminal.Colors256 {
+					out = append(out, p, "5", strconv.Itoa(nearest256(r, g, b)))
+					continue
+				}
+			default:
+				out = append(out, p)
+				continue
+			}
+			out = append(out, ansiParam(nearest16(r, g, b), p == "48"))
+		}
+		seqs[i] = strings.Join(out, ";") + "m"
+	}
+	return strings.Join(seqs, "\x1b[")
+}
+
+// ansiParam returns the SGR parameter for ANSI colour n, 0-15, as a
+// foreground or background.
+func ansiParam(n int, background bool) string {
+	base := 30
+	if n >= 8 {
+		base, n = 90, n-8
+	}
+	if background {
+		base += 10
+	}
+	return strconv.Itoa(base + n)
+}
+
+// cubeLevels are the channel values of the 256-colour palette's 6×6×6 cube.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// ansiRGB are xterm's values for the 16 ANSI colours. Terminals vary, but
+// only the 256-colour palette's own first 16 entries are taken from here.
+var ansiRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// xtermRGB returns the colour of entry n of the 256-colour palette.
+func xtermRGB(n int) (r, g, b int) {
+	switch {
+	case n < 16:
+		c := ansiRGB[max(n, 0)]
+		return c[0], c[1], c[2]
+	case n < 232:
+		n -= 16
+		return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+	}
+	v := 8 + 10*(min(n, 255)-232)
+	return v, v, v
+}
+
+// nearest256 returns the entry of the 256-colour palette's cube or grey
+// ramp nearest r, g, b. The first 16 are left out, as terminals change them.
+func nearest256(r, g, b int) int {
+	level := func(v int) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if abs(v-l) < abs(v-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	cube := 16 + 36*level(r) + 6*level(g) + level(b)
+	grey := 232 + max(0, min(23, ((r+g+b)/3-3)/10))
+	if colorDistance(r, g, b, grey) < colorDistance(r, g, b, cube) {
+		return grey
+	}
+	return cube
+}
+
+// colorDistance returns the squared distance from r, g, b to palette entry n.
+func colorDistance(r, g, b, n int) int {
+	pr, pg, pb := xtermRGB(n)
+	return (r-pr)*(r-pr) + (g-pg)*(g-pg) + (b-pb)*(b-pb)
+}
+
+// nearest16 returns the ANSI colour, 0-15, nearest r, g, b by hue. Greys
+// go by lightness, and light, strong colours get the bright versions.
+func nearest16(r, g, b int) int {
+	hi, lo := max(r, g, b), min(r, g, b)
+	if hi-lo < 32 {
+		switch {
+		case hi < 64:
+			return 0 // Black
+		case hi < 170:
+			return 8 // Grey
+		case hi < 240:
+			return 7 // White
+		}
+		return 15 // Bright white
+	}
+	// Hue in degrees, from red through yellow, green, cyan, blue and magenta.
+	var hue float64
+	d := float64(hi - lo)
+	switch hi {
+	case r:
+		hue = math.Mod(float64(g-b)/d+6, 6) * 60
+	case g:
+		hue = (float64(b-r)/d + 2) * 60
+	default:
+		hue = (float64(r-g)/d + 4) * 60
+	}
+	n := [6]int{1, 3, 2, 6, 4, 5}[int((hue+30)/60)%6]
+	if hi > 191 && (hi-lo)*2 > hi {
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,111 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestReduceColor(t *testing.T) {
+	tests := []struct {
+		code  string
+		depth terminal.ColorDepth
+		want  string
+	}{
+		{"\x1b[48;2;255;212;212m", terminal.ColorsTrue, "\x1b[48;2;255;212;212m"},
+		{"\x1b[48;2;255;212;212m", terminal.Colors256, "\x1b[48;5;224m"},
+		{"\x1b[48;2;255;212;212m", terminal.Colors16, "\x1b[41m"},
+		{"\x1b[38;5;0m\x1b[48;2;255;240;42m", terminal.Colors256, "\x1b[38;5;0m\x1b[48;5;226m"},
+		{"\x1b[38;5;0m\x1b[48;2;255;240;42m", terminal.Colors16, "\x1b[30m\x1b[103m"},
+		{"\x1b[1;38;2;31;95;174m", terminal.Colors16, "\x1b[1;34m"},
+		{"\x1b[4;38;5;28m", terminal.Colors16, "\x1b[4;32m"},
+		{"\x1b[48;5;9m", terminal.Colors16, "\x1b[101m"},
+		{"\x1b[48;2;43;45;49m", terminal.Colors16, "\x1b[40m"},
+		{"\x1b[38;5;0m\x1b[48;2;189;191;194m", terminal.Colors16, "\x1b[30m\x1b[47m"},
+		{"\x1b[7m", terminal.Colors16, "\x1b[7m"},
+		{"\x1b[1;33m", terminal.Colors256, "\x1b[1;33m"},
+	}
+	for _, tt := range tests {
+		if got := reduceColor(tt.code, tt.depth); got != tt.want {
+			t.Errorf("reduceColor(%q, %d) = %q, want %q", tt.code, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestNearest256RoundTrip(t *testing.T) {
+	for n := 16; n < 256; n++ {
+		r, g, b := xtermRGB(n)
+		if got := nearest256(r, g, b); got != n {
+			t.Errorf("nearest256(xtermRGB(%d)) = %d", n, got)
+		}
+	}
+}
+
+// On 256-colour terminals the built-in colours are the 256-colour ones they
+// were before they were given in 24-bit.
+func TestBuiltInColorsAt256(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{themes["dark"].forDepth(terminal.Colors256).Spell, "\x1b[38;5;0m\x1b[48;5;224m"},
+		{themes["dark"].forDepth(terminal.Colors256).SearchOther, "\x1b[38;5;0m\x1b[48;5;229m"},
+		{themes["dark"].forDepth(terminal.Colors256).CursorLine, "\x1b[48;5;236m"},
+		{themes["dark"].forDepth(terminal.Colors256).Modified, "\x1b[1;48;5;208m"},
+		{reduceColor(palettes["deuteranopia"].Spell, terminal.Colors256), "\x1b[38;5;0m\x1b[48;5;117m"},
+		{reduceColor(palettes["deuteranopia"].SearchCurrent, terminal.Colors256), "\x1b[38;5;0m\x1b[48;5;214m"},
+		{reduceColor(palettes["deuteranopia"].SearchOther, terminal.Colors256), "\x1b[38;5;0m\x1b[48;5;223m"},
+		{reduceColor(palettes["deuteranopia"].Selection, terminal.Colors256), "\x1b[38;5;0m\x1b[48;5;250m"},
+		{reduceColor(palettes["protanopia"].Spell, terminal.Colors256), "\x1b[38;5;0m\x1b[48;5;153m"},
+		{reduceColor(palettes["protanopia"].SearchCurrent, terminal.Colors256), "\x1b[38;5;0m\x1b[48;5;220m"},
+		{reduceColor(palettes["protanopia"].SearchOther, terminal.Colors256), "\x1b[38;5;0m\x1b[48;5;230m"},
+		{themes["light"].forDepth(terminal.Colors256).CursorLine, "\x1b[48;5;254m"},
+		{themes["light"].forDepth(terminal.Colors256).Heading, "\x1b[1;38;5;25m"},
+		{themes["light"].forDepth(terminal.Colors256).Bold, "\x1b[1;38;5;130m"},
+		{themes["light"].forDepth(terminal.Colors256).Italic, "\x1b[3;38;5;30m"},
+		{themes["light"].forDepth(terminal.Colors256).Keyword, "\x1b[1;38;5;90m"},
+		{themes["light"].forDepth(terminal.Colors256).Link, "\x1b[4;38;5;28m"},
+		{themes["light"].forDepth(terminal.Colors256).Muted, "\x1b[38;5;244m"},
+		{themes["light"].forDepth(terminal.Colors256).Modified, "\x1b[1;48;5;166m"},
+		{themes["light"].forDepth(terminal.Colors256).Errors, "\x1b[48;5;160m"},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("colour %d = %q, want %q", i, tt.got, tt.want)
+		}
+	}
+}
+
+// Highlights that differ in 24-bit still differ with 16 colours.
+func TestHighlightsDistinctAt16(t *testing.T) {
+	for name, p := range palettes {
+		theme := Theme{Palette: p}.forDepth(terminal.Colors16)
+		if theme.Spell == theme.SearchCurrent || theme.SearchCurrent == theme.SearchOther {
+			t.Errorf("palette %s: spell %q, search %q, other %q", name, theme.Spell, theme.SearchCurrent, theme.SearchOther)
+		}
+	}
+}
+
+func TestParseColorHex(t *testing.T) {
+	if got, err := parseColor("bold #1F5FAE on #ffd7d7"); err != nil || got != "\x1b[1;38;2;31;95;174;48;2;255;215;215m" {
+		t.Errorf("parseColor = %q, %v", got, err)
+	}
+	for _, desc := range []string{"#fff", "#ffd7zz"} {
+		if _, err := parseColor(desc); err == nil || !strings.Contains(err.Error(), "#ffd7d7") {
+			t.Errorf("parseColor(%q) error = %v", desc, err)
+		}
+	}
+}
+
+func TestConfigColorDepth(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader("colors = 256\n"))
+	if err != nil || cfg.colorDepth() != terminal.Colors256 {
+		t.Errorf("colors = 256: got %d, %v", cfg.colorDepth(), err)
+	}
+	if _, err := parseConfig(strings.NewReader("colours = lots\n")); err == nil || !strings.Contains(err.Error(), "want auto, truecolor, 256 or 16") {
+		t.Errorf("colours = lots: error %v", err)
+	}
+	if cfg := DefaultConfig(); cfg.ColorDepth != "auto" {
+		t.Errorf("default colors = %q", cfg.ColorDepth)
+	}
+}
@@ -1,26 +1,16 @@
 package editor
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/JackWReid/prose/internal/terminal"
 )
 
-// newTestApp creates a minimal App for testing executeCommand.
-func newTestApp(filename string) *App {
-	eb := NewEditorBuffer(filename)
-	return &App{
-		buffers:   []*EditorBuffer{eb},
-		renderer:  NewRenderer(),
-		statusBar: NewStatusBar(),
-		picker:    &Picker{},
-		mode:      ModeDefault,
-	}
-}
-
 func TestCommandQuit(t *testing.T) {
 	a := newTestApp("test.txt")
 	a.executeCommand("q")
@@ -71,6 +61,27 @@ func TestCommandWrite(t *testing.T) {
 	}
 }
 
+func TestCtrlSWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	a := newTestApp(path)
+	a.currentBuf().buf.Lines = []string{"hello"}
+	a.currentBuf().buf.Dirty = true
+	a.mode = ModeEdit
+
+	a.handleKey(terminal.Key{Type: terminal.KeyCombo, Rune: 's', Mod: terminal.ModCtrl})
+	if data, err := os.ReadFile(path); err != nil || string(data) != "hello\n" {
+		t.Errorf("Ctrl-S should save: %q, %v", data, err)
+	}
+	if a.mode != ModeEdit {
+		t.Errorf("Ctrl-S should keep the mode, got %v", a.mode)
+	}
+
+	a.handleKey(terminal.Key{Type: terminal.KeyCombo, Rune: 'x', Mod: terminal.ModCtrl})
+	if got := a.currentBuf().buf.Lines[0]; got != "hello" {
+		t.Errorf("other Ctrl keys should not type, got %q", got)
+	}
+}
+
 func TestCommandWriteUnnamed(t *testing.T) {
 	a := newTestApp("")
 	a.currentBuf().buf.Lines = []string{"hello"}
@@ -792,6 +803,30 @@ func TestPasteEmptyBuffer(t *testing.T) {
 	}
 }
 
+func TestPasteMultipleLinesInPlace(t *testing.T) {
+	// Lines are inserted in place when the slice has room; undo and redo
+	// must still see the text as it was.
+	a := newTestApp("test.txt")
+	eb := a.currentBuf()
+	eb.buf.Lines = append(make([]string, 0, 10), "first", "second", "third")
+	a.yankBuffer = "one\ntwo"
+
+	sendKeys(a, "p")
+	want := []string{"first", "one", "two", "second", "third"}
+	if !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Fatalf("after paste: %q", eb.buf.Lines)
+	}
+	sendKeys(a, "jjdd")
+	sendKeys(a, "uu")
+	if want := []string{"first", "second", "third"}; !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Errorf("after undo: %q", eb.buf.Lines)
+	}
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyCtrlR})
+	if !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Errorf("after redo: %q, want %q", eb.buf.Lines, want)
+	}
+}
+
 func TestDDPopulatesYankBuffer(t *testing.T) {
 	a := newTestApp("test.txt")
 	a.currentBuf().buf.Lines = []string{"first", "second", "third"}
@@ -879,6 +914,29 @@ func TestRedoWithCtrlR(t *testing.T) {
 	}
 }
 
+func TestUndoRedoLastLineKeepsCursorInBuffer(t *testing.T) {
+	for _, keys := range []string{"{", "}", "rx", "Rx", "p"} {
+		a := newTestApp("test.txt")
+		eb := a.currentBuf()
+		eb.buf.Lines = []string{"first", "second", "third"}
+		eb.cursorLine, eb.cursorCol = 2, 4
+
+		sendKeys(a, "ddu")
+		if eb.cursorLine >= eb.buf.LineCount() || eb.cursorCol > eb.buf.LineLen(eb.cursorLine) {
+			t.Fatalf("after undo the cursor is at (%d,%d) in %q", eb.cursorLine, eb.cursorCol, eb.buf.Lines)
+		}
+		sendKey(a, terminal.KeyCtrlR)
+		if eb.cursorLine >= eb.buf.LineCount() || eb.cursorCol > eb.buf.LineLen(eb.cursorLine) {
+			t.Fatalf("after redo the cursor is at (%d,%d) in %q", eb.cursorLine, eb.cursorCol, eb.buf.Lines)
+		}
+		sendKeys(a, keys+"\x1b")
+
+		// Nor with the cursor left past the end some other way.
+		eb.cursorLine, eb.cursorCol = eb.buf.LineCount(), 9
+		sendKeys(a, keys+"\x1b")
+	}
+}
+
 // --- v1.7.0 feature tests: Quit-all commands ---
 
 func TestCommandQuitAll(t *testing.T) {
@@ -993,23 +1051,66 @@ func TestCommandWriteQuitAllAlternate(t *testing.T) {
 }
 
 func TestCommandWriteQuitAllWithUnnamed(t *testing.T) {
+	dir := t.TempDir()
 	a := newTestApp("")
-	a.buffers = append(a.buffers, NewEditorBuffer("file2.txt"))
+	a.buffers = append(a.buffers, NewEditorBuffer(filepath.Join(dir, "file2.txt")), NewEditorBuffer(""))
+	a.currentBuffer = 1
 
-	// Make unnamed buffer dirty.
-	a.buffers[0].buf.Lines = []string{"unsaved"}
-	a.buffers[0].buf.Dirty = true
+	// Make both unnamed buffers and the named one dirty.
+	for i, eb := range a.buffers {
+		eb.buf.Lines = []string{fmt.Sprintf("unsaved %d", i)}
+		eb.buf.Dirty = true
+	}
 
 	a.executeCommand("wqa")
 
 	if a.quit {
-		t.Error(":wqa should not quit with unnamed dirty buffer")
+		t.Fatal(":wqa should not quit before unnamed buffers are named")
 	}
-	if a.statusBar.StatusMessage == "" {
-		t.Error(":wqa with unnamed dirty buffer should show error message")
+	if a.buffers[1].buf.Dirty {
+		t.Error(":wqa should save named buffers first")
+	}
+	if a.statusBar.Prompt != PromptSaveNew || a.currentBuffer != 0 {
+		t.Fatalf(":wqa should show the first unnamed buffer and ask for a name, prompt %d, buffer %d", a.statusBar.Prompt, a.currentBuffer)
+	}
+
+	// An empty name asks again.
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	if a.statusBar.Prompt != PromptSaveNew || a.currentBuffer != 0 {
+		t.Fatal("an empty name should ask again")
+	}
+
+	a.statusBar.PromptText = filepath.Join(dir, "first.txt")
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	if a.quit || a.statusBar.Prompt != PromptSaveNew || a.currentBuffer != 2 {
+		t.Fatalf("the next unnamed buffer should be asked for, buffer %d", a.currentBuffer)
+	}
+
+	a.statusBar.PromptText = filepath.Join(dir, "second.txt")
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	if !a.quit {
+		t.Fatal(":wqa should quit once every buffer is saved")
+	}
+	for _, name := range []string{"first.txt", "second.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should be saved: %v", name, err)
+		}
+	}
+}
+
+func TestCommandWriteQuitAllUnnamedCancel(t *testing.T) {
+	a := newTestApp("")
+	a.currentBuf().buf.Lines = []string{"unsaved"}
+	a.currentBuf().buf.Dirty = true
+
+	a.executeCommand("wqa")
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEscape})
+
+	if a.quit || len(a.saveAsQueue) != 0 {
+		t.Error("cancelling a name should cancel the quit")
 	}
-	if !strings.Contains(a.statusBar.StatusMessage, "unnamed") {
-		t.Errorf("error should mention unnamed buffers, got: %q", a.statusBar.StatusMessage)
+	if a.statusBar.StatusMessage != "Quit cancelled" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
 	}
 }
 
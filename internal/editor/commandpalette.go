@@ -0,0 +1,218 @@
+package editor
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// PaletteCommand is one entry of the command palette. It runs an action by
+// name, as a key bound to it would, or a command as typed at the : prompt.
+// A command ending in a space needs more, so it opens the prompt with the
+// command typed.
+type PaletteCommand struct {
+	Name   string
+	Action string
+	Ex     string
+}
+
+// paletteCommands are the entries of the command palette, in the order
+// listed.
+var paletteCommands = []PaletteCommand{
+	{Name: "Save", Ex: "w"},
+	{Name: "Save all", Ex: "wa"},
+	{Name: "Save as", Ex: "w "},
+	{Name: "Save and close", Ex: "wq"},
+	{Name: "Close buffer", Ex: "q"},
+	{Name: "Quit all", Ex: "qa"},
+	{Name: "Open file", Ex: "e "},
+	{Name: "Rename file", Ex: "rename "},
+	{Name: "Open buffer picker", Action: "buffers"},
+	{Name: "Open outline", Action: "outline"},
+	{Name: "Open file browser", Action: "browser"},
+	{Name: "Open recent files", Action: "recent"},
+	{Name: "Open scratch buffer", Action: "scratch"},
+	{Name: "Search", Action: "search"},
+	{Name: "Search files", Action: "grep"},
+	{Name: "Show backlinks", Action: "backlinks"},
+	{Name: "Go to line", Ex: "goto "},
+	{Name: "Next spelling error", Action: "next-spelling"},
+	{Name: "Previous spelling error", Action: "prev-spelling"},
+	{Name: "Toggle spell check", Ex: "spell"},
+	{Name: "Toggle read-only", Ex: "ro"},
+	{Name: "Toggle track changes", Ex: "track"},
+	{Name: "Accept change", Ex: "accept"},
+	{Name: "Reject change", Ex: "reject"},
+	{Name: "Accept all changes", Ex: "accept all"},
+	{Name: "Reject all changes", Ex: "reject all"},
+	{Name: "Add a note", Ex: "note"},
+	{Name: "List notes", Ex: "notes"},
+	{Name: "Split window", Ex: "split"},
+	{Name: "Split window side by side", Ex: "vsplit"},
+	{Name: "Toggle preview", Ex: "preview"},
+	{Name: "Focus the other window", Action: "other-window"},
+	{Name: "Close the other window", Ex: "only"},
+	{Name: "Toggle section hoist", Ex: "hoist"},
+	{Name: "Adjust the column width", Action: "column-width"},
+	{Name: "Undo", Action: "undo"},
+	{Name: "Redo", Action: "redo"},
+	{Name: "Open undo tree", Ex: "undotree"},
+	{Name: "Align table", Ex: "table"},
+	{Name: "Add table row", Ex: "table row"},
+	{Name: "Add table column", Ex: "table col"},
+	{Name: "Fix heading case", Ex: "headcase"},
+	{Name: "Tidy sentence capitals and spacing", Ex: "tidy"},
+	{Name: "Smarten quotes, dashes and ellipses", Ex: "smartpunct"},
+	{Name: "Straighten quotes, dashes and ellipses", Ex: "smartpunct strip"},
+	{Name: "Check character names", Ex: "names"},
+	{Name: "Find repeated sentences", Ex: "dupes"},
+	{Name: "Project statistics", Ex: "stats"},
+	{Name: "Set a word goal", Ex: "goal "},
+	{Name: "Compile project", Ex: "compile"},
+	{Name: "Export as HTML", Ex: "export html"},
+	{Name: "Export as PDF", Ex: "export pdf"},
+	{Name: "Today's journal note", Ex: "today"},
+	{Name: "Journal archive", Ex: "journal list"},
+	{Name: "Merge with the file on disk", Ex: "merge"},
+	{Name: "Show changes since the last commit", Ex: "gitdiff"},
+	{Name: "Read the line aloud", Ex: "speak"},
+	{Name: "Suspend to the shell", Action: "suspend"},
+	{Name: "Help", Action: "help"},
+	{Name: "Tutorial", Ex: "tutor"},
+	{Name: "Recent messages", Ex: "messages"},
+	{Name: "Set an option", Ex: "set "},
+}
+
+// CommandPalette manages the command palette overlay. What is typed
+// filters the commands at once; there is no / to start it. Its items are
+// the commands matching the filter.
+type CommandPalette struct {
+	ListView[PaletteCommand]
+	Filter OverlayFilter
+}
+
+// Show activates the palette with every command listed.
+func (p *CommandPalette) Show() {
+	p.Active = true
+	p.Filter = OverlayFilter{Typing: true}
+	p.ApplyFilter()
+}
+
+// Hide deactivates the palette.
+func (p *CommandPalette) Hide() {
+	p.ListView.Hide()
+	p.Filter = OverlayFilter{}
+}
+
+// ApplyFilter lists the commands whose names fuzzy match the filter and
+// selects the first.
+func (p *CommandPalette) ApplyFilter() {
+	p.Items = nil
+	for _, cmd := range paletteCommands {
+		if _, ok := fuzzyMatch(p.Filter.Text, cmd.Name); ok {
+			p.Items = append(p.Items, cmd)
+		}
+	}
+	p.Selected = 0
+	p.ScrollOffset = 0
+}
+
+// commandKeys returns how else cmd can be run, to show beside it: the
+// first key bound to its action, or the command typed at the : prompt.
+func (k *Keymap) commandKeys(cmd PaletteCommand) string {
+	if cmd.Action == "" {
+		return ":" + strings.TrimSpace(cmd.Ex)
+	}
+	var keys []string
+	for key, action := range k.Default {
+		if action == cmd.Action {
+			keys = append(keys, key)
+		}
+	}
+	for key, action := range k.Leader {
+		if action == cmd.Action {
+			keys = append(keys, "Space "+key)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	// The shortest, so j is shown rather than Down.
+	sort.Slice(keys, func(i, j int) bool {
+		if li, lj := utf8.RuneCountInString(keys[i]), utf8.RuneCountInString(keys[j]); li != lj {
+			return li < lj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys[0]
+}
+
+// showPalette opens the command palette.
+func (a *App) showPalette() {
+	a.palette.Show()
+}
+
+// runPaletteCommand closes the palette and runs cmd.
+func (a *App) runPaletteCommand(cmd PaletteCommand) {
+	a.palette.Hide()
+	switch {
+	case cmd.Action != "":
+		a.runAction(cmd.Action)
+	case strings.HasSuffix(cmd.Ex, " "):
+		a.statusBar.StartPrompt(PromptCommand)
+		a.statusBar.PromptText = cmd.Ex
+	default:
+		a.executeCommand(cmd.Ex)
+	}
+}
+
+func (a *App) handlePaletteKey(key terminal.Key) {
+	switch {
+	case key.Type == terminal.KeyEscape:
+		a.palette.Hide()
+		return
+	case key.Type == terminal.KeyUp, key.Type == terminal.KeyCombo && key.Mod == terminal.ModCtrl && key.Rune == 'p':
+		a.palette.MoveUp()
+		return
+	case key.Type == terminal.KeyDown, key.Type == terminal.KeyCombo && key.Mod == terminal.ModCtrl && key.Rune == 'n':
+		a.palette.MoveDown()
+		return
+	case key.Type == terminal.KeyEnter:
+		if a.palette.Selected < len(a.palette.Items) {
+			a.runPaletteCommand(a.palette.Items[a.palette.Selected])
+		}
+		return
+	}
+	if _, changed := a.palette.Filter.HandleKey(key); changed {
+		a.palette.ApplyFilter()
+	}
+	// Backspace past the start leaves the filter; the palette has no other
+	// use for keys, so keep typing.
+	a.palette.Filter.Typing = true
+}
+
+// paletteLabel pads a command's name to width and adds the keys that run
+// it, dimmed. The name's letters matching filter are underlined.
+func paletteLabel(cmd PaletteCommand, keys, filter string, width int) OverlayItem {
+	pad := strings.Repeat(" ", width-utf8.RuneCountInString(cmd.Name)+2)
+	positions, _ := fuzzyMatch(filter, cmd.Name)
+	display := highlightMatches(cmd.Name, positions)
+	if keys == "" {
+		return OverlayItem{DisplayText: display, RawText: cmd.Name}
+	}
+	return OverlayItem{
+		DisplayText: display + pad + "\x1b[90m" + keys + "\x1b[0m",
+		RawText:     cmd.Name + pad + keys,
+	}
+}
+
+// paletteNameWidth returns the width of the longest command name, so the
+// keys line up however the list is filtered.
+func paletteNameWidth() int {
+	return utf8.RuneCountInString(slices.MaxFunc(paletteCommands, func(x, y PaletteCommand) int {
+		return utf8.RuneCountInString(x.Name) - utf8.RuneCountInString(y.Name)
+	}).Name)
+}
@@ -0,0 +1,116 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestPaletteCommandsExist(t *testing.T) {
+	for _, cmd := range paletteCommands {
+		if (cmd.Action == "") == (cmd.Ex == "") {
+			t.Errorf("%q should run an action or a command", cmd.Name)
+		}
+		if _, ok := actions[cmd.Action]; cmd.Action != "" && !ok {
+			t.Errorf("%q runs unknown action %q", cmd.Name, cmd.Action)
+		}
+	}
+}
+
+func TestPaletteFilter(t *testing.T) {
+	a := newTestApp("test.md")
+	a.handleKey(terminal.Key{Type: terminal.KeyCombo, Rune: 'p', Mod: terminal.ModCtrl})
+	if !a.palette.Active || len(a.palette.Items) != len(paletteCommands) {
+		t.Fatal("Ctrl-P should open the palette with every command")
+	}
+	sendKeys(a, "tglspl")
+	if len(a.palette.Items) == 0 || a.palette.Items[0].Name != "Toggle spell check" {
+		t.Fatalf("items = %+v, want Toggle spell check first", a.palette.Items)
+	}
+
+	// j and k are typed, not moves.
+	sendKeys(a, "jk")
+	if a.palette.Filter.Text != "tglspljk" || len(a.palette.Items) != 0 {
+		t.Errorf("filter = %q with %d items", a.palette.Filter.Text, len(a.palette.Items))
+	}
+	for range 3 {
+		sendKey(a, terminal.KeyBackspace)
+	}
+	if a.palette.Filter.Text != "tglsp" || len(a.palette.Items) == 0 {
+		t.Errorf("Backspace should widen the filter, got %q", a.palette.Filter.Text)
+	}
+}
+
+func TestPaletteRunsCommand(t *testing.T) {
+	a := newTestApp("test.md")
+	a.spellCheckEnabled = true
+	sendKeys(a, " :")
+	if !a.palette.Active {
+		t.Fatal("Space : should open the palette")
+	}
+	sendKeys(a, "toggle spell")
+	sendKey(a, terminal.KeyEnter)
+	if a.palette.Active || a.spellCheckEnabled {
+		t.Errorf("Enter should close the palette and toggle spell check, got %v, %v", a.palette.Active, a.spellCheckEnabled)
+	}
+
+	a.handleKey(terminal.Key{Type: terminal.KeyCombo, Rune: 'p', Mod: terminal.ModCtrl})
+	sendKeys(a, "buffer picker")
+	sendKey(a, terminal.KeyEnter)
+	if !a.picker.Active {
+		t.Error("the buffer picker should open")
+	}
+}
+
+func TestPaletteStartsPrompt(t *testing.T) {
+	a := newTestApp("test.md")
+	a.palette.Show()
+	sendKeys(a, "go to line")
+	sendKey(a, terminal.KeyEnter)
+	if a.statusBar.Prompt != PromptCommand || a.statusBar.PromptText != "goto " {
+		t.Errorf("a command needing more should open the prompt, got %v %q", a.statusBar.Prompt, a.statusBar.PromptText)
+	}
+}
+
+func TestPaletteEscape(t *testing.T) {
+	a := newTestApp("test.md")
+	a.palette.Show()
+	sendKeys(a, "sav")
+	sendKey(a, terminal.KeyEscape)
+	if a.palette.Active {
+		t.Error("Esc should close the palette, filter and all")
+	}
+}
+
+func TestCommandKeys(t *testing.T) {
+	k := DefaultKeymap()
+	tests := []struct {
+		cmd  PaletteCommand
+		want string
+	}{
+		{PaletteCommand{Action: "outline"}, "Space H"},
+		{PaletteCommand{Action: "undo"}, "u"},
+		{PaletteCommand{Ex: "goto "}, ":goto"},
+		{PaletteCommand{Action: "insert"}, "i"},
+	}
+	for _, tt := range tests {
+		if got := k.commandKeys(tt.cmd); got != tt.want {
+			t.Errorf("commandKeys(%+v) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+	k.Bind("u", "none")
+	if got := k.commandKeys(PaletteCommand{Action: "undo"}); got != "" {
+		t.Errorf("an unbound action should show no keys, got %q", got)
+	}
+}
+
+func TestRenderPalette(t *testing.T) {
+	a := newTestApp("test.md")
+	a.palette.Show()
+	sendKeys(a, "outline")
+	frame := a.renderer.RenderPalette(a.palette, a.keymap, NewViewport(80, 24))
+	if !strings.Contains(frame, "Commands /outline") || !strings.Contains(frame, "Space H") {
+		t.Errorf("overlay should show the filter and keys: %q", frame)
+	}
+}
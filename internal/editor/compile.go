@@ -0,0 +1,252 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CompileOptions controls how chapters are joined into a manuscript.
+type CompileOptions struct {
+	Separator string // Line put between chapters; empty for none
+	Shift     int    // Levels to move headings down by (negative moves them up)
+}
+
+// CompileManuscript joins chapters into one document. Each chapter's front
+// matter is dropped and its headings shifted, and chapters are separated by a
+// blank line, or by the separator line with blank lines around it.
+func CompileManuscript(chapters [][]string, opts CompileOptions) []string {
+	var out []string
+	for _, lines := range chapters {
+		lines = lines[frontMatterEnd(lines)+1:]
+		lines = trimBlankLines(shiftHeadings(lines, opts.Shift))
+		if len(lines) == 0 {
+			continue
+		}
+		if len(out) > 0 {
+			out = append(out, "")
+			if opts.Separator != "" {
+				out = append(out, opts.Separator, "")
+			}
+		}
+		out = append(out, lines...)
+	}
+	return out
+}
+
+// shiftHeadings moves every heading in lines down by n levels (up for
+// negative n), keeping levels between 1 and 6. Setext headings become ATX
+// headings, since an underline can only mark levels 1 and 2.
+func shiftHeadings(lines []string, n int) []string {
+	if n == 0 {
+		return lines
+	}
+	level := func(l int) string {
+		return strings.Repeat("#", max(1, min(6, l+n)))
+	}
+	blocks := ComputeBlockStates(lines)
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if blocks[i].InCode {
+			out = append(out, line)
+			continue
+		}
+		if m := reHeadingATX.FindStringSubmatch(line); m != nil {
+			out = append(out, level(len(m[1]))+" "+m[2])
+			continue
+		}
+		if l := setextLevel(lines, blocks, i); l > 0 {
+			out = append(out, level(l)+" "+strings.TrimSpace(line))
+			i++ // Skip the underline.
+			continue
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// trimBlankLines drops blank lines from the start and end of lines.
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// chapterLines returns a chapter's text, taken from its buffer when the file
+// is open so unsaved changes are included.
+func (a *App) chapterLines(path string) ([]string, error) {
+	path = absPath(path)
+	for _, eb := range a.buffers {
+		if eb.buf.Filename != "" && absPath(eb.buf.Filename) == path {
+			return eb.buf.Lines, nil
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	buf := NewBuffer(path)
+	if err := buf.Load(); err != nil {
+		return nil, err
+	}
+	return buf.Lines, nil
+}
+
+// readChapters returns the text of each chapter file in order.
+func (a *App) readChapters(paths []string) ([][]string, error) {
+	chapters := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		lines, err := a.chapterLines(path)
+		if err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, lines)
+	}
+	return chapters, nil
+}
+
+// splitArgs splits a command line into words. Double quotes group words
+// containing spaces, as in --sep "* * *".
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inWord, quoted := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case r == ' ' && !quoted:
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// compileRequest is a parsed :compile command.
+type compileRequest struct {
+	files    []string
+	output   string // Empty to open the result in a new buffer
+	opts     CompileOptions
+	hasSep   bool // --sep was given, overriding the project's separator
+	hasShift bool // --shift was given, overriding the project's setting
+}
+
+// parseCompileArgs reads :compile's arguments: files or glob patterns, -o
+// file, --sep text and --shift n.
+func parseCompileArgs(args []string) (compileRequest, error) {
+	var req compileRequest
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg != "-o" && arg != "--sep" && arg != "--shift" {
+			if strings.HasPrefix(arg, "-") {
+				return req, fmt.Errorf("unknown option %s", arg)
+			}
+			matches, err := filepath.Glob(arg)
+			if err != nil || len(matches) == 0 {
+				matches = []string{arg}
+			}
+			req.files = append(req.files, matches...)
+			continue
+		}
+		if i+1 >= len(args) {
+			return req, fmt.Errorf("%s needs a value", arg)
+		}
+		i++
+		switch arg {
+		case "-o":
+			req.output = args[i]
+		case "--sep":
+			req.opts.Separator = args[i]
+			req.hasSep = true
+		case "--shift":
+			n, err := strconv.Atoi(args[i])
+			if err != nil {
+				return req, fmt.Errorf("--shift: want a whole number, got %q", args[i])
+			}
+			req.opts.Shift = n
+			req.hasShift = true
+		}
+	}
+	return req, nil
+}
+
+// compile runs :compile. Without files it compiles the project's chapters
+// using the project's settings, which the options override.
+func (a *App) compile(argLine string) {
+	args, err := splitArgs(argLine)
+	if err != nil {
+		a.statusBar.SetError("Compile: " + err.Error())
+		return
+	}
+	req, err := parseCompileArgs(args)
+	if err != nil {
+		a.statusBar.SetError("Compile: " + err.Error())
+		return
+	}
+	if len(req.files) == 0 {
+		if err := a.loadProject(); a.project == nil {
+			if err == nil {
+				err = errors.New("no files given and no " + projectFileName + " file found")
+			}
+			a.statusBar.SetError("Compile: " + err.Error())
+			return
+		}
+		req.files = a.project.Chapters
+		if !req.hasSep {
+			req.opts.Separator = a.project.Separator
+		}
+		if !req.hasShift {
+			req.opts.Shift = a.project.Shift
+		}
+	}
+	if len(req.files) == 0 {
+		a.statusBar.SetMessage("Compile: no chapters to compile")
+		return
+	}
+
+	chapters, err := a.readChapters(req.files)
+	if err != nil {
+		a.statusBar.SetError("Compile failed: " + err.Error())
+		return
+	}
+	out := CompileManuscript(chapters, req.opts)
+	if len(out) == 0 {
+		out = []string{""}
+	}
+
+	if req.output != "" {
+		if err := os.WriteFile(req.output, []byte(strings.Join(out, "\n")+"\n"), 0644); err != nil {
+			a.statusBar.SetError("Compile failed: " + err.Error())
+			return
+		}
+		a.statusBar.SetMessage(fmt.Sprintf("Compiled %d file(s) to %s", len(req.files), req.output))
+		return
+	}
+	eb := NewEditorBuffer("")
+	eb.buf.Lines = out
+	eb.buf.Dirty = true
+	a.buffers = append(a.buffers, eb)
+	a.currentBuffer = len(a.buffers) - 1
+	a.statusBar.SetMessage(fmt.Sprintf("Compiled %d file(s) into a new buffer", len(req.files)))
+}
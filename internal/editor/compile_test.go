@@ -0,0 +1,162 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompileManuscript(t *testing.T) {
+	chapters := [][]string{
+		{"---", "title: One", "---", "", "# One", "", "First.", ""},
+		{""},
+		{"# Two", "Second."},
+	}
+	tests := []struct {
+		opts CompileOptions
+		want []string
+	}{
+		{CompileOptions{}, []string{"# One", "", "First.", "", "# Two", "Second."}},
+		{CompileOptions{Separator: "* * *"}, []string{"# One", "", "First.", "", "* * *", "", "# Two", "Second."}},
+		{CompileOptions{Shift: 1}, []string{"## One", "", "First.", "", "## Two", "Second."}},
+	}
+	for _, tt := range tests {
+		got := CompileManuscript(chapters, tt.opts)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: got %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestShiftHeadings(t *testing.T) {
+	lines := []string{
+		"Title",
+		"=====",
+		"",
+		"### Part",
+		"###### Deep",
+		"```",
+		"# not a heading",
+		"```",
+		"",
+		"Sub",
+		"---",
+	}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{0, lines},
+		{1, []string{"## Title", "", "#### Part", "###### Deep", "```", "# not a heading", "```", "", "### Sub"}},
+		{-2, []string{"# Title", "", "# Part", "#### Deep", "```", "# not a heading", "```", "", "# Sub"}},
+	}
+	for _, tt := range tests {
+		got := shiftHeadings(lines, tt.n)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shift %d: got %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{" a  b ", []string{"a", "b"}},
+		{`--sep "* * *" one.md`, []string{"--sep", "* * *", "one.md"}},
+		{`--sep ""`, []string{"--sep", ""}},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := splitArgs(`"open`); err == nil {
+		t.Error("unterminated quote should be an error")
+	}
+}
+
+func TestParseCompileArgs(t *testing.T) {
+	req, err := parseCompileArgs([]string{"a.md", "-o", "out.md", "--sep", "***", "--shift", "2", "b.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(req.files, []string{"a.md", "b.md"}) || req.output != "out.md" {
+		t.Errorf("files %q, output %q", req.files, req.output)
+	}
+	if req.opts != (CompileOptions{Separator: "***", Shift: 2}) || !req.hasSep || !req.hasShift {
+		t.Errorf("opts %+v", req.opts)
+	}
+	for _, args := range [][]string{{"-x"}, {"-o"}, {"--shift", "two"}} {
+		if _, err := parseCompileArgs(args); err == nil {
+			t.Errorf("%q should be an error", args)
+		}
+	}
+}
+
+func writeChapters(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompileCommandToFile(t *testing.T) {
+	dir := t.TempDir()
+	writeChapters(t, dir, map[string]string{
+		"01.md": "# One\n",
+		"02.md": "# Two\n",
+	})
+	out := filepath.Join(dir, "book.md")
+	a := newTestApp("")
+	a.executeCommand(`compile ` + filepath.Join(dir, "0*.md") + ` --shift 1 --sep "* * *" -o ` + out)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("output not written: %v (%s)", err, a.statusBar.StatusMessage)
+	}
+	if want := "## One\n\n* * *\n\n## Two\n"; string(data) != want {
+		t.Errorf("manuscript = %q, want %q", data, want)
+	}
+	if len(a.buffers) != 1 {
+		t.Error("compiling to a file should not open a buffer")
+	}
+}
+
+func TestCompileCommandToBuffer(t *testing.T) {
+	dir := t.TempDir()
+	writeChapters(t, dir, map[string]string{
+		projectFileName: "chapter = a.md\nchapter = b.md\nseparator = ***\n",
+		"a.md":          "A\n",
+		"b.md":          "B\n",
+	})
+	a := newTestApp(filepath.Join(dir, "a.md"))
+	a.currentBuf().buf.Lines = []string{"A (unsaved)"}
+	a.executeCommand("compile")
+
+	if len(a.buffers) != 2 || a.currentBuffer != 1 {
+		t.Fatalf("expected a new current buffer: %s", a.statusBar.StatusMessage)
+	}
+	eb := a.currentBuf()
+	if got := strings.Join(eb.buf.Lines, "|"); got != "A (unsaved)||***||B" {
+		t.Errorf("compiled buffer = %q", got)
+	}
+	if eb.buf.Filename != "" || !eb.buf.Dirty {
+		t.Error("compiled buffer should be unnamed and modified")
+	}
+}
+
+func TestCompileCommandNoFiles(t *testing.T) {
+	a := newTestApp(filepath.Join(t.TempDir(), "draft.md"))
+	a.executeCommand("compile")
+	if !strings.Contains(a.statusBar.StatusMessage, "no files given") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
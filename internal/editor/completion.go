@@ -0,0 +1,142 @@
+package editor
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// CompletionItem is one candidate in the completion list.
+type CompletionItem struct {
+	Text   string // Inserted in place of what was typed
+	Detail string // Shown beside it
+	Suffix string // Typed after Text unless already there, e.g. the ]] of a wiki link
+}
+
+// Completion manages the completion overlay opened by Tab in Edit mode, or
+// the list of words by the cursor opened by Ctrl-N. It replaces the text
+// from Start to the cursor on Line.
+type Completion struct {
+	ListView[CompletionItem]
+	Words bool // Completing a word, listed by the cursor
+	Title string
+	Line  int
+	Start int // Rune column where the completed text begins
+}
+
+// Show activates the overlay with the given candidates.
+func (c *Completion) Show(title string, line, start int, items []CompletionItem) {
+	c.ListView.Show(items)
+	c.Words = false
+	c.Title = title
+	c.Line = line
+	c.Start = start
+}
+
+// MoveUp moves the selection up, wrapping to the bottom.
+func (c *Completion) MoveUp() {
+	if len(c.Items) > 0 {
+		c.Selected = (c.Selected + len(c.Items) - 1) % len(c.Items)
+	}
+}
+
+// MoveDown moves the selection down, wrapping to the top.
+func (c *Completion) MoveDown() {
+	if len(c.Items) > 0 {
+		c.Selected = (c.Selected + 1) % len(c.Items)
+	}
+}
+
+// completeAtCursor opens the completion list for the text before the
+// cursor. It reports false when there is nothing to complete there, so Tab
+// keeps its other uses.
+func (a *App) completeAtCursor() bool {
+	return a.complete(false)
+}
+
+// complete lists the completions for a wiki link or citation being typed
+// before the cursor. When quiet, as while narrowing the list, a failure
+// closes it without a message.
+func (a *App) complete(quiet bool) bool {
+	return a.completeWikiLink(quiet) || a.completeCitation(quiet)
+}
+
+// acceptCompletion inserts a chosen completion in place of the text from
+// column start to the cursor, then its suffix, or steps over the suffix
+// if the text after the cursor has it already.
+func (a *App) acceptCompletion(start int, item CompletionItem) {
+	a.insertCompletion(start, item.Text)
+	if item.Suffix == "" {
+		return
+	}
+	eb := a.currentBuf()
+	if strings.HasPrefix(string([]rune(eb.buf.Lines[eb.cursorLine])[eb.cursorCol:]), item.Suffix) {
+		eb.cursorCol += utf8.RuneCountInString(item.Suffix)
+		return
+	}
+	a.insertCompletion(eb.cursorCol, item.Suffix)
+}
+
+// insertCompletion replaces the text from column start to the cursor with
+// text, as if it had been typed, so undo and track changes treat it alike.
+func (a *App) insertCompletion(start int, text string) {
+	eb := a.currentBuf()
+	typed := []rune(eb.buf.Lines[eb.cursorLine])[start:eb.cursorCol]
+	// Keep what was typed if the completion starts with it.
+	keep := 0
+	for keep < len(typed) && keep < utf8.RuneCountInString(text) && typed[keep] == []rune(text)[keep] {
+		keep++
+	}
+	for range len(typed) - keep {
+		if eb.trackChanges {
+			a.trackedDeleteChar()
+		} else {
+			a.deleteChar()
+		}
+	}
+	for _, ch := range []rune(text)[keep:] {
+		if eb.trackChanges {
+			a.trackedInsertChar(ch)
+		} else {
+			a.insertChar(ch)
+		}
+	}
+}
+
+func (a *App) handleCompletionKey(key terminal.Key) {
+	if key.Type == terminal.KeyCombo && key.Mod == terminal.ModCtrl {
+		switch key.Rune {
+		case 'n':
+			a.completion.MoveDown()
+			return
+		case 'p':
+			a.completion.MoveUp()
+			return
+		}
+	}
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.completion.Hide()
+	case terminal.KeyUp, terminal.KeyShiftTab:
+		a.completion.MoveUp()
+	case terminal.KeyDown, terminal.KeyTab:
+		a.completion.MoveDown()
+	case terminal.KeyEnter:
+		eb := a.currentBuf()
+		if a.completion.Selected < len(a.completion.Items) && eb.cursorLine == a.completion.Line {
+			a.acceptCompletion(a.completion.Start, a.completion.Items[a.completion.Selected])
+		}
+		a.completion.Hide()
+	case terminal.KeyRune, terminal.KeyBackspace:
+		// Typing goes on into the text, narrowing the list.
+		words := a.completion.Words
+		a.handleEditKey(key)
+		if words && !a.completeWord(true) || !words && !a.complete(true) {
+			a.completion.Hide()
+		}
+	default:
+		a.completion.Hide()
+		a.handleKey(key)
+	}
+}
@@ -0,0 +1,46 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestCompletionKeys(t *testing.T) {
+	a := newTestApp("notes.md")
+	a.currentBuf().buf.Lines = []string{"@"}
+	a.currentBuf().cursorCol = 1
+	a.mode = ModeEdit
+	a.completion.Show("Test", 0, 1, []CompletionItem{{Text: "one"}, {Text: "two"}, {Text: "three"}})
+
+	sendKey(a, terminal.KeyUp)
+	if a.completion.Selected != 2 {
+		t.Errorf("Up from the top should wrap to the bottom, got %d", a.completion.Selected)
+	}
+	sendKey(a, terminal.KeyTab)
+	if a.completion.Selected != 0 {
+		t.Errorf("Tab from the bottom should wrap to the top, got %d", a.completion.Selected)
+	}
+	sendKey(a, terminal.KeyShiftTab)
+	sendKey(a, terminal.KeyEnter)
+	if got := a.currentBuf().buf.Lines[0]; got != "@three" || a.completion.Active {
+		t.Errorf("Enter should insert the selection, got %q", got)
+	}
+
+	a.completion.Show("Test", 0, 1, []CompletionItem{{Text: "one"}})
+	sendKey(a, terminal.KeyEscape)
+	if a.completion.Active || a.mode != ModeEdit {
+		t.Error("Esc should close the list and stay in Edit mode")
+	}
+}
+
+func TestInsertCompletionReplacesTyped(t *testing.T) {
+	a := newTestApp("notes.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"@Smi end"}
+	eb.cursorCol = 4
+	a.insertCompletion(1, "smith")
+	if got := eb.buf.Lines[0]; got != "@smith end" || eb.cursorCol != 6 {
+		t.Errorf("got %q with the cursor at %d", got, eb.cursorCol)
+	}
+}
@@ -0,0 +1,74 @@
+package editor
+
+import "sort"
+
+// With conceal on, the markers of Markdown's inline markup are hidden so
+// that text reads as it will print: the asterisks, underscores and tildes
+// of emphasis, the backticks of code, the backslashes of escapes and the
+// addresses of links and images. The text keeps its highlighting, and the
+// cursor's line is shown as typed so that the markers can be edited.
+
+// concealedRanges returns the rune ranges of line holding inline markup
+// markers: what the preview leaves out.
+func concealedRanges(line string) [][2]int {
+	runes := []rune(line)
+	var ranges [][2]int
+	next := 0
+	for _, c := range previewInline(runes, 0, 0) {
+		if c.pos > next {
+			ranges = append(ranges, [2]int{next, c.pos})
+		}
+		next = c.pos + 1
+	}
+	if next < len(runes) {
+		ranges = append(ranges, [2]int{next, len(runes)})
+	}
+	return ranges
+}
+
+// concealMarkup hides the markup markers of lines in dls, other than in
+// line reveal, code, tables, front matter and folded headings. Markers
+// already concealed, as in notes, are left alone.
+func concealMarkup(dls []DisplayLine, lines []string, reveal int) {
+	body := frontMatterEnd(lines) + 1
+	cached, ranges := -1, [][2]int(nil)
+	for i := range dls {
+		dl := &dls[i]
+		if dl.BufferLine == reveal || dl.BufferLine < body || dl.Folded > 0 || dl.Block.InCode || IsTableLine(lines[dl.BufferLine]) {
+			continue
+		}
+		if dl.BufferLine != cached {
+			cached, ranges = dl.BufferLine, concealedRanges(lines[dl.BufferLine])
+		}
+		added := false
+		for _, r := range ranges {
+			start, end, ok := dl.span(r[0], r[1])
+			if !ok || start == end || overlapsConceal(dl.Conceal, start, end) {
+				continue
+			}
+			dl.Conceal = append(dl.Conceal, ConcealSpan{Start: start, End: end})
+			added = true
+		}
+		if added {
+			sort.Slice(dl.Conceal, func(x, y int) bool { return dl.Conceal[x].Start < dl.Conceal[y].Start })
+		}
+	}
+}
+
+// overlapsConceal reports whether the range start to end overlaps any of
+// spans.
+func overlapsConceal(spans []ConcealSpan, start, end int) bool {
+	for _, s := range spans {
+		if start < s.End && s.Start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// concealsMarkup reports whether eb's markup markers are hidden: with
+// conceal on, in Markdown buffers.
+func (a *App) concealsMarkup(eb *EditorBuffer) bool {
+	_, markdown := eb.highlighter.(MarkdownHighlighter)
+	return a.config.Conceal && markdown
+}
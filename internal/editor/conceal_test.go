@@ -0,0 +1,92 @@
+package editor
+
+import "testing"
+
+func TestConcealMarkup(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"emphasis", "Some **bold** and _it_ ~~gone~~", "Some bold and it gone"},
+		{"code", "Run `go test` now", "Run go test now"},
+		{"link", "See [the *site*](http://x.org) here", "See the site here"},
+		{"image", "A ![pic](i.png) b", "A [pic] b"},
+		{"escape", `Not \*this\*`, "Not *this*"},
+		{"plain", "snake_case and 2 * 3", "snake_case and 2 * 3"},
+		{"heading", "## A *title*", "## A title"},
+	}
+	for _, tt := range tests {
+		buf := &Buffer{Lines: []string{tt.line}}
+		dls := WrapBuffer(buf, 80)
+		concealMarkup(dls, buf.Lines, -1)
+		if got := applyConceal(dls[0].Text, dls[0].Conceal); got != tt.want {
+			t.Errorf("%s: shown %q, want %q", tt.name, got, tt.want)
+		}
+		dls = WrapBuffer(buf, 80)
+		concealMarkup(dls, buf.Lines, 0)
+		if len(dls[0].Conceal) != 0 {
+			t.Errorf("%s: cursor line concealed: %+v", tt.name, dls[0].Conceal)
+		}
+	}
+}
+
+func TestConcealMarkupSkips(t *testing.T) {
+	buf := &Buffer{Lines: []string{
+		"---",
+		"title: *raw*",
+		"---",
+		"```",
+		"x := *p",
+		"```",
+		"| *a* | b |",
+		"one *two* three *four*",
+	}}
+	dls := WrapBuffer(buf, 12)
+	concealMarkup(dls, buf.Lines, -1)
+	var shown []string
+	for _, dl := range dls {
+		if dl.BufferLine < 7 && len(dl.Conceal) != 0 {
+			t.Errorf("line %d concealed: %+v", dl.BufferLine, dl.Conceal)
+		}
+		if dl.BufferLine == 7 {
+			shown = append(shown, applyConceal(dl.Text, dl.Conceal))
+		}
+	}
+	// The wrapped line is concealed row by row.
+	if len(shown) != 2 || shown[0] != "one two" || shown[1] != "three four" {
+		t.Errorf("wrapped line shown as %q", shown)
+	}
+}
+
+func TestConcealClick(t *testing.T) {
+	a := newSplitTestApp(t)
+	eb := a.currentBuf()
+	eb.highlighter = MarkdownHighlighter{}
+	eb.buf.Lines = []string{"one", "**bold** text"}
+	a.config.Conceal = true
+
+	// Off the cursor line "bold text" is shown; clicking its t lands past
+	// the hidden markers.
+	top, _ := a.viewport.Padding(0)
+	click(a, top+2, a.viewport.LeftMargin+6)
+	if eb.cursorLine != 1 || eb.cursorCol != 9 {
+		t.Errorf("cursor = %d:%d, want 1:9", eb.cursorLine, eb.cursorCol)
+	}
+}
+
+func TestSetConceal(t *testing.T) {
+	a := newTestApp("notes.md")
+	a.executeCommand("set conceal")
+	if !a.config.Conceal || !a.concealsMarkup(a.currentBuf()) {
+		t.Error(":set conceal should hide markup in Markdown")
+	}
+	a.currentBuf().highlighter = PlainHighlighter{}
+	if a.concealsMarkup(a.currentBuf()) {
+		t.Error("plain text should not be concealed")
+	}
+	a.executeCommand("set noconceal")
+	if a.config.Conceal {
+		t.Error(":set noconceal should show markup")
+	}
+}
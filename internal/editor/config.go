@@ -0,0 +1,352 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// Config holds user settings read from the config file.
+type Config struct {
+	ColumnWidth   int         // Target width of the text column
+	TopPadding    int         // Blank rows above the text at the top of a document
+	BottomPadding int         // Blank rows between the text and the status bar
+	LeftMargin    int         // Fixed left margin in columns; -1 centres the text
+	JournalDir    string      // Directory of dated journal notes; may start with ~
+	JournalFile   string      // Path of a day's note in JournalDir, with date variables
+	JournalTmpl   string      // Template for a new day's note; empty for none
+	Author        string      // Fills {{author}} in templates
+	Theme         string      // Colours; a key of themes
+	Palette       string      // Highlight colours; a key of palettes
+	ColorDepth    string      // Colours the terminal draws; a key of colorDepths, or "auto" to detect them
+	ScreenReader  bool        // Draw for terminal screen readers
+	LowBandwidth  bool        // Send as little as possible per frame
+	CursorLine    bool        // Highlight the cursor's display line
+	Numbers       LineNumbers // Line-number gutter
+	GitGutter     bool        // Mark lines changed since git's HEAD beside the text
+	CursorStyle   string      // Cursor shape and blinking; a key of cursorStyles
+	QuitSummary   bool        // :qa lists unsaved buffers to save or discard
+	Abbreviations bool        // Expand abbreviations while typing
+	Tidy          bool        // Capitalize sentences and close up double spaces while typing
+	Conceal       bool        // Hide inline markup markers off the cursor line
+	SmartPunct    bool        // Curl quotes and make dashes and ellipses while typing
+	AmbientStatus int         // Seconds each ambient status item is shown for; 0 for off
+	WordGoal      int         // Words to write in a session; 0 for none
+	Surround      string      // Characters that wrap a selection when typed
+	PDFCommand    string      // Converter for :export pdf; empty for the first installed
+	Keys          *Keymap     // Key bindings with "map" lines applied; nil if there are none
+	Colors        *Theme      // Colours set by "color" lines, over the theme's; nil if there are none
+}
+
+// cursorStyles are the cursor_style values, with their DECSCUSR parameters.
+var cursorStyles = map[string]int{
+	"default":            terminal.CursorDefault,
+	"blinking-block":     terminal.CursorBlinkingBlock,
+	"block":              terminal.CursorBlock,
+	"blinking-underline": terminal.CursorBlinkingUnderline,
+	"underline":          terminal.CursorUnderline,
+	"blinking-bar":       terminal.CursorBlinkingBar,
+	"bar":                terminal.CursorBar,
+}
+
+// DefaultConfig returns the built-in settings.
+func DefaultConfig() Config {
+	return Config{
+		ColumnWidth:   DefaultColumnWidth,
+		TopPadding:    1,
+		BottomPadding: 0,
+		LeftMargin:    -1,
+		JournalDir:    "~/journal",
+		JournalFile:   "{{date}}.md",
+		JournalTmpl:   "journal",
+		Theme:         "dark",
+		Palette:       "default",
+		ColorDepth:    "auto",
+		CursorStyle:   "default",
+		QuitSummary:   true,
+		GitGutter:     true,
+		Abbreviations: true,
+		Surround:      DefaultSurround,
+	}
+}
+
+// configPath returns the location of the config file.
+func configPath() string {
+	return filepath.Join(configDir(), "config")
+}
+
+// LoadConfig reads the config file. A missing file yields the defaults. On
+// errors the valid settings are still applied and the problems returned.
+func LoadConfig() (Config, error) {
+	f, err := os.Open(configPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultConfig(), nil
+		}
+		return DefaultConfig(), err
+	}
+	defer f.Close()
+	return parseConfig(f)
+}
+
+// parseConfig reads "key = value" lines. Blank lines and lines starting with
+// '#' are ignored, as is anything after a " #" on a line. A "map keys =
+// action" line binds a key, and keeps the case of the key's name. A "color
+// name = colour" line sets one of the theme's colours.
+func parseConfig(r io.Reader) (Config, error) {
+	cfg := DefaultConfig()
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("line %d: expected key = value", n))
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if keys, ok := strings.CutPrefix(key, "map "); ok {
+			if cfg.Keys == nil {
+				cfg.Keys = DefaultKeymap()
+			}
+			if err := cfg.Keys.Bind(keys, value); err != nil {
+				errs = append(errs, fmt.Errorf("line %d: map: %w", n, err))
+			}
+			continue
+		}
+		if name, ok := cutColorPrefix(strings.ToLower(key)); ok {
+			if cfg.Colors == nil {
+				cfg.Colors = &Theme{}
+			}
+			if err := cfg.Colors.SetColor(strings.TrimSpace(name), value); err != nil {
+				errs = append(errs, fmt.Errorf("line %d: color: %w", n, err))
+			}
+			continue
+		}
+		if err := cfg.set(strings.ToLower(key), value); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", n, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// set applies one setting.
+func (c *Config) set(key, value string) error {
+	switch key {
+	case "column_width":
+		return setInt(&c.ColumnWidth, key, value, 20)
+	case "top_padding":
+		return setInt(&c.TopPadding, key, value, 0)
+	case "bottom_padding":
+		return setInt(&c.BottomPadding, key, value, 0)
+	case "left_margin":
+		if value == "auto" {
+			c.LeftMargin = -1
+			return nil
+		}
+		return setInt(&c.LeftMargin, key, value, 0)
+	case "author":
+		c.Author = value
+		return nil
+	case "theme":
+		if _, ok := themes[value]; !ok {
+			return fmt.Errorf("%s: want one of %s, got %q", key, choices(themes), value)
+		}
+		c.Theme = value
+		return nil
+	case "palette":
+		if _, ok := palettes[value]; !ok {
+			return fmt.Errorf("%s: want one of %s, got %q", key, choices(palettes), value)
+		}
+		c.Palette = value
+		return nil
+	case "numbers":
+		switch value {
+		case "off":
+			c.Numbers = NumbersOff
+		case "on":
+			c.Numbers = NumbersAbsolute
+		case "relative":
+			c.Numbers = NumbersRelative
+		default:
+			return fmt.Errorf("%s: want off, on or relative, got %q", key, value)
+		}
+		return nil
+	case "cursorline":
+		return setBool(&c.CursorLine, key, value)
+	case "colors", "colours":
+		if _, ok := colorDepths[value]; !ok && value != "auto" {
+			return fmt.Errorf("%s: want auto, truecolor, 256 or 16, got %q", key, value)
+		}
+		c.ColorDepth = value
+		return nil
+	case "cursor_style":
+		if _, ok := cursorStyles[value]; !ok {
+			return fmt.Errorf("%s: want one of %s, got %q", key, choices(cursorStyles), value)
+		}
+		c.CursorStyle = value
+		return nil
+	case "screen_reader":
+		return setBool(&c.ScreenReader, key, value)
+	case "low_bandwidth":
+		return setBool(&c.LowBandwidth, key, value)
+	case "quit_summary":
+		return setBool(&c.QuitSummary, key, value)
+	case "abbreviations":
+		return setBool(&c.Abbreviations, key, value)
+	case "tidy":
+		return setBool(&c.Tidy, key, value)
+	case "conceal":
+		return setBool(&c.Conceal, key, value)
+	case "git_gutter":
+		return setBool(&c.GitGutter, key, value)
+	case "smart_punctuation":
+		return setBool(&c.SmartPunct, key, value)
+	case "ambient_status":
+		if value == "off" {
+			c.AmbientStatus = 0
+			return nil
+		}
+		return setInt(&c.AmbientStatus, key, value, 1)
+	case "word_goal":
+		if value == "off" {
+			c.WordGoal = 0
+			return nil
+		}
+		return setInt(&c.WordGoal, key, value, 1)
+	case "surround":
+		if value == "off" {
+			value = ""
+		}
+		if err := validSurround(value); err != nil {
+			return err
+		}
+		c.Surround = value
+		return nil
+	case "pdf_command":
+		if value == "auto" {
+			value = ""
+		} else if err := validPDFCommand(value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.PDFCommand = value
+		return nil
+	case "journal_dir":
+		if value == "" {
+			return fmt.Errorf("%s: want a directory", key)
+		}
+		c.JournalDir = value
+		return nil
+	case "journal_file":
+		if err := validJournalFile(value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.JournalFile = value
+		return nil
+	case "journal_template":
+		if value == "off" {
+			value = ""
+		}
+		c.JournalTmpl = value
+		return nil
+	}
+	return fmt.Errorf("unknown setting %q", key)
+}
+
+// cutColorPrefix returns the colour named by the key of a "color" line,
+// spelled either way.
+func cutColorPrefix(key string) (string, bool) {
+	if name, ok := strings.CutPrefix(key, "color "); ok {
+		return name, true
+	}
+	return strings.CutPrefix(key, "colour ")
+}
+
+// theme returns the colours to draw with: the chosen theme, with the
+// palette's highlights if one other than the default is chosen, and any
+// colours set by "color" lines.
+func (c Config) theme() Theme {
+	t, ok := themes[c.Theme]
+	if !ok {
+		t = darkTheme
+	}
+	if p, ok := palettes[c.Palette]; ok && c.Palette != "default" {
+		// Colour-blind palettes change how highlights are told apart; the
+		// cursor line keeps the theme's shade for its background.
+		p.CursorLine = t.CursorLine
+		t.Palette = p
+	}
+	if c.Colors != nil {
+		t.override(c.Colors)
+	}
+	return t
+}
+
+// colorDepth returns the colours the terminal draws: as set, or for "auto"
+// as its environment says.
+func (c Config) colorDepth() terminal.ColorDepth {
+	if depth, ok := colorDepths[c.ColorDepth]; ok {
+		return depth
+	}
+	return terminal.DetectColorDepth(os.Getenv)
+}
+
+// setInt parses value into dst, requiring at least minValue.
+func setInt(dst *int, key, value string, minValue int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < minValue {
+		return fmt.Errorf("%s: want a whole number of at least %d, got %q", key, minValue, value)
+	}
+	*dst = n
+	return nil
+}
+
+// choices lists the keys of a setting's table of values, sorted.
+func choices[V any](values map[string]V) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// setBool parses an on/off value into dst.
+func setBool(dst *bool, key, value string) error {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes":
+		*dst = true
+	case "off", "false", "no":
+		*dst = false
+	default:
+		return fmt.Errorf("%s: want on or off, got %q", key, value)
+	}
+	return nil
+}
+
+// ApplyTo sets a viewport's page layout from the config.
+func (c Config) ApplyTo(vp *Viewport) {
+	vp.TargetColWidth = c.ColumnWidth
+	vp.TopPadding = c.TopPadding
+	vp.BottomPadding = c.BottomPadding
+	vp.FixedMargin = c.LeftMargin
+	vp.recalcLayout()
+}
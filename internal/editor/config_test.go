@@ -0,0 +1,139 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	input := `# Page layout
+top_padding = 3
+bottom_padding = 2   # room above the status bar
+left_margin = 8
+column_width = "72"
+journal_dir = ~/notes/daily
+journal_file = {{year}}/{{month}}/{{date}}.md
+journal_template = off
+author = Ada Lovelace
+theme = light
+palette = deuteranopia
+colors = truecolor
+screen_reader = on
+low_bandwidth = on
+cursorline = on
+numbers = relative
+git_gutter = off
+cursor_style = blinking-bar
+quit_summary = off
+abbreviations = off
+tidy = on
+ambient_status = 20
+word_goal = 1000
+pdf_command = wkhtmltopdf
+conceal = on
+smart_punctuation = on
+surround = *_
+`
+	cfg, err := parseConfig(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{ColumnWidth: 72, TopPadding: 3, BottomPadding: 2, LeftMargin: 8, JournalDir: "~/notes/daily", JournalFile: "{{year}}/{{month}}/{{date}}.md", Author: "Ada Lovelace", Theme: "light", Palette: "deuteranopia", ColorDepth: "truecolor", ScreenReader: true, LowBandwidth: true, CursorLine: true, Numbers: NumbersRelative, CursorStyle: "blinking-bar", Tidy: true, AmbientStatus: 20, WordGoal: 1000, Surround: "*_", PDFCommand: "wkhtmltopdf", Conceal: true, SmartPunct: true}
+	if cfg != want {
+		t.Errorf("parseConfig = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	input := `top_padding = lots
+colour = blue
+bottom_padding = 1
+not a setting
+column_width = 5
+`
+	cfg, err := parseConfig(strings.NewReader(input))
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{"line 1", "top_padding", "line 2", `unknown setting "colour"`, "line 4", "line 5"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+	// Valid settings still apply; invalid ones keep their defaults.
+	if cfg.BottomPadding != 1 || cfg.TopPadding != 1 || cfg.ColumnWidth != DefaultColumnWidth {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestParseConfigBool(t *testing.T) {
+	for _, value := range []string{"on", "true", "Yes"} {
+		cfg, err := parseConfig(strings.NewReader("screen_reader = " + value + "\n"))
+		if err != nil || !cfg.ScreenReader {
+			t.Errorf("screen_reader = %s: got %v, %v", value, cfg.ScreenReader, err)
+		}
+	}
+	if _, err := parseConfig(strings.NewReader("screen_reader = maybe\n")); err == nil {
+		t.Error("screen_reader = maybe should be an error")
+	}
+}
+
+func TestParseConfigUnknownCursorStyle(t *testing.T) {
+	_, err := parseConfig(strings.NewReader("cursor_style = triangle\n"))
+	if err == nil || !strings.Contains(err.Error(), "bar, blinking-bar") {
+		t.Errorf("unknown cursor style should list the choices, got %v", err)
+	}
+}
+
+func TestParseConfigUnknownPalette(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader("palette = sepia\n"))
+	if err == nil || !strings.Contains(err.Error(), "default, deuteranopia, protanopia") {
+		t.Errorf("unknown palette should list the choices, got %v", err)
+	}
+	if cfg.Palette != "default" {
+		t.Errorf("palette = %q, want the default kept", cfg.Palette)
+	}
+}
+
+func TestParseConfigLeftMarginAuto(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader("left_margin = 4\nleft_margin = auto\n"))
+	if err != nil || cfg.LeftMargin != -1 {
+		t.Errorf("left_margin = auto: got %d, %v", cfg.LeftMargin, err)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := LoadConfig()
+	if err != nil || cfg != DefaultConfig() {
+		t.Errorf("LoadConfig = %+v, %v; want defaults", cfg, err)
+	}
+}
+
+func TestLoadConfigFromXDG(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "prose"), 0755)
+	os.WriteFile(filepath.Join(dir, "prose", "config"), []byte("top_padding = 0\n"), 0644)
+
+	cfg, err := LoadConfig()
+	if err != nil || cfg.TopPadding != 0 {
+		t.Errorf("LoadConfig = %+v, %v", cfg, err)
+	}
+}
+
+func TestConfigApplyTo(t *testing.T) {
+	vp := NewViewport(100, 30)
+	Config{ColumnWidth: 60, TopPadding: 2, BottomPadding: 1, LeftMargin: 10}.ApplyTo(vp)
+	if vp.LeftMargin != 10 {
+		t.Errorf("LeftMargin = %d, want 10", vp.LeftMargin)
+	}
+	if got := vp.VisibleLines(0); got != 30-1-2-1 {
+		t.Errorf("VisibleLines(0) = %d, want 26", got)
+	}
+	if got := vp.VisibleLines(5); got != 30-1-1 {
+		t.Errorf("VisibleLines(5) = %d, want 28 (no top padding once scrolled)", got)
+	}
+}
@@ -0,0 +1,375 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CriticKind is the type of a CriticMarkup revision mark.
+type CriticKind int
+
+const (
+	CriticInsert  CriticKind = iota // {++text++}
+	CriticDelete                    // {--text--}
+	CriticComment                   // {>>text<<}
+)
+
+// CriticMark is a tracked change stored in the text using CriticMarkup.
+type CriticMark struct {
+	Kind  CriticKind
+	Line  int
+	Start int    // Rune offset of the opening "{"
+	End   int    // Rune offset just past the closing "}"
+	Text  string // Content between the delimiters
+}
+
+// criticDelimLen is the length of each delimiter: "{++" and "++}", "{--" and
+// "--}", "{>>" and "<<}".
+const criticDelimLen = 3
+
+var reCritic = regexp.MustCompile(`\{\+\+(.*?)\+\+\}|\{--(.*?)--\}|\{>>(.*?)<<\}`)
+
+// Styles for tracked changes: insertions underlined, deletions struck through
+// and comments in italics.
+var criticStyles = map[CriticKind]StyleSpan{
+	CriticInsert:  {Code: "\x1b[4;32m", Reset: "\x1b[24;39m"},
+	CriticDelete:  {Code: "\x1b[9;31m", Reset: "\x1b[29;39m"},
+	CriticComment: {Code: "\x1b[3;35m", Reset: "\x1b[23;39m"},
+}
+
+// criticDelimStyle dims the delimiters on the cursor line, where they show.
+var criticDelimStyle = StyleSpan{Code: "\x1b[90m", Reset: "\x1b[39m"}
+
+// parseCriticLine returns the revision marks in a single line.
+func parseCriticLine(line string, lineNo int) []CriticMark {
+	if !strings.Contains(line, "{++") && !strings.Contains(line, "{--") && !strings.Contains(line, "{>>") {
+		return nil
+	}
+	var marks []CriticMark
+	for _, m := range reCritic.FindAllStringSubmatchIndex(line, -1) {
+		mark := CriticMark{
+			Line:  lineNo,
+			Start: len([]rune(line[:m[0]])),
+			End:   len([]rune(line[:m[1]])),
+		}
+		switch {
+		case m[2] >= 0:
+			mark.Kind = CriticInsert
+			mark.Text = line[m[2]:m[3]]
+		case m[4] >= 0:
+			mark.Kind = CriticDelete
+			mark.Text = line[m[4]:m[5]]
+		default:
+			mark.Kind = CriticComment
+			mark.Text = line[m[6]:m[7]]
+		}
+		marks = append(marks, mark)
+	}
+	return marks
+}
+
+// ExtractCriticMarks returns every revision mark in lines, in document order,
+// skipping fenced code blocks.
+func ExtractCriticMarks(lines []string) []CriticMark {
+	var marks []CriticMark
+	var blocks []BlockState
+	for i, line := range lines {
+		found := parseCriticLine(line, i)
+		if len(found) == 0 {
+			continue
+		}
+		if blocks == nil {
+			blocks = ComputeBlockStates(lines)
+		}
+		if !blocks[i].InCode {
+			marks = append(marks, found...)
+		}
+	}
+	return marks
+}
+
+// contentStart and contentEnd bound the text between the delimiters.
+func (m CriticMark) contentStart() int { return m.Start + criticDelimLen }
+func (m CriticMark) contentEnd() int   { return m.End - criticDelimLen }
+
+// Resolve returns the text that replaces the mark when the change is
+// accepted (accept true) or rejected. Comments are removed either way.
+func (m CriticMark) Resolve(accept bool) string {
+	switch m.Kind {
+	case CriticInsert:
+		if accept {
+			return m.Text
+		}
+	case CriticDelete:
+		if !accept {
+			return m.Text
+		}
+	}
+	return ""
+}
+
+// stripCriticMarkup returns line as it reads with every change accepted and
+// comments removed.
+func stripCriticMarkup(line string) string {
+	marks := parseCriticLine(line, 0)
+	if len(marks) == 0 {
+		return line
+	}
+	runes := []rune(line)
+	var b strings.Builder
+	prev := 0
+	for _, m := range marks {
+		b.WriteString(string(runes[prev:m.Start]))
+		b.WriteString(m.Resolve(true))
+		prev = m.End
+	}
+	b.WriteString(string(runes[prev:]))
+	return b.String()
+}
+
+// criticMarkAt returns the mark in line covering rune offset col.
+func criticMarkAt(line string, col int) (CriticMark, bool) {
+	for _, m := range parseCriticLine(line, 0) {
+		if col >= m.Start && col < m.End {
+			return m, true
+		}
+	}
+	return CriticMark{}, false
+}
+
+// styleCriticMarks styles each revision mark in the display lines. On the
+// reveal line the delimiters are shown dimmed; elsewhere they are hidden so
+// only the styled change is seen.
+func styleCriticMarks(dls []DisplayLine, marks []CriticMark, reveal int) {
+	mi := 0
+	for i := range dls {
+		dl := &dls[i]
+		if dl.Folded > 0 {
+			continue
+		}
+		for mi < len(marks) && marks[mi].Line < dl.BufferLine {
+			mi++
+		}
+		for _, m := range marks[mi:] {
+			if m.Line != dl.BufferLine {
+				break
+			}
+			style := criticStyles[m.Kind]
+			if s, e, ok := dl.span(m.contentStart(), m.contentEnd()); ok {
+				style.Start, style.End = s, e
+				dl.Styles = append(dl.Styles, style)
+			}
+			for _, delim := range [][2]int{{m.Start, m.contentStart()}, {m.contentEnd(), m.End}} {
+				s, e, ok := dl.span(delim[0], delim[1])
+				if !ok {
+					continue
+				}
+				if dl.BufferLine == reveal {
+					ds := criticDelimStyle
+					ds.Start, ds.End = s, e
+					dl.Styles = append(dl.Styles, ds)
+				} else {
+					dl.Conceal = append(dl.Conceal, ConcealSpan{Start: s, End: e})
+				}
+			}
+		}
+	}
+}
+
+// toggleTrackChanges turns revision tracking on or off for the current buffer.
+func (a *App) toggleTrackChanges() {
+	eb := a.currentBuf()
+	eb.trackChanges = !eb.trackChanges
+	if eb.trackChanges {
+		a.statusBar.SetMessage("Track changes on")
+	} else {
+		a.statusBar.SetMessage("Track changes off")
+	}
+}
+
+// trackedInsertChar types ch as a tracked insertion. Inside (or just after)
+// an insertion mark the character joins it; elsewhere a new mark is started.
+func (a *App) trackedInsertChar(ch rune) {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	col := eb.cursorCol
+	for _, m := range parseCriticLine(line, eb.cursorLine) {
+		if m.Kind != CriticInsert {
+			continue
+		}
+		if col == m.End {
+			eb.cursorCol = m.contentEnd()
+			col = eb.cursorCol
+		}
+		if col >= m.contentStart() && col <= m.contentEnd() {
+			a.insertChar(ch)
+			return
+		}
+	}
+	// A new mark can't go inside a delimiter or a deletion: move past it.
+	if m, ok := criticMarkAt(line, col); ok && col > m.Start {
+		col = m.End
+	}
+	runes := []rune(line)
+	newLine := string(runes[:col]) + "{++" + string(ch) + "++}" + string(runes[col:])
+	a.replaceLines(eb.cursorLine, 1, []string{newLine})
+	eb.cursorCol = col + criticDelimLen + 1
+	eb.ScheduleSpellCheck()
+}
+
+// trackedDeleteChar handles Backspace while tracking. Text typed in this
+// revision is removed outright; other text is marked as deleted, extending an
+// adjacent deletion. At the start of a line the lines are joined untracked.
+func (a *App) trackedDeleteChar() {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	col := eb.cursorCol
+	for {
+		if col == 0 {
+			eb.cursorCol = 0
+			a.deleteChar()
+			return
+		}
+		m, ok := criticMarkAt(line, col-1)
+		if !ok {
+			break
+		}
+		if m.Kind == CriticInsert && col > m.contentStart() {
+			a.deleteInInsertion(m, min(col, m.contentEnd())-1)
+			return
+		}
+		// Step over deletions and opening delimiters.
+		col = m.Start
+	}
+
+	runes := []rune(line)
+	deleted := string(runes[col-1])
+	rest := string(runes[col:])
+	if m, ok := criticMarkAt(line, col); ok && m.Kind == CriticDelete && m.Start == col {
+		deleted += m.Text
+		rest = string(runes[m.End:])
+	}
+	newLine := string(runes[:col-1]) + "{--" + deleted + "--}" + rest
+	a.replaceLines(eb.cursorLine, 1, []string{newLine})
+	eb.cursorCol = col - 1
+	eb.ScheduleSpellCheck()
+}
+
+// trackedDeleteCharForward handles Delete while tracking, marking the
+// character under the cursor as deleted and leaving the cursor after the mark.
+func (a *App) trackedDeleteCharForward() {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	col := eb.cursorCol
+	for {
+		if col >= len([]rune(line)) {
+			eb.cursorCol = col
+			a.deleteCharForward()
+			return
+		}
+		m, ok := criticMarkAt(line, col)
+		if !ok {
+			break
+		}
+		if m.Kind == CriticInsert && col >= m.contentStart() && col < m.contentEnd() {
+			a.deleteInInsertion(m, col)
+			return
+		}
+		col = m.End
+	}
+
+	runes := []rune(line)
+	before := string(runes[:col])
+	deleted := string(runes[col])
+	if m, ok := criticMarkAt(line, col-1); ok && col > 0 && m.Kind == CriticDelete && m.End == col {
+		before = string(runes[:m.Start])
+		deleted = m.Text + deleted
+	}
+	newLine := before + "{--" + deleted + "--}" + string(runes[col+1:])
+	a.replaceLines(eb.cursorLine, 1, []string{newLine})
+	eb.cursorCol = len([]rune(before)) + 2*criticDelimLen + len([]rune(deleted))
+	eb.ScheduleSpellCheck()
+}
+
+// deleteInInsertion removes the character at pos from an insertion mark,
+// dropping the mark entirely once it is empty.
+func (a *App) deleteInInsertion(m CriticMark, pos int) {
+	eb := a.currentBuf()
+	runes := []rune(eb.buf.Lines[eb.cursorLine])
+	if m.contentEnd()-m.contentStart() <= 1 {
+		a.replaceLines(eb.cursorLine, 1, []string{string(runes[:m.Start]) + string(runes[m.End:])})
+		eb.cursorCol = m.Start
+	} else {
+		a.replaceLines(eb.cursorLine, 1, []string{string(runes[:pos]) + string(runes[pos+1:])})
+		eb.cursorCol = pos
+	}
+	eb.ScheduleSpellCheck()
+}
+
+// trackedDeleteLine marks the whole current line as deleted (dd while tracking).
+func (a *App) trackedDeleteLine() {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	if line == "" {
+		return
+	}
+	a.yankBuffer = line
+	a.yankCharwise = false
+	if m, ok := criticMarkAt(line, 0); ok && m.Kind == CriticDelete && m.Start == 0 && m.End == len([]rune(line)) {
+		return
+	}
+	a.replaceLines(eb.cursorLine, 1, []string{"{--" + line + "--}"})
+	eb.cursorCol = 0
+	eb.ScheduleSpellCheck()
+}
+
+// resolveChangeAtCursor accepts or rejects the revision mark under the cursor.
+func (a *App) resolveChangeAtCursor(accept bool) {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	m, ok := criticMarkAt(line, eb.cursorCol)
+	if !ok {
+		a.statusBar.SetMessage("No change under cursor")
+		return
+	}
+	runes := []rune(line)
+	a.replaceLines(eb.cursorLine, 1, []string{string(runes[:m.Start]) + m.Resolve(accept) + string(runes[m.End:])})
+	eb.cursorCol = m.Start
+	eb.ScheduleSpellCheck()
+	switch {
+	case m.Kind == CriticComment:
+		a.statusBar.SetMessage("Comment removed")
+	case accept:
+		a.statusBar.SetMessage("Change accepted")
+	default:
+		a.statusBar.SetMessage("Change rejected")
+	}
+}
+
+// resolveAllChanges accepts or rejects every revision mark in the buffer as
+// a single undoable change.
+func (a *App) resolveAllChanges(accept bool) {
+	eb := a.currentBuf()
+	marks := ExtractCriticMarks(eb.buf.Lines)
+	if len(marks) == 0 {
+		a.statusBar.SetMessage("No changes")
+		return
+	}
+	first, last := marks[0].Line, marks[len(marks)-1].Line
+	lines := append([]string(nil), eb.buf.Lines[first:last+1]...)
+	// Resolve right to left so earlier offsets on a line stay valid.
+	for i := len(marks) - 1; i >= 0; i-- {
+		m := marks[i]
+		runes := []rune(lines[m.Line-first])
+		lines[m.Line-first] = string(runes[:m.Start]) + m.Resolve(accept) + string(runes[m.End:])
+	}
+	a.replaceLines(first, last-first+1, lines)
+	eb.cursorCol = min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+	eb.ScheduleSpellCheck()
+	verb := "Rejected"
+	if accept {
+		verb = "Accepted"
+	}
+	a.statusBar.SetMessage(fmt.Sprintf("%s %d change(s)", verb, len(marks)))
+}
@@ -0,0 +1,232 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestParseCriticLine(t *testing.T) {
+	got := parseCriticLine("The {--old--}{++new++} café{++!++}", 3)
+	want := []CriticMark{
+		{Kind: CriticDelete, Line: 3, Start: 4, End: 13, Text: "old"},
+		{Kind: CriticInsert, Line: 3, Start: 13, End: 22, Text: "new"},
+		{Kind: CriticInsert, Line: 3, Start: 27, End: 34, Text: "!"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseCriticLine = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("mark %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCriticComment(t *testing.T) {
+	got := parseCriticLine("Fine.{>>too short?<<}", 0)
+	want := CriticMark{Kind: CriticComment, Start: 5, End: 21, Text: "too short?"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("parseCriticLine = %+v, want %+v", got, want)
+	}
+}
+
+func TestStripCriticMarkup(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"The {--cat--}{++dog++} sat.", "The dog sat."},
+		{"word{++s++}", "words"},
+		{"Done.{>>really?<<} Next.", "Done. Next."},
+		{"{-- --}", ""},
+	}
+	for _, tt := range tests {
+		if got := stripCriticMarkup(tt.line); got != tt.want {
+			t.Errorf("stripCriticMarkup(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExtractCriticMarksSkipsCode(t *testing.T) {
+	lines := []string{"```", "{++code++}", "```", "{--text--}"}
+	marks := ExtractCriticMarks(lines)
+	if len(marks) != 1 || marks[0].Line != 3 {
+		t.Errorf("ExtractCriticMarks = %+v", marks)
+	}
+}
+
+func TestCriticResolve(t *testing.T) {
+	ins := CriticMark{Kind: CriticInsert, Text: "a"}
+	del := CriticMark{Kind: CriticDelete, Text: "b"}
+	if ins.Resolve(true) != "a" || ins.Resolve(false) != "" {
+		t.Error("insertion resolves wrongly")
+	}
+	if del.Resolve(true) != "" || del.Resolve(false) != "b" {
+		t.Error("deletion resolves wrongly")
+	}
+	com := CriticMark{Kind: CriticComment, Text: "c"}
+	if com.Resolve(true) != "" || com.Resolve(false) != "" {
+		t.Error("comments should be removed either way")
+	}
+}
+
+// trackingApp returns a test app in Edit mode with track changes on.
+func trackingApp(line string, col int) (*App, *EditorBuffer) {
+	a := newTestAppWithLines(line)
+	a.mode = ModeEdit
+	eb := a.currentBuf()
+	eb.cursorCol = col
+	eb.trackChanges = true
+	return a, eb
+}
+
+func TestTrackedTyping(t *testing.T) {
+	a, eb := trackingApp("The cat.", 4)
+	sendKeys(a, "big ")
+	if got := eb.buf.Lines[0]; got != "The {++big ++}cat." {
+		t.Errorf("after typing: %q", got)
+	}
+	if eb.cursorCol != 11 {
+		t.Errorf("cursor = %d, want 11 (inside the insertion)", eb.cursorCol)
+	}
+
+	// Backspace inside the insertion removes typed text outright.
+	sendKey(a, terminal.KeyBackspace)
+	if got := eb.buf.Lines[0]; got != "The {++big++}cat." {
+		t.Errorf("after backspace in insertion: %q", got)
+	}
+	for range 3 {
+		sendKey(a, terminal.KeyBackspace)
+	}
+	if got := eb.buf.Lines[0]; got != "The cat." {
+		t.Errorf("emptied insertion should vanish: %q", got)
+	}
+}
+
+func TestTrackedBackspaceMarksDeletion(t *testing.T) {
+	a, eb := trackingApp("The cat.", 7)
+	for range 3 {
+		sendKey(a, terminal.KeyBackspace)
+	}
+	if got := eb.buf.Lines[0]; got != "The {--cat--}." {
+		t.Errorf("after backspaces: %q", got)
+	}
+	if eb.cursorCol != 4 {
+		t.Errorf("cursor = %d, want 4", eb.cursorCol)
+	}
+
+	// Typing now starts an insertion before the deletion.
+	sendKeys(a, "dog")
+	if got := eb.buf.Lines[0]; got != "The {++dog++}{--cat--}." {
+		t.Errorf("after typing replacement: %q", got)
+	}
+}
+
+func TestTrackedDeleteForward(t *testing.T) {
+	a, eb := trackingApp("The cat.", 4)
+	for range 3 {
+		sendKey(a, terminal.KeyDelete)
+	}
+	if got := eb.buf.Lines[0]; got != "The {--cat--}." {
+		t.Errorf("after deletes: %q", got)
+	}
+	if eb.cursorCol != 13 {
+		t.Errorf("cursor = %d, want 13 (after the mark)", eb.cursorCol)
+	}
+}
+
+func TestTrackedDeleteLine(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Cut this.", "Keep this."}
+	eb.trackChanges = true
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: 'd'})
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: 'd'})
+	if got := eb.buf.Lines; len(got) != 2 || got[0] != "{--Cut this.--}" {
+		t.Errorf("after dd: %q", got)
+	}
+}
+
+func TestAcceptRejectAtCursor(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The {--cat--}{++dog++} sat."}
+
+	eb.cursorCol = 6
+	a.executeCommand("accept")
+	if got := eb.buf.Lines[0]; got != "The {++dog++} sat." {
+		t.Errorf("after accept: %q", got)
+	}
+	a.executeCommand("reject")
+	if got := eb.buf.Lines[0]; got != "The  sat." {
+		t.Errorf("after reject: %q", got)
+	}
+
+	eb.buf.Lines = []string{"Fine.{>>too short?<<}"}
+	eb.cursorCol = 8
+	a.executeCommand("accept")
+	if got := eb.buf.Lines[0]; got != "Fine." {
+		t.Errorf("accepting a comment should remove it: %q", got)
+	}
+
+	eb.cursorCol = 0
+	a.executeCommand("accept")
+	if a.statusBar.StatusMessage != "No change under cursor" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestAcceptAllAndRejectAll(t *testing.T) {
+	lines := []string{"A {--b--}{++c++}.", "plain", "{++d++} e{--f--}"}
+
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = append([]string(nil), lines...)
+	a.executeCommand("accept all")
+	if got := strings.Join(eb.buf.Lines, "|"); got != "A c.|plain|d e" {
+		t.Errorf("accept all: %q", got)
+	}
+	a.undoAction()
+	if got := strings.Join(eb.buf.Lines, "|"); got != strings.Join(lines, "|") {
+		t.Errorf("undo should restore every change at once: %q", got)
+	}
+
+	a.executeCommand("reject all")
+	if got := strings.Join(eb.buf.Lines, "|"); got != "A b.|plain| ef" {
+		t.Errorf("reject all: %q", got)
+	}
+}
+
+func TestStyleCriticMarks(t *testing.T) {
+	buf := &Buffer{Lines: []string{"a {++b++} c"}}
+	marks := ExtractCriticMarks(buf.Lines)
+
+	dls := WrapBuffer(buf, 60)
+	styleCriticMarks(dls, marks, -1)
+	if len(dls[0].Conceal) != 2 {
+		t.Fatalf("delimiters should be concealed off the cursor line: %+v", dls[0].Conceal)
+	}
+	text := applyConceal(applyStyles(dls[0].Text, dls[0].Styles), dls[0].Conceal)
+	if visibleLen(text) != 5 || !strings.Contains(text, "\x1b[4;32mb\x1b[24;39m") {
+		t.Errorf("rendered = %q", text)
+	}
+
+	dls = WrapBuffer(buf, 60)
+	styleCriticMarks(dls, marks, 0)
+	if len(dls[0].Conceal) != 0 || len(dls[0].Styles) != 3 {
+		t.Errorf("cursor line should show styled delimiters: %+v", dls[0])
+	}
+}
+
+func TestApplyStylesSurvivesResets(t *testing.T) {
+	spans := []StyleSpan{{Start: 1, End: 3, Code: "<", Reset: ">"}}
+	if got := applyStyles("a\x1b[0mbc", spans); got != "a\x1b[0m<bc>" {
+		t.Errorf("applyStyles = %q", got)
+	}
+	if got := applyStyles("ab\x1b[0mcd", spans); got != "a<b\x1b[0m<c>d" {
+		t.Errorf("applyStyles = %q", got)
+	}
+}
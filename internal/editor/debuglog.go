@@ -0,0 +1,121 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// NewDebugLogger returns a structured logger that writes every debug record
+// to w. Used by --debug; the screen is never written to.
+func NewDebugLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// SetLogger directs debug logging to l. A nil logger disables logging.
+func (a *App) SetLogger(l *slog.Logger) {
+	a.logger = l
+}
+
+// debugLog records a debug-level event if logging is enabled.
+func (a *App) debugLog(msg string, args ...any) {
+	if a.logger != nil {
+		a.logger.Debug(msg, args...)
+	}
+}
+
+// errorLog records an error if logging is enabled.
+func (a *App) errorLog(msg string, err error, args ...any) {
+	if a.logger != nil {
+		a.logger.Error(msg, append([]any{"err", err}, args...)...)
+	}
+}
+
+// logInput records an input event along with its raw bytes.
+func (a *App) logInput(event terminal.InputEvent) {
+	if a.logger == nil {
+		return
+	}
+	switch event.Type {
+	case terminal.EventMouse:
+		m := event.Mouse
+		a.logger.Debug("input", "type", "mouse", "button", int(m.Button), "row", m.Row, "col", m.Col, "press", m.Press, "raw", event.Raw)
+	case terminal.EventResize:
+		a.logger.Debug("input", "type", "resize")
+	case terminal.EventTerminate:
+		a.logger.Debug("input", "type", "terminate", "signal", fmt.Sprint(event.Signal))
+	case terminal.EventSuspend:
+		a.logger.Debug("input", "type", "suspend")
+	default:
+		a.logger.Debug("input", "type", "key", "key", keyName(event.Key), "mode", a.mode.String(), "raw", event.Raw)
+	}
+}
+
+// keyName returns a readable name for a key, for logs, with any
+// modifiers, e.g. "Ctrl-S" or "Shift-Up".
+func keyName(k terminal.Key) string {
+	name := baseKeyName(k)
+	for _, m := range []struct {
+		mod  terminal.Modifier
+		name string
+	}{{terminal.ModShift, "Shift-"}, {terminal.ModAlt, "Alt-"}, {terminal.ModCtrl, "Ctrl-"}} {
+		if k.Mod&m.mod != 0 {
+			name = m.name + name
+		}
+	}
+	return name
+}
+
+// baseKeyName names a key without its modifiers.
+func baseKeyName(k terminal.Key) string {
+	switch k.Type {
+	case terminal.KeyRune:
+		return string(k.Rune)
+	case terminal.KeyCombo:
+		return strings.ToUpper(string(k.Rune))
+	case terminal.KeyEscape:
+		return "Esc"
+	case terminal.KeyEnter:
+		return "Enter"
+	case terminal.KeyBackspace:
+		return "Backspace"
+	case terminal.KeyTab:
+		return "Tab"
+	case terminal.KeyShiftTab:
+		return "Shift-Tab"
+	case terminal.KeyUp:
+		return "Up"
+	case terminal.KeyDown:
+		return "Down"
+	case terminal.KeyLeft:
+		return "Left"
+	case terminal.KeyRight:
+		return "Right"
+	case terminal.KeyCtrlZ:
+		return "Ctrl-Z"
+	case terminal.KeyCtrlY:
+		return "Ctrl-Y"
+	case terminal.KeyCtrlR:
+		return "Ctrl-R"
+	case terminal.KeyCtrlD:
+		return "Ctrl-D"
+	case terminal.KeyCtrlU:
+		return "Ctrl-U"
+	case terminal.KeyCtrlO:
+		return "Ctrl-O"
+	case terminal.KeyHome:
+		return "Home"
+	case terminal.KeyEnd:
+		return "End"
+	case terminal.KeyDelete:
+		return "Delete"
+	case terminal.KeyPgUp:
+		return "PgUp"
+	case terminal.KeyPgDn:
+		return "PgDn"
+	}
+	return "Unknown"
+}
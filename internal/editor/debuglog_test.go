@@ -0,0 +1,59 @@
+package editor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestDebugLogRecordsCommandsAndInput(t *testing.T) {
+	var out bytes.Buffer
+	a := newTestApp("test.txt")
+	a.SetLogger(NewDebugLogger(&out))
+
+	a.logInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyUp}, Raw: []byte("\x1b[A")})
+	a.executeCommand("spell-nope")
+
+	log := out.String()
+	if !strings.Contains(log, "msg=input") || !strings.Contains(log, "key=Up") {
+		t.Errorf("expected input record, got:\n%s", log)
+	}
+	if !strings.Contains(log, "msg=command") || !strings.Contains(log, "cmd=spell-nope") {
+		t.Errorf("expected command record, got:\n%s", log)
+	}
+}
+
+func TestDebugLogDisabledByDefault(t *testing.T) {
+	a := newTestApp("test.txt")
+	// Must not panic without a logger.
+	a.debugLog("nothing")
+	a.logInput(terminal.InputEvent{Type: terminal.EventResize})
+}
+
+func TestModeString(t *testing.T) {
+	if ModeLineSelect.String() != "LINE-SELECT" {
+		t.Errorf("ModeLineSelect.String() = %q", ModeLineSelect.String())
+	}
+	if ModeVisual.String() != "VISUAL" {
+		t.Errorf("ModeVisual.String() = %q", ModeVisual.String())
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		key  terminal.Key
+		want string
+	}{
+		{terminal.Key{Type: terminal.KeyRune, Rune: 'x'}, "x"},
+		{terminal.Key{Type: terminal.KeyCombo, Rune: 's', Mod: terminal.ModCtrl}, "Ctrl-S"},
+		{terminal.Key{Type: terminal.KeyUp, Mod: terminal.ModShift}, "Shift-Up"},
+		{terminal.Key{Type: terminal.KeyLeft, Mod: terminal.ModShift | terminal.ModCtrl}, "Ctrl-Shift-Left"},
+	}
+	for _, tt := range tests {
+		if got := keyName(tt.key); got != tt.want {
+			t.Errorf("keyName(%+v) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
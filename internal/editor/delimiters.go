@@ -0,0 +1,240 @@
+package editor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// lineRange is a run of columns on one line.
+type lineRange struct {
+	Line       int
+	Start, End int // Rune columns, End exclusive
+}
+
+// Delimiter is an emphasis or math delimiter left without a partner, which
+// renders as a stray character or swallows the text after it.
+type Delimiter struct {
+	Line, Col int
+	Text      string // The unmatched run, e.g. "**" or "$"
+}
+
+// isDelimiterWord reports whether a spelling error's word is an unmatched
+// delimiter rather than a word.
+func isDelimiterWord(word string) bool {
+	return word != "" && strings.Trim(word, "*_$") == ""
+}
+
+// markupChar is one character of a paragraph and where it is.
+type markupChar struct {
+	r         rune
+	line, col int
+}
+
+// ScanMarkup finds the math in a Markdown document, inline $...$ and
+// display $$...$$, and the emphasis and math delimiters that have no
+// partner. Both are looked for within paragraphs, as they can't span a
+// blank line. Code, front matter and link targets are skipped.
+func ScanMarkup(lines []string) (math []lineRange, unmatched []Delimiter) {
+	blocks := ComputeBlockStates(lines)
+	var para []markupChar
+	flush := func() {
+		m, u := scanParagraph(para)
+		math = append(math, m...)
+		unmatched = append(unmatched, u...)
+		para = para[:0]
+	}
+	for li := frontMatterEnd(lines) + 1; li < len(lines); li++ {
+		line := lines[li]
+		if blocks[li].InCode || strings.TrimSpace(line) == "" || reSceneBreak.MatchString(line) {
+			flush()
+			continue
+		}
+		// Headings and table rows stand alone.
+		alone := reHeading.MatchString(line) || IsTableLine(line)
+		if alone {
+			flush()
+		} else if len(para) > 0 {
+			para = append(para, markupChar{r: '\n', line: li, col: -1})
+		}
+		for col, r := range []rune(line) {
+			para = append(para, markupChar{r: r, line: li, col: col})
+		}
+		if alone {
+			flush()
+		}
+	}
+	flush()
+	return math, unmatched
+}
+
+// delimRun is a run of '*' or '_' that may open or close emphasis.
+type delimRun struct {
+	ch          rune
+	at, count   int // Index of the first character, and how many are unmatched
+	open, close bool
+}
+
+// scanParagraph does the work of ScanMarkup for one paragraph.
+func scanParagraph(text []markupChar) (math []lineRange, unmatched []Delimiter) {
+	n := len(text)
+	at := func(i int) rune {
+		if i < 0 || i >= n {
+			return '\n'
+		}
+		return text[i].r
+	}
+	runLen := func(i int, ch rune) int {
+		j := i
+		for j < n && text[j].r == ch {
+			j++
+		}
+		return j - i
+	}
+	report := func(i, count int) {
+		unmatched = append(unmatched, Delimiter{Line: text[i].line, Col: text[i].col, Text: strings.Repeat(string(text[i].r), count)})
+	}
+	addMath := func(from, to int) {
+		for i := from; i < to; i++ {
+			c := text[i]
+			if c.col < 0 {
+				continue
+			}
+			if k := len(math) - 1; k >= 0 && math[k].Line == c.line && math[k].End == c.col {
+				math[k].End++
+			} else {
+				math = append(math, lineRange{Line: c.line, Start: c.col, End: c.col + 1})
+			}
+		}
+	}
+
+	var runs []*delimRun
+	for i := 0; i < n; {
+		r := text[i].r
+		switch {
+		case r == '\\':
+			i += 2
+		case r == '`':
+			// A code span closes with a run of as many backticks.
+			k := runLen(i, '`')
+			j := i + k
+			for j < n && !(text[j].r == '`' && runLen(j, '`') == k) {
+				j += max(1, runLen(j, '`'))
+			}
+			if j < n {
+				i = j + k
+			} else {
+				i += k
+			}
+		case r == ']' && at(i+1) == '(':
+			j := i + 2
+			for j < n && text[j].r != ')' {
+				j++
+			}
+			i = j + 1
+		case r == '<' && i+1 < n && unicode.IsLetter(at(i+1)):
+			// An autolink or HTML tag.
+			j := i + 1
+			for j < n && text[j].r != '>' && text[j].r != '\n' {
+				j++
+			}
+			if j < n && text[j].r == '>' {
+				i = j + 1
+			} else {
+				i++
+			}
+		case r == '$' && at(i+1) == '$':
+			j := i + 2
+			for j < n && !(text[j].r == '$' && at(j+1) == '$') {
+				if text[j].r == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < n {
+				addMath(i, j+2)
+				i = j + 2
+			} else {
+				report(i, 2)
+				i += 2
+			}
+		case r == '$':
+			// Math opens on a '$' before a non-space and closes on one after
+			// a non-space that isn't followed by a digit, as in Pandoc.
+			if unicode.IsSpace(at(i + 1)) {
+				i++
+				break
+			}
+			j := i + 2
+			for j < n && !(text[j].r == '$' && !unicode.IsSpace(at(j-1)) && !unicode.IsDigit(at(j+1))) {
+				if text[j].r == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < n {
+				addMath(i, j+1)
+				i = j + 1
+				break
+			}
+			// An amount of money, as in "$5", is left alone.
+			if !unicode.IsDigit(at(i + 1)) {
+				report(i, 1)
+			}
+			i++
+		case r == '*' || r == '_':
+			k := runLen(i, r)
+			runs = append(runs, newDelimRun(r, i, k, at(i-1), at(i+k)))
+			i += k
+		default:
+			i++
+		}
+	}
+
+	// Each run closes the nearest open run of the same character, as far as
+	// it can, and opens what's left.
+	var open []*delimRun
+	for _, run := range runs {
+		if run.close {
+			for k := len(open) - 1; k >= 0 && run.count > 0; k-- {
+				op := open[k]
+				if op.ch != run.ch {
+					continue
+				}
+				m := min(op.count, run.count)
+				op.count -= m
+				run.count -= m
+				if op.count == 0 {
+					open = append(open[:k], open[k+1:]...)
+				}
+			}
+		}
+		if run.count == 0 {
+			continue
+		}
+		if run.open {
+			open = append(open, run)
+		} else if run.close {
+			report(run.at, run.count)
+		}
+	}
+	for _, run := range open {
+		report(run.at, run.count)
+	}
+	return math, unmatched
+}
+
+// newDelimRun classifies a run of count ch between prev and next by the
+// CommonMark flanking rules. A '_' inside a word, as in snake_case, is
+// neither opener nor closer; nor is a '*' between spaces, as in a list
+// bullet or "2 * 3".
+func newDelimRun(ch rune, at, count int, prev, next rune) *delimRun {
+	punct := func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) }
+	left := !unicode.IsSpace(next) && (!punct(next) || unicode.IsSpace(prev) || punct(prev))
+	right := !unicode.IsSpace(prev) && (!punct(prev) || unicode.IsSpace(next) || punct(next))
+	run := &delimRun{ch: ch, at: at, count: count, open: left, close: right}
+	if ch == '_' {
+		run.open = left && (!right || punct(prev))
+		run.close = right && (!left || punct(next))
+	}
+	return run
+}
@@ -0,0 +1,83 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+func TestScanMarkupUnmatched(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string // "line:col text" of each unmatched delimiter
+	}{
+		{"balanced emphasis", []string{"Some *italic* and **bold** and _under_ text."}, nil},
+		{"emphasis across lines", []string{"It was *very", "late* indeed."}, nil},
+		{"unclosed italic", []string{"It was *very late."}, []string{"0:7 *"}},
+		{"unclosed bold", []string{"A **bold claim. And *this*."}, []string{"0:2 **"}},
+		{"stray closer", []string{"It ended* there."}, []string{"0:8 *"}},
+		{"bold italic half closed", []string{"***Both* now."}, []string{"0:0 **"}},
+		{"snake case", []string{"Call my_long_name here."}, nil},
+		{"list bullet and product", []string{"* 2 * 3 is six"}, nil},
+		{"escaped", []string{`Not \*emphasis here.`}, nil},
+		{"code span", []string{"Use `*ptr` and ``a`*b`` freely."}, nil},
+		{"link target", []string{"See [it](http://x.com/a_b_) now."}, nil},
+		{"paragraphs apart", []string{"Open *here", "", "closed* there."}, []string{"0:5 *", "2:6 *"}},
+		{"heading alone", []string{"# A *title", "and* more"}, []string{"0:4 *", "1:3 *"}},
+		{"code block", []string{"```", "a * b *c", "```"}, nil},
+		{"inline math", []string{"Let $x_1 * y$ be."}, nil},
+		{"unclosed math", []string{"Let $x be."}, []string{"0:4 $"}},
+		{"money", []string{"It cost $5 or $10."}, nil},
+		{"display math", []string{"$$", "a_1 * b", "$$"}, nil},
+		{"unclosed display math", []string{"Then $$a + b", "", "text"}, []string{"0:5 $$"}},
+		{"front matter", []string{"---", "title: *x", "---", "Body."}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, unmatched := ScanMarkup(tt.lines)
+			var got []string
+			for _, d := range unmatched {
+				got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Col, d.Text))
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("unmatched = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanMarkupMath(t *testing.T) {
+	math, _ := ScanMarkup([]string{"Let $abc$ and $$d$$ hold,", "and $$e", "f$$ too."})
+	want := []lineRange{{0, 4, 9}, {0, 14, 19}, {1, 4, 7}, {2, 0, 3}}
+	if fmt.Sprint(math) != fmt.Sprint(want) {
+		t.Errorf("math = %v, want %v", math, want)
+	}
+}
+
+func TestCheckLinesMath(t *testing.T) {
+	sc, err := spell.NewSpellChecker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newTestApp("paper.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Where $\\mathrm{xqzt}$ holds *always."}
+	errs := eb.checkLines(sc)
+	if len(errs) != 1 || errs[0].Word != "*" || errs[0].StartCol != 28 {
+		t.Fatalf("math should not be checked and the stray * reported, got %+v", errs)
+	}
+
+	eb.spellErrors = errs
+	a.jumpToNextSpellError()
+	if eb.cursorCol != 28 || a.statusBar.StatusMessage != "Unmatched *" {
+		t.Errorf("jump should name the delimiter, got col %d, %q", eb.cursorCol, a.statusBar.StatusMessage)
+	}
+
+	eb.buf.Filename = "notes.txt"
+	if errs := eb.checkLines(sc); len(errs) != 2 || errs[1].Word != "xqzt" {
+		t.Errorf("plain text should be checked as before, got %+v", errs)
+	}
+}
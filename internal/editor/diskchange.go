@@ -0,0 +1,117 @@
+package editor
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// When you come back to prose and press a key or click, it checks whether
+// another program, such as git or another editor, has written the current
+// buffer's file. If it has, and the text differs, a prompt asks whether to
+// reload the file, keep the buffer as it is, or see the differences in the
+// merge view. Kept buffers are merged with the file when next saved, as :w
+// always does. An idle editor doesn't wake to check.
+
+// diskCheckInterval is the least time between checks, so that a burst of
+// typing doesn't stat the file for every key.
+const diskCheckInterval = time.Second
+
+// diskPromptMessage is the prompt shown when the file has changed.
+const diskPromptMessage = "File changed on disk: r reload, k keep, d diff"
+
+// keepOverDisk notes that the buffer is being kept over the file as it
+// now is on disk, so the change isn't asked about again.
+func (b *Buffer) keepOverDisk() {
+	if info, err := os.Stat(b.Filename); err == nil {
+		b.kept = diskSnapshot{modTime: info.ModTime(), size: info.Size()}
+	}
+}
+
+// newlyChangedOnDisk reports whether the file has changed on disk since it
+// was last loaded or saved, other than in a change the buffer was kept over.
+func (b *Buffer) newlyChangedOnDisk() bool {
+	if !b.ChangedOnDisk() {
+		return false
+	}
+	info, err := os.Stat(b.Filename)
+	return err == nil && !(info.ModTime().Equal(b.kept.modTime) && info.Size() == b.kept.size)
+}
+
+// checkDisk asks about the current buffer's file if it has changed on disk,
+// at most every diskCheckInterval, after input, and only in Default mode
+// with nothing else open, so that no typing is taken for an answer.
+// Reports whether the status bar changed.
+func (a *App) checkDisk(now time.Time) bool {
+	if now.Sub(a.lastDiskCheck) < diskCheckInterval {
+		return false
+	}
+	a.lastDiskCheck = now
+	eb := a.currentBuf()
+	if a.diskPrompt != nil || a.mode != ModeDefault || a.overlayActive() || a.statusBar.Prompt != PromptNone || !eb.buf.newlyChangedOnDisk() {
+		return false
+	}
+	disk, err := eb.buf.ReadDisk()
+	if err != nil {
+		return false
+	}
+	if slices.Equal(disk, eb.buf.Lines) {
+		// Written with the same text, as by git checking out a branch and
+		// back: nothing to ask.
+		eb.buf.recordDisk()
+		return false
+	}
+	a.diskPrompt = eb
+	a.statusBar.SetMessage(diskPromptMessage)
+	return true
+}
+
+// handleDiskPromptKey answers the prompt about a file changed on disk.
+// Other keys are ignored until it is answered.
+func (a *App) handleDiskPromptKey(key terminal.Key) {
+	eb := a.diskPrompt
+	if eb != a.currentBuf() {
+		a.diskPrompt = nil
+		return
+	}
+	switch {
+	case key.Type == terminal.KeyEscape, key.Type == terminal.KeyRune && key.Rune == 'k':
+		a.diskPrompt = nil
+		eb.buf.keepOverDisk()
+		a.statusBar.SetMessage("Kept the buffer; :w merges it with the file on disk")
+	case key.Type == terminal.KeyRune && key.Rune == 'r':
+		a.diskPrompt = nil
+		a.reloadFromDisk()
+	case key.Type == terminal.KeyRune && key.Rune == 'd':
+		a.diskPrompt = nil
+		eb.buf.keepOverDisk()
+		if !a.showMerge(eb) {
+			a.statusBar.SetMessage("No differences from the file on disk")
+		}
+	default:
+		a.statusBar.SetMessage(diskPromptMessage)
+	}
+}
+
+// reloadFromDisk replaces the current buffer's text with its file's, as an
+// undo step, so u gets the buffer back.
+func (a *App) reloadFromDisk() {
+	eb := a.currentBuf()
+	data, err := os.ReadFile(eb.buf.Filename)
+	if err != nil {
+		a.statusBar.SetError("Reload failed: " + err.Error())
+		return
+	}
+	lines, crlf, bom := decodeFileText(data)
+	if !slices.Equal(lines, eb.buf.Lines) {
+		a.replaceLines(0, len(eb.buf.Lines), lines)
+	}
+	eb.buf.CRLF, eb.buf.BOM = crlf, bom
+	eb.buf.recordDisk()
+	eb.buf.Dirty = false
+	eb.cursorLine = min(eb.cursorLine, len(eb.buf.Lines)-1)
+	eb.cursorCol = min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+	a.statusBar.SetMessage("Reloaded " + truncatePath(eb.buf.Filename) + "; u restores the buffer")
+}
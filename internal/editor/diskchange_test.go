@@ -0,0 +1,96 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// changedOnDiskApp returns an App editing a file that another program then
+// rewrites with text.
+func changedOnDiskApp(t *testing.T, text string) (*App, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "draft.md")
+	os.WriteFile(path, []byte("one\ntwo\n"), 0644)
+	a := newTestApp(path)
+	a.currentBuf().buf.Load()
+	os.WriteFile(path, []byte(text), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	return a, path
+}
+
+func TestCheckDiskReload(t *testing.T) {
+	a, _ := changedOnDiskApp(t, "one\r\ntwo\r\nthree\r\n")
+	if !a.checkDisk(time.Now()) || a.statusBar.StatusMessage != diskPromptMessage {
+		t.Fatalf("a changed file should prompt, got %q", a.statusBar.StatusMessage)
+	}
+	if a.checkDisk(time.Now().Add(time.Minute)) {
+		t.Error("should not prompt twice")
+	}
+
+	sendKeys(a, "j")
+	if a.diskPrompt == nil || a.statusBar.StatusMessage != diskPromptMessage {
+		t.Error("other keys should leave the prompt up")
+	}
+	sendKeys(a, "r")
+	eb := a.currentBuf()
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(eb.buf.Lines, want) || !eb.buf.CRLF {
+		t.Errorf("reloaded %q (crlf %v), want %q", eb.buf.Lines, eb.buf.CRLF, want)
+	}
+	if eb.IsDirty() || eb.buf.ChangedOnDisk() {
+		t.Error("a reloaded buffer should match its file")
+	}
+	sendKeys(a, "u")
+	if want := []string{"one", "two"}; !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Errorf("undo = %q, want the buffer back", eb.buf.Lines)
+	}
+}
+
+func TestCheckDiskKeep(t *testing.T) {
+	a, path := changedOnDiskApp(t, "changed\n")
+	a.currentBuf().buf.Lines[0] = "edited"
+	a.checkDisk(time.Now())
+	sendKeys(a, "k")
+	if a.diskPrompt != nil || a.currentBuf().buf.Lines[0] != "edited" {
+		t.Error("k should keep the buffer")
+	}
+	if a.checkDisk(time.Now().Add(time.Minute)) {
+		t.Error("a kept change should not be asked about again")
+	}
+
+	os.WriteFile(path, []byte("changed again\n"), 0644)
+	later := time.Now().Add(2 * time.Minute)
+	os.Chtimes(path, later, later)
+	if !a.checkDisk(time.Now().Add(2 * time.Minute)) {
+		t.Error("a further change should be asked about")
+	}
+	sendKeys(a, "d")
+	if !a.merge.Active {
+		t.Error("d should open the merge view")
+	}
+}
+
+func TestCheckDiskQuiet(t *testing.T) {
+	// The same text written again isn't asked about.
+	a, _ := changedOnDiskApp(t, "one\ntwo\n")
+	if a.checkDisk(time.Now()) || a.currentBuf().buf.ChangedOnDisk() {
+		t.Error("an unchanged text should be taken quietly")
+	}
+
+	// Nor is a change noticed while typing.
+	a, _ = changedOnDiskApp(t, "changed\n")
+	a.mode = ModeEdit
+	if a.checkDisk(time.Now()) {
+		t.Error("should not prompt in Edit mode")
+	}
+	a.mode = ModeDefault
+	if a.checkDisk(time.Now()) {
+		t.Error("should not check again within diskCheckInterval")
+	}
+	if !a.checkDisk(time.Now().Add(diskCheckInterval)) {
+		t.Error("should prompt back in Default mode")
+	}
+}
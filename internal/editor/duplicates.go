@@ -0,0 +1,269 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// Sentences shorter than minDuplicateWords are not compared: short ones
+// like "She nodded." repeat innocently.
+const minDuplicateWords = 6
+
+// duplicateSimilarity is the share of words, in order, that two sentences
+// must have in common to be listed.
+const duplicateSimilarity = 0.8
+
+// maxBigramSentences caps how many sentences a word pair may appear in and
+// still be used to find candidates, so "of the" doesn't pair everything.
+const maxBigramSentences = 50
+
+// Sentence is one sentence of a document.
+type Sentence struct {
+	Line  int    // Line the sentence starts on
+	Col   int    // Rune offset of its first character
+	Text  string // As written, with line breaks as spaces
+	words []string
+}
+
+// before reports whether s starts before t.
+func (s Sentence) before(t Sentence) bool {
+	return s.Line < t.Line || s.Line == t.Line && s.Col < t.Col
+}
+
+// DuplicatePair is two sentences that are the same or nearly so. First
+// comes before Second in the document.
+type DuplicatePair struct {
+	First, Second Sentence
+	Similarity    int // Percentage of words in common
+}
+
+// Label returns the overlay text for a pair, e.g.
+// "Lines 12 and 48 (90%): The rain fell...".
+func (p DuplicatePair) Label() string {
+	match := fmt.Sprintf("%d%%", p.Similarity)
+	if p.Similarity == 100 {
+		match = "same"
+	}
+	return fmt.Sprintf("Lines %d and %d (%s): %s", p.First.Line+1, p.Second.Line+1, match, p.Second.Text)
+}
+
+// isClosing reports whether r may follow the end of a sentence: closing
+// quotes, brackets and emphasis.
+func isClosing(r rune) bool {
+	return strings.ContainsRune("\"'”’)]*_", r)
+}
+
+// SplitSentences returns the sentences of a document's prose. A sentence
+// ends at '.', '!' or '?' followed by a space or the end of the line, unless
+// a lowercase word comes next or the full stop ends an abbreviation such as
+// "Dr." or "e.g.". Sentences run across lines, but not past a
+// blank line, heading or code block. Front matter is skipped.
+func SplitSentences(lines []string) []Sentence {
+	var sentences []Sentence
+	var cur *Sentence
+	var text strings.Builder
+	flush := func() {
+		if cur != nil {
+			cur.Text = strings.TrimSpace(text.String())
+			cur.words = sentenceWords(cur.Text)
+			sentences = append(sentences, *cur)
+		}
+		cur = nil
+		text.Reset()
+	}
+
+	blocks := ComputeBlockStates(lines)
+	for li := frontMatterEnd(lines) + 1; li < len(lines); li++ {
+		trimmed := strings.TrimSpace(lines[li])
+		if blocks[li].InCode || trimmed == "" || strings.HasPrefix(trimmed, "#") || reSceneBreak.MatchString(lines[li]) {
+			flush()
+			continue
+		}
+		runes := []rune(lines[li])
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			if cur == nil {
+				if unicode.IsSpace(r) || r == '>' {
+					continue
+				}
+				cur = &Sentence{Line: li, Col: i}
+			}
+			text.WriteRune(r)
+			if r != '.' && r != '!' && r != '?' {
+				continue
+			}
+			j := i + 1
+			for j < len(runes) && (isClosing(runes[j]) || runes[j] == '.' || runes[j] == '!' || runes[j] == '?') {
+				j++
+			}
+			// A lowercase word after, as in "Stop!" she said, continues it.
+			k := j
+			for k < len(runes) && unicode.IsSpace(runes[k]) {
+				k++
+			}
+			// Nor does the full stop of an abbreviation, as in Dr. Watson.
+			w := i
+			for w > 0 && !unicode.IsSpace(runes[w-1]) {
+				w--
+			}
+			abbreviation := r == '.' && isAbbreviation(string(runes[w:i+1]))
+			if (j == len(runes) || unicode.IsSpace(runes[j])) && (k == len(runes) || !unicode.IsLower(runes[k])) && !abbreviation {
+				text.WriteString(string(runes[i+1 : j]))
+				i = j - 1
+				flush()
+			}
+		}
+		if cur != nil {
+			text.WriteByte(' ')
+		}
+	}
+	flush()
+	return sentences
+}
+
+// sentenceWords returns the lowercased words of a sentence, ignoring
+// punctuation and markup.
+func sentenceWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// wordDistance returns the number of words inserted, deleted or replaced
+// to turn a into b.
+func wordDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// FindDuplicates returns the pairs of sentences in lines that are the
+// same, or nearly, ignoring case and punctuation, in document order.
+func FindDuplicates(lines []string) []DuplicatePair {
+	sentences := SplitSentences(lines)
+
+	// Only sentences sharing a pair of adjacent words are compared. Two
+	// sentences differing in at most a fifth of their words always do.
+	bigrams := make(map[string][]int)
+	for i, s := range sentences {
+		if len(s.words) < minDuplicateWords {
+			continue
+		}
+		seen := make(map[string]bool)
+		for k := 1; k < len(s.words); k++ {
+			bg := s.words[k-1] + " " + s.words[k]
+			if !seen[bg] {
+				seen[bg] = true
+				bigrams[bg] = append(bigrams[bg], i)
+			}
+		}
+	}
+	candidates := make(map[[2]int]bool)
+	for _, ids := range bigrams {
+		if len(ids) > maxBigramSentences {
+			continue
+		}
+		for x, i := range ids {
+			for _, j := range ids[x+1:] {
+				candidates[[2]int{i, j}] = true
+			}
+		}
+	}
+
+	var pairs []DuplicatePair
+	for c := range candidates {
+		a, b := sentences[c[0]], sentences[c[1]]
+		longest := max(len(a.words), len(b.words))
+		if float64(abs(len(a.words)-len(b.words))) > float64(longest)*(1-duplicateSimilarity) {
+			continue
+		}
+		similarity := 1 - float64(wordDistance(a.words, b.words))/float64(longest)
+		if similarity < duplicateSimilarity {
+			continue
+		}
+		pairs = append(pairs, DuplicatePair{First: a, Second: b, Similarity: int(similarity * 100)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		p, q := pairs[i], pairs[j]
+		if p.First.Line != q.First.Line || p.First.Col != q.First.Col {
+			return p.First.before(q.First)
+		}
+		return p.Second.before(q.Second)
+	})
+	return pairs
+}
+
+// DuplicateList manages the duplicate sentences overlay.
+type DuplicateList struct {
+	ListView[DuplicatePair]
+}
+
+// showDuplicates lists the repeated sentences of the current buffer.
+func (a *App) showDuplicates() {
+	pairs := FindDuplicates(a.currentBuf().buf.Lines)
+	if len(pairs) == 0 {
+		a.statusBar.SetMessage("No duplicate sentences found")
+		return
+	}
+	a.dupes.Show(pairs)
+}
+
+// jumpToDuplicate moves to the later sentence of the selected pair, or the
+// earlier one if first is set.
+func (a *App) jumpToDuplicate(first bool) {
+	if a.dupes.Selected < 0 || a.dupes.Selected >= len(a.dupes.Items) {
+		return
+	}
+	pair := a.dupes.Items[a.dupes.Selected]
+	to, other := pair.Second, pair.First
+	if first {
+		to, other = other, to
+	}
+	a.pushJump()
+	eb := a.currentBuf()
+	eb.cursorLine = min(to.Line, eb.buf.LineCount()-1)
+	eb.cursorCol = to.Col
+	a.statusBar.SetMessage(fmt.Sprintf("Also at line %d", other.Line+1))
+}
+
+func (a *App) handleDuplicatesKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.dupes.Hide()
+	case terminal.KeyUp:
+		a.dupes.MoveUp()
+	case terminal.KeyDown:
+		a.dupes.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.dupes.MoveUp()
+		case 'j':
+			a.dupes.MoveDown()
+		case 'o':
+			a.jumpToDuplicate(true)
+			a.dupes.Hide()
+		}
+	case terminal.KeyEnter:
+		a.jumpToDuplicate(false)
+		a.dupes.Hide()
+	}
+}
@@ -0,0 +1,114 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestSplitSentences(t *testing.T) {
+	lines := []string{
+		"---",
+		"title: Rain. Again.",
+		"---",
+		"# A heading. Not prose.",
+		`The rain fell. "Stop!" she said. It ran`,
+		"across lines...  Then ended?",
+		"",
+		"```",
+		"code. More code.",
+		"```",
+		"> Quoted text (here.) Done",
+	}
+	want := []Sentence{
+		{Line: 4, Col: 0, Text: "The rain fell."},
+		{Line: 4, Col: 15, Text: `"Stop!" she said.`},
+		{Line: 4, Col: 33, Text: "It ran across lines..."},
+		{Line: 5, Col: 17, Text: "Then ended?"},
+		{Line: 10, Col: 2, Text: "Quoted text (here.)"},
+		{Line: 10, Col: 22, Text: "Done"},
+	}
+	got := SplitSentences(lines)
+	if len(got) != len(want) {
+		t.Fatalf("SplitSentences = %+v, want %d sentences", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Line != w.Line || got[i].Col != w.Col || got[i].Text != w.Text {
+			t.Errorf("sentence %d = %d:%d %q, want %d:%d %q", i, got[i].Line, got[i].Col, got[i].Text, w.Line, w.Col, w.Text)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []int // Similarity of each pair found
+	}{
+		{"identical", []string{"She walked down to the harbour at dawn.", "", "She walked down to the harbour at dawn."}, []int{100}},
+		{"case and punctuation", []string{"She walked down to the harbour at dawn.", "she walked down, to the harbour at dawn!"}, []int{100}},
+		{"one word changed", []string{"She walked down to the harbour at dawn.", "She walked down to the harbour at dusk."}, []int{87}},
+		{"across lines", []string{"She walked down to the", "harbour at dawn. Later she", "walked down to the harbour at dawn."}, []int{88}},
+		{"too different", []string{"She walked down to the harbour at dawn.", "She ran down to the market at noon."}, nil},
+		{"too short", []string{"She nodded. He nodded. She nodded."}, nil},
+		{"three copies", []string{"The kettle boiled over on the stove.", "The kettle boiled over on the stove.", "The kettle boiled over on the stove."}, []int{100, 100, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs := FindDuplicates(tt.lines)
+			if len(pairs) != len(tt.want) {
+				t.Fatalf("FindDuplicates = %+v, want %d pairs", pairs, len(tt.want))
+			}
+			for i, p := range pairs {
+				if p.Similarity != tt.want[i] {
+					t.Errorf("pair %d similarity = %d, want %d", i, p.Similarity, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicatePairLabel(t *testing.T) {
+	p := DuplicatePair{First: Sentence{Line: 11}, Second: Sentence{Line: 47, Text: "The rain fell."}, Similarity: 90}
+	if got := p.Label(); got != "Lines 12 and 48 (90%): The rain fell." {
+		t.Errorf("Label = %q", got)
+	}
+	p.Similarity = 100
+	if got := p.Label(); got != "Lines 12 and 48 (same): The rain fell." {
+		t.Errorf("Label = %q", got)
+	}
+}
+
+func TestDupesCommand(t *testing.T) {
+	a := newTestApp("one.md")
+	a.currentBuf().buf.Lines = []string{
+		"She walked down to the harbour at dawn.",
+		"Gulls wheeled.",
+		"Then she walked down to the harbour at dawn.",
+	}
+	a.executeCommand("dupes")
+	if !a.dupes.Active || len(a.dupes.Items) != 1 {
+		t.Fatalf("expected one pair in the overlay, got %+v", a.dupes.Items)
+	}
+
+	a.handleDuplicatesKey(terminal.Key{Type: terminal.KeyEnter})
+	eb := a.currentBuf()
+	if a.dupes.Active || eb.cursorLine != 2 || eb.cursorCol != 0 {
+		t.Errorf("Enter should jump to the later copy, got %d:%d, active %v", eb.cursorLine, eb.cursorCol, a.dupes.Active)
+	}
+	if a.statusBar.StatusMessage != "Also at line 1" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+
+	a.executeCommand("dupes")
+	sendKeys(a, "o")
+	if eb.cursorLine != 0 {
+		t.Errorf("o should jump to the earlier copy, got line %d", eb.cursorLine)
+	}
+
+	a.currentBuf().buf.Lines = []string{"Nothing repeats here at all, not once."}
+	a.executeCommand("dupes")
+	if a.dupes.Active || a.statusBar.StatusMessage != "No duplicate sentences found" {
+		t.Errorf("no pairs should give a message, got %q", a.statusBar.StatusMessage)
+	}
+}
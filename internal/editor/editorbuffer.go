@@ -1,8 +1,6 @@
 package editor
 
 import (
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/JackWReid/prose/internal/spell"
@@ -16,12 +14,28 @@ type EditorBuffer struct {
 	cursorLine   int
 	cursorCol    int
 	scrollOffset int
-	isScratch    bool // True if this is the session scratch buffer
+	isScratch    bool         // True if this is the session scratch buffer
+	startWords   int          // Word count when opened, for the words written this session
+	readOnly     bool         // Edits and plain :w are refused
+	unwritable   bool         // Read-only because the file can't be written
+	pinned       bool         // Kept at the top of the buffer list
+	folds        map[int]bool // Heading lines whose sections are folded
+	trackChanges bool         // Typing and deletes are recorded as CriticMarkup
+	hoist        *FoldRange   // The section shown alone by :hoist, if any
+	wraps        wrapCache    // Wrapped rows of the lines, by text
+	git          gitState     // The file at git's HEAD, and the gutter's signs
+
+	marks map[rune]Mark // Positions saved with m<letter>
+
+	// Advisory lock state
+	lockFile  string // Lock file held by this process ("" if none)
+	lockOwner int    // Pid of another prose holding the file, if any
 
 	// Spell checking state
 	spellErrors       []spell.SpellError // Cached spell errors
 	spellCheckPending bool               // Debounce flag
 	lastEdit          time.Time          // Last edit timestamp
+	bib               *Bibliography      // Checked citation keys; nil for none
 
 	// Search state
 	searchActive     bool
@@ -60,6 +74,13 @@ func (eb *EditorBuffer) IsDirty() bool {
 	return eb.buf.Dirty
 }
 
+// clampCursor moves the cursor back inside the buffer, for positions
+// recorded against other text, such as those undo and redo restore.
+func (eb *EditorBuffer) clampCursor() {
+	eb.cursorLine = max(0, min(eb.cursorLine, eb.buf.LineCount()-1))
+	eb.cursorCol = max(0, min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine)))
+}
+
 // WordCount returns the word count of the buffer.
 func (eb *EditorBuffer) WordCount() int {
 	return eb.buf.WordCount()
@@ -72,8 +93,7 @@ func (eb *EditorBuffer) ShouldSpellCheck() bool {
 		return false
 	}
 
-	ext := strings.ToLower(filepath.Ext(eb.buf.Filename))
-	return ext == ".md" || ext == ".txt" || ext == ".markdown"
+	return isProseFile(eb.buf.Filename)
 }
 
 // SpellErrorCount returns the number of cached spell errors.
@@ -81,6 +101,23 @@ func (eb *EditorBuffer) SpellErrorCount() int {
 	return len(eb.spellErrors)
 }
 
+// SpellErrorIndex returns the 1-based index of the spell error nearest the
+// cursor: the error under the cursor, otherwise the last error before it.
+// Before the first error it returns 1. Returns 0 when there are no errors.
+func (eb *EditorBuffer) SpellErrorIndex() int {
+	if len(eb.spellErrors) == 0 {
+		return 0
+	}
+	idx := 0
+	for i, err := range eb.spellErrors {
+		if err.Line > eb.cursorLine || (err.Line == eb.cursorLine && err.StartCol > eb.cursorCol) {
+			break
+		}
+		idx = i
+	}
+	return idx + 1
+}
+
 // ScheduleSpellCheck marks that a spell check should be performed after debouncing.
 func (eb *EditorBuffer) ScheduleSpellCheck() {
 	if !eb.ShouldSpellCheck() {
@@ -90,28 +127,28 @@ func (eb *EditorBuffer) ScheduleSpellCheck() {
 	eb.lastEdit = time.Now()
 }
 
-// PerformSpellCheck runs spell checking if enough time has elapsed since the last edit.
-// This implements debouncing to avoid checking on every keystroke.
-func (eb *EditorBuffer) PerformSpellCheck(spellChecker *spell.SpellChecker) {
-	if !eb.spellCheckPending {
-		return
-	}
+// spellCheckDelay is how long typing must pause before the buffer is
+// spell checked again.
+const spellCheckDelay = 300 * time.Millisecond
 
-	// Debounce: only check if 300ms have elapsed since last edit
-	elapsed := time.Since(eb.lastEdit)
-	if elapsed < 300*time.Millisecond {
-		return
+// spellCheckDue reports whether a spell check is scheduled and typing has
+// paused for long enough.
+func (eb *EditorBuffer) spellCheckDue() bool {
+	return eb.spellCheckPending && time.Since(eb.lastEdit) >= spellCheckDelay
+}
+
+// PerformSpellCheck runs spell checking if enough time has elapsed since the last edit.
+// This implements debouncing to avoid checking on every keystroke. It
+// reports whether it checked, so the results can be drawn.
+func (eb *EditorBuffer) PerformSpellCheck(spellChecker *spell.SpellChecker) bool {
+	if !eb.spellCheckDue() {
+		return false
 	}
 
 	// Clear pending flag
 	eb.spellCheckPending = false
 
-	// Clear previous errors
-	eb.spellErrors = nil
-
 	// Check all lines for spelling errors
-	for i := 0; i < len(eb.buf.Lines); i++ {
-		lineErrors := spellChecker.CheckLine(i, eb.buf.Lines[i])
-		eb.spellErrors = append(eb.spellErrors, lineErrors...)
-	}
+	eb.spellErrors = eb.checkLines(spellChecker)
+	return true
 }
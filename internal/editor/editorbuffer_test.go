@@ -1,6 +1,10 @@
 package editor
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
 
 func TestNewEditorBuffer(t *testing.T) {
 	eb := NewEditorBuffer("test.md")
@@ -55,3 +59,34 @@ func TestEditorBufferHighlighter(t *testing.T) {
 		t.Errorf("expected PlainHighlighter for .go, got %T", plain.highlighter)
 	}
 }
+
+func TestEditorBufferSpellErrorIndex(t *testing.T) {
+	eb := NewEditorBuffer("test.md")
+	if got := eb.SpellErrorIndex(); got != 0 {
+		t.Errorf("no errors: SpellErrorIndex() = %d, want 0", got)
+	}
+
+	eb.spellErrors = []spell.SpellError{
+		{Line: 1, StartCol: 4, EndCol: 8},
+		{Line: 3, StartCol: 0, EndCol: 5},
+		{Line: 3, StartCol: 10, EndCol: 15},
+	}
+
+	tests := []struct {
+		line, col int
+		want      int
+	}{
+		{0, 0, 1},  // Before the first error.
+		{1, 4, 1},  // On the first error.
+		{2, 0, 1},  // Between first and second.
+		{3, 0, 2},  // On the second error.
+		{3, 12, 3}, // Inside the third error.
+		{9, 0, 3},  // After the last error.
+	}
+	for _, tt := range tests {
+		eb.cursorLine, eb.cursorCol = tt.line, tt.col
+		if got := eb.SpellErrorIndex(); got != tt.want {
+			t.Errorf("cursor (%d,%d): SpellErrorIndex() = %d, want %d", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// :export writes the buffer out in another format. HTML is converted here,
+// covering the Markdown that prose is written in: headings, paragraphs,
+// emphasis, links and images, lists, quotes, code, tables and rules. Front
+// matter is left out, and CriticMarkup is exported as if every change were
+// accepted. PDFs are made by an outside converter; see exportpdf.go.
+
+// exportStyle is the style sheet embedded in exported pages, so that they
+// read well without any other file.
+const exportStyle = `body { margin: 0 auto; max-width: 38em; padding: 2em 1em; font-family: Georgia, "Times New Roman", serif; font-size: 1.1em; line-height: 1.6; color: #222; background: #fff; }
+h1, h2, h3, h4, h5, h6 { line-height: 1.25; margin: 1.5em 0 0.5em; }
+a { color: #1f5fae; }
+img { max-width: 100%; }
+code, pre { font-family: Menlo, Consolas, monospace; font-size: 0.9em; background: #f4f4f4; }
+code { padding: 0.1em 0.3em; border-radius: 3px; }
+pre { padding: 0.8em 1em; overflow-x: auto; border-radius: 4px; }
+pre code { padding: 0; background: none; }
+blockquote { margin: 1em 0; padding: 0 1em; border-left: 3px solid #ccc; color: #555; }
+hr { border: none; text-align: center; margin: 2em 0; }
+hr::after { content: "* * *"; color: #888; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+li > input { margin-right: 0.4em; }
+`
+
+var (
+	reBlockquote  = regexp.MustCompile(`^ {0,3}> ?`)
+	reImage       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+&quot;(.*?)&quot;)?\)`)
+	reLinkFull    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]*)(?:\s+&quot;(.*?)&quot;)?\)`)
+	reAutolink    = regexp.MustCompile(`&lt;((?:https?|mailto):[^\s&]+)&gt;`)
+	reStrongStar  = regexp.MustCompile(`\*\*([^*\s](?:.*?[^*\s])?)\*\*`)
+	reStrongUs    = regexp.MustCompile(`(^|[^\pL\pN_])__([^_\s](?:.*?[^_\s])?)__([^\pL\pN_]|$)`)
+	reEmStar      = regexp.MustCompile(`\*([^*\s](?:[^*]*?[^*\s])?)\*`)
+	reEmUs        = regexp.MustCompile(`(^|[^\pL\pN_])_([^_\s](?:[^_]*?[^_\s])?)_([^\pL\pN_]|$)`)
+	reStrike      = regexp.MustCompile(`~~([^~\s](?:.*?[^~\s])?)~~`)
+	reStash       = regexp.MustCompile("\x00(\\d+)\x00")
+	reClosingHash = regexp.MustCompile(`\s+#+\s*$`)
+)
+
+// escapeHTML escapes the characters that are special in HTML text and
+// attributes. Apostrophes are left as they are, being common in prose.
+var escapeHTML = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace
+
+// MarkdownToHTML converts Markdown to the body of an HTML page.
+func MarkdownToHTML(lines []string) string {
+	lines = exportLines(lines)
+	c := htmlConverter{ids: map[string]int{}}
+	var b strings.Builder
+	c.blocks(&b, lines, false)
+	return b.String()
+}
+
+// HTMLDocument returns lines as a complete HTML page, with its style sheet
+// embedded and its title taken from the front matter, else the first
+// heading, else fallback.
+func HTMLDocument(lines []string, fallback string) string {
+	title := frontMatterValue(lines, "title")
+	if title == "" {
+		if headings := ExtractHeadings(&Buffer{Lines: lines}); len(headings) > 0 {
+			title = stripCriticMarkup(headings[0].Text)
+		}
+	}
+	if title == "" {
+		title = fallback
+	}
+	lang := frontMatterValue(lines, "lang")
+	if lang == "" {
+		lang = "en"
+	}
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	fmt.Fprintf(&b, "<html lang=\"%s\">\n<head>\n", escapeHTML(lang))
+	b.WriteString("<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", escapeHTML(title))
+	b.WriteString("<style>\n" + exportStyle + "</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	b.WriteString(MarkdownToHTML(lines))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// exportLines returns lines without front matter, and with CriticMarkup
+// outside code accepted.
+func exportLines(lines []string) []string {
+	lines = lines[frontMatterEnd(lines)+1:]
+	blocks := ComputeBlockStates(lines)
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if !blocks[i].InCode {
+			line = stripCriticMarkup(line)
+		}
+		out[i] = line
+	}
+	return out
+}
+
+// htmlConverter converts Markdown blocks to HTML, keeping track of the
+// heading ids given out so that each is unique.
+type htmlConverter struct {
+	ids map[string]int
+}
+
+// blocks writes the HTML for lines to b. In a tight list item, paragraphs
+// are written without <p> tags.
+func (c *htmlConverter) blocks(b *strings.Builder, lines []string, tight bool) {
+	states := ComputeBlockStates(lines)
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case states[i].Fence:
+			j := i + 1
+			for j < len(lines) && !states[j].Fence {
+				j++
+			}
+			c.code(b, lines[i+1:j], states[i].Lang)
+			i = j + 1
+		case reHeadingATX.MatchString(line):
+			m := reHeadingATX.FindStringSubmatch(line)
+			c.heading(b, len(m[1]), reClosingHash.ReplaceAllString(m[2], ""))
+			i++
+		case setextLevel(lines, states, i) > 0:
+			c.heading(b, setextLevel(lines, states, i), strings.TrimSpace(line))
+			i += 2
+		case reSceneBreak.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+		case reBlockquote.MatchString(line):
+			j := i
+			var quoted []string
+			for ; j < len(lines) && reBlockquote.MatchString(lines[j]); j++ {
+				quoted = append(quoted, reBlockquote.ReplaceAllString(lines[j], ""))
+			}
+			b.WriteString("<blockquote>\n")
+			c.blocks(b, quoted, false)
+			b.WriteString("</blockquote>\n")
+			i = j
+		case reListItem.MatchString(line):
+			i = c.list(b, lines, i)
+		case startsTable(lines, i):
+			j := i
+			for j < len(lines) && IsTableLine(lines[j]) {
+				j++
+			}
+			c.table(b, lines[i:j])
+			i = j
+		default:
+			j := i + 1
+			for j < len(lines) && !endsParagraph(lines, j) {
+				j++
+			}
+			c.paragraph(b, lines[i:j], tight)
+			i = j
+		}
+	}
+}
+
+// endsParagraph reports whether line i of lines ends the paragraph before
+// it, being blank or starting a block of its own.
+func endsParagraph(lines []string, i int) bool {
+	line := lines[i]
+	return strings.TrimSpace(line) == "" || reHeadingATX.MatchString(line) || reCodeFence.MatchString(line) ||
+		reSceneBreak.MatchString(line) || reBlockquote.MatchString(line) || reListItem.MatchString(line) || startsTable(lines, i)
+}
+
+// startsTable reports whether line i of lines is a table's header row.
+func startsTable(lines []string, i int) bool {
+	return IsTableLine(lines[i]) && i+1 < len(lines) && IsTableLine(lines[i+1]) && isSeparatorRow(SplitTableRow(lines[i+1]))
+}
+
+// heading writes a heading, with an id made from its text for links.
+func (c *htmlConverter) heading(b *strings.Builder, level int, text string) {
+	id := headingID(text)
+	if n := c.ids[id]; n > 0 {
+		c.ids[id]++
+		id += "-" + strconv.Itoa(n)
+	} else {
+		c.ids[id] = 1
+	}
+	fmt.Fprintf(b, "<h%d id=\"%s\">%s</h%d>\n", level, id, inlineHTML(text), level)
+}
+
+// headingID makes an id from a heading's text as pandoc and GitHub do:
+// lower case, with spaces turned to hyphens and punctuation dropped.
+func headingID(text string) string {
+	var id strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			id.WriteRune(r)
+		case unicode.IsSpace(r):
+			id.WriteByte('-')
+		}
+	}
+	if id.Len() == 0 {
+		return "section"
+	}
+	return id.String()
+}
+
+// code writes a fenced code block, marking its language for highlighters.
+func (c *htmlConverter) code(b *strings.Builder, lines []string, lang string) {
+	b.WriteString("<pre><code")
+	if lang != "" {
+		fmt.Fprintf(b, " class=\"language-%s\"", escapeHTML(lang))
+	}
+	b.WriteString(">")
+	for _, line := range lines {
+		b.WriteString(escapeHTML(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+// paragraph writes a paragraph. Lines ending in two spaces or a backslash
+// end with a line break.
+func (c *htmlConverter) paragraph(b *strings.Builder, lines []string, tight bool) {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		brk := i < len(lines)-1 && (strings.HasSuffix(line, "  ") || strings.HasSuffix(line, `\`))
+		line = strings.TrimSpace(line)
+		if brk {
+			line = strings.TrimSuffix(line, `\`)
+		}
+		parts[i] = inlineHTML(line)
+		if brk {
+			parts[i] += "<br>"
+		}
+	}
+	text := strings.Join(parts, "\n")
+	if tight {
+		b.WriteString(text + "\n")
+		return
+	}
+	b.WriteString("<p>" + text + "</p>\n")
+}
+
+// list writes the list starting at line i of lines, with any lists nested
+// in its items, and returns the line after it. A list with blank lines
+// between or inside its items is loose, and its items' paragraphs get <p>
+// tags.
+func (c *htmlConverter) list(b *strings.Builder, lines []string, i int) int {
+	m := reListItem.FindStringSubmatch(lines[i])
+	indent := indentWidth(m[1])
+	ordered := unicode.IsDigit(rune(m[2][0]))
+	kind := func(marker string) bool { return unicode.IsDigit(rune(marker[0])) == ordered }
+
+	type item struct {
+		lines []string
+		task  string // "[ ]" or "[x]" for a task list item
+	}
+	var items []item
+	loose := false
+	for i < len(lines) {
+		m := reListItem.FindStringSubmatch(lines[i])
+		if m == nil || indentWidth(m[1]) != indent || !kind(m[2]) {
+			break
+		}
+		content := indentWidth(m[1] + m[2] + m[3]) // Where the item's text starts
+		it := item{lines: []string{lines[i][len(m[0]):]}, task: strings.ToLower(strings.TrimSpace(m[4]))}
+		j := i + 1
+		for j < len(lines) {
+			line := lines[j]
+			if strings.TrimSpace(line) == "" {
+				k := j
+				for k < len(lines) && strings.TrimSpace(lines[k]) == "" {
+					k++
+				}
+				if k == len(lines) || indentWidth(leadingSpace(lines[k])) <= indent {
+					break
+				}
+				loose = true // The item's blocks are apart
+				it.lines = append(it.lines, lines[j:k]...)
+				j = k
+				continue
+			}
+			if n := reListItem.FindStringSubmatch(line); n != nil && indentWidth(n[1]) <= indent {
+				break
+			}
+			if indentWidth(leadingSpace(line)) > indent {
+				it.lines = append(it.lines, dedent(line, content))
+			} else if strings.TrimSpace(lines[j-1]) != "" && !endsParagraph(lines, j) {
+				it.lines = append(it.lines, strings.TrimSpace(line)) // A lazy continuation
+			} else {
+				break
+			}
+			j++
+		}
+		items = append(items, it)
+		i = j
+
+		// Blank lines before the next item make the list loose.
+		k := i
+		for k < len(lines) && strings.TrimSpace(lines[k]) == "" {
+			k++
+		}
+		if k > i && k < len(lines) {
+			if n := reListItem.FindStringSubmatch(lines[k]); n != nil && indentWidth(n[1]) == indent && kind(n[2]) {
+				loose = true
+				i = k
+			}
+		}
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if start, _ := strconv.Atoi(strings.TrimRight(m[2], ".)")); start != 1 {
+			fmt.Fprintf(b, "<ol start=\"%d\">\n", start)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+	for _, it := range items {
+		var inner strings.Builder
+		c.blocks(&inner, it.lines, !loose)
+		b.WriteString("<li>")
+		switch it.task {
+		case "[ ]":
+			b.WriteString(`<input type="checkbox" disabled>`)
+		case "[x]":
+			b.WriteString(`<input type="checkbox" disabled checked>`)
+		}
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// leadingSpace returns the spaces and tabs that start line.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// indentWidth returns the width of indentation, with tab stops every four
+// columns.
+func indentWidth(space string) int {
+	w := 0
+	for _, r := range space {
+		if r == '\t' {
+			w += 4 - w%4
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// dedent removes up to n columns of indentation from line.
+func dedent(line string, n int) string {
+	w := 0
+	for i, r := range line {
+		if w >= n || r != ' ' && r != '\t' {
+			return line[i:]
+		}
+		w += indentWidth(string(r))
+	}
+	return ""
+}
+
+// table writes a pipe table, its columns aligned as the separator row says.
+func (c *htmlConverter) table(b *strings.Builder, lines []string) {
+	var aligns []TableAlign
+	for _, cell := range SplitTableRow(lines[1]) {
+		aligns = append(aligns, parseAlign(cell))
+	}
+	row := func(line, tag string) {
+		b.WriteString("<tr>")
+		for i, cell := range SplitTableRow(line) {
+			style := ""
+			if i < len(aligns) {
+				switch aligns[i] {
+				case AlignLeft:
+					style = ` style="text-align: left"`
+				case AlignCenter:
+					style = ` style="text-align: center"`
+				case AlignRight:
+					style = ` style="text-align: right"`
+				}
+			}
+			fmt.Fprintf(b, "<%s%s>%s</%s>", tag, style, inlineHTML(cell), tag)
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("<table>\n<thead>\n")
+	row(lines[0], "th")
+	b.WriteString("</thead>\n")
+	if len(lines) > 2 {
+		b.WriteString("<tbody>\n")
+		for _, line := range lines[2:] {
+			row(line, "td")
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// inlineHTML converts a line's inline Markdown: code spans, backslash
+// escapes, images, links, and strong, emphasized and struck-out text.
+func inlineHTML(text string) string {
+	text = strings.ReplaceAll(text, "\x00", "")
+	// Finished HTML is set aside behind placeholders, so that the asterisks
+	// and underscores in code and addresses aren't taken for emphasis.
+	var stash []string
+	hold := func(s string) string {
+		stash = append(stash, s)
+		return "\x00" + strconv.Itoa(len(stash)-1) + "\x00"
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch ch := text[i]; {
+		case ch == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!~|<>\"", text[i+1]) >= 0:
+			b.WriteString(hold(escapeHTML(text[i+1 : i+2])))
+			i++
+		case ch == '`':
+			ticks := i
+			for ticks < len(text) && text[ticks] == '`' {
+				ticks++
+			}
+			fence := text[i:ticks]
+			end := strings.Index(text[ticks:], fence)
+			if end < 0 {
+				b.WriteString(fence)
+				i = ticks - 1
+				continue
+			}
+			code := strings.TrimSpace(text[ticks : ticks+end])
+			b.WriteString(hold("<code>" + escapeHTML(code) + "</code>"))
+			i = ticks + end + len(fence) - 1
+		default:
+			b.WriteByte(ch)
+		}
+	}
+
+	// Escaping leaves the held placeholders alone.
+	s := escapeHTML(b.String())
+	s = reImage.ReplaceAllStringFunc(s, func(m string) string {
+		g := reImage.FindStringSubmatch(m)
+		img := fmt.Sprintf(`<img src="%s" alt="%s"`, g[2], g[1])
+		if g[3] != "" {
+			img += fmt.Sprintf(` title="%s"`, g[3])
+		}
+		return hold(img + ">")
+	})
+	s = reLinkFull.ReplaceAllStringFunc(s, func(m string) string {
+		g := reLinkFull.FindStringSubmatch(m)
+		a := fmt.Sprintf(`<a href="%s"`, g[2])
+		if g[3] != "" {
+			a += fmt.Sprintf(` title="%s"`, g[3])
+		}
+		return hold(a + ">" + emphasisHTML(g[1]) + "</a>")
+	})
+	s = reAutolink.ReplaceAllStringFunc(s, func(m string) string {
+		url := reAutolink.FindStringSubmatch(m)[1]
+		return hold(fmt.Sprintf(`<a href="%s">%s</a>`, url, strings.TrimPrefix(url, "mailto:")))
+	})
+	s = emphasisHTML(s)
+
+	// Placeholders may hold others, as links hold code.
+	for strings.Contains(s, "\x00") {
+		s = reStash.ReplaceAllStringFunc(s, func(m string) string {
+			n, _ := strconv.Atoi(strings.Trim(m, "\x00"))
+			return stash[n]
+		})
+	}
+	return s
+}
+
+// emphasisHTML converts strong, emphasized and struck-out text.
+func emphasisHTML(s string) string {
+	s = reStrongStar.ReplaceAllString(s, "<strong>$1</strong>")
+	s = reStrongUs.ReplaceAllString(s, "$1<strong>$2</strong>$3")
+	s = reEmStar.ReplaceAllString(s, "<em>$1</em>")
+	s = reEmUs.ReplaceAllString(s, "$1<em>$2</em>$3")
+	return reStrike.ReplaceAllString(s, "<del>$1</del>")
+}
+
+// exportPath returns where to export the buffer as ext: output if given,
+// else the buffer's file name with ext in place of its extension.
+func exportPath(filename, output, ext string) (string, error) {
+	if output == "" {
+		if filename == "" {
+			return "", fmt.Errorf("the buffer has no file name; give one, as in :export %s notes.%s", ext, ext)
+		}
+		output = strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + ext
+	}
+	output = expandHome(output)
+	if filename != "" && absPath(output) == absPath(filename) {
+		return "", errors.New("that would overwrite the buffer's own file")
+	}
+	return output, nil
+}
+
+// exportCommand runs :export format [file].
+func (a *App) exportCommand(argLine string) {
+	args, err := splitArgs(argLine)
+	if err != nil {
+		a.statusBar.SetError("Export: " + err.Error())
+		return
+	}
+	if len(args) == 0 || len(args) > 2 {
+		a.statusBar.SetMessage("Usage: :export html|pdf [file]")
+		return
+	}
+	output := ""
+	if len(args) == 2 {
+		output = args[1]
+	}
+	switch args[0] {
+	case "html":
+		a.exportHTML(output)
+	case "pdf":
+		a.exportPDF(output)
+	default:
+		a.statusBar.SetMessage(fmt.Sprintf("Export: unknown format %q; want html or pdf", args[0]))
+	}
+}
+
+// exportHTML writes the buffer to output, or beside its file, as a
+// self-contained HTML page.
+func (a *App) exportHTML(output string) {
+	eb := a.currentBuf()
+	path, err := exportPath(eb.buf.Filename, output, "html")
+	if err != nil {
+		a.statusBar.SetError("Export: " + err.Error())
+		return
+	}
+	title := strings.TrimSuffix(filepath.Base(eb.buf.Filename), filepath.Ext(eb.buf.Filename))
+	if eb.buf.Filename == "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := os.WriteFile(path, []byte(HTMLDocument(eb.buf.Lines, title)), 0644); err != nil {
+		a.statusBar.SetError("Export failed: " + err.Error())
+		return
+	}
+	a.statusBar.SetMessage("Exported to " + path)
+}
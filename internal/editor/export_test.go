@@ -0,0 +1,118 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want string
+	}{
+		{"headings", []string{"# One #", "", "Two", "---", "", "## One"},
+			"<h1 id=\"one\">One</h1>\n<h2 id=\"two\">Two</h2>\n<h2 id=\"one-1\">One</h2>\n"},
+		{"paragraphs", []string{"First line", "second line.", "", "Next  ", "break."},
+			"<p>First line\nsecond line.</p>\n<p>Next<br>\nbreak.</p>\n"},
+		{"front matter", []string{"---", "title: T", "---", "Text"}, "<p>Text</p>\n"},
+		{"critic", []string{"A {++good ++}{--bad --}day{>>note<<}."}, "<p>A good day.</p>\n"},
+		{"escaping", []string{`1 < 2 & "it's" \*so\*`}, "<p>1 &lt; 2 &amp; &quot;it's&quot; *so*</p>\n"},
+		{"emphasis", []string{"**bold** *it* __b__ _i_ ~~gone~~ snake_case_name 2 * 3 * 4"},
+			"<p><strong>bold</strong> <em>it</em> <strong>b</strong> <em>i</em> <del>gone</del> snake_case_name 2 * 3 * 4</p>\n"},
+		{"code span", []string{"Run `a *b* <c>` and ``x`y``"},
+			"<p>Run <code>a *b* &lt;c&gt;</code> and <code>x`y</code></p>\n"},
+		{"links", []string{`[*A* site](http://x.org/a_b_c?q=1&r=2 "Title") ![pic](i.png) <https://y.org>`},
+			`<p><a href="http://x.org/a_b_c?q=1&amp;r=2" title="Title"><em>A</em> site</a> <img src="i.png" alt="pic"> <a href="https://y.org">https://y.org</a></p>` + "\n"},
+		{"code block", []string{"```go", "x := *p < 1", "", "```", "after"},
+			"<pre><code class=\"language-go\">x := *p &lt; 1\n\n</code></pre>\n<p>after</p>\n"},
+		{"rule and quote", []string{"* * *", "> Quoted", "> *text*", ">", "> More"},
+			"<hr>\n<blockquote>\n<p>Quoted\n<em>text</em></p>\n<p>More</p>\n</blockquote>\n"},
+		{"tight list", []string{"- one", "  - nested", "- [x] two", "continued"},
+			"<ul>\n<li>one\n<ul>\n<li>nested</li>\n</ul></li>\n<li><input type=\"checkbox\" disabled checked>two\ncontinued</li>\n</ul>\n"},
+		{"loose ordered list", []string{"3. one", "", "4. two", "", "   more", "", "Para"},
+			"<ol start=\"3\">\n<li><p>one</p></li>\n<li><p>two</p>\n<p>more</p></li>\n</ol>\n<p>Para</p>\n"},
+		{"list after paragraph", []string{"Shopping:", "- eggs", "1. first"},
+			"<p>Shopping:</p>\n<ul>\n<li>eggs</li>\n</ul>\n<ol>\n<li>first</li>\n</ol>\n"},
+		{"table", []string{"| A | B |", "|:--|--:|", "| *x* | 1 |"},
+			"<table>\n<thead>\n<tr><th style=\"text-align: left\">A</th><th style=\"text-align: right\">B</th></tr>\n</thead>\n" +
+				"<tbody>\n<tr><td style=\"text-align: left\"><em>x</em></td><td style=\"text-align: right\">1</td></tr>\n</tbody>\n</table>\n"},
+	}
+	for _, tt := range tests {
+		if got := MarkdownToHTML(tt.in); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLDocumentTitle(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"---", "title: \"Front & Back\"", "---", "# Heading"}, "<title>Front &amp; Back</title>"},
+		{[]string{"Intro", "", "# Heading"}, "<title>Heading</title>"},
+		{[]string{"Intro"}, "<title>draft</title>"},
+	}
+	for _, tt := range tests {
+		doc := HTMLDocument(tt.lines, "draft")
+		if !strings.Contains(doc, tt.want) {
+			t.Errorf("%q: no %s in\n%s", tt.lines, tt.want, doc)
+		}
+		if !strings.HasPrefix(doc, "<!DOCTYPE html>") || !strings.Contains(doc, "<style>") || !strings.HasSuffix(doc, "</html>\n") {
+			t.Errorf("%q: not a self-contained page", tt.lines)
+		}
+	}
+}
+
+func TestExportPath(t *testing.T) {
+	tests := []struct {
+		filename, output, want string
+		err                    bool
+	}{
+		{"notes/draft.md", "", "notes/draft.html", false},
+		{"draft", "", "draft.html", false},
+		{"draft.md", "out/page.htm", "out/page.htm", false},
+		{"", "", "", true},
+		{"", "page.html", "page.html", false},
+		{"draft.md", "draft.md", "", true},
+	}
+	for _, tt := range tests {
+		got, err := exportPath(tt.filename, tt.output, "html")
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("exportPath(%q, %q) = %q, %v", tt.filename, tt.output, got, err)
+		}
+	}
+}
+
+func TestExportCommand(t *testing.T) {
+	dir := t.TempDir()
+	a := newTestApp(filepath.Join(dir, "draft.md"))
+	a.currentBuf().buf.Lines = []string{"# Title", "", "Some *unsaved* text."}
+	a.executeCommand("export html")
+
+	out := filepath.Join(dir, "draft.html")
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("output not written: %v (%s)", err, a.statusBar.StatusMessage)
+	}
+	if !strings.Contains(string(data), "<p>Some <em>unsaved</em> text.</p>") {
+		t.Errorf("page = %s", data)
+	}
+	if want := "Exported to " + out; a.statusBar.StatusMessage != want {
+		t.Errorf("message = %q, want %q", a.statusBar.StatusMessage, want)
+	}
+
+	for _, tt := range []struct{ cmd, want string }{
+		{"export", "Usage: :export html|pdf [file]"},
+		{"export docx", `Export: unknown format "docx"; want html or pdf`},
+		{"export html " + filepath.Join(dir, "missing", "x.html"), "Export failed: "},
+	} {
+		a.executeCommand(tt.cmd)
+		if !strings.HasPrefix(a.statusBar.StatusMessage, tt.want) {
+			t.Errorf("%s: message = %q, want %q", tt.cmd, a.statusBar.StatusMessage, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// :export pdf hands the buffer to an outside converter, pandoc or
+// wkhtmltopdf unless pdf_command names another, and carries on while it
+// runs. The buffer is written to a temporary directory, as Markdown or as
+// the HTML :export html makes, and the PDF is copied into place only once
+// the converter succeeds, so a failed run never leaves half a file behind.
+
+// pdfConverters are the converters tried, in order, when pdf_command isn't
+// set, with their command lines. {md} and {html} are replaced with the
+// buffer written as Markdown or HTML, {pdf} with the file to write and {dir}
+// with the buffer's directory, where relative image paths start.
+var pdfConverters = []struct {
+	name, command string
+}{
+	{"pandoc", "pandoc --from markdown --resource-path {dir} --output {pdf} {md}"},
+	{"wkhtmltopdf", "wkhtmltopdf --quiet --enable-local-file-access {html} {pdf}"},
+}
+
+// exportTimeout is how long a converter may run before it is stopped.
+const exportTimeout = 2 * time.Minute
+
+// exportPollInterval is how often the main loop checks on a running export.
+const exportPollInterval = 250 * time.Millisecond
+
+// pdfExport is a PDF export in progress.
+type pdfExport struct {
+	output   string     // Where the PDF goes
+	tool     string     // The converter's name, for messages
+	started  time.Time  // When the converter was started
+	progress string     // The progress message last shown
+	done     chan error // Receives the result when the converter finishes
+	cancel   context.CancelFunc
+}
+
+// pdfCommand returns the command line to convert with, from pdf_command
+// or the first converter installed.
+func pdfCommand(setting string, lookPath func(string) (string, error)) (string, error) {
+	if setting != "" {
+		for _, c := range pdfConverters {
+			if setting == c.name {
+				return c.command, nil
+			}
+		}
+		return setting, nil
+	}
+	for _, c := range pdfConverters {
+		if _, err := lookPath(c.name); err == nil {
+			return c.command, nil
+		}
+	}
+	return "", errors.New("no PDF converter found; install pandoc or wkhtmltopdf, or set pdf_command")
+}
+
+// validPDFCommand checks a pdf_command setting: a converter's name, or a
+// command line that names the PDF and the file to convert.
+func validPDFCommand(command string) error {
+	for _, c := range pdfConverters {
+		if command == c.name {
+			return nil
+		}
+	}
+	if !strings.Contains(command, "{pdf}") || !strings.Contains(command, "{md}") && !strings.Contains(command, "{html}") {
+		return fmt.Errorf("want pandoc, wkhtmltopdf or a command using {pdf} and {md} or {html}, got %q", command)
+	}
+	if _, err := splitArgs(command); err != nil {
+		return err
+	}
+	return nil
+}
+
+// exportPDF starts converting the buffer to a PDF at output, or beside its
+// file.
+func (a *App) exportPDF(output string) {
+	if a.pdfExport != nil {
+		a.statusBar.SetMessage("Export: already exporting " + a.pdfExport.output)
+		return
+	}
+	eb := a.currentBuf()
+	path, err := exportPath(eb.buf.Filename, output, "pdf")
+	if err != nil {
+		a.statusBar.SetError("Export: " + err.Error())
+		return
+	}
+	command, err := pdfCommand(a.config.PDFCommand, exec.LookPath)
+	if err != nil {
+		a.statusBar.SetError("Export: " + err.Error())
+		return
+	}
+	args, err := splitArgs(command)
+	if err != nil || len(args) == 0 {
+		a.statusBar.SetMessage(fmt.Sprintf("Export: bad pdf_command %q", command))
+		return
+	}
+
+	tmp, err := os.MkdirTemp("", "prose-export-")
+	if err != nil {
+		a.statusBar.SetError("Export failed: " + err.Error())
+		return
+	}
+	dir := "."
+	if eb.buf.Filename != "" {
+		dir = filepath.Dir(absPath(eb.buf.Filename))
+	}
+	title := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	files := map[string]string{
+		"{md}":   filepath.Join(tmp, title+".md"),
+		"{html}": filepath.Join(tmp, title+".html"),
+		"{pdf}":  filepath.Join(tmp, title+".pdf"),
+		"{dir}":  dir,
+	}
+	for i, arg := range args {
+		for placeholder, file := range files {
+			arg = strings.ReplaceAll(arg, placeholder, file)
+		}
+		args[i] = arg
+	}
+	if err := writeExportSources(command, files, eb.buf.Lines, title, dir); err != nil {
+		os.RemoveAll(tmp)
+		a.statusBar.SetError("Export failed: " + err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	x := &pdfExport{output: path, tool: filepath.Base(args[0]), started: time.Now(), done: make(chan error, 1), cancel: cancel}
+	a.pdfExport = x
+	go func() {
+		defer os.RemoveAll(tmp)
+		defer cancel()
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			err = copyFile(files["{pdf}"], path)
+		} else {
+			err = converterError(x.tool, ctx, err, out)
+		}
+		x.done <- err
+	}()
+	a.showExportProgress(time.Now())
+}
+
+// writeExportSources writes the buffer to the temporary files the command
+// converts from. Markdown goes as it is but for CriticMarkup, accepted;
+// HTML is given the buffer's directory as its base, for images.
+func writeExportSources(command string, files map[string]string, lines []string, title, dir string) error {
+	if strings.Contains(command, "{md}") {
+		md := make([]string, len(lines))
+		blocks := ComputeBlockStates(lines)
+		for i, line := range lines {
+			if !blocks[i].InCode {
+				line = stripCriticMarkup(line)
+			}
+			md[i] = line
+		}
+		if err := os.WriteFile(files["{md}"], []byte(strings.Join(md, "\n")+"\n"), 0600); err != nil {
+			return err
+		}
+	}
+	if strings.Contains(command, "{html}") {
+		page := HTMLDocument(lines, title)
+		base := fmt.Sprintf("<head>\n<base href=\"file://%s/\">", escapeHTML(filepath.ToSlash(dir)))
+		page = strings.Replace(page, "<head>", base, 1)
+		if err := os.WriteFile(files["{html}"], []byte(page), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// converterError explains why a converter failed: it wasn't found, it took
+// too long, or the last line of what it printed.
+func converterError(tool string, ctx context.Context, err error, out []byte) error {
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("%s not found", tool)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s took longer than %s", tool, exportTimeout)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return fmt.Errorf("%s: %s", tool, last)
+	}
+	return fmt.Errorf("%s: %w", tool, err)
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("no PDF was written: %w", err)
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// showExportProgress shows how long the export has run, unless another
+// message has taken the status bar.
+func (a *App) showExportProgress(now time.Time) {
+	x := a.pdfExport
+	if msg := a.statusBar.StatusMessage; msg != "" && msg != x.progress {
+		return
+	}
+	x.progress = fmt.Sprintf("Exporting %s with %s…", x.output, x.tool)
+	if secs := int(now.Sub(x.started).Seconds()); secs > 0 {
+		x.progress = fmt.Sprintf("Exporting %s with %s (%ds)…", x.output, x.tool, secs)
+	}
+	a.statusBar.SetMessage(x.progress)
+}
+
+// pollExport checks on a running export, showing its progress or result,
+// and reports whether the status bar changed.
+func (a *App) pollExport(now time.Time) bool {
+	x := a.pdfExport
+	if x == nil {
+		return false
+	}
+	select {
+	case err := <-x.done:
+		a.pdfExport = nil
+		if err != nil {
+			a.statusBar.SetError("Export failed: " + err.Error())
+		} else {
+			a.statusBar.SetMessage("Exported to " + x.output)
+		}
+		return true
+	default:
+	}
+	before := a.statusBar.StatusMessage
+	a.showExportProgress(now)
+	return a.statusBar.StatusMessage != before
+}
+
+// cancelExport stops a running export, as the editor quits.
+func (a *App) cancelExport() {
+	if a.pdfExport != nil {
+		a.pdfExport.cancel()
+	}
+}
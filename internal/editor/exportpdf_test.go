@@ -0,0 +1,158 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPDFCommand(t *testing.T) {
+	installed := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+	tests := []struct {
+		setting   string
+		installed []string
+		want      string
+	}{
+		{"", []string{"pandoc", "wkhtmltopdf"}, pdfConverters[0].command},
+		{"", []string{"wkhtmltopdf"}, pdfConverters[1].command},
+		{"wkhtmltopdf", []string{"pandoc"}, pdfConverters[1].command},
+		{"weasyprint {html} {pdf}", nil, "weasyprint {html} {pdf}"},
+		{"", nil, ""},
+	}
+	for _, tt := range tests {
+		got, err := pdfCommand(tt.setting, installed(tt.installed...))
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("pdfCommand(%q) with %q = %q, %v; want %q", tt.setting, tt.installed, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidPDFCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		ok      bool
+	}{
+		{"pandoc", true},
+		{"weasyprint {html} {pdf}", true},
+		{`pandoc -V "mainfont=Gentium Plus" -o {pdf} {md}`, true},
+		{"prince", false},
+		{"weasyprint {html}", false},
+		{"convert {pdf}", false},
+		{`pandoc "-o {pdf} {md}`, false},
+	}
+	for _, tt := range tests {
+		if err := validPDFCommand(tt.command); (err == nil) != tt.ok {
+			t.Errorf("validPDFCommand(%q) = %v", tt.command, err)
+		}
+	}
+}
+
+func TestConverterError(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		err  error
+		out  string
+		want string
+	}{
+		{exec.ErrNotFound, "", "pandoc not found"},
+		{errors.New("exit status 43"), "[WARNING] one\npdflatex not found. Please select a different --pdf-engine\n", "pandoc: pdflatex not found. Please select a different --pdf-engine"},
+		{errors.New("exit status 1"), "", "pandoc: exit status 1"},
+	}
+	for _, tt := range tests {
+		if got := converterError("pandoc", ctx, tt.err, []byte(tt.out)).Error(); got != tt.want {
+			t.Errorf("converterError(%v, %q) = %q, want %q", tt.err, tt.out, got, tt.want)
+		}
+	}
+}
+
+// waitForExport polls a's export until it finishes, as the main loop does.
+func waitForExport(t *testing.T, a *App) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for a.pdfExport != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("export didn't finish")
+		}
+		a.pollExport(time.Now())
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExportPDF(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	a := newTestApp(filepath.Join(dir, "draft.md"))
+	a.currentBuf().buf.Lines = []string{"# Title", "", "Text {++added++}."}
+
+	// A stand-in converter that copies the Markdown it is given.
+	a.config.PDFCommand = `sh -c "cp $0 $1" {md} {pdf}`
+	a.executeCommand("export pdf")
+	if !strings.HasPrefix(a.statusBar.StatusMessage, "Exporting "+filepath.Join(dir, "draft.pdf")+" with sh") {
+		t.Errorf("progress = %q", a.statusBar.StatusMessage)
+	}
+	a.executeCommand("export pdf")
+	if !strings.HasPrefix(a.statusBar.StatusMessage, "Export: already exporting") {
+		t.Errorf("second export: message = %q", a.statusBar.StatusMessage)
+	}
+	waitForExport(t, a)
+	out := filepath.Join(dir, "draft.pdf")
+	if want := "Exported to " + out; a.statusBar.StatusMessage != want {
+		t.Errorf("message = %q, want %q", a.statusBar.StatusMessage, want)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || string(data) != "# Title\n\nText added.\n" {
+		t.Errorf("pdf = %q, %v", data, err)
+	}
+
+	// A failing converter leaves the last PDF alone and says why.
+	a.config.PDFCommand = `sh -c "echo warning >&2; echo no fonts >&2; exit 1" {html} {pdf}`
+	a.executeCommand("export pdf")
+	waitForExport(t, a)
+	if want := "Export failed: sh: no fonts"; a.statusBar.StatusMessage != want {
+		t.Errorf("message = %q, want %q", a.statusBar.StatusMessage, want)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "# Title\n\nText added.\n" {
+		t.Errorf("failed export changed the pdf: %q", data)
+	}
+
+	// A converter that writes nothing is a failure too.
+	a.config.PDFCommand = `sh -c "true" {html} {pdf}`
+	a.executeCommand("export pdf " + filepath.Join(dir, "other.pdf"))
+	waitForExport(t, a)
+	if !strings.HasPrefix(a.statusBar.StatusMessage, "Export failed: no PDF was written") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestExportProgressKeepsOtherMessages(t *testing.T) {
+	a := newTestApp("draft.md")
+	start := time.Now()
+	a.pdfExport = &pdfExport{output: "draft.pdf", tool: "pandoc", started: start, done: make(chan error, 1)}
+	a.showExportProgress(start)
+	if got := a.statusBar.StatusMessage; got != "Exporting draft.pdf with pandoc…" {
+		t.Errorf("progress = %q", got)
+	}
+	if !a.pollExport(start.Add(3*time.Second)) || a.statusBar.StatusMessage != "Exporting draft.pdf with pandoc (3s)…" {
+		t.Errorf("progress after 3s = %q", a.statusBar.StatusMessage)
+	}
+	a.statusBar.SetMessage("Saved")
+	if a.pollExport(start.Add(4*time.Second)) || a.statusBar.StatusMessage != "Saved" {
+		t.Errorf("progress replaced another message: %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,102 @@
+package editor
+
+import "strings"
+
+// Files are edited as lines without their endings, and written back the
+// way they were read: with Windows line endings (\r\n) if most of their
+// lines had them, and with a byte order mark if they started with one.
+// :set ff=unix or :set ff=dos changes the line endings the next save writes.
+
+// utf8BOM is the byte order mark some Windows programs start UTF-8 files with.
+const utf8BOM = "\ufeff"
+
+// decodeFileText splits a file's contents into lines, reporting whether
+// they ended in \r\n and whether the file started with a byte order mark.
+func decodeFileText(data []byte) (lines []string, crlf, bom bool) {
+	text := string(data)
+	text, bom = strings.CutPrefix(text, utf8BOM)
+	crlf = strings.Count(text, "\r\n")*2 > strings.Count(text, "\n")
+	// Strip trailing newline to avoid a phantom empty line.
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return []string{""}, crlf, bom
+	}
+	lines = strings.Split(text, "\n")
+	if crlf {
+		for i, line := range lines {
+			lines[i] = strings.TrimSuffix(line, "\r")
+		}
+	}
+	return lines, crlf, bom
+}
+
+// encodeFileText joins lines into a file's contents, the reverse of
+// decodeFileText.
+func encodeFileText(lines []string, crlf, bom bool) []byte {
+	eol := "\n"
+	if crlf {
+		eol = "\r\n"
+	}
+	text := strings.Join(lines, eol) + eol
+	if bom {
+		text = utf8BOM + text
+	}
+	return []byte(text)
+}
+
+// FileFormat names the buffer's line endings as :set ff does: dos for
+// \r\n, unix for \n.
+func (b *Buffer) FileFormat() string {
+	if b.CRLF {
+		return "dos"
+	}
+	return "unix"
+}
+
+// formatTag returns the status bar's note of a file format other than
+// plain UTF-8 with \n endings, such as "DOS BOM  ", or "".
+func formatTag(b *Buffer) string {
+	var tags []string
+	if b.CRLF {
+		tags = append(tags, "DOS")
+	}
+	if b.BOM {
+		tags = append(tags, "BOM")
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return strings.Join(tags, " ") + "  "
+}
+
+// setFileFormat runs :set ff, changing the line endings the buffer is
+// saved with, or showing them when format is empty.
+func (a *App) setFileFormat(format string) {
+	eb := a.currentBuf()
+	var crlf bool
+	switch format {
+	case "":
+		a.statusBar.SetMessage("fileformat=" + eb.buf.FileFormat())
+		return
+	case "unix":
+	case "dos":
+		crlf = true
+	default:
+		a.statusBar.SetMessage("Usage: :set ff=unix|dos")
+		return
+	}
+	if crlf == eb.buf.CRLF {
+		a.statusBar.SetMessage("Already fileformat=" + format)
+		return
+	}
+	if a.guardReadOnly() {
+		return
+	}
+	eb.buf.CRLF = crlf
+	eb.buf.Dirty = true
+	ending := `\n`
+	if crlf {
+		ending = `\r\n`
+	}
+	a.statusBar.SetMessage("Lines will end in " + ending + " (" + format + ") when saved")
+}
@@ -0,0 +1,90 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeFileText(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		lines     []string
+		crlf, bom bool
+	}{
+		{"unix", "a\nb\n", []string{"a", "b"}, false, false},
+		{"dos", "a\r\nb\r\n", []string{"a", "b"}, true, false},
+		{"dos without final newline", "a\r\nb", []string{"a", "b"}, true, false},
+		{"mostly unix", "a\nb\nc\r\n", []string{"a", "b", "c\r"}, false, false},
+		{"bom", "\ufeffa\r\n", []string{"a"}, true, true},
+		{"empty", "", []string{""}, false, false},
+	}
+	for _, tt := range tests {
+		lines, crlf, bom := decodeFileText([]byte(tt.data))
+		if !reflect.DeepEqual(lines, tt.lines) || crlf != tt.crlf || bom != tt.bom {
+			t.Errorf("%s: got %q, crlf %v, bom %v; want %q, %v, %v", tt.name, lines, crlf, bom, tt.lines, tt.crlf, tt.bom)
+		}
+	}
+}
+
+func TestFileFormatRoundTrip(t *testing.T) {
+	for _, data := range []string{"one\r\ntwo\r\n", "\ufeffone\ntwo\n", "\ufeffone\r\n"} {
+		path := filepath.Join(t.TempDir(), "win.md")
+		os.WriteFile(path, []byte(data), 0644)
+		buf := NewBuffer(path)
+		if err := buf.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if strings.ContainsAny(strings.Join(buf.Lines, ""), "\r\ufeff") {
+			t.Errorf("%q: line endings or BOM left in the lines %q", data, buf.Lines)
+		}
+		buf.Save("")
+		if got, _ := os.ReadFile(path); string(got) != data {
+			t.Errorf("saved %q, want %q", got, data)
+		}
+		if buf.ChangedOnDisk() {
+			t.Errorf("%q: should not look changed on disk after saving", data)
+		}
+	}
+}
+
+func TestSetFileFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "win.md")
+	os.WriteFile(path, []byte("one\r\ntwo\r\n"), 0644)
+	a := newTestApp(path)
+	eb := a.currentBuf()
+	eb.buf.Load()
+	if got := formatTag(eb.buf); got != "DOS  " {
+		t.Errorf("formatTag = %q", got)
+	}
+
+	a.executeCommand("set ff")
+	if msg := a.statusBar.StatusMessage; msg != "fileformat=dos" {
+		t.Errorf("message = %q", msg)
+	}
+	a.executeCommand("set ff=unix")
+	if eb.buf.CRLF || !eb.buf.Dirty {
+		t.Error(":set ff=unix should mark the buffer to save with \\n")
+	}
+	eb.buf.Save("")
+	if got, _ := os.ReadFile(path); string(got) != "one\ntwo\n" {
+		t.Errorf("saved %q", got)
+	}
+	if got := formatTag(eb.buf); got != "" {
+		t.Errorf("formatTag = %q, want none for unix", got)
+	}
+
+	for _, tt := range []struct{ cmd, want string }{
+		{"set ff=unix", "Already fileformat=unix"},
+		{"set ff=mac", "Usage: :set ff=unix|dos"},
+		{"set fileformat=dos", `Lines will end in \r\n (dos) when saved`},
+	} {
+		a.executeCommand(tt.cmd)
+		if msg := a.statusBar.StatusMessage; msg != tt.want {
+			t.Errorf("%s: message = %q, want %q", tt.cmd, msg, tt.want)
+		}
+	}
+}
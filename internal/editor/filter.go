@@ -0,0 +1,182 @@
+package editor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// :!cmd sends the buffer to a shell command and replaces it with what the
+// command prints; :'<,'>!cmd does the same for the lines last selected in
+// Line-Select mode, and :3,9!cmd for a range of lines.
+
+// reFilterLines matches a filter range of line numbers, such as 3,9.
+var reFilterLines = regexp.MustCompile(`^(\d+)(?:,(\d+))?$`)
+
+// selectionRange is the filter range of the lines last selected.
+const selectionRange = "'<,'>"
+
+// cutFilter splits a filter command into its range and shell command. It
+// reports false if cmd isn't a filter.
+func cutFilter(cmd string) (rng, command string, ok bool) {
+	rng, command, ok = strings.Cut(cmd, "!")
+	rng = strings.TrimSpace(rng)
+	if !ok || (rng != "" && rng != "%" && rng != selectionRange && !reFilterLines.MatchString(rng)) {
+		return "", "", false
+	}
+	return rng, strings.TrimSpace(command), true
+}
+
+// isFilter reports whether cmd is a filter command.
+func isFilter(cmd string) bool {
+	_, _, ok := cutFilter(cmd)
+	return ok
+}
+
+// filterRange returns the first and last lines a filter range covers,
+// or reports false with a message if there are none.
+func (a *App) filterRange(rng string) (start, end int, ok bool) {
+	eb := a.currentBuf()
+	last := eb.buf.LineCount() - 1
+	switch {
+	case rng == "" || rng == "%":
+		return 0, last, true
+	case rng == selectionRange:
+		from, ok1 := eb.marks['<']
+		to, ok2 := eb.marks['>']
+		if !ok1 || !ok2 {
+			a.statusBar.SetMessage("No lines selected")
+			return 0, 0, false
+		}
+		return min(from.Line, last), min(to.Line, last), true
+	}
+	m := reFilterLines.FindStringSubmatch(rng)
+	start, _ = strconv.Atoi(m[1])
+	end = start
+	if m[2] != "" {
+		end, _ = strconv.Atoi(m[2])
+	}
+	if start > end {
+		start, end = end, start
+	}
+	if start < 1 || start > last+1 {
+		a.statusBar.SetMessage(fmt.Sprintf("No line %d", max(start, 1)))
+		return 0, 0, false
+	}
+	return start - 1, min(end, last+1) - 1, true
+}
+
+// shellCommand returns the command that runs command in the user's shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/c", command)
+	}
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return exec.Command(shell, "-c", command)
+}
+
+// runFilter runs command with input on its standard input and returns its
+// standard output. An error carries the first line of what it printed on
+// standard error.
+func runFilter(command, input string) (string, error) {
+	cmd := shellCommand(command)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// outsideEditor runs fn with the terminal out of raw mode and on its normal
+// screen, so that what a command writes to the terminal shows and Ctrl-C
+// stops the command rather than prose. The screen is drawn afresh after.
+func (a *App) outsideEditor(fn func()) {
+	if a.terminal == nil {
+		fn()
+		return
+	}
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	a.terminal.Suspend()
+	fn()
+	if err := a.terminal.Resume(); err != nil {
+		a.errorLog("resume terminal", err)
+	}
+	a.redrawAll()
+}
+
+// filterLines runs :!cmd, replacing the lines in rng with what command
+// prints when given them. The change is one undo step; if the command
+// fails the text is left alone.
+func (a *App) filterLines(rng, command string) {
+	if command == "" {
+		a.statusBar.SetMessage("Usage: :[range]!command")
+		return
+	}
+	if a.guardReadOnly() {
+		return
+	}
+	eb := a.currentBuf()
+	if eb.trackChanges {
+		a.statusBar.SetMessage("Can't filter while tracking changes")
+		return
+	}
+	start, end, ok := a.filterRange(rng)
+	if !ok {
+		return
+	}
+
+	input := strings.Join(eb.buf.Lines[start:end+1], "\n") + "\n"
+	var out string
+	var err error
+	a.outsideEditor(func() { out, err = runFilter(command, input) })
+	if err != nil {
+		a.statusBar.SetError("!" + command + ": " + err.Error())
+		return
+	}
+
+	var lines []string
+	if out != "" {
+		lines = splitFileText([]byte(out))
+	}
+	if len(lines) == 0 && end-start+1 == eb.buf.LineCount() {
+		lines = []string{""}
+	}
+	a.replaceLines(start, end-start+1, lines)
+	eb.cursorLine = min(start, eb.buf.LineCount()-1)
+	eb.cursorCol = 0
+	a.statusBar.SetMessage(fmt.Sprintf("Filtered %d lines through %s", end-start+1, command))
+}
+
+// filterSelectedLines leaves Line-Select mode for the command prompt, with
+// the range of the selected lines ready for :'<,'>!cmd.
+func (a *App) filterSelectedLines() {
+	eb := a.currentBuf()
+	start, end := a.getSelectionRange()
+	if eb.marks == nil {
+		eb.marks = make(map[rune]Mark)
+	}
+	eb.marks['<'] = Mark{Line: start}
+	eb.marks['>'] = Mark{Line: end}
+	a.mode = ModeDefault
+	a.statusBar.StartPrompt(PromptCommand)
+	a.statusBar.PromptText = selectionRange
+}
@@ -0,0 +1,107 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestCutFilter(t *testing.T) {
+	tests := []struct {
+		cmd, rng, command string
+		ok                bool
+	}{
+		{"!sort", "", "sort", true},
+		{"%! sort -r", "%", "sort -r", true},
+		{"'<,'>!fmt -w 60", "'<,'>", "fmt -w 60", true},
+		{"3,9!tr a-z A-Z", "3,9", "tr a-z A-Z", true},
+		{"4!rev", "4", "rev", true},
+		{"e!", "", "", false},
+		{"set nowrap", "", "", false},
+	}
+	for _, tt := range tests {
+		rng, command, ok := cutFilter(tt.cmd)
+		if rng != tt.rng || command != tt.command || ok != tt.ok {
+			t.Errorf("cutFilter(%q) = %q, %q, %v", tt.cmd, rng, command, ok)
+		}
+	}
+}
+
+func TestFilterBuffer(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"pear", "apple", "fig"}
+
+	a.executeCommand("%!sort")
+	if want := []string{"apple", "fig", "pear"}; !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Fatalf(":%%!sort = %q, want %q", eb.buf.Lines, want)
+	}
+	if a.statusBar.StatusMessage != "Filtered 3 lines through sort" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+
+	a.undoAction()
+	if want := []string{"pear", "apple", "fig"}; !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Errorf("undo should restore the text in one step, got %q", eb.buf.Lines)
+	}
+}
+
+func TestFilterLineRange(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"one", "two", "three", "four"}
+
+	a.executeCommand("2,3!tr a-z A-Z")
+	if want := []string{"one", "TWO", "THREE", "four"}; !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Errorf(":2,3! = %q, want %q", eb.buf.Lines, want)
+	}
+	if eb.cursorLine != 1 {
+		t.Errorf("the cursor should be at the first filtered line, got %d", eb.cursorLine)
+	}
+
+	a.executeCommand("9!rev")
+	if a.statusBar.StatusMessage != "No line 9" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+	a.executeCommand("'<,'>!rev")
+	if a.statusBar.StatusMessage != "No lines selected" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestFilterSelectedLines(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Title", "c", "b", "a", "End"}
+	eb.cursorLine = 1
+	a.startLineSelect()
+	sendKeys(a, "jj:")
+	if a.mode != ModeDefault || a.statusBar.PromptText != "'<,'>" {
+		t.Fatalf(": should prompt for the selected range, got %q in %v", a.statusBar.PromptText, a.mode)
+	}
+	sendKeys(a, "!sort")
+	sendKey(a, terminal.KeyEnter)
+	if want := []string{"Title", "a", "b", "c", "End"}; !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Errorf("filtering the selection = %q, want %q", eb.buf.Lines, want)
+	}
+}
+
+func TestFilterFailure(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Keep this."}
+
+	a.executeCommand("!echo broken >&2; exit 1")
+	if eb.buf.Lines[0] != "Keep this." {
+		t.Errorf("a failed command should leave the text alone, got %q", eb.buf.Lines)
+	}
+	if !strings.HasSuffix(a.statusBar.StatusMessage, ": broken") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+	a.executeCommand("!")
+	if a.statusBar.StatusMessage != "Usage: :[range]!command" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
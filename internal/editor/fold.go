@@ -0,0 +1,293 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FoldRange is a folded markdown section: the heading line stays visible and
+// the lines after it, up to End, are hidden.
+type FoldRange struct {
+	Start int // Heading line
+	End   int // Last line of the section (inclusive)
+}
+
+// Hidden returns the number of lines the fold hides.
+func (f FoldRange) Hidden() int {
+	return f.End - f.Start
+}
+
+// SectionEnd returns the last line of the section started by the heading at
+// index i of headings: the line before the next heading of the same or a
+// higher level, or the last line of the buffer.
+func SectionEnd(headings []OutlineItem, i int, lineCount int) int {
+	for _, h := range headings[i+1:] {
+		if h.Level <= headings[i].Level {
+			return h.BufferLine - 1
+		}
+	}
+	return lineCount - 1
+}
+
+// SectionAt returns the index into headings of the innermost section
+// containing line, or -1 if the line comes before the first heading.
+func SectionAt(headings []OutlineItem, line int) int {
+	idx := -1
+	for i, h := range headings {
+		if h.BufferLine > line {
+			break
+		}
+		idx = i
+	}
+	return idx
+}
+
+// foldRanges returns the buffer's active folds in document order. Folds
+// recorded on lines that are no longer headings are ignored, as are folds
+// nested inside another fold.
+func (eb *EditorBuffer) foldRanges() []FoldRange {
+	if len(eb.folds) == 0 {
+		return nil
+	}
+	headings := ExtractHeadings(eb.buf)
+	var ranges []FoldRange
+	for i, h := range headings {
+		if !eb.folds[h.BufferLine] {
+			continue
+		}
+		if len(ranges) > 0 && h.BufferLine <= ranges[len(ranges)-1].End {
+			continue
+		}
+		end := SectionEnd(headings, i, eb.buf.LineCount())
+		if end > h.BufferLine {
+			ranges = append(ranges, FoldRange{Start: h.BufferLine, End: end})
+		}
+	}
+	return ranges
+}
+
+// hiddenBy returns the fold hiding line, if any.
+func hiddenBy(ranges []FoldRange, line int) (FoldRange, bool) {
+	for _, f := range ranges {
+		if line > f.Start && line <= f.End {
+			return f, true
+		}
+	}
+	return FoldRange{}, false
+}
+
+// WrapBufferFolded wraps the buffer like WrapBuffer, but shows each folded
+// section as a single marker line on its heading.
+func WrapBufferFolded(buf *Buffer, maxWidth int, folds []FoldRange) []DisplayLine {
+	return foldDisplayLines(WrapBuffer(buf, maxWidth), buf, folds)
+}
+
+// foldDisplayLines replaces the display lines of each folded section with a
+// single marker line on its heading.
+func foldDisplayLines(all []DisplayLine, buf *Buffer, folds []FoldRange) []DisplayLine {
+	if len(folds) == 0 {
+		return all
+	}
+	result := all[:0:0]
+	fi := 0
+	for _, dl := range all {
+		for fi < len(folds) && dl.BufferLine > folds[fi].End {
+			fi++
+		}
+		if fi < len(folds) && dl.BufferLine >= folds[fi].Start {
+			f := folds[fi]
+			if dl.BufferLine == f.Start && dl.Offset == 0 {
+				result = append(result, DisplayLine{
+					BufferLine: f.Start,
+					Text:       buf.Lines[f.Start],
+					Block:      dl.Block,
+					Folded:     f.Hidden(),
+				})
+			}
+			continue
+		}
+		result = append(result, dl)
+	}
+	return result
+}
+
+// foldMarker and foldMarkerWidth decorate the heading of a folded section.
+const (
+	foldMarker      = "▸ "
+	foldMarkerWidth = 2
+)
+
+// renderFoldLine draws a folded section's marker line in t's colours, e.g.
+// "▸ ## Heading (42 lines)".
+func renderFoldLine(dl DisplayLine, t *Theme) string {
+	unit := "lines"
+	if dl.Folded == 1 {
+		unit = "line"
+	}
+	return fmt.Sprintf("%s%s%s\x1b[0m%s (%d %s)\x1b[0m", t.Heading, foldMarker, dl.Text, t.Muted, dl.Folded, unit)
+}
+
+// DisplayLines wraps the buffer for display, applying folds and any hoist,
+// collapsing notes and styling tracked changes. The cursor line is shown unconcealed.
+func (eb *EditorBuffer) DisplayLines(maxWidth int) []DisplayLine {
+	dls, _ := eb.displayLines(maxWidth)
+	return dls
+}
+
+// displayLines is DisplayLines, also reporting whether the lines depend on
+// which line the cursor is on.
+func (eb *EditorBuffer) displayLines(maxWidth int) (dls []DisplayLine, cursorStyled bool) {
+	dls = eb.hoistLines(foldDisplayLines(eb.wraps.wrap(eb.buf, maxWidth), eb.buf, eb.foldRanges()))
+	if anns := ExtractAnnotations(eb.buf.Lines); len(anns) > 0 {
+		concealAnnotations(dls, anns, eb.cursorLine)
+		cursorStyled = true
+	}
+	if marks := ExtractCriticMarks(eb.buf.Lines); len(marks) > 0 {
+		styleCriticMarks(dls, marks, eb.cursorLine)
+		cursorStyled = true
+	}
+	for i := range dls {
+		if len(dls[i].Conceal) > 1 {
+			sort.Slice(dls[i].Conceal, func(x, y int) bool {
+				return dls[i].Conceal[x].Start < dls[i].Conceal[y].Start
+			})
+		}
+	}
+	return dls, cursorStyled
+}
+
+// openFoldsAt removes any fold hiding line, so jumps (search, outline, G)
+// never leave the cursor inside a collapsed section.
+func (eb *EditorBuffer) openFoldsAt(line int) {
+	for {
+		f, ok := hiddenBy(eb.foldRanges(), line)
+		if !ok {
+			return
+		}
+		delete(eb.folds, f.Start)
+	}
+}
+
+// shiftFolds moves folds below line by delta after lines were inserted
+// (delta > 0) or deleted (delta < 0) at line. Folds whose heading was
+// deleted are dropped.
+func (eb *EditorBuffer) shiftFolds(line, delta int) {
+	if delta == 0 || len(eb.folds) == 0 {
+		return
+	}
+	shifted := make(map[int]bool, len(eb.folds))
+	for h := range eb.folds {
+		switch {
+		case h <= line:
+			shifted[h] = true
+		case h+delta > line:
+			shifted[h+delta] = true
+		}
+	}
+	eb.folds = shifted
+}
+
+// toggleFold folds or unfolds the section containing the cursor (za).
+func (a *App) toggleFold() {
+	eb := a.currentBuf()
+	headings, i := a.sectionAtCursor()
+	if i < 0 {
+		return
+	}
+	if eb.folds[headings[i].BufferLine] {
+		delete(eb.folds, headings[i].BufferLine)
+		return
+	}
+	a.closeFold()
+}
+
+// closeFold folds the section containing the cursor (zc), moving the cursor
+// to its heading.
+func (a *App) closeFold() {
+	eb := a.currentBuf()
+	headings, i := a.sectionAtCursor()
+	if i < 0 {
+		return
+	}
+	h := headings[i].BufferLine
+	if SectionEnd(headings, i, eb.buf.LineCount()) == h {
+		a.statusBar.SetMessage("Nothing to fold")
+		return
+	}
+	if eb.folds == nil {
+		eb.folds = make(map[int]bool)
+	}
+	eb.folds[h] = true
+	eb.cursorLine = h
+	eb.cursorCol = 0
+}
+
+// openFold unfolds the section under the cursor (zo).
+func (a *App) openFold() {
+	eb := a.currentBuf()
+	headings, i := a.sectionAtCursor()
+	if i >= 0 {
+		delete(eb.folds, headings[i].BufferLine)
+	}
+}
+
+// foldAll folds every section with content (zM).
+func (a *App) foldAll() {
+	eb := a.currentBuf()
+	if !IsMarkdownFile(eb.buf.Filename) {
+		a.statusBar.SetMessage("Folding only available for markdown files")
+		return
+	}
+	headings := ExtractHeadings(eb.buf)
+	eb.folds = make(map[int]bool)
+	for i, h := range headings {
+		if SectionEnd(headings, i, eb.buf.LineCount()) > h.BufferLine {
+			eb.folds[h.BufferLine] = true
+		}
+	}
+	// Land on the heading of the section that was under the cursor.
+	for _, f := range eb.foldRanges() {
+		if eb.cursorLine > f.Start && eb.cursorLine <= f.End {
+			eb.cursorLine = f.Start
+			eb.cursorCol = 0
+		}
+	}
+}
+
+// unfoldAll removes every fold (zR).
+func (a *App) unfoldAll() {
+	a.currentBuf().folds = nil
+}
+
+// sectionAtCursor returns the document headings and the index of the section
+// containing the cursor. It reports -1 (with a message) outside a section or
+// in a non-markdown buffer.
+func (a *App) sectionAtCursor() ([]OutlineItem, int) {
+	eb := a.currentBuf()
+	if !IsMarkdownFile(eb.buf.Filename) {
+		a.statusBar.SetMessage("Folding only available for markdown files")
+		return nil, -1
+	}
+	headings := ExtractHeadings(eb.buf)
+	i := SectionAt(headings, eb.cursorLine)
+	if i < 0 {
+		a.statusBar.SetMessage("No section to fold")
+	}
+	return headings, i
+}
+
+// skipFold moves the cursor out of a folded section after a vertical move:
+// forward moves land after the fold, backward moves on its heading.
+func (a *App) skipFold(forward bool) {
+	eb := a.currentBuf()
+	f, ok := hiddenBy(eb.foldRanges(), eb.cursorLine)
+	if !ok {
+		return
+	}
+	if forward && f.End+1 < eb.buf.LineCount() {
+		eb.cursorLine = f.End + 1
+	} else {
+		eb.cursorLine = f.Start
+	}
+	eb.cursorCol = min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+}
@@ -0,0 +1,147 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// foldTestLines is a document with a chapter nested in its first part.
+var foldTestLines = []string{
+	"# Part One", // 0
+	"intro",      // 1
+	"## Chapter", // 2
+	"text",       // 3
+	"more text",  // 4
+	"# Part Two", // 5
+	"closing",    // 6
+}
+
+func TestSectionEnd(t *testing.T) {
+	a := newTestAppWithLines(foldTestLines...)
+	headings := ExtractHeadings(a.currentBuf().buf)
+	tests := []struct {
+		heading int
+		want    int
+	}{
+		{0, 4}, // Part One runs until Part Two
+		{1, 4}, // Chapter ends with its parent
+		{2, 6}, // Part Two runs to end of buffer
+	}
+	for _, tt := range tests {
+		if got := SectionEnd(headings, tt.heading, 7); got != tt.want {
+			t.Errorf("SectionEnd(%d) = %d, want %d", tt.heading, got, tt.want)
+		}
+	}
+}
+
+func TestFoldToggleHidesSection(t *testing.T) {
+	a := newTestAppWithLines(foldTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 3
+
+	sendKeys(a, "za")
+	if eb.cursorLine != 2 {
+		t.Errorf("cursor should move to the folded heading, got line %d", eb.cursorLine)
+	}
+	dls := eb.DisplayLines(60)
+	if len(dls) != 5 {
+		t.Fatalf("got %d display lines, want 5", len(dls))
+	}
+	if dls[2].BufferLine != 2 || dls[2].Folded != 2 {
+		t.Errorf("fold marker = %+v, want heading line 2 hiding 2 lines", dls[2])
+	}
+	if dls[3].BufferLine != 5 {
+		t.Errorf("line after fold should be Part Two, got buffer line %d", dls[3].BufferLine)
+	}
+
+	sendKeys(a, "za")
+	if len(eb.DisplayLines(60)) != 7 {
+		t.Error("za again should unfold the section")
+	}
+}
+
+func TestFoldAllAndUnfoldAll(t *testing.T) {
+	a := newTestAppWithLines(foldTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 4
+
+	sendKeys(a, "zM")
+	dls := eb.DisplayLines(60)
+	if len(dls) != 2 || dls[0].Folded != 4 || dls[1].Folded != 1 {
+		t.Fatalf("zM display lines = %+v", dls)
+	}
+	if eb.cursorLine != 0 {
+		t.Errorf("cursor should land on the outer folded heading, got %d", eb.cursorLine)
+	}
+
+	sendKeys(a, "zR")
+	if len(eb.DisplayLines(60)) != 7 {
+		t.Error("zR should unfold everything")
+	}
+}
+
+func TestMoveCursorSkipsFold(t *testing.T) {
+	a := newTestAppWithLines(foldTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 2
+	sendKeys(a, "zc")
+
+	sendKeys(a, "j")
+	if eb.cursorLine != 5 {
+		t.Errorf("j over fold: cursor line = %d, want 5", eb.cursorLine)
+	}
+	sendKeys(a, "k")
+	if eb.cursorLine != 2 {
+		t.Errorf("k over fold: cursor line = %d, want 2", eb.cursorLine)
+	}
+}
+
+func TestFoldOpensWhenCursorJumpsInside(t *testing.T) {
+	a := newTestAppWithLines(foldTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 2
+	sendKeys(a, "zc")
+
+	eb.cursorLine = 4 // e.g. a search match
+	eb.openFoldsAt(eb.cursorLine)
+	if len(eb.folds) != 0 {
+		t.Error("fold containing the cursor should open")
+	}
+}
+
+func TestFoldsShiftWithEdits(t *testing.T) {
+	a := newTestAppWithLines(foldTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 5
+	sendKeys(a, "zc")
+
+	// Open a line below Part One's intro; Part Two's fold moves down.
+	a.outline = &Outline{}
+	a.browser = &Browser{}
+	a.columnAdjust = &ColumnAdjust{}
+	eb.cursorLine = 1
+	a.handleInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyRune, Rune: 'o'}})
+	if !eb.folds[6] || eb.folds[5] {
+		t.Errorf("fold should follow its heading to line 6, folds = %v", eb.folds)
+	}
+}
+
+func TestRenderFoldLine(t *testing.T) {
+	got := renderFoldLine(DisplayLine{Text: "## Chapter", Folded: 42}, &darkTheme)
+	if !strings.Contains(got, "▸ ## Chapter") || !strings.Contains(got, "(42 lines)") {
+		t.Errorf("renderFoldLine = %q", got)
+	}
+	if got := renderFoldLine(DisplayLine{Text: "# A", Folded: 1}, &darkTheme); !strings.Contains(got, "(1 line)") {
+		t.Errorf("single hidden line should be singular, got %q", got)
+	}
+}
+
+func TestFoldNonMarkdown(t *testing.T) {
+	a := newTestApp("notes.txt")
+	sendKeys(a, "za")
+	if !strings.Contains(a.statusBar.StatusMessage, "markdown") {
+		t.Errorf("expected markdown-only message, got %q", a.statusBar.StatusMessage)
+	}
+}
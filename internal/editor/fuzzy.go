@@ -0,0 +1,100 @@
+package editor
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// OverlayFilter is the type-to-filter line of the buffer picker and the
+// outline. / starts typing; the list then narrows to entries that fuzzy
+// match what is typed.
+type OverlayFilter struct {
+	Typing bool   // Keys go to the filter
+	Text   string // The filter; entries must contain its letters in order
+}
+
+// HandleKey takes a key for the filter: / starts typing, and while typing
+// runes and Backspace edit the text and Esc clears it. It returns whether
+// the key was used, and whether the text changed so the list must be
+// filtered again. Arrows and Enter are left to the overlay.
+func (f *OverlayFilter) HandleKey(key terminal.Key) (used, changed bool) {
+	if !f.Typing {
+		if key.Type == terminal.KeyRune && key.Rune == '/' {
+			f.Typing = true
+			return true, false
+		}
+		return false, false
+	}
+	switch key.Type {
+	case terminal.KeyRune:
+		f.Text += string(key.Rune)
+		return true, true
+	case terminal.KeyBackspace:
+		if f.Text == "" {
+			f.Typing = false
+			return true, false
+		}
+		runes := []rune(f.Text)
+		f.Text = string(runes[:len(runes)-1])
+		return true, true
+	case terminal.KeyEscape:
+		changed = f.Text != ""
+		*f = OverlayFilter{}
+		return true, changed
+	}
+	return false, false
+}
+
+// Title adds the filter to an overlay's title while it is in use.
+func (f OverlayFilter) Title(title string) string {
+	if !f.Typing && f.Text == "" {
+		return title
+	}
+	return title + " /" + f.Text
+}
+
+// fuzzyMatch reports whether text contains the runes of pattern in order,
+// ignoring case, and returns the positions of the runes it matched, earliest
+// first.
+func fuzzyMatch(pattern, text string) ([]int, bool) {
+	want := []rune(pattern)
+	if len(want) == 0 {
+		return nil, true
+	}
+	var positions []int
+	for i, r := range []rune(text) {
+		if unicode.ToLower(r) == unicode.ToLower(want[len(positions)]) {
+			positions = append(positions, i)
+			if len(positions) == len(want) {
+				return positions, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// highlightMatches underlines the runes of text at positions.
+func highlightMatches(text string, positions []int) string {
+	if len(positions) == 0 {
+		return text
+	}
+	var b strings.Builder
+	next := 0
+	for i, r := range []rune(text) {
+		if next < len(positions) && positions[next] == i {
+			b.WriteString("\x1b[4m")
+			b.WriteRune(r)
+			b.WriteString("\x1b[24m")
+			next++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// noMatchesItem stands in for an overlay's list when the filter matches
+// nothing, so the overlay and its filter stay on screen.
+var noMatchesItem = OverlayItem{DisplayText: "\x1b[90mNo matches\x1b[0m", RawText: "No matches"}
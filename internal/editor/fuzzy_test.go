@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		want          []int
+		ok            bool
+	}{
+		{"", "chapter.md", nil, true},
+		{"chp", "chapter.md", []int{0, 1, 3}, true},
+		{"CHmd", "chapter.md", []int{0, 1, 8, 9}, true},
+		{"ée", "Épée", []int{0, 3}, true},
+		{"dm", "chapter.md", nil, false},
+		{"chapters", "chapter", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := fuzzyMatch(tt.pattern, tt.text)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, %v; want %v, %v", tt.pattern, tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	if got := highlightMatches("café", []int{1, 3}); got != "c\x1b[4ma\x1b[24mf\x1b[4mé\x1b[24m" {
+		t.Errorf("highlightMatches = %q", got)
+	}
+	if got := highlightMatches("café", nil); got != "café" {
+		t.Errorf("no positions should leave the text alone, got %q", got)
+	}
+}
+
+func TestOverlayFilterHandleKey(t *testing.T) {
+	var f OverlayFilter
+	if used, _ := f.HandleKey(terminal.Key{Type: terminal.KeyRune, Rune: 'j'}); used {
+		t.Error("keys should reach the overlay until / is pressed")
+	}
+	f.HandleKey(terminal.Key{Type: terminal.KeyRune, Rune: '/'})
+	for _, r := range "ab" {
+		if used, changed := f.HandleKey(terminal.Key{Type: terminal.KeyRune, Rune: r}); !used || !changed {
+			t.Errorf("typing %q should change the filter", r)
+		}
+	}
+	if used, _ := f.HandleKey(terminal.Key{Type: terminal.KeyDown}); used {
+		t.Error("arrows should reach the overlay while typing")
+	}
+	f.HandleKey(terminal.Key{Type: terminal.KeyBackspace})
+	if f.Text != "a" || f.Title("Open Buffers") != "Open Buffers /a" {
+		t.Errorf("after backspace text %q, title %q", f.Text, f.Title("Open Buffers"))
+	}
+	if _, changed := f.HandleKey(terminal.Key{Type: terminal.KeyEscape}); !changed || f.Typing || f.Text != "" {
+		t.Errorf("Esc should clear the filter, got %+v", f)
+	}
+	if used, _ := f.HandleKey(terminal.Key{Type: terminal.KeyEscape}); used {
+		t.Error("a second Esc should close the overlay")
+	}
+}
+
+func TestPickerFilter(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.buffers = append(a.buffers, NewEditorBuffer("chapter-one.md"), NewEditorBuffer("chapter-two.md"))
+	a.viewport = NewViewport(80, 24)
+	a.picker.Show(0)
+
+	sendKeys(a, "/chtw")
+	if !reflect.DeepEqual(a.picker.Shown, []int{2}) || a.picker.Selected != 0 {
+		t.Fatalf("shown %v, selected %d; want [2], 0", a.picker.Shown, a.picker.Selected)
+	}
+	frame := a.renderer.RenderPicker(a.buffers, a.picker, a.currentBuffer, a.viewport)
+	if !strings.Contains(frame, "Open Buffers /chtw") || !strings.Contains(frame, "\x1b[4mh\x1b[24map\x1b[4mt\x1b[24mer-t\x1b[4mw\x1b[24mo.md") {
+		t.Errorf("picker should show the filter and highlight matches: %q", frame)
+	}
+
+	sendKeys(a, "x")
+	if a.picker.Count(len(a.buffers)) != 0 {
+		t.Fatalf("nothing should match, shown %v", a.picker.Shown)
+	}
+	if frame := a.renderer.RenderPicker(a.buffers, a.picker, a.currentBuffer, a.viewport); !strings.Contains(frame, "No matches") {
+		t.Errorf("an empty filter result should say so: %q", frame)
+	}
+	sendKey(a, terminal.KeyEnter)
+	if !a.picker.Active || a.currentBuffer != 0 {
+		t.Error("Enter with no matches should do nothing")
+	}
+
+	sendKey(a, terminal.KeyBackspace)
+	sendKey(a, terminal.KeyEnter)
+	if a.picker.Active || a.currentBuffer != 2 {
+		t.Errorf("Enter should open the match, got buffer %d", a.currentBuffer)
+	}
+}
+
+func TestOutlineFilter(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.currentBuf().buf.Lines = make([]string, 10)
+	a.viewport = NewViewport(80, 24)
+	a.outline.Show(testOutlineItems())
+	a.outline.Selected = 0
+	a.outline.Collapse() // Filtering searches inside collapsed headings
+
+	sendKeys(a, "/sa")
+	if got := outlineTexts(a.outline); !reflect.DeepEqual(got, []string{"Scene A"}) {
+		t.Fatalf("filtered outline = %v", got)
+	}
+	sendKeys(a, "\x1b")
+	if got := outlineTexts(a.outline); !reflect.DeepEqual(got, []string{"Part One", "Part Two"}) {
+		t.Fatalf("clearing the filter should restore the folded outline, got %v", got)
+	}
+
+	sendKeys(a, "/ch2")
+	sendKey(a, terminal.KeyEnter)
+	if a.outline.Active || a.currentBuf().cursorLine != 6 {
+		t.Errorf("Enter should jump to Chapter 2, cursor on %d", a.currentBuf().cursorLine)
+	}
+}
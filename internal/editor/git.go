@@ -0,0 +1,223 @@
+package editor
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// For a file committed in git, the gutter marks the lines that differ from
+// HEAD, and :gitdiff lists the differences. Both compare HEAD with the text
+// in the buffer, saved or not.
+
+// gitSign marks a buffer line in the gutter.
+type gitSign byte
+
+const (
+	gitUnchanged gitSign = iota
+	gitAdded
+	gitModified
+	gitRemoved // Lines were removed just above this one
+)
+
+// gitSignChars are drawn in the gutter for each sign.
+var gitSignChars = [...]string{
+	gitUnchanged: " ",
+	gitAdded:     "+",
+	gitModified:  "~",
+	gitRemoved:   "-",
+}
+
+// gitState is what a buffer knows of its file in git.
+type gitState struct {
+	read    bool      // HEAD has been looked up
+	modTime time.Time // The file's mod time when it was
+	head    []string  // The file at HEAD; nil if it isn't committed
+	lines   []string  // The text signs were last worked out for
+	signs   []gitSign
+}
+
+// gitHeadLines returns the lines of the file at path as committed at HEAD.
+// It reports false if the file isn't in a repository or isn't committed, or
+// if git isn't installed.
+func gitHeadLines(path string) ([]string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false
+	}
+	out, err := exec.Command("git", "-C", filepath.Dir(abs), "show", "HEAD:./"+filepath.Base(abs)).Output()
+	if err != nil {
+		return nil, false
+	}
+	lines, _, _ := decodeFileText(out)
+	return lines, true
+}
+
+// gitLineSigns marks the lines of text that differ from head. Where a
+// changed run is longer than what it replaced, the extra lines are added.
+func gitLineSigns(head, text []string) []gitSign {
+	signs := make([]gitSign, len(text))
+	for _, h := range diffLines(head, text) {
+		if h.B0 == h.B1 {
+			if line := min(h.B0, len(text)-1); line >= 0 && signs[line] == gitUnchanged {
+				signs[line] = gitRemoved
+			}
+			continue
+		}
+		for i := h.B0; i < h.B1; i++ {
+			signs[i] = gitAdded
+			if i-h.B0 < h.A1-h.A0 {
+				signs[i] = gitModified
+			}
+		}
+	}
+	return signs
+}
+
+// gitHead returns the buffer's file at HEAD, or nil. HEAD is read again
+// after the file is saved, or when refresh is set.
+func (eb *EditorBuffer) gitHead(refresh bool) []string {
+	if eb.buf.Filename == "" || eb.isScratch {
+		return nil
+	}
+	g := &eb.git
+	if refresh || !g.read || !g.modTime.Equal(eb.buf.disk.modTime) {
+		g.head, _ = gitHeadLines(eb.buf.Filename)
+		g.read, g.modTime, g.lines = true, eb.buf.disk.modTime, nil
+	}
+	return g.head
+}
+
+// gitSigns returns the gutter signs of the buffer's lines, or nil if its
+// file isn't committed in git.
+func (eb *EditorBuffer) gitSigns() []gitSign {
+	head := eb.gitHead(false)
+	if head == nil {
+		return nil
+	}
+	g := &eb.git
+	if g.lines == nil || !slices.Equal(g.lines, eb.buf.Lines) {
+		g.lines = slices.Clone(eb.buf.Lines)
+		g.signs = gitLineSigns(head, eb.buf.Lines)
+	}
+	return g.signs
+}
+
+// gitDiffContext is the number of unchanged lines shown around a change.
+const gitDiffContext = 3
+
+// gitDiffRowKind is what a row of the diff shows.
+type gitDiffRowKind int
+
+const (
+	gitDiffHeading gitDiffRowKind = iota
+	gitDiffContextRow
+	gitDiffRemovedRow
+	gitDiffAddedRow
+)
+
+// gitDiffRow is one line of the diff overlay.
+type gitDiffRow struct {
+	Text string
+	Kind gitDiffRowKind
+	Line int // Buffer line to go to
+}
+
+// gitDiffRows lays out the differences between head and text as a unified
+// diff, with a heading for each group of nearby changes.
+func gitDiffRows(head, text []string) []gitDiffRow {
+	hunks := diffLines(head, text)
+	var rows []gitDiffRow
+	for i := 0; i < len(hunks); {
+		// Join changes whose context would touch.
+		j := i + 1
+		for j < len(hunks) && hunks[j].A0-hunks[j-1].A1 <= 2*gitDiffContext {
+			j++
+		}
+		a0, b0 := max(hunks[i].A0-gitDiffContext, 0), max(hunks[i].B0-gitDiffContext, 0)
+		a1 := min(hunks[j-1].A1+gitDiffContext, len(head))
+		b1 := min(hunks[j-1].B1+gitDiffContext, len(text))
+		rows = append(rows, gitDiffRow{
+			Text: fmt.Sprintf("@@ -%d,%d +%d,%d @@", a0+1, a1-a0, b0+1, b1-b0),
+			Kind: gitDiffHeading,
+			Line: min(hunks[i].B0, len(text)-1),
+		})
+		a, b := a0, b0
+		for _, h := range hunks[i:j] {
+			for ; a < h.A0; a, b = a+1, b+1 {
+				rows = append(rows, gitDiffRow{Text: " " + text[b], Kind: gitDiffContextRow, Line: b})
+			}
+			line := min(h.B0, len(text)-1)
+			for ; a < h.A1; a++ {
+				rows = append(rows, gitDiffRow{Text: "-" + head[a], Kind: gitDiffRemovedRow, Line: line})
+			}
+			for ; b < h.B1; b++ {
+				rows = append(rows, gitDiffRow{Text: "+" + text[b], Kind: gitDiffAddedRow, Line: b})
+			}
+		}
+		for ; b < b1; b++ {
+			rows = append(rows, gitDiffRow{Text: " " + text[b], Kind: gitDiffContextRow, Line: b})
+		}
+		i = j
+	}
+	return rows
+}
+
+// GitDiffView manages the :gitdiff overlay.
+type GitDiffView struct {
+	ListView[gitDiffRow]
+	Title string
+}
+
+// Show activates the overlay with the given rows.
+func (d *GitDiffView) Show(title string, rows []gitDiffRow) {
+	d.ListView.Show(rows)
+	d.Title = title
+}
+
+// showGitDiff runs :gitdiff, listing how the buffer differs from its file
+// at HEAD.
+func (a *App) showGitDiff() {
+	eb := a.currentBuf()
+	head := eb.gitHead(true)
+	if head == nil {
+		a.statusBar.SetMessage("Not committed in git")
+		return
+	}
+	rows := gitDiffRows(head, eb.buf.Lines)
+	if len(rows) == 0 {
+		a.statusBar.SetMessage("No changes since the last commit")
+		return
+	}
+	a.gitDiff.Show("Changes: "+filepath.Base(eb.buf.Filename), rows)
+}
+
+func (a *App) handleGitDiffKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.gitDiff.Hide()
+	case terminal.KeyUp:
+		a.gitDiff.MoveUp()
+	case terminal.KeyDown:
+		a.gitDiff.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.gitDiff.MoveUp()
+		case 'j':
+			a.gitDiff.MoveDown()
+		}
+	case terminal.KeyEnter:
+		if a.gitDiff.Selected < len(a.gitDiff.Items) {
+			eb := a.currentBuf()
+			a.pushJump()
+			eb.cursorLine = max(a.gitDiff.Items[a.gitDiff.Selected].Line, 0)
+			eb.cursorCol = 0
+		}
+		a.gitDiff.Hide()
+	}
+}
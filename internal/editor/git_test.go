@@ -0,0 +1,149 @@
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestGitLineSigns(t *testing.T) {
+	head := []string{"One.", "Two.", "Three.", "Four.", "Five."}
+	text := []string{"One.", "Two, changed.", "And more.", "Three.", "Five.", "Six."}
+	want := []gitSign{gitUnchanged, gitModified, gitAdded, gitUnchanged, gitRemoved, gitAdded}
+	if got := gitLineSigns(head, text); !reflect.DeepEqual(got, want) {
+		t.Errorf("gitLineSigns = %v, want %v", got, want)
+	}
+
+	if got := gitLineSigns([]string{"One.", "Two."}, []string{"One."}); !reflect.DeepEqual(got, []gitSign{gitRemoved}) {
+		t.Errorf("lines removed from the end should mark the last line, got %v", got)
+	}
+}
+
+func TestGitDiffRows(t *testing.T) {
+	head := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16", "17", "18"}
+	text := append([]string(nil), head...)
+	text[1] = "two"
+	text[4] = "five"
+	text = append(text[:15], text[16:]...) // Remove 16
+
+	var got []string
+	for _, row := range gitDiffRows(head, text) {
+		got = append(got, row.Text)
+	}
+	want := []string{
+		"@@ -1,8 +1,8 @@", " 1", "-2", "+two", " 3", " 4", "-5", "+five", " 6", " 7", " 8",
+		"@@ -13,6 +13,5 @@", " 13", " 14", " 15", "-16", " 17", " 18",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("gitDiffRows =\n%q\nwant\n%q", got, want)
+	}
+	if gitDiffRows(head, head) != nil {
+		t.Error("unchanged text should have no rows")
+	}
+}
+
+// gitRepo makes a repository in a temporary directory with files committed,
+// skipping the test if git isn't installed.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "Draft"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	return dir
+}
+
+func TestGitSignsAgainstHead(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"chapter.md": "One.\nTwo.\nThree.\n"})
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("Untracked.\n"), 0644)
+
+	eb := NewEditorBuffer(filepath.Join(dir, "chapter.md"))
+	eb.buf.Load()
+	if signs := eb.gitSigns(); !reflect.DeepEqual(signs, []gitSign{0, 0, 0}) {
+		t.Errorf("an unchanged file should have blank signs, got %v", signs)
+	}
+	eb.buf.Lines[1] = "Two, again."
+	eb.buf.Lines = append(eb.buf.Lines, "Four.")
+	if signs := eb.gitSigns(); !reflect.DeepEqual(signs, []gitSign{gitUnchanged, gitModified, gitUnchanged, gitAdded}) {
+		t.Errorf("signs after editing = %v", signs)
+	}
+
+	untracked := NewEditorBuffer(filepath.Join(dir, "notes.md"))
+	untracked.buf.Load()
+	if signs := untracked.gitSigns(); signs != nil {
+		t.Errorf("an uncommitted file should have no signs, got %v", signs)
+	}
+	if signs := NewEditorBuffer("").gitSigns(); signs != nil {
+		t.Errorf("an unnamed buffer should have no signs, got %v", signs)
+	}
+}
+
+func TestRenderGitSigns(t *testing.T) {
+	dls := []DisplayLine{
+		{BufferLine: 0, Text: "One."},
+		{BufferLine: 1, Text: "Two, "},
+		{BufferLine: 1, Offset: 5, Text: "again."},
+	}
+	r := NewRenderer()
+	r.gitSigns = []gitSign{gitUnchanged, gitModified}
+	vp := NewViewport(64, 10)
+	vp.SetGutter(gutterWidth(NumbersOff, 2, true))
+	frame := r.RenderFrame(dls, vp, 0, 0, 0, " f.md", "DEFAULT ", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, 0)
+	for _, want := range []string{"\x1b[90m \x1b[0m One.", "\x1b[90m~\x1b[0m Two, ", "\x1b[90m \x1b[0m again."} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("frame should contain %q: %q", want, frame)
+		}
+	}
+}
+
+func TestGitDiffCommand(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"chapter.md": "One.\nTwo.\nThree.\n"})
+	a := newTestApp(filepath.Join(dir, "chapter.md"))
+	eb := a.currentBuf()
+	eb.buf.Load()
+
+	a.executeCommand("gitdiff")
+	if a.gitDiff.Active || a.statusBar.StatusMessage != "No changes since the last commit" {
+		t.Errorf("unchanged: active %v, message %q", a.gitDiff.Active, a.statusBar.StatusMessage)
+	}
+
+	eb.buf.Lines[2] = "Three, again."
+	a.executeCommand("gitdiff")
+	if !a.gitDiff.Active || len(a.gitDiff.Items) != 5 || a.gitDiff.Title != "Changes: chapter.md" {
+		t.Fatalf(":gitdiff should list the change, got %+v", a.gitDiff.Items)
+	}
+	for range 4 {
+		a.handleGitDiffKey(terminal.Key{Type: terminal.KeyRune, Rune: 'j'})
+	}
+	a.handleGitDiffKey(terminal.Key{Type: terminal.KeyEnter})
+	if a.gitDiff.Active || eb.cursorLine != 2 {
+		t.Errorf("Enter should go to the changed line, got line %d", eb.cursorLine)
+	}
+
+	a = newTestApp("draft.md")
+	a.executeCommand("gitdiff")
+	if a.statusBar.StatusMessage != "Not committed in git" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
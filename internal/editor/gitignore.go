@@ -0,0 +1,115 @@
+package editor
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern from a .gitignore file.
+type ignoreRule struct {
+	base     string   // Directory holding the .gitignore
+	segments []string // Pattern split on /; ** matches any number of directories
+	negate   bool     // !pattern re-includes what an earlier rule ignored
+	dirOnly  bool     // pattern/ matches only directories
+}
+
+// parseGitignore reads the rules of the .gitignore in dir, if there is one.
+func parseGitignore(dir string) []ignoreRule {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		// A pattern with no slash, bar a trailing one, matches at any depth.
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		r.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// gitignoreRules collects the .gitignore rules that apply in dir: those in
+// dir and its parents up to the root of its git repository, outermost
+// first. Outside a repository there are none.
+func gitignoreRules(dir string) []ignoreRule {
+	var dirs []string
+	for d := dir; ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			break
+		}
+		if filepath.Dir(d) == d {
+			return nil
+		}
+	}
+	var rules []ignoreRule
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules = append(rules, parseGitignore(dirs[i])...)
+	}
+	return rules
+}
+
+// gitignored reports whether the rules ignore p. As in git, the last rule
+// that matches decides.
+func gitignored(rules []ignoreRule, p string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(r.base, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if matchSegments(r.segments, strings.Split(filepath.ToSlash(rel), "/")) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments matches a path, split on /, against a pattern split the
+// same way.
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchSegments(pattern[1:], name[1:])
+}
@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignored(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, ".git"), 0755)
+	os.MkdirAll(filepath.Join(root, "book", "drafts"), 0755)
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.pdf\n/out\nsecret/\n"), 0644)
+	os.WriteFile(filepath.Join(root, "book", ".gitignore"), []byte("drafts/**/*.bak\n!keep.pdf\n"), 0644)
+	rules := gitignoreRules(filepath.Join(root, "book", "drafts"))
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"book/drafts/chapter.pdf", false, true},
+		{"book/drafts/keep.pdf", false, false},
+		{"out", false, true},
+		{"book/out", false, false},
+		{"book/drafts/secret", true, true},
+		{"book/drafts/secret", false, false},
+		{"book/drafts/a/b/c.bak", false, true},
+		{"book/drafts/chapter.md", false, false},
+	}
+	for _, tt := range tests {
+		if got := gitignored(rules, filepath.Join(root, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("gitignored(%s, dir %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	if rules := gitignoreRules(t.TempDir()); rules != nil {
+		t.Errorf("outside a repository there should be no rules, got %v", rules)
+	}
+}
@@ -0,0 +1,66 @@
+package editor
+
+import "strconv"
+
+// isLineNumber reports whether a command is a bare line number (:42) or
+// the last line (:$).
+func isLineNumber(cmd string) bool {
+	if cmd == "$" {
+		return true
+	}
+	for _, r := range cmd {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return cmd != ""
+}
+
+// parseLineArg turns a 1-based line number, or "$" for the last line, into
+// a buffer line. Numbers past the end go to the last line.
+func parseLineArg(arg string, lineCount int) (int, bool) {
+	if arg == "$" {
+		return lineCount - 1, true
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return max(0, min(n, lineCount)-1), true
+}
+
+// goToLine moves the cursor to the start of a line given as :goto's
+// argument, scrolling it to the middle of the window if it was off screen.
+func (a *App) goToLine(arg string) {
+	if arg == "" {
+		a.statusBar.SetMessage("Usage: :goto <line> or :goto $")
+		return
+	}
+	eb := a.currentBuf()
+	line, ok := parseLineArg(arg, eb.buf.LineCount())
+	if !ok {
+		a.statusBar.SetMessage("Not a line number: " + arg)
+		return
+	}
+	a.pushJump()
+	eb.cursorLine = line
+	eb.cursorCol = 0
+	a.centreCursorIfHidden()
+}
+
+// centreCursorIfHidden scrolls the cursor line to the middle of the window
+// when it is outside it, so a long jump lands with context on both sides.
+// Render's own scrolling would leave it at the top or bottom edge.
+func (a *App) centreCursorIfHidden() {
+	if a.viewport == nil {
+		return
+	}
+	eb := a.currentBuf()
+	eb.openFoldsAt(eb.cursorLine)
+	dls := eb.DisplayLines(a.viewport.ColWidth)
+	dl, _ := CursorToDisplayLine(dls, eb.cursorLine, eb.cursorCol)
+	if dl >= eb.scrollOffset && dl < eb.scrollOffset+a.viewport.VisibleLines(eb.scrollOffset) {
+		return
+	}
+	eb.scrollOffset = max(0, dl-a.viewport.VisibleLines(1)/2)
+}
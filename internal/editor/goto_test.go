@@ -0,0 +1,100 @@
+package editor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseLineArg(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want int
+		ok   bool
+	}{
+		{"1", 0, true},
+		{"42", 41, true},
+		{"0", 0, true},
+		{"500", 99, true},
+		{"$", 99, true},
+		{"x", 0, false},
+		{"-3", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLineArg(tt.arg, 100)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseLineArg(%q) = %d, %v; want %d, %v", tt.arg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// gotoTestLines is a document long enough to scroll, numbered from 1.
+func gotoTestLines() []string {
+	var lines []string
+	for i := 1; i <= 200; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	return lines
+}
+
+func TestGotoCommand(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want int
+	}{
+		{"42", 41},
+		{"goto 42", 41},
+		{"$", 199},
+		{"goto $", 199},
+		{"9999", 199},
+	}
+	for _, tt := range tests {
+		a := newTestAppWithLines(gotoTestLines()...)
+		eb := a.currentBuf()
+		eb.cursorCol = 3
+		a.executeCommand(tt.cmd)
+		if eb.cursorLine != tt.want || eb.cursorCol != 0 {
+			t.Errorf(":%s went to %d:%d, want %d:0", tt.cmd, eb.cursorLine, eb.cursorCol, tt.want)
+		}
+	}
+}
+
+func TestGotoCentresOffscreenLine(t *testing.T) {
+	a := newTestAppWithLines(gotoTestLines()...)
+	eb := a.currentBuf()
+
+	a.executeCommand("100")
+	vis := a.viewport.VisibleLines(eb.scrollOffset)
+	if mid := eb.scrollOffset + vis/2; mid < 98 || mid > 100 {
+		t.Errorf("line 100 should be near the middle of the window, scroll offset %d", eb.scrollOffset)
+	}
+
+	// A line already on screen doesn't scroll.
+	before := eb.scrollOffset
+	a.executeCommand("102")
+	if eb.scrollOffset != before {
+		t.Errorf("visible line scrolled from %d to %d", before, eb.scrollOffset)
+	}
+}
+
+func TestGotoIsAJump(t *testing.T) {
+	a := newTestAppWithLines(gotoTestLines()...)
+	eb := a.currentBuf()
+	eb.cursorLine = 10
+	a.executeCommand("150")
+	a.jumpBack()
+	if eb.cursorLine != 10 {
+		t.Errorf("Ctrl-O after :150 went to line %d, want 10", eb.cursorLine)
+	}
+}
+
+func TestGotoUsage(t *testing.T) {
+	a := newTestAppWithLines(gotoTestLines()...)
+	a.executeCommand("goto")
+	if a.statusBar.StatusMessage != "Usage: :goto <line> or :goto $" {
+		t.Errorf("message %q", a.statusBar.StatusMessage)
+	}
+	a.executeCommand("goto abc")
+	if a.statusBar.StatusMessage != "Not a line number: abc" {
+		t.Errorf("message %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,67 @@
+package editor
+
+import "unicode"
+
+// The cursor moves and deletes by grapheme cluster — what the reader sees as
+// one character — rather than by rune, so an accent typed as a combining
+// mark or an emoji built from several code points is never split. Cursor
+// columns stay rune offsets; these helpers only decide where clusters start
+// and end.
+
+// extendsGrapheme reports whether r continues the cluster that prev ends:
+// combining marks, joiners, variation selectors, emoji modifiers and tags
+// attach to what comes before, and anything after a zero-width joiner joins
+// it.
+func extendsGrapheme(prev, r rune) bool {
+	switch {
+	case prev == '\u200d':
+		return true
+	case r < 0x300:
+		return false
+	case r == '\u200d', r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF, r >= 0xE0020 && r <= 0xE007F:
+		return true
+	case r >= 0x1160 && r <= 0x11FF:
+		return true // Hangul vowel and final consonant jamo
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+// isRegionalIndicator reports whether r is one of the letters that pair up
+// into a flag emoji.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// nextGrapheme returns the end of the grapheme cluster starting at col.
+func nextGrapheme(runes []rune, col int) int {
+	col = max(col, 0)
+	if col >= len(runes) {
+		return len(runes)
+	}
+	i := col + 1
+	if i < len(runes) && isRegionalIndicator(runes[col]) && isRegionalIndicator(runes[i]) {
+		i++
+	}
+	for i < len(runes) && extendsGrapheme(runes[i-1], runes[i]) {
+		i++
+	}
+	return i
+}
+
+// prevGrapheme returns the start of the grapheme cluster ending at col. It
+// walks the clusters from the start of the line, since flags can only be
+// paired up from the left. A col past the end counts from the end.
+func prevGrapheme(runes []rune, col int) int {
+	col = min(col, len(runes))
+	start := 0
+	for start < col {
+		next := nextGrapheme(runes, start)
+		if next >= col {
+			return start
+		}
+		start = next
+	}
+	return 0
+}
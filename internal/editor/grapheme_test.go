@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestNextPrevGrapheme(t *testing.T) {
+	tests := []struct {
+		line   string
+		bounds []int // Cluster boundaries after the start
+	}{
+		{"abc", []int{1, 2, 3}},
+		{"e\u0301x", []int{2, 3}},                                 // Combining acute
+		{"\U0001F469\u200d\U0001F4BBa", []int{3, 4}},              // Woman technologist
+		{"\U0001F44D\U0001F3FD!", []int{2, 3}},                    // Skin tone modifier
+		{"❤\ufe0f", []int{2}},                                     // Variation selector
+		{"\U0001F1EC\U0001F1E7\U0001F1EB\U0001F1F7", []int{2, 4}}, // Two flags
+		{"\u1100\u1161\u11a8", []int{3}},                          // Hangul jamo
+	}
+	for _, tt := range tests {
+		runes := []rune(tt.line)
+		col := 0
+		for _, want := range tt.bounds {
+			if got := nextGrapheme(runes, col); got != want {
+				t.Errorf("%q: nextGrapheme(%d) = %d, want %d", tt.line, col, got, want)
+			}
+			if got := prevGrapheme(runes, want); got != col {
+				t.Errorf("%q: prevGrapheme(%d) = %d, want %d", tt.line, want, got, col)
+			}
+			col = want
+		}
+	}
+}
+
+func TestGraphemePastEnd(t *testing.T) {
+	runes := []rune("abc")
+	if got := prevGrapheme(runes, 5); got != 2 {
+		t.Errorf("prevGrapheme past the end = %d, want 2", got)
+	}
+	if got := nextGrapheme(runes, 5); got != 3 {
+		t.Errorf("nextGrapheme past the end = %d, want 3", got)
+	}
+	if got := prevGrapheme(nil, 1); got != 0 {
+		t.Errorf("prevGrapheme on an empty line = %d, want 0", got)
+	}
+}
+
+func TestMoveCursorOverGrapheme(t *testing.T) {
+	a := newTestApp("test.txt")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"ae\u0301b"}
+	eb.cursorCol = 1
+
+	a.moveCursor(terminal.KeyRight)
+	if eb.cursorCol != 3 {
+		t.Errorf("right over e + accent: col = %d, want 3", eb.cursorCol)
+	}
+	a.moveCursor(terminal.KeyLeft)
+	if eb.cursorCol != 1 {
+		t.Errorf("left over e + accent: col = %d, want 1", eb.cursorCol)
+	}
+}
+
+func TestDeleteGrapheme(t *testing.T) {
+	a := newTestApp("test.txt")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"hi \U0001F469\u200d\U0001F4BB!"}
+	eb.cursorCol = 6
+	a.mode = ModeEdit
+
+	a.deleteChar()
+	if eb.buf.Lines[0] != "hi !" || eb.cursorCol != 3 {
+		t.Fatalf("backspace: %q at %d, want %q at 3", eb.buf.Lines[0], eb.cursorCol, "hi !")
+	}
+	a.undoAction()
+	if eb.buf.Lines[0] != "hi \U0001F469\u200d\U0001F4BB!" || eb.cursorCol != 6 {
+		t.Errorf("undo: %q at %d", eb.buf.Lines[0], eb.cursorCol)
+	}
+
+	eb.cursorCol = 3
+	a.deleteCharForward()
+	if eb.buf.Lines[0] != "hi !" || eb.cursorCol != 3 {
+		t.Errorf("forward delete: %q at %d, want %q at 3", eb.buf.Lines[0], eb.cursorCol, "hi !")
+	}
+}
+
+func TestChangeWordEndKeepsMarks(t *testing.T) {
+	if got := changeWordEnd("cafe\u0301 noir", 0); got != 5 {
+		t.Errorf("changeWordEnd = %d, want 5", got)
+	}
+}
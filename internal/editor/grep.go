@@ -0,0 +1,192 @@
+package editor
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// grepMaxResults caps how many lines :grep lists.
+const grepMaxResults = 1000
+
+// GrepResult is a line matching a :grep search.
+type GrepResult struct {
+	Path string // Relative to the directory searched
+	Line int
+	Col  int
+	Text string
+}
+
+// Label formats a result for the overlay, e.g. "ch1.md:12: The harbour lay".
+func (g GrepResult) Label() string {
+	return fmt.Sprintf("%s:%d: %s", g.Path, g.Line+1, strings.TrimSpace(g.Text))
+}
+
+// isGrepFile reports whether :grep searches a file: Markdown and plain text.
+func isGrepFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".mdx", ".txt":
+		return true
+	}
+	return false
+}
+
+// Grep finds the lines of Markdown and text files under dir containing
+// query, ignoring case as / does, in path then line order. Hidden files and
+// directories are skipped. open holds the lines of files being edited, by
+// absolute path, which are searched instead of what is on disk.
+func Grep(dir, query string, open map[string][]string) ([]GrepResult, error) {
+	var results []GrepResult
+	err := walkTextFiles(dir, open, func(path, rel string, lines []string) bool {
+		last := -1
+		for _, m := range findMatches(lines, query) {
+			if m.Line == last {
+				continue
+			}
+			last = m.Line
+			results = append(results, GrepResult{Path: rel, Line: m.Line, Col: m.StartCol, Text: lines[m.Line]})
+			if len(results) == grepMaxResults {
+				return false
+			}
+		}
+		return true
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+	return results, err
+}
+
+// walkTextFiles calls fn with the path, the path relative to dir, and the
+// lines of each Markdown and text file under dir, skipping hidden files and
+// directories and those it can't read. open holds the lines of files being
+// edited, by absolute path, which are used instead of what is on disk. The
+// walk stops when fn returns false.
+func walkTextFiles(dir string, open map[string][]string, fn func(path, rel string, lines []string) bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries are skipped
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !isGrepFile(path) {
+			return nil
+		}
+		lines, ok := open[absPath(path)]
+		if !ok {
+			buf := NewBuffer(path)
+			if err := buf.Load(); err != nil {
+				return nil
+			}
+			lines = buf.Lines
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		if !fn(path, rel, lines) {
+			return fs.SkipAll
+		}
+		return nil
+	})
+}
+
+// GrepList manages the :grep results overlay.
+type GrepList struct {
+	ListView[GrepResult]
+	Query string
+	Title string // Shown above the results
+	Hint  string // Command or key that showed them
+}
+
+// Show activates the overlay with the given results.
+func (g *GrepList) Show(query string, items []GrepResult) {
+	g.ShowTitled("Grep: "+query, ":grep", items)
+	g.Query = query
+}
+
+// ShowTitled activates the overlay with results found other than by
+// :grep, such as backlinks.
+func (g *GrepList) ShowTitled(title, hint string, items []GrepResult) {
+	g.ListView.Show(items)
+	g.Query = ""
+	g.Title = title
+	g.Hint = hint
+}
+
+// grep runs :grep, searching the current directory and listing the
+// matching lines. Space-G starts the command.
+func (a *App) grep(query string) {
+	if query == "" {
+		a.statusBar.SetMessage("Usage: :grep <text>")
+		return
+	}
+	results, err := Grep(".", query, a.openFileLines())
+	if err != nil {
+		a.statusBar.SetError("grep: " + err.Error())
+		return
+	}
+	if len(results) == 0 {
+		a.statusBar.SetMessage("No matches for " + query)
+		return
+	}
+	if len(results) == grepMaxResults {
+		a.statusBar.SetMessage(fmt.Sprintf("Showing the first %d matches", grepMaxResults))
+	}
+	a.grepList.Show(query, results)
+}
+
+// openFileLines returns the lines of the files open in buffers, by
+// absolute path, for searches to read instead of the files on disk.
+func (a *App) openFileLines() map[string][]string {
+	open := make(map[string][]string, len(a.buffers))
+	for _, eb := range a.buffers {
+		if eb.buf.Filename != "" && !eb.isScratch {
+			open[absPath(eb.buf.Filename)] = eb.buf.Lines
+		}
+	}
+	return open
+}
+
+// openGrepResult opens the selected result's file at its line.
+func (a *App) openGrepResult() {
+	if a.grepList.Selected < 0 || a.grepList.Selected >= len(a.grepList.Items) {
+		return
+	}
+	res := a.grepList.Items[a.grepList.Selected]
+	a.pushJump()
+	a.currentBuffer = a.openBuffer(res.Path)
+	eb := a.currentBuf()
+	eb.cursorLine = min(res.Line, eb.buf.LineCount()-1)
+	eb.cursorCol = min(res.Col, eb.buf.LineLen(eb.cursorLine))
+	a.centreCursorIfHidden()
+}
+
+func (a *App) handleGrepKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.grepList.Hide()
+	case terminal.KeyUp:
+		a.grepList.MoveUp()
+	case terminal.KeyDown:
+		a.grepList.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.grepList.MoveUp()
+		case 'j':
+			a.grepList.MoveDown()
+		}
+	case terminal.KeyEnter:
+		a.openGrepResult()
+		a.grepList.Hide()
+	}
+}
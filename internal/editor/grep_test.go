@@ -0,0 +1,104 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func writeGrepTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"ch1.md":            "The harbour lay quiet.\nNothing moved.\nA HARBOUR wall, another harbour.",
+		"notes/ideas.txt":   "harbour scene",
+		"notes/script.go":   "// harbour",
+		".drafts/old.md":    "harbour",
+		"notes/.hidden.md":  "harbour",
+		"appendix.markdown": "No match here.",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGrep(t *testing.T) {
+	dir := writeGrepTree(t)
+	got, err := Grep(dir, "harbour", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GrepResult{
+		{Path: "ch1.md", Line: 0, Col: 4, Text: "The harbour lay quiet."},
+		{Path: "ch1.md", Line: 2, Col: 2, Text: "A HARBOUR wall, another harbour."},
+		{Path: filepath.Join("notes", "ideas.txt"), Line: 0, Col: 0, Text: "harbour scene"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Grep = %+v, want %+v", got, want)
+	}
+}
+
+func TestGrepSearchesOpenBuffers(t *testing.T) {
+	dir := writeGrepTree(t)
+	open := map[string][]string{
+		absPath(filepath.Join(dir, "ch1.md")): {"Edited, no longer matching."},
+	}
+	got, err := Grep(dir, "harbour", open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Path != filepath.Join("notes", "ideas.txt") {
+		t.Errorf("unsaved text of open files should be searched, got %+v", got)
+	}
+}
+
+func TestGrepResultLabel(t *testing.T) {
+	res := GrepResult{Path: "ch1.md", Line: 11, Text: "  The harbour lay quiet."}
+	if got := res.Label(); got != "ch1.md:12: The harbour lay quiet." {
+		t.Errorf("Label = %q", got)
+	}
+}
+
+func TestGrepCommand(t *testing.T) {
+	t.Chdir(writeGrepTree(t))
+	a := newTestApp("")
+	a.viewport = NewViewport(80, 24)
+
+	a.executeCommand("grep")
+	if a.statusBar.StatusMessage != "Usage: :grep <text>" {
+		t.Errorf("no query: message %q", a.statusBar.StatusMessage)
+	}
+	a.executeCommand("grep lighthouse")
+	if a.grepList.Active || a.statusBar.StatusMessage != "No matches for lighthouse" {
+		t.Errorf("no matches: active %v, message %q", a.grepList.Active, a.statusBar.StatusMessage)
+	}
+
+	a.executeCommand("grep harbour")
+	if !a.grepList.Active || len(a.grepList.Items) != 3 {
+		t.Fatalf("grep should list 3 matches, got %+v", a.grepList.Items)
+	}
+	sendKeys(a, "j")
+	sendKey(a, terminal.KeyEnter)
+	eb := a.currentBuf()
+	if a.grepList.Active || filepath.Base(eb.Filename()) != "ch1.md" || eb.cursorLine != 2 || eb.cursorCol != 2 {
+		t.Errorf("Enter should open ch1.md at 2:2, got %s at %d:%d", eb.Filename(), eb.cursorLine, eb.cursorCol)
+	}
+}
+
+func TestLeaderGStartsGrep(t *testing.T) {
+	a := newTestApp("draft.md")
+	sendKeys(a, " g")
+	if a.statusBar.Prompt != PromptCommand || a.statusBar.PromptText != "grep " {
+		t.Errorf("Space-G should start a :grep prompt, got %d %q", a.statusBar.Prompt, a.statusBar.PromptText)
+	}
+}
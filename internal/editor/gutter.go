@@ -0,0 +1,117 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LineNumbers selects the line-number gutter drawn left of the text column.
+type LineNumbers int
+
+const (
+	NumbersOff      LineNumbers = iota
+	NumbersAbsolute             // Each line's number
+	NumbersRelative             // Distance from the cursor line, which shows its own number
+)
+
+// gutterWidth returns the columns the gutter needs for a buffer of
+// lineCount lines: room for at least three digits, then a space, and two
+// columns before them for git signs.
+func gutterWidth(numbers LineNumbers, lineCount int, signs bool) int {
+	width := 0
+	if numbers != NumbersOff {
+		width = max(len(strconv.Itoa(lineCount)), 3) + 1
+	}
+	if signs {
+		width += 2
+	}
+	return width
+}
+
+// gutterCell returns a display line's gutter: its git sign and the buffer
+// line's number on its first row, and blanks on rows it wraps onto.
+// cursorLine is the cursor's buffer line, or -1 in an unfocused window,
+// which shows absolute numbers.
+func (r *Renderer) gutterCell(dl DisplayLine, width, cursorLine int) string {
+	cell := ""
+	if r.gitSigns != nil {
+		sign := " "
+		if dl.Offset == 0 && dl.BufferLine < len(r.gitSigns) {
+			sign = gitSignChars[r.gitSigns[dl.BufferLine]]
+		}
+		cell = r.theme.Muted + sign + "\x1b[0m "
+		width -= 2
+	}
+	if width <= 0 {
+		return cell
+	}
+	if dl.Offset > 0 {
+		return cell + strings.Repeat(" ", width)
+	}
+	n := dl.BufferLine + 1
+	if r.lineNumbers == NumbersRelative && cursorLine >= 0 && dl.BufferLine != cursorLine {
+		n = max(dl.BufferLine-cursorLine, cursorLine-dl.BufferLine)
+	}
+	return cell + fmt.Sprintf("%s%*d\x1b[0m ", r.theme.Muted, width-1, n)
+}
+
+// gitSigns returns the git signs to draw beside eb, or nil if they are
+// turned off or its file isn't committed.
+func (a *App) gitSigns(eb *EditorBuffer) []gitSign {
+	if !a.config.GitGutter {
+		return nil
+	}
+	return eb.gitSigns()
+}
+
+// setOption runs :set, which changes a display or typing option for the
+// session.
+func (a *App) setOption(name string) {
+	if format, ok := strings.CutPrefix(name, "ff="); ok {
+		a.setFileFormat(format)
+		return
+	}
+	if format, ok := strings.CutPrefix(name, "fileformat="); ok {
+		a.setFileFormat(format)
+		return
+	}
+	switch name {
+	case "numbers", "number", "nu":
+		a.renderer.lineNumbers = NumbersAbsolute
+	case "relativenumbers", "relativenumber", "rnu":
+		a.renderer.lineNumbers = NumbersRelative
+	case "nonumbers", "nonumber", "nonu", "norelativenumbers", "norelativenumber", "nornu":
+		a.renderer.lineNumbers = NumbersOff
+	case "cursorline", "cul":
+		a.renderer.cursorLine = true
+	case "nocursorline", "nocul":
+		a.renderer.cursorLine = false
+	case "abbreviations", "abbrev":
+		a.config.Abbreviations = true
+	case "noabbreviations", "noabbrev":
+		a.config.Abbreviations = false
+	case "tidy":
+		a.config.Tidy = true
+	case "notidy":
+		a.config.Tidy = false
+	case "conceal":
+		a.config.Conceal = true
+	case "noconceal":
+		a.config.Conceal = false
+	case "gitgutter":
+		a.config.GitGutter = true
+	case "nogitgutter":
+		a.config.GitGutter = false
+	case "smartpunct":
+		a.config.SmartPunct = true
+	case "nosmartpunct":
+		a.config.SmartPunct = false
+	case "ff", "fileformat":
+		a.setFileFormat("")
+	case "":
+		a.statusBar.SetMessage("Usage: :set numbers|relativenumbers|nonumbers|cursorline|nocursorline|abbrev|noabbrev|tidy|notidy|conceal|noconceal|smartpunct|nosmartpunct|gitgutter|nogitgutter|ff=unix|ff=dos")
+	default:
+		a.statusBar.SetMessage("Unknown option: " + name)
+	}
+}
@@ -0,0 +1,150 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGutterWidth(t *testing.T) {
+	tests := []struct {
+		numbers   LineNumbers
+		lineCount int
+		signs     bool
+		want      int
+	}{
+		{NumbersOff, 500, false, 0},
+		{NumbersAbsolute, 1, false, 4},
+		{NumbersAbsolute, 999, false, 4},
+		{NumbersRelative, 1000, false, 5},
+		{NumbersAbsolute, 123456, false, 7},
+		{NumbersOff, 500, true, 2},
+		{NumbersAbsolute, 999, true, 6},
+	}
+	for _, tt := range tests {
+		if got := gutterWidth(tt.numbers, tt.lineCount, tt.signs); got != tt.want {
+			t.Errorf("gutterWidth(%d, %d, %v) = %d, want %d", tt.numbers, tt.lineCount, tt.signs, got, tt.want)
+		}
+	}
+}
+
+func TestViewportGutterLayout(t *testing.T) {
+	tests := []struct {
+		name             string
+		width, gutter    int
+		fixed            int
+		wantCol, wantMar int
+	}{
+		{"wide terminal keeps the column centred", 200, 4, -1, 60, 70},
+		{"margin just holds the gutter", 64, 4, -1, 60, 4},
+		{"narrow terminal gives the gutter room", 60, 4, -1, 56, 4},
+		{"fixed margin never covers the gutter", 200, 4, 2, 60, 4},
+		{"fixed margin wider than the gutter", 200, 4, 10, 60, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vp := NewViewport(tt.width, 24)
+			vp.FixedMargin = tt.fixed
+			vp.SetGutter(tt.gutter)
+			if vp.ColWidth != tt.wantCol || vp.LeftMargin != tt.wantMar {
+				t.Errorf("col width %d, margin %d; want %d, %d", vp.ColWidth, vp.LeftMargin, tt.wantCol, tt.wantMar)
+			}
+		})
+	}
+
+	vp := NewViewport(60, 24)
+	vp.SetGutter(4)
+	vp.SetGutter(0)
+	if vp.ColWidth != 60 || vp.LeftMargin != 0 {
+		t.Errorf("turning the gutter off should restore the layout, got %d, %d", vp.ColWidth, vp.LeftMargin)
+	}
+}
+
+func TestRenderFrameGutter(t *testing.T) {
+	dls := []DisplayLine{
+		{BufferLine: 0, Text: "First line."},
+		{BufferLine: 1, Text: "Second line, "},
+		{BufferLine: 1, Offset: 13, Text: "wrapped."},
+		{BufferLine: 2, Text: "Third line."},
+		{BufferLine: 3, Text: "Fourth line."},
+	}
+	tests := []struct {
+		name    string
+		numbers LineNumbers
+		want    []string
+	}{
+		{"absolute", NumbersAbsolute, []string{
+			"\x1b[90m  1\x1b[0m First line.",
+			"\x1b[90m  2\x1b[0m Second line, ",
+			"    wrapped.",
+			"\x1b[90m  3\x1b[0m Third line.",
+			"\x1b[90m  4\x1b[0m Fourth line.",
+		}},
+		{"relative", NumbersRelative, []string{
+			"\x1b[90m  2\x1b[0m First line.",
+			"\x1b[90m  1\x1b[0m Second line, ",
+			"    wrapped.",
+			"\x1b[90m  3\x1b[0m Third line.",
+			"\x1b[90m  1\x1b[0m Fourth line.",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRenderer()
+			r.lineNumbers = tt.numbers
+			vp := NewViewport(64, 10)
+			vp.SetGutter(gutterWidth(tt.numbers, 4, false))
+			// The cursor is on the third line (display line 3).
+			frame := r.RenderFrame(dls, vp, 0, 3, 0, " f.txt", "DEFAULT ", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, 0)
+			for _, want := range tt.want {
+				if !strings.Contains(frame, want) {
+					t.Errorf("frame should contain %q: %q", want, frame)
+				}
+			}
+		})
+	}
+}
+
+func TestMouseClickWithGutter(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.currentBuf().buf.Lines = []string{"Hello world", "Second line"}
+	a.viewport = NewViewport(64, 10)
+	a.viewport.TopPadding = 0
+	a.viewport.SetGutter(4)
+
+	// The text column starts after the 4-column margin holding the gutter.
+	if line, col := a.mouseToBufferPos(2, 5+6); line != 1 || col != 6 {
+		t.Errorf("click in text went to %d:%d, want 1:6", line, col)
+	}
+	if line, col := a.mouseToBufferPos(1, 2); line != 0 || col != 0 {
+		t.Errorf("click in gutter went to %d:%d, want 0:0", line, col)
+	}
+}
+
+func TestSetOption(t *testing.T) {
+	tests := []struct {
+		cmd        string
+		numbers    LineNumbers
+		cursorLine bool
+		message    string
+	}{
+		{"set numbers", NumbersAbsolute, false, ""},
+		{"set nu", NumbersAbsolute, false, ""},
+		{"set relativenumbers", NumbersRelative, false, ""},
+		{"set nonumbers", NumbersOff, false, ""},
+		{"set cursorline", NumbersOff, true, ""},
+		{"set", NumbersOff, false, "Usage: :set numbers|relativenumbers|nonumbers|cursorline|nocursorline|abbrev|noabbrev|tidy|notidy|conceal|noconceal|smartpunct|nosmartpunct|gitgutter|nogitgutter|ff=unix|ff=dos"},
+		{"set wrap", NumbersOff, false, "Unknown option: wrap"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			a := newTestApp("draft.md")
+			a.executeCommand(tt.cmd)
+			if a.renderer.lineNumbers != tt.numbers || a.renderer.cursorLine != tt.cursorLine {
+				t.Errorf("numbers %d, cursorline %v; want %d, %v", a.renderer.lineNumbers, a.renderer.cursorLine, tt.numbers, tt.cursorLine)
+			}
+			if a.statusBar.StatusMessage != tt.message {
+				t.Errorf("message %q, want %q", a.statusBar.StatusMessage, tt.message)
+			}
+		})
+	}
+}
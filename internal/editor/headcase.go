@@ -0,0 +1,218 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// headingCase is a capitalization style for headings.
+type headingCase int
+
+const (
+	caseChicago  headingCase = iota // Title case, lowercasing all short words
+	caseAP                          // Title case, capitalizing words of four letters or more
+	caseSentence                    // Only the first word, and names, capitalized
+)
+
+// parseHeadingCase reads a style name as used by :headcase and the
+// project's heading_case setting.
+func parseHeadingCase(name string) (headingCase, error) {
+	switch strings.ToLower(name) {
+	case "title", "chicago":
+		return caseChicago, nil
+	case "ap":
+		return caseAP, nil
+	case "sentence":
+		return caseSentence, nil
+	}
+	return 0, fmt.Errorf("want title, chicago, ap or sentence, got %q", name)
+}
+
+// minorWords stay lowercase in title case unless they start or end the
+// heading or follow a colon: articles, coordinating conjunctions,
+// prepositions, and "to" and "as".
+var minorWords = map[string]bool{
+	"a": true, "an": true, "the": true,
+	"and": true, "but": true, "for": true, "nor": true, "or": true, "so": true, "yet": true,
+	"as": true, "to": true,
+	"about": true, "above": true, "across": true, "after": true, "against": true,
+	"along": true, "amid": true, "among": true, "around": true, "at": true,
+	"before": true, "behind": true, "below": true, "beneath": true, "beside": true,
+	"between": true, "beyond": true, "by": true, "down": true, "during": true,
+	"except": true, "from": true, "in": true, "inside": true, "into": true,
+	"like": true, "near": true, "of": true, "off": true, "on": true, "onto": true,
+	"out": true, "outside": true, "over": true, "past": true, "per": true,
+	"since": true, "than": true, "through": true, "throughout": true,
+	"toward": true, "towards": true, "under": true, "underneath": true,
+	"until": true, "up": true, "upon": true, "via": true, "with": true,
+	"within": true, "without": true,
+}
+
+// reHeadingMarkup splits an ATX heading into its opening hashes, text and
+// any closing hashes.
+var reHeadingMarkup = regexp.MustCompile(`^(#{1,6}\s+)(.*?)(\s+#+)?\s*$`)
+
+// convertHeadingCase recapitalizes heading text. Words with capitals after
+// their first letter (NASA, iPhone) are left alone, as are the given names
+// in sentence case.
+func convertHeadingCase(text string, style headingCase, names []string) string {
+	words := strings.Split(text, " ")
+	// Index of the last word with letters, always capitalized in title case.
+	last := -1
+	for i, w := range words {
+		if strings.IndexFunc(w, unicode.IsLetter) >= 0 {
+			last = i
+		}
+	}
+	first := true // The next word starts the heading or a subtitle
+	for i, w := range words {
+		if strings.IndexFunc(w, unicode.IsLetter) < 0 || strings.ContainsAny(w, "`/@") {
+			continue
+		}
+		parts := strings.Split(w, "-")
+		for j, part := range parts {
+			starts := first && j == 0
+			switch style {
+			case caseSentence:
+				parts[j] = sentenceWord(part, starts, names)
+			default:
+				parts[j] = titleWord(part, starts || i == last && j == len(parts)-1, style)
+			}
+		}
+		words[i] = strings.Join(parts, "-")
+		first = strings.HasSuffix(w, ":")
+	}
+	return strings.Join(words, " ")
+}
+
+// letters returns the span of w from its first letter to the end of its
+// last, so surrounding quotes and punctuation are kept as they are.
+func letters(w string) (start, end int) {
+	start = strings.IndexFunc(w, unicode.IsLetter)
+	end = strings.LastIndexFunc(w, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’' })
+	if start < 0 || end < start {
+		return 0, 0
+	}
+	_, size := utf8.DecodeRuneInString(w[end:])
+	return start, end + size
+}
+
+// mixedCase reports whether word has a capital after its first letter.
+func mixedCase(word string) bool {
+	_, size := utf8.DecodeRuneInString(word)
+	return strings.IndexFunc(word[size:], unicode.IsUpper) >= 0
+}
+
+// titleWord capitalizes one word, or part of a hyphenated word, for title
+// case.
+func titleWord(w string, always bool, style headingCase) string {
+	start, end := letters(w)
+	word := w[start:end]
+	if word == "" || mixedCase(word) {
+		return w
+	}
+	lower := strings.ToLower(word)
+	minor := minorWords[lower]
+	if style == caseAP {
+		minor = minor && utf8.RuneCountInString(lower) <= 3
+	}
+	if minor && !always {
+		return w[:start] + lower + w[end:]
+	}
+	return w[:start] + capitalize(word) + w[end:]
+}
+
+// sentenceWord lowercases one word for sentence case, unless it starts the
+// heading or is one of the names or the word "I".
+func sentenceWord(w string, starts bool, names []string) string {
+	start, end := letters(w)
+	word := w[start:end]
+	switch {
+	case word == "" || mixedCase(word) || word == "I" || slices.Contains(names, word):
+		return w
+	case starts:
+		return w[:start] + capitalize(word) + w[end:]
+	}
+	return w[:start] + strings.ToLower(word) + w[end:]
+}
+
+// capitalize uppercases the first letter of word.
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// recaseHeadingLine returns line, a heading's text line, in the given style.
+func recaseHeadingLine(line string, style headingCase, names []string) string {
+	if m := reHeadingMarkup.FindStringSubmatch(line); m != nil {
+		return m[1] + convertHeadingCase(m[2], style, names) + m[3]
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	return line[:indent] + convertHeadingCase(line[indent:], style, names)
+}
+
+// headCaseCommand runs :headcase [all] [style], recapitalizing the heading
+// of the section the cursor is in, or every heading. Without a style, the
+// project's heading_case is used, else title case.
+func (a *App) headCaseCommand(arg string) {
+	eb := a.currentBuf()
+	fields := strings.Fields(arg)
+	all := len(fields) > 0 && fields[0] == "all"
+	if all {
+		fields = fields[1:]
+	}
+	a.loadProject()
+	style := caseChicago
+	var names []string
+	if a.project != nil {
+		style = a.project.HeadingCase
+		names = a.project.KnownNames
+	}
+	if len(fields) > 0 {
+		var err error
+		if style, err = parseHeadingCase(fields[0]); err != nil {
+			a.statusBar.SetError("Heading case: " + err.Error())
+			return
+		}
+	}
+
+	headings := ExtractHeadings(eb.buf)
+	if !all {
+		i := SectionAt(headings, eb.cursorLine)
+		if i < 0 {
+			a.statusBar.SetMessage("Not in a section")
+			return
+		}
+		headings = headings[i : i+1]
+	}
+	lines := slices.Clone(eb.buf.Lines)
+	first, last, changed := -1, -1, 0
+	for _, h := range headings {
+		if h.Scene {
+			continue
+		}
+		line := recaseHeadingLine(lines[h.BufferLine], style, names)
+		if line == lines[h.BufferLine] {
+			continue
+		}
+		lines[h.BufferLine] = line
+		if first < 0 {
+			first = h.BufferLine
+		}
+		last = h.BufferLine
+		changed++
+	}
+	if changed == 0 {
+		a.statusBar.SetMessage("Headings unchanged")
+		return
+	}
+	if a.guardReadOnly() {
+		return
+	}
+	a.replaceLines(first, last-first+1, lines[first:last+1])
+	a.statusBar.SetMessage(fmt.Sprintf("Recapitalized %d heading(s)", changed))
+}
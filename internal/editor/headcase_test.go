@@ -0,0 +1,67 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestConvertHeadingCase(t *testing.T) {
+	tests := []struct {
+		text  string
+		style headingCase
+		want  string
+	}{
+		{"the lord of the rings", caseChicago, "The Lord of the Rings"},
+		{"a walk through the woods", caseChicago, "A Walk through the Woods"},
+		{"a walk through the woods", caseAP, "A Walk Through the Woods"},
+		{"what the storm was for", caseChicago, "What the Storm Was For"},
+		{"chapter one: the end of summer", caseChicago, "Chapter One: The End of Summer"},
+		{"a well-known story of NASA and iPhones", caseChicago, "A Well-Known Story of NASA and iPhones"},
+		{"“into the dark”", caseChicago, "“Into the Dark”"},
+		{"The Lord Of The Rings", caseSentence, "The lord of the rings"},
+		{"Letters From Anna: Part Two", caseSentence, "Letters from Anna: Part two"},
+		{"When I Met NASA", caseSentence, "When I met NASA"},
+	}
+	for _, tt := range tests {
+		if got := convertHeadingCase(tt.text, tt.style, []string{"Anna"}); got != tt.want {
+			t.Errorf("convertHeadingCase(%q, %d) = %q, want %q", tt.text, tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestRecaseHeadingLine(t *testing.T) {
+	if got := recaseHeadingLine("## the road home ##", caseChicago, nil); got != "## The Road Home ##" {
+		t.Errorf("ATX heading = %q", got)
+	}
+	if got := recaseHeadingLine("the road home", caseChicago, nil); got != "The Road Home" {
+		t.Errorf("setext heading = %q", got)
+	}
+}
+
+func TestHeadCaseCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.currentBuf().buf.Lines = []string{"# the first part", "", "Text.", "", "## Into The Night", "", "More text."}
+	a.currentBuf().cursorLine = 2
+
+	a.executeCommand("headcase")
+	if got := a.currentBuf().buf.Lines[0]; got != "# The First Part" {
+		t.Errorf(":headcase should fix the cursor's section heading, got %q", got)
+	}
+	if got := a.currentBuf().buf.Lines[4]; got != "## Into The Night" {
+		t.Errorf(":headcase should leave other headings, got %q", got)
+	}
+
+	a.executeCommand("headcase all sentence")
+	want := []string{"# The first part", "## Into the night"}
+	if a.currentBuf().buf.Lines[0] != want[0] || a.currentBuf().buf.Lines[4] != want[1] {
+		t.Errorf(":headcase all sentence = %q, %q", a.currentBuf().buf.Lines[0], a.currentBuf().buf.Lines[4])
+	}
+	a.currentBuf().undo.Undo(a.currentBuf().buf)
+	if got := a.currentBuf().buf.Lines[4]; got != "## Into The Night" {
+		t.Errorf("recasing every heading should undo as one step, got %q", got)
+	}
+
+	a.executeCommand("headcase shouting")
+	if a.statusBar.StatusMessage != `Heading case: want title, chicago, ap or sentence, got "shouting"` {
+		t.Errorf("unknown style message = %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,69 @@
+package editor
+
+import "time"
+
+// ]] and [[ move between headings without opening the outline. The heading
+// arrived at is flashed in the search colour for a moment, so the eye finds
+// it after the jump; low-bandwidth and screen reader output skip the flash,
+// since it costs two extra frames.
+
+// headingFlashTime is how long a heading stays highlighted after a jump.
+const headingFlashTime = 400 * time.Millisecond
+
+// jumpToNextHeading moves the cursor to the next heading.
+func (a *App) jumpToNextHeading() {
+	eb := a.currentBuf()
+	for _, h := range ExtractHeadings(eb.buf) {
+		if h.BufferLine > eb.cursorLine {
+			a.jumpToHeading(h.BufferLine)
+			return
+		}
+	}
+	a.statusBar.SetMessage("No next heading")
+}
+
+// jumpToPrevHeading moves the cursor to the heading of the current section,
+// or the previous one if already on it.
+func (a *App) jumpToPrevHeading() {
+	eb := a.currentBuf()
+	headings := ExtractHeadings(eb.buf)
+	for i := len(headings) - 1; i >= 0; i-- {
+		if line := headings[i].BufferLine; line < eb.cursorLine || (line == eb.cursorLine && eb.cursorCol > 0) {
+			a.jumpToHeading(line)
+			return
+		}
+	}
+	a.statusBar.SetMessage("No previous heading")
+}
+
+// jumpToHeading moves the cursor to the start of a heading line and flashes
+// it.
+func (a *App) jumpToHeading(line int) {
+	eb := a.currentBuf()
+	a.pushJump()
+	eb.cursorLine = line
+	eb.cursorCol = 0
+	if !a.renderer.lowBandwidth && !a.renderer.screenReader {
+		a.flashLine = line
+		a.flashUntil = time.Now().Add(headingFlashTime)
+	}
+}
+
+// flashDone reports whether a heading flash has just run its time, clearing
+// it so the next frame draws the heading plainly.
+func (a *App) flashDone(now time.Time) bool {
+	if a.flashUntil.IsZero() || now.Before(a.flashUntil) {
+		return false
+	}
+	a.flashUntil = time.Time{}
+	return true
+}
+
+// styleFlash highlights the flashed heading's display lines.
+func (a *App) styleFlash(dls []DisplayLine, lines []string) {
+	if a.flashUntil.IsZero() || a.flashLine >= len(lines) {
+		return
+	}
+	flash := StyleSpan{Code: a.renderer.theme.SearchCurrent, Reset: colorReset}
+	styleSelection(dls, flash, a.flashLine, 0, a.flashLine, len([]rune(lines[a.flashLine])))
+}
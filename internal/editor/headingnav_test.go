@@ -0,0 +1,89 @@
+package editor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func headingTestApp() *App {
+	a := newTestApp("test.md")
+	a.currentBuf().buf.Lines = []string{
+		"# One",
+		"Text.",
+		"```",
+		"# not a heading",
+		"```",
+		"",
+		"Two",
+		"===",
+		"More text.",
+	}
+	return a
+}
+
+func TestHeadingKeys(t *testing.T) {
+	a := headingTestApp()
+	eb := a.currentBuf()
+	press := func(keys string) {
+		for _, r := range keys {
+			a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: r})
+		}
+	}
+
+	press("]]")
+	if eb.cursorLine != 6 {
+		t.Errorf("]] moved to line %d, want the setext heading on 6", eb.cursorLine)
+	}
+	press("]]")
+	if eb.cursorLine != 6 || a.statusBar.StatusMessage != "No next heading" {
+		t.Errorf("]] past the last heading: line %d, %q", eb.cursorLine, a.statusBar.StatusMessage)
+	}
+
+	eb.cursorLine, eb.cursorCol = 8, 3
+	press("[[")
+	if eb.cursorLine != 6 || eb.cursorCol != 0 {
+		t.Errorf("[[ moved to %d:%d, want 6:0", eb.cursorLine, eb.cursorCol)
+	}
+	press("[[")
+	if eb.cursorLine != 0 {
+		t.Errorf("[[ on a heading moved to line %d, want 0", eb.cursorLine)
+	}
+	press("[s")
+	if eb.cursorLine != 0 {
+		t.Errorf("[s should still move by scenes, moved to line %d", eb.cursorLine)
+	}
+}
+
+func TestHeadingFlash(t *testing.T) {
+	a := headingTestApp()
+	a.jumpToNextHeading()
+	if a.flashUntil.IsZero() || a.flashLine != 6 {
+		t.Fatalf("no flash after jumping to a heading: line %d until %v", a.flashLine, a.flashUntil)
+	}
+
+	dls := WrapBuffer(a.currentBuf().buf, 60)
+	a.styleFlash(dls, a.currentBuf().buf.Lines)
+	for _, dl := range dls {
+		if flashed := len(dl.Styles) > 0; flashed != (dl.BufferLine == 6) {
+			t.Errorf("line %d flashed = %v", dl.BufferLine, flashed)
+		}
+	}
+
+	if a.flashDone(time.Now()) {
+		t.Error("flash ended at once")
+	}
+	if !a.flashDone(a.flashUntil) || !a.flashUntil.IsZero() {
+		t.Error("flash should end after headingFlashTime")
+	}
+}
+
+func TestHeadingFlashLowBandwidth(t *testing.T) {
+	a := headingTestApp()
+	a.SetLowBandwidth(true)
+	a.jumpToNextHeading()
+	if !a.flashUntil.IsZero() {
+		t.Error("low-bandwidth mode should not flash headings")
+	}
+}
@@ -0,0 +1,120 @@
+package editor
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// :help, or Space ?, lists the keys bound in Default mode and after the
+// leader key, the keys of Edit mode, and every : command. It is built from
+// the keymap as it is, so keys remapped in the config file show as they
+// are.
+
+// helpCommands are the : commands the command palette doesn't list, with
+// what they do, for the help. The help lists the palette's commands too.
+var helpCommands = [][2]string{
+	{":w!", "Save even if read-only or changed on disk"},
+	{":q!", "Close the buffer without saving"},
+	{":qa!, :!qa", "Quit all without saving"},
+	{":wqa, :qwa", "Save all and quit"},
+	{":sp [file], :vs [file]", "Split the window, stacked or side by side"},
+	{":new name [file]", "New buffer from a template"},
+	{":template name", "Insert a template"},
+	{":project [build]", "Reload the project, or build the manuscript"},
+	{":grep text", "Search the files under the directory"},
+	{":recent", "Recent files"},
+	{":help", "Help"},
+	{":!cmd, :'<,'>!cmd", "Filter the buffer or lines through a command"},
+	{":42, :$", "Go to a line"},
+}
+
+// helpEditKeys are the keys Edit mode gives besides typing and moving,
+// which the keymap doesn't cover.
+var helpEditKeys = [][2]string{
+	{"Ctrl-_, Ctrl-/", "Undo"},
+	{"Ctrl-Y", "Redo"},
+	{"Ctrl-N, Ctrl-P", "Complete the word"},
+	{"Ctrl-Z", "Suspend to the shell"},
+}
+
+// helpRow is one line of the help overlay.
+type helpRow struct {
+	Text    string
+	Heading bool
+}
+
+// helpRows lays out the bindings in k, by action, and the palette's
+// commands, each under a heading.
+func helpRows(k *Keymap) []helpRow {
+	var rows []helpRow
+	// section adds a heading and its entries, each a key or command and
+	// what it does, in columns.
+	section := func(title string, entries [][2]string) {
+		width := 0
+		for _, e := range entries {
+			width = max(width, utf8.RuneCountInString(e[0]))
+		}
+		rows = append(rows, helpRow{Text: title, Heading: true})
+		for _, e := range entries {
+			rows = append(rows, helpRow{Text: e[0] + strings.Repeat(" ", width-utf8.RuneCountInString(e[0])+2) + e[1]})
+		}
+	}
+	bindings := func(table map[string]string, prefix string) [][2]string {
+		var entries [][2]string
+		for _, item := range bindingItems(table, k.actions) {
+			keys := make([]string, len(item.Keys))
+			for i, key := range item.Keys {
+				keys[i] = prefix + key
+			}
+			entries = append(entries, [2]string{strings.Join(keys, ", "), k.actions[item.Action].Desc})
+		}
+		return entries
+	}
+	section("Default mode", bindings(k.Default, ""))
+	section("Edit mode", helpEditKeys)
+	section("Leader key", bindings(k.Leader, "Space "))
+
+	var commands [][2]string
+	for _, cmd := range paletteCommands {
+		if cmd.Ex != "" {
+			commands = append(commands, [2]string{":" + strings.TrimSpace(cmd.Ex), cmd.Name})
+		}
+	}
+	commands = append(commands, helpCommands...)
+	sort.SliceStable(commands, func(i, j int) bool { return commands[i][0] < commands[j][0] })
+	section("Commands", commands)
+	return rows
+}
+
+// HelpView manages the :help overlay.
+type HelpView struct {
+	ListView[helpRow]
+}
+
+// showHelp runs :help.
+func (a *App) showHelp() {
+	a.help.Show(helpRows(a.keymap))
+}
+
+func (a *App) handleHelpKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape, terminal.KeyEnter:
+		a.help.Hide()
+	case terminal.KeyUp:
+		a.help.MoveUp()
+	case terminal.KeyDown:
+		a.help.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.help.MoveUp()
+		case 'j':
+			a.help.MoveDown()
+		case 'q':
+			a.help.Hide()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package editor
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// helpText returns the help's rows as lines, headings marked with #.
+func helpText(rows []helpRow) string {
+	var b strings.Builder
+	for _, row := range rows {
+		if row.Heading {
+			b.WriteString("# ")
+		}
+		b.WriteString(row.Text + "\n")
+	}
+	return b.String()
+}
+
+func TestHelpRows(t *testing.T) {
+	k := DefaultKeymap()
+	k.Bind("U", "undo")
+	k.Bind("Ctrl-R", "none")
+	text := helpText(helpRows(k))
+	for _, want := range []string{"# Default mode\n", "# Edit mode\n", "# Leader key\n", "# Commands\n", "u, U ", "Space ?  ", ":gitdiff "} {
+		if !strings.Contains(text, want) {
+			t.Errorf("help should contain %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Ctrl-R") {
+		t.Error("an unbound key should not be listed")
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.executeCommand("help")
+	if !a.help.Active || !a.help.Items[0].Heading {
+		t.Fatal(":help should open the help")
+	}
+	sendKeys(a, "jj")
+	if a.help.Selected != 2 {
+		t.Errorf("j should move down, at %d", a.help.Selected)
+	}
+	sendKeys(a, "q")
+	if a.help.Active {
+		t.Error("q should close the help")
+	}
+
+	sendKeys(a, " ?")
+	if !a.help.Active {
+		t.Error("Space ? should open the help")
+	}
+	sendKey(a, terminal.KeyEscape)
+	if a.help.Active {
+		t.Error("Esc should close the help")
+	}
+}
+
+// TestHelpListsEveryCommand checks that each command executeCommand knows
+// has a row in the help.
+func TestHelpListsEveryCommand(t *testing.T) {
+	listed := make(map[string]bool)
+	inCommands := false
+	for _, row := range helpRows(DefaultKeymap()) {
+		if row.Heading {
+			inCommands = row.Text == "Commands"
+			continue
+		}
+		if !inCommands {
+			continue
+		}
+		cmds, _, _ := strings.Cut(row.Text, "  ")
+		for _, cmd := range strings.Split(cmds, ", ") {
+			listed[strings.Fields(strings.TrimPrefix(cmd, ":"))[0]] = true
+		}
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "app.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hidden := map[string]bool{"bench": true}
+	ast.Inspect(file, func(n ast.Node) bool {
+		fn, ok := n.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "executeCommand" {
+			return true
+		}
+		// Every string compared with the command, or that it starts with,
+		// names a command.
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			clause, ok := n.(*ast.CaseClause)
+			if !ok {
+				return true
+			}
+			for _, expr := range clause.List {
+				ast.Inspect(expr, func(n ast.Node) bool {
+					lit, ok := n.(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						return true
+					}
+					s, _ := strconv.Unquote(lit.Value)
+					if name := strings.Fields(s); len(name) > 0 && !hidden[name[0]] && !listed[name[0]] {
+						t.Errorf(":%s isn't in the help", name[0])
+					}
+					return true
+				})
+			}
+			return false
+		})
+		return false
+	})
+}
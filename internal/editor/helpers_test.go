@@ -0,0 +1,91 @@
+package editor
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// newTestApp creates a minimal App editing filename, with no window.
+func newTestApp(filename string) *App {
+	eb := NewEditorBuffer(filename)
+	return &App{
+		buffers:      []*EditorBuffer{eb},
+		renderer:     NewRenderer(),
+		statusBar:    NewStatusBar(),
+		picker:       &Picker{},
+		nameCheck:    &NameCheck{},
+		dupes:        &DuplicateList{},
+		completion:   &Completion{},
+		undoTree:     &UndoTreeView{},
+		notes:        &NoteList{},
+		journal:      &JournalList{},
+		stats:        &StatsView{},
+		grepList:     &GrepList{},
+		quitSummary:  &QuitSummary{},
+		merge:        &MergeView{},
+		gitDiff:      &GitDiffView{},
+		help:         &HelpView{},
+		messages:     &MessageLog{},
+		recent:       &RecentList{},
+		palette:      &CommandPalette{},
+		outline:      &Outline{},
+		browser:      &Browser{},
+		columnAdjust: &ColumnAdjust{},
+		keymap:       DefaultKeymap(),
+		leaderMenu:   &LeaderMenu{},
+		snippets:     defaultSnippets,
+		mode:         ModeDefault,
+	}
+}
+
+// newTestAppWithLines returns a test app editing draft.md in an 80x24
+// window, with the given lines.
+func newTestAppWithLines(lines ...string) *App {
+	a := newTestApp("draft.md")
+	a.viewport = NewViewport(80, 24)
+	a.currentBuf().buf.Lines = slices.Clone(lines)
+	return a
+}
+
+// newTempTestApp returns a test app with one buffer per name in a new temp
+// dir, and the dir. Session and undo state go in another temp dir.
+func newTempTestApp(t *testing.T, names ...string) (*App, string) {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	a := newTestApp(filepath.Join(dir, names[0]))
+	for _, name := range names[1:] {
+		a.buffers = append(a.buffers, NewEditorBuffer(filepath.Join(dir, name)))
+	}
+	return a, dir
+}
+
+// newSplitTestApp returns a test app with a four-line buffer in an 81x24
+// terminal, ready to be split.
+func newSplitTestApp(t *testing.T) *App {
+	t.Helper()
+	a, _ := newTempTestApp(t, "a.md")
+	a.buffers[0].buf.Lines = []string{"one", "two", "three", "four"}
+	a.viewport = NewViewport(81, 24)
+	a.termWidth, a.termHeight = 81, 24
+	return a
+}
+
+// sendKeys sends runes through handleInput as key presses, with \x1b as Esc.
+func sendKeys(a *App, keys string) {
+	for _, r := range keys {
+		key := terminal.Key{Type: terminal.KeyRune, Rune: r}
+		if r == '\x1b' {
+			key = terminal.Key{Type: terminal.KeyEscape}
+		}
+		a.handleInput(terminal.InputEvent{Type: terminal.EventKey, Key: key})
+	}
+}
+
+// sendKey sends a key with no rune, such as Enter or Tab, through handleInput.
+func sendKey(a *App, keyType int) {
+	a.handleInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: keyType}})
+}
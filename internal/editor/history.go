@@ -0,0 +1,117 @@
+package editor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// History is the search and command history saved between runs, with the
+// files recently opened.
+type History struct {
+	Commands []string `json:"commands"` // Oldest first
+	Searches []string `json:"searches"`
+	Files    []string `json:"files,omitempty"` // Absolute paths
+}
+
+// historyMax caps how many entries each history keeps.
+const historyMax = 200
+
+// historyPath returns the location of the history file.
+func historyPath() string {
+	return filepath.Join(stateDir(), "history.json")
+}
+
+// loadHistory reads the history file. A missing or unreadable file yields
+// an empty history.
+func loadHistory() History {
+	var h History
+	data, err := os.ReadFile(historyPath())
+	if err != nil {
+		return h
+	}
+	json.Unmarshal(data, &h)
+	return h
+}
+
+// saveHistory writes the history file, creating its directory if needed.
+func saveHistory(h History) error {
+	if err := os.MkdirAll(stateDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(historyPath(), append(data, '\n'), 0600)
+}
+
+// addHistory appends entry to list, moving an earlier copy to the end and
+// dropping the oldest entries past historyMax.
+func addHistory(list []string, entry string) []string {
+	if strings.TrimSpace(entry) == "" {
+		return list
+	}
+	if i := indexOf(list, entry); i >= 0 {
+		list = append(list[:i:i], list[i+1:]...)
+	}
+	list = append(list, entry)
+	if len(list) > historyMax {
+		list = list[len(list)-historyMax:]
+	}
+	return list
+}
+
+// recordHistory saves a command or search just entered. The file is read
+// first so entries from other running instances are kept.
+func (a *App) recordHistory(pt PromptType, entry string) {
+	h := loadHistory()
+	switch pt {
+	case PromptCommand:
+		h.Commands = addHistory(h.Commands, entry)
+	case PromptSearch:
+		h.Searches = addHistory(h.Searches, entry)
+	}
+	if err := saveHistory(h); err != nil {
+		a.errorLog("history save failed", err)
+	}
+}
+
+// history returns the history the active prompt recalls with the arrow
+// keys, or nil if it has none.
+func (s *StatusBar) history() *[]string {
+	switch s.Prompt {
+	case PromptCommand:
+		return &s.CommandHistory
+	case PromptSearch:
+		return &s.SearchHistory
+	}
+	return nil
+}
+
+// recallHistory moves through the active prompt's history: dir -1 for an
+// older entry (Up), 1 for a newer one (Down). Only entries starting with
+// what was typed before the first Up are recalled, and going past the newest
+// entry brings the typed text back.
+func (s *StatusBar) recallHistory(dir int) {
+	h := s.history()
+	if h == nil {
+		return
+	}
+	if s.historyIdx == len(*h) {
+		s.historyDraft = s.PromptText
+	}
+	for i := s.historyIdx + dir; i >= 0 && i <= len(*h); i += dir {
+		if i == len(*h) {
+			s.historyIdx = i
+			s.PromptText = s.historyDraft
+			return
+		}
+		if strings.HasPrefix((*h)[i], s.historyDraft) {
+			s.historyIdx = i
+			s.PromptText = (*h)[i]
+			return
+		}
+	}
+}
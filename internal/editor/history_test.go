@@ -0,0 +1,121 @@
+package editor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestAddHistory(t *testing.T) {
+	tests := []struct {
+		list  []string
+		entry string
+		want  []string
+	}{
+		{nil, "w", []string{"w"}},
+		{[]string{"w", "q"}, "set numbers", []string{"w", "q", "set numbers"}},
+		{[]string{"w", "q", "wq"}, "w", []string{"q", "wq", "w"}},
+		{[]string{"w"}, "  ", []string{"w"}},
+	}
+	for _, tt := range tests {
+		if got := addHistory(tt.list, tt.entry); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("addHistory(%q, %q) = %q, want %q", tt.list, tt.entry, got, tt.want)
+		}
+	}
+
+	var long []string
+	for i := 0; i < historyMax+5; i++ {
+		long = addHistory(long, fmt.Sprint(i))
+	}
+	if len(long) != historyMax || long[0] != "5" {
+		t.Errorf("history should keep the newest %d entries, starts at %q with %d", historyMax, long[0], len(long))
+	}
+}
+
+func TestPromptHistoryRecall(t *testing.T) {
+	sb := NewStatusBar()
+	sb.CommandHistory = []string{"set numbers", "w", "split notes.md"}
+	sb.StartPrompt(PromptCommand)
+
+	up := terminal.Key{Type: terminal.KeyUp}
+	down := terminal.Key{Type: terminal.KeyDown}
+	steps := []struct {
+		key  terminal.Key
+		want string
+	}{
+		{up, "split notes.md"},
+		{up, "w"},
+		{up, "set numbers"},
+		{up, "set numbers"}, // Stays on the oldest
+		{down, "w"},
+		{down, "split notes.md"},
+		{down, ""}, // Back to what was typed
+		{down, ""},
+	}
+	for i, step := range steps {
+		sb.HandlePromptKey(step.key)
+		if sb.PromptText != step.want {
+			t.Errorf("step %d: prompt %q, want %q", i, sb.PromptText, step.want)
+		}
+	}
+}
+
+func TestPromptHistoryPrefix(t *testing.T) {
+	sb := NewStatusBar()
+	sb.CommandHistory = []string{"set numbers", "w", "set cursorline", "q"}
+	sb.StartPrompt(PromptCommand)
+	sb.PromptText = "se"
+
+	sb.HandlePromptKey(terminal.Key{Type: terminal.KeyUp})
+	if sb.PromptText != "set cursorline" {
+		t.Errorf("first Up: %q", sb.PromptText)
+	}
+	sb.HandlePromptKey(terminal.Key{Type: terminal.KeyUp})
+	if sb.PromptText != "set numbers" {
+		t.Errorf("second Up: %q", sb.PromptText)
+	}
+	sb.HandlePromptKey(terminal.Key{Type: terminal.KeyDown})
+	sb.HandlePromptKey(terminal.Key{Type: terminal.KeyDown})
+	if sb.PromptText != "se" {
+		t.Errorf("Down past the newest match should restore the typed text, got %q", sb.PromptText)
+	}
+}
+
+func TestPromptHistoryPerPrompt(t *testing.T) {
+	sb := NewStatusBar()
+	sb.StartPrompt(PromptSearch)
+	sb.PromptText = "harbour"
+	sb.HandlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	if len(sb.SearchHistory) != 1 || len(sb.CommandHistory) != 0 {
+		t.Fatalf("search should go in search history: %q, %q", sb.SearchHistory, sb.CommandHistory)
+	}
+
+	sb.StartPrompt(PromptCommand)
+	sb.HandlePromptKey(terminal.Key{Type: terminal.KeyUp})
+	if sb.PromptText != "" {
+		t.Errorf("command prompt should not recall searches, got %q", sb.PromptText)
+	}
+
+	sb.StartPrompt(PromptNote)
+	sb.HandlePromptKey(terminal.Key{Type: terminal.KeyUp})
+	if sb.PromptText != "" {
+		t.Errorf("note prompt has no history, got %q", sb.PromptText)
+	}
+}
+
+func TestHistoryPersisted(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	a := newTestApp("draft.md")
+	a.currentBuf().buf.Lines = []string{"The harbour lay quiet."}
+
+	sendKeys(a, ":goto 1")
+	sendKey(a, terminal.KeyEnter)
+	sendKeys(a, "/harbour")
+	sendKey(a, terminal.KeyEnter)
+	want := History{Commands: []string{"goto 1"}, Searches: []string{"harbour"}}
+	if got := loadHistory(); !reflect.DeepEqual(got, want) {
+		t.Errorf("history file = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package editor
+
+// A hoisted section is shown alone: the lines before its heading and after
+// its end are hidden and the cursor stays between them. The buffer itself is
+// untouched, so edits, undo and :w work on the whole document as usual.
+
+// hoistBounds returns the first and last lines shown: the hoisted section,
+// or the whole buffer.
+func (eb *EditorBuffer) hoistBounds() (first, last int) {
+	last = eb.buf.LineCount() - 1
+	if eb.hoist == nil {
+		return 0, last
+	}
+	return min(eb.hoist.Start, last), min(eb.hoist.End, last)
+}
+
+// hoistLines drops the display lines outside the hoisted section.
+func (eb *EditorBuffer) hoistLines(dls []DisplayLine) []DisplayLine {
+	if eb.hoist == nil {
+		return dls
+	}
+	first, last := eb.hoistBounds()
+	shown := dls[:0]
+	for _, dl := range dls {
+		if dl.BufferLine >= first && dl.BufferLine <= last {
+			shown = append(shown, dl)
+		}
+	}
+	return shown
+}
+
+// keepInHoist moves the cursor back inside the hoisted section, so motions
+// and jumps stop at its edges.
+func (eb *EditorBuffer) keepInHoist() {
+	if eb.hoist == nil {
+		return
+	}
+	first, last := eb.hoistBounds()
+	if eb.cursorLine < first || eb.cursorLine > last {
+		eb.cursorLine = max(first, min(eb.cursorLine, last))
+		eb.cursorCol = min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+	}
+}
+
+// shiftHoist keeps the hoisted section on its text after delta lines were
+// inserted or deleted just below line. Lines added inside the section, or
+// above its heading with O, belong to it.
+func (eb *EditorBuffer) shiftHoist(line, delta int) {
+	h := eb.hoist
+	if h == nil {
+		return
+	}
+	if delta > 0 && line >= h.Start-1 && line <= h.End {
+		h.End += delta
+		return
+	}
+	h.Start = shiftedLine(h.Start, line, delta)
+	h.End = max(h.Start, shiftedLine(h.End, line, delta))
+}
+
+// toggleHoist runs :hoist, showing only the section under the cursor, from
+// its heading to the next heading of the same or a higher level. Run again,
+// it shows the whole document.
+func (a *App) toggleHoist() {
+	eb := a.currentBuf()
+	if eb.hoist != nil {
+		eb.hoist = nil
+		a.statusBar.SetMessage("Showing the whole document")
+		return
+	}
+	if !IsMarkdownFile(eb.buf.Filename) {
+		a.statusBar.SetMessage("Hoisting only available for markdown files")
+		return
+	}
+	headings := ExtractHeadings(eb.buf)
+	i := SectionAt(headings, eb.cursorLine)
+	if i < 0 {
+		a.statusBar.SetMessage("No section to hoist")
+		return
+	}
+	h := headings[i]
+	eb.hoist = &FoldRange{Start: h.BufferLine, End: SectionEnd(headings, i, eb.buf.LineCount())}
+	delete(eb.folds, h.BufferLine)
+	a.statusBar.SetMessage("Hoisted " + h.Text + "; :hoist again to show the whole document")
+}
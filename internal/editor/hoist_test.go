@@ -0,0 +1,102 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// hoistTestLines is a three-section document; the tests put the cursor in
+// the second section, on line 3.
+var hoistTestLines = []string{"# One", "first", "# Two", "second", "## Sub", "more", "# Three", "third"}
+
+func shownLines(eb *EditorBuffer) []int {
+	var lines []int
+	for _, dl := range eb.DisplayLines(80) {
+		lines = append(lines, dl.BufferLine)
+	}
+	return lines
+}
+
+func TestHoistShowsSection(t *testing.T) {
+	a := newTestAppWithLines(hoistTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 3
+	a.executeCommand("hoist")
+	if got := shownLines(eb); len(got) != 4 || got[0] != 2 || got[3] != 5 {
+		t.Fatalf("shown lines = %v, want 2-5", got)
+	}
+
+	sendKeys(a, "G")
+	if eb.cursorLine != 5 {
+		t.Errorf("G should stop at the end of the section, at %d", eb.cursorLine)
+	}
+	sendKeys(a, "gg")
+	if eb.cursorLine != 2 {
+		t.Errorf("gg should stop at the heading, at %d", eb.cursorLine)
+	}
+	sendKeys(a, "k")
+	if eb.cursorLine != 2 {
+		t.Errorf("k should not leave the section, at %d", eb.cursorLine)
+	}
+
+	a.executeCommand("hoist")
+	if got := shownLines(eb); len(got) != len(eb.buf.Lines) {
+		t.Errorf(":hoist again should show the whole document, got %v", got)
+	}
+}
+
+func TestHoistEdits(t *testing.T) {
+	a := newTestAppWithLines(hoistTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 3
+	a.executeCommand("hoist")
+
+	// A line added at the end of the section stays in it.
+	eb.cursorLine = 5
+	sendKeys(a, "oadded\x1b")
+	if eb.hoist.Start != 2 || eb.hoist.End != 6 {
+		t.Errorf("hoist = %+v after o, want 2-6", *eb.hoist)
+	}
+	sendKeys(a, "ggdd")
+	if eb.hoist.Start != 2 || eb.hoist.End != 5 {
+		t.Errorf("hoist = %+v after dd, want 2-5", *eb.hoist)
+	}
+
+	a.executeCommand("hoist")
+	want := "# One|first|second|## Sub|more|added|# Three|third"
+	if got := strings.Join(eb.buf.Lines, "|"); got != want {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestHoistRefused(t *testing.T) {
+	a := newTestAppWithLines(hoistTestLines...)
+	a.currentBuf().buf.Lines[0] = "no heading"
+	a.executeCommand("hoist")
+	if a.currentBuf().hoist != nil || a.statusBar.StatusMessage != "No section to hoist" {
+		t.Errorf("hoist before the first heading: %q", a.statusBar.StatusMessage)
+	}
+
+	a = newTestApp("notes.txt")
+	a.executeCommand("hoist")
+	if a.currentBuf().hoist != nil {
+		t.Error("plain text has no sections to hoist")
+	}
+}
+
+func TestHoistUnfoldsHeading(t *testing.T) {
+	a := newTestAppWithLines(hoistTestLines...)
+	eb := a.currentBuf()
+	eb.cursorLine = 3
+	sendKeys(a, "zc")
+	a.executeCommand("hoist")
+	if got := shownLines(eb); len(got) != 4 {
+		t.Errorf("the hoisted section should be unfolded, shown %v", got)
+	}
+	sendKey(a, terminal.KeyDown)
+	if eb.cursorLine != 3 {
+		t.Errorf("Down should move into the section, at %d", eb.cursorLine)
+	}
+}
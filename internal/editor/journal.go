@@ -0,0 +1,325 @@
+package editor
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// JournalEntry is one dated note in the journal directory.
+type JournalEntry struct {
+	Path  string
+	Date  time.Time
+	Words int
+}
+
+// reJournalDate matches the date a journal file is named after, as in
+// 2024-05-31.md or 2024-05-31-holiday.md.
+var reJournalDate = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})`)
+
+// expandHome replaces a leading "~" in path with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// ScanJournal finds the dated notes under dir, including subdirectories such
+// as 2024/05/, newest first. Files that aren't Markdown or text, or whose
+// names don't start with a date, are skipped.
+func ScanJournal(dir string) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown", ".txt":
+		default:
+			return nil
+		}
+		m := reJournalDate.FindString(d.Name())
+		if m == "" {
+			return nil
+		}
+		date, err := time.Parse("2006-01-02", m)
+		if err != nil {
+			return nil
+		}
+		buf := NewBuffer(path)
+		if err := buf.Load(); err != nil {
+			return nil
+		}
+		entries = append(entries, JournalEntry{Path: path, Date: date, Words: buf.WordCount()})
+		return nil
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.After(entries[j].Date)
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, err
+}
+
+// JournalGrouping is how the archive groups its entries.
+type JournalGrouping int
+
+const (
+	GroupByMonth JournalGrouping = iota
+	GroupByWeek
+)
+
+// JournalRow is one row of the archive: a group heading or an entry.
+type JournalRow struct {
+	Heading string        // Set for group headings
+	Entry   *JournalEntry // Set for entries
+}
+
+// weekStart returns the Monday of the week containing t.
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// groupKey returns the heading for the group an entry falls in, e.g.
+// "May 2024" or "Week 22, 2024 (from 27 May)".
+func groupKey(date time.Time, grouping JournalGrouping) string {
+	if grouping == GroupByWeek {
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("Week %d, %d (from %s)", week, year, weekStart(date).Format("2 Jan"))
+	}
+	return date.Format("January 2006")
+}
+
+// GroupJournal arranges entries (newest first) under a heading for each
+// month or week, with the group's entry and word totals.
+func GroupJournal(entries []JournalEntry, grouping JournalGrouping) []JournalRow {
+	var rows []JournalRow
+	for i := 0; i < len(entries); {
+		key := groupKey(entries[i].Date, grouping)
+		j, words := i, 0
+		for j < len(entries) && groupKey(entries[j].Date, grouping) == key {
+			words += entries[j].Words
+			j++
+		}
+		noun := "entries"
+		if j-i == 1 {
+			noun = "entry"
+		}
+		rows = append(rows, JournalRow{Heading: fmt.Sprintf("%s  %d %s, %d words", key, j-i, noun, words)})
+		for k := i; k < j; k++ {
+			rows = append(rows, JournalRow{Entry: &entries[k]})
+		}
+		i = j
+	}
+	return rows
+}
+
+// journalEntryLabel formats an entry for the archive, e.g. "Fri 31 May  420 words".
+func journalEntryLabel(e JournalEntry) string {
+	label := e.Date.Format("Mon 2 Jan") + fmt.Sprintf("  %d words", e.Words)
+	name := strings.TrimSuffix(filepath.Base(e.Path), filepath.Ext(e.Path))
+	if rest := strings.TrimLeft(strings.TrimPrefix(name, e.Date.Format("2006-01-02")), "-_ "); rest != "" {
+		label += "  " + rest
+	}
+	return label
+}
+
+// JournalList manages the journal archive overlay. Its items are the rows
+// of the entries grouped, headings among them.
+type JournalList struct {
+	ListView[JournalRow]
+	Entries  []JournalEntry
+	Grouping JournalGrouping
+}
+
+// Show activates the overlay with the given entries, newest first.
+func (j *JournalList) Show(entries []JournalEntry) {
+	j.Active = true
+	j.Entries = entries
+	j.ScrollOffset = 0
+	j.SetGrouping(j.Grouping)
+}
+
+// Hide deactivates the overlay.
+func (j *JournalList) Hide() {
+	j.ListView.Hide()
+	j.Entries = nil
+}
+
+// SetGrouping regroups the entries, keeping the selected entry selected.
+func (j *JournalList) SetGrouping(grouping JournalGrouping) {
+	var selected *JournalEntry
+	if j.Selected < len(j.Items) {
+		selected = j.Items[j.Selected].Entry
+	}
+	j.Grouping = grouping
+	j.Items = GroupJournal(j.Entries, grouping)
+	j.Selected = 0
+	for i, row := range j.Items {
+		if row.Entry == nil {
+			continue
+		}
+		if selected == nil || row.Entry == selected {
+			j.Selected = i
+			break
+		}
+	}
+}
+
+// SelectedEntry returns the selected entry, or nil.
+func (j *JournalList) SelectedEntry() *JournalEntry {
+	if j.Selected < 0 || j.Selected >= len(j.Items) {
+		return nil
+	}
+	return j.Items[j.Selected].Entry
+}
+
+// MoveUp moves the selection to the previous entry, skipping headings.
+func (j *JournalList) MoveUp() {
+	for i := j.Selected - 1; i >= 0; i-- {
+		if j.Items[i].Entry != nil {
+			j.Selected = i
+			return
+		}
+	}
+}
+
+// MoveDown moves the selection to the next entry, skipping headings.
+func (j *JournalList) MoveDown() {
+	for i := j.Selected + 1; i < len(j.Items); i++ {
+		if j.Items[i].Entry != nil {
+			j.Selected = i
+			return
+		}
+	}
+}
+
+// VisibleItems returns the rows that fit in maxHeight, scrolled to keep the
+// selection (and the heading just above it) visible.
+func (j *JournalList) VisibleItems(maxHeight int) []JournalRow {
+	top := j.Selected
+	if top > 0 && top < len(j.Items) && j.Items[top-1].Entry == nil {
+		top--
+	}
+	j.ScrollOffset = min(j.ScrollOffset, top)
+	return j.ListView.VisibleItems(maxHeight)
+}
+
+// showJournalList opens the archive of the journal directory.
+func (a *App) showJournalList() {
+	dir := expandHome(a.config.JournalDir)
+	entries, err := ScanJournal(dir)
+	if len(entries) == 0 {
+		if err != nil && !os.IsNotExist(err) {
+			a.statusBar.SetError("Journal: " + err.Error())
+		} else {
+			a.statusBar.SetMessage("No journal entries in " + a.config.JournalDir)
+		}
+		return
+	}
+	a.journal.Show(entries)
+}
+
+func (a *App) handleJournalKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.journal.Hide()
+	case terminal.KeyUp:
+		a.journal.MoveUp()
+	case terminal.KeyDown:
+		a.journal.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.journal.MoveUp()
+		case 'j':
+			a.journal.MoveDown()
+		case 'w':
+			a.journal.SetGrouping(GroupByWeek)
+		case 'm':
+			a.journal.SetGrouping(GroupByMonth)
+		}
+	case terminal.KeyEnter:
+		if entry := a.journal.SelectedEntry(); entry != nil {
+			a.currentBuffer = a.openBuffer(entry.Path)
+		}
+		a.journal.Hide()
+	}
+}
+
+// journalFileVars returns the values of the variables a journal_file
+// pattern can use on the given day.
+func journalFileVars(day time.Time) map[string]string {
+	return map[string]string{
+		"date":  day.Format("2006-01-02"),
+		"year":  day.Format("2006"),
+		"month": day.Format("01"),
+		"day":   day.Format("02"),
+	}
+}
+
+// validJournalFile checks a journal_file pattern: a relative path using
+// only the date variables.
+func validJournalFile(pattern string) error {
+	if pattern == "" || filepath.IsAbs(pattern) || strings.HasPrefix(pattern, "~") {
+		return fmt.Errorf("want a path inside journal_dir, got %q", pattern)
+	}
+	vars := journalFileVars(time.Time{})
+	for _, name := range templateVars(pattern) {
+		if _, ok := vars[name]; !ok {
+			return fmt.Errorf("unknown variable {{%s}}; use {{date}}, {{year}}, {{month}} or {{day}}", name)
+		}
+	}
+	return nil
+}
+
+// journalPath returns the path of the note for day.
+func journalPath(cfg Config, day time.Time) string {
+	return filepath.Join(expandHome(cfg.JournalDir), expandTemplate(cfg.JournalFile, journalFileVars(day)))
+}
+
+// openToday opens today's journal note, creating the directories it goes
+// in. A new note is filled from the journal template, if there is one.
+func (a *App) openToday() bool {
+	path := journalPath(a.config, time.Now())
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		a.statusBar.SetError("Journal: " + err.Error())
+		return false
+	}
+	a.pushJump()
+	a.currentBuffer = a.openBuffer(path)
+	eb := a.currentBuf()
+	if eb.buf.LineCount() > 1 || eb.buf.Lines[0] != "" {
+		return true
+	}
+	if _, err := loadTemplate(a.config.JournalTmpl); err == nil && !eb.readOnly {
+		a.startTemplate(a.config.JournalTmpl)
+	} else {
+		a.statusBar.SetMessage("New journal entry " + filepath.Base(path))
+	}
+	return true
+}
+
+// SetToday opens today's journal note on starting, as prose --today does.
+func (a *App) SetToday(on bool) {
+	a.today = on
+}
@@ -0,0 +1,226 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// writeJournal creates journal files under dir, keyed by relative path.
+func writeJournal(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScanJournal(t *testing.T) {
+	dir := t.TempDir()
+	writeJournal(t, dir, map[string]string{
+		"2024-05-31.md":              "one two three\n",
+		"2024/06/2024-06-03-trip.md": "a b\n",
+		"2024-05-27.txt":             "x\n",
+		"notes.md":                   "undated\n",
+		"2024-05-30.png":             "binary",
+		".archive/2020-01-01.md":     "hidden\n",
+	})
+	entries, err := ScanJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, filepath.Base(e.Path)+":"+e.Date.Format("2006-01-02"))
+	}
+	want := "2024-06-03-trip.md:2024-06-03,2024-05-31.md:2024-05-31,2024-05-27.txt:2024-05-27"
+	if strings.Join(got, ",") != want {
+		t.Errorf("ScanJournal = %v, want %s", got, want)
+	}
+	if entries[1].Words != 3 {
+		t.Errorf("words = %d, want 3", entries[1].Words)
+	}
+}
+
+func TestScanJournalMissingDir(t *testing.T) {
+	entries, err := ScanJournal(filepath.Join(t.TempDir(), "nope"))
+	if len(entries) != 0 || !os.IsNotExist(err) {
+		t.Errorf("ScanJournal = %v, %v", entries, err)
+	}
+}
+
+func journalDate(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestGroupJournal(t *testing.T) {
+	entries := []JournalEntry{
+		{Path: "c", Date: journalDate("2024-06-03"), Words: 10},
+		{Path: "b", Date: journalDate("2024-05-31"), Words: 20},
+		{Path: "a", Date: journalDate("2024-05-27"), Words: 5},
+	}
+
+	var got []string
+	for _, row := range GroupJournal(entries, GroupByMonth) {
+		if row.Entry != nil {
+			got = append(got, row.Entry.Path)
+		} else {
+			got = append(got, row.Heading)
+		}
+	}
+	want := []string{"June 2024  1 entry, 10 words", "c", "May 2024  2 entries, 25 words", "b", "a"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("by month = %q, want %q", got, want)
+	}
+
+	got = nil
+	for _, row := range GroupJournal(entries, GroupByWeek) {
+		if row.Entry == nil {
+			got = append(got, row.Heading)
+		}
+	}
+	want = []string{"Week 23, 2024 (from 3 Jun)  1 entry, 10 words", "Week 22, 2024 (from 27 May)  2 entries, 25 words"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("by week = %q, want %q", got, want)
+	}
+}
+
+func TestJournalEntryLabel(t *testing.T) {
+	e := JournalEntry{Path: "/j/2024-05-31-holiday.md", Date: journalDate("2024-05-31"), Words: 420}
+	if got := journalEntryLabel(e); got != "Fri 31 May  420 words  holiday" {
+		t.Errorf("label = %q", got)
+	}
+	e.Path = "/j/2024-05-31.md"
+	if got := journalEntryLabel(e); got != "Fri 31 May  420 words" {
+		t.Errorf("label = %q", got)
+	}
+}
+
+func TestJournalListNavigation(t *testing.T) {
+	entries := []JournalEntry{
+		{Path: "c", Date: journalDate("2024-06-03")},
+		{Path: "b", Date: journalDate("2024-05-31")},
+		{Path: "a", Date: journalDate("2024-05-27")},
+	}
+	var j JournalList
+	j.Show(entries)
+	if e := j.SelectedEntry(); e == nil || e.Path != "c" {
+		t.Fatalf("first entry should be selected, got %+v", e)
+	}
+	j.MoveDown()
+	if e := j.SelectedEntry(); e == nil || e.Path != "b" {
+		t.Errorf("MoveDown should skip the heading, got %+v", e)
+	}
+	j.MoveDown()
+	j.MoveDown()
+	if e := j.SelectedEntry(); e.Path != "a" {
+		t.Errorf("MoveDown past the end, got %+v", e)
+	}
+
+	// Regrouping keeps the selection on the same entry.
+	j.SetGrouping(GroupByWeek)
+	if e := j.SelectedEntry(); e.Path != "a" {
+		t.Errorf("after regrouping, got %+v", e)
+	}
+	j.MoveUp()
+	if e := j.SelectedEntry(); e.Path != "b" {
+		t.Errorf("MoveUp, got %+v", e)
+	}
+}
+
+func TestCommandJournalList(t *testing.T) {
+	dir := t.TempDir()
+	writeJournal(t, dir, map[string]string{"2024-05-31.md": "hello\n"})
+
+	a := newTestApp("draft.md")
+	a.config.JournalDir = filepath.Join(dir, "missing")
+	a.executeCommand("journal list")
+	if a.journal.Active || !strings.Contains(a.statusBar.StatusMessage, "No journal entries") {
+		t.Errorf("missing dir: active %v, message %q", a.journal.Active, a.statusBar.StatusMessage)
+	}
+
+	a.config.JournalDir = dir
+	a.executeCommand("journal list")
+	if !a.journal.Active {
+		t.Fatal(":journal list should open the archive")
+	}
+	a.handleJournalKey(terminal.Key{Type: terminal.KeyEnter})
+	if a.journal.Active {
+		t.Error("Enter should close the archive")
+	}
+	if got := a.currentBuf().buf.Lines[0]; got != "hello" {
+		t.Errorf("opened entry = %q", got)
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	t.Setenv("HOME", "/home/writer")
+	tests := map[string]string{
+		"~/journal": "/home/writer/journal",
+		"~":         "/home/writer",
+		"/abs/path": "/abs/path",
+		"~other":    "~other",
+	}
+	for in, want := range tests {
+		if got := expandHome(in); got != want {
+			t.Errorf("expandHome(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestJournalPath(t *testing.T) {
+	day := time.Date(2024, 5, 31, 9, 0, 0, 0, time.UTC)
+	cfg := DefaultConfig()
+	cfg.JournalDir = "/notes"
+	if got := journalPath(cfg, day); got != "/notes/2024-05-31.md" {
+		t.Errorf("journalPath = %q", got)
+	}
+	cfg.JournalFile = "{{year}}/{{month}}/{{day}}.txt"
+	if got := journalPath(cfg, day); got != "/notes/2024/05/31.txt" {
+		t.Errorf("journalPath = %q", got)
+	}
+
+	for _, bad := range []string{"", "/abs/{{date}}.md", "{{title}}.md"} {
+		if validJournalFile(bad) == nil {
+			t.Errorf("journal_file %q should be refused", bad)
+		}
+	}
+}
+
+func TestCommandToday(t *testing.T) {
+	writeTemplate(t, "journal.md", "# {{date}}\n")
+	dir := t.TempDir()
+	a := newTestApp("draft.md")
+	a.config = DefaultConfig()
+	a.config.JournalDir = dir
+	a.config.JournalFile = "{{year}}/{{date}}.md"
+	today := time.Now().Format("2006-01-02")
+	path := filepath.Join(dir, today[:4], today+".md")
+
+	a.executeCommand("today")
+	eb := a.currentBuf()
+	if eb.buf.Filename != path || eb.buf.Lines[0] != "# "+today {
+		t.Errorf("opened %s with %q, want %s from the template", eb.buf.Filename, eb.buf.Lines, path)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		t.Errorf("the year's directory should be made: %v", err)
+	}
+
+	eb.buf.Lines = []string{"Rain all day."}
+	eb.Save(path)
+	a.currentBuffer = 0
+	a.executeCommand("today")
+	if eb := a.currentBuf(); eb.buf.Lines[0] != "Rain all day." || len(a.buffers) != 2 {
+		t.Errorf("today's note should be switched to as it is, got %q in %d buffers", eb.buf.Lines, len(a.buffers))
+	}
+}
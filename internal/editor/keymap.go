@@ -0,0 +1,305 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// Action is something a key can be bound to in Default mode.
+type Action struct {
+	Desc   string
+	Edits  bool // Refused in read-only buffers
+	Motion bool // Only moves the cursor or scrolls, so the text needn't be redrawn
+	Run    func(a *App)
+}
+
+// actions are the bindable actions, by the name used in "map" lines.
+// Those that wait for a second key, such as delete for dd, keep waiting for
+// the same second key however they are bound.
+var actions = map[string]Action{
+	"leader":          {Desc: "Wait for a leader key", Run: (*App).startLeader},
+	"insert":          {Desc: "Edit mode", Edits: true, Run: func(a *App) { a.mode = ModeEdit }},
+	"append":          {Desc: "Edit mode at the end of the line", Edits: true, Run: (*App).appendAtLineEnd},
+	"open-below":      {Desc: "Open a line below", Edits: true, Run: (*App).openLineBelow},
+	"open-above":      {Desc: "Open a line above", Edits: true, Run: (*App).openLineAbove},
+	"command":         {Desc: "Command prompt", Run: func(a *App) { a.statusBar.StartPrompt(PromptCommand) }},
+	"search":          {Desc: "Search prompt", Run: func(a *App) { a.statusBar.StartPrompt(PromptSearch) }},
+	"next-match":      {Desc: "Next search match", Run: (*App).nextMatchIfSearching},
+	"prev-match":      {Desc: "Previous search match", Run: (*App).prevMatchIfSearching},
+	"left":            {Desc: "Move left", Motion: true, Run: func(a *App) { a.moveCursor(terminal.KeyLeft) }},
+	"down":            {Desc: "Move down", Motion: true, Run: func(a *App) { a.moveCursor(terminal.KeyDown) }},
+	"up":              {Desc: "Move up", Motion: true, Run: func(a *App) { a.moveCursor(terminal.KeyUp) }},
+	"right":           {Desc: "Move right", Motion: true, Run: func(a *App) { a.moveCursor(terminal.KeyRight) }},
+	"line-start":      {Desc: "Start of the line", Motion: true, Run: func(a *App) { a.currentBuf().cursorCol = 0 }},
+	"first-non-blank": {Desc: "First non-blank character of the line", Motion: true, Run: (*App).jumpToFirstNonBlank},
+	"line-end":        {Desc: "End of the line", Motion: true, Run: (*App).jumpToLineEnd},
+	"next-word":       {Desc: "Next word", Motion: true, Run: (*App).jumpToNextWord},
+	"prev-word":       {Desc: "Previous word", Motion: true, Run: (*App).jumpToPrevWord},
+	"next-paragraph":  {Desc: "Next paragraph", Motion: true, Run: (*App).jumpToNextParagraph},
+	"prev-paragraph":  {Desc: "Previous paragraph", Motion: true, Run: (*App).jumpToPrevParagraph},
+	"next-sentence":   {Desc: "Next sentence", Motion: true, Run: (*App).jumpToNextSentence},
+	"prev-sentence":   {Desc: "Previous sentence", Motion: true, Run: (*App).jumpToPrevSentence},
+	"go":              {Desc: "gg, gx: top of the document or follow a link", Run: func(a *App) { a.gPending = true }},
+	"bottom":          {Desc: "Bottom of the document", Run: (*App).jumpToBottom},
+	"forward":         {Desc: "]s, ]]: next scene or heading", Run: func(a *App) { a.bracketPending = ']' }},
+	"backward":        {Desc: "[s, [[: previous scene or heading", Run: func(a *App) { a.bracketPending = '[' }},
+	"half-page-down":  {Desc: "Scroll down half a page", Motion: true, Run: func(a *App) { a.scrollDown(a.visibleLines() / 2) }},
+	"half-page-up":    {Desc: "Scroll up half a page", Motion: true, Run: func(a *App) { a.scrollUp(a.visibleLines() / 2) }},
+	"page-down":       {Desc: "Scroll down a page", Motion: true, Run: func(a *App) { a.scrollDown(a.visibleLines()) }},
+	"page-up":         {Desc: "Scroll up a page", Motion: true, Run: func(a *App) { a.scrollUp(a.visibleLines()) }},
+	"jump-back":       {Desc: "Back through the jump list", Run: (*App).jumpBack},
+	"jump-forward":    {Desc: "Forward through the jump list", Run: (*App).jumpForward},
+	"next-spelling":   {Desc: "Next spelling error", Run: (*App).jumpToNextSpellError},
+	"prev-spelling":   {Desc: "Previous spelling error", Run: (*App).jumpToPrevSpellError},
+	"delete":          {Desc: "dd: delete the line", Edits: true, Run: func(a *App) { a.dPending = true }},
+	"change":          {Desc: "cc, cw: change the line or word", Edits: true, Run: func(a *App) { a.cPending = true }},
+	"replace-char":    {Desc: "Replace the character with the next one typed", Edits: true, Run: func(a *App) { a.rPending = true }},
+	"replace-mode":    {Desc: "Replace mode", Edits: true, Run: (*App).startReplace},
+	"yank":            {Desc: "yy: yank the line", Run: func(a *App) { a.yPending = true }},
+	"paste-below":     {Desc: "Paste below", Edits: true, Run: (*App).pasteBelow},
+	"paste-above":     {Desc: "Paste above", Edits: true, Run: (*App).pasteAbove},
+	"undo":            {Desc: "Undo", Run: (*App).undoAction},
+	"redo":            {Desc: "Redo", Run: (*App).redoAction},
+	"suspend":         {Desc: "Suspend to the shell", Run: (*App).suspend},
+	"send":            {Desc: "ss: send the line to the scratch buffer", Run: func(a *App) { a.sPending = true }},
+	"scratch":         {Desc: "Scratch buffer", Run: (*App).jumpToScratch},
+	"fold":            {Desc: "z: fold commands", Run: func(a *App) { a.zPending = true }},
+	"set-mark":        {Desc: "Set a mark", Run: func(a *App) { a.markPending = 'm' }},
+	"jump-to-mark":    {Desc: "Jump to a mark", Run: func(a *App) { a.markPending = '`' }},
+	"visual":          {Desc: "Visual mode", Run: (*App).startVisual},
+	"line-select":     {Desc: "Line Select mode", Run: (*App).startLineSelect},
+	"buffers":         {Desc: "Buffer picker", Run: func(a *App) { a.picker.Show(a.currentBuffer) }},
+	"other-window":    {Desc: "Focus the other window", Run: (*App).switchWindow},
+	"outline":         {Desc: "Outline", Run: (*App).showOutline},
+	"browser":         {Desc: "File browser", Run: (*App).showBrowser},
+	"column-width":    {Desc: "Adjust the column width", Run: (*App).showColumnAdjust},
+	"grep":            {Desc: "Search files", Run: (*App).startGrepPrompt},
+	"recent":          {Desc: "Recent files", Run: (*App).showRecent},
+	"palette":         {Desc: "Command palette", Run: (*App).showPalette},
+	"follow-link":     {Desc: "Follow the link under the cursor", Run: (*App).followLink},
+	"backlinks":       {Desc: "Notes linking to this one", Run: (*App).showBacklinks},
+	"help":            {Desc: "Help", Run: (*App).showHelp},
+}
+
+// unbound is the action name that takes a default binding away.
+const unbound = "none"
+
+// Keymap binds keys to actions by name, in Default mode and after the
+// leader key. Keys are named as in "map" lines, e.g. "j", "Space" or
+// "Ctrl-D".
+type Keymap struct {
+	Default map[string]string
+	Leader  map[string]string
+
+	// The actions bound, for describing them. The help reads them here
+	// rather than from actions, as it is one of them.
+	actions map[string]Action
+}
+
+// DefaultKeymap returns the built-in bindings.
+func DefaultKeymap() *Keymap {
+	return &Keymap{
+		actions: actions,
+		Default: map[string]string{
+			"Space": "leader", "i": "insert", "A": "append", "o": "open-below", "O": "open-above",
+			":": "command", "/": "search", "n": "next-match", "N": "prev-match",
+			"h": "left", "j": "down", "k": "up", "l": "right",
+			"Left": "left", "Down": "down", "Up": "up", "Right": "right",
+			"Home": "line-start", "^": "first-non-blank", "$": "line-end", "End": "line-end",
+			"w": "next-word", "b": "prev-word", "}": "next-paragraph", "{": "prev-paragraph",
+			")": "next-sentence", "(": "prev-sentence", "g": "go", "G": "bottom", "]": "forward", "[": "backward",
+			"Ctrl-D": "half-page-down", "Ctrl-U": "half-page-up", "PgDn": "page-down", "PgUp": "page-up",
+			"Ctrl-O": "jump-back", "Tab": "jump-forward", "x": "next-spelling", "X": "prev-spelling",
+			"d": "delete", "c": "change", "r": "replace-char", "R": "replace-mode", "y": "yank",
+			"p": "paste-below", "P": "paste-above", "u": "undo", "Ctrl-Z": "suspend", "Ctrl-Y": "redo", "Ctrl-R": "redo",
+			"s": "send", "S": "scratch", "z": "fold", "m": "set-mark", "`": "jump-to-mark",
+			"v": "visual", "V": "line-select", "Ctrl-P": "palette", "Enter": "follow-link",
+		},
+		Leader: map[string]string{
+			"b": "buffers", "t": "buffers", "w": "other-window", "h": "outline", "H": "outline",
+			"o": "browser", "O": "browser", "-": "column-width", "g": "grep", "G": "grep",
+			"r": "recent", "R": "recent", "l": "backlinks", ":": "palette", "?": "help",
+		},
+	}
+}
+
+// Bind binds a key sequence, one key or the leader key and one more, to an
+// action. The action "none" removes the binding.
+func (k *Keymap) Bind(keys, action string) error {
+	if _, ok := actions[action]; !ok && action != unbound {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	fields := strings.Fields(keys)
+	for i, f := range fields {
+		if !validKeyName(f) {
+			return fmt.Errorf("unknown key %q", f)
+		}
+		fields[i] = canonicalKeyName(f)
+	}
+	table := k.Default
+	switch {
+	case len(fields) == 2 && fields[0] == "Space":
+		table = k.Leader
+		fields = fields[1:]
+	case len(fields) != 1:
+		return fmt.Errorf("want a key, or Space and a key, got %q", keys)
+	}
+	if action == unbound {
+		delete(table, fields[0])
+	} else {
+		table[fields[0]] = action
+	}
+	return nil
+}
+
+// namedKeys are the keys with names longer than one character, less
+// modifiers.
+var namedKeys = map[string]bool{
+	"Space": true, "Enter": true, "Tab": true, "Backspace": true, "Delete": true,
+	"Up": true, "Down": true, "Left": true, "Right": true,
+	"Home": true, "End": true, "PgUp": true, "PgDn": true,
+}
+
+// validKeyName reports whether name is a key a binding can be made for.
+func validKeyName(name string) bool {
+	_, base := splitModifiers(name)
+	return utf8.RuneCountInString(base) == 1 || namedKeys[base]
+}
+
+// splitModifiers splits a key name into its modifier prefixes, such as
+// "Ctrl-", and the key.
+func splitModifiers(name string) (mods, base string) {
+	base = name
+	for _, mod := range []string{"Ctrl-", "Alt-", "Shift-"} {
+		base = strings.TrimPrefix(base, mod)
+	}
+	return name[:len(name)-len(base)], base
+}
+
+// canonicalKeyName writes a letter held with Ctrl or Alt in capitals, as
+// keyName does, so "Ctrl-n" and "Ctrl-N" are the same key.
+func canonicalKeyName(name string) string {
+	mods, base := splitModifiers(name)
+	if utf8.RuneCountInString(base) == 1 && (strings.Contains(mods, "Ctrl-") || strings.Contains(mods, "Alt-")) {
+		return mods + strings.ToUpper(base)
+	}
+	return name
+}
+
+// bindingName names a key as Keymap does.
+func bindingName(key terminal.Key) string {
+	if key.Type == terminal.KeyRune && key.Rune == ' ' && key.Mod == 0 {
+		return "Space"
+	}
+	return keyName(key)
+}
+
+// boundAction returns the name of the action bound to key in table. A
+// modified key with no binding of its own acts as the key alone, so
+// Ctrl-Left moves left.
+func boundAction(table map[string]string, key terminal.Key) string {
+	name, ok := table[bindingName(key)]
+	if !ok && key.Mod != 0 {
+		key.Mod = 0
+		name = table[bindingName(key)]
+	}
+	return name
+}
+
+// runBinding runs the action bound to key in table, reporting whether
+// there was one.
+func (a *App) runBinding(table map[string]string, key terminal.Key) bool {
+	return a.runAction(boundAction(table, key))
+}
+
+// runAction runs the named action, reporting whether there is one.
+func (a *App) runAction(name string) bool {
+	action, ok := actions[name]
+	if !ok {
+		return false
+	}
+	if action.Edits && a.guardReadOnly() {
+		return true
+	}
+	action.Run(a)
+	return true
+}
+
+// appendAtLineEnd enters Edit mode at the end of the line.
+func (a *App) appendAtLineEnd() {
+	eb := a.currentBuf()
+	eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+	a.mode = ModeEdit
+}
+
+// openLineBelow opens a new line below the cursor in Edit mode.
+func (a *App) openLineBelow() {
+	eb := a.currentBuf()
+	eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+	a.insertNewline()
+	a.mode = ModeEdit
+}
+
+// openLineAbove opens a new line above the cursor in Edit mode.
+func (a *App) openLineAbove() {
+	eb := a.currentBuf()
+	eb.buf.InsertLine(eb.cursorLine, "")
+	eb.undo.PushInsertWholeLine(eb.cursorLine)
+	eb.cursorCol = 0
+	a.mode = ModeEdit
+}
+
+// nextMatchIfSearching jumps to the next search match, if there is a
+// search.
+func (a *App) nextMatchIfSearching() {
+	if a.currentBuf().searchActive {
+		a.jumpToNextMatch()
+	}
+}
+
+// prevMatchIfSearching jumps to the previous search match, if there is a
+// search.
+func (a *App) prevMatchIfSearching() {
+	if a.currentBuf().searchActive {
+		a.jumpToPrevMatch()
+	}
+}
+
+// jumpToFirstNonBlank moves to the first character of the line that isn't
+// a space or tab.
+func (a *App) jumpToFirstNonBlank() {
+	eb := a.currentBuf()
+	for i, r := range []rune(eb.buf.Lines[eb.cursorLine]) {
+		if r != ' ' && r != '\t' {
+			eb.cursorCol = i
+			return
+		}
+	}
+	eb.cursorCol = 0
+}
+
+// jumpToLineEnd moves past the last character of the line.
+func (a *App) jumpToLineEnd() {
+	eb := a.currentBuf()
+	eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+}
+
+// startLineSelect enters Line Select mode on the cursor's line.
+func (a *App) startLineSelect() {
+	a.mode = ModeLineSelect
+	a.lineSelectAnchor = a.currentBuf().cursorLine
+}
+
+// startGrepPrompt opens the command prompt with "grep " typed.
+func (a *App) startGrepPrompt() {
+	a.statusBar.StartPrompt(PromptCommand)
+	a.statusBar.PromptText = "grep "
+}
+
+// visibleLines returns how many buffer lines fit in the viewport.
+func (a *App) visibleLines() int {
+	return a.viewport.VisibleLines(a.currentBuf().scrollOffset)
+}
@@ -0,0 +1,178 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestKeymapBind(t *testing.T) {
+	tests := []struct {
+		keys, action string
+		table        string // "default" or "leader"
+		key          string // Where the binding is stored
+		err          string
+	}{
+		{keys: ";", action: "command", table: "default", key: ";"},
+		{keys: "Space f", action: "outline", table: "leader", key: "f"},
+		{keys: "Ctrl-n", action: "next-spelling", table: "default", key: "Ctrl-N"},
+		{keys: "Alt-Shift-j", action: "down", table: "default", key: "Alt-Shift-J"},
+		{keys: "PgDn", action: "half-page-down", table: "default", key: "PgDn"},
+		{keys: "j", action: "fly", err: `unknown action "fly"`},
+		{keys: "Hyper", action: "down", err: `unknown key "Hyper"`},
+		{keys: "g g", action: "bottom", err: "want a key, or Space and a key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keys, func(t *testing.T) {
+			k := DefaultKeymap()
+			err := k.Bind(tt.keys, tt.action)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Bind(%q, %q) = %v, want %q", tt.keys, tt.action, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			table := k.Default
+			if tt.table == "leader" {
+				table = k.Leader
+			}
+			if table[tt.key] != tt.action {
+				t.Errorf("%s[%q] = %q, want %q", tt.table, tt.key, table[tt.key], tt.action)
+			}
+		})
+	}
+
+	k := DefaultKeymap()
+	if err := k.Bind("x", "none"); err != nil || k.Default["x"] != "" {
+		t.Errorf("none should remove the binding, got %q, %v", k.Default["x"], err)
+	}
+}
+
+func TestDefaultKeymapActionsExist(t *testing.T) {
+	k := DefaultKeymap()
+	for _, table := range []map[string]string{k.Default, k.Leader} {
+		for key, action := range table {
+			if _, ok := actions[action]; !ok {
+				t.Errorf("%q is bound to unknown action %q", key, action)
+			}
+			if !validKeyName(key) {
+				t.Errorf("default binding for invalid key name %q", key)
+			}
+		}
+	}
+}
+
+func TestRemappedKeys(t *testing.T) {
+	a := newTestApp("test.txt")
+	a.currentBuf().buf.Lines = []string{"one", "two", "three"}
+	for _, b := range [][2]string{{";", "command"}, {"j", "left"}, {"Down", "down"}, {"Space f", "buffers"}, {"Space b", "none"}} {
+		if err := a.keymap.Bind(b[0], b[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sendKeys(a, ";")
+	if a.statusBar.Prompt != PromptCommand {
+		t.Fatal("; should open the command prompt")
+	}
+	sendKey(a, terminal.KeyEscape)
+
+	eb := a.currentBuf()
+	eb.cursorCol = 2
+	sendKeys(a, "j")
+	if eb.cursorLine != 0 || eb.cursorCol != 1 {
+		t.Errorf("j should move left, at %d:%d", eb.cursorLine, eb.cursorCol)
+	}
+	sendKey(a, terminal.KeyDown)
+	if eb.cursorLine != 1 {
+		t.Errorf("Down should still move down, at line %d", eb.cursorLine)
+	}
+
+	sendKeys(a, " b")
+	if a.picker.Active {
+		t.Error("an unbound leader key should do nothing")
+	}
+	sendKeys(a, " f")
+	if !a.picker.Active {
+		t.Error("Space f should open the buffer picker")
+	}
+}
+
+func TestBoundEditsRespectReadOnly(t *testing.T) {
+	a := newTestApp("test.txt")
+	a.currentBuf().readOnly = true
+	if err := a.keymap.Bind("e", "insert"); err != nil {
+		t.Fatal(err)
+	}
+	sendKeys(a, "e")
+	if a.mode != ModeDefault || !strings.Contains(a.statusBar.StatusMessage, "Read-only") {
+		t.Errorf("insert in a read-only buffer should be refused, got mode %v, %q", a.mode, a.statusBar.StatusMessage)
+	}
+}
+
+func TestParseConfigMap(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader("map ; = command\nmap Space F = browser\ncolumn_width = 70\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Keys == nil || cfg.Keys.Default[";"] != "command" || cfg.Keys.Leader["F"] != "browser" || cfg.Keys.Default["j"] != "down" {
+		t.Errorf("map lines should bind over the defaults, got %+v", cfg.Keys)
+	}
+	if cfg.ColumnWidth != 70 {
+		t.Errorf("column_width = %d after map lines", cfg.ColumnWidth)
+	}
+
+	_, err = parseConfig(strings.NewReader("map q = quit\n"))
+	if err == nil || !strings.Contains(err.Error(), `line 1: map: unknown action "quit"`) {
+		t.Errorf("unknown action should be reported, got %v", err)
+	}
+}
+
+func TestCursorOnlyFollowsBindings(t *testing.T) {
+	a := newTestApp("test.txt")
+	j := terminal.Key{Type: terminal.KeyRune, Rune: 'j'}
+	if !a.cursorOnly(j) {
+		t.Error("j should only move the cursor")
+	}
+	if err := a.keymap.Bind("j", "command"); err != nil {
+		t.Fatal(err)
+	}
+	if a.cursorOnly(j) {
+		t.Error("j bound to command should redraw more than the cursor")
+	}
+}
+
+func TestCtrlZDoesNotUndo(t *testing.T) {
+	a := newTestApp("test.txt")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"one", "two"}
+	sendKeys(a, "dd")
+	sendKey(a, terminal.KeyCtrlZ)
+	if len(eb.buf.Lines) != 1 {
+		t.Errorf("Ctrl-Z should suspend rather than undo, got %q", eb.buf.Lines)
+	}
+	if a.keymap.Default["Ctrl-Z"] != "suspend" {
+		t.Errorf("Ctrl-Z is bound to %q", a.keymap.Default["Ctrl-Z"])
+	}
+}
+
+func TestCtrlUnderscoreUndoesInEditMode(t *testing.T) {
+	for _, key := range []terminal.Key{
+		{Type: terminal.KeyCombo, Rune: '_', Mod: terminal.ModCtrl},
+		{Type: terminal.KeyCombo, Rune: '/', Mod: terminal.ModCtrl},
+		{Type: terminal.KeyCombo, Rune: '-', Mod: terminal.ModCtrl | terminal.ModShift},
+	} {
+		a := newTestApp("test.txt")
+		eb := a.currentBuf()
+		eb.buf.Lines = []string{"one"}
+		sendKeys(a, "Atwo")
+		a.handleInput(terminal.InputEvent{Type: terminal.EventKey, Key: key})
+		if eb.buf.Lines[0] != "one" || a.mode != ModeEdit {
+			t.Errorf("%+v should undo the typing and stay in Edit mode, got %q", key, eb.buf.Lines[0])
+		}
+	}
+}
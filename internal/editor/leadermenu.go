@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// leaderMenuDelay is how long the leader key waits for a second key before
+// listing the choices. Someone who knows the combination has typed it by
+// then, and sees nothing.
+const leaderMenuDelay = 500 * time.Millisecond
+
+// LeaderItem is one action in the leader menu and the keys bound to it.
+type LeaderItem struct {
+	Keys   []string
+	Action string
+}
+
+// Label returns the overlay text for an item, e.g. "b t  Buffer picker".
+func (item LeaderItem) Label(keyWidth int) string {
+	keys := strings.Join(item.Keys, " ")
+	return keys + strings.Repeat(" ", keyWidth-utf8.RuneCountInString(keys)+2) + actions[item.Action].Desc
+}
+
+// LeaderMenu lists the leader key's combinations while it waits for one.
+type LeaderMenu struct {
+	Active bool
+	Items  []LeaderItem
+}
+
+// Show activates the menu with the bindings in table.
+func (m *LeaderMenu) Show(table map[string]string) {
+	m.Active = true
+	m.Items = bindingItems(table, actions)
+}
+
+// bindingItems groups the bindings in table to actions in known by action,
+// one item per action in order of their first key.
+func bindingItems(table map[string]string, known map[string]Action) []LeaderItem {
+	byAction := make(map[string]*LeaderItem)
+	var items []*LeaderItem
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := strings.ToLower(keys[i]), strings.ToLower(keys[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return keys[i] > keys[j] // Lower case first
+	})
+	for _, key := range keys {
+		action := table[key]
+		if _, ok := known[action]; !ok {
+			continue
+		}
+		item, ok := byAction[action]
+		if !ok {
+			item = &LeaderItem{Action: action}
+			byAction[action] = item
+			items = append(items, item)
+		}
+		item.Keys = append(item.Keys, key)
+	}
+	var result []LeaderItem
+	for _, item := range items {
+		result = append(result, *item)
+	}
+	return result
+}
+
+// Hide deactivates the menu.
+func (m *LeaderMenu) Hide() {
+	m.Active = false
+	m.Items = nil
+}
+
+// KeyWidth returns the width of the widest item's keys.
+func (m *LeaderMenu) KeyWidth() int {
+	width := 0
+	for _, item := range m.Items {
+		width = max(width, utf8.RuneCountInString(strings.Join(item.Keys, " ")))
+	}
+	return width
+}
+
+// startLeader waits for a leader key, noting when so that the menu can be
+// shown if none comes.
+func (a *App) startLeader() {
+	a.leaderPending = true
+	a.leaderAt = time.Now()
+}
+
+// leaderMenuDue reports whether the leader key has waited long enough at
+// now for its menu to be shown.
+func (a *App) leaderMenuDue(now time.Time) bool {
+	return a.leaderPending && !a.leaderMenu.Active && a.mode == ModeDefault && !now.Before(a.leaderAt.Add(leaderMenuDelay))
+}
+
+// runLeaderItem runs item n of the leader menu, as typing its key would.
+func (a *App) runLeaderItem(n int) {
+	if n < 0 || n >= len(a.leaderMenu.Items) {
+		return
+	}
+	action := a.leaderMenu.Items[n].Action
+	a.leaderPending = false
+	a.leaderMenu.Hide()
+	a.runAction(action)
+}
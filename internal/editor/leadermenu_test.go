@@ -0,0 +1,72 @@
+package editor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestLeaderMenuShow(t *testing.T) {
+	var m LeaderMenu
+	m.Show(map[string]string{"b": "buffers", "t": "buffers", "H": "outline", "h": "outline", "-": "column-width", "q": "unknown"})
+	want := []string{
+		"-    Adjust the column width",
+		"b t  Buffer picker",
+		"h H  Outline",
+	}
+	if len(m.Items) != len(want) {
+		t.Fatalf("items = %+v, want %d", m.Items, len(want))
+	}
+	for i, item := range m.Items {
+		if got := item.Label(m.KeyWidth()); got != want[i] {
+			t.Errorf("item %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestLeaderMenuAfterDelay(t *testing.T) {
+	a := newTestApp("test.md")
+	sendKeys(a, " ")
+	if a.leaderMenuDue(a.leaderAt.Add(leaderMenuDelay / 2)) {
+		t.Error("the menu should wait for the delay")
+	}
+	if !a.leaderMenuDue(a.leaderAt.Add(leaderMenuDelay)) {
+		t.Fatal("the menu should be due after the delay")
+	}
+	if d := a.wait(a.leaderAt); d <= 0 || d > leaderMenuDelay {
+		t.Errorf("wait = %v, want the loop woken for the menu", d)
+	}
+
+	a.leaderMenu.Show(a.keymap.Leader)
+	sendKeys(a, "b")
+	if a.leaderMenu.Active || a.leaderPending || !a.picker.Active {
+		t.Errorf("a key should close the menu and run, got menu %v, picker %v", a.leaderMenu.Active, a.picker.Active)
+	}
+}
+
+func TestLeaderMenuEscape(t *testing.T) {
+	a := newTestApp("test.md")
+	sendKeys(a, " ")
+	a.leaderMenu.Show(a.keymap.Leader)
+	sendKey(a, terminal.KeyEscape)
+	if a.leaderMenu.Active || a.leaderPending {
+		t.Error("Esc should close the menu")
+	}
+	if a.leaderMenuDue(time.Now().Add(time.Hour)) {
+		t.Error("the menu should not come back after Esc")
+	}
+}
+
+func TestMouseClickLeaderMenu(t *testing.T) {
+	a := newSplitTestApp(t)
+	sendKeys(a, " ")
+	a.leaderMenu.Show(a.keymap.Leader)
+	a.renderer.RenderLeaderMenu(a.leaderMenu, a.screenViewport())
+
+	box := a.renderer.overlay
+	click(a, box.itemTop+3, box.left+4) // b t  Buffer picker
+	if a.leaderMenu.Active || !a.picker.Active {
+		t.Errorf("clicking an item should run it, got menu %v, picker %v", a.leaderMenu.Active, a.picker.Active)
+	}
+}
@@ -0,0 +1,247 @@
+package editor
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// [text](target "title"), or an image ![alt](target). The target may be
+	// wrapped in angle brackets to hold spaces.
+	reInlineLink = regexp.MustCompile(`!?\[[^\]]*\]\(\s*(<[^>]*>|[^\s()]+)(?:\s+"[^"]*")?\s*\)`)
+	reAngleLink  = regexp.MustCompile(`<((?:https?://|mailto:)[^>\s]+)>`)
+	reBareURL    = regexp.MustCompile(`https?://[^\s<>()\[\]]+`)
+)
+
+// noteLink is a link on a line. Start and End are rune offsets of the whole
+// link, so the cursor anywhere on it follows it.
+type noteLink struct {
+	Start, End int
+	Target     string
+	Wiki       bool // A [[Note Name]] link, whose target is a note's name
+}
+
+// findLinks returns the links on a line in order: [[wiki links]], inline
+// Markdown links, <autolinks> and bare http(s) URLs.
+func findLinks(line string) []noteLink {
+	var links []noteLink
+	taken := func(start, end int) bool {
+		for _, l := range links {
+			if start < l.End && end > l.Start {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(start, end int, target string, wiki bool) {
+		s := utf8.RuneCountInString(line[:start])
+		e := s + utf8.RuneCountInString(line[start:end])
+		if target != "" && !taken(s, e) {
+			links = append(links, noteLink{Start: s, End: e, Target: target, Wiki: wiki})
+		}
+	}
+	for _, m := range reWikiLink.FindAllStringSubmatchIndex(line, -1) {
+		add(m[0], m[1], strings.TrimSpace(line[m[2]:m[3]]), true)
+	}
+	for _, m := range reInlineLink.FindAllStringSubmatchIndex(line, -1) {
+		add(m[0], m[1], strings.Trim(line[m[2]:m[3]], "<>"), false)
+	}
+	for _, m := range reAngleLink.FindAllStringSubmatchIndex(line, -1) {
+		add(m[0], m[1], line[m[2]:m[3]], false)
+	}
+	for _, m := range reBareURL.FindAllStringIndex(line, -1) {
+		// Sentence punctuation after a URL isn't part of it.
+		end := m[0] + len(strings.TrimRight(line[m[0]:m[1]], ".,;:!?'\""))
+		add(m[0], end, line[m[0]:end], false)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Start < links[j].Start })
+	return links
+}
+
+// linkAt returns the link under rune column col of line.
+func linkAt(line string, col int) (noteLink, bool) {
+	for _, l := range findLinks(line) {
+		if col >= l.Start && col < l.End {
+			return l, true
+		}
+	}
+	return noteLink{}, false
+}
+
+// isURL reports whether a link target is for the OS to open rather than a
+// local file.
+func isURL(target string) bool {
+	return strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:")
+}
+
+// resolveLink turns a local link target into the file it names, relative
+// to dir, and the #fragment after it. A target without an extension finds
+// the Markdown file of that name, as notes often link to "chapter-two" for
+// chapter-two.md. An empty path is the linking file itself.
+func resolveLink(dir, target string) (path, fragment string) {
+	path, fragment, _ = strings.Cut(target, "#")
+	if path == "" {
+		return "", fragment
+	}
+	if p, err := url.PathUnescape(path); err == nil {
+		path = p
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if filepath.Ext(path) == "" {
+		if _, err := os.Stat(path); err != nil {
+			if _, err := os.Stat(path + ".md"); err == nil {
+				path += ".md"
+			}
+		}
+	}
+	return path, fragment
+}
+
+// openURL hands a URL or a file prose doesn't edit to the OS to open.
+// Tests replace it.
+var openURL = func(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// followLink opens the link under the cursor: URLs and files other than
+// text with the OS opener, and notes in a buffer, at the heading a
+// #fragment names. A wiki link to a note that doesn't exist yet creates
+// it. Enter and gx follow links.
+func (a *App) followLink() {
+	eb := a.currentBuf()
+	link, ok := linkAt(eb.buf.Lines[eb.cursorLine], eb.cursorCol)
+	if !ok {
+		a.statusBar.SetMessage("No link under the cursor")
+		return
+	}
+	if link.Wiki {
+		a.followWikiLink(link.Target)
+		return
+	}
+	target := link.Target
+	if isURL(target) {
+		if err := openURL(target); err != nil {
+			a.statusBar.SetError("Can't open link: " + err.Error())
+			return
+		}
+		a.statusBar.SetMessage("Opened " + target)
+		return
+	}
+
+	dir := "."
+	if eb.buf.Filename != "" && !eb.isScratch {
+		dir = filepath.Dir(absPath(eb.buf.Filename))
+	}
+	path, fragment := resolveLink(dir, target)
+	if path != "" && !isGrepFile(path) {
+		if err := openURL(path); err != nil {
+			a.statusBar.SetError("Can't open link: " + err.Error())
+		}
+		return
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			a.statusBar.SetMessage("No such file: " + target)
+			return
+		}
+	}
+	a.openNoteAt(path, fragment)
+}
+
+// openNoteAt opens the note at path, or stays in the current buffer if
+// path is empty, and moves to the heading whose id is fragment, if any.
+func (a *App) openNoteAt(path, fragment string) {
+	a.pushJump()
+	eb := a.currentBuf()
+	if path != "" {
+		a.currentBuffer = a.openBuffer(path)
+		eb = a.currentBuf()
+		eb.cursorLine, eb.cursorCol = 0, 0
+	}
+	if fragment != "" {
+		for _, h := range ExtractHeadings(eb.buf) {
+			if headingID(h.Text) == headingID(fragment) {
+				eb.cursorLine, eb.cursorCol = h.BufferLine, 0
+				break
+			}
+		}
+	}
+	a.centreCursorIfHidden()
+}
+
+// Backlinks indexes the links between the Markdown and text files under
+// dir, returning the lines that link to each file, by absolute path. A
+// file's links to itself aren't counted, and wiki links find their note by
+// name among the Markdown files under dir. Files are found and read as
+// :grep finds them, with open holding the lines of files being edited.
+func Backlinks(dir string, open map[string][]string) map[string][]GrepResult {
+	index := make(map[string][]GrepResult)
+	notes := notePaths(dir)
+	walkTextFiles(dir, open, func(path, rel string, lines []string) bool {
+		from := filepath.Dir(path)
+		for i, line := range lines {
+			for _, l := range findLinks(line) {
+				var to string
+				switch {
+				case l.Wiki:
+					name, _, _ := strings.Cut(l.Target, "#")
+					to = resolveWikiLink(dir, notes, name)
+				case !isURL(l.Target):
+					to, _ = resolveLink(from, l.Target)
+				}
+				if to == "" || to == path {
+					continue
+				}
+				if n := len(index[to]); n > 0 && index[to][n-1].Path == rel && index[to][n-1].Line == i {
+					continue // Another link on the same line
+				}
+				index[to] = append(index[to], GrepResult{Path: rel, Line: i, Col: l.Start, Text: line})
+			}
+		}
+		return true
+	})
+	return index
+}
+
+// showBacklinks lists the lines in the current directory's notes that
+// link to the current file, opening one as a :grep result is opened.
+func (a *App) showBacklinks() {
+	eb := a.currentBuf()
+	if eb.buf.Filename == "" || eb.isScratch {
+		a.statusBar.SetMessage("Backlinks need a file")
+		return
+	}
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		a.statusBar.SetError("backlinks: " + err.Error())
+		return
+	}
+	links := Backlinks(dir, a.openFileLines())[absPath(eb.buf.Filename)]
+	if len(links) == 0 {
+		a.statusBar.SetMessage("No backlinks")
+		return
+	}
+	a.grepList.ShowTitled("Backlinks: "+filepath.Base(eb.buf.Filename), "Space-l", links)
+}
@@ -0,0 +1,169 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestFindLinks(t *testing.T) {
+	tests := []struct {
+		line string
+		want []noteLink
+	}{
+		{"No links here.", nil},
+		{"See [the map](map.md) first.", []noteLink{{Start: 4, End: 21, Target: "map.md"}}},
+		{`[Title](<my notes.md> "Notes")`, []noteLink{{Start: 0, End: 30, Target: "my notes.md"}}},
+		{"![Harbour](harbour.jpg)", []noteLink{{Start: 0, End: 23, Target: "harbour.jpg"}}},
+		{"Mail <mailto:ed@example.com>.", []noteLink{{Start: 5, End: 28, Target: "mailto:ed@example.com"}}},
+		{"Read https://example.com/a.", []noteLink{{Start: 5, End: 26, Target: "https://example.com/a"}}},
+		{"[Site](https://example.com) and é [b](b.md)", []noteLink{
+			{Start: 0, End: 27, Target: "https://example.com"},
+			{Start: 34, End: 43, Target: "b.md"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := findLinks(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findLinks(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLinkAt(t *testing.T) {
+	line := "See [the map](map.md) first."
+	for col, want := range map[int]bool{3: false, 4: true, 12: true, 20: true, 21: false} {
+		if _, ok := linkAt(line, col); ok != want {
+			t.Errorf("linkAt(%d) = %v, want %v", col, ok, want)
+		}
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "chapter-two.md"), []byte("Two"), 0644)
+
+	tests := []struct {
+		target, path, fragment string
+	}{
+		{"chapter-two", filepath.Join(dir, "chapter-two.md"), ""},
+		{"chapter-two.md#the-storm", filepath.Join(dir, "chapter-two.md"), "the-storm"},
+		{"my%20notes.md", filepath.Join(dir, "my notes.md"), ""},
+		{"../up.md", filepath.Join(filepath.Dir(dir), "up.md"), ""},
+		{"#intro", "", "intro"},
+	}
+	for _, tt := range tests {
+		path, fragment := resolveLink(dir, tt.target)
+		if path != tt.path || fragment != tt.fragment {
+			t.Errorf("resolveLink(%q) = %q, %q, want %q, %q", tt.target, path, fragment, tt.path, tt.fragment)
+		}
+	}
+}
+
+func writeLinkTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.md":       "# Index\n\n[One](ch1.md) and [one again](ch1.md#the-harbour)\n[Two](notes/ch2)",
+		"ch1.md":         "# Chapter one\n\n## The harbour\n\nSee [the index](index.md) and [here](#the-harbour).",
+		"notes/ch2.md":   "Back to [chapter one](../ch1.md).",
+		".drafts/old.md": "[One](../ch1.md)",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBacklinks(t *testing.T) {
+	dir := writeLinkTree(t)
+	index := Backlinks(dir, nil)
+
+	want := []GrepResult{
+		{Path: "index.md", Line: 2, Col: 0, Text: "[One](ch1.md) and [one again](ch1.md#the-harbour)"},
+		{Path: filepath.Join("notes", "ch2.md"), Line: 0, Col: 8, Text: "Back to [chapter one](../ch1.md)."},
+	}
+	if got := index[filepath.Join(dir, "ch1.md")]; !reflect.DeepEqual(got, want) {
+		t.Errorf("backlinks of ch1.md = %+v, want %+v", got, want)
+	}
+	if got := index[filepath.Join(dir, "notes", "ch2.md")]; len(got) != 1 || got[0].Path != "index.md" {
+		t.Errorf("an extensionless link should count for ch2.md, got %+v", got)
+	}
+}
+
+func TestFollowLink(t *testing.T) {
+	t.Chdir(writeLinkTree(t))
+	a := newTestApp("index.md")
+	a.viewport = NewViewport(80, 24)
+	eb := a.currentBuf()
+	eb.buf.Load()
+
+	eb.cursorLine, eb.cursorCol = 0, 0
+	sendKey(a, terminal.KeyEnter)
+	if a.statusBar.StatusMessage != "No link under the cursor" {
+		t.Errorf("Enter off a link: message %q", a.statusBar.StatusMessage)
+	}
+
+	eb.cursorLine, eb.cursorCol = 2, 25
+	sendKeys(a, "gx")
+	eb = a.currentBuf()
+	if filepath.Base(eb.Filename()) != "ch1.md" || eb.cursorLine != 2 {
+		t.Errorf("gx should open ch1.md at its harbour heading, got %s at line %d", eb.Filename(), eb.cursorLine)
+	}
+	sendKey(a, terminal.KeyCtrlO)
+	if eb = a.currentBuf(); filepath.Base(eb.Filename()) != "index.md" {
+		t.Errorf("Ctrl-O should return to index.md, got %s", eb.Filename())
+	}
+}
+
+func TestFollowLinkOpensURLs(t *testing.T) {
+	var opened []string
+	defer func(open func(string) error) { openURL = open }(openURL)
+	openURL = func(target string) error {
+		opened = append(opened, target)
+		return nil
+	}
+
+	a := newTestApp("")
+	a.currentBuf().buf.Lines = []string{"Read https://example.com and ![map](map.png)."}
+	a.currentBuf().cursorCol = 5
+	sendKey(a, terminal.KeyEnter)
+	a.currentBuf().cursorCol = 30
+	sendKey(a, terminal.KeyEnter)
+	if len(opened) != 2 || opened[0] != "https://example.com" || filepath.Base(opened[1]) != "map.png" {
+		t.Errorf("URLs and images should go to the OS opener, got %q", opened)
+	}
+}
+
+func TestShowBacklinks(t *testing.T) {
+	t.Chdir(writeLinkTree(t))
+	a := newTestApp("ch1.md")
+	a.viewport = NewViewport(80, 24)
+	a.currentBuf().buf.Load()
+
+	sendKeys(a, " l")
+	if !a.grepList.Active || a.grepList.Title != "Backlinks: ch1.md" || len(a.grepList.Items) != 2 {
+		t.Fatalf("Space-l should list 2 backlinks, got %q %+v", a.grepList.Title, a.grepList.Items)
+	}
+	sendKeys(a, "j")
+	sendKey(a, terminal.KeyEnter)
+	if eb := a.currentBuf(); filepath.Base(eb.Filename()) != "ch2.md" {
+		t.Errorf("Enter should open the linking note, got %s", eb.Filename())
+	}
+
+	a = newTestApp("ch1.md")
+	a.currentBuf().buf.Lines = []string{"Nothing links to me."}
+	a.currentBuf().buf.Filename = "orphan.md"
+	sendKeys(a, " l")
+	if a.grepList.Active || a.statusBar.StatusMessage != "No backlinks" {
+		t.Errorf("no backlinks: active %v, message %q", a.grepList.Active, a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,115 @@
+package editor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ListIndent is the indentation added or removed by Tab/Shift-Tab on list items.
+const ListIndent = "  "
+
+var (
+	// Bullet, ordered or task list item: indent, marker, spacing, optional checkbox.
+	reListItem = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])(\s+)(\[[ xX]\]\s+)?`)
+	// Blockquote prefix, possibly nested ("> > ").
+	reQuotePrefix = regexp.MustCompile(`^\s*(?:>\s?)+`)
+)
+
+// ListPrefix returns the list or blockquote marker at the start of line
+// (including indentation and trailing space), or "" if there is none.
+func ListPrefix(line string) string {
+	if m := reListItem.FindString(line); m != "" {
+		return m
+	}
+	return reQuotePrefix.FindString(line)
+}
+
+// IsListItem reports whether the line starts with a bullet or ordered list marker.
+func IsListItem(line string) bool {
+	return reListItem.MatchString(line)
+}
+
+// NextListPrefix returns the marker to start the line following one that
+// begins with prefix: ordered numbers are incremented and checked task boxes
+// are reset to unchecked.
+func NextListPrefix(prefix string) string {
+	m := reListItem.FindStringSubmatch(prefix)
+	if m == nil {
+		// Blockquote: ensure the continuation has a space after the last '>'.
+		if strings.HasSuffix(prefix, ">") {
+			return prefix + " "
+		}
+		return prefix
+	}
+	indent, marker, spacing, task := m[1], m[2], m[3], m[4]
+
+	if n, err := strconv.Atoi(marker[:len(marker)-1]); err == nil {
+		marker = strconv.Itoa(n+1) + marker[len(marker)-1:]
+	}
+	if task != "" {
+		task = "[ ]" + task[3:]
+	}
+	return indent + marker + spacing + task
+}
+
+// insertNewlineContinuingList handles Enter in a markdown buffer. On a list
+// or quote line the marker is carried onto the new line; on a line holding
+// only a marker, the marker is removed instead, ending the list.
+func (a *App) insertNewlineContinuingList() {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	prefix := ListPrefix(line)
+	prefixLen := len([]rune(prefix))
+
+	if prefix == "" || eb.cursorCol < prefixLen {
+		a.insertNewline()
+		return
+	}
+
+	// Empty item: pressing Enter again leaves the list.
+	if strings.TrimSpace(line[len(prefix):]) == "" {
+		a.replaceLines(eb.cursorLine, 1, []string{""})
+		eb.cursorCol = 0
+		return
+	}
+
+	a.insertNewline()
+	next := NextListPrefix(prefix)
+	eb.undo.PushInsertText(eb.cursorLine, 0, next, eb.cursorLine, 0)
+	eb.cursorLine, eb.cursorCol = eb.buf.InsertText(eb.cursorLine, 0, next)
+}
+
+// indentListItem indents (delta > 0) or outdents (delta < 0) the list item
+// under the cursor by ListIndent. Returns false if the line is not a list item.
+func (a *App) indentListItem(delta int) bool {
+	eb := a.currentBuf()
+	line := eb.buf.Lines[eb.cursorLine]
+	if !IsListItem(line) {
+		return false
+	}
+
+	var newLine string
+	shift := 0
+	if delta > 0 {
+		newLine = ListIndent + line
+		shift = len(ListIndent)
+	} else {
+		trimmed := strings.TrimPrefix(line, ListIndent)
+		if trimmed == line {
+			trimmed = strings.TrimLeft(line[:min(len(line), len(ListIndent))], " \t") + line[min(len(line), len(ListIndent)):]
+		}
+		newLine = trimmed
+		shift = len(newLine) - len(line)
+	}
+	if newLine == line {
+		return true
+	}
+
+	a.replaceLines(eb.cursorLine, 1, []string{newLine})
+	eb.cursorCol += shift
+	if eb.cursorCol < 0 {
+		eb.cursorCol = 0
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestListPrefix(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"- item", "- "},
+		{"  * nested", "  * "},
+		{"12. twelfth", "12. "},
+		{"- [x] done", "- [x] "},
+		{"> quoted", "> "},
+		{"> > deeper", "> > "},
+		{"plain text", ""},
+		{"-not a list", ""},
+	}
+	for _, tt := range tests {
+		if got := ListPrefix(tt.line); got != tt.want {
+			t.Errorf("ListPrefix(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestNextListPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"- ", "- "},
+		{"9. ", "10. "},
+		{"  3) ", "  4) "},
+		{"- [x] ", "- [ ] "},
+		{">", "> "},
+	}
+	for _, tt := range tests {
+		if got := NextListPrefix(tt.prefix); got != tt.want {
+			t.Errorf("NextListPrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestEnterContinuesList(t *testing.T) {
+	a := newTestApp("notes.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"1. first"}
+	eb.cursorCol = eb.buf.LineLen(0)
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyEnter})
+
+	if len(eb.buf.Lines) != 2 || eb.buf.Lines[1] != "2. " {
+		t.Fatalf("expected continued ordered marker, got %q", eb.buf.Lines)
+	}
+	if eb.cursorLine != 1 || eb.cursorCol != 3 {
+		t.Errorf("cursor should follow the marker, got (%d,%d)", eb.cursorLine, eb.cursorCol)
+	}
+}
+
+func TestUndoContinuedListMarker(t *testing.T) {
+	a := newTestApp("notes.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"10. tenth"}
+	eb.cursorCol = eb.buf.LineLen(0)
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyEnter})
+	a.handleEditKey(terminal.Key{Type: terminal.KeyRune, Rune: 'x'})
+
+	// The typing, the marker and the newline are undone a step each.
+	eb.undo.Undo(eb.buf)
+	if len(eb.buf.Lines) != 2 || eb.buf.Lines[1] != "11. " {
+		t.Errorf("undo should remove the typing alone, got %q", eb.buf.Lines)
+	}
+	eb.undo.Undo(eb.buf)
+	if len(eb.buf.Lines) != 2 || eb.buf.Lines[1] != "" {
+		t.Errorf("undo should remove the whole marker, got %q", eb.buf.Lines)
+	}
+	eb.undo.Undo(eb.buf)
+	if len(eb.buf.Lines) != 1 || eb.buf.Lines[0] != "10. tenth" {
+		t.Errorf("a second undo should remove the line, got %q", eb.buf.Lines)
+	}
+}
+
+func TestEnterOnEmptyItemEndsList(t *testing.T) {
+	a := newTestApp("notes.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"- first", "- "}
+	eb.cursorLine, eb.cursorCol = 1, 2
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyEnter})
+
+	if len(eb.buf.Lines) != 2 || eb.buf.Lines[1] != "" {
+		t.Errorf("empty item should be cleared, got %q", eb.buf.Lines)
+	}
+	if eb.cursorCol != 0 {
+		t.Errorf("cursor col = %d, want 0", eb.cursorCol)
+	}
+}
+
+func TestEnterContinuesQuote(t *testing.T) {
+	a := newTestApp("notes.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"> said so"}
+	eb.cursorCol = eb.buf.LineLen(0)
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyEnter})
+
+	if eb.buf.Lines[1] != "> " {
+		t.Errorf("expected quote continuation, got %q", eb.buf.Lines[1])
+	}
+}
+
+func TestEnterPlainTextFileDoesNotContinue(t *testing.T) {
+	a := newTestApp("notes.txt")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"- item"}
+	eb.cursorCol = eb.buf.LineLen(0)
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyEnter})
+
+	if eb.buf.Lines[1] != "" {
+		t.Errorf("plain text should not continue lists, got %q", eb.buf.Lines[1])
+	}
+}
+
+func TestTabIndentsListItem(t *testing.T) {
+	a := newTestApp("notes.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"- item"}
+	eb.cursorCol = 3
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyTab})
+	if eb.buf.Lines[0] != "  - item" || eb.cursorCol != 5 {
+		t.Errorf("after tab: %q col %d", eb.buf.Lines[0], eb.cursorCol)
+	}
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyShiftTab})
+	if eb.buf.Lines[0] != "- item" || eb.cursorCol != 3 {
+		t.Errorf("after shift-tab: %q col %d", eb.buf.Lines[0], eb.cursorCol)
+	}
+
+	// Outdenting at the left margin is a no-op.
+	a.handleEditKey(terminal.Key{Type: terminal.KeyShiftTab})
+	if eb.buf.Lines[0] != "- item" {
+		t.Errorf("outdent at margin changed line: %q", eb.buf.Lines[0])
+	}
+}
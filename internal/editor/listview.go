@@ -0,0 +1,59 @@
+package editor
+
+// ListView is the state of an overlay listing items, one of them selected,
+// scrolled to keep the selection in view. The list overlays embed it,
+// adding what is their own.
+type ListView[T any] struct {
+	Active       bool
+	Items        []T
+	Selected     int
+	ScrollOffset int
+}
+
+// Show activates the overlay with the given items, the first selected.
+func (l *ListView[T]) Show(items []T) {
+	l.Active = true
+	l.Items = items
+	l.Selected = 0
+	l.ScrollOffset = 0
+}
+
+// Hide deactivates the overlay.
+func (l *ListView[T]) Hide() {
+	l.Active = false
+	l.Items = nil
+	l.Selected = 0
+	l.ScrollOffset = 0
+}
+
+// MoveUp moves the selection up.
+func (l *ListView[T]) MoveUp() {
+	if l.Selected > 0 {
+		l.Selected--
+	}
+}
+
+// MoveDown moves the selection down.
+func (l *ListView[T]) MoveDown() {
+	if l.Selected < len(l.Items)-1 {
+		l.Selected++
+	}
+}
+
+// VisibleItems returns the items that fit in maxHeight rows, scrolled to
+// keep the selection visible.
+func (l *ListView[T]) VisibleItems(maxHeight int) []T {
+	if len(l.Items) == 0 {
+		return nil
+	}
+	l.Selected = min(l.Selected, len(l.Items)-1)
+	if l.Selected < l.ScrollOffset {
+		l.ScrollOffset = l.Selected
+	}
+	if l.Selected >= l.ScrollOffset+maxHeight {
+		l.ScrollOffset = l.Selected - maxHeight + 1
+	}
+	l.ScrollOffset = max(0, min(l.ScrollOffset, len(l.Items)-maxHeight))
+	end := min(l.ScrollOffset+maxHeight, len(l.Items))
+	return l.Items[l.ScrollOffset:end]
+}
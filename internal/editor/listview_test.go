@@ -0,0 +1,49 @@
+package editor
+
+import "testing"
+
+func TestListViewMove(t *testing.T) {
+	var l ListView[string]
+	l.Show([]string{"a", "b", "c"})
+	if !l.Active || l.Selected != 0 {
+		t.Fatalf("Show: active %v, selected %d", l.Active, l.Selected)
+	}
+	l.MoveUp()
+	if l.Selected != 0 {
+		t.Errorf("MoveUp at the top moved to %d", l.Selected)
+	}
+	l.MoveDown()
+	l.MoveDown()
+	l.MoveDown()
+	if l.Selected != 2 {
+		t.Errorf("MoveDown should stop at the bottom, at %d", l.Selected)
+	}
+	l.Hide()
+	if l.Active || l.Items != nil || l.Selected != 0 || l.ScrollOffset != 0 {
+		t.Errorf("Hide should reset the list, got %+v", l)
+	}
+}
+
+func TestListViewVisibleItems(t *testing.T) {
+	var l ListView[int]
+	if l.VisibleItems(3) != nil {
+		t.Error("an empty list shows nothing")
+	}
+	l.Show([]int{0, 1, 2, 3, 4, 5})
+
+	l.Selected = 4
+	if got := l.VisibleItems(3); len(got) != 3 || got[0] != 2 || l.ScrollOffset != 2 {
+		t.Errorf("scrolling down to 4 shows %v from %d", got, l.ScrollOffset)
+	}
+	l.Selected = 1
+	if got := l.VisibleItems(3); got[0] != 1 {
+		t.Errorf("scrolling up to 1 shows %v", got)
+	}
+
+	// A selection past the end, as after the items shrink, is pulled back.
+	l.Items = l.Items[:2]
+	l.Selected = 5
+	if got := l.VisibleItems(3); len(got) != 2 || l.Selected != 1 || l.ScrollOffset != 0 {
+		t.Errorf("after shrinking: %v, selected %d, offset %d", got, l.Selected, l.ScrollOffset)
+	}
+}
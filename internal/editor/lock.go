@@ -0,0 +1,143 @@
+package editor
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// lockDir returns the directory holding advisory lock files.
+func lockDir() string {
+	return filepath.Join(stateDir(), "locks")
+}
+
+// pathKey returns a file name-safe key for a document, derived from its
+// absolute path so that different relative names for the same file match.
+func pathKey(filename string) string {
+	sum := sha1.Sum([]byte(absPath(filename)))
+	return hex.EncodeToString(sum[:])
+}
+
+// lockPath returns the lock file for a document.
+func lockPath(filename string) string {
+	return filepath.Join(lockDir(), pathKey(filename)+".lock")
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// readLockOwner returns the pid recorded in a lock file, or 0 if the file is
+// missing or malformed.
+func readLockOwner(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// acquireLock takes the advisory lock for filename. If another live prose
+// process holds it, the lock is left alone and that process's pid is
+// returned. Stale locks (dead owner) are taken over.
+func acquireLock(filename string) (lockFile string, ownerPID int, err error) {
+	path := lockPath(filename)
+	if owner := readLockOwner(path); owner != 0 && owner != os.Getpid() && processAlive(owner) {
+		return "", owner, nil
+	}
+	if err := os.MkdirAll(lockDir(), 0700); err != nil {
+		return "", 0, err
+	}
+	abs, _ := filepath.Abs(filename)
+	content := fmt.Sprintf("%d\n%s\n", os.Getpid(), abs)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", 0, err
+	}
+	return path, 0, nil
+}
+
+// releaseLock removes a lock file if this process still owns it.
+func releaseLock(lockFile string) {
+	if lockFile == "" {
+		return
+	}
+	if readLockOwner(lockFile) == os.Getpid() {
+		os.Remove(lockFile)
+	}
+}
+
+// lockBuffer takes the lock for a buffer's file. If another prose instance
+// has the file open, or the file can't be written, the buffer is made
+// read-only and a warning is shown.
+func (a *App) lockBuffer(eb *EditorBuffer) {
+	if eb.isScratch || eb.buf.Filename == "" {
+		return
+	}
+	a.checkWritable(eb)
+	lockFile, owner, err := acquireLock(eb.buf.Filename)
+	if err != nil {
+		a.errorLog("lock failed", err, "file", eb.buf.Filename)
+		return
+	}
+	if owner != 0 {
+		eb.readOnly = true
+		eb.lockOwner = owner
+		a.statusBar.SetMessage(fmt.Sprintf("%s is open in another prose (pid %d). Opened read-only; :w! to write anyway.",
+			filepath.Base(eb.buf.Filename), owner))
+		return
+	}
+	eb.lockFile = lockFile
+}
+
+// unlockBuffer releases the buffer's lock, if it holds one.
+func (a *App) unlockBuffer(eb *EditorBuffer) {
+	releaseLock(eb.lockFile)
+	eb.lockFile = ""
+}
+
+// relockBuffer moves a buffer's lock after its filename changes.
+func (a *App) relockBuffer(eb *EditorBuffer) {
+	a.unlockBuffer(eb)
+	eb.lockOwner = 0
+	a.lockBuffer(eb)
+}
+
+// releaseAllLocks drops every lock held by this process.
+func (a *App) releaseAllLocks() {
+	for _, eb := range a.buffers {
+		a.unlockBuffer(eb)
+	}
+}
+
+// guardReadOnly reports whether the current buffer is read-only, showing a
+// message if so. Editing actions call it before changing the buffer.
+func (a *App) guardReadOnly() bool {
+	eb := a.currentBuf()
+	if !eb.readOnly {
+		return false
+	}
+	if eb.lockOwner != 0 {
+		a.statusBar.SetMessage(fmt.Sprintf("Read-only: open in another prose (pid %d). :ro to allow edits.", eb.lockOwner))
+	} else if eb.unwritable {
+		a.statusBar.SetMessage("Read-only: " + filepath.Base(eb.buf.Filename) + " isn't writable. :ro to edit anyway, :w newname to save a copy.")
+	} else {
+		a.statusBar.SetMessage("Read-only buffer. :ro to allow edits.")
+	}
+	return true
+}
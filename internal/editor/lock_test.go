@@ -0,0 +1,169 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestAcquireAndReleaseLock(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "doc.md")
+
+	lockFile, owner, err := acquireLock(file)
+	if err != nil || owner != 0 || lockFile == "" {
+		t.Fatalf("acquireLock = (%q, %d, %v)", lockFile, owner, err)
+	}
+	if got := readLockOwner(lockFile); got != os.Getpid() {
+		t.Errorf("lock owner = %d, want %d", got, os.Getpid())
+	}
+
+	// Re-acquiring from the same process is allowed.
+	if _, owner, _ := acquireLock(file); owner != 0 {
+		t.Errorf("own lock reported as held by %d", owner)
+	}
+
+	releaseLock(lockFile)
+	if _, err := os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Error("lock file should be removed on release")
+	}
+}
+
+// writeForeignLock records a live process other than this one as the owner.
+func writeForeignLock(t *testing.T, file string) int {
+	t.Helper()
+	pid := os.Getppid()
+	if err := os.MkdirAll(lockDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lockPath(file), []byte(fmt.Sprintf("%d\n%s\n", pid, file)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return pid
+}
+
+func TestAcquireLockHeldByOtherProcess(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "doc.md")
+	pid := writeForeignLock(t, file)
+
+	lockFile, owner, err := acquireLock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner != pid || lockFile != "" {
+		t.Errorf("acquireLock = (%q, %d), want held by %d", lockFile, owner, pid)
+	}
+
+	// Releasing a lock we don't own must leave it in place.
+	releaseLock(lockPath(file))
+	if readLockOwner(lockPath(file)) != pid {
+		t.Error("foreign lock should not be removed")
+	}
+}
+
+func TestAcquireLockTakesOverStaleLock(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "doc.md")
+	os.MkdirAll(lockDir(), 0700)
+	// Pid 0 is never a live editor; treat as stale.
+	os.WriteFile(lockPath(file), []byte("0\n"), 0600)
+
+	if _, owner, _ := acquireLock(file); owner != 0 {
+		t.Errorf("stale lock should be taken over, got owner %d", owner)
+	}
+}
+
+func TestLockedBufferOpensReadOnly(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "doc.md")
+	os.WriteFile(file, []byte("hello\n"), 0644)
+	writeForeignLock(t, file)
+
+	a := newTestApp("")
+	idx := a.openBuffer(file)
+	a.currentBuffer = idx
+	eb := a.currentBuf()
+
+	if !eb.readOnly {
+		t.Fatal("buffer locked by another prose should be read-only")
+	}
+	if !strings.Contains(a.statusBar.StatusMessage, "another prose") {
+		t.Errorf("expected lock warning, got %q", a.statusBar.StatusMessage)
+	}
+
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: 'i'})
+	if a.mode != ModeDefault {
+		t.Error("read-only buffer should not enter Edit mode")
+	}
+
+	eb.buf.Dirty = true
+	a.executeCommand("w")
+	if !eb.buf.Dirty {
+		t.Error(":w should refuse to write a read-only buffer")
+	}
+	a.executeCommand("w!")
+	if eb.buf.Dirty {
+		t.Error(":w! should write a read-only buffer")
+	}
+}
+
+func TestLockedBufferRefusesUndo(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "doc.md")
+	os.WriteFile(file, []byte("world\n"), 0644)
+	editAndSave(t, file, "hello ")
+	writeForeignLock(t, file)
+
+	a := newTestApp("")
+	a.currentBuffer = a.openBuffer(file)
+	eb := a.currentBuf()
+	if !eb.readOnly || eb.undo.Len() == 0 {
+		t.Fatalf("want a locked buffer with its history restored, got read-only %v", eb.readOnly)
+	}
+	sendKeys(a, "u")
+	if eb.buf.Lines[0] != "hello world" {
+		t.Errorf("u should leave a locked buffer alone, got %q", eb.buf.Lines[0])
+	}
+	if !strings.Contains(a.statusBar.StatusMessage, "another prose") {
+		t.Errorf("expected lock warning, got %q", a.statusBar.StatusMessage)
+	}
+	eb.undo.Undo(eb.buf)
+	sendKey(a, terminal.KeyCtrlR)
+	if eb.buf.Lines[0] != "world" {
+		t.Errorf("Ctrl-R should leave a locked buffer alone, got %q", eb.buf.Lines[0])
+	}
+}
+
+func TestReadOnlyToggle(t *testing.T) {
+	a := newTestApp("doc.md")
+	a.executeCommand("ro")
+	if !a.currentBuf().readOnly {
+		t.Error(":ro should make the buffer read-only")
+	}
+	a.executeCommand("ro")
+	if a.currentBuf().readOnly {
+		t.Error(":ro again should make the buffer editable")
+	}
+}
+
+func TestCloseBufferReleasesLock(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "doc.md")
+
+	a := newTestApp("other.md")
+	a.currentBuffer = a.openBuffer(file)
+	lockFile := a.currentBuf().lockFile
+	if lockFile == "" {
+		t.Fatal("opened buffer should hold a lock")
+	}
+
+	a.executeCommand("q")
+	if _, err := os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Error("closing the buffer should release its lock")
+	}
+}
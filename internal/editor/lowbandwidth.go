@@ -0,0 +1,13 @@
+package editor
+
+// Low-bandwidth mode cuts what is sent per keystroke for slow links such as
+// SSH over a poor connection: only rows that changed are drawn, frames are
+// not bracketed as synchronized output, and the cursor is not hidden and
+// shown around each frame.
+
+// SetLowBandwidth turns low-bandwidth mode on, as the --low-bandwidth flag
+// does. The low_bandwidth setting can also turn it on.
+func (a *App) SetLowBandwidth(on bool) {
+	a.renderer.lowBandwidth = on
+	a.renderer.Invalidate()
+}
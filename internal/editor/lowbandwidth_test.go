@@ -0,0 +1,60 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLowBandwidthFrames(t *testing.T) {
+	r := NewRenderer()
+	r.lowBandwidth = true
+	vp := NewViewport(80, 10)
+	dls := []DisplayLine{{BufferLine: 0, Text: "First line."}, {BufferLine: 1, Text: "Second line."}}
+
+	first := renderTestFrame(r, dls, vp, " draft.md")
+	if !strings.Contains(first, "First line.") || !strings.Contains(first, "draft.md") {
+		t.Fatalf("first frame should draw every row: %q", first)
+	}
+	for _, code := range []string{"\x1b[?25l", "\x1b[?25h", "\x1b[H"} {
+		if strings.Contains(first, code) {
+			t.Errorf("low-bandwidth frames should not send %q", code)
+		}
+	}
+
+	dls[0].Text = "First line, edited."
+	second := renderTestFrame(r, dls, vp, " draft.md")
+	if !strings.Contains(second, "First line, edited.") || strings.Contains(second, "Second line.") {
+		t.Errorf("only the changed row should be drawn: %q", second)
+	}
+	if third := renderTestFrame(r, dls, vp, " draft.md"); third != "\x1b[2;11H" {
+		t.Errorf("an unchanged frame should only place the cursor, got %q", third)
+	}
+}
+
+func TestLowBandwidthRedrawsUnderOverlay(t *testing.T) {
+	r := NewRenderer()
+	r.lowBandwidth = true
+	vp := NewViewport(80, 10)
+	dls := []DisplayLine{
+		{BufferLine: 0, Text: "Line one."},
+		{BufferLine: 1, Text: "Line two."},
+		{BufferLine: 2, Text: "Line three."},
+	}
+	renderTestFrame(r, dls, vp, " draft.md")
+
+	// A one-item overlay covers rows 3 to 5.
+	frame := r.RenderFrame(dls, vp, 0, 0, 0, " draft.md", "DEFAULT ", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, 0)
+	frame += r.RenderOverlay("Title", "key", []OverlayItem{{DisplayText: "item", RawText: "item"}}, 0, vp, OverlayScrollInfo{})
+	r.FlushRows()
+
+	closed := renderTestFrame(r, dls, vp, " draft.md")
+	if strings.Contains(closed, "Line one.") {
+		t.Errorf("rows clear of the overlay should not be redrawn: %q", closed)
+	}
+	if !strings.Contains(closed, "Line two.") || !strings.Contains(closed, "Line three.") {
+		t.Errorf("rows under the closed overlay should be redrawn: %q", closed)
+	}
+	if !strings.Contains(closed, "\x1b[?25h") {
+		t.Error("the cursor the overlay hid should be shown again")
+	}
+}
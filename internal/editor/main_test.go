@@ -0,0 +1,21 @@
+package editor
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain points the XDG base directories at a throwaway location so tests
+// never read or write the developer's real config, data or state.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "prose-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", dir+"/config")
+	os.Setenv("XDG_DATA_HOME", dir+"/data")
+	os.Setenv("XDG_STATE_HOME", dir+"/state")
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
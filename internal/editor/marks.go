@@ -0,0 +1,162 @@
+package editor
+
+import "unicode"
+
+// maxJumps is how many positions the jump list keeps.
+const maxJumps = 100
+
+// Mark is a position saved with m<letter>.
+type Mark struct {
+	Line int
+	Col  int
+}
+
+// jump is a position in the jump list.
+type jump struct {
+	buffer *EditorBuffer
+	line   int
+	col    int
+}
+
+// shiftedLine returns where line l ends up after delta lines were inserted
+// (delta > 0) or deleted (delta < 0) just below line. Deleted lines
+// collapse onto line.
+func shiftedLine(l, line, delta int) int {
+	switch {
+	case l <= line:
+		return l
+	case l+delta > line:
+		return l + delta
+	}
+	return max(line, 0)
+}
+
+// shiftMarks keeps marks on their text after lines were inserted or deleted
+// below line.
+func (eb *EditorBuffer) shiftMarks(line, delta int) {
+	for name, m := range eb.marks {
+		m.Line = shiftedLine(m.Line, line, delta)
+		eb.marks[name] = m
+	}
+}
+
+// shiftJumps does the same for the jump list's positions in eb.
+func (a *App) shiftJumps(eb *EditorBuffer, line, delta int) {
+	for i := range a.jumps {
+		if a.jumps[i].buffer == eb {
+			a.jumps[i].line = shiftedLine(a.jumps[i].line, line, delta)
+		}
+	}
+}
+
+// changedLine returns the last line above an edit that moved the cursor from
+// line before to line after, given the text that was on the cursor line. It
+// is the cursor line itself unless lines were inserted above it (O, P), in
+// which case that text has moved down.
+func changedLine(lines []string, before, after, delta int, text string) int {
+	line := min(before, after)
+	if delta > 0 && before == after && before+delta < len(lines) &&
+		lines[before] != text && lines[before+delta] == text {
+		return before - 1
+	}
+	return line
+}
+
+// setMark saves the cursor position under name (m<letter>).
+func (a *App) setMark(name rune) {
+	if !unicode.IsLetter(name) {
+		a.statusBar.SetMessage("Marks are named with a letter")
+		return
+	}
+	eb := a.currentBuf()
+	if eb.marks == nil {
+		eb.marks = make(map[rune]Mark)
+	}
+	eb.marks[name] = Mark{Line: eb.cursorLine, Col: eb.cursorCol}
+}
+
+// jumpToMark moves the cursor to the mark saved under name (`<letter>).
+func (a *App) jumpToMark(name rune) {
+	eb := a.currentBuf()
+	m, ok := eb.marks[name]
+	if !ok {
+		a.statusBar.SetMessage("Mark " + string(name) + " not set")
+		return
+	}
+	a.pushJump()
+	eb.cursorLine = min(m.Line, eb.buf.LineCount()-1)
+	eb.cursorCol = min(m.Col, eb.buf.LineLen(eb.cursorLine))
+}
+
+// pushJump adds the cursor position to the jump list before a jump. An
+// older entry for the same line is replaced, and going back with Ctrl-O
+// starts again from the newest entry.
+func (a *App) pushJump() {
+	eb := a.currentBuf()
+	a.recordJump(jump{buffer: eb, line: eb.cursorLine, col: eb.cursorCol})
+}
+
+// recordJump adds j to the end of the jump list.
+func (a *App) recordJump(j jump) {
+	kept := a.jumps[:0]
+	for _, old := range a.jumps {
+		if old.buffer != j.buffer || old.line != j.line {
+			kept = append(kept, old)
+		}
+	}
+	a.jumps = append(kept, j)
+	if len(a.jumps) > maxJumps {
+		a.jumps = a.jumps[len(a.jumps)-maxJumps:]
+	}
+	a.jumpIdx = len(a.jumps)
+}
+
+// jumpBack returns to the previous position in the jump list (Ctrl-O). The
+// first step back saves the current position so Ctrl-I can return to it.
+func (a *App) jumpBack() {
+	if a.jumpIdx == len(a.jumps) {
+		a.pushJump()
+		a.jumpIdx = len(a.jumps) - 1
+	}
+	if a.jumpIdx == 0 {
+		a.statusBar.SetMessage("At oldest jump")
+		return
+	}
+	a.jumpIdx--
+	a.goToJump()
+}
+
+// jumpForward undoes a jumpBack (Ctrl-I).
+func (a *App) jumpForward() {
+	if a.jumpIdx+1 >= len(a.jumps) {
+		a.statusBar.SetMessage("At newest jump")
+		return
+	}
+	a.jumpIdx++
+	a.goToJump()
+}
+
+// goToJump moves to the jump list's current entry, switching buffers if
+// needed.
+func (a *App) goToJump() {
+	j := a.jumps[a.jumpIdx]
+	a.walkingJumps = true
+	a.currentBuffer = a.bufferIndex(j.buffer)
+	eb := a.currentBuf()
+	eb.cursorLine = min(j.line, eb.buf.LineCount()-1)
+	eb.cursorCol = min(j.col, eb.buf.LineLen(eb.cursorLine))
+}
+
+// forgetJumps drops a closed buffer's entries from the jump list.
+func (a *App) forgetJumps(eb *EditorBuffer) {
+	kept := a.jumps[:0]
+	for i, j := range a.jumps {
+		if j.buffer != eb {
+			kept = append(kept, j)
+		} else if i < a.jumpIdx {
+			a.jumpIdx--
+		}
+	}
+	a.jumps = kept
+	a.jumpIdx = min(a.jumpIdx, len(a.jumps))
+}
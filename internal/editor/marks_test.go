@@ -0,0 +1,176 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestShiftedLine(t *testing.T) {
+	tests := []struct {
+		l, line, delta, want int
+	}{
+		{2, 3, 1, 2},   // Above an insertion
+		{3, 3, 1, 3},   // On the line the insertion follows
+		{4, 3, 2, 6},   // Below an insertion
+		{5, 3, -1, 4},  // Below a deletion
+		{4, 3, -1, 3},  // On a deleted line
+		{4, 2, -3, 2},  // Inside a larger deletion
+		{0, -1, 1, 1},  // Lines inserted at the top
+		{0, -1, -1, 0}, // First line deleted
+	}
+	for _, tt := range tests {
+		if got := shiftedLine(tt.l, tt.line, tt.delta); got != tt.want {
+			t.Errorf("shiftedLine(%d, %d, %d) = %d, want %d", tt.l, tt.line, tt.delta, got, tt.want)
+		}
+	}
+}
+
+func TestMarkSetAndJump(t *testing.T) {
+	a := newTestAppWithLines("one", "two", "three", "four", "five")
+	eb := a.currentBuf()
+	eb.cursorLine, eb.cursorCol = 2, 3
+
+	sendKeys(a, "ma")
+	eb.cursorLine, eb.cursorCol = 0, 0
+	sendKeys(a, "`a")
+	if eb.cursorLine != 2 || eb.cursorCol != 3 {
+		t.Errorf("`a went to %d:%d, want 2:3", eb.cursorLine, eb.cursorCol)
+	}
+
+	sendKeys(a, "`b")
+	if a.statusBar.StatusMessage != "Mark b not set" {
+		t.Errorf("unset mark: message %q", a.statusBar.StatusMessage)
+	}
+	if eb.cursorLine != 2 {
+		t.Errorf("unset mark should not move the cursor, at line %d", eb.cursorLine)
+	}
+}
+
+func TestMarksArePerBuffer(t *testing.T) {
+	a := newTestAppWithLines("one", "two", "three", "four", "five")
+	other := NewEditorBuffer("other.md")
+	other.buf.Lines = []string{"alpha", "beta"}
+	a.buffers = append(a.buffers, other)
+
+	a.currentBuf().cursorLine = 4
+	sendKeys(a, "ma")
+	a.currentBuffer = 1
+	sendKeys(a, "`a")
+	if a.statusBar.StatusMessage != "Mark a not set" {
+		t.Errorf("marks should not be shared between buffers, got %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestMarksFollowEdits(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor int
+		keys   string
+		want   int
+	}{
+		{"line opened above", 0, "O\x1b", 4},
+		{"line opened below", 0, "o\x1b", 4},
+		{"line opened above the mark", 3, "O\x1b", 4},
+		{"line opened below the mark", 3, "o\x1b", 3},
+		{"line deleted above", 0, "dd", 2},
+		{"line deleted below", 4, "dd", 3},
+		{"marked line deleted", 3, "dd", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAppWithLines("one", "two", "three", "four", "five")
+			eb := a.currentBuf()
+			eb.cursorLine = 3
+			sendKeys(a, "ma")
+			eb.cursorLine = tt.cursor
+			sendKeys(a, tt.keys)
+			if got := eb.marks['a'].Line; got != tt.want {
+				t.Errorf("mark on line %d, want %d (lines %q)", got, tt.want, eb.buf.Lines)
+			}
+		})
+	}
+}
+
+func TestJumpList(t *testing.T) {
+	a := newTestAppWithLines("one", "two", "three", "four", "five")
+	eb := a.currentBuf()
+	eb.cursorLine, eb.cursorCol = 2, 1
+
+	sendKeys(a, "G")
+	sendKeys(a, "gg")
+	if eb.cursorLine != 0 {
+		t.Fatalf("gg went to line %d", eb.cursorLine)
+	}
+
+	sendKey(a, terminal.KeyCtrlO)
+	if eb.cursorLine != 4 {
+		t.Errorf("first Ctrl-O went to line %d, want 4", eb.cursorLine)
+	}
+	sendKey(a, terminal.KeyCtrlO)
+	if eb.cursorLine != 2 || eb.cursorCol != 1 {
+		t.Errorf("second Ctrl-O went to %d:%d, want 2:1", eb.cursorLine, eb.cursorCol)
+	}
+	sendKey(a, terminal.KeyCtrlO)
+	if a.statusBar.StatusMessage != "At oldest jump" || eb.cursorLine != 2 {
+		t.Errorf("Ctrl-O past the start: line %d, message %q", eb.cursorLine, a.statusBar.StatusMessage)
+	}
+
+	sendKey(a, terminal.KeyTab)
+	sendKey(a, terminal.KeyTab)
+	if eb.cursorLine != 0 {
+		t.Errorf("Ctrl-I should return to where Ctrl-O started, at line %d", eb.cursorLine)
+	}
+	sendKey(a, terminal.KeyTab)
+	if a.statusBar.StatusMessage != "At newest jump" {
+		t.Errorf("Ctrl-I past the end: message %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestJumpListAcrossBuffers(t *testing.T) {
+	a := newTestAppWithLines("one", "two", "three", "four", "five")
+	first := a.currentBuf()
+	first.cursorLine = 3
+	second := NewEditorBuffer("other.md")
+	second.buf.Lines = []string{"alpha", "beta"}
+	second.cursorLine = 1
+	a.buffers = append(a.buffers, second)
+
+	// Any buffer switch is recorded, here through the picker.
+	sendKeys(a, " b")
+	sendKeys(a, "j")
+	sendKey(a, terminal.KeyEnter)
+	if a.currentBuf() != second {
+		t.Fatal("picker should have switched buffers")
+	}
+
+	sendKey(a, terminal.KeyCtrlO)
+	if a.currentBuf() != first || first.cursorLine != 3 {
+		t.Errorf("Ctrl-O should return to the first buffer's line 3, at line %d", a.currentBuf().cursorLine)
+	}
+	sendKey(a, terminal.KeyTab)
+	if a.currentBuf() != second || second.cursorLine != 1 {
+		t.Errorf("Ctrl-I should return to the second buffer, at line %d", a.currentBuf().cursorLine)
+	}
+
+	// Closing a buffer drops its jumps.
+	a.closeCurrentBuffer()
+	for _, j := range a.jumps {
+		if j.buffer == second {
+			t.Error("jumps into a closed buffer should be dropped")
+		}
+	}
+}
+
+func TestJumpsFollowEdits(t *testing.T) {
+	a := newTestAppWithLines("one", "two", "three", "four", "five")
+	eb := a.currentBuf()
+	eb.cursorLine = 3
+	sendKeys(a, "gg")
+	sendKeys(a, "O\x1b")
+
+	sendKey(a, terminal.KeyCtrlO)
+	if eb.cursorLine != 4 || eb.buf.Lines[eb.cursorLine] != "four" {
+		t.Errorf("Ctrl-O went to line %d, want the moved line 4", eb.cursorLine)
+	}
+}
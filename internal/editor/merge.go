@@ -0,0 +1,409 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// When the file has changed on disk since it was loaded or last saved, :w
+// opens a merge view instead of overwriting it. The text as prose last saw
+// it is the common base: hunks changed on only one side default to that
+// side, and for each hunk the writer can keep the disk text, the buffer's,
+// or both.
+
+// lineHunk is one difference between two versions of a text: a[A0:A1] was
+// replaced by b[B0:B1].
+type lineHunk struct {
+	A0, A1, B0, B1 int
+}
+
+// maxDiffCells bounds the table diffLines builds. Past it, everything
+// between the common start and end is treated as one hunk.
+const maxDiffCells = 4 << 20
+
+// diffLines returns the hunks that turn a into b, found from their longest
+// common subsequence of lines.
+func diffLines(a, b []string) []lineHunk {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	a, b = a[pre:len(a)-suf], b[pre:len(b)-suf]
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	if n == 0 || m == 0 || n*m > maxDiffCells {
+		return []lineHunk{{pre, pre + n, pre, pre + m}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var hunks []lineHunk
+	i, j := 0, 0
+	for i < n || j < m {
+		if i < n && j < m && a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		h := lineHunk{A0: pre + i, B0: pre + j}
+		for (i < n || j < m) && !(i < n && j < m && a[i] == b[j]) {
+			if j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]) {
+				i++
+			} else {
+				j++
+			}
+		}
+		h.A1, h.B1 = pre+i, pre+j
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// mergeChoice is the text a merge hunk keeps.
+type mergeChoice int
+
+const (
+	mergeBuffer mergeChoice = iota
+	mergeDisk
+	mergeBoth // The buffer's lines, then the disk's
+)
+
+// mergeChunk is a run of the merged text. Outside hunks Base, Disk and
+// Buffer are the same lines.
+type mergeChunk struct {
+	Line   int // First base line, from 0
+	Base   []string
+	Disk   []string
+	Buffer []string
+	Choice mergeChoice
+}
+
+// IsHunk reports whether the chunk needs a choice: the disk and buffer
+// differ in it.
+func (c *mergeChunk) IsHunk() bool {
+	return !slices.Equal(c.Disk, c.Buffer)
+}
+
+// Lines returns the chunk's text as chosen.
+func (c *mergeChunk) Lines() []string {
+	switch c.Choice {
+	case mergeDisk:
+		return c.Disk
+	case mergeBoth:
+		return append(slices.Clone(c.Buffer), c.Disk...)
+	}
+	return c.Buffer
+}
+
+// merge3 splits a three-way merge of the disk and buffer versions of base
+// into chunks. Changes on both sides that overlap form one hunk.
+func merge3(base, disk, buffer []string) []mergeChunk {
+	dh, bh := diffLines(base, disk), diffLines(base, buffer)
+	var chunks []mergeChunk
+	stable := func(lo, hi int) {
+		if lo < hi {
+			lines := base[lo:hi]
+			chunks = append(chunks, mergeChunk{Line: lo, Base: lines, Disk: lines, Buffer: lines})
+		}
+	}
+	// side returns one version's lines for base[lo:hi], given its hunks
+	// there.
+	side := func(lines []string, hunks []lineHunk, lo, hi int) []string {
+		if len(hunks) == 0 {
+			return base[lo:hi]
+		}
+		first, last := hunks[0], hunks[len(hunks)-1]
+		return lines[first.B0-(first.A0-lo) : last.B1+(hi-last.A1)]
+	}
+
+	pos, di, bi := 0, 0, 0
+	for di < len(dh) || bi < len(bh) {
+		lo := len(base)
+		if di < len(dh) {
+			lo = dh[di].A0
+		}
+		if bi < len(bh) {
+			lo = min(lo, bh[bi].A0)
+		}
+		hi, d0, b0 := lo, di, bi
+		// A hunk joins the group if it overlaps it, or if either is an
+		// insertion where the other starts or ends.
+		joins := func(h lineHunk) bool {
+			return h.A0 < hi || h.A0 == hi && (h.A0 == h.A1 || lo == hi)
+		}
+		for {
+			if di < len(dh) && joins(dh[di]) {
+				hi = max(hi, dh[di].A1)
+				di++
+			} else if bi < len(bh) && joins(bh[bi]) {
+				hi = max(hi, bh[bi].A1)
+				bi++
+			} else {
+				break
+			}
+		}
+		stable(pos, lo)
+		c := mergeChunk{
+			Line:   lo,
+			Base:   base[lo:hi],
+			Disk:   side(disk, dh[d0:di], lo, hi),
+			Buffer: side(buffer, bh[b0:bi], lo, hi),
+		}
+		if b0 == bi {
+			c.Choice = mergeDisk
+		}
+		chunks = append(chunks, c)
+		pos = hi
+	}
+	stable(pos, len(base))
+	return chunks
+}
+
+// mergeRowKind is what a row of the merge view shows.
+type mergeRowKind int
+
+const (
+	mergeRowHeading mergeRowKind = iota
+	mergeRowBase
+	mergeRowDisk
+	mergeRowBuffer
+)
+
+// mergeRow is one line of the merge view.
+type mergeRow struct {
+	Text string
+	Kind mergeRowKind
+	Hunk int // Index into MergeView.Hunks
+}
+
+// mergeSideLines is the most lines of each version shown for a hunk.
+const mergeSideLines = 4
+
+// MergeView manages the overlay for merging a buffer with its file after
+// both have changed.
+type MergeView struct {
+	Active       bool
+	Buffer       *EditorBuffer
+	Chunks       []mergeChunk
+	Hunks        []int // Indexes of the chunks that need a choice
+	Selected     int   // Index into Hunks
+	ScrollOffset int   // In rows
+}
+
+// Show activates the view for eb with the given chunks. Returns false,
+// leaving it inactive, if no chunk needs a choice.
+func (m *MergeView) Show(eb *EditorBuffer, chunks []mergeChunk) bool {
+	var hunks []int
+	for i := range chunks {
+		if chunks[i].IsHunk() {
+			hunks = append(hunks, i)
+		}
+	}
+	if len(hunks) == 0 {
+		return false
+	}
+	m.Active = true
+	m.Buffer = eb
+	m.Chunks = chunks
+	m.Hunks = hunks
+	m.Selected = 0
+	m.ScrollOffset = 0
+	return true
+}
+
+// Hide deactivates the view.
+func (m *MergeView) Hide() {
+	m.Active = false
+	m.Buffer = nil
+	m.Chunks = nil
+	m.Hunks = nil
+	m.Selected = 0
+	m.ScrollOffset = 0
+}
+
+// MoveUp selects the previous hunk.
+func (m *MergeView) MoveUp() {
+	if m.Selected > 0 {
+		m.Selected--
+	}
+}
+
+// MoveDown selects the next hunk.
+func (m *MergeView) MoveDown() {
+	if m.Selected < len(m.Hunks)-1 {
+		m.Selected++
+	}
+}
+
+// Choose sets what the selected hunk keeps.
+func (m *MergeView) Choose(choice mergeChoice) {
+	if m.Selected < len(m.Hunks) {
+		m.Chunks[m.Hunks[m.Selected]].Choice = choice
+	}
+}
+
+// Result returns the merged text.
+func (m *MergeView) Result() []string {
+	var lines []string
+	for i := range m.Chunks {
+		lines = append(lines, m.Chunks[i].Lines()...)
+	}
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
+}
+
+// Rows lays out the hunks: a heading saying where each is and what it
+// keeps, then its base, disk and buffer text.
+func (m *MergeView) Rows() []mergeRow {
+	var rows []mergeRow
+	for h, i := range m.Hunks {
+		c := &m.Chunks[i]
+		changed := "changed in both"
+		switch {
+		case slices.Equal(c.Base, c.Buffer):
+			changed = "changed on disk"
+		case slices.Equal(c.Base, c.Disk):
+			changed = "changed in buffer"
+		}
+		keep := map[mergeChoice]string{mergeBuffer: "buffer", mergeDisk: "disk", mergeBoth: "both"}[c.Choice]
+		rows = append(rows, mergeRow{Text: fmt.Sprintf("Line %d, %s: keep %s", c.Line+1, changed, keep), Kind: mergeRowHeading, Hunk: h})
+		for _, s := range []struct {
+			kind  mergeRowKind
+			label string
+			lines []string
+		}{
+			{mergeRowBase, "base   │ ", c.Base},
+			{mergeRowDisk, "disk   │ ", c.Disk},
+			{mergeRowBuffer, "buffer │ ", c.Buffer},
+		} {
+			if len(s.lines) == 0 {
+				rows = append(rows, mergeRow{Text: s.label + "(no lines)", Kind: s.kind, Hunk: h})
+			}
+			for n, line := range s.lines {
+				if n == mergeSideLines {
+					rows = append(rows, mergeRow{Text: fmt.Sprintf("%s… %d more lines", s.label, len(s.lines)-n), Kind: s.kind, Hunk: h})
+					break
+				}
+				rows = append(rows, mergeRow{Text: s.label + line, Kind: s.kind, Hunk: h})
+			}
+		}
+	}
+	return rows
+}
+
+// VisibleRows returns the rows that fit in maxHeight, scrolled to show as
+// much of the selected hunk as fits, and the index of its heading among
+// them.
+func (m *MergeView) VisibleRows(maxHeight int) ([]mergeRow, int) {
+	rows := m.Rows()
+	first, last := -1, 0
+	for i, row := range rows {
+		if row.Hunk == m.Selected {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if last >= m.ScrollOffset+maxHeight {
+		m.ScrollOffset = last - maxHeight + 1
+	}
+	if first < m.ScrollOffset {
+		m.ScrollOffset = first
+	}
+	m.ScrollOffset = max(0, min(m.ScrollOffset, len(rows)-maxHeight))
+	end := min(m.ScrollOffset+maxHeight, len(rows))
+	return rows[m.ScrollOffset:end], first - m.ScrollOffset
+}
+
+// showMerge opens the merge view for eb against its file on disk. Returns
+// false if the file can't be read or the two don't differ.
+func (a *App) showMerge(eb *EditorBuffer) bool {
+	disk, err := eb.buf.ReadDisk()
+	if err != nil {
+		return false
+	}
+	if !a.merge.Show(eb, merge3(eb.buf.disk.lines, disk, eb.buf.Lines)) {
+		return false
+	}
+	a.statusBar.SetMessage("File changed on disk: choose what to keep, then Enter to save")
+	return true
+}
+
+// mergeCommand runs :merge, merging the buffer with its file if another
+// program has changed it.
+func (a *App) mergeCommand() {
+	eb := a.currentBuf()
+	if !eb.buf.ChangedOnDisk() {
+		a.statusBar.SetMessage("File unchanged on disk")
+		return
+	}
+	if !a.showMerge(eb) {
+		a.statusBar.SetMessage("No differences from the file on disk")
+	}
+}
+
+// applyMerge puts the merged text in the buffer, as one undo step, and
+// saves it.
+func (a *App) applyMerge() {
+	eb := a.merge.Buffer
+	merged := a.merge.Result()
+	a.merge.Hide()
+	if !slices.Equal(merged, eb.buf.Lines) {
+		a.replaceLines(0, len(eb.buf.Lines), merged)
+	}
+	eb.cursorLine = min(eb.cursorLine, len(eb.buf.Lines)-1)
+	eb.cursorCol = min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+	a.writeBuffer(eb)
+}
+
+func (a *App) handleMergeKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.merge.Hide()
+		a.statusBar.SetMessage("Merge cancelled; file not saved")
+	case terminal.KeyUp:
+		a.merge.MoveUp()
+	case terminal.KeyDown:
+		a.merge.MoveDown()
+	case terminal.KeyEnter:
+		a.applyMerge()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.merge.MoveUp()
+		case 'j':
+			a.merge.MoveDown()
+		case 'b':
+			a.merge.Choose(mergeBuffer)
+		case 'd':
+			a.merge.Choose(mergeDisk)
+		case 'a':
+			a.merge.Choose(mergeBoth)
+		}
+	}
+}
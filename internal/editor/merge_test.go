@@ -0,0 +1,141 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []lineHunk
+	}{
+		{"same", []string{"a", "b"}, []string{"a", "b"}, nil},
+		{"changed", []string{"a", "b", "c"}, []string{"a", "x", "c"}, []lineHunk{{1, 2, 1, 2}}},
+		{"inserted", []string{"a", "c"}, []string{"a", "b", "c"}, []lineHunk{{1, 1, 1, 2}}},
+		{"deleted", []string{"a", "b", "c"}, []string{"c"}, []lineHunk{{0, 2, 0, 0}}},
+		{"two hunks", []string{"a", "b", "c", "d", "e"}, []string{"x", "b", "c", "e"}, []lineHunk{{0, 1, 0, 1}, {3, 4, 3, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := []string{"one", "two", "three", "four", "five"}
+	disk := []string{"one", "TWO", "three", "four", "five", "six"}
+	buffer := []string{"one", "two", "three", "4", "five"}
+	m := &MergeView{}
+	if !m.Show(nil, merge3(base, disk, buffer)) {
+		t.Fatal("the versions differ, so there should be hunks")
+	}
+	if len(m.Hunks) != 3 {
+		t.Fatalf("got %d hunks, want 3", len(m.Hunks))
+	}
+	want := []string{"one", "TWO", "three", "4", "five", "six"}
+	if got := m.Result(); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes on one side should be kept by default, got %v", got)
+	}
+
+	// Changes on both sides at the same place form one hunk.
+	chunks := merge3([]string{"a", "b"}, []string{"a", "disk"}, []string{"a", "buffer"})
+	m.Show(nil, chunks)
+	if len(m.Hunks) != 1 || m.Result()[1] != "buffer" {
+		t.Fatalf("a conflict should keep the buffer, got %v", m.Result())
+	}
+	m.Choose(mergeBoth)
+	if got := m.Result(); !reflect.DeepEqual(got, []string{"a", "buffer", "disk"}) {
+		t.Errorf("both should keep the buffer's lines then the disk's, got %v", got)
+	}
+
+	if m.Show(nil, merge3(base, disk, disk)) {
+		t.Error("the same change on both sides needs no choice")
+	}
+}
+
+// newMergeTestApp opens a file, edits its buffer and rewrites the file, as
+// another program would.
+func newMergeTestApp(t *testing.T) (*App, string) {
+	t.Helper()
+	a, dir := newTempTestApp(t, "draft.md")
+	path := filepath.Join(dir, "draft.md")
+	if err := os.WriteFile(path, []byte("Title\n\nFirst.\nSecond.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a.currentBuf().buf.Load()
+	a.currentBuf().buf.Lines[3] = "Second, edited."
+	a.currentBuf().buf.Dirty = true
+
+	if err := os.WriteFile(path, []byte("Title\n\nFirst, elsewhere.\nSecond.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	return a, path
+}
+
+func TestMergeOnSave(t *testing.T) {
+	a, path := newMergeTestApp(t)
+	a.executeCommand("w")
+	if !a.merge.Active {
+		t.Fatal(":w should open the merge view when the file changed on disk")
+	}
+
+	a.viewport = NewViewport(80, 24)
+	frame := a.renderer.RenderMerge(a.merge, a.viewport)
+	if !strings.Contains(frame, "Line 3, changed on disk: keep disk") || !strings.Contains(frame, "buffer │ Second, edited.") {
+		t.Errorf("the view should show each hunk's versions: %q", frame)
+	}
+
+	sendKeys(a, "jd")
+	sendKey(a, terminal.KeyEnter)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Title\n\nFirst, elsewhere.\nSecond.\n"; string(data) != want {
+		t.Errorf("saved %q, want %q", data, want)
+	}
+	if a.merge.Active || a.currentBuf().buf.Dirty || a.currentBuf().buf.ChangedOnDisk() {
+		t.Error("Enter should save the merged text")
+	}
+
+	a.currentBuf().undo.Undo(a.currentBuf().buf)
+	if a.currentBuf().buf.Lines[3] != "Second, edited." {
+		t.Errorf("the merge should undo as one step, got %q", a.currentBuf().buf.Lines[3])
+	}
+}
+
+func TestMergeCancelAndForce(t *testing.T) {
+	a, path := newMergeTestApp(t)
+	a.executeCommand("w")
+	sendKeys(a, "\x1b")
+	if a.merge.Active || !a.currentBuf().buf.Dirty {
+		t.Error("Esc should cancel without saving")
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "elsewhere") {
+		t.Error("the file should be left alone")
+	}
+
+	a.executeCommand("w!")
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "elsewhere") || a.merge.Active {
+		t.Errorf(":w! should overwrite the file, got %q", data)
+	}
+	a.executeCommand("merge")
+	if a.statusBar.StatusMessage != "File unchanged on disk" {
+		t.Errorf(":merge after saving = %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,48 @@
+package editor
+
+import "github.com/JackWReid/prose/internal/terminal"
+
+// :messages lists the status bar's recent messages, for those gone before
+// they could be read. Errors stay in the status bar until Esc dismisses
+// them, and are listed in red.
+
+// MessageLog manages the :messages overlay.
+type MessageLog struct {
+	ListView[LoggedMessage]
+}
+
+// Show activates the overlay with the given messages, selecting the newest.
+func (m *MessageLog) Show(messages []LoggedMessage) {
+	m.ListView.Show(messages)
+	m.Selected = len(messages) - 1
+}
+
+// showMessages runs :messages.
+func (a *App) showMessages() {
+	if len(a.statusBar.Messages) == 0 {
+		a.statusBar.SetMessage("No messages")
+		return
+	}
+	a.statusBar.DismissMessage()
+	a.messages.Show(append([]LoggedMessage(nil), a.statusBar.Messages...))
+}
+
+func (a *App) handleMessagesKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape, terminal.KeyEnter:
+		a.messages.Hide()
+	case terminal.KeyUp:
+		a.messages.MoveUp()
+	case terminal.KeyDown:
+		a.messages.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.messages.MoveUp()
+		case 'j':
+			a.messages.MoveDown()
+		case 'q':
+			a.messages.Hide()
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestMessageLogKeepsRecentMessages(t *testing.T) {
+	s := NewStatusBar()
+	for i := range maxMessages + 5 {
+		s.SetMessage(fmt.Sprintf("Message %d", i))
+	}
+	s.SetMessage("")
+	if len(s.Messages) != maxMessages {
+		t.Fatalf("kept %d messages, want %d", len(s.Messages), maxMessages)
+	}
+	if s.Messages[0].Text != "Message 5" || s.Messages[maxMessages-1].Text != fmt.Sprintf("Message %d", maxMessages+4) {
+		t.Errorf("should keep the newest, oldest first: %q ... %q", s.Messages[0].Text, s.Messages[maxMessages-1].Text)
+	}
+}
+
+func TestErrorMessagePersists(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.statusBar.SetError("Save failed: disk full")
+	sendKeys(a, "jk")
+	if a.statusBar.StatusMessage != "Save failed: disk full" {
+		t.Errorf("an error should stay after other keys, got %q", a.statusBar.StatusMessage)
+	}
+	sendKeys(a, "\x1b")
+	if a.statusBar.StatusMessage != "" || a.statusBar.MessageError {
+		t.Errorf("Esc should dismiss the error, got %q", a.statusBar.StatusMessage)
+	}
+
+	a.statusBar.SetMessage("Sent line to scratch")
+	sendKeys(a, "j")
+	if a.statusBar.StatusMessage != "" {
+		t.Errorf("other messages should clear on the next key, got %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestMessagesCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.executeCommand("messages")
+	if a.messages.Active || a.statusBar.StatusMessage != "No messages" {
+		t.Errorf("with none: active %v, message %q", a.messages.Active, a.statusBar.StatusMessage)
+	}
+
+	a.statusBar.SetError("Export failed: pandoc not found")
+	a.statusBar.SetMessage("Sent line to scratch")
+	a.executeCommand("messages")
+	if !a.messages.Active || len(a.messages.Items) != 3 || a.messages.Selected != 2 {
+		t.Fatalf(":messages should list them all with the newest selected, got %+v", a.messages)
+	}
+	if a.statusBar.StatusMessage != "" {
+		t.Errorf("the status bar should be clear under the log, got %q", a.statusBar.StatusMessage)
+	}
+
+	frame := NewRenderer().RenderMessages(a.messages, NewViewport(80, 24))
+	if !strings.Contains(frame, "\x1b[31m"+a.messages.Items[1].Time.Format("15:04:05")+"  Export failed") {
+		t.Errorf("errors should be listed in red: %q", frame)
+	}
+
+	a.handleMessagesKey(terminal.Key{Type: terminal.KeyRune, Rune: 'k'})
+	if a.messages.Selected != 1 {
+		t.Errorf("k should move up, got %d", a.messages.Selected)
+	}
+	a.handleMessagesKey(terminal.Key{Type: terminal.KeyRune, Rune: 'q'})
+	if a.messages.Active {
+		t.Error("q should close the log")
+	}
+}
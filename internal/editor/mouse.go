@@ -0,0 +1,70 @@
+package editor
+
+import "github.com/JackWReid/prose/internal/terminal"
+
+// clickOverlay handles a click while an overlay is open. Clicking an item
+// of the picker, outline or browser opens it as Enter would; clicking
+// outside the overlay dismisses it.
+func (a *App) clickOverlay(row, col int) {
+	box := a.renderer.overlay
+	if !box.contains(row, col) {
+		a.dismissOverlay()
+		return
+	}
+	i := row - box.itemTop
+	if i < 0 || i >= box.items {
+		return
+	}
+	if o := a.activeOverlay(); o != nil {
+		if o.click != nil {
+			o.click(i)
+		}
+		return
+	}
+	switch {
+	case a.browser.Active:
+		a.browser.DeletePending = false
+		a.clickItem(&a.browser.Selected, a.browser.ScrollOffset+i, len(a.browser.Items), a.handleBrowserKey)
+	case a.leaderMenu.Active:
+		a.runLeaderItem(i)
+	}
+}
+
+// clickItem selects item n of a list of count and opens it with Enter.
+// Clicks on placeholder rows, past the end of the list, do nothing.
+func (a *App) clickItem(selected *int, n, count int, handle func(terminal.Key)) {
+	if n >= count {
+		return
+	}
+	*selected = n
+	handle(terminal.Key{Type: terminal.KeyEnter})
+}
+
+// dismissOverlay closes the open overlay as Esc does. Filters and pending
+// deletes are dropped first so one click is always enough.
+func (a *App) dismissOverlay() {
+	a.outline.Filter = OverlayFilter{}
+	a.picker.Filter = OverlayFilter{}
+	a.browser.DeletePending = false
+	a.handleKey(terminal.Key{Type: terminal.KeyEscape})
+}
+
+// clickModeIndicator switches between Default and Edit mode when the click
+// is on the mode shown at the right of the status bar. From any other mode
+// it returns to Default mode.
+func (a *App) clickModeIndicator(row, col int) bool {
+	if a.viewport == nil {
+		return false
+	}
+	label := len(a.mode.String())
+	end := a.viewport.Left + a.viewport.Width - 1
+	if row != a.viewport.Top+a.viewport.Height || col < end-label+1 || col > end {
+		return false
+	}
+	if a.mode == ModeDefault {
+		a.runAction("insert")
+	} else {
+		a.handleKey(terminal.Key{Type: terminal.KeyEscape})
+	}
+	return true
+}
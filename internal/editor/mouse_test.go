@@ -0,0 +1,75 @@
+package editor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func click(a *App, row, col int) {
+	a.handleMouse(terminal.MouseEvent{Button: terminal.MouseLeft, Press: true, Row: row, Col: col})
+}
+
+func TestMouseClickPickerItem(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.executeCommand("e " + filepath.Join(t.TempDir(), "b.md"))
+	a.currentBuffer = 0
+	a.picker.Show(0)
+	a.renderer.RenderPicker(a.buffers, a.picker, a.currentBuffer, a.screenViewport())
+
+	box := a.renderer.overlay
+	click(a, box.itemTop+1, box.left+4)
+	if a.picker.Active || a.currentBuffer != 1 {
+		t.Errorf("clicking the second buffer should open it, got buffer %d, active %v", a.currentBuffer, a.picker.Active)
+	}
+}
+
+func TestMouseClickOutlineItem(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.buffers[0].buf.Lines = []string{"# One", "text", "# Two", "more"}
+	a.showOutline()
+	a.renderer.RenderOutline(a.outline, a.screenViewport())
+
+	box := a.renderer.overlay
+	click(a, box.itemTop+1, box.left+4)
+	if a.outline.Active || a.currentBuf().cursorLine != 2 {
+		t.Errorf("clicking a heading should jump to it, got line %d, active %v", a.currentBuf().cursorLine, a.outline.Active)
+	}
+}
+
+func TestMouseClickOutsideDismissesOverlay(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.picker.Show(0)
+	a.picker.Filter = OverlayFilter{Typing: true, Text: "zz"}
+	a.picker.ApplyFilter(a.buffers, 0)
+	a.renderer.RenderPicker(a.buffers, a.picker, a.currentBuffer, a.screenViewport())
+
+	box := a.renderer.overlay
+	click(a, box.itemTop, box.left+4)
+	if !a.picker.Active {
+		t.Fatal("clicking the no-matches row should do nothing")
+	}
+	click(a, 1, 1)
+	if a.picker.Active {
+		t.Error("clicking outside the overlay should close it")
+	}
+}
+
+func TestMouseClickModeIndicator(t *testing.T) {
+	a := newSplitTestApp(t)
+	row, col := a.viewport.Top+a.viewport.Height, a.viewport.Left+a.viewport.Width-1
+
+	click(a, row, col)
+	if a.mode != ModeEdit {
+		t.Fatalf("clicking DEFAULT should enter Edit mode, got %v", a.mode)
+	}
+	click(a, row, col-len("EDIT")+1)
+	if a.mode != ModeDefault {
+		t.Fatalf("clicking EDIT should return to Default mode, got %v", a.mode)
+	}
+	click(a, row, col-len("DEFAULT"))
+	if a.mode != ModeDefault {
+		t.Errorf("clicks left of the mode label should not switch modes, got %v", a.mode)
+	}
+}
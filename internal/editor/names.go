@@ -0,0 +1,280 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// NameUse is one occurrence of a name in an open buffer.
+type NameUse struct {
+	Buffer int // Index into App.buffers
+	Line   int
+	Col    int // Rune offset within the line
+}
+
+// NameVariant is one spelling of a name and every place it is used.
+type NameVariant struct {
+	Name string
+	Uses []NameUse
+}
+
+// NameClash pairs two spellings that are probably meant to be the same name.
+// Common is the more frequent spelling; Rare is the likely mistake.
+type NameClash struct {
+	Common NameVariant
+	Rare   NameVariant
+}
+
+// Label returns the overlay text for a clash, e.g. "Katherine (12) ~ Katharine (1)".
+func (c NameClash) Label() string {
+	return fmt.Sprintf("%s (%d) ~ %s (%d)", c.Common.Name, len(c.Common.Uses), c.Rare.Name, len(c.Rare.Uses))
+}
+
+// nameToken is a capitalised word found while scanning a line.
+type nameToken struct {
+	word          string
+	col           int
+	sentenceStart bool
+}
+
+// scanNames returns the capitalised words in line. A trailing possessive
+// ('s) is dropped, and all-caps words such as acronyms are skipped. Each
+// line is taken to begin a sentence.
+func scanNames(line string) []nameToken {
+	var tokens []nameToken
+	runes := []rune(line)
+	sentenceStart := true
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !unicode.IsLetter(r) {
+			switch r {
+			case '.', '!', '?':
+				sentenceStart = true
+			case ' ', '\t', '"', '\'', '“', '”', '‘', '’', '(', ')', '*', '_', '#', '>', '-', '–', '—':
+			default:
+				if !unicode.IsDigit(r) {
+					sentenceStart = false
+				}
+			}
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || isInnerApostrophe(runes, j)) {
+			j++
+		}
+		word := string(runes[i:j])
+		word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+		if isNameWord(word) {
+			tokens = append(tokens, nameToken{word: word, col: i, sentenceStart: sentenceStart})
+		}
+		sentenceStart = false
+		i = j
+	}
+	return tokens
+}
+
+// isInnerApostrophe reports whether runes[i] is an apostrophe between two
+// letters, as in "O'Brien".
+func isInnerApostrophe(runes []rune, i int) bool {
+	if runes[i] != '\'' && runes[i] != '’' {
+		return false
+	}
+	return i > 0 && i+1 < len(runes) && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1])
+}
+
+// isNameWord reports whether word looks like a proper noun: at least three
+// letters, an upper-case first letter and some lower case after it.
+func isNameWord(word string) bool {
+	runes := []rune(word)
+	if len(runes) < 3 || !unicode.IsUpper(runes[0]) {
+		return false
+	}
+	for _, r := range runes[1:] {
+		if unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexNames collects the capitalised names used across buffers. Words seen
+// only at the start of sentences are left out, since there capitalisation
+// says nothing about whether a word is a name. Code blocks and front matter
+// are skipped.
+func IndexNames(buffers []*EditorBuffer) map[string]*NameVariant {
+	index := make(map[string]*NameVariant)
+	midSentence := make(map[string]bool)
+	for bi, eb := range buffers {
+		if eb.isScratch {
+			continue
+		}
+		lines := eb.buf.Lines
+		blocks := ComputeBlockStates(lines)
+		for li := frontMatterEnd(lines) + 1; li < len(lines); li++ {
+			if blocks[li].InCode {
+				continue
+			}
+			for _, tok := range scanNames(lines[li]) {
+				v := index[tok.word]
+				if v == nil {
+					v = &NameVariant{Name: tok.word}
+					index[tok.word] = v
+				}
+				v.Uses = append(v.Uses, NameUse{Buffer: bi, Line: li, Col: tok.col})
+				if !tok.sentenceStart {
+					midSentence[tok.word] = true
+				}
+			}
+		}
+	}
+	for word := range index {
+		if !midSentence[word] {
+			delete(index, word)
+		}
+	}
+	return index
+}
+
+// FindNameClashes returns pairs of names whose spellings are close enough to
+// be the same character, sorted by the common spelling.
+func FindNameClashes(index map[string]*NameVariant) []NameClash {
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var clashes []NameClash
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			if !similarNames(a, b) {
+				continue
+			}
+			common, rare := *index[a], *index[b]
+			if len(rare.Uses) > len(common.Uses) {
+				common, rare = rare, common
+			}
+			clashes = append(clashes, NameClash{Common: common, Rare: rare})
+		}
+	}
+	sort.SliceStable(clashes, func(i, j int) bool {
+		return clashes[i].Common.Name < clashes[j].Common.Name
+	})
+	return clashes
+}
+
+// similarNames reports whether two names are probably variant spellings:
+// same first letter, and within one edit (two for names of eight letters or
+// more). Plurals such as "Smith" and "Smiths" are not counted.
+func similarNames(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if ra[0] != rb[0] {
+		return false
+	}
+	if a+"s" == b || b+"s" == a {
+		return false
+	}
+	limit := 1
+	if min(len(ra), len(rb)) >= 8 {
+		limit = 2
+	}
+	if abs(len(ra)-len(rb)) > limit {
+		return false
+	}
+	return editDistance(ra, rb) <= limit
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and adjacent transpositions needed to turn a into b.
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if unicode.ToLower(a[i-1]) == unicode.ToLower(b[j-1]) {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// NameCheck manages the name consistency overlay.
+type NameCheck struct {
+	ListView[NameClash]
+}
+
+// showNameCheck indexes names across every open buffer and lists the likely
+// misspellings.
+func (a *App) showNameCheck() {
+	clashes := FindNameClashes(IndexNames(a.buffers))
+	if a.project != nil {
+		clashes = a.project.FilterClashes(clashes)
+	}
+	if len(clashes) == 0 {
+		a.statusBar.SetMessage("No inconsistent names found")
+		return
+	}
+	a.nameCheck.Show(clashes)
+}
+
+// jumpToNameClash moves to the first use of the selected clash's rarer
+// spelling, switching buffers if needed.
+func (a *App) jumpToNameClash() {
+	if a.nameCheck.Selected < 0 || a.nameCheck.Selected >= len(a.nameCheck.Items) {
+		return
+	}
+	clash := a.nameCheck.Items[a.nameCheck.Selected]
+	use := clash.Rare.Uses[0]
+	a.pushJump()
+	a.currentBuffer = use.Buffer
+	eb := a.currentBuf()
+	eb.cursorLine = use.Line
+	eb.cursorCol = use.Col
+	a.statusBar.SetMessage(fmt.Sprintf("%s: %d use(s); %s: %d", clash.Rare.Name, len(clash.Rare.Uses), clash.Common.Name, len(clash.Common.Uses)))
+}
+
+func (a *App) handleNameCheckKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.nameCheck.Hide()
+	case terminal.KeyUp:
+		a.nameCheck.MoveUp()
+	case terminal.KeyDown:
+		a.nameCheck.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.nameCheck.MoveUp()
+		case 'j':
+			a.nameCheck.MoveDown()
+		}
+	case terminal.KeyEnter:
+		a.jumpToNameClash()
+		a.nameCheck.Hide()
+	}
+}
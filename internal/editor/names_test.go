@@ -0,0 +1,145 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestScanNames(t *testing.T) {
+	tokens := scanNames(`The door opened. Katherine's coat hung by O'Brien and NASA, said "Tom".`)
+	want := []nameToken{
+		{word: "The", col: 0, sentenceStart: true},
+		{word: "Katherine", col: 17, sentenceStart: true},
+		{word: "O'Brien", col: 42, sentenceStart: false},
+		{word: "Tom", col: 66, sentenceStart: false},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("scanNames = %+v, want %+v", tokens, want)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("token %d = %+v, want %+v", i, tokens[i], want[i])
+		}
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Katherine", "Katharine", true},
+		{"Katherine", "Kathryn", false},
+		{"Jon", "John", true},
+		{"Sarah", "Sara", true},
+		{"Smith", "Smiths", false},
+		{"Mark", "Mary", true},
+		{"Anna", "Hanna", false}, // Different first letter
+		{"Alexander", "Alexnader", true},
+		{"Tom", "Sam", false},
+	}
+	for _, tt := range tests {
+		if got := similarNames(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarNames(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"abc", "abc", 0},
+		{"abc", "acb", 1},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIndexNamesSkipsSentenceStartsAndCode(t *testing.T) {
+	eb := &EditorBuffer{buf: &Buffer{Lines: []string{
+		"---",
+		"author: Katharine",
+		"---",
+		"Then Katherine left. Then she came back.",
+		"```",
+		"Katharyne = 1",
+		"```",
+		"Maybe. Maybe not.",
+	}}}
+	index := IndexNames([]*EditorBuffer{eb})
+	if _, ok := index["Katherine"]; !ok {
+		t.Error("expected Katherine to be indexed")
+	}
+	for _, word := range []string{"Then", "Maybe", "Katharine", "Katharyne"} {
+		if _, ok := index[word]; ok {
+			t.Errorf("%q should not be indexed", word)
+		}
+	}
+}
+
+func TestFindNameClashesAcrossBuffers(t *testing.T) {
+	one := &EditorBuffer{buf: &Buffer{Lines: []string{
+		"She met Katherine at noon, and Katherine smiled.",
+	}}}
+	two := &EditorBuffer{buf: &Buffer{Lines: []string{
+		"",
+		"Later, Katharine and Tom walked home.",
+	}}}
+	clashes := FindNameClashes(IndexNames([]*EditorBuffer{one, two}))
+	if len(clashes) != 1 {
+		t.Fatalf("got %d clashes, want 1: %+v", len(clashes), clashes)
+	}
+	c := clashes[0]
+	if c.Common.Name != "Katherine" || c.Rare.Name != "Katharine" {
+		t.Errorf("clash = %s / %s, want Katherine / Katharine", c.Common.Name, c.Rare.Name)
+	}
+	if got := c.Label(); got != "Katherine (2) ~ Katharine (1)" {
+		t.Errorf("Label = %q", got)
+	}
+	if use := c.Rare.Uses[0]; use != (NameUse{Buffer: 1, Line: 1, Col: 7}) {
+		t.Errorf("rare use = %+v", use)
+	}
+}
+
+func TestNamesCommandJumpsToRareSpelling(t *testing.T) {
+	a := newTestApp("one.md")
+	a.currentBuf().buf.Lines = []string{"I saw Katherine and Katherine saw me."}
+	a.buffers = append(a.buffers, &EditorBuffer{buf: &Buffer{
+		Filename: "two.md",
+		Lines:    []string{"Nothing here.", "Ask Katharine."},
+	}})
+
+	a.executeCommand("names")
+	if !a.nameCheck.Active || len(a.nameCheck.Items) != 1 {
+		t.Fatalf("expected one clash in the overlay, got %+v", a.nameCheck.Items)
+	}
+
+	a.handleNameCheckKey(terminal.Key{Type: terminal.KeyEnter})
+	if a.nameCheck.Active {
+		t.Error("overlay should close after Enter")
+	}
+	eb := a.currentBuf()
+	if a.currentBuffer != 1 || eb.cursorLine != 1 || eb.cursorCol != 4 {
+		t.Errorf("cursor at buffer %d %d:%d, want 1 1:4", a.currentBuffer, eb.cursorLine, eb.cursorCol)
+	}
+}
+
+func TestNamesCommandNoClashes(t *testing.T) {
+	a := newTestApp("one.md")
+	a.currentBuf().buf.Lines = []string{"Only Katherine here."}
+	a.executeCommand("names")
+	if a.nameCheck.Active {
+		t.Error("overlay should not open without clashes")
+	}
+	if a.statusBar.StatusMessage != "No inconsistent names found" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
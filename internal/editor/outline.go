@@ -1,86 +1,155 @@
 package editor
 
-// Outline manages the document outline overlay state.
+// Outline manages the document outline overlay state. Headings form a tree by
+// level; collapsing a heading hides its subsections. Its items are the
+// headings listed, in document order.
 type Outline struct {
-	Active       bool
-	Items        []OutlineItem
-	Selected     int
-	ScrollOffset int // For scrolling long outlines
+	ListView[OutlineItem]
+	Project bool // Showing every project file rather than one document
+	Filter  OverlayFilter
+
+	all       []OutlineItem // Every heading in the document
+	visible   []int         // Index into all for each entry in Items
+	collapsed map[int]bool  // Collapsed headings, by index into all
 }
 
-// Show activates the outline with the given items.
+// Show activates the outline with the given items, fully expanded.
 func (o *Outline) Show(items []OutlineItem) {
 	o.Active = true
-	o.Items = items
+	o.Project = false
+	o.all = items
+	o.collapsed = make(map[int]bool)
 	o.Selected = 0
 	o.ScrollOffset = 0
+	o.Filter = OverlayFilter{}
+	o.rebuild()
 }
 
 // Hide deactivates the outline.
 func (o *Outline) Hide() {
-	o.Active = false
-	o.Items = nil
+	o.ListView.Hide()
+	o.Project = false
+	o.all = nil
+	o.visible = nil
+	o.collapsed = nil
+	o.Filter = OverlayFilter{}
+}
+
+// ApplyFilter lists the headings matching the filter and selects the first.
+func (o *Outline) ApplyFilter() {
 	o.Selected = 0
 	o.ScrollOffset = 0
+	o.rebuild()
 }
 
-// MoveUp moves the selection up, adjusting scroll offset if needed.
-func (o *Outline) MoveUp() {
-	if o.Selected > 0 {
-		o.Selected--
-		// Adjust scroll offset if selection moves above visible window.
-		if o.Selected < o.ScrollOffset {
-			o.ScrollOffset = o.Selected
+// rebuild recomputes the visible items, skipping descendants of collapsed
+// headings. While filtering, every heading that matches is listed.
+func (o *Outline) rebuild() {
+	o.Items = o.Items[:0]
+	o.visible = o.visible[:0]
+	hideBelow := 0 // While non-zero, skip headings deeper than this level
+	for i, item := range o.all {
+		if o.Filter.Text != "" {
+			if _, ok := fuzzyMatch(o.Filter.Text, item.Text); ok {
+				o.Items = append(o.Items, item)
+				o.visible = append(o.visible, i)
+			}
+			continue
+		}
+		if hideBelow != 0 {
+			if item.Level > hideBelow {
+				continue
+			}
+			hideBelow = 0
+		}
+		o.Items = append(o.Items, item)
+		o.visible = append(o.visible, i)
+		if o.collapsed[i] {
+			hideBelow = item.Level
 		}
 	}
+	if o.Selected >= len(o.Items) {
+		o.Selected = len(o.Items) - 1
+	}
+	if o.Selected < 0 {
+		o.Selected = 0
+	}
 }
 
-// MoveDown moves the selection down, adjusting scroll offset if needed.
-func (o *Outline) MoveDown() {
-	if o.Selected < len(o.Items)-1 {
-		o.Selected++
-		// Adjust scroll offset if selection moves below visible window.
-		// We'll calculate the max visible items in the renderer.
-	}
+// hasChildren reports whether the heading at index i of all has subsections.
+func (o *Outline) hasChildren(i int) bool {
+	return i+1 < len(o.all) && o.all[i+1].Level > o.all[i].Level
 }
 
-// VisibleItems returns the slice of items currently visible given a max height.
-func (o *Outline) VisibleItems(maxHeight int) []OutlineItem {
-	if len(o.Items) == 0 {
-		return nil
+// HasChildren reports whether the visible item at idx has subsections.
+func (o *Outline) HasChildren(idx int) bool {
+	if idx < 0 || idx >= len(o.visible) {
+		return false
 	}
+	return o.hasChildren(o.visible[idx])
+}
 
-	// Ensure selection is within bounds.
-	if o.Selected >= len(o.Items) {
-		o.Selected = len(o.Items) - 1
+// IsCollapsed reports whether the visible item at idx is collapsed.
+func (o *Outline) IsCollapsed(idx int) bool {
+	if idx < 0 || idx >= len(o.visible) {
+		return false
 	}
+	return o.collapsed[o.visible[idx]]
+}
 
-	// Adjust scroll offset to keep selection visible.
-	if o.Selected < o.ScrollOffset {
-		o.ScrollOffset = o.Selected
+// Collapse folds the selected heading's subsections. If it is already
+// collapsed or has none, the selection moves to its parent instead.
+func (o *Outline) Collapse() {
+	if o.Selected >= len(o.visible) {
+		return
 	}
-	if o.Selected >= o.ScrollOffset+maxHeight {
-		o.ScrollOffset = o.Selected - maxHeight + 1
+	i := o.visible[o.Selected]
+	if o.hasChildren(i) && !o.collapsed[i] {
+		o.collapsed[i] = true
+		o.rebuild()
+		return
 	}
-
-	// Clamp scroll offset.
-	if o.ScrollOffset < 0 {
-		o.ScrollOffset = 0
+	for j := o.Selected - 1; j >= 0; j-- {
+		if o.Items[j].Level < o.Items[o.Selected].Level {
+			o.Selected = j
+			return
+		}
 	}
-	maxScroll := len(o.Items) - maxHeight
-	if maxScroll < 0 {
-		maxScroll = 0
+}
+
+// Expand unfolds the selected heading's subsections.
+func (o *Outline) Expand() {
+	if o.Selected >= len(o.visible) {
+		return
 	}
-	if o.ScrollOffset > maxScroll {
-		o.ScrollOffset = maxScroll
+	i := o.visible[o.Selected]
+	if o.collapsed[i] {
+		delete(o.collapsed, i)
+		o.rebuild()
 	}
+}
 
-	// Return visible slice.
-	start := o.ScrollOffset
-	end := o.ScrollOffset + maxHeight
-	if end > len(o.Items) {
-		end = len(o.Items)
+// Toggle collapses or expands the selected heading.
+func (o *Outline) Toggle() {
+	if o.IsCollapsed(o.Selected) {
+		o.Expand()
+	} else if o.HasChildren(o.Selected) {
+		o.Collapse()
 	}
+}
 
-	return o.Items[start:end]
+// ProjectOutline returns the outlines of several files, each nested under an
+// entry naming its file. names holds the label for each file and texts its
+// lines.
+func ProjectOutline(files, names []string, texts [][]string) []OutlineItem {
+	var items []OutlineItem
+	for i, path := range files {
+		items = append(items, OutlineItem{Level: 1, Text: names[i], File: path, IsFile: true})
+		for _, item := range ExtractOutline(&Buffer{Lines: texts[i]}) {
+			item.Level++
+			item.File = path
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,178 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func testOutlineItems() []OutlineItem {
+	return []OutlineItem{
+		{Level: 1, Text: "Part One", BufferLine: 0},
+		{Level: 2, Text: "Chapter 1", BufferLine: 2},
+		{Level: 3, Text: "Scene A", BufferLine: 4},
+		{Level: 2, Text: "Chapter 2", BufferLine: 6},
+		{Level: 1, Text: "Part Two", BufferLine: 8},
+	}
+}
+
+func outlineTexts(o *Outline) []string {
+	var texts []string
+	for _, item := range o.Items {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
+
+func TestOutlineCollapseExpand(t *testing.T) {
+	o := &Outline{}
+	o.Show(testOutlineItems())
+	if len(o.Items) != 5 {
+		t.Fatalf("outline should start expanded, got %v", outlineTexts(o))
+	}
+
+	o.Collapse() // Part One
+	if got := outlineTexts(o); len(got) != 2 || got[0] != "Part One" || got[1] != "Part Two" {
+		t.Fatalf("after collapsing Part One, items = %v", got)
+	}
+	if !o.IsCollapsed(0) || !o.HasChildren(0) || o.HasChildren(1) {
+		t.Error("fold state reported incorrectly")
+	}
+
+	o.Expand()
+	if len(o.Items) != 5 {
+		t.Errorf("after expanding, items = %v", outlineTexts(o))
+	}
+}
+
+func TestOutlineNestedCollapseKeepsState(t *testing.T) {
+	o := &Outline{}
+	o.Show(testOutlineItems())
+
+	o.Selected = 1 // Chapter 1
+	o.Collapse()
+	if got := outlineTexts(o); len(got) != 4 || got[2] != "Chapter 2" {
+		t.Fatalf("after collapsing Chapter 1, items = %v", got)
+	}
+
+	o.Selected = 0
+	o.Collapse()
+	o.Expand()
+	// Chapter 1 stays folded inside the re-expanded part.
+	if got := outlineTexts(o); len(got) != 4 {
+		t.Errorf("nested fold should survive parent toggle, items = %v", got)
+	}
+}
+
+func TestOutlineCollapseLeafSelectsParent(t *testing.T) {
+	o := &Outline{}
+	o.Show(testOutlineItems())
+	o.Selected = 2 // Scene A has no children
+	o.Collapse()
+	if o.Selected != 1 {
+		t.Errorf("Selected = %d, want 1 (parent)", o.Selected)
+	}
+}
+
+func TestOutlineToggle(t *testing.T) {
+	o := &Outline{}
+	o.Show(testOutlineItems())
+	o.Selected = 3 // Chapter 2 is a leaf
+	o.Toggle()
+	if len(o.Items) != 5 || o.Selected != 3 {
+		t.Error("toggling a leaf should do nothing")
+	}
+	o.Selected = 4
+	o.Toggle()
+	if len(o.Items) != 5 {
+		t.Error("toggling a heading without subsections should do nothing")
+	}
+	o.Selected = 0
+	o.Toggle()
+	if len(o.Items) != 2 {
+		t.Errorf("toggle should collapse, items = %v", outlineTexts(o))
+	}
+	o.Toggle()
+	if len(o.Items) != 5 {
+		t.Errorf("toggle should expand, items = %v", outlineTexts(o))
+	}
+}
+
+func TestProjectOutline(t *testing.T) {
+	items := ProjectOutline(
+		[]string{"/book/one.md", "/book/two.md"},
+		[]string{"one.md", "two.md"},
+		[][]string{{"# One", "", "## Arrival"}, {"No headings here."}},
+	)
+	want := []OutlineItem{
+		{Level: 1, Text: "one.md", File: "/book/one.md", IsFile: true},
+		{Level: 2, Text: "One", BufferLine: 0, File: "/book/one.md"},
+		{Level: 3, Text: "Arrival", BufferLine: 2, File: "/book/one.md"},
+		{Level: 1, Text: "two.md", File: "/book/two.md", IsFile: true},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("ProjectOutline = %+v, want %+v", items, want)
+	}
+
+	// Collapsing a file hides its headings.
+	o := &Outline{}
+	o.Show(items)
+	o.Collapse()
+	if got := outlineTexts(o); !reflect.DeepEqual(got, []string{"one.md", "two.md"}) {
+		t.Errorf("after collapsing the first file: %v", got)
+	}
+}
+
+func TestShowProjectOutlineJumpsAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		projectFileName: "chapter = two.md\nchapter = one.md\n",
+		"one.md":        "# One\n",
+		"two.md":        "# Two\n\ntext\n\n## Later\n",
+		"notes.md":      "# Not in the project\n",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := newTestApp(filepath.Join(dir, "one.md"))
+	a.currentBuf().buf.Load()
+	a.showOutline()
+	a.handleOutlineKey(terminal.Key{Type: terminal.KeyRune, Rune: 'p'})
+
+	if !a.outline.Active || !a.outline.Project {
+		t.Fatalf("project outline should be open: %s", a.statusBar.StatusMessage)
+	}
+	if got := outlineTexts(a.outline); !reflect.DeepEqual(got, []string{"two.md", "Two", "Later", "one.md", "One"}) {
+		t.Fatalf("project outline = %v", got)
+	}
+	if a.outline.Selected != 3 {
+		t.Errorf("current file should be selected, got %d", a.outline.Selected)
+	}
+
+	a.outline.Selected = 2
+	a.handleOutlineKey(terminal.Key{Type: terminal.KeyEnter})
+	eb := a.currentBuf()
+	if filepath.Base(eb.buf.Filename) != "two.md" || eb.cursorLine != 4 {
+		t.Errorf("jumped to %s:%d, want two.md:4", eb.buf.Filename, eb.cursorLine)
+	}
+}
+
+func TestShowProjectOutlineWithoutProject(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.md", "a.md", "image.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := newTestApp(filepath.Join(dir, "b.md"))
+	a.currentBuf().buf.Load()
+	a.showProjectOutline()
+	if got := outlineTexts(a.outline); !reflect.DeepEqual(got, []string{"a.md", "a.md", "b.md", "b.md"}) {
+		t.Errorf("directory outline = %v", got)
+	}
+}
@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// overlay is a view drawn over the text that takes the keys while it is
+// open. Adding one to overlays routes keys, clicks and drawing to it.
+type overlay struct {
+	active *bool
+	key    func(terminal.Key)
+	click  func(item int)            // Clicking a row; nil if clicks do nothing
+	render func(vp *Viewport) string // Nil if drawn on its own
+}
+
+// overlays returns the overlays that take keys before any prompt, in the
+// order they take them. The browser comes after the prompt, which it uses
+// for file names, and the leader menu only shows while a leader key waits.
+func (a *App) overlays() []overlay {
+	return []overlay{
+		// Completions are offered while typing, so they come before the rest.
+		{active: &a.completion.Active, key: a.handleCompletionKey, click: func(i int) {
+			a.clickItem(&a.completion.Selected, a.completion.ScrollOffset+i, len(a.completion.Items), a.handleCompletionKey)
+		}},
+		{active: &a.columnAdjust.Active, key: a.handleColumnAdjustKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderColumnAdjust(a.columnAdjust, vp)
+		}},
+		{active: &a.outline.Active, key: a.handleOutlineKey, click: func(i int) {
+			a.outline.Filter.Typing = false
+			a.clickItem(&a.outline.Selected, a.outline.ScrollOffset+i, len(a.outline.Items), a.handleOutlineKey)
+		}, render: func(vp *Viewport) string {
+			return a.renderer.RenderOutline(a.outline, vp)
+		}},
+		{active: &a.nameCheck.Active, key: a.handleNameCheckKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderNameCheck(a.nameCheck, vp)
+		}},
+		{active: &a.dupes.Active, key: a.handleDuplicatesKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderDuplicates(a.dupes, vp)
+		}},
+		{active: &a.undoTree.Active, key: a.handleUndoTreeKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderUndoTree(a.undoTree, vp, time.Now())
+		}},
+		{active: &a.notes.Active, key: a.handleNotesKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderNotes(a.notes, vp)
+		}},
+		{active: &a.grepList.Active, key: a.handleGrepKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderGrep(a.grepList, vp)
+		}},
+		{active: &a.recent.Active, key: a.handleRecentKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderRecent(a.recent, vp)
+		}},
+		{active: &a.palette.Active, key: a.handlePaletteKey, click: func(i int) {
+			a.clickItem(&a.palette.Selected, a.palette.ScrollOffset+i, len(a.palette.Items), a.handlePaletteKey)
+		}, render: func(vp *Viewport) string {
+			return a.renderer.RenderPalette(a.palette, a.keymap, vp)
+		}},
+		{active: &a.quitSummary.Active, key: a.handleQuitSummaryKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderQuitSummary(a.quitSummary, vp)
+		}},
+		{active: &a.merge.Active, key: a.handleMergeKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderMerge(a.merge, vp)
+		}},
+		{active: &a.gitDiff.Active, key: a.handleGitDiffKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderGitDiff(a.gitDiff, vp)
+		}},
+		{active: &a.help.Active, key: a.handleHelpKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderHelp(a.help, vp)
+		}},
+		{active: &a.messages.Active, key: a.handleMessagesKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderMessages(a.messages, vp)
+		}},
+		{active: &a.journal.Active, key: a.handleJournalKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderJournal(a.journal, vp)
+		}},
+		{active: &a.stats.Active, key: a.handleStatsKey, render: func(vp *Viewport) string {
+			return a.renderer.RenderStats(a.stats, vp)
+		}},
+		{active: &a.picker.Active, key: a.handlePickerKey, click: func(i int) {
+			a.picker.Filter.Typing = false
+			a.clickItem(&a.picker.Selected, a.picker.ScrollOffset+i, a.picker.Count(len(a.buffers)), a.handlePickerKey)
+		}, render: func(vp *Viewport) string {
+			return a.renderer.RenderPicker(a.buffers, a.picker, a.currentBuffer, vp)
+		}},
+	}
+}
+
+// activeOverlay returns the overlay taking keys, or nil if none is open.
+func (a *App) activeOverlay() *overlay {
+	for _, o := range a.overlays() {
+		if *o.active {
+			return &o
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package editor
+
+import "testing"
+
+func TestOverlayTable(t *testing.T) {
+	a := newTestApp("draft.md")
+	seen := make(map[*bool]bool)
+	for i, o := range a.overlays() {
+		if o.active == nil || o.key == nil {
+			t.Errorf("overlay %d needs an active flag and a key handler", i)
+		}
+		if seen[o.active] {
+			t.Errorf("overlay %d shares its active flag with another", i)
+		}
+		seen[o.active] = true
+	}
+	if a.activeOverlay() != nil || a.overlayActive() {
+		t.Error("no overlay should be open at first")
+	}
+}
+
+func TestActiveOverlayTakesKeys(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.statusBar.SetMessage("Saved")
+	a.showMessages()
+	a.showHelp()
+
+	// The help comes before the log in the table, so it takes the keys.
+	o := a.activeOverlay()
+	if o == nil || o.active != &a.help.Active {
+		t.Fatal("the help should be the active overlay")
+	}
+	sendKeys(a, "q")
+	if a.help.Active || !a.messages.Active {
+		t.Errorf("q should close the help alone: help %v, messages %v", a.help.Active, a.messages.Active)
+	}
+	sendKeys(a, "q")
+	if a.messages.Active || a.overlayActive() {
+		t.Error("q should then close the log")
+	}
+}
@@ -0,0 +1,51 @@
+package editor
+
+// Palette is the set of highlight colours for spelling errors, search
+// matches, selections and the cursor line.
+type Palette struct {
+	Spell         string // Misspelled words
+	SearchCurrent string // The current search match
+	SearchOther   string // Other search matches
+	Selection     string // Visual and line-select selections
+	CursorLine    string // Background of the cursor's display line, with cursorline on
+}
+
+// colorReset ends a palette highlight, restoring the default colours so
+// syntax highlighting underneath shows through again.
+const colorReset = "\x1b[39m\x1b[49m"
+
+// palettes are the built-in palettes, by the name used in the config file.
+// Colours are in 24-bit, brought down to what the terminal draws by forDepth.
+// The colour-blind palettes avoid telling highlights apart by red and green:
+// spelling errors are blue, search matches orange and selections grey.
+var palettes = map[string]Palette{
+	"default": {
+		Spell:         "\x1b[38;5;0m\x1b[48;2;255;212;212m", // Black on light red
+		SearchCurrent: "\x1b[38;5;0m\x1b[48;2;255;240;42m",  // Black on bright yellow
+		SearchOther:   "\x1b[38;5;0m\x1b[48;2;255;250;180m", // Black on light yellow
+		Selection:     "\x1b[7m",                            // Reverse video
+		CursorLine:    "\x1b[48;2;43;45;49m",                // Dark grey
+	},
+	"deuteranopia": {
+		Spell:         "\x1b[38;5;0m\x1b[48;2;140;212;255m", // Black on sky blue
+		SearchCurrent: "\x1b[38;5;0m\x1b[48;2;255;170;30m",  // Black on orange
+		SearchOther:   "\x1b[38;5;0m\x1b[48;2;255;216;176m", // Black on pale orange
+		Selection:     "\x1b[38;5;0m\x1b[48;2;189;191;194m", // Black on light grey
+		CursorLine:    "\x1b[48;2;43;45;49m",                // Dark grey
+	},
+	"protanopia": {
+		Spell:         "\x1b[38;5;0m\x1b[48;2;176;216;255m", // Black on pale blue
+		SearchCurrent: "\x1b[38;5;0m\x1b[48;2;255;210;30m",  // Black on gold
+		SearchOther:   "\x1b[38;5;0m\x1b[48;2;255;252;216m", // Black on cream
+		Selection:     "\x1b[38;5;0m\x1b[48;2;189;191;194m", // Black on light grey
+		CursorLine:    "\x1b[48;2;43;45;49m",                // Dark grey
+	},
+}
+
+// selectionSpan returns the style for a character-wise selection.
+func (p Palette) selectionSpan() StyleSpan {
+	if p.Selection == "\x1b[7m" {
+		return StyleSpan{Code: p.Selection, Reset: "\x1b[27m"}
+	}
+	return StyleSpan{Code: p.Selection, Reset: colorReset}
+}
@@ -0,0 +1,52 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+func TestPalettesComplete(t *testing.T) {
+	for name, p := range palettes {
+		if p.Spell == "" || p.SearchCurrent == "" || p.SearchOther == "" || p.Selection == "" || p.CursorLine == "" {
+			t.Errorf("palette %q has unset colours: %+v", name, p)
+		}
+	}
+}
+
+func TestPaletteSelectionSpan(t *testing.T) {
+	tests := []struct {
+		palette string
+		reset   string
+	}{
+		{"default", "\x1b[27m"},
+		{"deuteranopia", colorReset},
+		{"protanopia", colorReset},
+	}
+	for _, tt := range tests {
+		span := palettes[tt.palette].selectionSpan()
+		if span.Code != palettes[tt.palette].Selection || span.Reset != tt.reset {
+			t.Errorf("%s: selectionSpan() = %q/%q, want reset %q", tt.palette, span.Code, span.Reset, tt.reset)
+		}
+	}
+}
+
+func TestRendererPalette(t *testing.T) {
+	r := NewRenderer()
+	r.theme = &Theme{Palette: palettes["deuteranopia"]}
+	dl := DisplayLine{BufferLine: 0, Text: "hello wrold"}
+
+	result := r.applySpellHighlighting(dl.Text, dl, []spell.SpellError{{Line: 0, StartCol: 6, EndCol: 11, Word: "wrold"}})
+	if !strings.Contains(result, palettes["deuteranopia"].Spell) {
+		t.Errorf("spell highlight should use the palette colour, got %q", result)
+	}
+	if strings.Contains(result, palettes["default"].Spell) {
+		t.Error("spell highlight should not use the default colour")
+	}
+
+	result = r.applySearchHighlighting(dl.Text, dl, true, []SearchMatch{{Line: 0, StartCol: 0, EndCol: 5}}, 0)
+	if !strings.Contains(result, palettes["deuteranopia"].SearchCurrent) {
+		t.Errorf("search highlight should use the palette colour, got %q", result)
+	}
+}
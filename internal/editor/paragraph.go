@@ -0,0 +1,59 @@
+package editor
+
+import "strings"
+
+// Paragraphs are runs of non-blank lines. { and } move to the blank line
+// before or after one, as in Vim, so in Line-Select mode V} selects a
+// paragraph and the blank line after it.
+
+// isBlankLine reports whether a line separates paragraphs.
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// nextParagraph returns the first blank line after the paragraph at or
+// below line, or the last line if the document ends first.
+func nextParagraph(lines []string, line int) int {
+	i := max(line, 0)
+	for i < len(lines) && isBlankLine(lines[i]) {
+		i++
+	}
+	for i < len(lines) && !isBlankLine(lines[i]) {
+		i++
+	}
+	return min(i, len(lines)-1)
+}
+
+// prevParagraph returns the last blank line before the paragraph at or
+// above line, or the first line if the document starts first.
+func prevParagraph(lines []string, line int) int {
+	i := min(line, len(lines)-1)
+	for i >= 0 && isBlankLine(lines[i]) {
+		i--
+	}
+	for i >= 0 && !isBlankLine(lines[i]) {
+		i--
+	}
+	return max(i, 0)
+}
+
+// jumpToNextParagraph moves the cursor to the blank line after the
+// paragraph, or to the end of the document.
+func (a *App) jumpToNextParagraph() {
+	eb := a.currentBuf()
+	eb.cursorLine = nextParagraph(eb.buf.Lines, eb.cursorLine)
+	eb.cursorCol = 0
+	if !isBlankLine(eb.buf.Lines[eb.cursorLine]) {
+		eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+	}
+	a.skipFold(true)
+}
+
+// jumpToPrevParagraph moves the cursor to the blank line before the
+// paragraph, or to the start of the document.
+func (a *App) jumpToPrevParagraph() {
+	eb := a.currentBuf()
+	eb.cursorLine = prevParagraph(eb.buf.Lines, eb.cursorLine)
+	eb.cursorCol = 0
+	a.skipFold(false)
+}
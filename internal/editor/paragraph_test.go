@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+var paragraphLines = []string{
+	"First paragraph,",
+	"second line.",
+	"",
+	"",
+	"Second paragraph.",
+	"  ",
+	"Third paragraph.",
+}
+
+func TestNextParagraph(t *testing.T) {
+	tests := []struct{ from, want int }{
+		{0, 2},
+		{1, 2},
+		{2, 5}, // Skips the blank lines to the end of the next paragraph
+		{4, 5}, // Whitespace-only lines are blank
+		{5, 6}, // Last paragraph runs to the end of the document
+		{6, 6},
+	}
+	for _, tt := range tests {
+		if got := nextParagraph(paragraphLines, tt.from); got != tt.want {
+			t.Errorf("nextParagraph(%d) = %d, want %d", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestPrevParagraph(t *testing.T) {
+	tests := []struct{ from, want int }{
+		{6, 5},
+		{5, 3},
+		{4, 3},
+		{3, 0},
+		{1, 0},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		if got := prevParagraph(paragraphLines, tt.from); got != tt.want {
+			t.Errorf("prevParagraph(%d) = %d, want %d", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestParagraphKeys(t *testing.T) {
+	a := newTestApp("test.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = append([]string(nil), paragraphLines...)
+	eb.cursorCol = 3
+
+	a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: '}'})
+	if eb.cursorLine != 2 || eb.cursorCol != 0 {
+		t.Errorf("} moved to %d:%d, want 2:0", eb.cursorLine, eb.cursorCol)
+	}
+	a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: '}'})
+	a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: '}'})
+	if eb.cursorLine != 6 || eb.cursorCol != 16 {
+		t.Errorf("} at the last paragraph moved to %d:%d, want 6:16", eb.cursorLine, eb.cursorCol)
+	}
+	a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: '{'})
+	if eb.cursorLine != 5 {
+		t.Errorf("{ moved to line %d, want 5", eb.cursorLine)
+	}
+}
+
+func TestParagraphKeysExtendLineSelect(t *testing.T) {
+	a := newTestApp("test.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = append([]string(nil), paragraphLines...)
+
+	a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: 'V'})
+	a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: '}'})
+	if start, end := a.getSelectionRange(); start != 0 || end != 2 {
+		t.Errorf("V} selected lines %d-%d, want 0-2", start, end)
+	}
+}
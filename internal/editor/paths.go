@@ -0,0 +1,34 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// xdgDir returns $<envVar>/prose, falling back to ~/<fallback>/prose when the
+// variable is unset, following the XDG Base Directory Specification.
+func xdgDir(envVar, fallback string) string {
+	if dir := os.Getenv(envVar); dir != "" {
+		return filepath.Join(dir, "prose")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "prose")
+	}
+	return filepath.Join(home, fallback, "prose")
+}
+
+// configDir returns the directory holding user configuration.
+func configDir() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// dataDir returns the directory holding persistent user data.
+func dataDir() string {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// stateDir returns the directory holding session state (recovery files, history).
+func stateDir() string {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
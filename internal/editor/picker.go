@@ -2,14 +2,59 @@ package editor
 
 // Picker manages the buffer-switching overlay state.
 type Picker struct {
-	Active   bool
-	Selected int
+	Active       bool
+	Selected     int // Position in the list shown
+	ScrollOffset int // First buffer shown when the list is taller than the overlay
+	Filter       OverlayFilter
+	Shown        []int // Indexes of the buffers matching the filter; nil lists them all
 }
 
 // Show activates the picker with the given buffer pre-selected.
 func (p *Picker) Show(currentIndex int) {
 	p.Active = true
 	p.Selected = currentIndex
+	p.ScrollOffset = 0
+	p.Filter = OverlayFilter{}
+	p.Shown = nil
+}
+
+// Count returns how many of total buffers are listed.
+func (p *Picker) Count(total int) int {
+	if p.Shown == nil {
+		return total
+	}
+	return len(p.Shown)
+}
+
+// BufferAt returns the index of the buffer at position i of the list.
+func (p *Picker) BufferAt(i int) int {
+	if p.Shown == nil {
+		return i
+	}
+	return p.Shown[i]
+}
+
+// ApplyFilter lists the buffers whose names fuzzy match the filter and
+// keeps buffer idx selected if it is still listed, else the first one.
+func (p *Picker) ApplyFilter(buffers []*EditorBuffer, idx int) {
+	if p.Filter.Text == "" {
+		p.Shown = nil
+		p.Selected = 0
+		if idx >= 0 && idx < len(buffers) {
+			p.Selected = idx
+		}
+		return
+	}
+	p.Shown = []int{}
+	p.Selected = 0
+	for i, eb := range buffers {
+		if _, ok := fuzzyMatch(p.Filter.Text, pickerDisplayName(eb.Filename(), eb.isScratch)); ok {
+			if i == idx {
+				p.Selected = len(p.Shown)
+			}
+			p.Shown = append(p.Shown, i)
+		}
+	}
 }
 
 // Hide deactivates the picker.
@@ -30,3 +75,22 @@ func (p *Picker) MoveDown(max int) {
 		p.Selected++
 	}
 }
+
+// VisibleRange returns the [start, end) slice of a list of count buffers to
+// show in at most maxHeight rows, scrolling to keep the selection visible.
+func (p *Picker) VisibleRange(count, maxHeight int) (int, int) {
+	if p.Selected >= count {
+		p.Selected = count - 1
+	}
+	if p.Selected < 0 {
+		p.Selected = 0
+	}
+	if p.Selected < p.ScrollOffset {
+		p.ScrollOffset = p.Selected
+	}
+	if p.Selected >= p.ScrollOffset+maxHeight {
+		p.ScrollOffset = p.Selected - maxHeight + 1
+	}
+	p.ScrollOffset = max(0, min(p.ScrollOffset, count-maxHeight))
+	return p.ScrollOffset, min(count, p.ScrollOffset+maxHeight)
+}
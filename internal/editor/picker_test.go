@@ -1,6 +1,10 @@
 package editor
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
 
 func TestPickerShowHide(t *testing.T) {
 	p := &Picker{}
@@ -63,3 +67,34 @@ func TestPickerShowPreselectsCurrent(t *testing.T) {
 		t.Errorf("Selected = %d, want 5", p.Selected)
 	}
 }
+
+func TestPickerVisibleRange(t *testing.T) {
+	p := &Picker{Active: true, Selected: 0}
+	if start, end := p.VisibleRange(10, 4); start != 0 || end != 4 {
+		t.Errorf("VisibleRange = (%d, %d), want (0, 4)", start, end)
+	}
+	p.Selected = 9
+	if start, end := p.VisibleRange(10, 4); start != 6 || end != 10 {
+		t.Errorf("VisibleRange = (%d, %d), want (6, 10)", start, end)
+	}
+	// Growing the overlay (e.g. after a resize) pulls the window back.
+	if start, end := p.VisibleRange(10, 20); start != 0 || end != 10 {
+		t.Errorf("VisibleRange = (%d, %d), want (0, 10)", start, end)
+	}
+}
+
+func TestPickerEnterAfterClearingEmptyFilter(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.buffers = append(a.buffers, NewEditorBuffer("notes.md"))
+	a.currentBuffer = 1
+
+	// Filtering to nothing and clearing the filter selects a buffer again.
+	sendKeys(a, " t/qq\x1b")
+	if !a.picker.Active || a.picker.Selected != 1 {
+		t.Fatalf("picker active %v, selected %d; want the current buffer selected", a.picker.Active, a.picker.Selected)
+	}
+	sendKey(a, terminal.KeyEnter)
+	if a.picker.Active || a.currentBuffer != 1 {
+		t.Errorf("Enter should switch to the selected buffer, current %d", a.currentBuffer)
+	}
+}
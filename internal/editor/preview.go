@@ -0,0 +1,596 @@
+package editor
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// :preview shows the buffer beside itself as it will read: emphasis drawn
+// rather than marked, headings set apart by space and rules, lists with
+// bullets, and the syntax markers, link addresses, front matter and fences
+// hidden. It is a second window of a split that draws the focused buffer
+// afresh each frame, so it follows typing, and scrolls to keep the text
+// under the cursor in view.
+
+// previewStyle is a set of the styles a character of the preview is drawn in.
+type previewStyle int
+
+const (
+	previewBold previewStyle = 1 << iota
+	previewItalic
+	previewStrike
+	previewCode
+	previewLink
+	previewHeading
+	previewMuted
+)
+
+// code returns the SGR sequence that draws s in theme.
+func (s previewStyle) code(theme *Theme) string {
+	var b strings.Builder
+	for _, st := range []struct {
+		style previewStyle
+		code  string
+	}{
+		{previewHeading, theme.Heading},
+		{previewCode, theme.Code},
+		{previewLink, theme.Link},
+		{previewMuted, theme.Muted},
+		{previewBold, "\x1b[1m"},
+		{previewItalic, "\x1b[3m"},
+		{previewStrike, "\x1b[9m"},
+	} {
+		if s&st.style != 0 {
+			b.WriteString(st.code)
+		}
+	}
+	return b.String()
+}
+
+// previewChar is a character of the preview, with its style and its rune
+// offset in the block's source text.
+type previewChar struct {
+	r     rune
+	style previewStyle
+	pos   int
+}
+
+// previewInline renders inline Markdown: emphasis and code are styled and
+// their markers dropped, links show only their text and images their
+// description. Unpaired markers are kept as typed.
+func previewInline(text []rune, base previewStyle, offset int) []previewChar {
+	var out []previewChar
+	var on previewStyle // Emphasis opened in text
+	style := base
+	emit := func(r rune, st previewStyle, pos int) {
+		out = append(out, previewChar{r, st, offset + pos})
+	}
+	runAt := func(i int, r rune) int {
+		n := 0
+		for i+n < len(text) && text[i+n] == r {
+			n++
+		}
+		return n
+	}
+	for i := 0; i < len(text); i++ {
+		r := text[i]
+		switch {
+		case r == '\\' && i+1 < len(text) && strings.ContainsRune("\\`*_{}[]()#+-.!~|<>\"", text[i+1]):
+			i++
+			emit(text[i], style, i)
+		case r == '`':
+			n := runAt(i, '`')
+			end := indexRun(text, i+n, '`', n)
+			if end < 0 {
+				for j := 0; j < n; j++ {
+					emit('`', style, i+j)
+				}
+				i += n - 1
+				continue
+			}
+			start, stop := i+n, end
+			if stop-start >= 2 && text[start] == ' ' && text[stop-1] == ' ' {
+				start, stop = start+1, stop-1
+			}
+			for j := start; j < stop; j++ {
+				emit(text[j], style|previewCode, j)
+			}
+			i = end + n - 1
+		case r == '[' || r == '!' && i+1 < len(text) && text[i+1] == '[':
+			open := i
+			if r == '!' {
+				open++
+			}
+			close, ok := linkEnd(text, open)
+			if !ok {
+				emit(r, style, i)
+				continue
+			}
+			label := text[open+1 : close]
+			if r == '!' {
+				// An image shows as its description in brackets.
+				emit('[', style|previewMuted, open)
+				for j, c := range label {
+					emit(c, style|previewMuted|previewItalic, open+1+j)
+				}
+				emit(']', style|previewMuted, close)
+			} else {
+				out = append(out, previewInline(label, style|previewLink, offset+open+1)...)
+			}
+			i = closeParen(text, close+1)
+		case r == '*' || r == '_' || r == '~' && i+1 < len(text) && text[i+1] == '~':
+			n := runAt(i, r)
+			if r == '~' {
+				n = 2
+			}
+			toggle := emphasisToggle(text, i, n, on)
+			if toggle == 0 {
+				for j := 0; j < n; j++ {
+					emit(r, style, i+j)
+				}
+			}
+			on ^= toggle
+			style = base | on
+			i += n - 1
+		default:
+			emit(r, style, i)
+		}
+	}
+	return out
+}
+
+// indexRun returns the index in text, from start, of a run of exactly n
+// copies of r, or -1.
+func indexRun(text []rune, start int, r rune, n int) int {
+	for i := start; i < len(text); i++ {
+		if text[i] != r {
+			continue
+		}
+		j := i
+		for j < len(text) && text[j] == r {
+			j++
+		}
+		if j-i == n {
+			return i
+		}
+		i = j
+	}
+	return -1
+}
+
+// linkEnd returns the index of the ] closing the link label opened at
+// text[open], if it is followed by an address in brackets.
+func linkEnd(text []rune, open int) (int, bool) {
+	depth := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				return i, i+1 < len(text) && text[i+1] == '(' && closeParen(text, i+1) < len(text)
+			}
+		}
+	}
+	return 0, false
+}
+
+// closeParen returns the index of the ) closing the bracket at text[open],
+// or len(text).
+func closeParen(text []rune, open int) int {
+	for i := open + 1; i < len(text); i++ {
+		if text[i] == ')' {
+			return i
+		}
+	}
+	return len(text)
+}
+
+// emphasisToggle returns the styles a run of n emphasis markers at text[i]
+// turns on or off, given the emphasis on, or 0 if the run is text: it can
+// only open before a word and with a closing run to come, and only close
+// after one. Underscores inside words, as in snake_case, are text.
+func emphasisToggle(text []rune, i, n int, on previewStyle) previewStyle {
+	r := text[i]
+	var toggle previewStyle
+	switch {
+	case r == '~':
+		toggle = previewStrike
+	case n == 1:
+		toggle = previewItalic
+	case n == 2:
+		toggle = previewBold
+	case n == 3:
+		toggle = previewBold | previewItalic
+	default:
+		return 0
+	}
+	before, after := ' ', ' '
+	if i > 0 {
+		before = text[i-1]
+	}
+	if i+n < len(text) {
+		after = text[i+n]
+	}
+	inWord := func(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) }
+	if on&toggle == toggle {
+		// Closing.
+		if unicode.IsSpace(before) || r == '_' && inWord(after) {
+			return 0
+		}
+		return toggle
+	}
+	if on&toggle != 0 || unicode.IsSpace(after) || r == '_' && inWord(before) {
+		return 0
+	}
+	if indexRun(text, i+n+1, r, n) < 0 {
+		return 0
+	}
+	return toggle
+}
+
+// previewBuilder lays out the preview's rows.
+type previewBuilder struct {
+	width int
+	theme *Theme
+	rows  []DisplayLine
+}
+
+// blank adds an empty row, unless the preview is empty or already ends in
+// n of them.
+func (p *previewBuilder) blank(line, n int) {
+	if len(p.rows) == 0 {
+		return
+	}
+	have := 0
+	for i := len(p.rows) - 1; i >= 0 && p.rows[i].Text == ""; i-- {
+		have++
+	}
+	for ; have < n; have++ {
+		p.rows = append(p.rows, DisplayLine{BufferLine: line})
+	}
+}
+
+// row adds a row of text from source line line, in style.
+func (p *previewBuilder) row(line int, text string, style previewStyle) {
+	dl := DisplayLine{BufferLine: line, Text: text}
+	if code := style.code(p.theme); code != "" && text != "" {
+		dl.Styles = []StyleSpan{{Start: 0, End: len([]rune(text)), Code: code, Reset: "\x1b[0m"}}
+	}
+	p.rows = append(p.rows, dl)
+}
+
+// flow wraps chars to the preview's width, the first row after first and
+// the rest after indent, both drawn in prefixStyle. lines are the source
+// lines of the chars' text and starts the offset each begins at. A newline
+// breaks the row.
+func (p *previewBuilder) flow(chars []previewChar, first, indent string, prefixStyle previewStyle, lines, starts []int) {
+	source := func(pos int) int {
+		return lines[max(sort.SearchInts(starts, pos+1)-1, 0)]
+	}
+	prefix := first
+	var row []previewChar
+	emit := func() {
+		line := lines[0]
+		if len(row) > 0 {
+			line = source(row[0].pos)
+		}
+		dl := DisplayLine{BufferLine: line, Text: prefix}
+		n := len([]rune(prefix))
+		if code := prefixStyle.code(p.theme); code != "" && n > 0 {
+			dl.Styles = append(dl.Styles, StyleSpan{Start: 0, End: n, Code: code, Reset: "\x1b[0m"})
+		}
+		var text strings.Builder
+		for i := 0; i < len(row); {
+			j := i
+			for j < len(row) && row[j].style == row[i].style {
+				text.WriteRune(row[j].r)
+				j++
+			}
+			if code := row[i].style.code(p.theme); code != "" {
+				dl.Styles = append(dl.Styles, StyleSpan{Start: n + i, End: n + j, Code: code, Reset: "\x1b[0m"})
+			}
+			i = j
+		}
+		dl.Text += text.String()
+		p.rows = append(p.rows, dl)
+		prefix, row = indent, nil
+	}
+
+	width := max(p.width, len([]rune(indent))+1)
+	for i := 0; i < len(chars); {
+		if chars[i].r == '\n' {
+			emit()
+			i++
+			continue
+		}
+		if chars[i].r == ' ' {
+			if len(row) > 0 {
+				row = append(row, chars[i])
+			}
+			i++
+			continue
+		}
+		j := i
+		for j < len(chars) && chars[j].r != ' ' && chars[j].r != '\n' {
+			j++
+		}
+		used := len([]rune(prefix)) + len(row)
+		if used+j-i > width && len(row) > 0 {
+			for len(row) > 0 && row[len(row)-1].r == ' ' {
+				row = row[:len(row)-1]
+			}
+			emit()
+			continue
+		}
+		if room := width - len([]rune(prefix)) - len(row); j-i > room && room > 0 {
+			j = i + room // A word longer than the row is broken
+		}
+		row = append(row, chars[i:j]...)
+		i = j
+	}
+	for len(row) > 0 && row[len(row)-1].r == ' ' {
+		row = row[:len(row)-1]
+	}
+	if len(row) > 0 || len(chars) == 0 {
+		emit()
+	}
+}
+
+// paragraph flows lines, the source lines first..., as one paragraph. Lines
+// ending in two spaces or a backslash end a row.
+func (p *previewBuilder) paragraph(texts []string, first int, prefix, indent string, prefixStyle, style previewStyle) {
+	var joined []rune
+	var lines, starts []int
+	for i, text := range texts {
+		brk := i < len(texts)-1 && (strings.HasSuffix(text, "  ") || strings.HasSuffix(text, `\`))
+		text = strings.TrimSpace(text)
+		if brk {
+			text = strings.TrimSuffix(text, `\`)
+		}
+		if i > 0 {
+			joined = append(joined, ' ')
+		}
+		lines = append(lines, first+i)
+		starts = append(starts, len(joined))
+		joined = append(joined, []rune(text)...)
+		if brk {
+			joined = append(joined, '\n')
+		}
+	}
+	p.flow(previewInline(joined, style, 0), prefix, indent, prefixStyle, lines, starts)
+}
+
+// heading sets out a heading: a first-level one in capitals with a double
+// rule under it, a second-level one with a single rule, and the rest in
+// the heading colour, with space around each.
+func (p *previewBuilder) heading(line, level int, text string) {
+	p.blank(line, min(level, 2))
+	chars := previewInline([]rune(text), previewHeading|previewBold, 0)
+	if level == 1 {
+		for i := range chars {
+			chars[i].r = unicode.ToUpper(chars[i].r)
+		}
+	}
+	start := len(p.rows)
+	p.flow(chars, "", "", 0, []int{line}, []int{0})
+	width := 0
+	for _, dl := range p.rows[start:] {
+		width = max(width, len([]rune(dl.Text)))
+	}
+	switch level {
+	case 1:
+		p.row(line, strings.Repeat("═", width), previewHeading)
+	case 2:
+		p.row(line, strings.Repeat("─", width), previewHeading)
+	}
+	p.blank(line, 1)
+}
+
+// previewLines lays out lines for the preview, width columns wide.
+func previewLines(lines []string, width int, theme *Theme) []DisplayLine {
+	p := &previewBuilder{width: width, theme: theme}
+	first := frontMatterEnd(lines) + 1
+	states := ComputeBlockStates(lines)
+	var listIndents []int // Indents of the list items open, outermost first
+	itemIndent := ""      // Where the text of the last list item starts
+	for i := first; i < len(lines); {
+		line := lines[i]
+		if !states[i].InCode {
+			line = stripCriticMarkup(line)
+		}
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case states[i].InCode:
+			if states[i].Fence {
+				i++
+				continue
+			}
+			p.row(i, "  "+strings.ReplaceAll(line, "\t", "    "), previewCode)
+			if i+1 == len(lines) || !states[i+1].InCode || states[i+1].Fence {
+				p.blank(i, 1)
+			}
+			i++
+		case reHeadingATX.MatchString(line):
+			m := reHeadingATX.FindStringSubmatch(line)
+			p.heading(i, len(m[1]), reClosingHash.ReplaceAllString(m[2], ""))
+			listIndents = nil
+			i++
+		case setextLevel(lines, states, i) > 0:
+			p.heading(i, setextLevel(lines, states, i), strings.TrimSpace(line))
+			listIndents = nil
+			i += 2
+		case reSceneBreak.MatchString(line):
+			mark := "*   *   *"
+			p.blank(i, 1)
+			p.row(i, strings.Repeat(" ", max((width-len(mark))/2, 0))+mark, previewMuted)
+			p.blank(i, 1)
+			listIndents = nil
+			i++
+		case reBlockquote.MatchString(line):
+			j := i
+			var texts []string
+			for ; j < len(lines) && reBlockquote.MatchString(lines[j]); j++ {
+				text := stripCriticMarkup(reBlockquote.ReplaceAllString(lines[j], ""))
+				if strings.TrimSpace(text) == "" {
+					if len(texts) > 0 {
+						p.paragraph(texts, j-len(texts), "│ ", "│ ", previewMuted, previewItalic)
+						p.row(j, "│", previewMuted)
+					}
+					texts = nil
+					continue
+				}
+				texts = append(texts, text)
+			}
+			if len(texts) > 0 {
+				p.paragraph(texts, j-len(texts), "│ ", "│ ", previewMuted, previewItalic)
+			}
+			p.blank(j, 1)
+			i = j
+		case startsTable(lines, i):
+			j := i
+			for j < len(lines) && IsTableLine(lines[j]) {
+				j++
+			}
+			for k, row := range AlignTable(lines[i:j]) {
+				if k == 1 {
+					row = strings.NewReplacer("-", "─", ":", "─", " ", "─", "|", "┼").Replace(row)
+				} else {
+					row = strings.ReplaceAll(row, "|", "│")
+				}
+				p.row(i+k, row, 0)
+			}
+			p.blank(j, 1)
+			i = j
+		case reListItem.MatchString(line):
+			m := reListItem.FindStringSubmatch(line)
+			indent := indentWidth(m[1])
+			for len(listIndents) > 0 && listIndents[len(listIndents)-1] >= indent {
+				listIndents = listIndents[:len(listIndents)-1]
+			}
+			listIndents = append(listIndents, indent)
+			depth := len(listIndents) - 1
+			marker := []string{"•", "◦", "▪"}[depth%3]
+			if unicode.IsDigit(rune(m[2][0])) {
+				marker = m[2]
+			}
+			switch strings.ToLower(strings.TrimSpace(m[4])) {
+			case "[ ]":
+				marker += " ☐"
+			case "[x]":
+				marker += " ☑"
+			}
+			pad := strings.Repeat("  ", depth)
+			itemIndent = pad + strings.Repeat(" ", len([]rune(marker))+1)
+			texts := []string{line[len(m[0]):]}
+			j := i + 1
+			for j < len(lines) && !states[j].InCode && !endsParagraph(lines, j) {
+				texts = append(texts, stripCriticMarkup(lines[j]))
+				j++
+			}
+			p.paragraph(texts, i, pad+marker+" ", itemIndent, previewMuted, 0)
+			if j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+				p.blank(j, 1)
+			}
+			i = j
+		default:
+			indent := ""
+			if len(listIndents) > 0 && indentWidth(leadingSpace(line)) > listIndents[0] {
+				indent = itemIndent // A paragraph continuing a list item
+			} else {
+				listIndents = nil
+			}
+			j := i + 1
+			texts := []string{line}
+			for j < len(lines) && !states[j].InCode && !endsParagraph(lines, j) {
+				texts = append(texts, stripCriticMarkup(lines[j]))
+				j++
+			}
+			p.paragraph(texts, i, indent, indent, 0, 0)
+			p.blank(j, 1)
+			i = j
+		}
+	}
+	for len(p.rows) > 0 && p.rows[len(p.rows)-1].Text == "" {
+		p.rows = p.rows[:len(p.rows)-1]
+	}
+	if len(p.rows) == 0 {
+		p.rows = []DisplayLine{{}}
+	}
+	return p.rows
+}
+
+// previewRow returns the row of the preview showing buffer line line: the
+// first drawn from it, else the last drawn from a line before it.
+func previewRow(rows []DisplayLine, line int) int {
+	row := 0
+	for i, dl := range rows {
+		if dl.BufferLine > line {
+			break
+		}
+		if dl.Text == "" {
+			continue
+		}
+		if dl.BufferLine == line {
+			return i
+		}
+		row = i
+	}
+	return row
+}
+
+// togglePreview opens the preview beside the focused window, or under it
+// when the terminal is too narrow, or closes it. It takes the place of any
+// other split.
+func (a *App) togglePreview() {
+	if a.split.Preview {
+		a.closeOtherWindow()
+		return
+	}
+	prev := a.split
+	for _, layout := range []SplitLayout{SplitSideBySide, SplitStacked} {
+		a.split = Split{Layout: layout, Preview: true}
+		if first, second := a.paneViewports(); !first.TooSmall() && !second.TooSmall() {
+			a.applyLayout()
+			return
+		}
+	}
+	a.split = prev
+	a.statusBar.SetMessage("Terminal too small to preview")
+}
+
+// previewViewport returns the preview window's viewport.
+func (a *App) previewViewport() *Viewport {
+	vp := a.otherViewport()
+	vp.SetGutter(0)
+	return vp
+}
+
+// renderPreview draws the preview of the focused buffer in the other
+// window, scrolled to the text under the cursor.
+func (a *App) renderPreview() string {
+	eb := a.currentBuf()
+	vp := a.previewViewport()
+	rows := previewLines(eb.buf.Lines, vp.ColWidth, a.renderer.theme)
+	w := &a.split.Other
+	vp.EnsureCursorVisible(previewRow(rows, eb.cursorLine), &w.scrollOffset)
+	w.scrollOffset = max(0, min(w.scrollOffset, len(rows)-1))
+	return a.renderer.RenderFrame(rows, vp, w.scrollOffset, -1, 0, " Preview", "", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, 0)
+}
+
+// clickPreview moves the cursor to the start of the line drawn at a screen
+// row of the preview.
+func (a *App) clickPreview(row int) {
+	eb := a.currentBuf()
+	vp := a.previewViewport()
+	rows := previewLines(eb.buf.Lines, vp.ColWidth, a.renderer.theme)
+	top, _ := vp.Padding(a.split.Other.scrollOffset)
+	idx := a.split.Other.scrollOffset + row - vp.Top - 1 - top
+	if idx < 0 || idx >= len(rows) {
+		return
+	}
+	eb.cursorLine = min(rows[idx].BufferLine, eb.buf.LineCount()-1)
+	eb.cursorCol = 0
+}
@@ -0,0 +1,187 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// previewText returns the characters of cs, with those in style in brackets.
+func previewText(cs []previewChar, style previewStyle) string {
+	var b strings.Builder
+	in := false
+	for _, c := range cs {
+		if on := c.style&style != 0; on != in {
+			b.WriteString(map[bool]string{true: "[", false: "]"}[on])
+			in = on
+		}
+		b.WriteRune(c.r)
+	}
+	if in {
+		b.WriteString("]")
+	}
+	return b.String()
+}
+
+func TestPreviewInline(t *testing.T) {
+	tests := []struct {
+		in    string
+		style previewStyle
+		want  string
+	}{
+		{"a **bold** word", previewBold, "a [bold] word"},
+		{"a *it* and _it_", previewItalic, "a [it] and [it]"},
+		{"***both***", previewBold, "[both]"},
+		{"**bold *and* bold**", previewItalic, "bold [and] bold"},
+		{"snake_case_name", previewItalic, "snake_case_name"},
+		{"2 * 3 * 4", previewItalic, "2 * 3 * 4"},
+		{"an *unclosed emphasis", previewItalic, "an *unclosed emphasis"},
+		{"~~gone~~ here", previewStrike, "[gone] here"},
+		{"run `a *b*` now", previewCode, "run [a *b*] now"},
+		{"see [the *site*](http://x.org) ok", previewLink, "see [the site] ok"},
+		{"a ![a cat](cat.png) b", previewItalic, "a [[a cat]] b"},
+		{`\*not\* [not a link]`, previewItalic, "*not* [not a link]"},
+	}
+	for _, tt := range tests {
+		if got := previewText(previewInline([]rune(tt.in), 0, 0), tt.style); got != tt.want {
+			t.Errorf("previewInline(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPreviewInlineInItalicBase(t *testing.T) {
+	// Emphasis inside an italic quote still hides its markers.
+	got := previewInline([]rune("a *b* c"), previewItalic, 0)
+	if text := previewText(got, 0); text != "a b c" {
+		t.Errorf("text = %q", text)
+	}
+	for _, c := range got {
+		if c.style&previewItalic == 0 {
+			t.Errorf("%q lost the base style", c.r)
+		}
+	}
+}
+
+func TestPreviewLines(t *testing.T) {
+	lines := []string{
+		"---", "title: T", "---", // 0-2
+		"# Chapter one", // 3
+		"",
+		"Some *text* that wraps", // 5
+		"over {++two++} lines.",  // 6
+		"",
+		"Part", // 8
+		"----",
+		"",
+		"- one", // 11
+		"  - [x] two",
+		"3. three",
+		"",
+		"> quoted", // 15
+		"",
+		"```", // 17
+		"code",
+		"```",
+		"* * *", // 20
+		"| a | b |",
+		"|---|---|",
+		"| 1 | 2 |",
+	}
+	type row struct {
+		line int
+		text string
+	}
+	want := []row{
+		{3, "CHAPTER ONE"}, {3, "═══════════"}, {3, ""},
+		{5, "Some text that"}, {5, "wraps over two"}, {6, "lines."}, {7, ""},
+		{8, ""}, {8, "Part"}, {8, "────"}, {8, ""},
+		{11, "• one"}, {12, "  ◦ ☑ two"}, {13, "3. three"}, {14, ""},
+		{15, "│ quoted"}, {16, ""},
+		{18, "  code"}, {18, ""},
+		{20, "   *   *   *"}, {20, ""},
+		{21, "│ a   │ b   │"}, {22, "┼─────┼─────┼"}, {23, "│ 1   │ 2   │"},
+	}
+	got := previewLines(lines, 16, &darkTheme)
+	if len(got) != len(want) {
+		for _, dl := range got {
+			t.Logf("%d %q", dl.BufferLine, dl.Text)
+		}
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].BufferLine != w.line || got[i].Text != w.text {
+			t.Errorf("row %d = %d %q, want %d %q", i, got[i].BufferLine, got[i].Text, w.line, w.text)
+		}
+	}
+	if styles := got[3].Styles; len(styles) != 1 || styles[0].Start != 5 || styles[0].End != 9 || styles[0].Code != "\x1b[3m" {
+		t.Errorf("emphasis styles = %+v", styles)
+	}
+}
+
+func TestPreviewRow(t *testing.T) {
+	rows := previewLines([]string{"# Title", "", "First para", "", "", "Second para"}, 40, &darkTheme)
+	for _, tt := range []struct{ line, want int }{{0, 0}, {1, 1}, {2, 3}, {4, 3}, {5, 5}} {
+		if got := previewRow(rows, tt.line); got != tt.want {
+			t.Errorf("previewRow(%d) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTogglePreview(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.buffers[0].buf.Lines = []string{"# Title", "", "Some **bold** text."}
+	a.executeCommand("preview")
+	if a.split.Layout != SplitSideBySide || !a.split.Preview || a.split.Second {
+		t.Fatalf("split = %+v, want a preview beside the text", a.split)
+	}
+	frame := a.renderPreview()
+	if !strings.Contains(frame, "TITLE") || !strings.Contains(frame, "\x1b[1mbold") || strings.Contains(frame, "**") {
+		t.Errorf("preview frame = %q", frame)
+	}
+
+	// The preview can't take focus, but a click on it moves to the line.
+	a.switchWindow()
+	if a.split.Second || !strings.Contains(a.statusBar.StatusMessage, ":preview closes it") {
+		t.Errorf("switching to the preview: split %+v, message %q", a.split, a.statusBar.StatusMessage)
+	}
+	vp := a.previewViewport()
+	rows := previewLines(a.currentBuf().buf.Lines, vp.ColWidth, a.renderer.theme)
+	top, _ := vp.Padding(0)
+	a.handleMouse(terminal.MouseEvent{Button: terminal.MouseLeft, Press: true, Row: vp.Top + 1 + top + previewRow(rows, 2), Col: vp.Left + vp.LeftMargin + 2})
+	if eb := a.currentBuf(); eb.cursorLine != 2 || a.split.Second {
+		t.Errorf("clicking the preview: cursor line %d, split %+v", eb.cursorLine, a.split)
+	}
+
+	a.executeCommand("preview")
+	if a.split.Layout != SplitNone {
+		t.Errorf("second :preview should close it, split = %+v", a.split)
+	}
+
+	// :q closes the preview, and :vsplit replaces it.
+	a.executeCommand("preview")
+	a.executeCommand("q")
+	if a.split.Layout != SplitNone || len(a.buffers) != 1 {
+		t.Errorf(":q in a preview: split %+v, %d buffers", a.split, len(a.buffers))
+	}
+	a.executeCommand("preview")
+	a.executeCommand("vsplit")
+	if a.split.Preview || a.split.Other.buffer != a.currentBuf() {
+		t.Errorf(":vsplit should replace the preview, split = %+v", a.split)
+	}
+}
+
+func TestTogglePreviewNarrow(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.termWidth, a.termHeight = 30, 24
+	a.executeCommand("preview")
+	if a.split.Layout != SplitStacked || !a.split.Preview {
+		t.Errorf("narrow terminal: split = %+v, want a preview below", a.split)
+	}
+	a.executeCommand("preview")
+	a.termWidth, a.termHeight = 30, 8
+	a.executeCommand("preview")
+	if a.split.Layout != SplitNone || a.statusBar.StatusMessage != "Terminal too small to preview" {
+		t.Errorf("tiny terminal: split %+v, message %q", a.split, a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,283 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// projectFileName is the file marking a project's root directory.
+const projectFileName = ".prose-project"
+
+// Project describes a manuscript split across files, read from a
+// .prose-project file. Paths in the file are relative to its directory.
+type Project struct {
+	Root         string      // Directory holding the project file
+	Name         string      // Shown in messages; defaults to the directory name
+	Chapters     []string    // Chapter files in manuscript order, as absolute paths
+	Output       string      // Where :project build writes the manuscript
+	Separator    string      // Line put between chapters; empty for none
+	Shift        int         // Levels to move chapter headings down by when building
+	Dictionary   string      // Word list added to the spell checker
+	KnownNames   []string    // Names the name check treats as distinct
+	HeadingCase  headingCase // Style :headcase uses by default
+	Bibliography string      // BibTeX or CSL-JSON file citations are checked against
+}
+
+// FindProject looks for a project file in dir and each of its parents. It
+// returns nil when there is none.
+func FindProject(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.Open(filepath.Join(dir, projectFileName))
+		if err == nil {
+			defer f.Close()
+			return parseProject(f, dir)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// parseProject reads a project file in the config file's "key = value"
+// format. Each chapter gets its own "chapter = file" line. On errors the
+// valid settings are still applied and the problems returned.
+func parseProject(r io.Reader, root string) (*Project, error) {
+	p := &Project{
+		Root:   root,
+		Name:   filepath.Base(root),
+		Output: filepath.Join(root, "manuscript.md"),
+	}
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("line %d: expected key = value", n))
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if err := p.set(key, value); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", n, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return p, errors.Join(errs...)
+}
+
+// set applies one project setting.
+func (p *Project) set(key, value string) error {
+	if value == "" && key != "separator" {
+		return fmt.Errorf("%s: missing value", key)
+	}
+	switch key {
+	case "name":
+		p.Name = value
+	case "chapter":
+		p.Chapters = append(p.Chapters, p.path(value))
+	case "output":
+		p.Output = p.path(value)
+	case "separator":
+		p.Separator = value
+	case "shift_headings":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: want a whole number, got %q", key, value)
+		}
+		p.Shift = n
+	case "dictionary":
+		p.Dictionary = p.path(value)
+	case "bibliography":
+		p.Bibliography = p.path(value)
+	case "heading_case":
+		style, err := parseHeadingCase(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		p.HeadingCase = style
+	case "known_names":
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				p.KnownNames = append(p.KnownNames, name)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// path resolves a path from the project file against the project root.
+func (p *Project) path(name string) string {
+	name = expandHome(name)
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(p.Root, name)
+}
+
+// DictionaryWords reads the project word list: one word per line, with blank
+// lines and lines starting with '#' ignored.
+func (p *Project) DictionaryWords() ([]string, error) {
+	if p.Dictionary == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(p.Dictionary)
+	if err != nil {
+		return nil, err
+	}
+	var words []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, nil
+}
+
+// FilterClashes drops name clashes between two of the project's known names.
+func (p *Project) FilterClashes(clashes []NameClash) []NameClash {
+	known := make(map[string]bool, len(p.KnownNames))
+	for _, name := range p.KnownNames {
+		known[name] = true
+	}
+	kept := clashes[:0]
+	for _, c := range clashes {
+		if !known[c.Common.Name] || !known[c.Rare.Name] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// CompileOptions returns the project's settings for joining its chapters.
+func (p *Project) CompileOptions() CompileOptions {
+	return CompileOptions{Separator: p.Separator, Shift: p.Shift}
+}
+
+// loadProject finds the project for the current buffer's directory, or the
+// working directory for an unnamed buffer, and adds its dictionary to the
+// spell checker.
+func (a *App) loadProject() error {
+	dir := "."
+	if name := a.currentBuf().buf.Filename; name != "" {
+		dir = filepath.Dir(name)
+	}
+	project, err := FindProject(dir)
+	a.project = project
+	return errors.Join(err, a.addProjectWords())
+}
+
+// addProjectWords adds the project's dictionary to the spell checker, once
+// both have been loaded.
+func (a *App) addProjectWords() error {
+	if a.project == nil || a.spellChecker == nil {
+		return nil
+	}
+	words, err := a.project.DictionaryWords()
+	a.spellChecker.AddWords(words)
+	return err
+}
+
+// projectFiles returns the files the project outline covers and the
+// directory their names are shown relative to: the project's chapters, or
+// without a project the Markdown files beside the current file.
+func (a *App) projectFiles() ([]string, string, error) {
+	if err := a.loadProject(); a.project != nil {
+		return a.project.Chapters, a.project.Root, nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	dir := "."
+	if name := a.currentBuf().buf.Filename; name != "" {
+		dir = filepath.Dir(name)
+	}
+	dir = absPath(dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") && IsMarkdownFile(e.Name()) {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files, dir, nil
+}
+
+// projectCommand runs :project, which reloads the project file and reports
+// on it, and :project build.
+func (a *App) projectCommand(arg string) {
+	err := a.loadProject()
+	if err != nil {
+		a.statusBar.SetError("Project: " + strings.ReplaceAll(err.Error(), "\n", "; "))
+		if a.project == nil {
+			return
+		}
+	}
+	p := a.project
+	if p == nil {
+		a.statusBar.SetMessage("No " + projectFileName + " file found")
+		return
+	}
+	switch arg {
+	case "":
+		if err == nil {
+			a.statusBar.SetMessage(fmt.Sprintf("Project %s: %d chapter(s)", p.Name, len(p.Chapters)))
+		}
+	case "build":
+		a.buildProject()
+	default:
+		a.statusBar.SetMessage("Unknown project command: " + arg)
+	}
+}
+
+// buildProject writes the project's chapters to its output file.
+func (a *App) buildProject() {
+	p := a.project
+	if len(p.Chapters) == 0 {
+		a.statusBar.SetMessage("Project " + p.Name + " has no chapters")
+		return
+	}
+	chapters, err := a.readChapters(p.Chapters)
+	if err != nil {
+		a.statusBar.SetError("Build failed: " + err.Error())
+		return
+	}
+	out := CompileManuscript(chapters, p.CompileOptions())
+	if err := os.WriteFile(p.Output, []byte(strings.Join(out, "\n")+"\n"), 0644); err != nil {
+		a.statusBar.SetError("Build failed: " + err.Error())
+		return
+	}
+	rel, err := filepath.Rel(p.Root, p.Output)
+	if err != nil {
+		rel = p.Output
+	}
+	a.statusBar.SetMessage(fmt.Sprintf("Built %s: %d chapter(s) to %s", p.Name, len(p.Chapters), rel))
+}
@@ -0,0 +1,145 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProject(t *testing.T) {
+	input := `# My novel
+name = The Long Road
+chapter = chapters/01-departure.md
+chapter = chapters/02-arrival.md   # still drafting
+output = build/road.md
+separator = * * *
+shift_headings = 1
+dictionary = words.txt
+known_names = Anna, Hanna
+heading_case = sentence
+bibliography = refs.bib
+`
+	p, err := parseProject(strings.NewReader(input), "/books/road")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Project{
+		Root:         "/books/road",
+		Name:         "The Long Road",
+		Chapters:     []string{"/books/road/chapters/01-departure.md", "/books/road/chapters/02-arrival.md"},
+		Output:       "/books/road/build/road.md",
+		Separator:    "* * *",
+		Shift:        1,
+		Dictionary:   "/books/road/words.txt",
+		KnownNames:   []string{"Anna", "Hanna"},
+		HeadingCase:  caseSentence,
+		Bibliography: "/books/road/refs.bib",
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("parseProject = %+v, want %+v", p, want)
+	}
+}
+
+func TestParseProjectDefaults(t *testing.T) {
+	p, err := parseProject(strings.NewReader("chapter = one.md\n"), "/books/road")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "road" || p.Output != "/books/road/manuscript.md" {
+		t.Errorf("defaults: name %q, output %q", p.Name, p.Output)
+	}
+}
+
+func TestParseProjectErrors(t *testing.T) {
+	p, err := parseProject(strings.NewReader("chapter =\ncolour = blue\nchapter = ok.md\n"), "/r")
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{"line 1", `unknown setting "colour"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+	if len(p.Chapters) != 1 {
+		t.Errorf("valid chapters should still apply, got %v", p.Chapters)
+	}
+}
+
+func TestFindProject(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "chapters", "part1")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, projectFileName), []byte("name = Road\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := FindProject(sub)
+	if err != nil || p == nil {
+		t.Fatalf("FindProject = %v, %v", p, err)
+	}
+	if p.Name != "Road" || p.Root != root {
+		t.Errorf("found %+v", p)
+	}
+
+	p, err = FindProject(t.TempDir())
+	if p != nil || err != nil {
+		t.Errorf("no project file: got %v, %v", p, err)
+	}
+}
+
+func TestProjectFilterClashes(t *testing.T) {
+	p := &Project{KnownNames: []string{"Anna", "Hanna"}}
+	clashes := []NameClash{
+		{Common: NameVariant{Name: "Anna"}, Rare: NameVariant{Name: "Hanna"}},
+		{Common: NameVariant{Name: "Katherine"}, Rare: NameVariant{Name: "Katharine"}},
+	}
+	got := p.FilterClashes(clashes)
+	if len(got) != 1 || got[0].Common.Name != "Katherine" {
+		t.Errorf("FilterClashes = %+v", got)
+	}
+}
+
+func TestProjectBuildCommand(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		projectFileName: "name = Road\nchapter = one.md\nchapter = two.md\noutput = out/road.md\n",
+		"one.md":        "# One\n\nOn disk.\n",
+		"two.md":        "# Two\n",
+	}
+	if err := os.Mkdir(filepath.Join(root, "out"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The open chapter's unsaved text is used.
+	a := newTestApp(filepath.Join(root, "two.md"))
+	a.currentBuf().buf.Lines = []string{"# Two", "", "Unsaved."}
+	a.executeCommand("project build")
+
+	data, err := os.ReadFile(filepath.Join(root, "out", "road.md"))
+	if err != nil {
+		t.Fatalf("output not written: %v (%s)", err, a.statusBar.StatusMessage)
+	}
+	want := "# One\n\nOn disk.\n\n# Two\n\nUnsaved.\n"
+	if string(data) != want {
+		t.Errorf("manuscript = %q, want %q", data, want)
+	}
+	if !strings.Contains(a.statusBar.StatusMessage, "Built Road: 2 chapter(s)") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestProjectCommandNoProject(t *testing.T) {
+	a := newTestApp(filepath.Join(t.TempDir(), "draft.md"))
+	a.executeCommand("project")
+	if !strings.Contains(a.statusBar.StatusMessage, "No .prose-project") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,283 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// quitChoice is what happens to a buffer with unsaved changes on quitting.
+type quitChoice int
+
+const (
+	quitUndecided quitChoice = iota // Unnamed and scratch buffers can't be saved as they are
+	quitSave
+	quitDiscard
+)
+
+// QuitSummary manages the overlay :qa opens when buffers have unsaved
+// changes, choosing for each whether to save or discard it before quitting.
+type QuitSummary struct {
+	ListView[*EditorBuffer]
+	Choices []quitChoice // For each of Items
+}
+
+// Show activates the overlay for the given buffers. Those that can be
+// saved start out marked to be saved.
+func (q *QuitSummary) Show(buffers []*EditorBuffer) {
+	q.ListView.Show(buffers)
+	q.Choices = make([]quitChoice, len(buffers))
+	for i, eb := range buffers {
+		if canSaveOnQuit(eb) == nil {
+			q.Choices[i] = quitSave
+		}
+	}
+}
+
+// Hide deactivates the overlay.
+func (q *QuitSummary) Hide() {
+	q.ListView.Hide()
+	q.Choices = nil
+}
+
+// Label formats item i for the overlay, e.g. "save     chapter-1.md".
+func (q *QuitSummary) Label(i int) string {
+	choice := "?"
+	switch q.Choices[i] {
+	case quitSave:
+		choice = "save"
+	case quitDiscard:
+		choice = "discard"
+	}
+	return fmt.Sprintf("%-8s %s", choice, quitName(q.Items[i]))
+}
+
+// quitName names a buffer in the quit summary.
+func quitName(eb *EditorBuffer) string {
+	if name := eb.Filename(); name != "" {
+		return name
+	}
+	return "[unnamed]"
+}
+
+// canSaveOnQuit returns why a buffer can't be saved from the quit summary,
+// or nil if it can.
+func canSaveOnQuit(eb *EditorBuffer) error {
+	switch {
+	case eb.isScratch:
+		return fmt.Errorf("the scratch buffer can't be saved")
+	case eb.buf.Filename == "":
+		return fmt.Errorf("%s has no file name; save it with :w <file>", quitName(eb))
+	case eb.readOnly:
+		return fmt.Errorf("%s is read-only", quitName(eb))
+	}
+	return nil
+}
+
+// dirtyBuffers returns the buffers with unsaved changes.
+func (a *App) dirtyBuffers() []*EditorBuffer {
+	var dirty []*EditorBuffer
+	for _, eb := range a.buffers {
+		if eb.buf.Dirty {
+			dirty = append(dirty, eb)
+		}
+	}
+	return dirty
+}
+
+// quitAll runs :qa. With unsaved changes it shows the quit summary, or
+// when quit_summary is off, lists the buffers in the status bar.
+func (a *App) quitAll() {
+	dirty := a.dirtyBuffers()
+	if len(dirty) == 0 {
+		a.quit = true
+		return
+	}
+	if a.config.QuitSummary {
+		a.quitSummary.Show(dirty)
+		return
+	}
+	names := make([]string, len(dirty))
+	for i, eb := range dirty {
+		names[i] = quitName(eb)
+	}
+	a.statusBar.SetMessage(fmt.Sprintf("Unsaved changes in %d buffer(s): %s. Use :qa! to discard.",
+		len(dirty), strings.Join(names, ", ")))
+}
+
+// chooseQuit sets what happens to the selected buffer and moves to the next.
+func (a *App) chooseQuit(choice quitChoice) {
+	q := a.quitSummary
+	if choice == quitSave {
+		if err := canSaveOnQuit(q.Items[q.Selected]); err != nil {
+			a.statusBar.SetError("Can't save: " + err.Error())
+			return
+		}
+	}
+	q.Choices[q.Selected] = choice
+	q.MoveDown()
+}
+
+// confirmQuit saves the buffers marked to be saved and quits. If a save
+// fails the summary stays open, without the buffers that were saved.
+func (a *App) confirmQuit() {
+	q := a.quitSummary
+	for i, choice := range q.Choices {
+		if choice == quitUndecided {
+			q.Selected = i
+			a.statusBar.SetMessage("Choose save or discard for " + quitName(q.Items[i]))
+			return
+		}
+	}
+	var items []*EditorBuffer
+	var choices []quitChoice
+	var failures []string
+	for i, eb := range q.Items {
+		if q.Choices[i] != quitSave {
+			continue
+		}
+		if err := eb.Save(""); err != nil {
+			a.errorLog("save failed", err, "file", eb.Filename())
+			failures = append(failures, eb.Filename()+": "+err.Error())
+			items = append(items, eb)
+			choices = append(choices, quitSave)
+		}
+	}
+	if len(failures) > 0 {
+		q.Items, q.Choices = items, choices
+		q.Selected, q.ScrollOffset = 0, 0
+		a.statusBar.SetError("Save failed: " + strings.Join(failures, "; "))
+		return
+	}
+	q.Hide()
+	a.quit = true
+}
+
+// writeQuitAll runs :wqa, saving every buffer with unsaved changes and
+// quitting. Unnamed buffers are then shown one at a time with a Save as
+// prompt; cancelling one cancels the quit. Scratch buffers are never saved.
+func (a *App) writeQuitAll() {
+	var failures []string
+	a.saveAsQueue = nil
+	for _, eb := range a.dirtyBuffers() {
+		switch {
+		case eb.isScratch:
+		case a.writeToStdout(eb):
+		case eb.buf.Filename == "":
+			a.saveAsQueue = append(a.saveAsQueue, eb)
+		case eb.readOnly:
+			failures = append(failures, eb.Filename()+": read-only")
+		default:
+			if err := eb.Save(""); err != nil {
+				failures = append(failures, eb.Filename()+": "+err.Error())
+				a.errorLog("save failed", err, "file", eb.Filename())
+			}
+		}
+	}
+	if len(failures) > 0 {
+		a.saveAsQueue = nil
+		a.statusBar.SetError(fmt.Sprintf("Save failed: %s", strings.Join(failures, "; ")))
+		return
+	}
+	a.promptQuitSaveAs()
+}
+
+// writeAll runs :wa, saving every dirty buffer with a name. Unnamed
+// buffers are left for :w.
+func (a *App) writeAll() {
+	var failures []string
+	unnamed := 0
+	for _, eb := range a.dirtyBuffers() {
+		switch {
+		case eb.isScratch:
+		case a.writeToStdout(eb):
+		case eb.buf.Filename == "":
+			unnamed++
+		case eb.readOnly:
+			failures = append(failures, eb.Filename()+": read-only")
+		default:
+			if err := eb.Save(""); err != nil {
+				failures = append(failures, eb.Filename()+": "+err.Error())
+				a.errorLog("save failed", err, "file", eb.Filename())
+			}
+		}
+	}
+	a.recordProjectWords(time.Now())
+	switch {
+	case len(failures) > 0:
+		a.statusBar.SetError(fmt.Sprintf("Save failed: %s", strings.Join(failures, "; ")))
+	case unnamed == 1:
+		a.statusBar.SetMessage("1 unnamed buffer not saved")
+	case unnamed > 1:
+		a.statusBar.SetMessage(fmt.Sprintf("%d unnamed buffers not saved", unnamed))
+	}
+}
+
+// promptQuitSaveAs shows the next unnamed buffer :wqa must save and asks
+// for its name, or quits once all are saved.
+func (a *App) promptQuitSaveAs() {
+	if len(a.saveAsQueue) == 0 {
+		a.quit = true
+		return
+	}
+	a.currentBuffer = a.bufferIndex(a.saveAsQueue[0])
+	a.statusBar.StartPrompt(PromptSaveNew)
+}
+
+// handleQuitSaveAsKey handles the Save as prompt for :wqa. An empty name
+// asks again; Esc cancels quitting.
+func (a *App) handleQuitSaveAsKey(key terminal.Key) {
+	text, done, cancelled := a.statusBar.HandlePromptKey(key)
+	if cancelled {
+		a.saveAsQueue = nil
+		a.statusBar.SetMessage("Quit cancelled")
+		return
+	}
+	if !done {
+		return
+	}
+	eb := a.saveAsQueue[0]
+	if text == "" {
+		a.promptQuitSaveAs()
+		return
+	}
+	if err := eb.Save(text); err != nil {
+		a.errorLog("save failed", err, "file", text)
+		eb.buf.Filename = ""
+		a.statusBar.SetError("Save failed: " + err.Error())
+		a.promptQuitSaveAs()
+		return
+	}
+	eb.highlighter = DetectHighlighter(eb.buf.Filename)
+	a.relockBuffer(eb)
+	a.saveAsQueue = a.saveAsQueue[1:]
+	a.promptQuitSaveAs()
+}
+
+func (a *App) handleQuitSummaryKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.quitSummary.Hide()
+	case terminal.KeyUp:
+		a.quitSummary.MoveUp()
+	case terminal.KeyDown:
+		a.quitSummary.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.quitSummary.MoveUp()
+		case 'j':
+			a.quitSummary.MoveDown()
+		case 's':
+			a.chooseQuit(quitSave)
+		case 'd':
+			a.chooseQuit(quitDiscard)
+		case 'c':
+			a.quitSummary.Hide()
+		}
+	case terminal.KeyEnter:
+		a.confirmQuit()
+	}
+}
@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// newQuitTestApp returns an app with the quit summary on and three buffers
+// in a temp dir, the first and last with unsaved changes, and the dir.
+func newQuitTestApp(t *testing.T) (*App, string) {
+	t.Helper()
+	a, dir := newTempTestApp(t, "one.md", "two.md", "three.md")
+	a.config.QuitSummary = true
+	for _, i := range []int{0, 2} {
+		a.buffers[i].buf.Lines = []string{"Edited"}
+		a.buffers[i].buf.Dirty = true
+	}
+	return a, dir
+}
+
+func TestQuitSummaryShowsDirtyBuffers(t *testing.T) {
+	a, _ := newQuitTestApp(t)
+	a.executeCommand("qa")
+	if a.quit || !a.quitSummary.Active {
+		t.Fatal(":qa with unsaved changes should open the quit summary")
+	}
+	if len(a.quitSummary.Items) != 2 || !strings.HasPrefix(a.quitSummary.Label(0), "save     ") || !strings.HasSuffix(a.quitSummary.Label(0), "one.md") {
+		t.Errorf("summary should list the dirty buffers, got %d, %q", len(a.quitSummary.Items), a.quitSummary.Label(0))
+	}
+
+	a.viewport = NewViewport(80, 24)
+	frame := a.renderer.RenderQuitSummary(a.quitSummary, a.viewport)
+	if !strings.Contains(frame, "Unsaved: s save, d discard, Enter quit") || !strings.Contains(frame, "three.md") {
+		t.Errorf("overlay should list the buffers: %q", frame)
+	}
+
+	sendKeys(a, "\x1b")
+	if a.quitSummary.Active || a.quit {
+		t.Error("Esc should cancel quitting")
+	}
+}
+
+func TestQuitSummarySaveAndDiscard(t *testing.T) {
+	a, dir := newQuitTestApp(t)
+	a.executeCommand("qa")
+	sendKeys(a, "sd") // Save one.md, discard three.md
+	sendKey(a, terminal.KeyEnter)
+
+	if !a.quit {
+		t.Fatalf("Enter should quit: %s", a.statusBar.StatusMessage)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "one.md")); err != nil || string(data) != "Edited\n" {
+		t.Errorf("one.md should be saved, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "three.md")); !os.IsNotExist(err) {
+		t.Errorf("three.md should be discarded, got %v", err)
+	}
+}
+
+func TestQuitSummaryUnnamedBuffer(t *testing.T) {
+	a := newTestApp("")
+	a.config.QuitSummary = true
+	a.currentBuf().buf.Dirty = true
+	a.executeCommand("qa")
+
+	sendKeys(a, "s")
+	if !strings.Contains(a.statusBar.StatusMessage, "[unnamed] has no file name") {
+		t.Errorf("an unnamed buffer can't be saved, got %q", a.statusBar.StatusMessage)
+	}
+	sendKey(a, terminal.KeyEnter)
+	if a.quit || !strings.HasPrefix(a.statusBar.StatusMessage, "Choose save or discard") {
+		t.Errorf("Enter should wait for a choice, got %q", a.statusBar.StatusMessage)
+	}
+	sendKeys(a, "d")
+	sendKey(a, terminal.KeyEnter)
+	if !a.quit {
+		t.Error("discarding the unnamed buffer should quit")
+	}
+}
+
+func TestQuitSummaryOff(t *testing.T) {
+	a, _ := newQuitTestApp(t)
+	a.config.QuitSummary = false
+	a.executeCommand("qa")
+	if a.quitSummary.Active || !strings.Contains(a.statusBar.StatusMessage, "Unsaved changes in 2 buffer(s)") {
+		t.Errorf("quit_summary = off should only warn, got %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	a, dir := newQuitTestApp(t)
+	unnamed := NewEditorBuffer("")
+	unnamed.buf.Dirty = true
+	a.buffers = append(a.buffers, unnamed)
+	a.executeCommand("wa")
+
+	if a.quit {
+		t.Fatal(":wa should not quit")
+	}
+	for _, name := range []string{"one.md", "three.md"} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != "Edited\n" {
+			t.Errorf("%s should be saved, got %q, %v", name, data, err)
+		}
+	}
+	if a.statusBar.StatusMessage != "1 unnamed buffer not saved" {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,58 @@
+package editor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// A buffer is read-only when its file can't be written, when it was opened
+// with --readonly, when another prose has the file open, or after :ro.
+// Edits are refused and the status bar marks it [RO]. Saving a file that
+// can't be written offers to save it under another name instead.
+
+// SetReadOnly opens the files named on the command line read-only, as the
+// --readonly flag does.
+func (a *App) SetReadOnly(on bool) {
+	for _, eb := range a.buffers {
+		if !eb.isScratch {
+			eb.readOnly = on
+		}
+	}
+}
+
+// fileWritable reports whether the file at path can be written, or, if it
+// doesn't exist yet, created.
+func fileWritable(path string) bool {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// checkWritable makes a buffer read-only if its file can't be written, and
+// editable again once it names one that can, as after saving a copy.
+func (a *App) checkWritable(eb *EditorBuffer) {
+	writable := eb.buf.Filename == "" || fileWritable(eb.buf.Filename)
+	switch {
+	case !writable && !eb.readOnly:
+		eb.readOnly, eb.unwritable = true, true
+		a.statusBar.SetMessage(filepath.Base(eb.buf.Filename) + " isn't writable. Opened read-only; :w newname saves a copy.")
+	case writable && eb.unwritable:
+		eb.readOnly, eb.unwritable = false, false
+	}
+}
+
+// promptSaveCopy asks for another name to save the current buffer under,
+// after its file couldn't be written.
+func (a *App) promptSaveCopy() {
+	a.statusBar.StartPrompt(PromptSaveNew)
+	a.statusBar.PromptLabel = "Can't write " + filepath.Base(a.currentBuf().buf.Filename) + "; save as"
+	a.statusBar.PromptText = a.currentBuf().buf.Filename
+}
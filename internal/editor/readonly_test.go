@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestStatusBarReadOnly(t *testing.T) {
+	sb := NewStatusBar()
+	sb.ReadOnly = true
+	if got := sb.FormatLeft("test.txt", false, "", 0, false); got != " test.txt [RO]" {
+		t.Errorf("FormatLeft = %q", got)
+	}
+	sb.Concise = true
+	if got := sb.FormatLeft("test.txt", false, "", 0, false); got != " test.txt read-only" {
+		t.Errorf("concise FormatLeft = %q", got)
+	}
+}
+
+func TestSetReadOnly(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.SetReadOnly(true)
+	sendKeys(a, "ihello")
+	if got := a.currentBuf().buf.Lines[0]; got != "" {
+		t.Errorf("a --readonly buffer was edited: %q", got)
+	}
+	a.executeCommand("ro")
+	if a.currentBuf().readOnly {
+		t.Error(":ro should allow edits")
+	}
+}
+
+func TestUnwritableFile(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write any file")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "locked.md")
+	os.WriteFile(path, []byte("text\n"), 0444)
+	a := newTestApp(path)
+	eb := a.currentBuf()
+	eb.buf.Load()
+	a.lockBuffer(eb)
+	defer a.releaseAllLocks()
+	if !eb.readOnly || !eb.unwritable {
+		t.Fatal("an unwritable file should open read-only")
+	}
+
+	// Writing anyway asks for another name, starting from the file's.
+	eb.buf.Dirty = true
+	a.executeCommand("w!")
+	if a.statusBar.Prompt != PromptSaveNew || a.statusBar.PromptText != path {
+		t.Fatalf("prompt = %v %q, want Save as %q", a.statusBar.Prompt, a.statusBar.PromptText, path)
+	}
+	if got := a.statusBar.FormatLeft(path, true, "", 0, false); got != " Can't write locked.md; save as: "+path {
+		t.Errorf("prompt shows %q", got)
+	}
+
+	a.statusBar.PromptText = filepath.Join(dir, "copy.md")
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	if eb.readOnly || eb.buf.Filename != filepath.Join(dir, "copy.md") {
+		t.Errorf("saving a copy should leave it editable, got %q read-only %v", eb.buf.Filename, eb.readOnly)
+	}
+}
+
+func TestFileWritable(t *testing.T) {
+	dir := t.TempDir()
+	if !fileWritable(filepath.Join(dir, "new.md")) {
+		t.Error("a file yet to be made should count as writable")
+	}
+	path := filepath.Join(dir, "draft.md")
+	os.WriteFile(path, []byte("text\n"), 0644)
+	if !fileWritable(path) {
+		t.Error("draft.md should be writable")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "text\n" {
+		t.Errorf("checking changed the file: %q", data)
+	}
+}
@@ -0,0 +1,94 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// recordRecent adds a file just opened to the recent files kept in the
+// history file. As with recordHistory the file is read first, so files
+// opened by other running instances are kept.
+func (a *App) recordRecent(eb *EditorBuffer) {
+	if eb.buf.Filename == "" || eb.isScratch {
+		return
+	}
+	h := loadHistory()
+	h.Files = addHistory(h.Files, absPath(eb.buf.Filename))
+	if err := saveHistory(h); err != nil {
+		a.errorLog("history save failed", err)
+	}
+}
+
+// recentFiles returns the files in history, most recently opened first,
+// leaving out those that no longer exist and the one being edited.
+func recentFiles(history []string, current string) []string {
+	var files []string
+	for _, path := range slices.Backward(history) {
+		if path == current {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+// recentDisplayName shortens a recent file's path for the overlay: relative
+// to the working directory if it is inside it, else with ~ for the home
+// directory.
+func recentDisplayName(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+// RecentList manages the recent files overlay.
+type RecentList struct {
+	ListView[string] // Absolute paths, most recent first
+}
+
+// showRecent runs :recent, listing recently opened files. Space-r opens it
+// too.
+func (a *App) showRecent() {
+	files := recentFiles(loadHistory().Files, absPath(a.currentBuf().buf.Filename))
+	if len(files) == 0 {
+		a.statusBar.SetMessage("No recent files")
+		return
+	}
+	a.recent.Show(files)
+}
+
+func (a *App) handleRecentKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.recent.Hide()
+	case terminal.KeyUp:
+		a.recent.MoveUp()
+	case terminal.KeyDown:
+		a.recent.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.recent.MoveUp()
+		case 'j':
+			a.recent.MoveDown()
+		}
+	case terminal.KeyEnter:
+		if a.recent.Selected < len(a.recent.Items) {
+			a.currentBuffer = a.openBuffer(a.recent.Items[a.recent.Selected])
+		}
+		a.recent.Hide()
+	}
+}
@@ -0,0 +1,72 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestRecentFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("text\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	history := []string{paths[0], filepath.Join(dir, "deleted.md"), paths[1], paths[2]}
+	want := []string{paths[1], paths[0]}
+	if got := recentFiles(history, paths[2]); !reflect.DeepEqual(got, want) {
+		t.Errorf("recentFiles = %v, want %v", got, want)
+	}
+}
+
+func TestRecentDisplayName(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if got := recentDisplayName(filepath.Join(dir, "notes", "a.md")); got != filepath.Join("notes", "a.md") {
+		t.Errorf("a file under the working directory should be relative, got %q", got)
+	}
+	home, _ := os.UserHomeDir()
+	if got := recentDisplayName(filepath.Join(home, "book", "a.md")); got != "~/book/a.md" {
+		t.Errorf("a file under home should start with ~, got %q", got)
+	}
+}
+
+func TestRecentOverlayReopensFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"one.md", "two.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := newTestApp(filepath.Join(dir, "one.md"))
+	a.currentBuffer = a.openBuffer(filepath.Join(dir, "two.md"))
+	a.closeCurrentBuffer()
+	if len(a.buffers) != 1 {
+		t.Fatalf("two.md should be closed, have %d buffers", len(a.buffers))
+	}
+
+	a.executeCommand("recent")
+	if !a.recent.Active || len(a.recent.Items) != 1 || a.recent.Items[0] != filepath.Join(dir, "two.md") {
+		t.Fatalf("recent files = %v, want two.md", a.recent.Items)
+	}
+	sendKey(a, terminal.KeyEnter)
+	if a.recent.Active || filepath.Base(a.currentBuf().buf.Filename) != "two.md" {
+		t.Errorf("Enter should reopen two.md, got %q", a.currentBuf().buf.Filename)
+	}
+}
+
+func TestRecentEmpty(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	a := newTestApp("draft.md")
+	sendKeys(a, " r")
+	if a.recent.Active || a.statusBar.StatusMessage != "No recent files" {
+		t.Errorf("with no history Space-r should say so, got %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,108 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// recoveryDir returns the directory emergency copies of buffers are written to.
+func recoveryDir() string {
+	return filepath.Join(stateDir(), "recovery")
+}
+
+// writeRecoveryFiles writes every buffer with unsaved changes (plus a
+// non-empty scratch buffer) to the recovery directory. It never touches the
+// original files. Returns the paths written.
+func (a *App) writeRecoveryFiles() ([]string, error) {
+	dir := recoveryDir()
+	stamp := time.Now().Format("20060102-150405")
+	var paths []string
+	var errs []string
+
+	for i, eb := range a.buffers {
+		if eb == nil || eb.buf == nil {
+			continue
+		}
+		content := strings.Join(eb.buf.Lines, "\n")
+		if eb.isScratch {
+			if strings.TrimSpace(content) == "" {
+				continue
+			}
+		} else if !eb.buf.Dirty {
+			continue
+		}
+
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return paths, err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s.%s.%d.recover", recoveryName(eb), stamp, i+1))
+		if err := os.WriteFile(path, []byte(content+"\n"), 0600); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	if len(errs) > 0 {
+		return paths, fmt.Errorf("recovery failed: %s", strings.Join(errs, "; "))
+	}
+	return paths, nil
+}
+
+// recoveryName returns the base name used for a buffer's recovery file.
+func recoveryName(eb *EditorBuffer) string {
+	switch {
+	case eb.isScratch:
+		return "scratch"
+	case eb.buf.Filename == "":
+		return "unnamed"
+	}
+	return filepath.Base(eb.buf.Filename)
+}
+
+// handlePanic restores the terminal, saves unsaved work to recovery files and
+// returns an error describing the crash, including the stack trace.
+func (a *App) handlePanic(r any) error {
+	stack := debug.Stack()
+	if a.terminal != nil {
+		a.terminal.Restore()
+	}
+	a.errorLog("panic", fmt.Errorf("%v", r), "stack", string(stack))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "panic: %v\n\n%s\n", r, stack)
+	a.reportRecovery(&b)
+	return fmt.Errorf("%s", strings.TrimRight(b.String(), "\n"))
+}
+
+// handleTerminate saves unsaved work to recovery files when the process is
+// asked to exit by a signal (e.g. the terminal window was closed), and
+// returns an error describing what happened.
+func (a *App) handleTerminate(sig os.Signal) error {
+	if a.terminal != nil {
+		a.terminal.Restore()
+	}
+	a.debugLog("terminated", "signal", fmt.Sprint(sig))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "received %v\n", sig)
+	a.reportRecovery(&b)
+	return fmt.Errorf("%s", strings.TrimRight(b.String(), "\n"))
+}
+
+// reportRecovery writes recovery files and appends their paths (and any
+// failure) to b.
+func (a *App) reportRecovery(b *strings.Builder) {
+	paths, err := a.writeRecoveryFiles()
+	for _, p := range paths {
+		fmt.Fprintf(b, "Unsaved changes written to %s\n", p)
+	}
+	if err != nil {
+		a.errorLog("recovery failed", err)
+		fmt.Fprintf(b, "%v\n", err)
+	}
+}
@@ -0,0 +1,94 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestWriteRecoveryFilesOnlyDirty(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	a := newTestApp("clean.md")
+	dirty := NewEditorBuffer("/some/where/chapter.md")
+	dirty.buf.Lines = []string{"unsaved", "words"}
+	dirty.buf.Dirty = true
+	a.buffers = append(a.buffers, dirty)
+
+	paths, err := a.writeRecoveryFiles()
+	if err != nil {
+		t.Fatalf("writeRecoveryFiles: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("expected 1 recovery file, got %d: %v", len(paths), paths)
+	}
+	if !strings.HasPrefix(filepath.Base(paths[0]), "chapter.md.") {
+		t.Errorf("recovery file should be named after the buffer, got %s", paths[0])
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("read recovery file: %v", err)
+	}
+	if string(data) != "unsaved\nwords\n" {
+		t.Errorf("recovery content: %q", data)
+	}
+}
+
+func TestWriteRecoveryFilesScratchAndUnnamed(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	a := newTestApp("")
+	a.currentBuf().buf.Lines = []string{"draft"}
+	a.currentBuf().buf.Dirty = true
+	a.appendToScratch("kept for later")
+
+	paths, err := a.writeRecoveryFiles()
+	if err != nil {
+		t.Fatalf("writeRecoveryFiles: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected unnamed and scratch recovery files, got %v", paths)
+	}
+	if !strings.HasPrefix(filepath.Base(paths[0]), "unnamed.") || !strings.HasPrefix(filepath.Base(paths[1]), "scratch.") {
+		t.Errorf("unexpected recovery names: %v", paths)
+	}
+}
+
+func TestHandlePanicReportsStackAndRecovery(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	a := newTestApp("doc.md")
+	a.currentBuf().buf.Dirty = true
+
+	err := a.handlePanic("boom")
+	msg := err.Error()
+	if !strings.Contains(msg, "panic: boom") {
+		t.Errorf("error should name the panic, got %q", msg)
+	}
+	if !strings.Contains(msg, "goroutine") {
+		t.Error("error should include a stack trace")
+	}
+	if !strings.Contains(msg, "Unsaved changes written to") {
+		t.Error("error should list recovery files")
+	}
+}
+
+func TestHandleTerminateSavesDirtyBuffers(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	a := newTestApp("doc.md")
+	a.currentBuf().buf.Lines = []string{"late night edits"}
+	a.currentBuf().buf.Dirty = true
+
+	err := a.handleTerminate(syscall.SIGHUP)
+	if !strings.Contains(err.Error(), "hangup") {
+		t.Errorf("error should name the signal, got %q", err)
+	}
+
+	entries, _ := os.ReadDir(recoveryDir())
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 recovery file, got %d", len(entries))
+	}
+}
@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// Frames are drawn only when something visible has changed, and no more
+// often than a display refreshes. Input marks what it changed; the main loop
+// draws once the frame interval has passed since the last frame.
+
+// dirty records what has changed since the last frame.
+type dirty uint8
+
+const (
+	dirtyCursor dirty = 1 << iota // The cursor or scroll position
+	dirtyText                     // Anything else: the text is wrapped and styled again
+)
+
+// frameInterval is the shortest time between frames, about 60 a second.
+const frameInterval = time.Second / 60
+
+// displayCache holds the last frame's display lines, which a cursor-only
+// move draws again rather than wrapping and styling the buffer.
+type displayCache struct {
+	eb         *EditorBuffer
+	width      int
+	folds      int
+	cursorLine int // Line drawn unconcealed, or -1 if the lines don't depend on it
+	lines      []DisplayLine
+}
+
+// markDirty records what an input event changes before it is handled.
+// Motions in Default mode only move the cursor; any other key may change
+// anything. Mouse clicks are marked by handleMouse, since most mouse events
+// are ignored.
+func (a *App) markDirty(event terminal.InputEvent) {
+	if event.Type != terminal.EventKey {
+		return
+	}
+	if a.cursorOnly(event.Key) {
+		a.dirty |= dirtyCursor
+	} else {
+		a.dirty |= dirtyText
+	}
+}
+
+// cursorOnly reports whether key only moves the cursor or scrolls.
+func (a *App) cursorOnly(key terminal.Key) bool {
+	if a.mode != ModeDefault || a.overlayActive() || a.statusBar.Prompt != PromptNone {
+		return false
+	}
+	if a.leaderPending || a.dPending || a.cPending || a.rPending || a.gPending || a.yPending || a.sPending || a.zPending || a.bracketPending != 0 || a.markPending != 0 {
+		return false
+	}
+	return actions[boundAction(a.keymap.Default, key)].Motion
+}
+
+// overlayActive reports whether an overlay is taking keys.
+func (a *App) overlayActive() bool {
+	return a.activeOverlay() != nil || a.browser.Active || a.leaderMenu.Active
+}
+
+// displayLines returns the current buffer's display lines, reusing the
+// last frame's when only the cursor has moved.
+func (a *App) displayLines(eb *EditorBuffer) []DisplayLine {
+	c := &a.display
+	if a.dirty&dirtyText == 0 && c.eb == eb && c.width == a.viewport.ColWidth && c.folds == len(eb.folds) &&
+		(c.cursorLine < 0 || c.cursorLine == eb.cursorLine) {
+		return c.lines
+	}
+	dls, cursorStyled := eb.displayLines(a.viewport.ColWidth)
+	if a.concealsMarkup(eb) {
+		concealMarkup(dls, eb.buf.Lines, eb.cursorLine)
+		cursorStyled = true
+	}
+	*c = displayCache{eb: eb, width: a.viewport.ColWidth, folds: len(eb.folds), cursorLine: -1, lines: dls}
+	if cursorStyled {
+		c.cursorLine = eb.cursorLine
+	}
+	return dls
+}
+
+// draw renders a frame if anything has changed, unless the last frame was
+// drawn less than frameInterval ago; the main loop then wakes to draw it.
+func (a *App) draw(now time.Time) {
+	if a.dirty == 0 || now.Sub(a.lastFrame) < frameInterval {
+		return
+	}
+	a.render()
+	a.dirty = 0
+	a.lastFrame = now
+}
+
+// wait returns how long the main loop can wait for input before it has
+// work to do: a frame held back by the frame rate cap, or a spell check
+// waiting for typing to pause. Zero waits for input.
+func (a *App) wait(now time.Time) time.Duration {
+	var d time.Duration
+	due := func(at time.Time) {
+		if left := max(at.Sub(now), time.Millisecond); d == 0 || left < d {
+			d = left
+		}
+	}
+	if a.dirty != 0 {
+		due(a.lastFrame.Add(frameInterval))
+	}
+	if eb := a.currentBuf(); a.spellCheckEnabled && eb.spellCheckPending && a.spellLoading == nil {
+		due(eb.lastEdit.Add(spellCheckDelay))
+	}
+	if a.leaderPending && !a.leaderMenu.Active {
+		due(a.leaderAt.Add(leaderMenuDelay))
+	}
+	if at, ok := a.nextAmbient(now); ok {
+		due(at)
+	}
+	if a.pdfExport != nil {
+		due(now.Add(exportPollInterval))
+	}
+	if a.spellLoading != nil {
+		due(now.Add(spellLoadPollInterval))
+	}
+	if !a.flashUntil.IsZero() {
+		due(a.flashUntil)
+	}
+	return d
+}
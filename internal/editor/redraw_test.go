@@ -0,0 +1,102 @@
+package editor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestMarkDirty(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(a *App)
+		keys  string
+		want  dirty
+	}{
+		{"motion", nil, "j", dirtyCursor},
+		{"word motion", nil, "w", dirtyCursor},
+		{"open line", nil, "o", dirtyText},
+		{"insert", nil, "i", dirtyText},
+		{"motion after an operator", nil, "dj", dirtyText},
+		{"motion in visual mode", func(a *App) { a.startVisual() }, "l", dirtyText},
+		{"key in an overlay", func(a *App) { a.picker.Show(0) }, "j", dirtyText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp("draft.md")
+			a.currentBuf().buf.Lines = []string{"One two", "three"}
+			if tt.setup != nil {
+				tt.setup(a)
+			}
+			a.dirty = 0
+			sendKeys(a, tt.keys)
+			if a.dirty != tt.want {
+				t.Errorf("dirty = %b, want %b", a.dirty, tt.want)
+			}
+		})
+	}
+
+	a := newTestApp("draft.md")
+	a.handleInput(terminal.InputEvent{Type: terminal.EventMouse, Mouse: terminal.MouseEvent{Button: terminal.MouseLeft}})
+	if a.dirty != 0 {
+		t.Errorf("a mouse release should change nothing, dirty = %b", a.dirty)
+	}
+}
+
+func TestDisplayLinesReusedForCursorMoves(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.viewport = NewViewport(80, 24)
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"First line", "Second line", "Third line"}
+	first := a.displayLines(eb)
+
+	eb.cursorLine = 1
+	a.dirty = dirtyCursor
+	if got := a.displayLines(eb); &got[0] != &first[0] {
+		t.Error("a cursor move should reuse the display lines")
+	}
+
+	eb.buf.Lines[1] = "Changed"
+	a.dirty = dirtyText
+	if got := a.displayLines(eb); got[1].Text != "Changed" {
+		t.Errorf("an edit should wrap the buffer again, got %q", got[1].Text)
+	}
+
+	// Notes are concealed except on the cursor line, so moving between
+	// lines styles them again.
+	eb.buf.Lines = []string{"Text {>>a note<<}", "More text {>>another<<}"}
+	a.dirty = dirtyText
+	before := a.displayLines(eb)
+	eb.cursorLine = 0
+	a.dirty = dirtyCursor
+	if got := a.displayLines(eb); &got[0] == &before[0] {
+		t.Error("moving to another line should restyle concealed notes")
+	}
+}
+
+func TestWait(t *testing.T) {
+	now := time.Now()
+	a := newTestApp("draft.md")
+	a.lastFrame = now
+	if d := a.wait(now); d != 0 {
+		t.Errorf("with nothing to do the loop should wait for input, got %v", d)
+	}
+
+	a.dirty = dirtyText
+	if d := a.wait(now.Add(5 * time.Millisecond)); d <= 0 || d > frameInterval-5*time.Millisecond {
+		t.Errorf("a held back frame should wake the loop when it is due, got %v", d)
+	}
+
+	a.dirty = 0
+	a.spellCheckEnabled = true
+	eb := a.currentBuf()
+	eb.spellCheckPending = true
+	eb.lastEdit = now
+	if d := a.wait(now.Add(100 * time.Millisecond)); d != spellCheckDelay-100*time.Millisecond {
+		t.Errorf("a pending spell check should wake the loop, got %v", d)
+	}
+	if d := a.wait(now.Add(time.Second)); d != time.Millisecond {
+		t.Errorf("an overdue spell check should wake the loop at once, got %v", d)
+	}
+}
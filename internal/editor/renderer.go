@@ -4,17 +4,59 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/JackWReid/prose/internal/spell"
 )
 
 // Renderer builds a frame buffer and writes it to the terminal in one go.
 type Renderer struct {
-	buf strings.Builder
+	buf         strings.Builder
+	theme       *Theme      // Colours
+	cursorLine  bool        // Highlight the cursor's display line
+	lineNumbers LineNumbers // Line-number gutter
+	gitSigns    []gitSign   // Git signs of the buffer being drawn; nil for none
+
+	// In screen-reader and low-bandwidth modes rows are collected in next
+	// and only those that changed since the last frame are drawn.
+	screenReader bool
+	lowBandwidth bool
+	rows         map[screenPos]string // Rows on screen
+	next         map[screenPos]string // Rows of the frame being built
+	covered      map[int]bool         // Rows an overlay drew over this frame
+	cursorHidden bool                 // An overlay hid the cursor; low-bandwidth mode shows it again
+
+	overlay overlayBox // Where the last overlay was drawn, for mouse clicks
+
+	highlights map[highlightKey]string // Rows highlighted lately, by text
+}
+
+// highlightKey is what a row's syntax highlighting depends on.
+type highlightKey struct {
+	highlighter Highlighter
+	theme       *Theme
+	text        string
+	block       BlockState
+}
+
+// highlightCacheSize bounds the rows kept highlighted, a few screens' worth;
+// past it they are all dropped and highlighted again as they are drawn.
+const highlightCacheSize = 1024
+
+// overlayBox is the screen area of an overlay, in 1-based cells.
+type overlayBox struct {
+	top, left, width, height int
+	itemTop                  int // Row of the first item
+	items                    int // Number of items drawn
+}
+
+// contains reports whether the cell at row, col is inside the box.
+func (o overlayBox) contains(row, col int) bool {
+	return row >= o.top && row < o.top+o.height && col >= o.left && col < o.left+o.width
 }
 
 func NewRenderer() *Renderer {
-	return &Renderer{}
+	return &Renderer{theme: &darkTheme}
 }
 
 // RenderFrame draws the full screen: text lines + status bar + cursor placement.
@@ -37,99 +79,204 @@ func (r *Renderer) RenderFrame(
 ) string {
 	r.buf.Reset()
 
-	// Hide cursor during drawing.
-	r.buf.WriteString("\x1b[?25l")
+	// Hide cursor during drawing. A screen reader would announce it
+	// vanishing and reappearing, so it stays put in that mode, and over a
+	// slow link the few rows drawn don't justify the bytes.
+	if !r.screenReader && !r.lowBandwidth {
+		r.buf.WriteString("\x1b[?25l")
 
-	// Move cursor to top-left (no full-screen clear — we erase per line instead).
-	r.buf.WriteString("\x1b[H")
+		// Move cursor to top-left (no full-screen clear — we erase per line instead).
+		r.buf.WriteString("\x1b[H")
+	}
 
 	visibleLines := vp.VisibleLines(scrollOffset)
-	topPadding := 0
-	if scrollOffset == 0 {
-		topPadding = 1
-	}
+	topPadding, _ := vp.Padding(scrollOffset)
 	marginStr := ""
 	if vp.LeftMargin > 0 {
 		marginStr = strings.Repeat(" ", vp.LeftMargin)
 	}
+	cursorLine := -1
+	if cursorDisplayLine >= 0 && cursorDisplayLine < len(displayLines) {
+		cursorLine = displayLines[cursorDisplayLine].BufferLine
+	}
 
-	// Clear top padding row if present.
-	if topPadding > 0 {
-		r.buf.WriteString("\x1b[1;1H\x1b[K")
+	// Clear top padding rows if present.
+	for row := 1; row <= topPadding; row++ {
+		r.putRow(vp.Top+row, vp.Left+1, r.eraseLine(vp, 0))
 	}
 
 	for i := 0; i < visibleLines; i++ {
 		idx := scrollOffset + i
-		// Move to row (1-indexed), offset by top padding.
-		row := i + 1 + topPadding
-		r.buf.WriteString(fmt.Sprintf("\x1b[%d;1H", row))
+		// Row (1-indexed), offset by top padding.
+		row := vp.Top + i + 1 + topPadding
+		line := ""
+		used := 0
 		if idx < len(displayLines) {
-			text := displayLines[idx].Text
-			text = highlighter.Highlight(text)
-			text = r.applySpellHighlighting(text, displayLines[idx], spellErrors)
-			text = r.applySearchHighlighting(text, displayLines[idx], searchActive, searchMatches, searchCurrentIdx)
+			var text string
+			if displayLines[idx].Folded > 0 {
+				text = renderFoldLine(displayLines[idx], r.theme)
+			} else {
+				text = r.highlight(highlighter, displayLines[idx])
+				text = r.applySpellHighlighting(text, displayLines[idx], spellErrors)
+				text = r.applySearchHighlighting(text, displayLines[idx], searchActive, searchMatches, searchCurrentIdx)
+				text = applyStyles(text, displayLines[idx].Styles)
+				text = applyConceal(text, displayLines[idx].Conceal)
+			}
 			text = TruncateVisible(text, vp.ColWidth)
 
 			// Apply reverse video for line-select mode
 			if mode == ModeLineSelect {
 				bufLine := displayLines[idx].BufferLine
 				if bufLine >= selectionStart && bufLine <= selectionEnd {
-					text = "\x1b[7m" + text + "\x1b[0m"
+					text = r.theme.Selection + text + "\x1b[0m"
 				}
+			} else if r.cursorLine && idx == cursorDisplayLine {
+				text = applyLineBackground(text, r.theme.CursorLine, vp.ColWidth)
 			}
 
-			r.buf.WriteString(marginStr)
-			r.buf.WriteString(text)
+			line = marginStr + text
+			if vp.Gutter > 0 {
+				line = marginStr[vp.Gutter:] + r.gutterCell(displayLines[idx], vp.Gutter, cursorLine) + text
+			}
+			used = vp.LeftMargin + visibleLen(text)
 		}
 		// Erase to end of line (clears stale content without a full-screen clear).
-		r.buf.WriteString("\x1b[K")
+		r.putRow(row, vp.Left+1, line+r.eraseLine(vp, used))
 	}
 
-	// Clear any remaining rows between content and status bar.
+	// Clear any remaining rows (and bottom padding) between content and
+	// status bar.
 	lastContentRow := visibleLines + topPadding
 	statusRow := vp.Height
 	for row := lastContentRow + 1; row < statusRow; row++ {
-		r.buf.WriteString(fmt.Sprintf("\x1b[%d;1H\x1b[K", row))
+		r.putRow(vp.Top+row, vp.Left+1, r.eraseLine(vp, 0))
 	}
 
 	// Status bar on the last row.
 	r.renderStatusBar(vp, statusLeft, statusRight)
 
-	// Position the cursor.
-	screenRow := cursorDisplayLine - scrollOffset + 1 + topPadding
-	screenCol := vp.LeftMargin + cursorDisplayCol + 1
-	r.buf.WriteString(fmt.Sprintf("\x1b[%d;%dH", screenRow, screenCol))
+	// Position the cursor, unless this is an unfocused split pane.
+	if cursorDisplayLine >= 0 {
+		screenRow := vp.Top + cursorDisplayLine - scrollOffset + 1 + topPadding
+		screenCol := vp.Left + vp.LeftMargin + cursorDisplayCol + 1
+		r.buf.WriteString(fmt.Sprintf("\x1b[%d;%dH", screenRow, screenCol))
 
-	// Show cursor.
-	r.buf.WriteString("\x1b[?25h")
+		// Show cursor.
+		if !r.screenReader && (!r.lowBandwidth || r.cursorHidden) {
+			r.buf.WriteString("\x1b[?25h")
+			r.cursorHidden = false
+		}
+	}
 
 	return r.buf.String()
 }
 
+// eraseLine returns what clears the rest of a row after used columns. A
+// split pane pads with spaces instead, so it doesn't wipe the pane beside it.
+func (r *Renderer) eraseLine(vp *Viewport, used int) string {
+	if !vp.Pane {
+		return "\x1b[K"
+	}
+	if pad := vp.Width - used; pad > 0 {
+		return "\x1b[0m" + strings.Repeat(" ", pad)
+	}
+	return ""
+}
+
+// RenderTooSmall draws the screen shown when the terminal is below the
+// minimum size: a cleared screen with the current and required sizes, and
+// status, the prompt or message, on the bottom row.
+func (r *Renderer) RenderTooSmall(width, height int, status string) string {
+	lines := []string{
+		"Terminal too small",
+		fmt.Sprintf("%dx%d, need %dx%d", width, height, MinWidth, MinHeight),
+	}
+	r.Invalidate() // The screen is cleared
+	var b strings.Builder
+	b.WriteString("\x1b[?25l\x1b[0m\x1b[2J")
+	top := max((height-len(lines))/2, 0)
+	for i, line := range lines {
+		if top+i >= height {
+			break
+		}
+		line = TruncateVisible(line, width)
+		col := max((width-visibleLen(line))/2, 0)
+		fmt.Fprintf(&b, "\x1b[%d;%dH%s", top+i+1, col+1, line)
+	}
+	if status != "" && height > len(lines) {
+		fmt.Fprintf(&b, "\x1b[%d;1H%s", height, TruncateVisible(status, width))
+	}
+	return b.String()
+}
+
+// highlightDisplayLine applies syntax highlighting in the theme's colours,
+// passing block context to highlighters that understand it.
+func highlightDisplayLine(highlighter Highlighter, dl DisplayLine, theme *Theme) string {
+	if mh, ok := highlighter.(MarkdownHighlighter); ok {
+		mh.Theme = theme
+		highlighter = mh
+	}
+	if bh, ok := highlighter.(BlockHighlighter); ok {
+		return bh.HighlightBlock(dl.Text, dl.Block)
+	}
+	return highlighter.Highlight(dl.Text)
+}
+
+// highlight is highlightDisplayLine in the renderer's theme, reusing the
+// result for rows whose text hasn't changed since they were last drawn.
+func (r *Renderer) highlight(highlighter Highlighter, dl DisplayLine) string {
+	key := highlightKey{highlighter, r.theme, dl.Text, dl.Block}
+	if text, ok := r.highlights[key]; ok {
+		return text
+	}
+	if r.highlights == nil || len(r.highlights) >= highlightCacheSize {
+		r.highlights = make(map[highlightKey]string)
+	}
+	text := highlightDisplayLine(highlighter, dl, r.theme)
+	r.highlights[key] = text
+	return text
+}
+
 // RenderPicker renders the buffer picker overlay centred on screen.
 func (r *Renderer) RenderPicker(buffers []*EditorBuffer, picker *Picker, currentBuffer int, vp *Viewport) string {
+	count := picker.Count(len(buffers))
+	start, end := picker.VisibleRange(count, vp.OverlayMaxItems())
+
 	// Build items for overlay.
-	items := make([]OverlayItem, len(buffers))
-	for i, eb := range buffers {
+	items := make([]OverlayItem, 0, end-start)
+	for i := start; i < end; i++ {
+		eb := buffers[picker.BufferAt(i)]
 		name := pickerDisplayName(eb.Filename(), eb.isScratch)
-		displayName := name
+		positions, _ := fuzzyMatch(picker.Filter.Text, name)
+		displayName := highlightMatches(name, positions)
+		if eb.pinned {
+			name += " (pinned)"
+			displayName += " (pinned)"
+		}
 		// Colour dirty filenames yellow/bold.
 		if eb.IsDirty() {
-			displayName = "\x1b[1;33m" + name + "\x1b[0m"
+			displayName = "\x1b[1;33m" + displayName + "\x1b[0m"
 		}
-		items[i] = OverlayItem{
+		items = append(items, OverlayItem{
 			DisplayText: displayName,
 			RawText:     name,
-		}
+		})
+	}
+	selected := picker.Selected - start
+	if count == 0 {
+		items, selected = []OverlayItem{noMatchesItem}, -1
 	}
 
 	return r.RenderOverlay(
-		"Open Buffers",
+		picker.Filter.Title("Open Buffers"),
 		"Space-b/t",
 		items,
-		picker.Selected,
+		selected,
 		vp,
-		OverlayScrollInfo{}, // No scrolling in picker
+		OverlayScrollInfo{
+			ShowUp:   start > 0,
+			ShowDown: end < count,
+		},
 	)
 }
 
@@ -145,29 +292,47 @@ func pickerDisplayName(filename string, isScratch bool) string {
 
 // RenderOutline renders the document outline overlay centred on screen.
 func (r *Renderer) RenderOutline(outline *Outline, vp *Viewport) string {
-	// Max visible items (use ~20 or calculate from viewport).
-	maxVisible := 20
-	if vp.Height-6 < maxVisible {
-		maxVisible = vp.Height - 6
-	}
-	if maxVisible < 3 {
-		maxVisible = 3
-	}
+	maxVisible := vp.OverlayMaxItems()
 
 	visibleItems := outline.VisibleItems(maxVisible)
+	title := "Document Outline"
+	if outline.Project {
+		title = "Project Outline"
+	}
+	title = outline.Filter.Title(title)
 	if len(visibleItems) == 0 {
-		return ""
+		if outline.Filter.Text == "" {
+			return ""
+		}
+		return r.RenderOverlay(title, "Space-h", []OverlayItem{noMatchesItem}, -1, vp, OverlayScrollInfo{})
 	}
 
 	// Build items for overlay.
 	items := make([]OverlayItem, len(visibleItems))
 	for i, item := range visibleItems {
-		// Indent based on heading level.
+		// Indent based on heading level, with a fold marker for headings
+		// that have subsections.
+		idx := outline.ScrollOffset + i
+		marker := "  "
+		if outline.IsCollapsed(idx) {
+			marker = "▸ "
+		} else if outline.HasChildren(idx) {
+			marker = "▾ "
+		}
 		indent := strings.Repeat(" ", (item.Level-1)*2)
-		displayText := indent + item.Text
+		rawText := indent + marker + item.Text
+		positions, _ := fuzzyMatch(outline.Filter.Text, item.Text)
+		text := highlightMatches(item.Text, positions)
+		displayText := indent + marker + text
+		if item.Scene {
+			// Scenes are dimmed to set them apart from headings.
+			displayText = indent + marker + "\x1b[90m" + text + "\x1b[0m"
+		} else if item.IsFile {
+			displayText = indent + marker + "\x1b[1m" + text + "\x1b[22m"
+		}
 		items[i] = OverlayItem{
 			DisplayText: displayText,
-			RawText:     displayText, // No ANSI codes in outline
+			RawText:     rawText,
 		}
 	}
 
@@ -175,7 +340,7 @@ func (r *Renderer) RenderOutline(outline *Outline, vp *Viewport) string {
 	selectedIdx := outline.Selected - outline.ScrollOffset
 
 	return r.RenderOverlay(
-		"Document Outline",
+		title,
 		"Space-h",
 		items,
 		selectedIdx,
@@ -187,24 +352,539 @@ func (r *Renderer) RenderOutline(outline *Outline, vp *Viewport) string {
 	)
 }
 
-// RenderBrowser renders the directory browser overlay centred on screen.
-func (r *Renderer) RenderBrowser(browser *Browser, vp *Viewport) string {
-	// Max visible items (use ~20 or calculate from viewport).
-	maxVisible := 20
-	if vp.Height-6 < maxVisible {
-		maxVisible = vp.Height - 6
+// RenderNameCheck renders the name consistency overlay centred on screen.
+func (r *Renderer) RenderNameCheck(nc *NameCheck, vp *Viewport) string {
+	visibleItems := nc.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
 	}
-	if maxVisible < 3 {
-		maxVisible = 3
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, clash := range visibleItems {
+		label := clash.Label()
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
 	}
 
-	visibleItems := browser.VisibleItems(maxVisible)
+	return r.RenderOverlay(
+		"Name Variants",
+		":names",
+		items,
+		nc.Selected-nc.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   nc.ScrollOffset > 0,
+			ShowDown: nc.ScrollOffset+len(visibleItems) < len(nc.Items),
+		},
+	)
+}
+
+// RenderCompletion renders the completion list centred on screen.
+func (r *Renderer) RenderCompletion(c *Completion, vp *Viewport) string {
+	visibleItems := c.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, item := range visibleItems {
+		width = max(width, len([]rune(item.Text)))
+	}
+	items := make([]OverlayItem, len(visibleItems))
+	for i, item := range visibleItems {
+		label := item.Text + strings.Repeat(" ", width-len([]rune(item.Text)))
+		if item.Detail != "" {
+			label += "  " + item.Detail
+		}
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
+	}
+
+	return r.RenderOverlay(
+		c.Title,
+		"Tab",
+		items,
+		c.Selected-c.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   c.ScrollOffset > 0,
+			ShowDown: c.ScrollOffset+len(visibleItems) < len(c.Items),
+		},
+	)
+}
+
+// popupMaxItems is how many words the list by the cursor shows at once.
+const popupMaxItems = 8
+
+// RenderWordCompletion renders the word completion list by the cursor at
+// screen row and col: below it, or above it when there isn't room, with
+// the words lined up under the one being typed, which starts at wordCol.
+// The cursor stays where it is. Screen readers get the overlay instead.
+func (r *Renderer) RenderWordCompletion(c *Completion, row, col, wordCol int, vp *Viewport) string {
+	if r.screenReader {
+		return r.RenderCompletion(c, vp)
+	}
+	visibleItems := c.VisibleItems(min(popupMaxItems, vp.OverlayMaxItems()))
 	if len(visibleItems) == 0 {
 		return ""
 	}
 
+	width := 0
+	for _, item := range visibleItems {
+		width = max(width, len([]rune(item.Text)))
+	}
+	labels := make([]string, len(visibleItems))
+	inner := 0
+	for i, item := range visibleItems {
+		labels[i] = item.Text
+		if item.Detail != "" {
+			labels[i] += strings.Repeat(" ", width-len([]rune(item.Text))) + "  " + item.Detail
+		}
+		inner = max(inner, visibleLen(labels[i])+2)
+	}
+	inner = min(inner, max(vp.Width-2, 3))
+	boxWidth, boxHeight := inner+2, len(labels)+2
+
+	top := row + 1
+	if top+boxHeight > vp.Height {
+		top = max(row-boxHeight, 1)
+	}
+	left := max(min(wordCol-2, vp.Width-boxWidth+1), 1)
+	r.overlay = overlayBox{
+		top: top, left: left, width: boxWidth, height: boxHeight,
+		itemTop: top + 1, items: len(labels),
+	}
+	r.cover(top, top+boxHeight-1)
+
+	var b strings.Builder
+	topLine, bottomLine := strings.Repeat("─", inner), strings.Repeat("─", inner)
+	if c.ScrollOffset > 0 {
+		topLine = strings.Repeat("─", inner-2) + "↑─"
+	}
+	if c.ScrollOffset+len(visibleItems) < len(c.Items) {
+		bottomLine = strings.Repeat("─", inner-2) + "↓─"
+	}
+	fmt.Fprintf(&b, "\x1b[%d;%dH╭%s╮", top, left, topLine)
+	for i, label := range labels {
+		label = TruncateVisible(label, inner-2)
+		content := " " + label + strings.Repeat(" ", max(inner-2-visibleLen(label), 0)) + " "
+		if i == c.Selected-c.ScrollOffset {
+			content = "\x1b[7m" + content + "\x1b[0m"
+		}
+		fmt.Fprintf(&b, "\x1b[%d;%dH│%s│", top+1+i, left, content)
+	}
+	fmt.Fprintf(&b, "\x1b[%d;%dH╰%s╯", top+boxHeight-1, left, bottomLine)
+	fmt.Fprintf(&b, "\x1b[%d;%dH", row, col)
+	return b.String()
+}
+
+// RenderDuplicates renders the duplicate sentences overlay centred on screen.
+func (r *Renderer) RenderDuplicates(d *DuplicateList, vp *Viewport) string {
+	visibleItems := d.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, pair := range visibleItems {
+		label := pair.Label()
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
+	}
+
+	return r.RenderOverlay(
+		"Duplicate Sentences",
+		":dupes",
+		items,
+		d.Selected-d.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   d.ScrollOffset > 0,
+			ShowDown: d.ScrollOffset+len(visibleItems) < len(d.Items),
+		},
+	)
+}
+
+// RenderLeaderMenu renders the leader key's combinations centred on screen.
+func (r *Renderer) RenderLeaderMenu(m *LeaderMenu, vp *Viewport) string {
+	width := m.KeyWidth()
+	items := make([]OverlayItem, len(m.Items))
+	for i, item := range m.Items {
+		label := item.Label(width)
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
+	}
+	return r.RenderOverlay("Leader", "Space", items, -1, vp, OverlayScrollInfo{})
+}
+
+// RenderPalette renders the command palette overlay centred on screen.
+func (r *Renderer) RenderPalette(p *CommandPalette, keys *Keymap, vp *Viewport) string {
+	title := p.Filter.Title("Commands")
+	visibleItems := p.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return r.RenderOverlay(title, "Ctrl-P", []OverlayItem{noMatchesItem}, -1, vp, OverlayScrollInfo{})
+	}
+
+	width := paletteNameWidth()
+	items := make([]OverlayItem, len(visibleItems))
+	for i, cmd := range visibleItems {
+		items[i] = paletteLabel(cmd, keys.commandKeys(cmd), p.Filter.Text, width)
+	}
+
+	return r.RenderOverlay(
+		title,
+		"Ctrl-P",
+		items,
+		p.Selected-p.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   p.ScrollOffset > 0,
+			ShowDown: p.ScrollOffset+len(visibleItems) < len(p.Items),
+		},
+	)
+}
+
+// RenderUndoTree renders the undo history overlay centred on screen.
+func (r *Renderer) RenderUndoTree(view *UndoTreeView, vp *Viewport, now time.Time) string {
+	visibleItems := view.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, entry := range visibleItems {
+		label := undoTreeLabel(entry, now)
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
+	}
+
+	return r.RenderOverlay(
+		"Undo Tree",
+		":undotree",
+		items,
+		view.Selected-view.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   view.ScrollOffset > 0,
+			ShowDown: view.ScrollOffset+len(visibleItems) < len(view.Items),
+		},
+	)
+}
+
+// RenderNotes renders the annotations overlay centred on screen.
+func (r *Renderer) RenderNotes(notes *NoteList, vp *Viewport) string {
+	visibleItems := notes.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, ann := range visibleItems {
+		label := noteLabel(ann)
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
+	}
+
+	return r.RenderOverlay(
+		"Notes",
+		":notes",
+		items,
+		notes.Selected-notes.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   notes.ScrollOffset > 0,
+			ShowDown: notes.ScrollOffset+len(visibleItems) < len(notes.Items),
+		},
+	)
+}
+
+// RenderRecent renders the recent files overlay centred on screen.
+func (r *Renderer) RenderRecent(rl *RecentList, vp *Viewport) string {
+	visibleItems := rl.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, path := range visibleItems {
+		name := recentDisplayName(path)
+		items[i] = OverlayItem{DisplayText: name, RawText: name}
+	}
+
+	return r.RenderOverlay(
+		"Recent Files",
+		"Space-r",
+		items,
+		rl.Selected-rl.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   rl.ScrollOffset > 0,
+			ShowDown: rl.ScrollOffset+len(visibleItems) < len(rl.Items),
+		},
+	)
+}
+
+// RenderQuitSummary renders the :qa unsaved changes overlay centred on
+// screen. Buffers to be discarded are shown in red.
+func (r *Renderer) RenderQuitSummary(q *QuitSummary, vp *Viewport) string {
+	visible := q.VisibleItems(vp.OverlayMaxItems())
+	if len(visible) == 0 {
+		return ""
+	}
+	start, end := q.ScrollOffset, q.ScrollOffset+len(visible)
+
+	items := make([]OverlayItem, 0, end-start)
+	for i := start; i < end; i++ {
+		label := q.Label(i)
+		display := label
+		if q.Choices[i] == quitDiscard {
+			display = "\x1b[31m" + label + "\x1b[0m"
+		}
+		items = append(items, OverlayItem{DisplayText: display, RawText: label})
+	}
+
+	return r.RenderOverlay(
+		"Unsaved: s save, d discard, Enter quit",
+		":qa",
+		items,
+		q.Selected-start,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   start > 0,
+			ShowDown: end < len(q.Items),
+		},
+	)
+}
+
+// RenderMerge renders the merge view centred on screen. Base text is
+// dimmed, and of the disk and buffer text what the hunk keeps is green.
+func (r *Renderer) RenderMerge(m *MergeView, vp *Viewport) string {
+	rows, selected := m.VisibleRows(vp.OverlayMaxItems())
+	if len(rows) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(rows))
+	for i, row := range rows {
+		display := row.Text
+		choice := m.Chunks[m.Hunks[row.Hunk]].Choice
+		switch {
+		case row.Kind == mergeRowBase:
+			display = "\x1b[90m" + row.Text + "\x1b[0m"
+		case row.Kind == mergeRowDisk && choice != mergeBuffer,
+			row.Kind == mergeRowBuffer && choice != mergeDisk:
+			display = "\x1b[32m" + row.Text + "\x1b[0m"
+		}
+		items[i] = OverlayItem{DisplayText: display, RawText: row.Text}
+	}
+
+	return r.RenderOverlay(
+		"Merge: d disk, b buffer, a both, Enter save",
+		":w",
+		items,
+		selected,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   m.ScrollOffset > 0,
+			ShowDown: m.ScrollOffset+len(rows) < len(m.Rows()),
+		},
+	)
+}
+
+// RenderGrep renders the :grep results or backlinks overlay centred on
+// screen.
+func (r *Renderer) RenderGrep(g *GrepList, vp *Viewport) string {
+	visibleItems := g.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, res := range visibleItems {
+		label := res.Label()
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
+	}
+
+	return r.RenderOverlay(
+		g.Title,
+		g.Hint,
+		items,
+		g.Selected-g.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   g.ScrollOffset > 0,
+			ShowDown: g.ScrollOffset+len(visibleItems) < len(g.Items),
+		},
+	)
+}
+
+// RenderGitDiff renders the :gitdiff overlay centred on screen. Removed
+// lines are red, added ones green and headings dimmed.
+func (r *Renderer) RenderGitDiff(d *GitDiffView, vp *Viewport) string {
+	rows := d.VisibleItems(vp.OverlayMaxItems())
+	if len(rows) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(rows))
+	for i, row := range rows {
+		display := row.Text
+		switch row.Kind {
+		case gitDiffHeading:
+			display = "\x1b[90m" + row.Text + "\x1b[0m"
+		case gitDiffRemovedRow:
+			display = "\x1b[31m" + row.Text + "\x1b[0m"
+		case gitDiffAddedRow:
+			display = "\x1b[32m" + row.Text + "\x1b[0m"
+		}
+		items[i] = OverlayItem{DisplayText: display, RawText: row.Text}
+	}
+
+	return r.RenderOverlay(
+		d.Title,
+		":gitdiff",
+		items,
+		d.Selected-d.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   d.ScrollOffset > 0,
+			ShowDown: d.ScrollOffset+len(rows) < len(d.Items),
+		},
+	)
+}
+
+// RenderHelp renders the :help overlay centred on screen. Headings are
+// dimmed.
+func (r *Renderer) RenderHelp(h *HelpView, vp *Viewport) string {
+	rows := h.VisibleItems(vp.OverlayMaxItems())
+	if len(rows) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(rows))
+	for i, row := range rows {
+		display := row.Text
+		if row.Heading {
+			display = "\x1b[90m" + row.Text + "\x1b[0m"
+		}
+		items[i] = OverlayItem{DisplayText: display, RawText: row.Text}
+	}
+
+	return r.RenderOverlay(
+		"Help",
+		":help",
+		items,
+		h.Selected-h.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   h.ScrollOffset > 0,
+			ShowDown: h.ScrollOffset+len(rows) < len(h.Items),
+		},
+	)
+}
+
+// RenderMessages renders the :messages overlay, each message with the time
+// it was shown and errors in red.
+func (r *Renderer) RenderMessages(m *MessageLog, vp *Viewport) string {
+	messages := m.VisibleItems(vp.OverlayMaxItems())
+	if len(messages) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(messages))
+	for i, msg := range messages {
+		raw := msg.Time.Format("15:04:05") + "  " + msg.Text
+		display := raw
+		if msg.Error {
+			display = "\x1b[31m" + raw + "\x1b[0m"
+		}
+		items[i] = OverlayItem{DisplayText: display, RawText: raw}
+	}
+
+	return r.RenderOverlay(
+		"Messages",
+		":messages",
+		items,
+		m.Selected-m.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   m.ScrollOffset > 0,
+			ShowDown: m.ScrollOffset+len(messages) < len(m.Items),
+		},
+	)
+}
+
+// RenderJournal renders the journal archive overlay centred on screen.
+// Group headings are dimmed.
+func (r *Renderer) RenderJournal(journal *JournalList, vp *Viewport) string {
+	visibleItems := journal.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, row := range visibleItems {
+		if row.Entry == nil {
+			items[i] = OverlayItem{DisplayText: "\x1b[90m" + row.Heading + "\x1b[0m", RawText: row.Heading}
+			continue
+		}
+		label := "  " + journalEntryLabel(*row.Entry)
+		items[i] = OverlayItem{DisplayText: label, RawText: label}
+	}
+
+	return r.RenderOverlay(
+		"Journal",
+		":journal list",
+		items,
+		journal.Selected-journal.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   journal.ScrollOffset > 0,
+			ShowDown: journal.ScrollOffset+len(visibleItems) < len(journal.Items),
+		},
+	)
+}
+
+// RenderStats renders the project stats overlay centred on screen. Headings
+// are dimmed.
+func (r *Renderer) RenderStats(stats *StatsView, vp *Viewport) string {
+	visibleItems := stats.VisibleItems(vp.OverlayMaxItems())
+	if len(visibleItems) == 0 {
+		return ""
+	}
+
+	items := make([]OverlayItem, len(visibleItems))
+	for i, row := range visibleItems {
+		if row.Heading {
+			items[i] = OverlayItem{DisplayText: "\x1b[90m" + row.Label + "\x1b[0m", RawText: row.Label}
+			continue
+		}
+		items[i] = OverlayItem{DisplayText: row.Label, RawText: row.Label}
+	}
+
+	return r.RenderOverlay(
+		"Project Stats",
+		":stats",
+		items,
+		stats.Selected-stats.ScrollOffset,
+		vp,
+		OverlayScrollInfo{
+			ShowUp:   stats.ScrollOffset > 0,
+			ShowDown: stats.ScrollOffset+len(visibleItems) < len(stats.Items),
+		},
+	)
+}
+
+// RenderBrowser renders the directory browser overlay centred on screen.
+func (r *Renderer) RenderBrowser(browser *Browser, vp *Viewport) string {
+	maxVisible := vp.OverlayMaxItems()
+
+	visibleItems := browser.VisibleItems(maxVisible)
+	if len(visibleItems) == 0 && browser.Filtered == 0 {
+		return ""
+	}
+
 	// Build items for overlay.
 	items := make([]OverlayItem, len(visibleItems))
+	if len(visibleItems) == 0 {
+		// Everything here is filtered out; say so, and how to show it.
+		text := fmt.Sprintf("%d hidden: . dotfiles, i ignored, f all files", browser.Filtered)
+		items = []OverlayItem{{DisplayText: "\x1b[90m" + text + "\x1b[0m", RawText: text}}
+	}
 	for i, item := range visibleItems {
 		displayName := item.Name
 		// Format directories with blue colour and "/" suffix.
@@ -236,6 +916,9 @@ func (r *Renderer) RenderBrowser(browser *Browser, vp *Viewport) string {
 // RenderColumnAdjust renders the column width adjustment overlay centred on screen.
 func (r *Renderer) RenderColumnAdjust(ca *ColumnAdjust, vp *Viewport) string {
 	display := fmt.Sprintf("← %d →", ca.Width)
+	if r.screenReader {
+		display = fmt.Sprintf("%d columns", ca.Width)
+	}
 	items := []OverlayItem{
 		{DisplayText: display, RawText: display},
 	}
@@ -272,10 +955,16 @@ func (r *Renderer) RenderOverlay(
 	vp *Viewport,
 	scroll OverlayScrollInfo,
 ) string {
+	if r.screenReader {
+		return r.renderPlainOverlay(title, keybinding, items, selectedIdx, vp, scroll)
+	}
+
 	var b strings.Builder
 
 	// Hide cursor while overlay is shown.
 	b.WriteString("\x1b[?25l")
+	r.cursorHidden = true
+	r.overlay = overlayBox{}
 
 	if len(items) == 0 {
 		return b.String()
@@ -300,6 +989,14 @@ func (r *Renderer) RenderOverlay(
 	if innerWidth < len(titleText)+2 {
 		innerWidth = len(titleText) + 2
 	}
+	// Never draw wider or taller than the terminal; long entries are cut.
+	if innerWidth > vp.Width-2 {
+		innerWidth = max(vp.Width-2, 8)
+		titleText = TruncateVisible(titleText, innerWidth)
+	}
+	if len(items) > vp.Height-2 {
+		items = items[:max(vp.Height-2, 1)]
+	}
 	boxWidth := innerWidth + 2  // +2 for left/right borders
 	boxHeight := len(items) + 2 // +2 for top/bottom borders
 
@@ -312,6 +1009,10 @@ func (r *Renderer) RenderOverlay(
 	if startRow < 1 {
 		startRow = 1
 	}
+	r.overlay = overlayBox{
+		top: startRow, left: startCol + 1, width: boxWidth, height: boxHeight,
+		itemTop: startRow + 1, items: len(items),
+	}
 
 	// Top border with embedded title: "「Title <keybinding> ─────╮"
 	dashCount := innerWidth - visibleLen(titleText)
@@ -319,6 +1020,7 @@ func (r *Renderer) RenderOverlay(
 		dashCount = 0
 	}
 	topLine := titleText + strings.Repeat("─", dashCount+1) + "╮"
+	r.cover(startRow, startRow+boxHeight-1)
 	b.WriteString(fmt.Sprintf("\x1b[%d;%dH%s", startRow, startCol+1, topLine))
 
 	// Item rows.
@@ -330,6 +1032,9 @@ func (r *Renderer) RenderOverlay(
 		}
 
 		// Calculate padding using visibleLen to account for ANSI codes.
+		if visibleLen(item.DisplayText) > innerWidth-6 {
+			item.DisplayText = TruncateVisible(item.DisplayText, max(innerWidth-6, 0))
+		}
 		visibleWidth := visibleLen(item.DisplayText)
 		padding := innerWidth - 4 - visibleWidth - 2 // -2 for the explicit spaces before right border
 		if padding < 0 {
@@ -380,10 +1085,8 @@ func (r *Renderer) RenderOverlay(
 }
 
 func (r *Renderer) renderStatusBar(vp *Viewport, left, right string) {
-	row := vp.Height
-	r.buf.WriteString(fmt.Sprintf("\x1b[%d;1H", row))
-	// Reverse video for status bar.
-	r.buf.WriteString("\x1b[7m")
+	var b strings.Builder
+	b.WriteString(r.theme.Status)
 
 	// Count visible (non-ANSI) characters for layout.
 	leftVisible := visibleLen(left)
@@ -406,15 +1109,17 @@ func (r *Renderer) renderStatusBar(vp *Viewport, left, right string) {
 		gap = 0
 	}
 
-	r.buf.WriteString(leftStr)
-	r.buf.WriteString(strings.Repeat(" ", gap))
-	r.buf.WriteString(right)
+	b.WriteString(leftStr)
+	b.WriteString(strings.Repeat(" ", gap))
+	b.WriteString(right)
 
 	// Reset attributes.
-	r.buf.WriteString("\x1b[0m")
+	b.WriteString("\x1b[0m")
+	r.putRow(vp.Top+vp.Height, vp.Left+1, b.String())
 }
 
-// visibleLen counts characters that aren't part of ANSI escape sequences.
+// visibleLen counts the columns taken by characters that aren't part of ANSI
+// escape sequences.
 func visibleLen(s string) int {
 	count := 0
 	runes := []rune(s)
@@ -429,13 +1134,115 @@ func visibleLen(s string) int {
 				i++
 			}
 		} else {
-			count++
+			count += runeWidth(runes[i])
 			i++
 		}
 	}
 	return count
 }
 
+// applyStyles switches on each span's style at its start and off at its end.
+// The style is re-applied after any escape code inside the span, so syntax
+// highlighting resets don't cut it short.
+func applyStyles(s string, spans []StyleSpan) string {
+	if len(spans) == 0 {
+		return s
+	}
+	var b strings.Builder
+	runes := []rune(s)
+	col := 0
+	active := func() []StyleSpan {
+		var in []StyleSpan
+		for _, span := range spans {
+			if col >= span.Start && col < span.End {
+				in = append(in, span)
+			}
+		}
+		return in
+	}
+	for i := 0; i < len(runes); {
+		if runes[i] == '\x1b' && i+1 < len(runes) && runes[i+1] == '[' {
+			j := i + 2
+			for j < len(runes) && !isAnsiTerminator(runes[j]) {
+				j++
+			}
+			if j < len(runes) {
+				j++
+			}
+			b.WriteString(string(runes[i:j]))
+			for _, span := range active() {
+				if col > span.Start {
+					b.WriteString(span.Code)
+				}
+			}
+			i = j
+			continue
+		}
+		for _, span := range spans {
+			if col == span.Start {
+				b.WriteString(span.Code)
+			}
+		}
+		b.WriteRune(runes[i])
+		col++
+		for _, span := range spans {
+			if col == span.End {
+				b.WriteString(span.Reset)
+			}
+		}
+		i++
+	}
+	return b.String()
+}
+
+// applyLineBackground gives a display line the background bg, padded to
+// width. The background is put back after codes that reset it, so
+// highlights keep their own colours and the band carries on after them.
+func applyLineBackground(s, bg string, width int) string {
+	s = strings.NewReplacer("\x1b[0m", "\x1b[0m"+bg, "\x1b[49m", "\x1b[49m"+bg).Replace(s)
+	pad := max(width-visibleLen(s), 0)
+	return bg + s + strings.Repeat(" ", pad) + "\x1b[0m"
+}
+
+// applyConceal replaces each concealed span of an ANSI-styled line with its
+// replacement. Escape codes inside a span are kept so styling carries on
+// correctly after it.
+func applyConceal(s string, spans []ConcealSpan) string {
+	if len(spans) == 0 {
+		return s
+	}
+	var b strings.Builder
+	runes := []rune(s)
+	col, si := 0, 0
+	for i := 0; i < len(runes); {
+		if runes[i] == '\x1b' && i+1 < len(runes) && runes[i+1] == '[' {
+			j := i + 2
+			for j < len(runes) && !isAnsiTerminator(runes[j]) {
+				j++
+			}
+			if j < len(runes) {
+				j++
+			}
+			b.WriteString(string(runes[i:j]))
+			i = j
+			continue
+		}
+		for si < len(spans) && col >= spans[si].End {
+			si++
+		}
+		if si < len(spans) && col >= spans[si].Start {
+			if col == spans[si].Start {
+				b.WriteString(spans[si].Replacement)
+			}
+		} else {
+			b.WriteRune(runes[i])
+		}
+		col++
+		i++
+	}
+	return b.String()
+}
+
 // truncateVisibleStr truncates a string with ANSI codes to maxVisible visible characters.
 func truncateVisibleStr(s string, maxVisible int) string {
 	return TruncateVisible(s, maxVisible)
@@ -468,6 +1275,7 @@ func (r *Renderer) applySpellHighlighting(text string, displayLine DisplayLine,
 	i := 0                             // Current index in runes
 	inANSI := false                    // Whether we're inside an ANSI escape sequence
 	activeErrors := make(map[int]bool) // Track which errors are currently highlighted
+	palette := r.theme.Palette
 
 	for i < len(runes) {
 		r := runes[i]
@@ -493,9 +1301,8 @@ func (r *Renderer) applySpellHighlighting(text string, displayLine DisplayLine,
 		// Check if we're at the start of any error
 		for idx, err := range relevantErrors {
 			if realCol == err.StartCol && !activeErrors[idx] {
-				// Start spell error highlighting: dark text on light red background
-				// Set foreground to black and background to light red
-				result.WriteString("\x1b[38;5;0m\x1b[48;5;224m")
+				// Start spell error highlighting in the palette's colours.
+				result.WriteString(palette.Spell)
 				activeErrors[idx] = true
 			}
 		}
@@ -524,7 +1331,7 @@ func (r *Renderer) applySpellHighlighting(text string, displayLine DisplayLine,
 }
 
 // applySearchHighlighting applies highlighting to search matches in the text.
-// Current match gets bright yellow background, other matches get lighter yellow.
+// The current match and the others get the palette's two search colours.
 func (r *Renderer) applySearchHighlighting(text string, displayLine DisplayLine, searchActive bool, searchMatches []SearchMatch, searchCurrentIdx int) string {
 	if !searchActive || len(searchMatches) == 0 {
 		return text
@@ -563,6 +1370,7 @@ func (r *Renderer) applySearchHighlighting(text string, displayLine DisplayLine,
 	i := 0                              // Current index in runes
 	inANSI := false                     // Whether we're inside an ANSI escape sequence
 	activeMatches := make(map[int]bool) // Track which matches are currently highlighted
+	palette := r.theme.Palette
 
 	for i < len(runes) {
 		r := runes[i]
@@ -590,11 +1398,9 @@ func (r *Renderer) applySearchHighlighting(text string, displayLine DisplayLine,
 			if realCol == rm.match.StartCol && !activeMatches[idx] {
 				// Start search match highlighting
 				if rm.isCurrent {
-					// Current match: bright yellow background, black text
-					result.WriteString("\x1b[38;5;0m\x1b[48;5;226m")
+					result.WriteString(palette.SearchCurrent)
 				} else {
-					// Other matches: lighter yellow background, black text
-					result.WriteString("\x1b[38;5;0m\x1b[48;5;229m")
+					result.WriteString(palette.SearchOther)
 				}
 				activeMatches[idx] = true
 			}
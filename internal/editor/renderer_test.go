@@ -202,6 +202,38 @@ func TestRenderPickerOverlay(t *testing.T) {
 	}
 }
 
+func TestRenderFrameCursorLine(t *testing.T) {
+	dls := []DisplayLine{
+		{BufferLine: 0, Text: "First line."},
+		{BufferLine: 1, Text: "Second line."},
+	}
+	vp := NewViewport(80, 10)
+	bg := palettes["default"].CursorLine
+
+	r := NewRenderer()
+	if frame := r.RenderFrame(dls, vp, 0, 1, 0, " f.txt", "DEFAULT ", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, 0); strings.Contains(frame, bg) {
+		t.Error("cursorline is off by default")
+	}
+
+	r.cursorLine = true
+	frame := r.RenderFrame(dls, vp, 0, 1, 0, " f.txt", "DEFAULT ", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, 0)
+	if !strings.Contains(frame, bg+"Second line.") {
+		t.Errorf("cursor line should have the background: %q", frame)
+	}
+	if strings.Contains(frame, bg+"First line.") {
+		t.Error("other lines should not have the background")
+	}
+}
+
+func TestApplyLineBackground(t *testing.T) {
+	bg := "\x1b[48;5;236m"
+	got := applyLineBackground("a \x1b[1mbold\x1b[0m and \x1b[48;5;224merr\x1b[49m end", bg, 30)
+	want := bg + "a \x1b[1mbold\x1b[0m" + bg + " and \x1b[48;5;224merr\x1b[49m" + bg + " end" + strings.Repeat(" ", 12) + "\x1b[0m"
+	if got != want {
+		t.Errorf("applyLineBackground = %q, want %q", got, want)
+	}
+}
+
 func TestApplySpellHighlightingWithOffset(t *testing.T) {
 	r := NewRenderer()
 
@@ -216,7 +248,7 @@ func TestApplySpellHighlightingWithOffset(t *testing.T) {
 	result := r.applySpellHighlighting(dl.Text, dl, errors)
 
 	// The highlight should start at column 0 of the display line text
-	if !strings.Contains(result, "\x1b[38;5;0m\x1b[48;5;224m") {
+	if !strings.Contains(result, darkTheme.Spell) {
 		t.Error("spell highlight should be applied to wrapped display line")
 	}
 	// Should contain the reset
@@ -235,7 +267,7 @@ func TestApplySpellHighlightingNoBleed(t *testing.T) {
 
 	result := r.applySpellHighlighting(dl.Text, dl, errors)
 
-	if strings.Contains(result, "\x1b[48;5;224m") {
+	if strings.Contains(result, darkTheme.Spell) {
 		t.Error("spell highlight should not bleed onto a different display line")
 	}
 }
@@ -249,7 +281,7 @@ func TestApplySearchHighlightingWithOffset(t *testing.T) {
 	result := r.applySearchHighlighting(dl.Text, dl, true, matches, 0)
 
 	// Current match highlight (bright yellow)
-	if !strings.Contains(result, "\x1b[38;5;0m\x1b[48;5;226m") {
+	if !strings.Contains(result, darkTheme.SearchCurrent) {
 		t.Error("search highlight should be applied to wrapped display line")
 	}
 	if !strings.Contains(result, "\x1b[39m\x1b[49m") {
@@ -266,7 +298,7 @@ func TestApplySearchHighlightingNoBleed(t *testing.T) {
 
 	result := r.applySearchHighlighting(dl.Text, dl, true, matches, 0)
 
-	if strings.Contains(result, "\x1b[48;5;226m") || strings.Contains(result, "\x1b[48;5;229m") {
+	if strings.Contains(result, darkTheme.SearchCurrent) || strings.Contains(result, darkTheme.SearchOther) {
 		t.Error("search highlight should not bleed onto a different display line")
 	}
 }
@@ -280,7 +312,7 @@ func TestApplySpellHighlightingFirstDisplayLine(t *testing.T) {
 
 	result := r.applySpellHighlighting(dl.Text, dl, errors)
 
-	if !strings.Contains(result, "\x1b[38;5;0m\x1b[48;5;224m") {
+	if !strings.Contains(result, darkTheme.Spell) {
 		t.Error("spell highlight should work on first display line")
 	}
 }
@@ -302,3 +334,114 @@ func TestRenderPickerDirtyIndicator(t *testing.T) {
 		t.Error("dirty file should be highlighted with yellow/bold")
 	}
 }
+
+func TestRenderOverlayFitsNarrowTerminal(t *testing.T) {
+	r := NewRenderer()
+	vp := NewViewport(30, 6)
+	items := []OverlayItem{
+		{DisplayText: strings.Repeat("x", 50), RawText: strings.Repeat("x", 50)},
+		{DisplayText: "b", RawText: "b"},
+		{DisplayText: "c", RawText: "c"},
+		{DisplayText: "d", RawText: "d"},
+		{DisplayText: "e", RawText: "e"},
+		{DisplayText: "f", RawText: "f"},
+	}
+
+	result := r.RenderOverlay("Title", "key", items, 0, vp, OverlayScrollInfo{})
+
+	// Every drawn row must fit within the terminal width.
+	for _, row := range strings.Split(result, "\x1b[")[1:] {
+		if _, text, ok := strings.Cut(row, "H"); ok && visibleLen(text) > vp.Width {
+			t.Errorf("overlay row %q is wider than the terminal", text)
+		}
+	}
+	// Borders plus at most Height-2 items.
+	if strings.Contains(result, "│    f") {
+		t.Error("overlay should not draw more rows than the terminal has")
+	}
+}
+
+func TestRenderPickerScrollsToSelection(t *testing.T) {
+	r := NewRenderer()
+	var buffers []*EditorBuffer
+	for _, name := range []string{"a.md", "b.md", "c.md", "d.md", "e.md", "f.md", "g.md", "h.md"} {
+		buffers = append(buffers, NewEditorBuffer(name))
+	}
+	picker := &Picker{Active: true, Selected: 7}
+	vp := NewViewport(80, 8) // Room for 3 items
+
+	result := r.RenderPicker(buffers, picker, 0, vp)
+	if !strings.Contains(result, "h.md") {
+		t.Error("picker should scroll to show the selected buffer")
+	}
+	if strings.Contains(result, "a.md") {
+		t.Error("picker should not show buffers scrolled out of view")
+	}
+	if !strings.Contains(result, "↑") {
+		t.Error("picker should show a scroll indicator")
+	}
+}
+
+func TestRenderTooSmall(t *testing.T) {
+	r := NewRenderer()
+	result := r.RenderTooSmall(18, 3, "")
+	if !strings.Contains(result, "Terminal too small") {
+		t.Error("should explain that the terminal is too small")
+	}
+	if !strings.Contains(result, "18x3") {
+		t.Error("should show the current size")
+	}
+	if !strings.Contains(result, "\x1b[2J") {
+		t.Error("should clear the screen")
+	}
+
+	// Even a tiny terminal gets no text past its edges.
+	result = r.RenderTooSmall(5, 1, "")
+	if strings.Contains(result, "need") {
+		t.Error("lines that don't fit should be dropped")
+	}
+
+	// A prompt or message goes on the bottom row.
+	result = r.RenderTooSmall(18, 4, ":q")
+	if !strings.Contains(result, "\x1b[4;1H:q") {
+		t.Error("the prompt should be shown on the bottom row")
+	}
+}
+
+func TestRenderFrameConfiguredPadding(t *testing.T) {
+	r := NewRenderer()
+	vp := NewViewport(80, 10)
+	vp.TopPadding = 2
+	vp.BottomPadding = 2
+	dls := WrapBuffer(&Buffer{Lines: []string{"first"}}, 60)
+
+	result := r.RenderFrame(dls, vp, 0, 0, 0, "", "", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, -1)
+	if !strings.Contains(result, "\x1b[3;1H") || !strings.Contains(result, "first") {
+		t.Error("text should start on row 3 after two rows of top padding")
+	}
+	// Rows 8 and 9 are bottom padding: cleared, above the status bar on row 10.
+	if !strings.Contains(result, "\x1b[8;1H\x1b[K") || !strings.Contains(result, "\x1b[9;1H\x1b[K") {
+		t.Error("bottom padding rows should be cleared")
+	}
+}
+
+func TestRendererHighlightCache(t *testing.T) {
+	r := NewRenderer()
+	h := DetectHighlighter("a.md")
+	first := r.highlight(h, DisplayLine{Text: "# Title"})
+	if got := r.highlight(h, DisplayLine{Text: "# Title"}); got != first || len(r.highlights) != 1 {
+		t.Errorf("an unchanged row should be highlighted once, got %q and %d entries", got, len(r.highlights))
+	}
+	if got := r.highlight(h, DisplayLine{Text: "# Title", Block: BlockState{InCode: true}}); got == first {
+		t.Error("a row moved into a code block should be highlighted again")
+	}
+	theme := darkTheme
+	theme.Heading = "\x1b[1;31m"
+	r.theme = &theme
+	if got := r.highlight(h, DisplayLine{Text: "# Title"}); !strings.HasPrefix(got, "\x1b[1;31m") {
+		t.Errorf("a new theme should highlight the row again, got %q", got)
+	}
+	if got := r.highlight(PlainHighlighter{}, DisplayLine{Text: "# Title"}); got != "# Title" {
+		t.Errorf("another highlighter should highlight the row again, got %q", got)
+	}
+}
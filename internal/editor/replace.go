@@ -0,0 +1,154 @@
+package editor
+
+import "github.com/JackWReid/prose/internal/terminal"
+
+// Replace mode (R) types over existing text. The characters typed since the
+// cursor last moved form a run that Backspace can unwind, putting back what
+// was overwritten. The whole visit to Replace mode is one undo step.
+
+// replaceRun is the text overwritten since the cursor last moved.
+type replaceRun struct {
+	line     int
+	start    int    // Column the run began at
+	before   string // The line as it was when the run began
+	original []rune // Characters overwritten, in order
+	typed    []rune // Characters typed over them; may run past the line's end
+}
+
+// render returns the run's line. With tracked on, the overwritten text is
+// marked as deleted and the typed text as inserted. The column returned is
+// just after the last typed character.
+func (r *replaceRun) render(tracked bool) (string, int) {
+	runes := []rune(r.before)
+	head := string(runes[:r.start])
+	tail := string(runes[r.start+len(r.original):])
+	if !tracked {
+		return head + string(r.typed) + tail, r.start + len(r.typed)
+	}
+	mid, col := "", r.start
+	if len(r.original) > 0 {
+		mid = "{--" + string(r.original) + "--}"
+		col += len([]rune(mid))
+	}
+	if len(r.typed) > 0 {
+		mid += "{++" + string(r.typed) + "++}"
+		col += criticDelimLen + len(r.typed)
+	}
+	return head + mid + tail, col
+}
+
+// replaceChar replaces the character under the cursor with ch (r). With
+// track changes on, the old character is marked as deleted and ch inserted.
+func (a *App) replaceChar(ch rune) {
+	eb := a.currentBuf()
+	if eb.cursorLine >= eb.buf.LineCount() {
+		return
+	}
+	runes := []rune(eb.buf.Lines[eb.cursorLine])
+	if eb.cursorCol >= len(runes) {
+		return
+	}
+	run := &replaceRun{
+		line:     eb.cursorLine,
+		start:    eb.cursorCol,
+		before:   string(runes),
+		original: []rune{runes[eb.cursorCol]},
+		typed:    []rune{ch},
+	}
+	line, col := run.render(eb.trackChanges)
+	a.replaceLines(eb.cursorLine, 1, []string{line})
+	eb.cursorCol = col - 1
+}
+
+// startReplace enters Replace mode.
+func (a *App) startReplace() {
+	a.currentBuf().undo.BeginGroup()
+	a.replaceRun = nil
+	a.mode = ModeReplace
+}
+
+// currentRun returns the run being typed, or nil if the cursor has moved
+// away from its end (by mouse, say) since the last character.
+func (a *App) currentRun() *replaceRun {
+	eb := a.currentBuf()
+	run := a.replaceRun
+	if run == nil || run.line != eb.cursorLine {
+		return nil
+	}
+	if _, col := run.render(eb.trackChanges); col != eb.cursorCol {
+		return nil
+	}
+	return run
+}
+
+// overwriteChar types ch over the character under the cursor, or appends it
+// at the end of the line.
+func (a *App) overwriteChar(ch rune) {
+	eb := a.currentBuf()
+	run := a.currentRun()
+	if run == nil {
+		if eb.cursorLine >= eb.buf.LineCount() {
+			return
+		}
+		run = &replaceRun{line: eb.cursorLine, start: eb.cursorCol, before: eb.buf.Lines[eb.cursorLine]}
+		run.start = min(run.start, len([]rune(run.before)))
+		a.replaceRun = run
+	}
+	runes := []rune(run.before)
+	if next := run.start + len(run.original); next < len(runes) {
+		run.original = append(run.original, runes[next])
+	}
+	run.typed = append(run.typed, ch)
+	a.applyReplaceRun()
+}
+
+// backspaceReplace steps back over the last character typed in Replace mode,
+// restoring the character it overwrote. Outside a run it only moves left.
+func (a *App) backspaceReplace() {
+	run := a.currentRun()
+	if run == nil || len(run.typed) == 0 {
+		a.moveCursor(terminal.KeyLeft)
+		return
+	}
+	if len(run.original) == len(run.typed) {
+		run.original = run.original[:len(run.original)-1]
+	}
+	run.typed = run.typed[:len(run.typed)-1]
+	a.applyReplaceRun()
+}
+
+// applyReplaceRun writes the current run into the buffer.
+func (a *App) applyReplaceRun() {
+	eb := a.currentBuf()
+	line, col := a.replaceRun.render(eb.trackChanges)
+	if line != eb.buf.Lines[eb.cursorLine] {
+		a.replaceLines(eb.cursorLine, 1, []string{line})
+	}
+	eb.cursorCol = col
+}
+
+func (a *App) handleReplaceKey(key terminal.Key) {
+	eb := a.currentBuf()
+	switch key.Type {
+	case terminal.KeyEscape:
+		eb.undo.EndGroup()
+		a.replaceRun = nil
+		a.mode = ModeDefault
+	case terminal.KeyRune:
+		a.overwriteChar(key.Rune)
+	case terminal.KeyBackspace:
+		a.backspaceReplace()
+	case terminal.KeyEnter:
+		a.replaceRun = nil
+		a.insertNewline()
+	case terminal.KeyUp, terminal.KeyDown, terminal.KeyLeft, terminal.KeyRight:
+		a.replaceRun = nil
+		a.moveCursor(key.Type)
+	case terminal.KeyHome:
+		a.replaceRun = nil
+		eb.cursorCol = 0
+	case terminal.KeyEnd:
+		a.replaceRun = nil
+		eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+	}
+}
@@ -0,0 +1,109 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestReplaceChar(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"cat"}
+	eb.cursorCol = 0
+	sendKeys(a, "rb")
+	if eb.buf.Lines[0] != "bat" || eb.cursorCol != 0 || a.mode != ModeDefault {
+		t.Errorf("after rb: %q col %d mode %v", eb.buf.Lines[0], eb.cursorCol, a.mode)
+	}
+	a.undoAction()
+	if eb.buf.Lines[0] != "cat" {
+		t.Errorf("after undo: %q", eb.buf.Lines[0])
+	}
+
+	// r past the end of the line does nothing.
+	eb.cursorCol = 3
+	sendKeys(a, "rx")
+	if eb.buf.Lines[0] != "cat" {
+		t.Errorf("r at end of line: %q", eb.buf.Lines[0])
+	}
+}
+
+func TestReplaceCharTracked(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"cat"}
+	eb.trackChanges = true
+	sendKeys(a, "rb")
+	if got := eb.buf.Lines[0]; got != "{--c--}{++b++}at" {
+		t.Errorf("tracked r: %q", got)
+	}
+	if eb.cursorCol != 10 {
+		t.Errorf("cursor = %d, want 10 (on the new character)", eb.cursorCol)
+	}
+}
+
+func TestReplaceMode(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The cat sat"}
+	eb.cursorCol = 4
+	sendKeys(a, "Rdog")
+	if a.mode != ModeReplace {
+		t.Fatalf("mode = %v, want REPLACE", a.mode)
+	}
+	if got := eb.buf.Lines[0]; got != "The dog sat" {
+		t.Errorf("after overwriting: %q", got)
+	}
+
+	// Typing past the end of the line appends.
+	eb.cursorCol = 8
+	sendKeys(a, "sits")
+	if got := eb.buf.Lines[0]; got != "The dog sits" {
+		t.Errorf("after overwriting past the end: %q", got)
+	}
+
+	// Backspace removes appended text and restores overwritten text.
+	for range 3 {
+		a.handleReplaceKey(terminal.Key{Type: terminal.KeyBackspace})
+	}
+	if got := eb.buf.Lines[0]; got != "The dog sat" || eb.cursorCol != 9 {
+		t.Errorf("after backspace: %q col %d", got, eb.cursorCol)
+	}
+
+	// The whole visit to Replace mode undoes in one step.
+	sendKeys(a, "\x1b")
+	if a.mode != ModeDefault {
+		t.Fatalf("mode = %v after Esc", a.mode)
+	}
+	a.undoAction()
+	if got := eb.buf.Lines[0]; got != "The cat sat" {
+		t.Errorf("after one undo: %q", got)
+	}
+}
+
+func TestReplaceModeTracked(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The cat sat"}
+	eb.cursorCol = 4
+	eb.trackChanges = true
+	sendKeys(a, "Rdog")
+	if got := eb.buf.Lines[0]; got != "The {--cat--}{++dog++} sat" {
+		t.Errorf("tracked overwrite: %q", got)
+	}
+	a.handleReplaceKey(terminal.Key{Type: terminal.KeyBackspace})
+	if got := eb.buf.Lines[0]; got != "The {--ca--}{++do++}t sat" {
+		t.Errorf("tracked backspace: %q", got)
+	}
+}
+
+func TestReplaceReadOnly(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"cat"}
+	eb.readOnly = true
+	sendKeys(a, "rbR")
+	if eb.buf.Lines[0] != "cat" || a.mode != ModeDefault {
+		t.Errorf("read-only: %q mode %v", eb.buf.Lines[0], a.mode)
+	}
+}
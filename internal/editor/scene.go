@@ -0,0 +1,143 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Thematic break: three or more -, * or _ (optionally spaced), used in
+// fiction as a scene separator.
+var reSceneBreak = regexp.MustCompile(`^ {0,3}(?:(?:- *){3,}|(?:\* *){3,}|(?:_ *){3,})$`)
+
+// frontMatterEnd returns the closing line of a YAML front matter block that
+// opens on the first line, or -1 if there is none.
+func frontMatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return -1
+	}
+	for i := 1; i < len(lines); i++ {
+		if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+			return i
+		}
+	}
+	return -1
+}
+
+// ExtractSceneBreaks returns the lines holding scene separators. Breaks in
+// code blocks or front matter, and setext heading underlines, don't count.
+func ExtractSceneBreaks(lines []string) []int {
+	blocks := ComputeBlockStates(lines)
+	var breaks []int
+	for i := frontMatterEnd(lines) + 1; i < len(lines); i++ {
+		if blocks[i].InCode || !reSceneBreak.MatchString(lines[i]) {
+			continue
+		}
+		if i > 0 && setextLevel(lines, blocks, i-1) > 0 {
+			continue
+		}
+		breaks = append(breaks, i)
+	}
+	return breaks
+}
+
+// sceneStarts returns the first line of each scene: the start of the
+// document (after any front matter) and the first non-blank line after each
+// separator.
+func sceneStarts(lines []string) []int {
+	starts := []int{frontMatterEnd(lines) + 1}
+	for _, b := range ExtractSceneBreaks(lines) {
+		start := b + 1
+		for start < len(lines)-1 && strings.TrimSpace(lines[start]) == "" {
+			start++
+		}
+		if start < len(lines) && start > starts[len(starts)-1] {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// sceneLabel returns an outline label for the scene following a separator:
+// its number and opening words.
+func sceneLabel(lines []string, breakLine, number int) string {
+	for _, line := range lines[breakLine+1:] {
+		text := strings.TrimSpace(line)
+		if text == "" {
+			continue
+		}
+		if reSceneBreak.MatchString(text) || reHeadingATX.MatchString(text) {
+			break
+		}
+		words := strings.Fields(text)
+		if len(words) > 6 {
+			return fmt.Sprintf("Scene %d: %s…", number, strings.Join(words[:6], " "))
+		}
+		return fmt.Sprintf("Scene %d: %s", number, text)
+	}
+	return fmt.Sprintf("Scene %d", number)
+}
+
+// ExtractOutline returns the headings of a buffer with its scene separators
+// interleaved. Each scene is nested one level below the heading it falls
+// under; scene numbering restarts at every heading.
+func ExtractOutline(buf *Buffer) []OutlineItem {
+	fm := frontMatterEnd(buf.Lines)
+	var headings []OutlineItem
+	for _, h := range ExtractHeadings(buf) {
+		if h.BufferLine > fm {
+			headings = append(headings, h)
+		}
+	}
+	breaks := ExtractSceneBreaks(buf.Lines)
+
+	var items []OutlineItem
+	level, scene := 0, 1
+	hi := 0
+	for _, b := range breaks {
+		for hi < len(headings) && headings[hi].BufferLine < b {
+			items = append(items, headings[hi])
+			level = headings[hi].Level
+			scene = 1
+			hi++
+		}
+		scene++
+		items = append(items, OutlineItem{
+			Level:      level + 1,
+			Text:       sceneLabel(buf.Lines, b, scene),
+			BufferLine: b,
+			Scene:      true,
+		})
+	}
+	return append(items, headings[hi:]...)
+}
+
+// jumpToNextScene moves the cursor to the start of the next scene.
+func (a *App) jumpToNextScene() {
+	eb := a.currentBuf()
+	for _, start := range sceneStarts(eb.buf.Lines) {
+		if start > eb.cursorLine {
+			a.pushJump()
+			eb.cursorLine = start
+			eb.cursorCol = 0
+			return
+		}
+	}
+	a.statusBar.SetMessage("No next scene")
+}
+
+// jumpToPrevScene moves the cursor to the start of the current scene, or of
+// the previous one if already there.
+func (a *App) jumpToPrevScene() {
+	eb := a.currentBuf()
+	starts := sceneStarts(eb.buf.Lines)
+	for i := len(starts) - 1; i >= 0; i-- {
+		if starts[i] < eb.cursorLine || (starts[i] == eb.cursorLine && eb.cursorCol > 0) {
+			a.pushJump()
+			eb.cursorLine = starts[i]
+			eb.cursorCol = 0
+			return
+		}
+	}
+	a.statusBar.SetMessage("No previous scene")
+}
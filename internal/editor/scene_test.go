@@ -0,0 +1,126 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+var sceneTestLines = []string{
+	"---",              // 0 front matter
+	"title: Draft",     // 1
+	"---",              // 2
+	"It was raining.",  // 3
+	"",                 // 4
+	"* * *",            // 5 scene break
+	"",                 // 6
+	"The next morning", // 7
+	"",                 // 8
+	"Chapter Two",      // 9 setext heading
+	"---",              // 10 underline, not a break
+	"Opening.",         // 11
+	"",                 // 12
+	"```",              // 13
+	"---",              // 14 inside code
+	"```",              // 15
+	"",                 // 16
+	"___",              // 17 scene break
+	"Last scene.",      // 18
+}
+
+func TestExtractSceneBreaks(t *testing.T) {
+	got := ExtractSceneBreaks(sceneTestLines)
+	want := []int{5, 17}
+	if len(got) != len(want) {
+		t.Fatalf("ExtractSceneBreaks = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ExtractSceneBreaks = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSceneBreakPatterns(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"---", true},
+		{"***", true},
+		{"___", true},
+		{"* * *", true},
+		{"- - - -", true},
+		{"--", false},
+		{"-*-", false},
+		{"--- text", false},
+		{"    ---", false}, // Indented code
+	}
+	for _, tt := range tests {
+		if got := reSceneBreak.MatchString(tt.line); got != tt.want {
+			t.Errorf("reSceneBreak(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExtractOutlineWithScenes(t *testing.T) {
+	buf := &Buffer{Lines: sceneTestLines}
+	items := ExtractOutline(buf)
+	want := []OutlineItem{
+		{Level: 1, Text: "Scene 2: The next morning", BufferLine: 5, Scene: true},
+		{Level: 2, Text: "Chapter Two", BufferLine: 9},
+		{Level: 3, Text: "Scene 2: Last scene.", BufferLine: 17, Scene: true},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("ExtractOutline = %+v, want %+v", items, want)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestSceneLabelTruncates(t *testing.T) {
+	lines := []string{"***", "", "One two three four five six seven eight"}
+	if got := sceneLabel(lines, 0, 3); got != "Scene 3: One two three four five six…" {
+		t.Errorf("sceneLabel = %q", got)
+	}
+}
+
+func TestSceneMotions(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = sceneTestLines
+	eb.cursorLine = 3
+
+	press := func(keys string) {
+		for _, r := range keys {
+			a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: r})
+		}
+	}
+
+	press("]s")
+	if eb.cursorLine != 7 {
+		t.Errorf("]s: cursor line = %d, want 7", eb.cursorLine)
+	}
+	press("]s")
+	if eb.cursorLine != 18 {
+		t.Errorf("]s: cursor line = %d, want 18", eb.cursorLine)
+	}
+	press("]s")
+	if !strings.Contains(a.statusBar.StatusMessage, "No next scene") {
+		t.Errorf("expected end message, got %q", a.statusBar.StatusMessage)
+	}
+
+	eb.cursorLine = 11
+	press("[s")
+	if eb.cursorLine != 7 {
+		t.Errorf("[s: cursor line = %d, want 7", eb.cursorLine)
+	}
+	press("[s")
+	if eb.cursorLine != 3 {
+		t.Errorf("[s: cursor line = %d, want 3 (after front matter)", eb.cursorLine)
+	}
+}
@@ -0,0 +1,170 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Screen-reader mode keeps the screen still for terminal screen readers:
+// only rows whose text changed are redrawn, overlays are plain lists without
+// box drawing, the status bar leaves out counts that change as you type, and
+// :speak puts the current line or a status summary in the status bar.
+
+// screenPos is where a row is drawn: a terminal row and its first column.
+type screenPos struct {
+	row, col int
+}
+
+// diffRows reports whether rows are held for FlushRows, which draws only
+// those that changed: in screen-reader mode to keep the screen still, and in
+// low-bandwidth mode to send less.
+func (r *Renderer) diffRows() bool {
+	return r.screenReader || r.lowBandwidth
+}
+
+// putRow draws a row's content at (row, col). When diffing rows the row is
+// held for FlushRows instead.
+func (r *Renderer) putRow(row, col int, s string) {
+	if !r.diffRows() {
+		fmt.Fprintf(&r.buf, "\x1b[%d;%dH%s", row, col, s)
+		return
+	}
+	if r.next == nil {
+		r.next = make(map[screenPos]string)
+	}
+	r.next[screenPos{row, col}] = s
+}
+
+// FlushRows returns the rows of the frame just built that differ from what
+// is on screen, top to bottom. When the layout changes every row is drawn.
+// When not diffing rows they are drawn as they are built and it returns "".
+func (r *Renderer) FlushRows() string {
+	if !r.diffRows() {
+		return ""
+	}
+	full := len(r.next) != len(r.rows)
+	positions := make([]screenPos, 0, len(r.next))
+	for pos := range r.next {
+		if _, ok := r.rows[pos]; !ok {
+			full = true
+		}
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].row != positions[j].row {
+			return positions[i].row < positions[j].row
+		}
+		return positions[i].col < positions[j].col
+	})
+	var b strings.Builder
+	for _, pos := range positions {
+		if s := r.next[pos]; full || r.rows[pos] != s {
+			fmt.Fprintf(&b, "\x1b[%d;%dH%s", pos.row, pos.col, s)
+		}
+	}
+	r.rows, r.next = r.next, nil
+	// Rows under an overlay are no longer what was drawn, so they are drawn
+	// again next frame, whether the overlay is still there or has closed. No
+	// row is empty, so blanking them forces that without a full redraw.
+	for pos := range r.rows {
+		if r.covered[pos.row] {
+			r.rows[pos] = ""
+		}
+	}
+	r.covered = nil
+	return b.String()
+}
+
+// cover notes that an overlay drew over rows top to bottom.
+func (r *Renderer) cover(top, bottom int) {
+	if !r.diffRows() {
+		return
+	}
+	if r.covered == nil {
+		r.covered = make(map[int]bool)
+	}
+	for row := top; row <= bottom; row++ {
+		r.covered[row] = true
+	}
+}
+
+// Invalidate forgets what is on screen, so the next frame is drawn in full.
+func (r *Renderer) Invalidate() {
+	r.rows = nil
+}
+
+// renderPlainOverlay draws an overlay for screen readers: a title row and
+// one row per item from the top of the screen, the selection marked with
+// "> ", and the rest of the screen above the status bar cleared. The
+// terminal cursor is left on the selected item so the reader follows it.
+func (r *Renderer) renderPlainOverlay(title, keybinding string, items []OverlayItem, selectedIdx int, vp *Viewport, scroll OverlayScrollInfo) string {
+	heading := title + " (" + keybinding + ")"
+	switch {
+	case scroll.ShowUp && scroll.ShowDown:
+		heading += ", more above and below"
+	case scroll.ShowUp:
+		heading += ", more above"
+	case scroll.ShowDown:
+		heading += ", more below"
+	}
+	r.putRow(1, 1, TruncateVisible(heading, vp.Width)+"\x1b[K")
+	r.overlay = overlayBox{
+		top: 1, left: 1, width: vp.Width, height: vp.Height,
+		itemTop: 2, items: max(min(len(items), vp.Height-2), 0),
+	}
+	for row := 2; row < vp.Height; row++ {
+		i := row - 2
+		if i >= len(items) {
+			r.putRow(row, 1, "\x1b[K")
+			continue
+		}
+		prefix := "  "
+		if i == selectedIdx {
+			prefix = "> "
+		}
+		r.putRow(row, 1, TruncateVisible(prefix+items[i].RawText, vp.Width)+"\x1b[K")
+	}
+	if selectedIdx < 0 || selectedIdx >= min(len(items), vp.Height-2) {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[%d;1H", selectedIdx+2)
+}
+
+// SetScreenReader turns screen-reader mode on, as the --screen-reader flag
+// does. The screen_reader setting can also turn it on.
+func (a *App) SetScreenReader(on bool) {
+	a.renderer.screenReader = on
+	a.statusBar.Concise = on
+}
+
+// speak puts the current line, or with "status" a summary of the buffer
+// and cursor, in the status bar for a screen reader to read out.
+func (a *App) speak(arg string) {
+	eb := a.currentBuf()
+	switch arg {
+	case "", "line":
+		line := eb.buf.Lines[eb.cursorLine]
+		if strings.TrimSpace(line) == "" {
+			line = "Blank line"
+		}
+		a.statusBar.SetMessage(line)
+	case "status":
+		parts := []string{truncatePathScratch(eb.Filename(), eb.isScratch)}
+		if eb.IsDirty() {
+			parts = append(parts, "modified")
+		}
+		parts = append(parts,
+			fmt.Sprintf("line %d of %d", eb.cursorLine+1, eb.buf.LineCount()),
+			fmt.Sprintf("column %d", eb.cursorCol+1),
+			fmt.Sprintf("%d words", eb.WordCount()),
+		)
+		if n := eb.SpellErrorCount(); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d spelling errors", n))
+		}
+		parts = append(parts, strings.ToLower(a.mode.String())+" mode")
+		a.statusBar.SetMessage(strings.Join(parts, ", "))
+	default:
+		a.statusBar.SetMessage("Usage: :speak [line|status]")
+	}
+}
@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func renderTestFrame(r *Renderer, dls []DisplayLine, vp *Viewport, left string) string {
+	frame := r.RenderFrame(dls, vp, 0, 0, 0, left, "DEFAULT ", PlainHighlighter{}, nil, ModeDefault, -1, -1, false, nil, 0)
+	return r.FlushRows() + frame
+}
+
+func TestScreenReaderRedrawsChangedRows(t *testing.T) {
+	r := NewRenderer()
+	r.screenReader = true
+	vp := NewViewport(80, 10)
+	dls := []DisplayLine{{BufferLine: 0, Text: "First line."}, {BufferLine: 1, Text: "Second line."}}
+
+	first := renderTestFrame(r, dls, vp, " draft.md")
+	if !strings.Contains(first, "First line.") || !strings.Contains(first, "Second line.") {
+		t.Fatalf("first frame should draw every row: %q", first)
+	}
+	if strings.Contains(first, "\x1b[?25l") {
+		t.Error("screen-reader frames should not hide the cursor")
+	}
+
+	dls[1].Text = "Second line, edited."
+	second := renderTestFrame(r, dls, vp, " draft.md")
+	if strings.Contains(second, "First line.") || strings.Contains(second, "draft.md") {
+		t.Errorf("unchanged rows should not be redrawn: %q", second)
+	}
+	if !strings.Contains(second, "Second line, edited.") {
+		t.Errorf("changed row should be redrawn: %q", second)
+	}
+
+	if third := renderTestFrame(r, dls, vp, " draft.md"); strings.Contains(third, "line") {
+		t.Errorf("an unchanged frame should draw nothing but the cursor: %q", third)
+	}
+
+	r.Invalidate()
+	if again := renderTestFrame(r, dls, vp, " draft.md"); !strings.Contains(again, "First line.") {
+		t.Error("after Invalidate every row should be drawn")
+	}
+}
+
+func TestRenderFrameWithoutScreenReader(t *testing.T) {
+	r := NewRenderer()
+	vp := NewViewport(80, 10)
+	dls := []DisplayLine{{BufferLine: 0, Text: "First line."}}
+	renderTestFrame(r, dls, vp, " draft.md")
+	if again := renderTestFrame(r, dls, vp, " draft.md"); !strings.Contains(again, "First line.") {
+		t.Error("without screen-reader mode every frame is drawn in full")
+	}
+}
+
+func TestScreenReaderOverlay(t *testing.T) {
+	r := NewRenderer()
+	r.screenReader = true
+	vp := NewViewport(80, 10)
+	items := []OverlayItem{
+		{DisplayText: "\x1b[1mone\x1b[0m", RawText: "one"},
+		{DisplayText: "two", RawText: "two"},
+	}
+
+	cursor := r.RenderOverlay("Buffers", "Space-B", items, 1, vp, OverlayScrollInfo{ShowDown: true})
+	out := r.FlushRows()
+	for _, box := range []string{"╭", "│", "─", "╯"} {
+		if strings.Contains(out, box) {
+			t.Errorf("overlay should not use box drawing, found %q", box)
+		}
+	}
+	if !strings.Contains(out, "Buffers (Space-B), more below") {
+		t.Errorf("overlay title missing: %q", out)
+	}
+	if !strings.Contains(out, "  one") || !strings.Contains(out, "> two") {
+		t.Errorf("items should be plain, with the selection marked: %q", out)
+	}
+	if cursor != "\x1b[3;1H" {
+		t.Errorf("cursor should be left on the selected item, got %q", cursor)
+	}
+}
+
+func TestConciseStatus(t *testing.T) {
+	s := &StatusBar{Concise: true}
+	if got := s.FormatLeft("/home/ada/book/draft.md", true, "[2/3]", 4, false); got != " book/draft.md modified [2/3]" {
+		t.Errorf("FormatLeft = %q", got)
+	}
+	if got := s.FormatRight(ModeEdit, 120, 4, 1, false, -1, 0); got != "EDIT " {
+		t.Errorf("FormatRight = %q, want the mode only", got)
+	}
+	if got := s.FormatRight(ModeDefault, 120, 0, 0, true, 1, 5); got != "match 2 of 5  DEFAULT " {
+		t.Errorf("FormatRight while searching = %q", got)
+	}
+}
+
+func TestSpeak(t *testing.T) {
+	a := newTestApp("/home/ada/book/draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"It was a dark night.", ""}
+
+	a.executeCommand("speak")
+	if a.statusBar.StatusMessage != "It was a dark night." {
+		t.Errorf(":speak = %q", a.statusBar.StatusMessage)
+	}
+
+	eb.cursorLine = 1
+	a.executeCommand("speak line")
+	if a.statusBar.StatusMessage != "Blank line" {
+		t.Errorf(":speak on a blank line = %q", a.statusBar.StatusMessage)
+	}
+
+	eb.buf.Dirty = true
+	a.executeCommand("speak status")
+	want := "book/draft.md, modified, line 2 of 2, column 1, 5 words, default mode"
+	if a.statusBar.StatusMessage != want {
+		t.Errorf(":speak status = %q, want %q", a.statusBar.StatusMessage, want)
+	}
+
+	a.executeCommand("speak louder")
+	if a.statusBar.StatusMessage != "Usage: :speak [line|status]" {
+		t.Errorf("bad argument: %q", a.statusBar.StatusMessage)
+	}
+}
@@ -0,0 +1,189 @@
+package editor
+
+import (
+	"strings"
+)
+
+// From the outline, K and J move the selected section, its heading and
+// everything under it, past the sibling section above or below, and < and >
+// promote or demote it with all its subheadings. Each is one undo step.
+
+// documentHeadings returns the buffer's headings outside front matter, as
+// the outline lists them.
+func documentHeadings(buf *Buffer) []OutlineItem {
+	fm := frontMatterEnd(buf.Lines)
+	var headings []OutlineItem
+	for _, h := range ExtractHeadings(buf) {
+		if h.BufferLine > fm {
+			headings = append(headings, h)
+		}
+	}
+	return headings
+}
+
+// headingIndex returns the index into headings of the heading on line, or
+// -1.
+func headingIndex(headings []OutlineItem, line int) int {
+	for i, h := range headings {
+		if h.BufferLine == line {
+			return i
+		}
+	}
+	return -1
+}
+
+// siblingSection returns the index into headings of the section beside
+// heading i at the same level under the same parent, above it or below it,
+// or -1 if there is none.
+func siblingSection(headings []OutlineItem, i, lineCount int, down bool) int {
+	level := headings[i].Level
+	if down {
+		next := SectionEnd(headings, i, lineCount) + 1
+		j := headingIndex(headings, next)
+		if j >= 0 && headings[j].Level == level {
+			return j
+		}
+		return -1
+	}
+	for j := i - 1; j >= 0; j-- {
+		switch {
+		case headings[j].Level < level:
+			return -1
+		case headings[j].Level == level:
+			return j
+		}
+	}
+	return -1
+}
+
+// swapSections returns the lines of two adjacent sections, first and
+// second, in the other order, and where first now starts. A blank line
+// ending the first is kept between them when the second, the end of the
+// document, has none.
+func swapSections(first, second []string) (lines []string, firstAt int) {
+	if len(first) > 0 && len(second) > 0 && isBlankLine(first[len(first)-1]) && !isBlankLine(second[len(second)-1]) {
+		second = append(second[:len(second):len(second)], "")
+		first = first[:len(first)-1]
+	}
+	return append(append([]string(nil), second...), first...), len(second)
+}
+
+// relevelHeading returns the lines of a heading at a new level, starting
+// with its text line; a setext heading also has its underline. A setext
+// heading stays one while its level is 1 or 2, and becomes an ATX heading
+// below that.
+func relevelHeading(lines []string, h OutlineItem, level int) []string {
+	line := lines[h.BufferLine]
+	if m := reHeadingATX.FindStringSubmatch(line); m != nil {
+		return []string{strings.Repeat("#", level) + line[len(m[1]):]}
+	}
+	switch level {
+	case 1:
+		return []string{line, strings.Repeat("=", max(len([]rune(strings.TrimSpace(line))), 3))}
+	case 2:
+		return []string{line, strings.Repeat("-", max(len([]rune(strings.TrimSpace(line))), 3))}
+	}
+	return []string{strings.Repeat("#", level) + " " + strings.TrimSpace(line)}
+}
+
+// outlineSection returns the headings and the index of the section selected
+// in the outline, or reports why it can't be changed.
+func (a *App) outlineSection() (headings []OutlineItem, i int, ok bool) {
+	o := a.outline
+	if o.Project {
+		a.statusBar.SetMessage("Sections can only be moved in the document outline")
+		return nil, -1, false
+	}
+	if o.Selected < 0 || o.Selected >= len(o.Items) || o.Items[o.Selected].Scene {
+		a.statusBar.SetMessage("Select a heading to move its section")
+		return nil, -1, false
+	}
+	if a.guardReadOnly() {
+		return nil, -1, false
+	}
+	headings = documentHeadings(a.currentBuf().buf)
+	i = headingIndex(headings, o.Items[o.Selected].BufferLine)
+	return headings, i, i >= 0
+}
+
+// moveOutlineSection moves the selected section down or up past its
+// sibling.
+func (a *App) moveOutlineSection(down bool) {
+	headings, i, ok := a.outlineSection()
+	if !ok {
+		return
+	}
+	eb := a.currentBuf()
+	j := siblingSection(headings, i, eb.buf.LineCount(), down)
+	if j < 0 {
+		a.statusBar.SetMessage("No section to move past")
+		return
+	}
+	first, second := j, i // Sections in document order
+	if down {
+		first, second = i, j
+	}
+	start, mid := headings[first].BufferLine, headings[second].BufferLine
+	end := SectionEnd(headings, second, eb.buf.LineCount())
+	lines, firstAt := swapSections(eb.buf.Lines[start:mid], eb.buf.Lines[mid:end+1])
+	a.replaceLines(start, end-start+1, lines)
+
+	line := start // Moving up, the section now starts the pair
+	if down {
+		line += firstAt
+	}
+	a.afterSectionChange(line)
+}
+
+// relevelOutlineSection promotes (delta -1) or demotes (delta 1) the
+// selected section's heading and every heading under it.
+func (a *App) relevelOutlineSection(delta int) {
+	headings, i, ok := a.outlineSection()
+	if !ok {
+		return
+	}
+	eb := a.currentBuf()
+	end := SectionEnd(headings, i, eb.buf.LineCount())
+	var inside []OutlineItem
+	for _, h := range headings[i:] {
+		if h.BufferLine > end {
+			break
+		}
+		if level := h.Level + delta; level < 1 || level > 6 {
+			a.statusBar.SetMessage("Headings go from level 1 to 6")
+			return
+		}
+		inside = append(inside, h)
+	}
+
+	start := headings[i].BufferLine
+	var lines []string
+	line := start
+	for _, h := range inside {
+		lines = append(lines, eb.buf.Lines[line:h.BufferLine]...)
+		lines = append(lines, relevelHeading(eb.buf.Lines, h, h.Level+delta)...)
+		line = h.BufferLine + 1
+		if reHeadingATX.FindStringIndex(eb.buf.Lines[h.BufferLine]) == nil {
+			line++ // Past the setext underline
+		}
+	}
+	lines = append(lines, eb.buf.Lines[line:end+1]...)
+	a.replaceLines(start, end-start+1, lines)
+	a.afterSectionChange(start)
+}
+
+// afterSectionChange lists the changed outline, keeping the filter, with
+// the heading on line selected and the cursor on it.
+func (a *App) afterSectionChange(line int) {
+	eb := a.currentBuf()
+	eb.cursorLine, eb.cursorCol = line, 0
+	filter := a.outline.Filter
+	a.outline.Show(ExtractOutline(eb.buf))
+	a.outline.Filter = filter
+	a.outline.rebuild()
+	for i, item := range a.outline.Items {
+		if item.BufferLine == line && !item.Scene {
+			a.outline.Selected = i
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func sectionTestApp(lines ...string) *App {
+	a := newTestApp("book.md")
+	a.currentBuf().buf.Lines = lines
+	a.showOutline()
+	return a
+}
+
+func selectOutline(t *testing.T, a *App, text string) {
+	t.Helper()
+	for i, item := range a.outline.Items {
+		if item.Text == text {
+			a.outline.Selected = i
+			return
+		}
+	}
+	t.Fatalf("no outline item %q in %v", text, outlineTexts(a.outline))
+}
+
+func outlineKey(a *App, r rune) {
+	a.handleOutlineKey(terminal.Key{Type: terminal.KeyRune, Rune: r})
+}
+
+func TestMoveSectionDown(t *testing.T) {
+	a := sectionTestApp(
+		"# Part",
+		"## One",
+		"First.",
+		"",
+		"### One A",
+		"",
+		"## Two",
+		"Second.",
+	)
+	selectOutline(t, a, "One")
+	outlineKey(a, 'J')
+
+	want := []string{
+		"# Part",
+		"## Two",
+		"Second.",
+		"",
+		"## One",
+		"First.",
+		"",
+		"### One A",
+	}
+	eb := a.currentBuf()
+	if !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Fatalf("lines = %q, want %q", eb.buf.Lines, want)
+	}
+	if got := a.outline.Items[a.outline.Selected].Text; got != "One" || eb.cursorLine != 4 {
+		t.Errorf("selected %q with the cursor on line %d, want One on line 4", got, eb.cursorLine)
+	}
+
+	outlineKey(a, 'J')
+	if a.statusBar.StatusMessage != "No section to move past" {
+		t.Errorf("moving the last sibling down: message %q", a.statusBar.StatusMessage)
+	}
+
+	a.undoAction()
+	if eb.buf.Lines[1] != "## One" || eb.buf.Lines[6] != "## Two" {
+		t.Errorf("one undo should put the sections back, got %q", eb.buf.Lines)
+	}
+}
+
+func TestMoveSectionUpStopsAtParent(t *testing.T) {
+	a := sectionTestApp(
+		"# One",
+		"## A",
+		"# Two",
+		"## B",
+	)
+	selectOutline(t, a, "B")
+	outlineKey(a, 'K')
+	if a.currentBuf().buf.Lines[3] != "## B" {
+		t.Errorf("B moved out of its parent: %q", a.currentBuf().buf.Lines)
+	}
+
+	selectOutline(t, a, "Two")
+	outlineKey(a, 'K')
+	want := []string{"# Two", "## B", "# One", "## A"}
+	if !reflect.DeepEqual(a.currentBuf().buf.Lines, want) {
+		t.Errorf("lines = %q, want %q", a.currentBuf().buf.Lines, want)
+	}
+}
+
+func TestRelevelSection(t *testing.T) {
+	a := sectionTestApp(
+		"# Top",
+		"",
+		"Chapter",
+		"-------",
+		"### Scene",
+		"Text.",
+		"## Next",
+	)
+	selectOutline(t, a, "Chapter")
+	outlineKey(a, '<')
+	want := []string{"# Top", "", "Chapter", "=======", "## Scene", "Text.", "## Next"}
+	if !reflect.DeepEqual(a.currentBuf().buf.Lines, want) {
+		t.Fatalf("promoted = %q, want %q", a.currentBuf().buf.Lines, want)
+	}
+
+	// At level 1 the chapter took in Next, which is demoted along with it.
+	outlineKey(a, '>')
+	outlineKey(a, '>')
+	want = []string{"# Top", "", "### Chapter", "#### Scene", "Text.", "#### Next"}
+	if !reflect.DeepEqual(a.currentBuf().buf.Lines, want) {
+		t.Fatalf("demoted twice = %q, want %q", a.currentBuf().buf.Lines, want)
+	}
+
+	selectOutline(t, a, "Top")
+	outlineKey(a, '<')
+	if a.statusBar.StatusMessage != "Headings go from level 1 to 6" {
+		t.Errorf("promoting a level 1 heading: message %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestMoveSectionReadOnly(t *testing.T) {
+	a := sectionTestApp("# One", "# Two")
+	a.currentBuf().readOnly = true
+	outlineKey(a, 'J')
+	if a.currentBuf().buf.Lines[0] != "# One" {
+		t.Error("a read-only buffer was changed")
+	}
+}
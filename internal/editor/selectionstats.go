@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SelectionStats counts the lines selected in Line-Select mode, shown in a
+// corner of the screen while selecting so that a passage can be cut down to
+// a word limit.
+type SelectionStats struct {
+	Lines int
+	Words int
+	Chars int // Characters, not counting line breaks
+}
+
+// countSelection counts lines the way the status bar counts the document:
+// CriticMarkup is counted as if every change were accepted.
+func countSelection(lines []string) SelectionStats {
+	s := SelectionStats{Lines: len(lines)}
+	for _, line := range lines {
+		line = stripCriticMarkup(line)
+		s.Words += len(strings.Fields(line))
+		s.Chars += utf8.RuneCountInString(line)
+	}
+	return s
+}
+
+// Rows returns the counts one to a row, e.g. "3 lines", "1 word".
+func (s SelectionStats) Rows() []string {
+	return []string{
+		plural(s.Lines, "line"),
+		plural(s.Words, "word"),
+		plural(s.Chars, "character"),
+	}
+}
+
+// String returns the counts on one line, for the status bar in screen-reader
+// mode.
+func (s SelectionStats) String() string {
+	return strings.Join(s.Rows(), ", ") + " selected"
+}
+
+// plural formats n and a noun, adding "s" unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// selectionStats counts the current Line-Select selection.
+func (a *App) selectionStats() SelectionStats {
+	start, end := a.getSelectionRange()
+	return countSelection(a.currentBuf().buf.Lines[start : end+1])
+}
+
+// RenderSelectionStats draws the selection's counts in a small box in the
+// top right corner of vp. Unlike the overlays it takes no keys and leaves the
+// cursor where it is.
+func (r *Renderer) RenderSelectionStats(s SelectionStats, vp *Viewport) string {
+	rows := s.Rows()
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	inner := width + 2 // A space either side
+	title := "─Selection"
+	inner = max(inner, utf8.RuneCountInString(title)+1)
+	if inner+2 > vp.Width || len(rows)+2 >= vp.Height {
+		return ""
+	}
+	top := vp.Top + 1
+	left := vp.Left + vp.Width - inner - 1 // Column of the left border, so the right is in the last
+
+	var b strings.Builder
+	b.WriteString("\x1b7") // Save the cursor
+	r.cover(top, top+len(rows)+1)
+	fmt.Fprintf(&b, "\x1b[%d;%dH╭%s%s╮", top, left, title, strings.Repeat("─", inner-utf8.RuneCountInString(title)))
+	for i, row := range rows {
+		fmt.Fprintf(&b, "\x1b[%d;%dH│ %s%s │", top+1+i, left, row, strings.Repeat(" ", inner-2-len(row)))
+	}
+	fmt.Fprintf(&b, "\x1b[%d;%dH╰%s╯", top+len(rows)+1, left, strings.Repeat("─", inner))
+	b.WriteString("\x1b8") // Restore it
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountSelection(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  SelectionStats
+	}{
+		{"one line", []string{"The cat sat."}, SelectionStats{Lines: 1, Words: 3, Chars: 12}},
+		{"blank lines", []string{"Two words", "", "and café"}, SelectionStats{Lines: 3, Words: 4, Chars: 17}},
+		{"tracked changes", []string{"A {--big --}{++small ++}dog"}, SelectionStats{Lines: 1, Words: 3, Chars: 11}},
+	}
+	for _, tt := range tests {
+		if got := countSelection(tt.lines); got != tt.want {
+			t.Errorf("%s: countSelection = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSelectionStatsString(t *testing.T) {
+	s := SelectionStats{Lines: 1, Words: 2, Chars: 1}
+	if got, want := s.String(), "1 line, 2 words, 1 character selected"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
+
+func TestSelectionStatsFollowSelection(t *testing.T) {
+	a := newTestApp("test.md")
+	a.currentBuf().buf.Lines = []string{"One two three.", "Four five.", "Six."}
+	sendKeys(a, "V")
+	if got := a.selectionStats(); got.Lines != 1 || got.Words != 3 {
+		t.Errorf("one line selected: %+v", got)
+	}
+	sendKeys(a, "jj")
+	if got := a.selectionStats(); got.Lines != 3 || got.Words != 6 {
+		t.Errorf("three lines selected: %+v", got)
+	}
+	sendKeys(a, "k")
+	if got := a.selectionStats(); got.Lines != 2 || got.Words != 5 {
+		t.Errorf("back to two lines: %+v", got)
+	}
+}
+
+func TestRenderSelectionStats(t *testing.T) {
+	r := NewRenderer()
+	vp := NewViewport(80, 24)
+	out := r.RenderSelectionStats(SelectionStats{Lines: 12, Words: 340, Chars: 1905}, vp)
+	if !strings.HasPrefix(out, "\x1b7") || !strings.HasSuffix(out, "\x1b8") {
+		t.Errorf("the cursor should be left where it was: %q", out)
+	}
+	if strings.Contains(out, "\x1b[?25l") {
+		t.Error("the cursor should stay visible while selecting")
+	}
+	for _, want := range []string{"\x1b[1;62H╭─Selection", "│ 12 lines        │", "│ 340 words       │", "│ 1905 characters │", "\x1b[5;62H╰"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("box should contain %q: %q", want, out)
+		}
+	}
+
+	if out := r.RenderSelectionStats(SelectionStats{}, NewViewport(10, 4)); out != "" {
+		t.Errorf("a box that doesn't fit should not be drawn: %q", out)
+	}
+}
@@ -0,0 +1,33 @@
+package editor
+
+// ( and ) move between the starts of sentences, as SplitSentences finds
+// them, so headings, code and blank lines are passed over.
+
+// jumpToNextSentence moves the cursor to the start of the next sentence.
+func (a *App) jumpToNextSentence() {
+	eb := a.currentBuf()
+	for _, s := range SplitSentences(eb.buf.Lines) {
+		if s.Line > eb.cursorLine || s.Line == eb.cursorLine && s.Col > eb.cursorCol {
+			eb.cursorLine, eb.cursorCol = s.Line, s.Col
+			a.skipFold(true)
+			return
+		}
+	}
+	a.statusBar.SetMessage("No next sentence")
+}
+
+// jumpToPrevSentence moves the cursor to the start of the current sentence,
+// or of the previous one if already there.
+func (a *App) jumpToPrevSentence() {
+	eb := a.currentBuf()
+	sentences := SplitSentences(eb.buf.Lines)
+	for i := len(sentences) - 1; i >= 0; i-- {
+		s := sentences[i]
+		if s.Line < eb.cursorLine || s.Line == eb.cursorLine && s.Col < eb.cursorCol {
+			eb.cursorLine, eb.cursorCol = s.Line, s.Col
+			a.skipFold(false)
+			return
+		}
+	}
+	a.statusBar.SetMessage("No previous sentence")
+}
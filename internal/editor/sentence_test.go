@@ -0,0 +1,36 @@
+package editor
+
+import "testing"
+
+func TestSentenceMotions(t *testing.T) {
+	a := newTestApp("test.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{
+		"# Chapter",
+		"",
+		"Dr. Watson arrived, e.g. by cab. He was late.",
+		"",
+		"The end.",
+	}
+	eb.cursorLine, eb.cursorCol = 2, 5
+
+	want := [][2]int{{2, 33}, {4, 0}}
+	for _, w := range want {
+		a.jumpToNextSentence()
+		if eb.cursorLine != w[0] || eb.cursorCol != w[1] {
+			t.Fatalf(") moved to %d:%d, want %d:%d", eb.cursorLine, eb.cursorCol, w[0], w[1])
+		}
+	}
+	a.jumpToNextSentence()
+	if eb.cursorLine != 4 || a.statusBar.StatusMessage != "No next sentence" {
+		t.Errorf(") at the last sentence: %d:%d, %q", eb.cursorLine, eb.cursorCol, a.statusBar.StatusMessage)
+	}
+
+	eb.cursorCol = 3
+	for _, w := range [][2]int{{4, 0}, {2, 33}, {2, 0}} {
+		a.jumpToPrevSentence()
+		if eb.cursorLine != w[0] || eb.cursorCol != w[1] {
+			t.Fatalf("( moved to %d:%d, want %d:%d", eb.cursorLine, eb.cursorCol, w[0], w[1])
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package editor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Session is the state persisted between runs in the session file.
+type Session struct {
+	Pinned  []string `json:"pinned"`            // Absolute paths of pinned buffers, top first
+	Order   []string `json:"order"`             // Absolute paths of buffers in picker order
+	Current string   `json:"current,omitempty"` // Absolute path of the buffer being edited
+}
+
+// sessionMaxOrder caps how many files the session remembers the order of.
+const sessionMaxOrder = 200
+
+// sessionPath returns the location of the session file.
+func sessionPath() string {
+	return filepath.Join(stateDir(), "session.json")
+}
+
+// loadSession reads the session file. A missing or unreadable file yields an
+// empty session.
+func loadSession() Session {
+	var s Session
+	data, err := os.ReadFile(sessionPath())
+	if err != nil {
+		return s
+	}
+	json.Unmarshal(data, &s)
+	return s
+}
+
+// saveSession writes the session file, creating its directory if needed.
+func saveSession(s Session) error {
+	if err := os.MkdirAll(stateDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sessionPath(), append(data, '\n'), 0600)
+}
+
+// absPath returns filename as an absolute path, or unchanged if that fails.
+func absPath(filename string) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filename
+	}
+	return abs
+}
+
+// indexOf returns the position of s in list, or -1.
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// applySession orders the open buffers using the saved session: pinned files
+// first in their saved order, then files the session knows about in their
+// saved order, then everything else in the order it was opened. The buffer
+// that was being edited is current again if it is open, and otherwise the
+// top one, so a pinned manuscript opens first.
+func (a *App) applySession(s Session) {
+	type rank struct{ group, pos int }
+	ranks := make(map[*EditorBuffer]rank, len(a.buffers))
+	var current *EditorBuffer
+	for i, eb := range a.buffers {
+		r := rank{2, i}
+		if eb.buf.Filename != "" && !eb.isScratch {
+			path := absPath(eb.buf.Filename)
+			if path == s.Current {
+				current = eb
+			}
+			if p := indexOf(s.Pinned, path); p >= 0 {
+				eb.pinned = true
+				r = rank{0, p}
+			} else if p := indexOf(s.Order, path); p >= 0 {
+				r = rank{1, p}
+			}
+		}
+		ranks[eb] = r
+	}
+
+	sort.SliceStable(a.buffers, func(i, j int) bool {
+		ri, rj := ranks[a.buffers[i]], ranks[a.buffers[j]]
+		if ri.group != rj.group {
+			return ri.group < rj.group
+		}
+		return ri.pos < rj.pos
+	})
+	a.currentBuffer = max(0, a.bufferIndex(current))
+}
+
+// bufferIndex returns the index of eb in the buffer list, or -1.
+func (a *App) bufferIndex(eb *EditorBuffer) int {
+	for i, b := range a.buffers {
+		if b == eb {
+			return i
+		}
+	}
+	return -1
+}
+
+// pinnedCount returns the number of pinned buffers, which always sit at the
+// top of the list.
+func (a *App) pinnedCount() int {
+	n := 0
+	for _, eb := range a.buffers {
+		if eb.pinned {
+			n++
+		}
+	}
+	return n
+}
+
+// moveBuffer moves the buffer at index from to index to, keeping the current
+// buffer selected.
+func (a *App) moveBuffer(from, to int) {
+	if from == to || from < 0 || to < 0 || from >= len(a.buffers) || to >= len(a.buffers) {
+		return
+	}
+	current := a.currentBuf()
+	eb := a.buffers[from]
+	a.buffers = append(a.buffers[:from], a.buffers[from+1:]...)
+	a.buffers = append(a.buffers[:to], append([]*EditorBuffer{eb}, a.buffers[to:]...)...)
+	a.currentBuffer = a.bufferIndex(current)
+}
+
+// shiftBuffer moves the buffer at index by delta places, without crossing the
+// boundary between pinned and unpinned buffers. Returns the new index.
+func (a *App) shiftBuffer(idx, delta int) int {
+	lo, hi := 0, a.pinnedCount()-1
+	if !a.buffers[idx].pinned {
+		lo, hi = a.pinnedCount(), len(a.buffers)-1
+	}
+	to := max(lo, min(hi, idx+delta))
+	a.moveBuffer(idx, to)
+	a.saveSessionState()
+	return to
+}
+
+// togglePin pins or unpins the buffer at index. Pinned buffers join the end
+// of the pinned group; unpinned ones go to the top of the rest. Returns the
+// buffer's new index.
+func (a *App) togglePin(idx int) int {
+	eb := a.buffers[idx]
+	if eb.isScratch {
+		a.statusBar.SetMessage("The scratch buffer can't be pinned")
+		return idx
+	}
+	var to int
+	if eb.pinned {
+		eb.pinned = false
+		to = a.pinnedCount()
+	} else {
+		to = a.pinnedCount()
+		eb.pinned = true
+	}
+	a.moveBuffer(idx, to)
+	a.saveSessionState()
+	return a.bufferIndex(eb)
+}
+
+// sessionState captures the buffer order, pins and current buffer, merged
+// with the previous session so files that aren't open keep their place.
+func (a *App) sessionState(prev Session) Session {
+	var s Session
+	open := make(map[string]bool)
+	for _, eb := range a.buffers {
+		if eb.isScratch || eb.buf.Filename == "" {
+			continue
+		}
+		path := absPath(eb.buf.Filename)
+		open[path] = true
+		s.Order = append(s.Order, path)
+		if eb.pinned {
+			s.Pinned = append(s.Pinned, path)
+		}
+		if eb == a.currentBuf() {
+			s.Current = path
+		}
+	}
+	for _, path := range prev.Pinned {
+		if !open[path] {
+			s.Pinned = append(s.Pinned, path)
+		}
+	}
+	for _, path := range prev.Order {
+		if !open[path] && len(s.Order) < sessionMaxOrder {
+			s.Order = append(s.Order, path)
+		}
+	}
+	return s
+}
+
+// saveSessionState writes the buffer order, pins and current buffer to the
+// session file.
+func (a *App) saveSessionState() {
+	if err := saveSession(a.sessionState(loadSession())); err != nil {
+		a.errorLog("session save failed", err)
+	}
+}
@@ -0,0 +1,154 @@
+package editor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func bufferNames(a *App) []string {
+	var names []string
+	for _, eb := range a.buffers {
+		names = append(names, filepath.Base(eb.buf.Filename))
+	}
+	return names
+}
+
+func assertOrder(t *testing.T, a *App, want ...string) {
+	t.Helper()
+	got := bufferNames(a)
+	if len(got) != len(want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	want := Session{Pinned: []string{"/a.md"}, Order: []string{"/a.md", "/b.md"}}
+	if err := saveSession(want); err != nil {
+		t.Fatal(err)
+	}
+	got := loadSession()
+	if len(got.Pinned) != 1 || got.Pinned[0] != "/a.md" || len(got.Order) != 2 || got.Order[1] != "/b.md" {
+		t.Errorf("loadSession = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadSessionMissing(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if s := loadSession(); s.Pinned != nil || s.Order != nil {
+		t.Errorf("missing session should be empty, got %+v", s)
+	}
+}
+
+func TestPickerMoveBuffer(t *testing.T) {
+	a, _ := newTempTestApp(t, "a.md", "b.md", "c.md")
+	a.picker.Show(2)
+
+	a.handlePickerKey(terminal.Key{Type: terminal.KeyRune, Rune: 'K'})
+	assertOrder(t, a, "a.md", "c.md", "b.md")
+	if a.picker.Selected != 1 {
+		t.Errorf("selection should follow the moved buffer, got %d", a.picker.Selected)
+	}
+	if a.currentBuf().buf.Filename != a.buffers[0].buf.Filename {
+		t.Error("current buffer should not change when reordering others")
+	}
+
+	a.handlePickerKey(terminal.Key{Type: terminal.KeyRune, Rune: 'K'})
+	a.handlePickerKey(terminal.Key{Type: terminal.KeyRune, Rune: 'K'}) // clamps at top
+	assertOrder(t, a, "c.md", "a.md", "b.md")
+	if a.currentBuffer != 1 {
+		t.Errorf("currentBuffer = %d, want 1 (a.md moved down)", a.currentBuffer)
+	}
+
+	a.handlePickerKey(terminal.Key{Type: terminal.KeyRune, Rune: 'J'})
+	assertOrder(t, a, "a.md", "c.md", "b.md")
+}
+
+func TestPickerPinBuffer(t *testing.T) {
+	a, _ := newTempTestApp(t, "a.md", "b.md", "c.md")
+	a.picker.Show(2)
+
+	a.handlePickerKey(terminal.Key{Type: terminal.KeyRune, Rune: 'p'})
+	assertOrder(t, a, "c.md", "a.md", "b.md")
+	if !a.buffers[0].pinned || a.picker.Selected != 0 {
+		t.Fatal("pinned buffer should move to the top and stay selected")
+	}
+
+	// Unpinned buffers can't move above pinned ones.
+	a.picker.Selected = 1
+	a.handlePickerKey(terminal.Key{Type: terminal.KeyRune, Rune: 'K'})
+	assertOrder(t, a, "c.md", "a.md", "b.md")
+
+	// Unpinning returns the buffer to the top of the unpinned group.
+	a.picker.Selected = 0
+	a.handlePickerKey(terminal.Key{Type: terminal.KeyRune, Rune: 'p'})
+	if a.buffers[0].pinned {
+		t.Error("buffer should be unpinned")
+	}
+}
+
+func TestPinPersistsAcrossSessions(t *testing.T) {
+	a, dir := newTempTestApp(t, "a.md", "b.md", "manuscript.md")
+	a.togglePin(2)
+
+	s := loadSession()
+	if len(s.Pinned) != 1 || filepath.Base(s.Pinned[0]) != "manuscript.md" {
+		t.Fatalf("session pinned = %v", s.Pinned)
+	}
+
+	// A later run opened in a different order puts the manuscript first.
+	b := newTestApp(filepath.Join(dir, "b.md"))
+	b.buffers = append(b.buffers, NewEditorBuffer(filepath.Join(dir, "manuscript.md")))
+	b.currentBuffer = 0
+	b.applySession(loadSession())
+	assertOrder(t, b, "manuscript.md", "b.md")
+	if b.currentBuffer != 0 || !b.buffers[0].pinned {
+		t.Error("pinned manuscript should be the current buffer at slot 1")
+	}
+
+	// Opening a pinned file later also places it in the pinned group.
+	c := newTestApp(filepath.Join(dir, "b.md"))
+	idx := c.openBuffer(filepath.Join(dir, "manuscript.md"))
+	if idx != 0 {
+		t.Errorf("openBuffer index = %d, want 0", idx)
+	}
+	assertOrder(t, c, "manuscript.md", "b.md")
+}
+
+func TestSessionStateKeepsClosedFiles(t *testing.T) {
+	a, _ := newTempTestApp(t, "a.md")
+	prev := Session{Pinned: []string{"/elsewhere/pinned.md"}, Order: []string{"/elsewhere/pinned.md"}}
+	s := a.sessionState(prev)
+	if len(s.Pinned) != 1 || s.Pinned[0] != "/elsewhere/pinned.md" {
+		t.Errorf("closed pinned file should be remembered, got %v", s.Pinned)
+	}
+	if len(s.Order) != 2 || filepath.Base(s.Order[0]) != "a.md" {
+		t.Errorf("order = %v, want open file first then closed file", s.Order)
+	}
+}
+
+func TestSessionRestoresCurrentBuffer(t *testing.T) {
+	a, dir := newTempTestApp(t, "a.md", "b.md", "manuscript.md")
+	a.togglePin(2)
+	a.currentBuffer = a.bufferIndex(a.buffers[2])
+	a.saveSessionState()
+	if s := loadSession(); filepath.Base(s.Current) != "b.md" {
+		t.Fatalf("session current = %q, want b.md", s.Current)
+	}
+
+	// The next run goes back to b.md, though the manuscript is pinned first.
+	b := newTestApp(filepath.Join(dir, "manuscript.md"))
+	b.buffers = append(b.buffers, NewEditorBuffer(filepath.Join(dir, "b.md")))
+	b.applySession(loadSession())
+	assertOrder(t, b, "manuscript.md", "b.md")
+	if b.currentBuffer != 1 {
+		t.Errorf("current buffer = %d, want b.md at 1", b.currentBuffer)
+	}
+}
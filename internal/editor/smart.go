@@ -0,0 +1,249 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Smart punctuation turns typewriter punctuation into typographer's: straight
+// quotes curl to open or close, -- becomes an en dash, --- an em dash and
+// ... an ellipsis. With smart_punctuation on it happens as you type, each
+// substitution an undo step of its own so that u gets back what was typed;
+// :smartpunct does the whole buffer and :smartpunct strip undoes it. Code,
+// front matter, HTML, link addresses, CriticMarkup delimiters and the lines
+// of dashes Markdown uses for rules are left alone.
+
+// straightPunctuation turns typographer's punctuation back to typewriter's.
+var straightPunctuation = map[rune]string{
+	'“': `"`, '”': `"`, '‘': "'", '’': "'", '–': "--", '—': "---", '…': "...",
+}
+
+// quoteOpeners are the characters after which a quote opens rather than
+// closes.
+const quoteOpeners = "([{<“‘—–-/"
+
+// curlQuote returns the curly form of the straight quote q, given the
+// text before it: opening at the start of a word, closing after one, so
+// that the apostrophe in "don't" closes. Emphasis markers are looked past.
+func curlQuote(q rune, before []rune) rune {
+	i := len(before) - 1
+	for i >= 0 && strings.ContainsRune("*_~", before[i]) {
+		i--
+	}
+	opens := i < 0 || unicode.IsSpace(before[i]) || strings.ContainsRune(quoteOpeners, before[i])
+	switch {
+	case q == '"' && opens:
+		return '“'
+	case q == '"':
+		return '”'
+	case opens:
+		return '‘'
+	}
+	return '’'
+}
+
+// protectedRunes marks the runes of line that punctuation is never changed
+// in: code spans, HTML tags and comments, autolinks, link addresses and
+// CriticMarkup delimiters. Code or a tag left open runs to the end of the
+// line, as it does while it is being typed.
+func protectedRunes(line []rune) []bool {
+	mask := make([]bool, len(line))
+	protect := func(start, end int) {
+		for j := start; j < end && j < len(mask); j++ {
+			mask[j] = true
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		r := line[i]
+		switch {
+		case r == '`':
+			n := 1
+			for i+n < len(line) && line[i+n] == '`' {
+				n++
+			}
+			end := len(line)
+			if close := indexRun(line, i+n, '`', n); close >= 0 {
+				end = close + n
+			}
+			protect(i, end)
+			i = end - 1
+		case r == '<' && i+1 < len(line) && (unicode.IsLetter(line[i+1]) || line[i+1] == '/' || line[i+1] == '!'):
+			end := len(line)
+			for j := i + 1; j < len(line); j++ {
+				if line[j] == '>' {
+					end = j + 1
+					break
+				}
+			}
+			protect(i, end)
+			i = end - 1
+		case r == ']' && i+1 < len(line) && line[i+1] == '(':
+			end := closeParen(line, i+1) + 1
+			protect(i+1, end)
+			i = end - 1
+		case r == '{' && i+2 < len(line) && line[i+1] == '-' && line[i+2] == '-',
+			r == '-' && i+2 < len(line) && line[i+1] == '-' && line[i+2] == '}':
+			protect(i, i+3)
+			i += 2
+		}
+	}
+	return mask
+}
+
+// dashRule reports whether line is only dashes and spaces, as a rule,
+// setext underline, front matter fence or table separator is.
+func dashRule(line string) bool {
+	if IsTableLine(line) && isSeparatorRow(SplitTableRow(line)) {
+		return true
+	}
+	return strings.Trim(line, " \t-–—") == "" && strings.TrimSpace(line) != ""
+}
+
+// runLength returns how many of r run back from the end of runes.
+func runLength(runes []rune, r rune) int {
+	n := 0
+	for n < len(runes) && runes[len(runes)-1-n] == r {
+		n++
+	}
+	return n
+}
+
+// smartenLine returns line with its punctuation smartened.
+func smartenLine(line string) string {
+	if dashRule(line) {
+		return line
+	}
+	runes := []rune(line)
+	mask := protectedRunes(runes)
+	var out []rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if mask[i] {
+			out = append(out, r)
+			continue
+		}
+		n := 1
+		for i+n < len(runes) && runes[i+n] == r && !mask[i+n] {
+			n++
+		}
+		switch {
+		case r == '"' || r == '\'':
+			out = append(out, curlQuote(r, out))
+			continue
+		case r == '-' && n == 2:
+			out = append(out, '–')
+		case r == '-' && n == 3:
+			out = append(out, '—')
+		case r == '.' && n == 3:
+			out = append(out, '…')
+		default:
+			out = append(out, runes[i:i+n]...)
+		}
+		i += n - 1
+	}
+	return string(out)
+}
+
+// straightenLine returns line with smart punctuation made straight again.
+func straightenLine(line string) string {
+	runes := []rune(line)
+	mask := protectedRunes(runes)
+	var b strings.Builder
+	for i, r := range runes {
+		if s, ok := straightPunctuation[r]; ok && !mask[i] {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// smartenTail returns the smart form of the punctuation just typed at the
+// end of before, and the rune column it starts at, or -1.
+func smartenTail(before []rune) (string, int) {
+	n := len(before)
+	if n == 0 || protectedRunes(before)[n-1] || dashRule(string(before)) {
+		return "", -1
+	}
+	switch before[n-1] {
+	case '"', '\'':
+		return string(curlQuote(before[n-1], before[:n-1])), n - 1
+	case '-':
+		if runLength(before, '-') == 2 {
+			return "–", n - 2
+		}
+		if n > 1 && before[n-2] == '–' {
+			return "—", n - 2
+		}
+	case '.':
+		if runLength(before, '.') == 3 {
+			return "…", n - 3
+		}
+	}
+	return "", -1
+}
+
+// smartenTyped smartens the punctuation just typed before the cursor in
+// Edit mode, with smart_punctuation on, in prose outside code and front
+// matter.
+func (a *App) smartenTyped() {
+	eb := a.currentBuf()
+	if !a.config.SmartPunct || eb.trackChanges || !isProseFile(eb.buf.Filename) || eb.cursorLine <= frontMatterEnd(eb.buf.Lines) {
+		return
+	}
+	if blocks := ComputeBlockStates(eb.buf.Lines[:eb.cursorLine+1]); blocks[eb.cursorLine].InCode {
+		return
+	}
+	runes := []rune(eb.buf.Lines[eb.cursorLine])
+	col := min(eb.cursorCol, len(runes))
+	smart, start := smartenTail(runes[:col])
+	if start < 0 {
+		return
+	}
+	a.tidyFix(string(runes[:start])+smart+string(runes[col:]), start+len([]rune(smart)))
+}
+
+// smartPunctCommand runs :smartpunct, smartening the buffer's punctuation
+// outside code and front matter, or with strip, straightening it, as one
+// undo step.
+func (a *App) smartPunctCommand(arg string) {
+	convert, verb := smartenLine, "smarten"
+	switch strings.TrimSpace(arg) {
+	case "":
+	case "strip":
+		convert, verb = straightenLine, "straighten"
+	default:
+		a.statusBar.SetMessage("Usage: :smartpunct [strip]")
+		return
+	}
+	eb := a.currentBuf()
+	lines := eb.buf.Lines
+	blocks := ComputeBlockStates(lines)
+	changed := make([]string, len(lines))
+	copy(changed, lines)
+	first, last, count := -1, -1, 0
+	for i := frontMatterEnd(lines) + 1; i < len(lines); i++ {
+		if blocks[i].InCode || reCodeFence.MatchString(lines[i]) {
+			continue
+		}
+		if changed[i] = convert(lines[i]); changed[i] != lines[i] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			count++
+		}
+	}
+	if count == 0 {
+		a.statusBar.SetMessage("No punctuation to " + verb)
+		return
+	}
+	if a.guardReadOnly() {
+		return
+	}
+	a.replaceLines(first, last-first+1, changed[first:last+1])
+	eb.cursorCol = min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+	a.statusBar.SetMessage(fmt.Sprintf("%sed punctuation on %s", capitalize(verb), plural(count, "line")))
+}
@@ -0,0 +1,136 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSmartenLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"double quotes", `He said "go" and went.`, "He said “go” and went."},
+		{"single quotes", `'Don't,' she said.`, "‘Don’t,’ she said."},
+		{"nested", `"It's 'fine'"`, "“It’s ‘fine’”"},
+		{"emphasis", `*"Hi"* and ("so")`, "*“Hi”* and (“so”)"},
+		{"dashes", "pages 3--4 --- or not", "pages 3–4 — or not"},
+		{"long dash run", "a ---- b", "a ---- b"},
+		{"ellipsis", "Wait... what.... no", "Wait… what.... no"},
+		{"code", "Run `echo \"--x\"` now", "Run `echo \"--x\"` now"},
+		{"html comment", "Text<!-- note: it's -->", "Text<!-- note: it's -->"},
+		{"link address", `[a "b"](http://x.org/a--b 'T')`, "[a “b”](http://x.org/a--b 'T')"},
+		{"critic", "{--old--}{++new++}", "{--old--}{++new++}"},
+		{"rule", "---", "---"},
+		{"table separator", "|---|:--:|", "|---|:--:|"},
+	}
+	for _, tt := range tests {
+		if got := smartenLine(tt.line); got != tt.want {
+			t.Errorf("%s: smartenLine(%q) = %q, want %q", tt.name, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestStraightenLine(t *testing.T) {
+	tests := []struct{ line, want string }{
+		{"“Don’t” – then — so…", `"Don't" -- then --- so...`},
+		{"`“code”` ‘x’", "`“code”` 'x'"},
+	}
+	for _, tt := range tests {
+		if got := straightenLine(tt.line); got != tt.want {
+			t.Errorf("straightenLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSmartPunctuationWhileTyping(t *testing.T) {
+	tests := []struct {
+		name  string
+		typed string
+		want  string
+	}{
+		{"quotes", `"It's" 'so'`, "“It’s” ‘so’"},
+		{"en dash", "3--4", "3–4"},
+		{"em dash", "yes---no", "yes—no"},
+		{"ellipsis", "so...", "so…"},
+		{"rule", "---", "---"},
+		{"code", "`a--b` c--d", "`a--b` c–d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp("draft.md")
+			a.config.SmartPunct = true
+			sendKeys(a, "i"+tt.typed)
+			if got := a.currentBuf().buf.Lines[0]; got != tt.want {
+				t.Errorf("typed %q, got %q, want %q", tt.typed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSmartPunctuationOff(t *testing.T) {
+	a := newTestApp("draft.md")
+	sendKeys(a, `i"a--b"`)
+	if got := a.currentBuf().buf.Lines[0]; got != `"a--b"` {
+		t.Errorf("smart punctuation is off by default, got %q", got)
+	}
+
+	a = newTestApp("main.go")
+	a.config.SmartPunct = true
+	sendKeys(a, `i"a"`)
+	if got := a.currentBuf().buf.Lines[0]; got != `"a"` {
+		t.Errorf("only prose files are smartened, got %q", got)
+	}
+}
+
+func TestSmartPunctuationUndo(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.executeCommand("set smartpunct")
+	sendKeys(a, "iso...\x1bu")
+	if got := a.currentBuf().buf.Lines[0]; got != "so..." {
+		t.Errorf("undo should take back the ellipsis and leave the dots, got %q", got)
+	}
+}
+
+func TestSmartPunctCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	lines := []string{
+		"---",
+		`title: "Draft"`,
+		"---",
+		`"Well," he said -- "wait..."`,
+		"```",
+		`x = "y" -- z`,
+		"```",
+		"Plain line.",
+	}
+	a.currentBuf().buf.Lines = append([]string(nil), lines...)
+	a.executeCommand("smartpunct")
+	want := append([]string(nil), lines...)
+	want[3] = "“Well,” he said – “wait…”"
+	if got := a.currentBuf().buf.Lines; !reflect.DeepEqual(got, want) {
+		t.Errorf(":smartpunct = %q, want %q", got, want)
+	}
+	if msg := a.statusBar.StatusMessage; msg != "Smartened punctuation on 1 line" {
+		t.Errorf("message = %q", msg)
+	}
+
+	a.executeCommand("smartpunct strip")
+	if got := a.currentBuf().buf.Lines; !reflect.DeepEqual(got, lines) {
+		t.Errorf(":smartpunct strip = %q, want %q", got, lines)
+	}
+	a.executeCommand("smartpunct strip")
+	if msg := a.statusBar.StatusMessage; msg != "No punctuation to straighten" {
+		t.Errorf("message = %q", msg)
+	}
+
+	sendKeys(a, "u")
+	if got := a.currentBuf().buf.Lines[3]; got != want[3] {
+		t.Errorf("undo should take back the whole strip, got %q", got)
+	}
+	a.executeCommand("smartpunct curly")
+	if msg := a.statusBar.StatusMessage; msg != "Usage: :smartpunct [strip]" {
+		t.Errorf("message = %q", msg)
+	}
+}
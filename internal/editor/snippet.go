@@ -0,0 +1,177 @@
+package editor
+
+import (
+	"maps"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Snippets are expanded by Tab in Edit mode, after their trigger. Unlike
+// abbreviations they only expand when asked, so triggers can be ordinary
+// words, and they can hold template variables and stops for Tab to jump
+// between.
+
+// defaultSnippets are available unless the snippets file redefines them.
+var defaultSnippets = map[string]string{
+	";date": "{{date}}",
+	";time": "{{time}}",
+	";fm":   "---\ntitle: $1\ndate: {{date}}\ntags: [$2]\n---\n\n$0",
+}
+
+// snippetsPath returns the location of the snippets file.
+func snippetsPath() string {
+	return filepath.Join(configDir(), "snippets")
+}
+
+// loadSnippets reads the snippets file over the defaults. On errors the
+// valid lines are still used and the problems returned.
+func loadSnippets() (map[string]string, error) {
+	snippets := maps.Clone(defaultSnippets)
+	user, err := loadExpansions(snippetsPath(), "snippet")
+	maps.Copy(snippets, user)
+	return snippets, err
+}
+
+// snippetStop is a place in an expanded snippet for Tab to jump to.
+type snippetStop struct {
+	line, col int
+}
+
+// snippetStops are the stops left in the snippet last expanded. They are
+// moved as the text typed at the current stop grows or breaks its line.
+type snippetStops struct {
+	eb        *EditorBuffer
+	stops     []snippetStop // Still to visit, in order
+	line, col int           // The current stop
+	lineLen   int           // Length of its line when the cursor arrived
+	lineCount int           // Lines in the buffer when the cursor arrived
+}
+
+// parseSnippet splits a snippet's text into lines and its stops, $1 to $9
+// and then $0, each at its first use. $$ is a dollar sign. Stop positions
+// are relative to the start of the snippet.
+func parseSnippet(text string) ([]string, []snippetStop) {
+	var b strings.Builder
+	found := make(map[int]snippetStop)
+	line, col := 0, 0
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '$' && i+1 < len(runes) {
+			next := runes[i+1]
+			if next == '$' {
+				i++
+			} else if next >= '0' && next <= '9' {
+				i++
+				n := int(next - '0')
+				if _, ok := found[n]; !ok {
+					found[n] = snippetStop{line, col}
+				}
+				continue
+			}
+		}
+		b.WriteRune(r)
+		if r == '\n' {
+			line, col = line+1, 0
+		} else {
+			col++
+		}
+	}
+	nums := make([]int, 0, len(found))
+	for n := range found {
+		nums = append(nums, n)
+	}
+	// $0 comes last.
+	sort.Slice(nums, func(i, j int) bool { return nums[j] == 0 || nums[i] != 0 && nums[i] < nums[j] })
+	stops := make([]snippetStop, len(nums))
+	for i, n := range nums {
+		stops[i] = found[n]
+	}
+	return strings.Split(b.String(), "\n"), stops
+}
+
+// expandSnippet replaces the snippet trigger before the cursor, if there is
+// one, with the snippet, and puts the cursor at its first stop, or after it.
+// The trigger runs back to whitespace. The expansion is one undo step.
+func (a *App) expandSnippet() bool {
+	eb := a.currentBuf()
+	if len(a.snippets) == 0 || eb.trackChanges {
+		return false
+	}
+	runes := []rune(eb.buf.Lines[eb.cursorLine])
+	end := min(eb.cursorCol, len(runes))
+	start := end
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	text, ok := a.snippets[string(runes[start:end])]
+	if !ok {
+		return false
+	}
+	text = expandTemplate(text, builtinTemplateVars(eb.buf.Filename, a.config, time.Now()))
+	body, stops := parseSnippet(text)
+
+	before, after := string(runes[:start]), string(runes[end:])
+	lines := append([]string(nil), body...)
+	lines[0] = before + lines[0]
+	lastLen := len([]rune(lines[len(lines)-1]))
+	lines[len(lines)-1] += after
+	eb.undo.PushReplaceLines(eb.cursorLine, []string{string(runes)}, lines, eb.cursorLine, eb.cursorCol)
+	replaceLines(eb.buf, eb.cursorLine, 1, lines)
+
+	for i := range stops {
+		if stops[i].line == 0 {
+			stops[i].col += start
+		}
+		stops[i].line += eb.cursorLine
+	}
+	a.snippet = nil
+	if len(stops) == 0 {
+		eb.cursorLine += len(lines) - 1
+		eb.cursorCol = lastLen
+		return true
+	}
+	a.snippet = &snippetStops{eb: eb, stops: stops}
+	a.nextSnippetStop()
+	return true
+}
+
+// nextSnippetStop moves the cursor to the next stop of the snippet last
+// expanded, reporting false if there is none. Text typed at the current
+// stop moves the stops after it on its line, and new lines move those
+// below.
+func (a *App) nextSnippetStop() bool {
+	s := a.snippet
+	eb := a.currentBuf()
+	if s == nil || s.eb != eb {
+		a.snippet = nil
+		return false
+	}
+	if s.lineCount > 0 { // Once a stop has been visited
+		dLines := eb.buf.LineCount() - s.lineCount
+		for i, stop := range s.stops {
+			switch {
+			case stop.line == s.line && stop.col >= s.col:
+				// Keep its distance from the end of the line, which may
+				// now be further down.
+				line := min(stop.line+dLines, eb.buf.LineCount()-1)
+				s.stops[i] = snippetStop{line, eb.buf.LineLen(line) - (s.lineLen - stop.col)}
+			case stop.line > s.line:
+				s.stops[i].line += dLines
+			}
+		}
+	}
+	stop := s.stops[0]
+	s.stops = s.stops[1:]
+	eb.cursorLine = max(min(stop.line, eb.buf.LineCount()-1), 0)
+	eb.cursorCol = max(min(stop.col, eb.buf.LineLen(eb.cursorLine)), 0)
+	s.line, s.col = eb.cursorLine, eb.cursorCol
+	s.lineLen, s.lineCount = eb.buf.LineLen(eb.cursorLine), eb.buf.LineCount()
+	if len(s.stops) == 0 {
+		a.snippet = nil
+	}
+	return true
+}
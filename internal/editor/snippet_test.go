@@ -0,0 +1,106 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestParseSnippet(t *testing.T) {
+	lines, stops := parseSnippet("Dear $1,\n\n$0\n\nYours, $2 ($$5) $1")
+	if want := []string{"Dear ,", "", "", "", "Yours,  ($5) "}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	if want := []snippetStop{{0, 5}, {4, 7}, {2, 0}}; !reflect.DeepEqual(stops, want) {
+		t.Errorf("stops = %v, want %v", stops, want)
+	}
+}
+
+func TestLoadSnippets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "prose"), 0755)
+	os.WriteFile(filepath.Join(dir, "prose", "snippets"), []byte(";date = {{date}} ({{time}})\nsig = Best,\\nAda\nbad\n"), 0644)
+
+	snippets, err := loadSnippets()
+	if err == nil || err.Error() != "line 3: expected snippet = expansion" {
+		t.Errorf("error = %v", err)
+	}
+	if snippets[";date"] != "{{date}} ({{time}})" || snippets["sig"] != "Best,\nAda" || snippets[";fm"] != defaultSnippets[";fm"] {
+		t.Errorf("the file should add to and override the defaults, got %q", snippets)
+	}
+}
+
+func TestTabExpandsSnippet(t *testing.T) {
+	a := newTestAppWithLines("Written ;date")
+	sendKeys(a, "A")
+	sendKey(a, terminal.KeyTab)
+	eb := a.currentBuf()
+	want := "Written " + time.Now().Format("2006-01-02")
+	if eb.buf.Lines[0] != want || eb.cursorCol != len(want) {
+		t.Errorf("Tab should expand ;date, got %q at %d", eb.buf.Lines[0], eb.cursorCol)
+	}
+
+	sendKeys(a, "\x1bu")
+	if eb.buf.Lines[0] != "Written ;date" {
+		t.Errorf("undo should take back the expansion, got %q", eb.buf.Lines[0])
+	}
+}
+
+func TestSnippetStops(t *testing.T) {
+	a := newTestAppWithLines(";fm")
+	sendKeys(a, "A")
+	eb := a.currentBuf()
+	sendKey(a, terminal.KeyTab)
+	if eb.cursorLine != 1 || eb.cursorCol != 7 {
+		t.Fatalf("the cursor should be at the title, got %d:%d", eb.cursorLine, eb.cursorCol)
+	}
+	sendKeys(a, "The Harbour")
+	sendKey(a, terminal.KeyTab)
+	if eb.cursorLine != 3 || eb.cursorCol != 7 {
+		t.Fatalf("Tab should jump to the tags, got %d:%d", eb.cursorLine, eb.cursorCol)
+	}
+	sendKeys(a, "draft, sea")
+	sendKey(a, terminal.KeyTab)
+	sendKeys(a, "It began.")
+	want := []string{"---", "title: The Harbour", "date: " + time.Now().Format("2006-01-02"), "tags: [draft, sea]", "---", "", "It began."}
+	if !reflect.DeepEqual(eb.buf.Lines, want) {
+		t.Errorf("got %q, want %q", eb.buf.Lines, want)
+	}
+	if a.snippet != nil {
+		t.Error("the snippet should be done after its last stop")
+	}
+}
+
+func TestSnippetStopsFollowEdits(t *testing.T) {
+	a := newTestAppWithLines("x")
+	sendKeys(a, "A")
+	a.snippets = map[string]string{"x": "($1) and ($2)\n[$3]"}
+	eb := a.currentBuf()
+	sendKey(a, terminal.KeyTab)
+	sendKeys(a, "one")
+	sendKey(a, terminal.KeyEnter)
+	sendKeys(a, "more")
+	sendKey(a, terminal.KeyTab)
+	if eb.cursorLine != 1 || eb.cursorCol != 11 {
+		t.Errorf("the second stop should follow a new line, got %d:%d in %q", eb.cursorLine, eb.cursorCol, eb.buf.Lines)
+	}
+	sendKey(a, terminal.KeyTab)
+	if eb.cursorLine != 2 || eb.cursorCol != 1 {
+		t.Errorf("the third stop should move down a line, got %d:%d in %q", eb.cursorLine, eb.cursorCol, eb.buf.Lines)
+	}
+}
+
+func TestSnippetEndsOnEscape(t *testing.T) {
+	a := newTestAppWithLines(";fm")
+	sendKeys(a, "A")
+	sendKey(a, terminal.KeyTab)
+	sendKeys(a, "\x1b")
+	if a.snippet != nil {
+		t.Error("Esc should forget the snippet's stops")
+	}
+}
@@ -0,0 +1,69 @@
+package editor
+
+import (
+	"time"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+// The spell checker trains on a large dictionary, which takes a few seconds,
+// so it loads in the background: the editor opens at once, and misspellings
+// are marked when it is ready. Spell checks due before then wait for it.
+
+// spellLoadPollInterval is how often the main loop checks whether the spell
+// checker has loaded.
+const spellLoadPollInterval = 100 * time.Millisecond
+
+// spellLoad is the outcome of loading the spell checker.
+type spellLoad struct {
+	checker *spell.SpellChecker
+	err     error
+}
+
+// startSpellLoad starts loading the spell checker in the background.
+func (a *App) startSpellLoad() {
+	done := make(chan spellLoad, 1)
+	a.spellLoading = done
+	go func() {
+		sc, err := spell.NewSpellChecker()
+		done <- spellLoad{checker: sc, err: err}
+	}()
+}
+
+// pollSpellLoad takes the spell checker once it has loaded, adds the
+// project's dictionary to it and checks the buffers. Reports whether the
+// screen changed.
+func (a *App) pollSpellLoad() bool {
+	if a.spellLoading == nil {
+		return false
+	}
+	var l spellLoad
+	select {
+	case l = <-a.spellLoading:
+	default:
+		return false
+	}
+	a.spellLoading = nil
+	if l.err != nil {
+		a.errorLog("spell checker", l.err)
+		a.spellCheckEnabled = false
+		a.statusBar.SetError("Spell check unavailable: " + l.err.Error())
+		return true
+	}
+	a.spellChecker = l.checker
+	if err := a.addProjectWords(); err != nil {
+		a.errorLog("project", err)
+	}
+
+	// Check every buffer that should be checked, along with its citations.
+	for _, eb := range a.buffers {
+		if a.spellCheckEnabled && eb.ShouldSpellCheck() {
+			if err := a.loadBibliography(eb); err != nil {
+				a.statusBar.SetError("Bibliography: " + err.Error())
+			}
+			eb.spellErrors = eb.checkLines(a.spellChecker)
+			eb.spellCheckPending = false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package editor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSpellLoad(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.spellCheckEnabled = true
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"A wrod spelt wrongly."}
+	eb.spellCheckPending = true
+	eb.lastEdit = time.Now().Add(-time.Minute)
+
+	a.startSpellLoad()
+	if d := a.wait(time.Now()); d != spellLoadPollInterval {
+		t.Errorf("the loop should poll while loading, not run the due spell check: waits %v", d)
+	}
+	a.toggleSpellCheck()
+	a.toggleSpellCheck()
+	if msg := a.statusBar.StatusMessage; msg != "Spell check enabled; loading the dictionary" {
+		t.Errorf("message = %q", msg)
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	for !a.pollSpellLoad() {
+		if time.Now().After(deadline) {
+			t.Fatal("the spell checker never loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if a.spellChecker == nil || a.spellLoading != nil {
+		t.Fatal("the loaded spell checker should be taken")
+	}
+	if len(eb.spellErrors) != 1 || eb.spellErrors[0].Word != "wrod" || eb.spellCheckPending {
+		t.Errorf("the buffer should be checked once loaded, got %+v", eb.spellErrors)
+	}
+	if a.pollSpellLoad() {
+		t.Error("nothing more to take")
+	}
+}
+
+func TestSpellLoadFailed(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.spellCheckEnabled = true
+	done := make(chan spellLoad, 1)
+	done <- spellLoad{err: errors.New("no dictionary")}
+	a.spellLoading = done
+	if !a.pollSpellLoad() || a.spellCheckEnabled {
+		t.Fatal("a failed load should turn spell checking off")
+	}
+	if msg := a.statusBar.StatusMessage; msg != "Spell check unavailable: no dictionary" {
+		t.Errorf("message = %q", msg)
+	}
+	a.toggleSpellCheck()
+	if a.spellCheckEnabled {
+		t.Error("spell checking can't be turned on without a checker")
+	}
+}
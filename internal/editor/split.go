@@ -0,0 +1,247 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// SplitLayout is how the screen is divided between windows.
+type SplitLayout int
+
+const (
+	SplitNone       SplitLayout = iota
+	SplitStacked                // One window above the other (:split)
+	SplitSideBySide             // Windows next to each other (:vsplit)
+)
+
+// Window is the saved view of the unfocused window in a split. The focused
+// window's cursor and scroll position live in its EditorBuffer as usual, so
+// all editing code works unchanged; they are swapped on focus change.
+type Window struct {
+	buffer       *EditorBuffer
+	cursorLine   int
+	cursorCol    int
+	scrollOffset int
+}
+
+// Split holds the state of a two-window split.
+type Split struct {
+	Layout  SplitLayout
+	Other   Window // The unfocused window
+	Second  bool   // Focus is in the second (bottom or right) window
+	Preview bool   // The second window previews the focused buffer (:preview)
+}
+
+// paneViewports returns the viewports of the first and second windows for
+// the current terminal size and split layout.
+func (a *App) paneViewports() (first, second *Viewport) {
+	w, h := a.termWidth, a.termHeight
+	pane := func(top, left, width, height int, shared bool) *Viewport {
+		vp := &Viewport{Width: width, Height: height, Top: top, Left: left, Pane: shared}
+		vp.CopyLayout(a.viewport)
+		return vp
+	}
+	switch a.split.Layout {
+	case SplitStacked:
+		h1 := h / 2
+		return pane(0, 0, w, h1, false), pane(h1, 0, w, h-h1, false)
+	case SplitSideBySide:
+		w1 := (w - 1) / 2 // One column for the divider
+		return pane(0, 0, w1, h, true), pane(0, w1+1, w-w1-1, h, true)
+	}
+	full := pane(0, 0, w, h, false)
+	return full, full
+}
+
+// applyLayout points the app viewport at the focused window.
+func (a *App) applyLayout() {
+	first, second := a.paneViewports()
+	if a.split.Second {
+		a.viewport = second
+	} else {
+		a.viewport = first
+	}
+}
+
+// otherViewport returns the viewport of the unfocused window.
+func (a *App) otherViewport() *Viewport {
+	first, second := a.paneViewports()
+	if a.split.Second {
+		return first
+	}
+	return second
+}
+
+// screenViewport returns a viewport covering the whole terminal, used to
+// centre overlays across a split.
+func (a *App) screenViewport() *Viewport {
+	if a.split.Layout == SplitNone {
+		return a.viewport
+	}
+	vp := NewViewport(a.termWidth, a.termHeight)
+	vp.CopyLayout(a.viewport)
+	return vp
+}
+
+// saveView captures the focused window's view.
+func (a *App) saveView() Window {
+	eb := a.currentBuf()
+	return Window{buffer: eb, cursorLine: eb.cursorLine, cursorCol: eb.cursorCol, scrollOffset: eb.scrollOffset}
+}
+
+// restoreView makes w the focused window's view. If its buffer has been
+// closed, the current buffer is kept.
+func (a *App) restoreView(w Window) {
+	if idx := a.bufferIndex(w.buffer); idx >= 0 {
+		a.currentBuffer = idx
+	}
+	eb := a.currentBuf()
+	eb.cursorLine = max(0, min(w.cursorLine, eb.buf.LineCount()-1))
+	eb.cursorCol = max(0, min(w.cursorCol, eb.buf.LineLen(eb.cursorLine)))
+	eb.scrollOffset = w.scrollOffset
+}
+
+// splitWindow divides the screen into two windows showing the current
+// buffer, then opens filename (if any) in the new, focused window. Splitting
+// again switches the layout.
+func (a *App) splitWindow(layout SplitLayout, filename string) {
+	prev := a.split
+	a.split.Layout = layout
+	first, second := a.paneViewports()
+	a.split = prev
+	if first.TooSmall() || second.TooSmall() {
+		a.statusBar.SetMessage("Terminal too small to split")
+		return
+	}
+
+	if a.split.Layout == SplitNone || a.split.Preview {
+		a.split = Split{Other: a.saveView(), Second: true}
+	}
+	a.split.Layout = layout
+	if filename != "" {
+		a.currentBuffer = a.openBuffer(filename)
+	}
+	a.applyLayout()
+}
+
+// switchWindow moves focus to the other window of a split.
+func (a *App) switchWindow() {
+	if a.split.Layout == SplitNone {
+		a.statusBar.SetMessage("No split. Use :split or :vsplit")
+		return
+	}
+	if a.split.Preview {
+		a.statusBar.SetMessage("The preview follows the cursor; :preview closes it")
+		return
+	}
+	focused := a.saveView()
+	a.restoreView(a.split.Other)
+	a.split.Other = focused
+	a.split.Second = !a.split.Second
+	a.applyLayout()
+}
+
+// closeWindow closes the focused window of a split; the buffer stays open.
+func (a *App) closeWindow() {
+	if a.split.Preview {
+		a.closeOtherWindow()
+		return
+	}
+	a.switchWindow()
+	a.closeOtherWindow()
+}
+
+// closeOtherWindow closes the unfocused window (:only).
+func (a *App) closeOtherWindow() {
+	a.split = Split{}
+	a.applyLayout()
+}
+
+// renderOtherWindow draws the unfocused window of a split, without a cursor.
+func (a *App) renderOtherWindow() string {
+	if a.split.Preview {
+		return a.renderPreview()
+	}
+	w := &a.split.Other
+	if a.bufferIndex(w.buffer) < 0 {
+		w.buffer = a.currentBuf()
+	}
+	eb := w.buffer
+	vp := a.otherViewport()
+	a.renderer.gitSigns = a.gitSigns(eb)
+	vp.SetGutter(gutterWidth(a.renderer.lineNumbers, eb.buf.LineCount(), a.renderer.gitSigns != nil))
+
+	w.cursorLine = max(0, min(w.cursorLine, eb.buf.LineCount()-1))
+	displayLines := eb.DisplayLines(vp.ColWidth)
+	if a.concealsMarkup(eb) {
+		concealMarkup(displayLines, eb.buf.Lines, w.cursorLine)
+	}
+	cursorDL, _ := CursorToDisplayLine(displayLines, w.cursorLine, w.cursorCol)
+	vp.EnsureCursorVisible(cursorDL, &w.scrollOffset)
+	w.scrollOffset = max(0, min(w.scrollOffset, len(displayLines)-1))
+
+	left := (&StatusBar{Concise: a.statusBar.Concise, Theme: a.statusBar.Theme, ReadOnly: eb.readOnly}).FormatLeft(eb.Filename(), eb.IsDirty(), "", eb.SpellErrorCount(), eb.isScratch)
+	right := fmt.Sprintf("%d words ", eb.WordCount())
+	if a.statusBar.Concise {
+		right = ""
+	}
+	return a.renderer.RenderFrame(displayLines, vp, w.scrollOffset, -1, 0, left, right, eb.highlighter, eb.spellErrors, ModeDefault, -1, -1, eb.searchActive, eb.searchMatches, eb.searchCurrentIdx)
+}
+
+// renderDivider draws the column between side-by-side windows.
+func (a *App) renderDivider() string {
+	first, _ := a.paneViewports()
+	col := first.Left + first.Width + 1
+	if a.renderer.screenReader {
+		// Plain ASCII, held back with the other rows so it isn't redrawn.
+		for row := 1; row <= a.termHeight; row++ {
+			a.renderer.putRow(row, col, "|")
+		}
+		return ""
+	}
+	if a.renderer.lowBandwidth {
+		for row := 1; row <= a.termHeight; row++ {
+			a.renderer.putRow(row, col, "\x1b[90m│\x1b[0m")
+		}
+		return ""
+	}
+	var b strings.Builder
+	for row := 1; row <= a.termHeight; row++ {
+		fmt.Fprintf(&b, "\x1b[%d;%dH\x1b[90m│\x1b[0m", row, col)
+	}
+	return b.String()
+}
+
+// tooSmall reports whether the terminal, or either window of a split, is
+// below the minimum size.
+func (a *App) tooSmall() bool {
+	if a.split.Layout != SplitNone && a.otherViewport().TooSmall() {
+		return true
+	}
+	return a.viewport.TooSmall()
+}
+
+// handleTooSmallInput handles input while the "too small" screen is up.
+// Most keys are ignored, since the user can't see what they would do, but
+// Esc and : commands still work, so :q or :only can get out of it.
+func (a *App) handleTooSmallInput(event terminal.InputEvent) {
+	if event.Type != terminal.EventKey {
+		return
+	}
+	key := event.Key
+	command := key.Type == terminal.KeyRune && key.Rune == ':' && a.mode == ModeDefault && !a.overlayActive()
+	if a.statusBar.Prompt != PromptNone || command || key.Type == terminal.KeyEscape {
+		a.handleInput(event)
+	}
+}
+
+// inOtherWindow reports whether a screen position lies in the unfocused window.
+func (a *App) inOtherWindow(row, col int) bool {
+	if a.split.Layout == SplitNone {
+		return false
+	}
+	vp := a.otherViewport()
+	return row > vp.Top && row <= vp.Top+vp.Height && col > vp.Left && col <= vp.Left+vp.Width
+}
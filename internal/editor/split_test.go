@@ -0,0 +1,211 @@
+package editor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestSplitPaneViewports(t *testing.T) {
+	a := newSplitTestApp(t)
+
+	a.split.Layout = SplitStacked
+	top, bottom := a.paneViewports()
+	if top.Top != 0 || top.Height != 12 || bottom.Top != 12 || bottom.Height != 12 {
+		t.Errorf("stacked panes = %+v / %+v", top, bottom)
+	}
+	if top.Width != 81 || bottom.Width != 81 {
+		t.Error("stacked panes should span the full width")
+	}
+
+	a.split.Layout = SplitSideBySide
+	left, right := a.paneViewports()
+	if left.Width != 40 || right.Left != 41 || right.Width != 40 {
+		t.Errorf("side-by-side panes = %+v / %+v", left, right)
+	}
+	if !left.Pane || !right.Pane {
+		t.Error("side-by-side panes must not erase each other's rows")
+	}
+}
+
+func TestSplitTwoViewsOfSameBuffer(t *testing.T) {
+	a := newSplitTestApp(t)
+	eb := a.currentBuf()
+	eb.cursorLine = 3
+
+	a.executeCommand("split")
+	if a.split.Layout != SplitStacked || !a.split.Second {
+		t.Fatalf("split = %+v, want stacked with focus in the second window", a.split)
+	}
+	if a.viewport.Top != 12 {
+		t.Errorf("focused viewport top = %d, want 12", a.viewport.Top)
+	}
+
+	// Move in the new window, then switch back: each window keeps its cursor.
+	eb.cursorLine = 0
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: ' '})
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: 'w'})
+	if eb.cursorLine != 3 {
+		t.Errorf("first window cursor = %d, want 3", eb.cursorLine)
+	}
+	if a.split.Other.cursorLine != 0 {
+		t.Errorf("second window cursor = %d, want 0", a.split.Other.cursorLine)
+	}
+	if a.split.Second || a.viewport.Top != 0 {
+		t.Error("focus should move to the first window")
+	}
+}
+
+func TestVsplitOpensFile(t *testing.T) {
+	a := newSplitTestApp(t)
+	other := filepath.Join(t.TempDir(), "notes.md")
+
+	a.executeCommand("vsplit " + other)
+	if a.split.Layout != SplitSideBySide {
+		t.Fatal(":vsplit should split side by side")
+	}
+	if filepath.Base(a.currentBuf().buf.Filename) != "notes.md" {
+		t.Errorf("focused window shows %q, want notes.md", a.currentBuf().buf.Filename)
+	}
+	if filepath.Base(a.split.Other.buffer.buf.Filename) != "a.md" {
+		t.Error("other window should keep the original buffer")
+	}
+}
+
+func TestSplitQuitClosesWindowOnly(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.executeCommand("vsplit " + filepath.Join(t.TempDir(), "notes.md"))
+
+	a.executeCommand("q")
+	if a.quit {
+		t.Fatal(":q in a split should not quit prose")
+	}
+	if a.split.Layout != SplitNone {
+		t.Error(":q should close the focused window")
+	}
+	if filepath.Base(a.currentBuf().buf.Filename) != "a.md" {
+		t.Error("the remaining window should be focused")
+	}
+	if len(a.buffers) != 2 {
+		t.Error("closing a window should leave its buffer open")
+	}
+	if a.viewport.Width != 81 || a.viewport.Pane {
+		t.Error("closing the split should restore the full-screen viewport")
+	}
+}
+
+func TestSplitOnly(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.executeCommand("sp")
+	a.executeCommand("only")
+	if a.split.Layout != SplitNone || a.viewport.Height != 24 {
+		t.Error(":only should return to a single window")
+	}
+}
+
+func TestSwitchWindowWithoutSplit(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.switchWindow()
+	if !strings.Contains(a.statusBar.StatusMessage, "No split") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestRenderSplitDrawsBothWindows(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.executeCommand("vsplit " + filepath.Join(t.TempDir(), "notes.md"))
+	a.currentBuf().buf.Lines = []string{"right side"}
+
+	frame := a.renderOtherWindow() + a.renderDivider()
+	if !strings.Contains(frame, "three") {
+		t.Error("unfocused window should be drawn")
+	}
+	if !strings.Contains(frame, "│") {
+		t.Error("side-by-side split should draw a divider")
+	}
+	if strings.Contains(frame, "\x1b[K") {
+		t.Error("side-by-side panes should pad rather than erase to end of line")
+	}
+}
+
+func TestMouseClickFocusesOtherWindow(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.executeCommand("sp")
+	// Focus is in the bottom window; click in the top one.
+	a.handleMouse(terminal.MouseEvent{Button: terminal.MouseLeft, Press: true, Row: 3, Col: 30})
+	if a.split.Second {
+		t.Error("clicking the top window should focus it")
+	}
+	if a.currentBuf().cursorLine != 1 {
+		t.Errorf("cursor line = %d, want 1", a.currentBuf().cursorLine)
+	}
+}
+
+func TestSplitRefusedWhenTooSmall(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.termWidth, a.termHeight = 30, 24
+	a.viewport = NewViewport(30, 24)
+
+	a.executeCommand("vsplit")
+	if a.split.Layout != SplitNone {
+		t.Error("side-by-side panes of 14 columns are below the minimum")
+	}
+	if !strings.Contains(a.statusBar.StatusMessage, "too small") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+
+	a.executeCommand("split")
+	if a.split.Layout != SplitStacked {
+		t.Error("stacked split should fit")
+	}
+}
+
+func TestTooSmallAfterResize(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.relayout(15, 10)
+	if !a.tooSmall() {
+		t.Error("15 columns is below the minimum")
+	}
+	a.relayout(80, 24)
+	if a.tooSmall() {
+		t.Error("layout should recover once the terminal grows")
+	}
+
+	a.executeCommand("sp")
+	a.relayout(80, 8) // Panes of 4 rows
+	if !a.tooSmall() {
+		t.Error("a split whose panes are too short should count as too small")
+	}
+}
+
+func TestTooSmallKeepsCommandsAndEscape(t *testing.T) {
+	a := newSplitTestApp(t)
+	a.executeCommand("sp")
+	a.relayout(80, 8)
+
+	// Ordinary keys are still ignored.
+	a.handleTooSmallInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyRune, Rune: 'i'}})
+	if a.mode != ModeDefault {
+		t.Error("keys other than : and Esc should be ignored")
+	}
+
+	// Esc still cancels a prompt.
+	for _, r := range ":x" {
+		a.handleTooSmallInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyRune, Rune: r}})
+	}
+	a.handleTooSmallInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyEscape}})
+	if a.statusBar.Prompt != PromptNone {
+		t.Error("Esc should close the prompt")
+	}
+
+	// :only closes the split and brings the editor back.
+	for _, r := range ":only" {
+		a.handleTooSmallInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyRune, Rune: r}})
+	}
+	a.handleTooSmallInput(terminal.InputEvent{Type: terminal.EventKey, Key: terminal.Key{Type: terminal.KeyEnter}})
+	if a.tooSmall() {
+		t.Error(":only should close the split")
+	}
+}
@@ -0,0 +1,287 @@
+package editor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// DayCount is a project's total word count over one day.
+type DayCount struct {
+	Start int `json:"start"` // First total seen that day
+	End   int `json:"end"`   // Latest total
+}
+
+// WordHistory holds the daily totals of each project, by project root and
+// then by date (2006-01-02).
+type WordHistory map[string]map[string]DayCount
+
+// wordHistoryPath returns the location of the word count history.
+func wordHistoryPath() string {
+	return filepath.Join(stateDir(), "wordcounts.json")
+}
+
+// loadWordHistory reads the word count history. A missing or unreadable file
+// yields an empty history.
+func loadWordHistory() WordHistory {
+	h := make(WordHistory)
+	data, err := os.ReadFile(wordHistoryPath())
+	if err != nil {
+		return h
+	}
+	json.Unmarshal(data, &h)
+	return h
+}
+
+// saveWordHistory writes the word count history, creating its directory if
+// needed.
+func saveWordHistory(h WordHistory) error {
+	if err := os.MkdirAll(stateDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(wordHistoryPath(), append(data, '\n'), 0600)
+}
+
+// Record notes a project's total for the given day.
+func (h WordHistory) Record(root, date string, total int) {
+	days := h[root]
+	if days == nil {
+		days = make(map[string]DayCount)
+		h[root] = days
+	}
+	day, ok := days[date]
+	if !ok {
+		day.Start = total
+	}
+	day.End = total
+	days[date] = day
+}
+
+// DayProgress is the change in a project's word count on one day.
+type DayProgress struct {
+	Date  string
+	Total int
+	Delta int
+}
+
+// Progress returns a project's daily totals, newest first. Each day's delta
+// is measured from the previous recorded day's total, or from the day's
+// first total for the earliest day.
+func (h WordHistory) Progress(root string) []DayProgress {
+	days := h[root]
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	progress := make([]DayProgress, len(dates))
+	for i, date := range dates {
+		base := days[date].Start
+		if i > 0 {
+			base = days[dates[i-1]].End
+		}
+		progress[len(dates)-1-i] = DayProgress{Date: date, Total: days[date].End, Delta: days[date].End - base}
+	}
+	return progress
+}
+
+// fileWordCount is a cached word count for a file on disk.
+type fileWordCount struct {
+	modTime time.Time
+	size    int64
+	words   int
+}
+
+// fileWords returns the word count of a file, from its buffer when it is open
+// so unsaved changes count. Counts of files on disk are cached until the
+// file changes.
+func (a *App) fileWords(path string) (int, error) {
+	for _, eb := range a.buffers {
+		if eb.buf.Filename != "" && absPath(eb.buf.Filename) == path {
+			return eb.buf.WordCount(), nil
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if c, ok := a.wordCache[path]; ok && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.words, nil
+	}
+	buf := NewBuffer(path)
+	if err := buf.Load(); err != nil {
+		return 0, err
+	}
+	if a.wordCache == nil {
+		a.wordCache = make(map[string]fileWordCount)
+	}
+	words := buf.WordCount()
+	a.wordCache[path] = fileWordCount{modTime: info.ModTime(), size: info.Size(), words: words}
+	return words, nil
+}
+
+// StatsRow is one row of the stats overlay: a heading, a file or a day.
+type StatsRow struct {
+	Label   string
+	File    string // Set for file rows, which Enter opens
+	Heading bool
+}
+
+// StatsView manages the project stats overlay.
+type StatsView struct {
+	ListView[StatsRow]
+}
+
+// Show activates the overlay with the given rows, selecting the first that
+// isn't a heading.
+func (s *StatsView) Show(rows []StatsRow) {
+	s.ListView.Show(rows)
+	if len(rows) > 0 && rows[0].Heading {
+		s.MoveDown()
+	}
+}
+
+// MoveUp moves the selection to the previous row, skipping headings.
+func (s *StatsView) MoveUp() {
+	for i := s.Selected - 1; i >= 0; i-- {
+		if !s.Items[i].Heading {
+			s.Selected = i
+			return
+		}
+	}
+}
+
+// MoveDown moves the selection to the next row, skipping headings.
+func (s *StatsView) MoveDown() {
+	for i := s.Selected + 1; i < len(s.Items); i++ {
+		if !s.Items[i].Heading {
+			s.Selected = i
+			return
+		}
+	}
+}
+
+// VisibleItems returns the rows that fit in maxHeight, scrolled to keep the
+// selection (and the heading just above it) visible.
+func (s *StatsView) VisibleItems(maxHeight int) []StatsRow {
+	top := s.Selected
+	if top > 0 && top < len(s.Items) && s.Items[top-1].Heading {
+		top--
+	}
+	s.ScrollOffset = min(s.ScrollOffset, top)
+	return s.ListView.VisibleItems(maxHeight)
+}
+
+// projectWordCounts returns the word count of each project file, or of the
+// Markdown files beside the current file when there is no project, and the
+// directory the files are shown relative to.
+func (a *App) projectWordCounts() (files []string, counts []int, root string, err error) {
+	files, root, err = a.projectFiles()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	counts = make([]int, len(files))
+	for i, path := range files {
+		counts[i], _ = a.fileWords(path) // A missing chapter counts as empty.
+	}
+	return files, counts, root, nil
+}
+
+// recordProjectWords adds the project's current total to the history. It
+// does nothing without a project file.
+func (a *App) recordProjectWords(now time.Time) {
+	if a.project == nil {
+		return
+	}
+	total := 0
+	for _, path := range a.project.Chapters {
+		n, _ := a.fileWords(path)
+		total += n
+	}
+	h := loadWordHistory()
+	h.Record(a.project.Root, now.Format("2006-01-02"), total)
+	if err := saveWordHistory(h); err != nil {
+		a.errorLog("word history", err)
+	}
+}
+
+// statsRows builds the overlay rows: the total, each file's count and the
+// daily progress.
+func statsRows(files, names []string, counts []int, progress []DayProgress) []StatsRow {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	rows := []StatsRow{{Label: fmt.Sprintf("%d words in %d file(s)", total, len(files)), Heading: true}}
+	for i, path := range files {
+		rows = append(rows, StatsRow{Label: fmt.Sprintf("  %-30s %7d", names[i], counts[i]), File: path})
+	}
+	if len(progress) > 0 {
+		rows = append(rows, StatsRow{Label: "Daily progress", Heading: true})
+	}
+	for _, p := range progress {
+		label := p.Date
+		if date, err := time.Parse("2006-01-02", p.Date); err == nil {
+			label = date.Format("Mon 2 Jan 2006")
+		}
+		rows = append(rows, StatsRow{Label: fmt.Sprintf("  %-30s %7d  %+d", label, p.Total, p.Delta)})
+	}
+	return rows
+}
+
+// showStats opens the project stats overlay, recording today's total first.
+func (a *App) showStats() {
+	files, counts, root, err := a.projectWordCounts()
+	if err != nil {
+		a.statusBar.SetError("Stats: " + err.Error())
+		return
+	}
+	if len(files) == 0 {
+		a.statusBar.SetMessage("No project files found")
+		return
+	}
+	var progress []DayProgress
+	if a.project != nil {
+		a.recordProjectWords(time.Now())
+		progress = loadWordHistory().Progress(root)
+	}
+	names := make([]string, len(files))
+	for i, path := range files {
+		if names[i], err = filepath.Rel(root, path); err != nil {
+			names[i] = path
+		}
+	}
+	a.stats.Show(statsRows(files, names, counts, progress))
+}
+
+func (a *App) handleStatsKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.stats.Hide()
+	case terminal.KeyUp:
+		a.stats.MoveUp()
+	case terminal.KeyDown:
+		a.stats.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.stats.MoveUp()
+		case 'j':
+			a.stats.MoveDown()
+		}
+	case terminal.KeyEnter:
+		if s := a.stats.Selected; s < len(a.stats.Items) && a.stats.Items[s].File != "" {
+			a.currentBuffer = a.openBuffer(a.stats.Items[s].File)
+		}
+		a.stats.Hide()
+	}
+}
@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestWordHistoryProgress(t *testing.T) {
+	h := make(WordHistory)
+	h.Record("/book", "2024-05-30", 1000)
+	h.Record("/book", "2024-05-30", 1200)
+	h.Record("/book", "2024-06-02", 1500)
+	h.Record("/book", "2024-06-02", 1450)
+	h.Record("/other", "2024-06-02", 10)
+
+	want := []DayProgress{
+		{Date: "2024-06-02", Total: 1450, Delta: 250},
+		{Date: "2024-05-30", Total: 1200, Delta: 200},
+	}
+	if got := h.Progress("/book"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Progress = %+v, want %+v", got, want)
+	}
+	if got := h.Progress("/missing"); len(got) != 0 {
+		t.Errorf("unknown project: %+v", got)
+	}
+}
+
+func TestWordHistoryRoundTrip(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	h := loadWordHistory()
+	h.Record("/book", "2024-05-30", 42)
+	if err := saveWordHistory(h); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadWordHistory()["/book"]["2024-05-30"]; got != (DayCount{Start: 42, End: 42}) {
+		t.Errorf("reloaded %+v", got)
+	}
+}
+
+func TestStatsRows(t *testing.T) {
+	rows := statsRows(
+		[]string{"/b/one.md", "/b/two.md"},
+		[]string{"one.md", "two.md"},
+		[]int{300, 45},
+		[]DayProgress{{Date: "2024-05-31", Total: 345, Delta: 45}},
+	)
+	var labels []string
+	for _, r := range rows {
+		labels = append(labels, strings.Join(strings.Fields(r.Label), " "))
+	}
+	want := []string{"345 words in 2 file(s)", "one.md 300", "two.md 45", "Daily progress", "Fri 31 May 2024 345 +45"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("rows = %q, want %q", labels, want)
+	}
+	if rows[1].File != "/b/one.md" || !rows[3].Heading {
+		t.Errorf("row kinds wrong: %+v", rows)
+	}
+}
+
+func TestFileWordsCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ch.md")
+	if err := os.WriteFile(path, []byte("one two three\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a := newTestApp("")
+	if n, err := a.fileWords(path); err != nil || n != 3 {
+		t.Fatalf("fileWords = %d, %v", n, err)
+	}
+	if err := os.WriteFile(path, []byte("one two three four five\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := a.fileWords(path); n != 5 {
+		t.Errorf("changed file should be recounted, got %d", n)
+	}
+}
+
+func TestShowStatsRecordsProgress(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeChapters(t, dir, map[string]string{
+		projectFileName: "chapter = one.md\nchapter = two.md\n",
+		"one.md":        "one two three\n",
+		"two.md":        "four five\n",
+	})
+	a := newTestApp(filepath.Join(dir, "one.md"))
+	a.currentBuf().buf.Lines = []string{"one two three extra"}
+	a.showStats()
+
+	if !a.stats.Active {
+		t.Fatalf("stats should be open: %s", a.statusBar.StatusMessage)
+	}
+	if got := a.stats.Items[0].Label; got != "6 words in 2 file(s)" {
+		t.Errorf("total row = %q", got)
+	}
+	today := time.Now().Format("2006-01-02")
+	if got := loadWordHistory()[absPath(dir)][today]; got.End != 6 {
+		t.Errorf("today's record = %+v, want end 6", got)
+	}
+
+	// Enter on a file row opens it.
+	a.stats.Selected = 2
+	a.handleStatsKey(terminal.Key{Type: terminal.KeyEnter})
+	if filepath.Base(a.currentBuf().buf.Filename) != "two.md" || a.stats.Active {
+		t.Errorf("Enter should open two.md, current %s", a.currentBuf().buf.Filename)
+	}
+}
@@ -3,6 +3,7 @@ package editor
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/JackWReid/prose/internal/terminal"
 )
@@ -11,17 +12,47 @@ import (
 type PromptType int
 
 const (
-	PromptNone    PromptType = iota
-	PromptSaveNew            // "Save as: " for unnamed buffer on first save
-	PromptCommand            // ":" command input
-	PromptSearch             // "/" search input
+	PromptNone        PromptType = iota
+	PromptSaveNew                // "Save as: " for unnamed buffer on first save
+	PromptCommand                // ":" command input
+	PromptSearch                 // "/" search input
+	PromptNote                   // "Note: " annotation text
+	PromptTemplateVar            // Value for a template variable, labelled with its name
+	PromptNewFile                // "New file: " name in the browser's directory
+	PromptRenameFile             // "Rename to: " new name for the browser's selection
 )
 
 // StatusBar generates status bar text and handles prompt state.
 type StatusBar struct {
 	Prompt        PromptType
 	PromptText    string // User input during rename/save-as prompts.
+	PromptLabel   string // What a template variable prompt is asking for, or why a Save as prompt is.
+	ReadOnly      bool   // The buffer can't be edited; marked [RO] after its name
 	StatusMessage string // Temporary message (e.g. error from command mode).
+	MessageError  bool   // StatusMessage is an error, kept until Esc dismisses it
+	Concise       bool   // Plain text without counts that change while typing, for screen readers.
+	Theme         *Theme // Colours; nil for the default theme
+	Ambient       string // Session information shown in place of the file name, when set
+
+	// Entries recalled with Up and Down in the command and search prompts,
+	// oldest first.
+	CommandHistory []string
+	SearchHistory  []string
+	historyIdx     int    // Entry shown in the prompt; the history's length when none is
+	historyDraft   string // What was typed before moving into the history
+
+	// The last maxMessages messages shown, oldest first, for :messages.
+	Messages []LoggedMessage
+}
+
+// maxMessages is how many status messages are kept for :messages.
+const maxMessages = 100
+
+// LoggedMessage is a status message as it was shown.
+type LoggedMessage struct {
+	Time  time.Time
+	Text  string
+	Error bool
 }
 
 func NewStatusBar() *StatusBar {
@@ -32,6 +63,9 @@ func NewStatusBar() *StatusBar {
 // bufferInfo is an optional "[2/3]" indicator when multiple buffers are open.
 // spellErrorCount is the number of spelling errors in the buffer.
 func (s *StatusBar) FormatLeft(filename string, dirty bool, bufferInfo string, spellErrorCount int, isScratch bool) string {
+	if s.Prompt == PromptSaveNew && s.PromptLabel != "" {
+		return fmt.Sprintf(" %s: %s", s.PromptLabel, s.PromptText)
+	}
 	if s.Prompt == PromptSaveNew {
 		return fmt.Sprintf(" Save as: %s", s.PromptText)
 	}
@@ -41,25 +75,56 @@ func (s *StatusBar) FormatLeft(filename string, dirty bool, bufferInfo string, s
 	if s.Prompt == PromptSearch {
 		return fmt.Sprintf(" /%s", s.PromptText)
 	}
+	if s.Prompt == PromptNote {
+		return fmt.Sprintf(" Note: %s", s.PromptText)
+	}
+	if s.Prompt == PromptTemplateVar {
+		return fmt.Sprintf(" %s: %s", s.PromptLabel, s.PromptText)
+	}
+	if s.Prompt == PromptNewFile {
+		return fmt.Sprintf(" New file: %s", s.PromptText)
+	}
+	if s.Prompt == PromptRenameFile {
+		return fmt.Sprintf(" Rename to: %s", s.PromptText)
+	}
 
 	if s.StatusMessage != "" {
 		return " " + s.StatusMessage
 	}
+	if s.Ambient != "" {
+		return " " + s.Ambient
+	}
 
 	name := truncatePathScratch(filename, isScratch)
 
-	// Colour dirty filenames bold + darker orange via ANSI codes.
-	// In reverse video mode, use background code to set text color.
+	if s.Concise {
+		if dirty {
+			name += " modified"
+		}
+		if s.ReadOnly {
+			name += " read-only"
+		}
+		if bufferInfo != "" {
+			name += " " + bufferInfo
+		}
+		return " " + name
+	}
+
+	// Colour dirty filenames in the theme's colour. In reverse video mode,
+	// background codes set the text colour. The bar's own colours are set
+	// again after, as turning off a background would clear them.
+	t := s.Theme.orDefault()
 	if dirty {
-		name = "\x1b[1;48;5;208m" + name + "\x1b[22;49m"
+		name = t.Modified + name + colorOff(t.Modified) + t.Status
+	}
+	if s.ReadOnly {
+		name += " [RO]"
 	}
 
-	// Add spell error indicator (red dot) if there are errors
-	// In reverse video mode, background codes affect foreground and vice versa
-	// So we use background code (48) to get red text in the inverted status bar
+	// Add spell error indicator (a dot, red by default) if there are errors.
 	spellIndicator := ""
 	if spellErrorCount > 0 {
-		spellIndicator = " \x1b[48;5;9m●\x1b[49m"
+		spellIndicator = " " + t.Errors + "●" + colorOff(t.Errors) + t.Status
 	}
 
 	if bufferInfo != "" {
@@ -69,18 +134,18 @@ func (s *StatusBar) FormatLeft(filename string, dirty bool, bufferInfo string, s
 }
 
 // FormatRight returns the right-aligned portion of the status bar.
-func (s *StatusBar) FormatRight(mode Mode, wordCount int, spellErrorCount int, searchActive bool, searchCurrentIdx int, searchMatchCount int) string {
+// spellErrorIdx is the 1-based index of the error nearest the cursor.
+func (s *StatusBar) FormatRight(mode Mode, wordCount int, spellErrorCount int, spellErrorIdx int, searchActive bool, searchCurrentIdx int, searchMatchCount int) string {
 	if s.Prompt != PromptNone {
 		return ""
 	}
-	modeStr := ""
-	switch mode {
-	case ModeDefault:
-		modeStr = "DEFAULT"
-	case ModeEdit:
-		modeStr = "EDIT"
-	case ModeLineSelect:
-		modeStr = "LINE-SELECT"
+	modeStr := mode.String()
+
+	if s.Concise {
+		if searchActive && searchMatchCount > 0 {
+			return fmt.Sprintf("match %d of %d  %s ", searchCurrentIdx+1, searchMatchCount, modeStr)
+		}
+		return modeStr + " "
 	}
 
 	// Show search match counter if search is active
@@ -89,10 +154,10 @@ func (s *StatusBar) FormatRight(mode Mode, wordCount int, spellErrorCount int, s
 		searchStr = fmt.Sprintf("%d/%d matches  ", searchCurrentIdx+1, searchMatchCount)
 	}
 
-	// Show error count if there are spelling errors
+	// Show position within the error list if there are spelling errors
 	errorStr := ""
 	if spellErrorCount > 0 {
-		errorStr = fmt.Sprintf("%d errors  ", spellErrorCount)
+		errorStr = fmt.Sprintf("err %d/%d  ", spellErrorIdx, spellErrorCount)
 	}
 
 	return fmt.Sprintf("%s%s%d words  %s ", searchStr, errorStr, wordCount, modeStr)
@@ -102,6 +167,11 @@ func (s *StatusBar) FormatRight(mode Mode, wordCount int, spellErrorCount int, s
 func (s *StatusBar) StartPrompt(pt PromptType) {
 	s.Prompt = pt
 	s.PromptText = ""
+	s.PromptLabel = ""
+	s.historyDraft = ""
+	if h := s.history(); h != nil {
+		s.historyIdx = len(*h)
+	}
 }
 
 // ClearPrompt resets the prompt state.
@@ -113,11 +183,51 @@ func (s *StatusBar) ClearPrompt() {
 // SetMessage sets a temporary status message.
 func (s *StatusBar) SetMessage(msg string) {
 	s.StatusMessage = msg
+	s.MessageError = false
+	s.logMessage(msg, false)
+}
+
+// SetError sets an error message, which stays until it is dismissed or
+// another message replaces it.
+func (s *StatusBar) SetError(msg string) {
+	s.StatusMessage = msg
+	s.MessageError = true
+	s.logMessage(msg, true)
+}
+
+// logMessage adds msg to the messages kept, dropping the oldest once there
+// are maxMessages.
+func (s *StatusBar) logMessage(msg string, isError bool) {
+	if msg == "" {
+		return
+	}
+	if len(s.Messages) == maxMessages {
+		s.Messages = append(s.Messages[:0], s.Messages[1:]...)
+	}
+	s.Messages = append(s.Messages, LoggedMessage{Time: time.Now(), Text: msg, Error: isError})
 }
 
-// ClearMessage clears the temporary status message.
+// ClearMessage clears the temporary status message, leaving an error.
 func (s *StatusBar) ClearMessage() {
+	if !s.MessageError {
+		s.StatusMessage = ""
+	}
+}
+
+// DismissMessage clears the status message, even an error.
+func (s *StatusBar) DismissMessage() {
 	s.StatusMessage = ""
+	s.MessageError = false
+}
+
+// fitPrompt keeps the end of a prompt visible when it is wider than the
+// status bar, so the text being typed is never cut off.
+func fitPrompt(prompt string, width int) string {
+	runes := []rune(prompt)
+	if len(runes) < width || width < 4 {
+		return prompt
+	}
+	return " …" + string(runes[len(runes)-width+3:])
 }
 
 // truncatePath shortens a file path to parent/basename.
@@ -150,8 +260,17 @@ func (s *StatusBar) HandlePromptKey(key terminal.Key) (string, bool, bool) {
 		return "", false, true
 	case terminal.KeyEnter:
 		text := s.PromptText
+		if h := s.history(); h != nil {
+			*h = addHistory(*h, text)
+		}
 		s.ClearPrompt()
 		return text, true, false
+	case terminal.KeyUp:
+		s.recallHistory(-1)
+		return "", false, false
+	case terminal.KeyDown:
+		s.recallHistory(1)
+		return "", false, false
 	case terminal.KeyBackspace:
 		if len(s.PromptText) > 0 {
 			runes := []rune(s.PromptText)
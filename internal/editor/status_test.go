@@ -17,7 +17,7 @@ func TestFormatLeftFilename(t *testing.T) {
 
 	got = sb.FormatLeft("test.txt", true, "", 0, false)
 	// Dirty filename should contain bold + darker orange ANSI code (background in reverse video).
-	if !strings.Contains(got, "\x1b[1;48;5;208m") {
+	if !strings.Contains(got, darkTheme.Modified) {
 		t.Errorf("dirty: expected bold + darker orange ANSI, got %q", got)
 	}
 	if !strings.Contains(got, "test.txt") {
@@ -104,18 +104,25 @@ func TestFormatLeftPrompt(t *testing.T) {
 
 func TestFormatRight(t *testing.T) {
 	sb := NewStatusBar()
-	if got := sb.FormatRight(ModeDefault, 42, 0, false, 0, 0); got != "42 words  DEFAULT " {
+	if got := sb.FormatRight(ModeDefault, 42, 0, 0, false, 0, 0); got != "42 words  DEFAULT " {
 		t.Errorf("default mode: %q", got)
 	}
-	if got := sb.FormatRight(ModeEdit, 0, 0, false, 0, 0); got != "0 words  EDIT " {
+	if got := sb.FormatRight(ModeEdit, 0, 0, 0, false, 0, 0); got != "0 words  EDIT " {
 		t.Errorf("edit mode: %q", got)
 	}
 	sb.StartPrompt(PromptSaveNew)
-	if got := sb.FormatRight(ModeDefault, 10, 0, false, 0, 0); got != "" {
+	if got := sb.FormatRight(ModeDefault, 10, 0, 0, false, 0, 0); got != "" {
 		t.Errorf("during prompt: %q", got)
 	}
 }
 
+func TestFormatRightSpellErrorIndex(t *testing.T) {
+	sb := NewStatusBar()
+	if got := sb.FormatRight(ModeDefault, 42, 17, 3, false, 0, 0); got != "err 3/17  42 words  DEFAULT " {
+		t.Errorf("error index: %q", got)
+	}
+}
+
 func TestHandlePromptKeyInput(t *testing.T) {
 	sb := NewStatusBar()
 	sb.StartPrompt(PromptCommand)
@@ -153,3 +160,17 @@ func TestHandlePromptKeyCancel(t *testing.T) {
 		t.Error("prompt should be cleared after escape")
 	}
 }
+
+func TestFitPrompt(t *testing.T) {
+	if got := fitPrompt(" :w", 80); got != " :w" {
+		t.Errorf("short prompt should be unchanged, got %q", got)
+	}
+	long := " :" + strings.Repeat("a", 50) + "END"
+	got := fitPrompt(long, 20)
+	if !strings.HasSuffix(got, "END") || !strings.HasPrefix(got, " …") {
+		t.Errorf("fitPrompt should keep the tail, got %q", got)
+	}
+	if n := len([]rune(got)); n >= 20 {
+		t.Errorf("fitted prompt is %d runes, want < 20", n)
+	}
+}
@@ -0,0 +1,73 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// A file named - is standard input, so prose can take part in a pipeline:
+// cat notes.md | prose - reads the notes into an unnamed buffer, with the
+// keyboard read from the terminal itself. If standard output isn't the
+// terminal either, as in prose - > out.md, :w keeps the buffer's text to be
+// written there when prose quits; until then it is the text read in.
+
+// stdinName is the file name that stands for standard input.
+const stdinName = "-"
+
+// openStdio fills the standard input buffer from a pipe or file, and notes
+// whether its text should go to standard output on quitting. Standard input
+// that is the terminal leaves the buffer empty.
+func (a *App) openStdio(in, out *os.File) error {
+	if !terminal.IsTerminal(in) {
+		if err := a.readStdin(in); err != nil {
+			return err
+		}
+	}
+	if !terminal.IsTerminal(out) {
+		a.stdout = out
+		a.stdoutLines = slices.Clone(a.stdin.buf.Lines)
+	}
+	return nil
+}
+
+// readStdin reads r into the standard input buffer.
+func (a *App) readStdin(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading standard input: %w", err)
+	}
+	b := a.stdin.buf
+	b.Lines, b.CRLF, b.BOM = decodeFileText(data)
+	a.stdin.startWords = a.stdin.WordCount()
+	return nil
+}
+
+// writeToStdout runs :w for the standard input buffer when its text goes to
+// standard output, keeping the text to be written on quitting. It reports
+// false for any other buffer.
+func (a *App) writeToStdout(eb *EditorBuffer) bool {
+	if eb != a.stdin || a.stdout == nil {
+		return false
+	}
+	a.stdoutLines = slices.Clone(eb.buf.Lines)
+	eb.buf.Dirty = false
+	a.statusBar.SetMessage(fmt.Sprintf("%d lines to be written to standard output", len(a.stdoutLines)))
+	return true
+}
+
+// writeStdout writes the standard input buffer's text, as last kept by :w,
+// to standard output after quitting.
+func (a *App) writeStdout() error {
+	if a.stdout == nil {
+		return nil
+	}
+	b := a.stdin.buf
+	if _, err := a.stdout.Write(encodeFileText(a.stdoutLines, b.CRLF, b.BOM)); err != nil {
+		return fmt.Errorf("writing standard output: %w", err)
+	}
+	return nil
+}
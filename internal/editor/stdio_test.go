@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// pipeIn returns the reading end of a pipe that text has been written to.
+func pipeIn(t *testing.T, text string) *os.File {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	go func() {
+		w.WriteString(text)
+		w.Close()
+	}()
+	return r
+}
+
+func TestStdinBuffer(t *testing.T) {
+	a := NewApp([]string{"notes.md", "-", "-"})
+	if len(a.buffers) != 2 || a.buffers[1] != a.stdin || a.stdin.buf.Filename != "" {
+		t.Fatalf("- should open one unnamed buffer, got %d buffers", len(a.buffers))
+	}
+	out, err := os.Create(filepath.Join(t.TempDir(), "out.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if err := a.openStdio(pipeIn(t, "One.\r\nTwo.\r\n"), out); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"One.", "Two."}; !reflect.DeepEqual(a.stdin.buf.Lines, want) || !a.stdin.buf.CRLF {
+		t.Errorf("lines = %q, want %q with \\r\\n endings", a.stdin.buf.Lines, want)
+	}
+	if a.stdout != out {
+		t.Error("output to a file should be written on quitting")
+	}
+}
+
+func TestWriteToStdout(t *testing.T) {
+	a := newTestApp("")
+	a.stdin = a.currentBuf()
+	path := filepath.Join(t.TempDir(), "out.md")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.openStdio(pipeIn(t, "Draft.\n"), out); err != nil {
+		t.Fatal(err)
+	}
+
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Kept."}
+	eb.buf.Dirty = true
+	a.executeCommand("w")
+	if eb.buf.Dirty || a.statusBar.Prompt == PromptSaveNew {
+		t.Error(":w should keep the text for standard output rather than ask for a name")
+	}
+	eb.buf.Lines = []string{"Discarded."}
+	a.executeCommand("q!")
+	if err := a.writeStdout(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	if data, _ := os.ReadFile(path); string(data) != "Kept.\n" {
+		t.Errorf("standard output = %q, want the text last written", data)
+	}
+}
+
+func TestStdoutPassesInputThrough(t *testing.T) {
+	a := newTestApp("")
+	a.stdin = a.currentBuf()
+	path := filepath.Join(t.TempDir(), "out.md")
+	out, _ := os.Create(path)
+	a.openStdio(pipeIn(t, "Unchanged.\n"), out)
+	a.writeStdout()
+	out.Close()
+	if data, _ := os.ReadFile(path); string(data) != "Unchanged.\n" {
+		t.Errorf("standard output = %q", data)
+	}
+}
@@ -0,0 +1,180 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+// Subcommands run without the editor, for scripts and for checking a
+// writing project in continuous integration. prose wc counts words, prose
+// spell lists the words the editor would mark, and prose lint the slips
+// :tidy and :dupes find along with links to missing files. Each reads the
+// files named, or standard input if there are none.
+
+// subcommands are the names that run a subcommand rather than open a file.
+// Each prints what it finds in the buffers and reports whether it found
+// anything to fix.
+var subcommands = map[string]func(*App, []*EditorBuffer, io.Writer) (bool, error){
+	"wc":    (*App).printWordCounts,
+	"spell": (*App).printSpellErrors,
+	"lint":  (*App).printLintProblems,
+}
+
+// IsSubcommand reports whether args, the command line after the program
+// name, run a subcommand such as wc. A subcommand's name alone, with
+// standard input a terminal it would sit waiting on, opens the file of
+// that name instead; prose -- wc or prose ./wc opens it in any case.
+func IsSubcommand(args []string, stdinTerminal bool) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if _, ok := subcommands[args[0]]; !ok {
+		return false
+	}
+	return len(args) > 1 || !stdinTerminal
+}
+
+// RunSubcommand runs the subcommand name on files, or on stdin if there are
+// none, printing to out. It reports whether it found misspellings or
+// problems, for the exit status.
+func RunSubcommand(name string, files []string, stdin io.Reader, out io.Writer) (bool, error) {
+	run, ok := subcommands[name]
+	if !ok {
+		return false, fmt.Errorf("unknown subcommand %q", name)
+	}
+	if len(files) == 0 {
+		files = []string{stdinName}
+	}
+	var buffers []*EditorBuffer
+	for _, f := range files {
+		var data []byte
+		var err error
+		if f == stdinName {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(f)
+		}
+		if err != nil {
+			return false, err
+		}
+		eb := NewEditorBuffer(f)
+		eb.buf.Lines, eb.buf.CRLF, eb.buf.BOM = decodeFileText(data)
+		buffers = append(buffers, eb)
+	}
+	return run(NewApp(nil), buffers, out)
+}
+
+// printWordCounts runs prose wc, printing each file's words as the status
+// bar counts them, and the total of several.
+func (a *App) printWordCounts(buffers []*EditorBuffer, out io.Writer) (bool, error) {
+	total := 0
+	for _, eb := range buffers {
+		n := eb.WordCount()
+		total += n
+		fmt.Fprintf(out, "%7d %s\n", n, eb.buf.Filename)
+	}
+	if len(buffers) > 1 {
+		fmt.Fprintf(out, "%7d total\n", total)
+	}
+	return false, nil
+}
+
+// printSpellErrors runs prose spell, printing the words the editor would
+// mark in each file, with the project's dictionary and bibliography.
+func (a *App) printSpellErrors(buffers []*EditorBuffer, out io.Writer) (bool, error) {
+	sc, err := spell.NewSpellChecker()
+	if err != nil {
+		return false, err
+	}
+	a.spellChecker = sc
+	found := false
+	for _, eb := range buffers {
+		a.buffers, a.currentBuffer = []*EditorBuffer{eb}, 0
+		if err := errors.Join(a.loadProject(), a.loadBibliography(eb)); err != nil {
+			return found, fmt.Errorf("%s: %w", eb.buf.Filename, err)
+		}
+		for _, e := range eb.checkLines(sc) {
+			found = true
+			fmt.Fprintf(out, "%s:%d:%d: %s\n", eb.buf.Filename, e.Line+1, e.StartCol+1, e.Word)
+		}
+	}
+	return found, nil
+}
+
+// printLintProblems runs prose lint, printing the problems in each file.
+func (a *App) printLintProblems(buffers []*EditorBuffer, out io.Writer) (bool, error) {
+	found := false
+	for _, eb := range buffers {
+		for _, p := range lintLines(eb.buf.Lines, filepath.Dir(eb.buf.Filename)) {
+			found = true
+			fmt.Fprintf(out, "%s:%d:%d: %s\n", eb.buf.Filename, p.Line+1, p.Col+1, p.Message)
+		}
+	}
+	return found, nil
+}
+
+// lintProblem is a slip prose lint reports, at a rune column of a line.
+type lintProblem struct {
+	Line, Col int
+	Message   string
+}
+
+// lintLines returns the problems in a document: sentences begun in lower
+// case and extra spaces after a sentence, outside code, front matter and
+// tables; sentences repeated; and links to files that don't exist,
+// relative to dir.
+func lintLines(lines []string, dir string) []lintProblem {
+	var problems []lintProblem
+	blocks := ComputeBlockStates(lines)
+	for i := frontMatterEnd(lines) + 1; i < len(lines); i++ {
+		if blocks[i].InCode || IsTableLine(lines[i]) {
+			continue
+		}
+		line := lines[i]
+		lineStart := startsParagraph(lines, i)
+		runes := []rune(line)
+		for col, r := range runes {
+			if !unicode.IsLower(r) || col > 0 && !unicode.IsSpace(runes[col-1]) && !strings.ContainsRune("\"“'‘([*_", runes[col-1]) {
+				continue
+			}
+			if needsCapital(line, col, lineStart) {
+				problems = append(problems, lintProblem{i, col, "sentence starts in lower case"})
+			}
+		}
+		for start, end := extraSpaces(line, 0); start >= 0; start, end = extraSpaces(line, end) {
+			problems = append(problems, lintProblem{i, start, "more than one space after a sentence"})
+		}
+		for _, l := range findLinks(line) {
+			if l.Wiki || isURL(l.Target) {
+				continue
+			}
+			path, _ := resolveLink(dir, l.Target)
+			if path == "" {
+				continue
+			}
+			if _, err := os.Stat(path); err != nil {
+				problems = append(problems, lintProblem{i, l.Start, "link to missing file " + l.Target})
+			}
+		}
+	}
+	for _, p := range FindDuplicates(lines) {
+		msg := fmt.Sprintf("repeats the sentence on line %d", p.First.Line+1)
+		if p.Similarity < 100 {
+			msg = fmt.Sprintf("nearly repeats the sentence on line %d (%d%%)", p.First.Line+1, p.Similarity)
+		}
+		problems = append(problems, lintProblem{p.Second.Line, p.Second.Col, msg})
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		p, q := problems[i], problems[j]
+		return p.Line < q.Line || p.Line == q.Line && p.Col < q.Col
+	})
+	return problems
+}
@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintLines(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "two.md"), []byte("Two.\n"), 0644)
+	lines := []string{
+		"---",
+		"title: lower case is fine here",
+		"---",
+		"The rain came down on the harbour wall.  It kept on.",
+		"See [two](two) and [three](three.md), or [[Four]]. she left.",
+		"",
+		"```",
+		"code. lower case",
+		"```",
+		"The rain came down on the harbour wall.",
+	}
+	want := []lintProblem{
+		{3, 40, "more than one space after a sentence"},
+		{4, 19, "link to missing file three.md"},
+		{4, 51, "sentence starts in lower case"},
+		{9, 0, "repeats the sentence on line 4"},
+	}
+	if got := lintLines(lines, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("lintLines =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestSubcommandWordCount(t *testing.T) {
+	dir := t.TempDir()
+	one, two := filepath.Join(dir, "one.md"), filepath.Join(dir, "two.md")
+	os.WriteFile(one, []byte("One two three.\n"), 0644)
+	os.WriteFile(two, []byte("Four {++five++}.\n"), 0644)
+
+	var out strings.Builder
+	found, err := RunSubcommand("wc", []string{one, two}, nil, &out)
+	want := "      3 " + one + "\n      2 " + two + "\n      5 total\n"
+	if err != nil || found || out.String() != want {
+		t.Errorf("prose wc = %q, %v, %v, want %q", out.String(), found, err, want)
+	}
+
+	out.Reset()
+	RunSubcommand("wc", nil, strings.NewReader("Read from a pipe.\n"), &out)
+	if out.String() != "      4 -\n" {
+		t.Errorf("prose wc on standard input = %q", out.String())
+	}
+
+	if _, err := RunSubcommand("wc", []string{filepath.Join(dir, "missing.md")}, nil, &out); err == nil {
+		t.Error("a missing file should be an error")
+	}
+}
+
+func TestIsSubcommand(t *testing.T) {
+	tests := []struct {
+		args          []string
+		stdinTerminal bool
+		want          bool
+	}{
+		{[]string{"lint", "draft.md"}, true, true},
+		{[]string{"wc"}, false, true},
+		{[]string{"wc"}, true, false},
+		{[]string{"--", "wc", "draft.md"}, true, false},
+		{[]string{"./wc", "draft.md"}, true, false},
+		{[]string{"chapter.md"}, false, false},
+		{nil, false, false},
+	}
+	for _, tt := range tests {
+		if got := IsSubcommand(tt.args, tt.stdinTerminal); got != tt.want {
+			t.Errorf("IsSubcommand(%q, %v) = %v, want %v", tt.args, tt.stdinTerminal, got, tt.want)
+		}
+	}
+}
+
+func TestSubcommandLintAndSpell(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "draft.md")
+	os.WriteFile(path, []byte("The harbour was quiet.\nwe recieved the letter.\n"), 0644)
+
+	var out strings.Builder
+	found, err := RunSubcommand("lint", []string{path}, nil, &out)
+	if err != nil || !found || out.String() != path+":2:1: sentence starts in lower case\n" {
+		t.Errorf("prose lint = %q, %v, %v", out.String(), found, err)
+	}
+
+	out.Reset()
+	found, err = RunSubcommand("spell", []string{path}, nil, &out)
+	if err != nil || !found || out.String() != path+":2:4: recieved\n" {
+		t.Errorf("prose spell = %q, %v, %v", out.String(), found, err)
+	}
+}
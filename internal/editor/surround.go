@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSurround is the surround setting's default: the characters that
+// wrap a selection when typed in Visual or Line Select mode.
+const DefaultSurround = "*_`\"("
+
+// surroundClosers are the characters closing a surround that opens with a
+// bracket. Every other character closes with itself.
+var surroundClosers = map[rune]rune{'(': ')', '[': ']', '{': '}', '<': '>'}
+
+// validSurround reports whether chars can be used as the surround setting.
+// Letters, digits and the motions ^, $, { and } are Visual mode keys of their
+// own.
+func validSurround(chars string) error {
+	for _, r := range chars {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || strings.ContainsRune("^${}", r) {
+			return fmt.Errorf("surround: %q is a key of its own in Visual mode", r)
+		}
+	}
+	return nil
+}
+
+// surroundWith reports whether r wraps the selection, and what closes it.
+func (a *App) surroundWith(r rune) (closer rune, ok bool) {
+	if !strings.ContainsRune(a.config.Surround, r) {
+		return 0, false
+	}
+	if c, ok := surroundClosers[r]; ok {
+		return c, true
+	}
+	return r, true
+}
+
+// surroundRange puts open before (sl, sc) and close at (el, ec), as one
+// change. With track changes on, the markers are marked as insertions.
+func (a *App) surroundRange(sl, sc, el, ec int, open, close rune) {
+	eb := a.currentBuf()
+	before, after := string(open), string(close)
+	if eb.trackChanges {
+		before, after = "{++"+before+"++}", "{++"+after+"++}"
+	}
+	lines := make([]string, el-sl+1)
+	copy(lines, eb.buf.Lines[sl:el+1])
+	last := []rune(lines[len(lines)-1])
+	ec = min(ec, len(last))
+	lines[len(lines)-1] = string(last[:ec]) + after + string(last[ec:])
+	first := []rune(lines[0])
+	sc = min(sc, len(first))
+	lines[0] = string(first[:sc]) + before + string(first[sc:])
+	a.replaceLines(sl, len(lines), lines)
+	eb.cursorLine = sl
+	eb.cursorCol = sc
+}
+
+// surroundSelection wraps the Visual mode selection in open and close.
+func (a *App) surroundSelection(open, close rune) {
+	sl, sc, el, ec := a.getVisualRange()
+	a.surroundRange(sl, sc, el, ec, open, close)
+}
+
+// surroundSelectedLines wraps the text of the selected lines in open and
+// close, leaving list, quote and heading markers outside.
+func (a *App) surroundSelectedLines(open, close rune) {
+	eb := a.currentBuf()
+	start, end := a.getSelectionRange()
+	a.surroundRange(start, lineTextStart(eb.buf.Lines[start]), end, eb.buf.LineLen(end), open, close)
+}
+
+// lineTextStart returns the rune column where the text of a line begins,
+// after indentation and any list, quote or heading marker.
+func lineTextStart(line string) int {
+	prefix := ListPrefix(line)
+	if prefix == "" {
+		prefix = reHeading.FindString(line)
+	}
+	if prefix == "" {
+		prefix = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+	return utf8.RuneCountInString(prefix)
+}
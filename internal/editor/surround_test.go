@@ -0,0 +1,92 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSurroundSelection(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		keys  string // From Default mode at line 0, column 4
+		want  []string
+	}{
+		{"italic word", []string{"The quick fox"}, "vllll*", []string{"The *quick* fox"}},
+		{"parentheses", []string{"The quick fox"}, "vllll(", []string{"The (quick) fox"}},
+		{"across lines", []string{"The quick", "brown fox"}, "vjh_", []string{"The _quick", "brow_n fox"}},
+		{"backticks", []string{"Run go vet now"}, "vlllll`", []string{"Run `go vet` now"}},
+		{"line select", []string{"Some text", "more text"}, "Vj\"", []string{"\"Some text", "more text\""}},
+		{"list item", []string{"- an item"}, "V*", []string{"- *an item*"}},
+		{"heading", []string{"## A heading"}, "V_", []string{"## _A heading_"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp("draft.md")
+			a.config.Surround = DefaultSurround
+			eb := a.currentBuf()
+			eb.buf.Lines = tt.lines
+			eb.cursorCol = 4
+			sendKeys(a, tt.keys)
+			if strings.Join(eb.buf.Lines, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("lines = %q, want %q", eb.buf.Lines, tt.want)
+			}
+			if a.mode != ModeDefault {
+				t.Errorf("mode = %v, want Default", a.mode)
+			}
+		})
+	}
+}
+
+func TestSurroundTwiceMakesBold(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.config.Surround = DefaultSurround
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"a word here"}
+	eb.cursorCol = 2
+	sendKeys(a, "vlll*")
+	sendKeys(a, "vlllll*")
+	if eb.buf.Lines[0] != "a **word** here" {
+		t.Errorf("line = %q", eb.buf.Lines[0])
+	}
+
+	a.undoAction()
+	if eb.buf.Lines[0] != "a *word* here" {
+		t.Errorf("undo should remove one pair, got %q", eb.buf.Lines[0])
+	}
+}
+
+func TestSurroundTracked(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.config.Surround = DefaultSurround
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"a word here"}
+	eb.trackChanges = true
+	eb.cursorCol = 2
+	sendKeys(a, "vlll*")
+	if eb.buf.Lines[0] != "a {++*++}word{++*++} here" {
+		t.Errorf("line = %q", eb.buf.Lines[0])
+	}
+}
+
+func TestSurroundOff(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"a word here"}
+	eb.cursorCol = 2
+	sendKeys(a, "vlll*")
+	if eb.buf.Lines[0] != "a word here" || a.mode != ModeVisual {
+		t.Errorf("with surround off * should do nothing, got %q in %v", eb.buf.Lines[0], a.mode)
+	}
+}
+
+func TestParseConfigSurround(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader("surround = off\n"))
+	if err != nil || cfg.Surround != "" {
+		t.Errorf("surround = off: got %q, %v", cfg.Surround, err)
+	}
+	cfg, err = parseConfig(strings.NewReader("surround = *x\n"))
+	if err == nil || cfg.Surround != DefaultSurround {
+		t.Errorf("surround = *x should be refused, got %q, %v", cfg.Surround, err)
+	}
+}
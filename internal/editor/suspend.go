@@ -0,0 +1,22 @@
+package editor
+
+// suspend stops prose and drops back to the shell, as Ctrl-Z does in other
+// terminal programs. When the shell brings it back with fg, the screen is
+// drawn afresh at whatever size the terminal is now.
+func (a *App) suspend() {
+	if a.terminal == nil {
+		return
+	}
+	a.debugLog("suspend")
+	if err := a.terminal.Stop(); err != nil {
+		a.errorLog("suspend", err)
+	}
+	a.redrawAll()
+}
+
+// redrawAll draws the whole screen again after the terminal has been given
+// over to something else.
+func (a *App) redrawAll() {
+	a.relayout(a.terminal.Width(), a.terminal.Height())
+	a.dirty |= dirtyText
+}
@@ -16,8 +16,11 @@ type PlainHighlighter struct{}
 
 func (PlainHighlighter) Highlight(line string) string { return line }
 
-// MarkdownHighlighter applies ANSI colour codes to markdown syntax.
-type MarkdownHighlighter struct{}
+// MarkdownHighlighter applies ANSI colour codes to markdown syntax, in the
+// colours of Theme, or the default theme if it is nil.
+type MarkdownHighlighter struct {
+	Theme *Theme
+}
 
 var (
 	// Line-level patterns.
@@ -34,23 +37,28 @@ var (
 	reItalicUs   = regexp.MustCompile(`(?:^|\s)_([^_]+?)_`)
 )
 
-func (MarkdownHighlighter) Highlight(line string) string {
+func (h MarkdownHighlighter) Highlight(line string) string {
+	t := h.Theme.orDefault()
+
 	// Line-level rules: if matched, style the entire line.
 	if reHR.MatchString(line) {
-		return "\x1b[90m" + line + "\x1b[0m"
+		return t.Muted + line + "\x1b[0m"
 	}
 	if reHeading.MatchString(line) {
-		return "\x1b[1;34m" + line + "\x1b[0m"
+		return t.Heading + line + "\x1b[0m"
 	}
 	if reQuote.MatchString(line) {
-		return "\x1b[90m" + line + "\x1b[0m"
+		return t.Muted + line + "\x1b[0m"
+	}
+	if IsTableLine(line) && isSeparatorRow(SplitTableRow(line)) {
+		return t.Muted + line + "\x1b[0m"
 	}
 
 	// Inline rules applied in order: bold, italic, code, link.
 	result := line
 
 	// Bold: **text** or __text__
-	result = reBold.ReplaceAllString(result, "$1\x1b[1;33m$2\x1b[22;39m$3")
+	result = reBold.ReplaceAllString(result, "$1"+t.Bold+"$2"+colorOff(t.Bold)+"$3")
 
 	// Italic *text* (not inside bold's **)
 	result = reItalicStar.ReplaceAllStringFunc(result, func(match string) string {
@@ -58,7 +66,7 @@ func (MarkdownHighlighter) Highlight(line string) string {
 		idx := strings.Index(match, "*")
 		prefix := match[:idx]
 		inner := match[idx+1 : len(match)-1]
-		return prefix + "*\x1b[3;36m" + inner + "\x1b[23;39m*"
+		return prefix + "*" + t.Italic + inner + colorOff(t.Italic) + "*"
 	})
 
 	// Italic _text_ (not inside a word)
@@ -66,11 +74,11 @@ func (MarkdownHighlighter) Highlight(line string) string {
 		idx := strings.Index(match, "_")
 		prefix := match[:idx]
 		inner := match[idx+1 : len(match)-1]
-		return prefix + "_\x1b[3;36m" + inner + "\x1b[23;39m_"
+		return prefix + "_" + t.Italic + inner + colorOff(t.Italic) + "_"
 	})
 
 	// Inline code: `code`
-	result = reCode.ReplaceAllString(result, "`\x1b[35m$1\x1b[39m`")
+	result = reCode.ReplaceAllString(result, "`"+t.Code+"$1"+colorOff(t.Code)+"`")
 
 	// Links: [text](url) — underline the link text
 	result = reLink.ReplaceAllStringFunc(result, func(match string) string {
@@ -82,12 +90,32 @@ func (MarkdownHighlighter) Highlight(line string) string {
 		}
 		text := match[open+1 : close]
 		rest := match[close:]
-		return "[" + "\x1b[4;32m" + text + "\x1b[24;39m" + rest
+		return "[" + t.Link + text + colorOff(t.Link) + rest
 	})
 
+	// Table pipes: dimmed so cell contents stand out. Applied last because the
+	// inserted escape codes contain '[' which would confuse the link pattern.
+	if IsTableLine(line) {
+		result = dimTablePipes(result, t.Muted)
+	}
+
 	return result + "\x1b[0m"
 }
 
+// dimTablePipes colours unescaped '|' characters in the muted colour.
+func dimTablePipes(s, muted string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '|' && (i == 0 || runes[i-1] != '\\') {
+			b.WriteString(muted + "|" + colorOff(muted))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // DetectHighlighter returns the appropriate highlighter for the given filename.
 func DetectHighlighter(filename string) Highlighter {
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -99,11 +127,13 @@ func DetectHighlighter(filename string) Highlighter {
 	}
 }
 
-// TruncateVisible truncates s to maxVisible visible characters,
-// preserving ANSI escape sequences and appending a reset.
+// TruncateVisible truncates s to maxVisible visible columns, preserving ANSI
+// escape sequences and appending a reset. A wide character that would
+// straddle the edge is dropped.
 func TruncateVisible(s string, maxVisible int) string {
 	var b strings.Builder
 	visible := 0
+	truncated := false
 	runes := []rune(s)
 	i := 0
 
@@ -120,17 +150,19 @@ func TruncateVisible(s string, maxVisible int) string {
 			}
 			b.WriteString(string(runes[start:i]))
 		} else {
-			if visible >= maxVisible {
+			w := runeWidth(runes[i])
+			if visible+w > maxVisible || (w == 0 && visible >= maxVisible) {
+				truncated = true
 				break
 			}
 			b.WriteRune(runes[i])
-			visible++
+			visible += w
 			i++
 		}
 	}
 
 	// If we truncated, append reset to close any open ANSI spans.
-	if visible >= maxVisible {
+	if truncated || visible >= maxVisible {
 		b.WriteString("\x1b[0m")
 	}
 
@@ -147,6 +179,9 @@ type OutlineItem struct {
 	Level      int    // 1-6 for h1-h6
 	Text       string // Heading text without # symbols
 	BufferLine int    // Line number in buffer (0-based)
+	Scene      bool   // A scene separator rather than a heading
+	File       string // File the item is in, in the project outline
+	IsFile     bool   // The entry naming File, in the project outline
 }
 
 // IsMarkdownFile checks if a filename has a markdown extension.
@@ -155,19 +190,35 @@ func IsMarkdownFile(filename string) bool {
 	return ext == ".md" || ext == ".markdown" || ext == ".mdx"
 }
 
-// ExtractHeadings extracts all ATX-style headings from a buffer.
+var (
+	reHeadingATX    = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
+	reSetextH1      = regexp.MustCompile(`^ {0,3}=+\s*$`)
+	reSetextH2      = regexp.MustCompile(`^ {0,3}-+\s*$`)
+	reNotSetextText = regexp.MustCompile(`^(\s{4}|\s*$|\s*#|\s*>|\s*([-*+]|\d+[.)])\s)`)
+)
+
+// ExtractHeadings extracts ATX (`# Title`) and setext (Title underlined with
+// `===` or `---`) headings from a buffer, skipping fenced code blocks.
 func ExtractHeadings(buf *Buffer) []OutlineItem {
 	var items []OutlineItem
-	reHeadingATX := regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
+	blocks := ComputeBlockStates(buf.Lines)
 
 	for i, line := range buf.Lines {
-		matches := reHeadingATX.FindStringSubmatch(line)
-		if matches != nil {
-			level := len(matches[1])
-			text := strings.TrimSpace(matches[2])
+		if blocks[i].InCode {
+			continue
+		}
+		if matches := reHeadingATX.FindStringSubmatch(line); matches != nil {
+			items = append(items, OutlineItem{
+				Level:      len(matches[1]),
+				Text:       strings.TrimSpace(matches[2]),
+				BufferLine: i,
+			})
+			continue
+		}
+		if level := setextLevel(buf.Lines, blocks, i); level > 0 {
 			items = append(items, OutlineItem{
 				Level:      level,
-				Text:       text,
+				Text:       strings.TrimSpace(line),
 				BufferLine: i,
 			})
 		}
@@ -175,3 +226,27 @@ func ExtractHeadings(buf *Buffer) []OutlineItem {
 
 	return items
 }
+
+// setextLevel returns 1 or 2 if line i is the text of a setext heading (a
+// single paragraph line followed by an === or --- underline), or 0. Lines
+// of YAML front matter are never headings, though the closing --- looks
+// like an underline.
+func setextLevel(lines []string, blocks []BlockState, i int) int {
+	if i+1 >= len(lines) || blocks[i+1].InCode || reNotSetextText.MatchString(lines[i]) {
+		return 0
+	}
+	// Only the first line of a paragraph can carry the heading here.
+	if i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+		return 0
+	}
+	if strings.TrimSpace(lines[0]) == "---" && i <= frontMatterEnd(lines) {
+		return 0
+	}
+	switch next := lines[i+1]; {
+	case reSetextH1.MatchString(next):
+		return 1
+	case reSetextH2.MatchString(next):
+		return 2
+	}
+	return 0
+}
@@ -179,6 +179,13 @@ func TestTruncateVisibleWithANSI(t *testing.T) {
 	}
 }
 
+func TestTruncateVisibleWide(t *testing.T) {
+	got := TruncateVisible("中文字", 5)
+	if got != "中文\x1b[0m" {
+		t.Errorf("TruncateVisible wide = %q, want %q", got, "中文\x1b[0m")
+	}
+}
+
 func TestTruncateVisibleEmptyString(t *testing.T) {
 	got := TruncateVisible("", 10)
 	if got != "" {
@@ -296,3 +303,75 @@ func TestExtractHeadingsWithTrailingSpaces(t *testing.T) {
 		t.Errorf("Item 1: Text = %q, want %q", items[1].Text, "Another heading")
 	}
 }
+
+func TestExtractHeadingsSetext(t *testing.T) {
+	buf := &Buffer{
+		Lines: []string{
+			"Title",
+			"=====",
+			"",
+			"Intro paragraph",
+			"",
+			"Chapter One",
+			"---",
+			"Body text",
+			"",
+			"---",
+			"- item",
+			"---",
+			"```",
+			"Not a heading",
+			"===",
+			"```",
+		},
+	}
+
+	items := ExtractHeadings(buf)
+	expected := []OutlineItem{
+		{Level: 1, Text: "Title", BufferLine: 0},
+		{Level: 2, Text: "Chapter One", BufferLine: 5},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("ExtractHeadings() = %+v, want %+v", items, expected)
+	}
+	for i, want := range expected {
+		if items[i] != want {
+			t.Errorf("Item %d = %+v, want %+v", i, items[i], want)
+		}
+	}
+}
+
+func TestExtractHeadingsSkipsFrontMatter(t *testing.T) {
+	buf := &Buffer{
+		Lines: []string{
+			"---",
+			"title: x",
+			"---",
+			"Text",
+			"---",
+			"tags: [a]",
+			"---",
+			"",
+			"# Real",
+		},
+	}
+	items := ExtractHeadings(buf)
+	if len(items) != 1 || items[0].Text != "Real" {
+		t.Errorf("ExtractHeadings() = %+v, want only the heading after the front matter", items)
+	}
+
+	buf.Lines = []string{"---", "", "title: x", "---", "", "# Real"}
+	if items := ExtractHeadings(buf); len(items) != 1 || items[0].Text != "Real" {
+		t.Errorf("ExtractHeadings() = %+v, want no heading inside the front matter", items)
+	}
+}
+
+func TestExtractHeadingsSkipsCodeBlocks(t *testing.T) {
+	buf := &Buffer{
+		Lines: []string{"# Real", "```sh", "# comment", "```", "## Also real"},
+	}
+	items := ExtractHeadings(buf)
+	if len(items) != 2 || items[0].Text != "Real" || items[1].Text != "Also real" {
+		t.Errorf("ExtractHeadings() = %+v, want headings outside the code block only", items)
+	}
+}
@@ -0,0 +1,279 @@
+package editor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TableAlign is the horizontal alignment of a table column, taken from the
+// separator row (":--", ":-:", "--:").
+type TableAlign int
+
+const (
+	AlignNone TableAlign = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+var reTableSeparatorCell = regexp.MustCompile(`^:?-+:?$`)
+
+// IsTableLine reports whether a line looks like a row of a markdown pipe table.
+func IsTableLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+// TableBounds returns the first and last buffer lines of the pipe table that
+// contains the given line. ok is false if the line is not part of a table.
+func TableBounds(buf *Buffer, line int) (start, end int, ok bool) {
+	if line < 0 || line >= len(buf.Lines) || !IsTableLine(buf.Lines[line]) {
+		return 0, 0, false
+	}
+	start, end = line, line
+	for start > 0 && IsTableLine(buf.Lines[start-1]) {
+		start--
+	}
+	for end < len(buf.Lines)-1 && IsTableLine(buf.Lines[end+1]) {
+		end++
+	}
+	return start, end, true
+}
+
+// SplitTableRow splits a table row into trimmed cell contents. Leading and
+// trailing border pipes are dropped, and escaped pipes (\|) stay in the cell.
+func SplitTableRow(line string) []string {
+	s := strings.TrimSpace(line)
+	s = strings.TrimPrefix(s, "|")
+	if strings.HasSuffix(s, "|") && !strings.HasSuffix(s, `\|`) {
+		s = s[:len(s)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '|' {
+			cell.WriteString(`\|`)
+			i++
+			continue
+		}
+		if runes[i] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteRune(runes[i])
+	}
+	cells = append(cells, strings.TrimSpace(cell.String()))
+	return cells
+}
+
+// isSeparatorRow reports whether all cells are of the form ---, :--, --: or :-:.
+func isSeparatorRow(cells []string) bool {
+	if len(cells) == 0 {
+		return false
+	}
+	for _, c := range cells {
+		if !reTableSeparatorCell.MatchString(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAlign returns the alignment encoded in a separator cell.
+func parseAlign(cell string) TableAlign {
+	left := strings.HasPrefix(cell, ":")
+	right := strings.HasSuffix(cell, ":")
+	switch {
+	case left && right:
+		return AlignCenter
+	case right:
+		return AlignRight
+	case left:
+		return AlignLeft
+	}
+	return AlignNone
+}
+
+// separatorCell renders a separator cell of the given width and alignment.
+func separatorCell(width int, align TableAlign) string {
+	switch align {
+	case AlignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case AlignRight:
+		return strings.Repeat("-", width-1) + ":"
+	case AlignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	}
+	return strings.Repeat("-", width)
+}
+
+// padCell pads text to width according to the column alignment.
+func padCell(text string, width int, align TableAlign) string {
+	gap := width - len([]rune(text))
+	if gap <= 0 {
+		return text
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", gap) + text
+	case AlignCenter:
+		left := gap / 2
+		return strings.Repeat(" ", left) + text + strings.Repeat(" ", gap-left)
+	}
+	return text + strings.Repeat(" ", gap)
+}
+
+// AlignTable reformats the rows of a pipe table so that every column is padded
+// to a common width. Short rows are filled with empty cells.
+func AlignTable(lines []string) []string {
+	rows := make([][]string, len(lines))
+	cols := 0
+	for i, line := range lines {
+		rows[i] = SplitTableRow(line)
+		if len(rows[i]) > cols {
+			cols = len(rows[i])
+		}
+	}
+
+	aligns := make([]TableAlign, cols)
+	widths := make([]int, cols)
+	for i := range widths {
+		widths[i] = 3 // Minimum width keeps separator cells valid.
+	}
+	for _, row := range rows {
+		if isSeparatorRow(row) {
+			for c, cell := range row {
+				aligns[c] = parseAlign(cell)
+			}
+			continue
+		}
+		for c, cell := range row {
+			if w := len([]rune(cell)); w > widths[c] {
+				widths[c] = w
+			}
+		}
+	}
+
+	result := make([]string, len(rows))
+	for i, row := range rows {
+		sep := isSeparatorRow(row)
+		var b strings.Builder
+		b.WriteString("|")
+		for c := 0; c < cols; c++ {
+			cell := ""
+			if c < len(row) {
+				cell = row[c]
+			}
+			if sep {
+				cell = separatorCell(widths[c], aligns[c])
+			} else {
+				cell = padCell(cell, widths[c], aligns[c])
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		result[i] = b.String()
+	}
+	return result
+}
+
+// TableCellAt returns the index of the cell containing rune column col.
+func TableCellAt(line string, col int) int {
+	runes := []rune(line)
+	cell := -1
+	seenPipe := false
+	for i := 0; i < len(runes) && i < col; i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '|' {
+			i++
+			continue
+		}
+		if runes[i] == '|' {
+			cell++
+			seenPipe = true
+		}
+	}
+	if !seenPipe {
+		return 0
+	}
+	if cell < 0 {
+		cell = 0
+	}
+	return cell
+}
+
+// TableCellStart returns the rune column where the content of the given cell
+// begins (after the pipe and any padding). Returns -1 if the cell does not exist.
+func TableCellStart(line string, cell int) int {
+	runes := []rune(line)
+	seen := -1
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '|' {
+			i++
+			continue
+		}
+		if runes[i] != '|' {
+			continue
+		}
+		seen++
+		if seen == cell {
+			if i+1 >= len(runes) {
+				return -1 // Trailing border pipe, not a cell.
+			}
+			col := i + 1
+			for col < len(runes) && runes[col] == ' ' {
+				col++
+			}
+			if col < len(runes) && runes[col] == '|' && col > i+1 {
+				col = i + 2 // Empty cell: sit just after the pipe's space.
+			}
+			return col
+		}
+	}
+	return -1
+}
+
+// TableColumnCount returns the number of columns in the widest table row.
+func TableColumnCount(lines []string) int {
+	cols := 0
+	for _, line := range lines {
+		if n := len(SplitTableRow(line)); n > cols {
+			cols = n
+		}
+	}
+	return cols
+}
+
+// InsertTableRow returns the table with an empty row inserted after row idx.
+// Inserting after the header row places the new row below the separator.
+func InsertTableRow(lines []string, idx int) []string {
+	if idx >= 0 && idx+1 < len(lines) && isSeparatorRow(SplitTableRow(lines[idx+1])) {
+		idx++
+	}
+	row := "|" + strings.Repeat("  |", TableColumnCount(lines))
+	result := make([]string, 0, len(lines)+1)
+	result = append(result, lines[:idx+1]...)
+	result = append(result, row)
+	result = append(result, lines[idx+1:]...)
+	return AlignTable(result)
+}
+
+// InsertTableColumn returns the table with an empty column inserted after
+// column idx in every row.
+func InsertTableColumn(lines []string, idx int) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		cells := SplitTableRow(line)
+		pos := idx + 1
+		if pos > len(cells) {
+			pos = len(cells)
+		}
+		newCell := ""
+		if isSeparatorRow(cells) {
+			newCell = "---"
+		}
+		cells = append(cells[:pos], append([]string{newCell}, cells[pos:]...)...)
+		result[i] = "| " + strings.Join(cells, " | ") + " |"
+	}
+	return AlignTable(result)
+}
@@ -0,0 +1,220 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestIsTableLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"| a | b |", true},
+		{"  | indented |", true},
+		{"not | a table", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsTableLine(tt.line); got != tt.want {
+			t.Errorf("IsTableLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTableBounds(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{"intro", "| a | b |", "|---|---|", "| 1 | 2 |", "", "outro"}
+
+	start, end, ok := TableBounds(buf, 2)
+	if !ok || start != 1 || end != 3 {
+		t.Errorf("TableBounds(2) = (%d, %d, %v), want (1, 3, true)", start, end, ok)
+	}
+	if _, _, ok := TableBounds(buf, 0); ok {
+		t.Error("TableBounds on a prose line should not be ok")
+	}
+}
+
+func TestSplitTableRow(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"| a | b |", []string{"a", "b"}},
+		{"|a|b", []string{"a", "b"}},
+		{"| a \\| b | c |", []string{"a \\| b", "c"}},
+		{"|  |  |", []string{"", ""}},
+	}
+	for _, tt := range tests {
+		if got := SplitTableRow(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitTableRow(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestAlignTable(t *testing.T) {
+	got := AlignTable([]string{
+		"| Name | Qty |",
+		"|:-|-:|",
+		"| apples | 3 |",
+		"| kiwi |",
+	})
+	want := []string{
+		"| Name   | Qty |",
+		"| :----- | --: |",
+		"| apples |   3 |",
+		"| kiwi   |     |",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AlignTable:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestAlignTableCenter(t *testing.T) {
+	got := AlignTable([]string{"| h |", "|:-:|", "| abcde |"})
+	want := []string{"|   h   |", "| :---: |", "| abcde |"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AlignTable:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestTableCellAtAndStart(t *testing.T) {
+	line := "| one | two |"
+	if got := TableCellAt(line, 2); got != 0 {
+		t.Errorf("TableCellAt(col 2) = %d, want 0", got)
+	}
+	if got := TableCellAt(line, 8); got != 1 {
+		t.Errorf("TableCellAt(col 8) = %d, want 1", got)
+	}
+	if got := TableCellStart(line, 1); got != 8 {
+		t.Errorf("TableCellStart(1) = %d, want 8", got)
+	}
+	if got := TableCellStart(line, 2); got != -1 {
+		t.Errorf("TableCellStart(2) = %d, want -1", got)
+	}
+	if got := TableCellStart("|     | x |", 0); got != 2 {
+		t.Errorf("TableCellStart(empty cell) = %d, want 2", got)
+	}
+}
+
+func TestInsertTableRowAfterHeader(t *testing.T) {
+	got := InsertTableRow([]string{"| a | b |", "| --- | --- |", "| 1 | 2 |"}, 0)
+	if len(got) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(got))
+	}
+	if got[2] != "|     |     |" {
+		t.Errorf("new row should follow separator, got %q", got[2])
+	}
+}
+
+func TestInsertTableColumn(t *testing.T) {
+	got := InsertTableColumn([]string{"| a | b |", "| --- | --- |"}, 0)
+	want := []string{"| a   |     | b   |", "| --- | --- | --- |"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InsertTableColumn:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestTableAlignOnEscape(t *testing.T) {
+	a := newTestApp("doc.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"| a | b |", "|---|---|", "| long cell | x |"}
+	eb.cursorLine, eb.cursorCol = 2, 5
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyEscape})
+
+	if eb.buf.Lines[0] != "| a         | b   |" {
+		t.Errorf("header not aligned: %q", eb.buf.Lines[0])
+	}
+	if eb.cursorLine != 2 || eb.cursorCol != 2 {
+		t.Errorf("cursor should stay in first cell, got (%d,%d)", eb.cursorLine, eb.cursorCol)
+	}
+
+	// Alignment is a single undoable change.
+	a.undoAction()
+	if eb.buf.Lines[0] != "| a | b |" {
+		t.Errorf("undo should restore table, got %q", eb.buf.Lines[0])
+	}
+}
+
+func TestTableEscapeIgnoresPlainText(t *testing.T) {
+	a := newTestApp("notes.txt")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"| a | b |"}
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyEscape})
+
+	if eb.buf.Lines[0] != "| a | b |" {
+		t.Errorf("non-markdown table should be untouched, got %q", eb.buf.Lines[0])
+	}
+}
+
+func TestTableTabMovesBetweenCells(t *testing.T) {
+	a := newTestApp("doc.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"| a | b |", "|---|---|", "| 1 | 2 |"}
+	eb.cursorLine, eb.cursorCol = 0, 2
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyTab})
+	if eb.cursorLine != 0 || eb.cursorCol != 8 {
+		t.Errorf("tab to second cell: got (%d,%d), want (0,8)", eb.cursorLine, eb.cursorCol)
+	}
+
+	// Wraps to next row, skipping the separator.
+	a.handleEditKey(terminal.Key{Type: terminal.KeyTab})
+	if eb.cursorLine != 2 || eb.cursorCol != 2 {
+		t.Errorf("tab should skip separator: got (%d,%d), want (2,2)", eb.cursorLine, eb.cursorCol)
+	}
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyShiftTab})
+	if eb.cursorLine != 0 || eb.cursorCol != 8 {
+		t.Errorf("shift-tab back over separator: got (%d,%d), want (0,8)", eb.cursorLine, eb.cursorCol)
+	}
+}
+
+func TestTableTabAppendsRow(t *testing.T) {
+	a := newTestApp("doc.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"| a | b |", "|---|---|", "| 1 | 2 |"}
+	eb.cursorLine, eb.cursorCol = 2, 8
+	a.mode = ModeEdit
+
+	a.handleEditKey(terminal.Key{Type: terminal.KeyTab})
+
+	if len(eb.buf.Lines) != 4 {
+		t.Fatalf("tab past last cell should append a row, got %d lines", len(eb.buf.Lines))
+	}
+	if eb.cursorLine != 3 {
+		t.Errorf("cursor should move to new row, got line %d", eb.cursorLine)
+	}
+}
+
+func TestCommandTableRowAndCol(t *testing.T) {
+	a := newTestApp("doc.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"| a | b |", "|---|---|", "| 1 | 2 |"}
+	eb.cursorLine = 2
+
+	a.executeCommand("table row")
+	if len(eb.buf.Lines) != 4 || eb.cursorLine != 3 {
+		t.Errorf(":table row: %d lines, cursor line %d", len(eb.buf.Lines), eb.cursorLine)
+	}
+
+	a.executeCommand("table col")
+	if n := len(SplitTableRow(eb.buf.Lines[0])); n != 3 {
+		t.Errorf(":table col: expected 3 columns, got %d", n)
+	}
+
+	eb.buf.Lines = append(eb.buf.Lines, "", "prose")
+	eb.cursorLine = len(eb.buf.Lines) - 1
+	a.executeCommand("table")
+	if !strings.Contains(a.statusBar.StatusMessage, "Not in a table") {
+		t.Errorf("expected not-in-table message, got %q", a.statusBar.StatusMessage)
+	}
+}
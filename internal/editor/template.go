@@ -0,0 +1,199 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// reTemplateVar matches a template variable such as {{title}}.
+var reTemplateVar = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// templateDir returns the directory holding user templates.
+func templateDir() string {
+	return filepath.Join(configDir(), "templates")
+}
+
+// loadTemplate reads a template by name. The ".md" extension may be left off.
+func loadTemplate(name string) (string, error) {
+	if name == "" || strings.ContainsRune(name, os.PathSeparator) {
+		return "", errors.New("bad template name")
+	}
+	path := filepath.Join(templateDir(), name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && filepath.Ext(name) == "" {
+		data, err = os.ReadFile(path + ".md")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// templateVars returns the variables used in text, in order of first use.
+func templateVars(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range reTemplateVar.FindAllStringSubmatch(text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// builtinTemplateVars returns the values prose fills in itself: the date and
+// time, a title made from the file name, and the author from the config.
+// Variables without a known value are left out so they can be asked for.
+func builtinTemplateVars(filename string, cfg Config, now time.Time) map[string]string {
+	vars := map[string]string{
+		"date": now.Format("2006-01-02"),
+		"time": now.Format("15:04"),
+	}
+	if title := titleFromFilename(filename); title != "" {
+		vars["title"] = title
+	}
+	if cfg.Author != "" {
+		vars["author"] = cfg.Author
+	}
+	return vars
+}
+
+// titleFromFilename turns a file name such as "my-first-post.md" into a
+// title, "My first post".
+func titleFromFilename(filename string) string {
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	if filename == "" || name == "" {
+		return ""
+	}
+	name = strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	runes := []rune(name)
+	if len(runes) == 0 {
+		return ""
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// expandTemplate substitutes vars into text. Variables without a value are
+// left as they are.
+func expandTemplate(text string, vars map[string]string) string {
+	return reTemplateVar.ReplaceAllStringFunc(text, func(m string) string {
+		name := reTemplateVar.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// templateFill is a template being instantiated while the user is asked for
+// the variables prose can't fill in itself.
+type templateFill struct {
+	name    string
+	text    string
+	vars    map[string]string
+	missing []string // Variables still to ask for, in order
+}
+
+// startTemplate inserts the named template at the cursor, first prompting for
+// any variables without a built-in value.
+func (a *App) startTemplate(name string) {
+	if a.guardReadOnly() {
+		return
+	}
+	text, err := loadTemplate(name)
+	if err != nil {
+		a.statusBar.SetMessage("No template " + name + " in " + templateDir())
+		return
+	}
+	eb := a.currentBuf()
+	fill := &templateFill{name: name, text: text, vars: builtinTemplateVars(eb.buf.Filename, a.config, time.Now())}
+	for _, v := range templateVars(text) {
+		if _, ok := fill.vars[v]; !ok {
+			fill.missing = append(fill.missing, v)
+		}
+	}
+	a.templateFill = fill
+	a.nextTemplatePrompt()
+}
+
+// nextTemplatePrompt asks for the next missing variable, or inserts the
+// template once every variable has a value.
+func (a *App) nextTemplatePrompt() {
+	fill := a.templateFill
+	if len(fill.missing) > 0 {
+		a.statusBar.StartPrompt(PromptTemplateVar)
+		a.statusBar.PromptLabel = fill.missing[0]
+		return
+	}
+	a.templateFill = nil
+	a.insertTemplate(expandTemplate(fill.text, fill.vars))
+	a.statusBar.SetMessage("Inserted template " + fill.name)
+}
+
+// finishTemplatePrompt records the answer for the variable being asked for.
+func (a *App) finishTemplatePrompt(value string) {
+	fill := a.templateFill
+	if fill == nil || len(fill.missing) == 0 {
+		return
+	}
+	fill.vars[fill.missing[0]] = value
+	fill.missing = fill.missing[1:]
+	a.nextTemplatePrompt()
+}
+
+// insertTemplate puts text below the cursor line, or in place of an empty
+// buffer, as a single undoable change.
+func (a *App) insertTemplate(text string) {
+	eb := a.currentBuf()
+	lines := strings.Split(text, "\n")
+	if eb.buf.LineCount() == 1 && eb.buf.Lines[0] == "" {
+		a.replaceLines(0, 1, lines)
+		eb.cursorLine = 0
+	} else {
+		a.replaceLines(eb.cursorLine+1, 0, lines)
+		eb.cursorLine++
+	}
+	eb.cursorCol = 0
+}
+
+// newFromTemplate opens filename, or a new unnamed buffer if it is empty,
+// filled from the named template. A file that already has text is opened
+// as it is.
+func (a *App) newFromTemplate(name, filename string) {
+	if _, err := loadTemplate(name); err != nil {
+		a.statusBar.SetMessage("No template " + name + " in " + templateDir())
+		return
+	}
+	a.pushJump()
+	if filename == "" {
+		a.buffers = append(a.buffers, NewEditorBuffer(""))
+		a.currentBuffer = len(a.buffers) - 1
+	} else {
+		a.currentBuffer = a.openBuffer(filename)
+	}
+	a.applyNewFileTemplate(name)
+}
+
+// applyNewFileTemplate fills the current buffer from the named template if
+// the buffer is empty.
+func (a *App) applyNewFileTemplate(name string) {
+	eb := a.currentBuf()
+	if eb.buf.LineCount() > 1 || eb.buf.Lines[0] != "" {
+		a.statusBar.SetMessage(filepath.Base(eb.buf.Filename) + " already exists; template not used")
+		return
+	}
+	a.startTemplate(name)
+}
+
+// SetTemplate fills the first file from the named template when it is new,
+// as prose --template does.
+func (a *App) SetTemplate(name string) {
+	a.newTemplate = name
+}
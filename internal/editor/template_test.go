@@ -0,0 +1,172 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// writeTemplate saves a template in the test config directory.
+func writeTemplate(t *testing.T, name, content string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(templateDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir(), name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemplateVars(t *testing.T) {
+	got := templateVars("# {{title}}\nby {{ author }} on {{date}}, {{title}} again, {{mood}}")
+	want := []string{"title", "author", "date", "mood"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("templateVars = %v, want %v", got, want)
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	got := expandTemplate("# {{title}}\n{{ date }} {{unknown}}", map[string]string{"title": "Hello", "date": "2024-05-31"})
+	if got != "# Hello\n2024-05-31 {{unknown}}" {
+		t.Errorf("expandTemplate = %q", got)
+	}
+}
+
+func TestBuiltinTemplateVars(t *testing.T) {
+	now := time.Date(2024, 5, 31, 9, 5, 0, 0, time.UTC)
+	vars := builtinTemplateVars("/posts/my-first_post.md", Config{Author: "Ada"}, now)
+	want := map[string]string{"date": "2024-05-31", "time": "09:05", "title": "My first post", "author": "Ada"}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("%s = %q, want %q", k, vars[k], v)
+		}
+	}
+
+	vars = builtinTemplateVars("", Config{}, now)
+	if _, ok := vars["title"]; ok {
+		t.Error("an unnamed buffer has no title")
+	}
+	if _, ok := vars["author"]; ok {
+		t.Error("author should be asked for when not configured")
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	writeTemplate(t, "post.md", "# {{title}}\n")
+	for _, name := range []string{"post", "post.md"} {
+		text, err := loadTemplate(name)
+		if err != nil || text != "# {{title}}" {
+			t.Errorf("loadTemplate(%q) = %q, %v", name, text, err)
+		}
+	}
+	if _, err := loadTemplate("../post.md"); err == nil {
+		t.Error("template names must not contain a path")
+	}
+}
+
+func TestTemplateCommandPrompts(t *testing.T) {
+	writeTemplate(t, "post.md", "---\ntitle: {{title}}\nauthor: {{author}}\ntags: {{tags}}\n---\n")
+
+	a := newTestApp("")
+	a.executeCommand("template post")
+	if a.statusBar.Prompt != PromptTemplateVar || a.statusBar.PromptLabel != "title" {
+		t.Fatalf("should prompt for title, got prompt %v %q", a.statusBar.Prompt, a.statusBar.PromptLabel)
+	}
+	answer := func(text string) {
+		for _, r := range text {
+			a.handlePromptKey(terminal.Key{Type: terminal.KeyRune, Rune: r})
+		}
+		a.handlePromptKey(terminal.Key{Type: terminal.KeyEnter})
+	}
+	answer("Spring")
+	if a.statusBar.PromptLabel != "author" {
+		t.Fatalf("should prompt for author next, got %q", a.statusBar.PromptLabel)
+	}
+	if got := a.statusBar.FormatLeft("", false, "", 0, false); got != " author: " {
+		t.Errorf("prompt shows %q", got)
+	}
+	answer("Ada")
+	answer("")
+	if a.statusBar.Prompt != PromptNone {
+		t.Fatal("all variables answered; prompt should close")
+	}
+	want := "---|title: Spring|author: Ada|tags: |---"
+	if got := strings.Join(a.currentBuf().buf.Lines, "|"); got != want {
+		t.Errorf("buffer = %q, want %q", got, want)
+	}
+
+	// The whole insertion is one undo step.
+	a.undoAction()
+	if got := strings.Join(a.currentBuf().buf.Lines, "|"); got != "" {
+		t.Errorf("after undo: %q", got)
+	}
+}
+
+func TestTemplateCommandCancel(t *testing.T) {
+	writeTemplate(t, "post.md", "{{mood}}\n")
+	a := newTestApp("")
+	a.executeCommand("template post")
+	a.handlePromptKey(terminal.Key{Type: terminal.KeyEscape})
+	if a.templateFill != nil || a.currentBuf().buf.Lines[0] != "" {
+		t.Errorf("Esc should cancel the template: %q", a.currentBuf().buf.Lines)
+	}
+}
+
+func TestTemplateCommandMissing(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	a := newTestApp("")
+	a.executeCommand("template nope")
+	if !strings.HasPrefix(a.statusBar.StatusMessage, "No template nope") {
+		t.Errorf("message = %q", a.statusBar.StatusMessage)
+	}
+}
+
+func TestTemplateInsertsBelowCursor(t *testing.T) {
+	writeTemplate(t, "sig.md", "-- \n{{date}}\n")
+	a := newTestApp("letter.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Dear Sir,", "Regards"}
+	eb.cursorLine = 1
+	a.executeCommand("template sig")
+	if len(eb.buf.Lines) != 4 || eb.buf.Lines[2] != "-- " || eb.cursorLine != 2 {
+		t.Errorf("buffer = %q, cursor line %d", eb.buf.Lines, eb.cursorLine)
+	}
+}
+
+func TestNewCommandFromTemplate(t *testing.T) {
+	writeTemplate(t, "journal.md", "# {{title}}\n\n{{date}}\n")
+	t.Chdir(t.TempDir())
+	os.WriteFile("old-entry.md", []byte("Written\n"), 0644)
+
+	a := newTestApp("draft.md")
+	a.executeCommand("new journal a-quiet-day.md")
+	eb := a.currentBuf()
+	want := "# A quiet day||" + time.Now().Format("2006-01-02")
+	if got := strings.Join(eb.buf.Lines, "|"); eb.buf.Filename != "a-quiet-day.md" || got != want {
+		t.Errorf("%s = %q, want %q", eb.buf.Filename, got, want)
+	}
+	if len(a.buffers) != 2 {
+		t.Errorf("the new file should open in a new tab, got %d", len(a.buffers))
+	}
+
+	a.executeCommand("new journal old-entry.md")
+	if eb := a.currentBuf(); eb.buf.Lines[0] != "Written" || !strings.Contains(a.statusBar.StatusMessage, "already exists") {
+		t.Errorf("a file with text should be left alone: %q, %q", eb.buf.Lines, a.statusBar.StatusMessage)
+	}
+
+	a.executeCommand("new journal")
+	if eb := a.currentBuf(); eb.buf.Filename != "" || a.statusBar.PromptLabel != "title" {
+		t.Errorf("without a name :new should fill an unnamed buffer, asking for the title, got %q", eb.buf.Filename)
+	}
+
+	a.statusBar.Prompt = PromptNone
+	a.executeCommand("new nope x.md")
+	if !strings.HasPrefix(a.statusBar.StatusMessage, "No template nope") || len(a.buffers) != 4 {
+		t.Errorf("a missing template should open nothing: %q, %d buffers", a.statusBar.StatusMessage, len(a.buffers))
+	}
+}
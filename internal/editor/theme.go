@@ -0,0 +1,235 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Theme is the set of colours the editor draws with: the highlight palette,
+// Markdown syntax and the status bar. Each colour is an ANSI SGR sequence.
+type Theme struct {
+	Palette
+	Heading string // Heading lines, and folded sections
+	Bold    string // **Bold** text
+	Italic  string // *Italic* text
+	Code    string // Inline code and code blocks
+	Keyword string // Keywords in code blocks
+	String  string // String literals in code blocks
+	Link    string // Link text
+	Muted   string // Rules, quotes, table borders, code fences and comments
+
+	Status   string // The status bar
+	Modified string // The file name in the status bar when there are unsaved changes
+	Errors   string // The status bar's mark for spelling errors
+}
+
+// darkTheme is the default theme, for terminals with dark backgrounds. The
+// status bar is in reverse video, so its colours are given as backgrounds.
+var darkTheme = Theme{
+	Palette:  palettes["default"],
+	Heading:  "\x1b[1;34m",              // Bold blue
+	Bold:     "\x1b[1;33m",              // Bold yellow
+	Italic:   "\x1b[3;36m",              // Italic cyan
+	Code:     "\x1b[35m",                // Magenta
+	Keyword:  "\x1b[1;35m",              // Bold magenta
+	String:   "\x1b[32m",                // Green
+	Link:     "\x1b[4;32m",              // Underlined green
+	Muted:    "\x1b[90m",                // Grey
+	Status:   "\x1b[7m",                 // Reverse video
+	Modified: "\x1b[1;48;2;255;140;26m", // Bold orange
+	Errors:   "\x1b[48;5;9m",            // Red
+}
+
+// themes are the built-in themes, by the name used in the config file.
+var themes = map[string]Theme{
+	"dark": darkTheme,
+	"light": {
+		Palette: Palette{
+			Spell:         "\x1b[38;5;0m\x1b[48;2;255;212;212m", // Black on light red
+			SearchCurrent: "\x1b[38;5;0m\x1b[48;2;255;240;42m",  // Black on bright yellow
+			SearchOther:   "\x1b[38;5;0m\x1b[48;2;255;250;180m", // Black on light yellow
+			Selection:     "\x1b[7m",                            // Reverse video
+			CursorLine:    "\x1b[48;2;230;230;232m",             // Pale grey
+		},
+		Heading:  "\x1b[1;38;2;31;95;174m",  // Bold dark blue
+		Bold:     "\x1b[1;38;2;176;95;10m",  // Bold brown
+		Italic:   "\x1b[3;38;2;0;135;138m",  // Italic teal
+		Code:     "\x1b[38;2;138;30;138m",   // Plum
+		Keyword:  "\x1b[1;38;2;138;30;138m", // Bold plum
+		String:   "\x1b[38;2;30;135;30m",    // Dark green
+		Link:     "\x1b[4;38;2;30;135;30m",  // Underlined dark green
+		Muted:    "\x1b[38;2;128;128;128m",  // Mid grey
+		Status:   "\x1b[7m",                 // Reverse video
+		Modified: "\x1b[1;48;2;210;100;30m", // Bold dark orange
+		Errors:   "\x1b[48;2;210;30;30m",    // Dark red
+	},
+}
+
+// themeColors are a theme's colours by the names used in "color" lines.
+var themeColors = map[string]func(t *Theme) *string{
+	"spell":        func(t *Theme) *string { return &t.Spell },
+	"search":       func(t *Theme) *string { return &t.SearchCurrent },
+	"search-other": func(t *Theme) *string { return &t.SearchOther },
+	"selection":    func(t *Theme) *string { return &t.Selection },
+	"cursorline":   func(t *Theme) *string { return &t.CursorLine },
+	"heading":      func(t *Theme) *string { return &t.Heading },
+	"bold":         func(t *Theme) *string { return &t.Bold },
+	"italic":       func(t *Theme) *string { return &t.Italic },
+	"code":         func(t *Theme) *string { return &t.Code },
+	"keyword":      func(t *Theme) *string { return &t.Keyword },
+	"string":       func(t *Theme) *string { return &t.String },
+	"link":         func(t *Theme) *string { return &t.Link },
+	"muted":        func(t *Theme) *string { return &t.Muted },
+	"status":       func(t *Theme) *string { return &t.Status },
+	"modified":     func(t *Theme) *string { return &t.Modified },
+	"errors":       func(t *Theme) *string { return &t.Errors },
+}
+
+// orDefault returns t, or the dark theme for nil, so that highlighters and
+// status bars made without a theme draw in the default colours.
+func (t *Theme) orDefault() *Theme {
+	if t == nil {
+		return &darkTheme
+	}
+	return t
+}
+
+// SetColor sets the named colour from a description such as "bold blue" or
+// "black on 224".
+func (t *Theme) SetColor(name, desc string) error {
+	field, ok := themeColors[name]
+	if !ok {
+		return fmt.Errorf("unknown colour %q; want one of %s", name, choices(themeColors))
+	}
+	code, err := parseColor(desc)
+	if err != nil {
+		return err
+	}
+	*field(t) = code
+	return nil
+}
+
+// override replaces the colours of t that are set in o.
+func (t *Theme) override(o *Theme) {
+	for _, field := range themeColors {
+		if c := *field(o); c != "" {
+			*field(t) = c
+		}
+	}
+}
+
+// colorAttributes are the attribute words of a colour description.
+var colorAttributes = map[string]int{"bold": 1, "dim": 2, "italic": 3, "underline": 4, "reverse": 7, "strike": 9}
+
+// colorNames are the eight standard terminal colours. "bright-" in front
+// picks the bright version.
+var colorNames = map[string]int{"black": 0, "red": 1, "green": 2, "yellow": 3, "blue": 4, "magenta": 5, "cyan": 6, "white": 7}
+
+// parseColor turns a colour description into an SGR sequence: attribute
+// words, a foreground colour, and "on" and a background colour. Colours are
+// named, "grey", "default", numbered 0-255 as in 256-colour terminals, or
+// 24-bit, as in "#ffd7d7".
+func parseColor(desc string) (string, error) {
+	var params []string
+	words := strings.Fields(strings.ToLower(desc))
+	if len(words) == 0 {
+		return "", fmt.Errorf("want a colour, got %q", desc)
+	}
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if n, ok := colorAttributes[w]; ok {
+			params = append(params, strconv.Itoa(n))
+			continue
+		}
+		base := 30
+		if w == "on" {
+			if i++; i == len(words) {
+				return "", fmt.Errorf("want a colour after \"on\" in %q", desc)
+			}
+			w, base = words[i], 40
+		}
+		p, err := colorParam(w, base)
+		if err != nil {
+			return "", err
+		}
+		params = append(params, p)
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m", nil
+}
+
+// colorParam returns the SGR parameter for a colour word, as a foreground
+// colour for base 30 or a background for base 40.
+func colorParam(w string, base int) (string, error) {
+	if hex, ok := strings.CutPrefix(w, "#"); ok {
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return "", fmt.Errorf("want a colour such as #ffd7d7, got %q", w)
+		}
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, rgb>>16, rgb>>8&0xff, rgb&0xff), nil
+	}
+	if n, err := strconv.Atoi(w); err == nil {
+		if n < 0 || n > 255 {
+			return "", fmt.Errorf("colour numbers run from 0 to 255, got %d", n)
+		}
+		return fmt.Sprintf("%d;5;%d", base+8, n), nil
+	}
+	switch w {
+	case "default":
+		return strconv.Itoa(base + 9), nil
+	case "grey", "gray":
+		return strconv.Itoa(base + 60), nil
+	}
+	name, bright := strings.CutPrefix(w, "bright-")
+	n, ok := colorNames[name]
+	if !ok {
+		return "", fmt.Errorf("unknown colour %q", w)
+	}
+	if bright {
+		n += 60
+	}
+	return strconv.Itoa(base + n), nil
+}
+
+// colorOff returns the SGR sequence that turns off what code turns on,
+// leaving other attributes alone, so a span can end inside a styled line.
+func colorOff(code string) string {
+	code = strings.ReplaceAll(code, "m\x1b[", ";") // One sequence
+	params := strings.Split(strings.TrimSuffix(strings.TrimPrefix(code, "\x1b["), "m"), ";")
+	var off []string
+	add := func(p string) {
+		for _, o := range off {
+			if o == p {
+				return
+			}
+		}
+		off = append(off, p)
+	}
+	for i := 0; i < len(params); i++ {
+		n, err := strconv.Atoi(params[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 1 || n == 2:
+			add("22")
+		case n == 3 || n == 4 || n == 7 || n == 9:
+			add(strconv.Itoa(20 + n))
+		case n == 38 || n == 48:
+			add(strconv.Itoa(n + 1))
+			if i+1 < len(params) && params[i+1] == "2" {
+				i += 4 // 2 and red, green and blue
+			} else {
+				i += 2 // 5 and the colour number
+			}
+		case n >= 30 && n <= 37 || n >= 90 && n <= 97:
+			add("39")
+		case n >= 40 && n <= 47 || n >= 100 && n <= 107:
+			add("49")
+		}
+	}
+	if len(off) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(off, ";") + "m"
+}
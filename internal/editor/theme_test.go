@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThemesComplete(t *testing.T) {
+	for name, theme := range themes {
+		for color, field := range themeColors {
+			if *field(&theme) == "" {
+				t.Errorf("theme %q has no %s colour", name, color)
+			}
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		desc, want, err string
+	}{
+		{desc: "blue", want: "\x1b[34m"},
+		{desc: "bold Blue", want: "\x1b[1;34m"},
+		{desc: "black on 224", want: "\x1b[30;48;5;224m"},
+		{desc: "italic bright-cyan on grey", want: "\x1b[3;96;100m"},
+		{desc: "underline 28", want: "\x1b[4;38;5;28m"},
+		{desc: "default on default", want: "\x1b[39;49m"},
+		{desc: "", err: "want a colour"},
+		{desc: "red on", err: `after "on"`},
+		{desc: "mauve", err: `unknown colour "mauve"`},
+		{desc: "300", err: "0 to 255"},
+	}
+	for _, tt := range tests {
+		got, err := parseColor(tt.desc)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseColor(%q) = %q, %v, want error %q", tt.desc, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseColor(%q) = %q, %v, want %q", tt.desc, got, err, tt.want)
+		}
+	}
+}
+
+func TestColorOff(t *testing.T) {
+	tests := []struct {
+		code, want string
+	}{
+		{"\x1b[1;33m", "\x1b[22;39m"},
+		{"\x1b[3;36m", "\x1b[23;39m"},
+		{"\x1b[1;48;5;208m", "\x1b[22;49m"},
+		{"\x1b[38;5;0m\x1b[48;5;224m", "\x1b[39;49m"},
+		{"\x1b[4;38;2;10;20;30m", "\x1b[24;39m"},
+		{"\x1b[7m", "\x1b[27m"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := colorOff(tt.code); got != tt.want {
+			t.Errorf("colorOff(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestConfigTheme(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader("theme = light\npalette = protanopia\ncolor heading = bold red\ncolour Status = black on 252\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	theme := cfg.theme()
+	if theme.Heading != "\x1b[1;31m" || theme.Status != "\x1b[30;48;5;252m" {
+		t.Errorf("color lines should set colours, got %q, %q", theme.Heading, theme.Status)
+	}
+	if theme.Bold != themes["light"].Bold {
+		t.Errorf("unset colours should be the theme's, got %q", theme.Bold)
+	}
+	if theme.Spell != palettes["protanopia"].Spell || theme.CursorLine != themes["light"].CursorLine {
+		t.Errorf("the palette should set highlights but not the cursor line, got %q, %q", theme.Spell, theme.CursorLine)
+	}
+
+	if got := DefaultConfig().theme(); got != darkTheme {
+		t.Errorf("the default theme should be dark, got %+v", got)
+	}
+}
+
+func TestParseConfigColorErrors(t *testing.T) {
+	_, err := parseConfig(strings.NewReader("color headline = blue\ncolor bold = shiny\ntheme = sepia\n"))
+	for _, want := range []string{`line 1: color: unknown colour "headline"`, `line 2: color: unknown colour "shiny"`, "line 3: theme: want one of dark, light"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v should mention %q", err, want)
+		}
+	}
+}
+
+func TestThemedHighlighting(t *testing.T) {
+	theme := darkTheme
+	theme.Heading = "\x1b[1;31m"
+	theme.Bold = "\x1b[1;38;5;130m"
+	h := MarkdownHighlighter{Theme: &theme}
+	if got := h.Highlight("# Title"); !strings.HasPrefix(got, "\x1b[1;31m") {
+		t.Errorf("heading should use the theme's colour, got %q", got)
+	}
+	if got := h.Highlight("some **bold** text"); !strings.Contains(got, "\x1b[1;38;5;130mbold\x1b[22;39m") {
+		t.Errorf("bold should use the theme's colour, got %q", got)
+	}
+
+	r := NewRenderer()
+	r.theme = &theme
+	dl := DisplayLine{Text: "# Title"}
+	if got := highlightDisplayLine(DetectHighlighter("a.md"), dl, r.theme); !strings.HasPrefix(got, "\x1b[1;31m") {
+		t.Errorf("the renderer should pass its theme to the highlighter, got %q", got)
+	}
+}
+
+func TestThemedStatusBar(t *testing.T) {
+	theme := themes["light"]
+	sb := &StatusBar{Theme: &theme}
+	got := sb.FormatLeft("draft.md", true, "", 2, false)
+	if !strings.Contains(got, theme.Modified+"draft.md") || !strings.Contains(got, theme.Errors+"●") {
+		t.Errorf("status bar should use the theme's colours, got %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+package editor
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tidying fixes two slips of typing: a sentence begun in lower case, and
+// more than one space after a full stop. With tidy = on they are fixed as
+// they are typed, and :tidy fixes the whole buffer. Each fix is an undo step
+// of its own, so u takes back a wrong one without the typing around it.
+
+// nonFinalAbbreviations end in a full stop but rarely end a sentence.
+var nonFinalAbbreviations = map[string]bool{
+	"e.g.": true, "i.e.": true, "cf.": true, "vs.": true, "viz.": true,
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "st.": true,
+	"no.": true, "p.": true, "pp.": true, "approx.": true,
+}
+
+// reBlockMarkers matches the heading, quote and list markers before the
+// first word of a line.
+var reBlockMarkers = regexp.MustCompile(`^\s*(?:(?:#{1,6}|>|[-*+]|\d+[.)])\s+)*>?\s*$`)
+
+// endsSentence reports whether text ends a sentence: with a full stop,
+// question or exclamation mark, perhaps followed by closing quotes,
+// brackets or emphasis. An ellipsis, an initial such as "J.", abbreviations
+// such as "e.g." and a quoted question or exclamation, which a dialogue tag
+// may follow, don't end one.
+func endsSentence(text string) bool {
+	unquoted := strings.TrimRight(text, "\"”'’")
+	quoted := unquoted != text
+	text = strings.TrimRight(unquoted, "\"”'’)]*_")
+	if !strings.HasSuffix(text, ".") && !strings.HasSuffix(text, "!") && !strings.HasSuffix(text, "?") {
+		return false
+	}
+	if quoted && !strings.HasSuffix(text, ".") {
+		return false // "Go!" she said.
+	}
+	if strings.HasSuffix(text, "..") {
+		return false
+	}
+	return !isAbbreviation(text[strings.LastIndexFunc(text, unicode.IsSpace)+1:])
+}
+
+// isAbbreviation reports whether word ends in a full stop that doesn't end a
+// sentence: it is an abbreviation such as "e.g." or an initial such as "J.".
+func isAbbreviation(word string) bool {
+	word = strings.TrimLeft(word, "\"“'‘([*_")
+	if nonFinalAbbreviations[strings.ToLower(word)] {
+		return true
+	}
+	r, size := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r) && word[size:] == "."
+}
+
+// startsParagraph reports whether the first word of line i in lines begins
+// a sentence: it is a heading, or follows a blank line, a heading, a code
+// fence, a scene break or front matter, or a line ending a sentence. List items are often fragments, so
+// their first words are left as typed.
+func startsParagraph(lines []string, i int) bool {
+	if reListItem.MatchString(lines[i]) {
+		return false
+	}
+	if i == 0 || strings.HasPrefix(strings.TrimSpace(lines[i]), "#") {
+		return true
+	}
+	prev := strings.TrimSpace(lines[i-1])
+	return prev == "" || strings.HasPrefix(prev, "#") || reCodeFence.MatchString(lines[i-1]) || reSceneBreak.MatchString(lines[i-1]) || endsSentence(prev)
+}
+
+// needsCapital reports whether the word starting at rune col of line begins
+// a sentence in lower case. lineStart says whether the line's first word
+// begins one. Words with capitals, digits or the dots and slashes of
+// addresses are left alone, as are words in inline code.
+func needsCapital(line string, col int, lineStart bool) bool {
+	runes := []rune(line)
+	if col >= len(runes) || !unicode.IsLower(runes[col]) {
+		return false
+	}
+	end := col
+	for end < len(runes) && !unicode.IsSpace(runes[end]) {
+		end++
+	}
+	word := strings.TrimRight(string(runes[col:end]), ".,;:!?\"”'’)]*_")
+	if mixedCase(word) || strings.ContainsAny(word, "./@:\\`0123456789") {
+		return false
+	}
+	before := string(runes[:col])
+	if strings.Count(before, "`")%2 == 1 {
+		return false
+	}
+	before = strings.TrimRight(before, "\"“'‘([*_")
+	if before != "" && !unicode.IsSpace(rune(before[len(before)-1])) {
+		return false // Inside a word
+	}
+	if reBlockMarkers.MatchString(before) {
+		return lineStart
+	}
+	return endsSentence(strings.TrimRight(before, " \t"))
+}
+
+// extraSpaces returns the rune span of spaces after the first following a
+// sentence's end at or after rune col, or -1, -1. Spaces ending the line
+// are a Markdown line break, and are kept.
+func extraSpaces(line string, col int) (start, end int) {
+	runes := []rune(line)
+	for i := max(col, 1); i+1 < len(runes); i++ {
+		if runes[i] != ' ' || runes[i+1] != ' ' || runes[i-1] == ' ' {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && runes[end] == ' ' {
+			end++
+		}
+		if end < len(runes) && endsSentence(string(runes[:i])) && strings.Count(string(runes[:i]), "`")%2 == 0 {
+			return i + 1, end
+		}
+	}
+	return -1, -1
+}
+
+// tidyLine returns line with its first fix made, and whether there was one
+// and which: a capital or closed-up spaces.
+func tidyLine(line string, lineStart bool) (fixed string, capital, ok bool) {
+	runes := []rune(line)
+	for i, r := range runes {
+		if !unicode.IsLower(r) || i > 0 && !unicode.IsSpace(runes[i-1]) && !strings.ContainsRune("\"“'‘([*_", runes[i-1]) {
+			continue
+		}
+		if needsCapital(line, i, lineStart) {
+			runes[i] = unicode.ToUpper(runes[i])
+			return string(runes), true, true
+		}
+	}
+	if start, end := extraSpaces(line, 0); start >= 0 {
+		return string(runes[:start]) + string(runes[end:]), false, true
+	}
+	return line, false, false
+}
+
+// tidyTyped fixes the text before the cursor as key is typed in Edit mode,
+// with tidy on. A word's end capitalizes it if it starts a sentence; the
+// next word's first letter closes up spaces typed after a full stop.
+func (a *App) tidyTyped(ch rune) {
+	eb := a.currentBuf()
+	if !a.config.Tidy || eb.trackChanges || !isProseFile(eb.buf.Filename) || eb.cursorLine <= frontMatterEnd(eb.buf.Lines) {
+		return
+	}
+	if blocks := ComputeBlockStates(eb.buf.Lines[:eb.cursorLine+1]); blocks[eb.cursorLine].InCode || IsTableLine(eb.buf.Lines[eb.cursorLine]) {
+		return
+	}
+	line := eb.buf.Lines[eb.cursorLine]
+	runes := []rune(line)
+	col := min(eb.cursorCol, len(runes))
+	if ch != ' ' && !endsAbbreviation(ch) {
+		// Typing a word's first letter after spaces.
+		spaces := col
+		for spaces > 0 && runes[spaces-1] == ' ' {
+			spaces--
+		}
+		if col-spaces < 2 {
+			return
+		}
+		if start, end := extraSpaces(line+"x", spaces); start >= 0 && end == col {
+			a.tidyFix(string(runes[:start])+string(runes[end:]), start)
+		}
+		return
+	}
+	start := col
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	for ; start < col; start++ {
+		if unicode.IsLetter(runes[start]) {
+			break
+		}
+	}
+	if start < col && needsCapital(line, start, startsParagraph(eb.buf.Lines, eb.cursorLine)) {
+		runes[start] = unicode.ToUpper(runes[start])
+		a.tidyFix(string(runes), col)
+	}
+}
+
+// tidyFix replaces the cursor's line with line as an undo step of its own,
+// leaving the cursor at col.
+func (a *App) tidyFix(line string, col int) {
+	eb := a.currentBuf()
+	eb.undo.PushReplaceLines(eb.cursorLine, []string{eb.buf.Lines[eb.cursorLine]}, []string{line}, eb.cursorLine, eb.cursorCol)
+	replaceLines(eb.buf, eb.cursorLine, 1, []string{line})
+	eb.cursorCol = col
+}
+
+// tidyBuffer runs :tidy, capitalizing sentences and closing up spaces after
+// them throughout the buffer, outside code, front matter and tables.
+func (a *App) tidyBuffer() {
+	eb := a.currentBuf()
+	lines := eb.buf.Lines
+	blocks := ComputeBlockStates(lines)
+	capitals, spaces := 0, 0
+	for i := frontMatterEnd(lines) + 1; i < len(lines); i++ {
+		if blocks[i].InCode || IsTableLine(lines[i]) {
+			continue
+		}
+		lineStart := startsParagraph(lines, i)
+		for {
+			fixed, capital, ok := tidyLine(lines[i], lineStart)
+			if !ok {
+				break
+			}
+			if capitals+spaces == 0 && a.guardReadOnly() {
+				return
+			}
+			a.replaceLines(i, 1, []string{fixed})
+			lines = eb.buf.Lines
+			if capital {
+				capitals++
+			} else {
+				spaces++
+			}
+		}
+	}
+	if capitals+spaces == 0 {
+		a.statusBar.SetMessage("Nothing to tidy")
+		return
+	}
+	eb.cursorCol = min(eb.cursorCol, eb.buf.LineLen(eb.cursorLine))
+	var fixes []string
+	if capitals > 0 {
+		fixes = append(fixes, "capitalized "+plural(capitals, "sentence"))
+	}
+	if spaces > 0 {
+		fixes = append(fixes, "closed up "+plural(spaces, "double space"))
+	}
+	a.statusBar.SetMessage(capitalize(strings.Join(fixes, " and ")) + "; u undoes one at a time")
+}
@@ -0,0 +1,189 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestEndsSentence(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"It rained.", true},
+		{"Did it?", true},
+		{"(as it was.)", true},
+		{"*Done.*", true},
+		{"It rained", false},
+		{"It rained,", false},
+		{"Wait...", false},
+		{"Ask Dr.", false},
+		{"fruit, e.g.", false},
+		{"by J.", false},
+		{`"Go!"`, false},
+		{`"Go."`, true},
+	}
+	for _, tt := range tests {
+		if got := endsSentence(tt.text); got != tt.want {
+			t.Errorf("endsSentence(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTidyLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		lineStart bool
+		want      string
+	}{
+		{"sentence start", "It rained. the end.", false, "It rained. The end."},
+		{"line start", "the rain fell.", true, "The rain fell."},
+		{"continued line", "the rain fell.", false, "the rain fell."},
+		{"heading", "## the rain", true, "## The rain"},
+		{"dialogue tag", `"Go!" she said. "now."`, false, `"Go!" she said. "Now."`},
+		{"abbreviation", "Fruit, e.g. apples.", false, "Fruit, e.g. apples."},
+		{"address", "See it. example.com has it.", false, "See it. example.com has it."},
+		{"mixed case", "I bought it. iPhones are dear.", false, "I bought it. iPhones are dear."},
+		{"inline code", "Run it. `go test` then.", false, "Run it. `go test` then."},
+		{"double space", "It rained.  Then it stopped.", false, "It rained. Then it stopped."},
+		{"many spaces", "Really?    Yes.", false, "Really? Yes."},
+		{"line break", "It rained.  ", false, "It rained.  "},
+		{"mid-sentence spaces", "It  rained.", false, "It  rained."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := tt.line
+			for fixes := 0; ; fixes++ {
+				fixed, _, ok := tidyLine(line, tt.lineStart)
+				if !ok {
+					break
+				}
+				if fixes > 10 {
+					t.Fatalf("tidyLine keeps fixing %q", line)
+				}
+				line = fixed
+			}
+			if line != tt.want {
+				t.Errorf("tidied %q to %q, want %q", tt.line, line, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartsParagraph(t *testing.T) {
+	lines := []string{"first line", "wrapped line.", "next sentence", "", "new paragraph", "- list item", "# heading"}
+	want := []bool{true, false, true, false, true, false, true}
+	for i := range lines {
+		if lines[i] == "" {
+			continue
+		}
+		if got := startsParagraph(lines, i); got != want[i] {
+			t.Errorf("startsParagraph(%q) = %v, want %v", lines[i], got, want[i])
+		}
+	}
+}
+
+func TestTidyWhileTyping(t *testing.T) {
+	tests := []struct {
+		name  string
+		typed string
+		want  []string
+	}{
+		{"capital", "it rained. the end", []string{"It rained. The end"}},
+		{"capital at enter", "it\n", []string{"It", ""}},
+		{"double space", "It rained.  Then", []string{"It rained. Then"}},
+		{"line break kept", "It rained.  \nthen", []string{"It rained.  ", "then"}},
+		{"unfinished word", "It rained. th", []string{"It rained. th"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp("draft.md")
+			a.config.Tidy = true
+			sendKeys(a, "i")
+			for _, r := range tt.typed {
+				if r == '\n' {
+					sendKey(a, terminal.KeyEnter)
+				} else {
+					sendKeys(a, string(r))
+				}
+			}
+			if got := a.currentBuf().buf.Lines; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("typed %q, got %q, want %q", tt.typed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTidyOff(t *testing.T) {
+	a := newTestApp("draft.md")
+	sendKeys(a, "iit rained.  the end ")
+	if got := a.currentBuf().buf.Lines[0]; got != "it rained.  the end " {
+		t.Errorf("tidy is off by default, got %q", got)
+	}
+
+	a = newTestApp("main.go")
+	a.config.Tidy = true
+	sendKeys(a, "iit ")
+	if got := a.currentBuf().buf.Lines[0]; got != "it " {
+		t.Errorf("only prose files are tidied, got %q", got)
+	}
+}
+
+func TestTidyUndo(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.executeCommand("set tidy")
+	sendKeys(a, "iok. yes and\x1bu")
+	if got := a.currentBuf().buf.Lines[0]; got != "Ok. Yes" {
+		t.Errorf("undo should take back the typing after the capital, got %q", got)
+	}
+	sendKeys(a, "u")
+	if got := a.currentBuf().buf.Lines[0]; got != "Ok. yes" {
+		t.Errorf("undo should take back the capital on its own, got %q", got)
+	}
+}
+
+func TestTidyCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.currentBuf().buf.Lines = []string{
+		"---",
+		"title: the draft",
+		"---",
+		"it rained.  then it stopped.",
+		"```",
+		"code. here",
+		"```",
+		"- a list item",
+	}
+	a.executeCommand("tidy")
+	want := []string{
+		"---",
+		"title: the draft",
+		"---",
+		"It rained. Then it stopped.",
+		"```",
+		"code. here",
+		"```",
+		"- a list item",
+	}
+	if got := a.currentBuf().buf.Lines; !reflect.DeepEqual(got, want) {
+		t.Errorf(":tidy = %q, want %q", got, want)
+	}
+	if msg := a.statusBar.StatusMessage; !strings.HasPrefix(msg, "Capitalized 2 sentences and closed up 1 double space") {
+		t.Errorf("message = %q", msg)
+	}
+
+	sendKeys(a, "u")
+	if got := a.currentBuf().buf.Lines[3]; got != "It rained.  Then it stopped." {
+		t.Errorf("undo should take back one fix, got %q", got)
+	}
+
+	a.executeCommand("tidy")
+	a.executeCommand("tidy")
+	if msg := a.statusBar.StatusMessage; msg != "Nothing to tidy" {
+		t.Errorf("message = %q", msg)
+	}
+}
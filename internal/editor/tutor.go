@@ -0,0 +1,26 @@
+package editor
+
+import _ "embed"
+
+// :tutor, or prose --tutor, opens a guided tutorial in an unnamed buffer, to
+// be worked through in the editor itself like vimtutor. Since the buffer is
+// unnamed, changes to it are lost unless saved under a name with :w.
+
+//go:embed tutor.md
+var tutorText []byte
+
+// openTutor runs :tutor, opening a fresh copy of the tutorial.
+func (a *App) openTutor() {
+	eb := NewEditorBuffer("")
+	eb.buf.Lines = splitFileText(tutorText)
+	eb.highlighter = DetectHighlighter("tutor.md")
+	a.pushJump()
+	a.buffers = append(a.buffers, eb)
+	a.currentBuffer = len(a.buffers) - 1
+	a.statusBar.SetMessage("Tutorial: j moves down, :q! closes it")
+}
+
+// SetTutor opens the tutorial on starting, as prose --tutor does.
+func (a *App) SetTutor(on bool) {
+	a.tutor = on
+}
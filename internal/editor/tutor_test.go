@@ -0,0 +1,47 @@
+package editor
+
+import "testing"
+
+func TestTutorCommand(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.executeCommand("tutor")
+	if len(a.buffers) != 2 || a.currentBuffer != 1 {
+		t.Fatalf(":tutor should open a new buffer, got %d buffers", len(a.buffers))
+	}
+	eb := a.currentBuf()
+	if eb.buf.Filename != "" || eb.buf.Dirty {
+		t.Errorf("the tutorial should be an unnamed, unchanged buffer, got %q dirty %v", eb.buf.Filename, eb.buf.Dirty)
+	}
+	if eb.buf.Lines[0] != "# The prose tutorial" {
+		t.Errorf("first line = %q", eb.buf.Lines[0])
+	}
+	if _, ok := eb.highlighter.(MarkdownHighlighter); !ok {
+		t.Error("the tutorial should be highlighted as Markdown")
+	}
+
+	a.jumpBack()
+	if a.currentBuffer != 0 {
+		t.Errorf("Ctrl-O should go back to the draft, got buffer %d", a.currentBuffer)
+	}
+}
+
+// The tutorial teaches the default bindings, so it needs changing with them.
+func TestTutorKeys(t *testing.T) {
+	k := DefaultKeymap()
+	for key, action := range map[string]string{
+		"j": "down", "w": "next-word", "}": "next-paragraph", "G": "bottom",
+		"i": "insert", "A": "append", "o": "open-below", "d": "delete", "c": "change",
+		"r": "replace-char", "y": "yank", "p": "paste-below", "u": "undo", "Ctrl-R": "redo",
+		"x": "next-spelling", "V": "line-select", "v": "visual", "/": "search",
+		"n": "next-match", "s": "send", "S": "scratch", ":": "command",
+	} {
+		if k.Default[key] != action {
+			t.Errorf("%s is bound to %q, the tutorial says %q", key, k.Default[key], action)
+		}
+	}
+	for key, action := range map[string]string{"b": "buffers", "h": "outline", ":": "palette", "?": "help"} {
+		if k.Leader[key] != action {
+			t.Errorf("Space %s is bound to %q, the tutorial says %q", key, k.Leader[key], action)
+		}
+	}
+}
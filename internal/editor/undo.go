@@ -1,5 +1,13 @@
 package editor
 
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
 // OpType describes the kind of edit operation for undo.
 type OpType int
 
@@ -13,27 +21,52 @@ const (
 	OpInsertWholeLine                   // Inserted an entire line (O or paste)
 	OpDeleteMultipleLines               // Deleted multiple lines (line-select d)
 	OpInsertMultipleLines               // Inserted multiple lines (multi-line paste)
+	OpReplaceLines                      // Replaced a range of lines (table align, reformat)
+	OpInsertText                        // Inserted text that may span lines (character-wise paste)
+	OpDeleteText                        // Deleted text that may span lines (visual d)
+	OpGroup                             // Several operations undone as one (change operator)
 )
 
 // UndoOp represents a single undoable operation or a coalesced group.
 type UndoOp struct {
-	Type    OpType
-	Line    int
-	Col     int
-	Char    rune     // For single char ops.
-	Text    string   // For coalesced inserts.
-	Lines   []string // For multi-line operations.
-	EndLine int      // For range operations.
+	Type     OpType   `json:"type"`
+	Line     int      `json:"line"`
+	Col      int      `json:"col,omitempty"`
+	Char     rune     `json:"char,omitempty"`      // For single char ops.
+	Text     string   `json:"text,omitempty"`      // For coalesced inserts.
+	Lines    []string `json:"lines,omitempty"`     // For multi-line operations.
+	NewLines []string `json:"new_lines,omitempty"` // Replacement lines for OpReplaceLines.
+	EndLine  int      `json:"end_line,omitempty"`  // For range operations.
+	Ops      []UndoOp `json:"ops,omitempty"`       // Grouped operations for OpGroup, in order.
 	// Cursor position to restore after undo.
-	CursorLine int
-	CursorCol  int
+	CursorLine int `json:"cursor_line"`
+	CursorCol  int `json:"cursor_col"`
 }
 
 // UndoStack manages the undo history with coalescing of consecutive inserts.
+// History is kept as a tree: undoing and then making a new edit starts a new
+// branch rather than discarding the undone changes, and JumpTo can move to
+// any state in the tree.
 type UndoStack struct {
-	ops      []UndoOp
-	redoOps  []UndoOp
+	nodes    []undoNode // nodes[0] is the root: the buffer before any edits
+	cur      int        // Node whose state the buffer is in
 	coalesce *coalesceState
+	group    *undoGroup // Set between BeginGroup and EndGroup
+}
+
+// undoGroup marks where a group of operations began.
+type undoGroup struct {
+	base  int // Node current when the group began
+	first int // Index the group's first node will have
+}
+
+// undoNode is one edit in the history tree. Nodes are appended in the order
+// the edits were made, so a node's index is also its sequence number.
+type undoNode struct {
+	op     UndoOp
+	parent int
+	next   int // Child that redo follows (the most recently visited), or -1
+	time   time.Time
 }
 
 type coalesceState struct {
@@ -45,18 +78,19 @@ type coalesceState struct {
 }
 
 func NewUndoStack() *UndoStack {
-	return &UndoStack{}
+	return &UndoStack{nodes: []undoNode{{parent: -1, next: -1}}}
 }
 
-// clearRedo clears the redo stack when a new operation is performed.
-func (u *UndoStack) clearRedo() {
-	u.redoOps = nil
+// push records op as a child of the current node and makes it current.
+func (u *UndoStack) push(op UndoOp) {
+	u.nodes = append(u.nodes, undoNode{op: op, parent: u.cur, next: -1, time: time.Now()})
+	u.cur = len(u.nodes) - 1
+	u.nodes[u.nodes[u.cur].parent].next = u.cur
 }
 
 // PushInsertChar records a character insertion, coalescing with the previous
 // insert if it's at an adjacent position on the same line.
 func (u *UndoStack) PushInsertChar(line, col int, ch rune) {
-	u.clearRedo()
 	if u.coalesce != nil {
 		c := u.coalesce
 		if line == c.line && col == c.nextCol {
@@ -78,9 +112,8 @@ func (u *UndoStack) PushInsertChar(line, col int, ch rune) {
 
 // PushDeleteChar records a character deletion.
 func (u *UndoStack) PushDeleteChar(line, col int, ch rune, cursorLine, cursorCol int) {
-	u.clearRedo()
 	u.flushCoalesce()
-	u.ops = append(u.ops, UndoOp{
+	u.push(UndoOp{
 		Type:       OpDeleteChar,
 		Line:       line,
 		Col:        col,
@@ -92,9 +125,8 @@ func (u *UndoStack) PushDeleteChar(line, col int, ch rune, cursorLine, cursorCol
 
 // PushInsertLine records a newline insertion (line split).
 func (u *UndoStack) PushInsertLine(line, col int, cursorLine, cursorCol int) {
-	u.clearRedo()
 	u.flushCoalesce()
-	u.ops = append(u.ops, UndoOp{
+	u.push(UndoOp{
 		Type:       OpInsertLine,
 		Line:       line,
 		Col:        col,
@@ -105,9 +137,8 @@ func (u *UndoStack) PushInsertLine(line, col int, cursorLine, cursorCol int) {
 
 // PushDeleteLine records a newline deletion (line join).
 func (u *UndoStack) PushDeleteLine(line, col int, cursorLine, cursorCol int) {
-	u.clearRedo()
 	u.flushCoalesce()
-	u.ops = append(u.ops, UndoOp{
+	u.push(UndoOp{
 		Type:       OpDeleteLine,
 		Line:       line,
 		Col:        col,
@@ -118,9 +149,8 @@ func (u *UndoStack) PushDeleteLine(line, col int, cursorLine, cursorCol int) {
 
 // PushDeleteWholeLine records a whole line deletion (dd operation).
 func (u *UndoStack) PushDeleteWholeLine(line int, content string, cursorLine, cursorCol int) {
-	u.clearRedo()
 	u.flushCoalesce()
-	u.ops = append(u.ops, UndoOp{
+	u.push(UndoOp{
 		Type:       OpDeleteWholeLine,
 		Line:       line,
 		Text:       content,
@@ -131,9 +161,8 @@ func (u *UndoStack) PushDeleteWholeLine(line int, content string, cursorLine, cu
 
 // PushInsertWholeLine records a whole line insertion (O operation or paste).
 func (u *UndoStack) PushInsertWholeLine(line int) {
-	u.clearRedo()
 	u.flushCoalesce()
-	u.ops = append(u.ops, UndoOp{
+	u.push(UndoOp{
 		Type:       OpInsertWholeLine,
 		Line:       line,
 		CursorLine: line,
@@ -143,9 +172,8 @@ func (u *UndoStack) PushInsertWholeLine(line int) {
 
 // PushDeleteMultipleLines records a multi-line deletion (line-select d).
 func (u *UndoStack) PushDeleteMultipleLines(startLine, endLine int, lines []string, cursorLine, cursorCol int) {
-	u.clearRedo()
 	u.flushCoalesce()
-	u.ops = append(u.ops, UndoOp{
+	u.push(UndoOp{
 		Type:       OpDeleteMultipleLines,
 		Line:       startLine,
 		EndLine:    endLine,
@@ -157,9 +185,8 @@ func (u *UndoStack) PushDeleteMultipleLines(startLine, endLine int, lines []stri
 
 // PushInsertMultipleLines records a multi-line insertion (multi-line paste).
 func (u *UndoStack) PushInsertMultipleLines(startLine int, lines []string, cursorLine, cursorCol int) {
-	u.clearRedo()
 	u.flushCoalesce()
-	u.ops = append(u.ops, UndoOp{
+	u.push(UndoOp{
 		Type:       OpInsertMultipleLines,
 		Line:       startLine,
 		Lines:      lines,
@@ -168,6 +195,99 @@ func (u *UndoStack) PushInsertMultipleLines(startLine int, lines []string, curso
 	})
 }
 
+// PushReplaceLines records the replacement of oldLines (starting at startLine)
+// with newLines. The two slices may differ in length.
+func (u *UndoStack) PushReplaceLines(startLine int, oldLines, newLines []string, cursorLine, cursorCol int) {
+	u.flushCoalesce()
+	u.push(UndoOp{
+		Type:       OpReplaceLines,
+		Line:       startLine,
+		Lines:      oldLines,
+		NewLines:   newLines,
+		CursorLine: cursorLine,
+		CursorCol:  cursorCol,
+	})
+}
+
+// PushInsertText records text inserted at (line, col); it may span lines.
+func (u *UndoStack) PushInsertText(line, col int, text string, cursorLine, cursorCol int) {
+	u.flushCoalesce()
+	u.push(UndoOp{
+		Type:       OpInsertText,
+		Line:       line,
+		Col:        col,
+		Text:       text,
+		CursorLine: cursorLine,
+		CursorCol:  cursorCol,
+	})
+}
+
+// PushDeleteText records text deleted from (line, col); it may span lines.
+func (u *UndoStack) PushDeleteText(line, col int, text string, cursorLine, cursorCol int) {
+	u.flushCoalesce()
+	u.push(UndoOp{
+		Type:       OpDeleteText,
+		Line:       line,
+		Col:        col,
+		Text:       text,
+		CursorLine: cursorLine,
+		CursorCol:  cursorCol,
+	})
+}
+
+// BeginGroup starts collecting operations so that EndGroup can merge them
+// into a single undo step.
+func (u *UndoStack) BeginGroup() {
+	u.EndGroup()
+	u.group = &undoGroup{base: u.cur, first: len(u.nodes)}
+}
+
+// EndGroup merges the operations made since BeginGroup into one node. If the
+// history was moved around in the meantime the operations are left as they
+// are. It does nothing when no group is open.
+func (u *UndoStack) EndGroup() {
+	g := u.group
+	if g == nil {
+		return
+	}
+	u.group = nil
+	u.flushCoalesce()
+
+	var ops []UndoOp
+	for n := u.cur; n != g.base; n = u.nodes[n].parent {
+		if n < g.first {
+			return
+		}
+		ops = append(ops, u.nodes[n].op)
+	}
+	if len(ops) < 2 || len(u.nodes) != g.first+len(ops) {
+		return
+	}
+	slices.Reverse(ops)
+	u.nodes = u.nodes[:g.first]
+	u.cur = g.base
+	u.push(UndoOp{
+		Type:       OpGroup,
+		Line:       ops[0].Line,
+		Ops:        ops,
+		CursorLine: ops[0].CursorLine,
+		CursorCol:  ops[0].CursorCol,
+	})
+}
+
+// replaceLines swaps count lines starting at start for the given replacement.
+func replaceLines(buf *Buffer, start, count int, replacement []string) {
+	end := start + count
+	if end > len(buf.Lines) {
+		end = len(buf.Lines)
+	}
+	buf.Lines = slices.Replace(buf.Lines, start, end, replacement...)
+	if len(buf.Lines) == 0 {
+		buf.Lines = []string{""}
+	}
+	buf.Dirty = true
+}
+
 // flushCoalesce converts the current coalescing state into an UndoOp.
 func (u *UndoStack) flushCoalesce() {
 	if u.coalesce == nil {
@@ -175,7 +295,7 @@ func (u *UndoStack) flushCoalesce() {
 	}
 	c := u.coalesce
 	if len(c.chars) == 1 {
-		u.ops = append(u.ops, UndoOp{
+		u.push(UndoOp{
 			Type:       OpInsertChar,
 			Line:       c.startLine,
 			Col:        c.startCol,
@@ -184,7 +304,7 @@ func (u *UndoStack) flushCoalesce() {
 			CursorCol:  c.startCol,
 		})
 	} else {
-		u.ops = append(u.ops, UndoOp{
+		u.push(UndoOp{
 			Type:       OpInsertChars,
 			Line:       c.startLine,
 			Col:        c.startCol,
@@ -196,19 +316,24 @@ func (u *UndoStack) flushCoalesce() {
 	u.coalesce = nil
 }
 
-// Undo pops the last operation and applies its inverse to the buffer.
-// Returns the cursor position to restore, and whether an undo occurred.
+// Undo applies the inverse of the current operation to the buffer and moves
+// to its parent. Returns the cursor position to restore, and whether an undo
+// occurred.
 func (u *UndoStack) Undo(buf *Buffer) (line, col int, ok bool) {
+	u.EndGroup()
 	u.flushCoalesce()
-	if len(u.ops) == 0 {
+	if u.cur == 0 {
 		return 0, 0, false
 	}
-	op := u.ops[len(u.ops)-1]
-	u.ops = u.ops[:len(u.ops)-1]
-
-	// Push to redo stack before applying inverse.
-	u.redoOps = append(u.redoOps, op)
+	node := u.nodes[u.cur]
+	// Redo should come back down this branch.
+	u.nodes[node.parent].next = u.cur
+	u.cur = node.parent
+	return revertOp(buf, node.op)
+}
 
+// revertOp applies the inverse of op to the buffer.
+func revertOp(buf *Buffer, op UndoOp) (line, col int, ok bool) {
 	switch op.Type {
 	case OpInsertChar:
 		// Undo insert: delete the character.
@@ -270,11 +395,7 @@ func (u *UndoStack) Undo(buf *Buffer) (line, col int, ok bool) {
 			copy(buf.Lines, op.Lines)
 		} else {
 			// Insert lines at op.Line position
-			newLines := make([]string, len(buf.Lines)+len(op.Lines))
-			copy(newLines, buf.Lines[:op.Line])
-			copy(newLines[op.Line:], op.Lines)
-			copy(newLines[op.Line+len(op.Lines):], buf.Lines[op.Line:])
-			buf.Lines = newLines
+			buf.Lines = slices.Insert(buf.Lines, op.Line, op.Lines...)
 		}
 		buf.Dirty = true
 		return op.CursorLine, op.CursorCol, true
@@ -290,23 +411,49 @@ func (u *UndoStack) Undo(buf *Buffer) (line, col int, ok bool) {
 		}
 		buf.Dirty = true
 		return op.CursorLine, op.CursorCol, true
+
+	case OpReplaceLines:
+		// Undo replacement: restore the original lines.
+		replaceLines(buf, op.Line, len(op.NewLines), op.Lines)
+		return op.CursorLine, op.CursorCol, true
+
+	case OpInsertText:
+		// Undo text insert: remove it again.
+		endLine, endCol := textEnd(op.Line, op.Col, op.Text)
+		buf.DeleteText(op.Line, op.Col, endLine, endCol)
+		return op.CursorLine, op.CursorCol, true
+
+	case OpDeleteText:
+		// Undo text delete: put it back.
+		buf.InsertText(op.Line, op.Col, op.Text)
+		return op.CursorLine, op.CursorCol, true
+
+	case OpGroup:
+		// Undo a group: undo its operations, last first.
+		for i := len(op.Ops) - 1; i >= 0; i-- {
+			revertOp(buf, op.Ops[i])
+		}
+		return op.CursorLine, op.CursorCol, true
 	}
 
 	return 0, 0, false
 }
 
-// Redo re-applies an operation from the redo stack.
+// Redo re-applies the most recently undone operation on the current branch.
 // Returns the cursor position to restore, and whether a redo occurred.
 func (u *UndoStack) Redo(buf *Buffer) (line, col int, ok bool) {
-	if len(u.redoOps) == 0 {
+	u.EndGroup()
+	u.flushCoalesce()
+	next := u.nodes[u.cur].next
+	if next < 0 {
 		return 0, 0, false
 	}
-	op := u.redoOps[len(u.redoOps)-1]
-	u.redoOps = u.redoOps[:len(u.redoOps)-1]
-
-	// Push back to ops stack.
-	u.ops = append(u.ops, op)
+	u.cur = next
+	return applyOp(buf, u.nodes[next].op)
+}
 
+// applyOp re-applies op to the buffer.
+func applyOp(buf *Buffer, op UndoOp) (line, col int, ok bool) {
 	switch op.Type {
 	case OpInsertChar:
 		// Redo insert: re-insert the character.
@@ -366,23 +513,202 @@ func (u *UndoStack) Redo(buf *Buffer) (line, col int, ok bool) {
 
 	case OpInsertMultipleLines:
 		// Redo multi-line insert: re-insert all lines.
-		newLines := make([]string, len(buf.Lines)+len(op.Lines))
-		copy(newLines, buf.Lines[:op.Line])
-		copy(newLines[op.Line:], op.Lines)
-		copy(newLines[op.Line+len(op.Lines):], buf.Lines[op.Line:])
-		buf.Lines = newLines
+		buf.Lines = slices.Insert(buf.Lines, op.Line, op.Lines...)
 		buf.Dirty = true
 		return op.Line + len(op.Lines), 0, true
+
+	case OpReplaceLines:
+		// Redo replacement: swap the new lines back in.
+		replaceLines(buf, op.Line, len(op.Lines), op.NewLines)
+		return op.CursorLine, op.CursorCol, true
+
+	case OpInsertText:
+		// Redo text insert: insert it again.
+		endLine, endCol := buf.InsertText(op.Line, op.Col, op.Text)
+		return endLine, max(endCol-1, 0), true
+
+	case OpDeleteText:
+		// Redo text delete: remove it again.
+		endLine, endCol := textEnd(op.Line, op.Col, op.Text)
+		buf.DeleteText(op.Line, op.Col, endLine, endCol)
+		return op.Line, op.Col, true
+
+	case OpGroup:
+		// Redo a group: redo its operations in order.
+		for _, sub := range op.Ops {
+			line, col, ok = applyOp(buf, sub)
+		}
+		return line, col, ok
 	}
 
 	return 0, 0, false
 }
 
+// fits reports whether op can be redone on lines, or undone from them,
+// without reaching outside them. The Buffer methods some operations use
+// check their own positions; the rest are checked here.
+func (op UndoOp) fits(lines []string, redo bool) bool {
+	inLine := op.Line >= 0 && op.Line < len(lines)
+	switch op.Type {
+	case OpInsertChar, OpDeleteChar:
+		return inLine && op.Col >= 0
+	case OpInsertChars:
+		return inLine && op.Col >= 0 && op.Col <= utf8.RuneCountInString(lines[op.Line])
+	case OpDeleteMultipleLines:
+		if redo {
+			return op.Line >= 0 && op.Line <= op.EndLine && op.EndLine < len(lines)
+		}
+		return op.Line >= 0 && op.Line <= len(lines)
+	case OpInsertMultipleLines:
+		if redo {
+			return op.Line >= 0 && op.Line <= len(lines)
+		}
+		return len(op.Lines) > 0 && op.Line >= 0 && op.Line+len(op.Lines) <= len(lines)
+	case OpReplaceLines:
+		return op.Line >= 0 && op.Line <= len(lines)
+	case OpGroup:
+		return len(op.Ops) > 0
+	}
+	return true
+}
+
 // Len returns the number of pending undo operations.
 func (u *UndoStack) Len() int {
-	n := len(u.ops)
+	n := 0
+	for i := u.cur; i != 0; i = u.nodes[i].parent {
+		n++
+	}
 	if u.coalesce != nil {
 		n++
 	}
 	return n
 }
+
+// Current returns the node the buffer is currently at. Node 0 is the
+// original text.
+func (u *UndoStack) Current() int {
+	u.flushCoalesce()
+	return u.cur
+}
+
+// JumpTo moves the buffer to the state after node target, undoing back to
+// the nearest common ancestor and redoing down the target's branch. Returns
+// the cursor position to restore, and whether the state changed.
+func (u *UndoStack) JumpTo(buf *Buffer, target int) (line, col int, ok bool) {
+	u.flushCoalesce()
+	if target < 0 || target >= len(u.nodes) || target == u.cur {
+		return 0, 0, false
+	}
+
+	// Collect target's ancestors (including itself), then undo until the
+	// current node is one of them.
+	onPath := make(map[int]bool)
+	for i := target; i >= 0; i = u.nodes[i].parent {
+		onPath[i] = true
+	}
+	for !onPath[u.cur] {
+		line, col, _ = u.Undo(buf)
+	}
+
+	// Redo down from the common ancestor to the target.
+	var path []int
+	for i := target; i != u.cur; i = u.nodes[i].parent {
+		path = append(path, i)
+	}
+	for k := len(path) - 1; k >= 0; k-- {
+		u.nodes[u.nodes[path[k]].parent].next = path[k]
+		line, col, _ = u.Redo(buf)
+	}
+	return line, col, true
+}
+
+// UndoTreeEntry describes one state in the undo tree, for display.
+type UndoTreeEntry struct {
+	Node    int // Sequence number; 0 is the original text
+	Depth   int // Branch nesting: alternate branches are indented one level
+	Label   string
+	Time    time.Time
+	Current bool
+}
+
+// Tree lists every state in the history. A chain of edits stays at one
+// depth; when a state has several children, the later branches are listed
+// straight after it, one level deeper, before the original line continues.
+func (u *UndoStack) Tree() []UndoTreeEntry {
+	u.flushCoalesce()
+	children := make([][]int, len(u.nodes))
+	for i := 1; i < len(u.nodes); i++ {
+		p := u.nodes[i].parent
+		children[p] = append(children[p], i)
+	}
+
+	var entries []UndoTreeEntry
+	var walk func(n, depth int)
+	walk = func(n, depth int) {
+		for {
+			label := "original"
+			if n != 0 {
+				label = u.nodes[n].op.describe()
+			}
+			entries = append(entries, UndoTreeEntry{
+				Node:    n,
+				Depth:   depth,
+				Label:   label,
+				Time:    u.nodes[n].time,
+				Current: n == u.cur,
+			})
+			kids := children[n]
+			if len(kids) == 0 {
+				return
+			}
+			for _, k := range kids[1:] {
+				walk(k, depth+1)
+			}
+			n = kids[0]
+		}
+	}
+	walk(0, 0)
+	return entries
+}
+
+// describe returns a short summary of an operation, e.g. `insert "the"`.
+func (op UndoOp) describe() string {
+	switch op.Type {
+	case OpInsertChar:
+		return fmt.Sprintf("insert %s", quoteSnippet(string(op.Char)))
+	case OpInsertChars:
+		return fmt.Sprintf("insert %s", quoteSnippet(op.Text))
+	case OpDeleteChar:
+		return fmt.Sprintf("delete %s", quoteSnippet(string(op.Char)))
+	case OpInsertLine:
+		return fmt.Sprintf("split line %d", op.Line+1)
+	case OpDeleteLine:
+		return fmt.Sprintf("join line %d", op.Line+1)
+	case OpDeleteWholeLine:
+		return fmt.Sprintf("delete line %d", op.Line+1)
+	case OpInsertWholeLine:
+		return fmt.Sprintf("insert line %d", op.Line+1)
+	case OpDeleteMultipleLines:
+		return fmt.Sprintf("delete lines %d-%d", op.Line+1, op.EndLine+1)
+	case OpInsertMultipleLines:
+		return fmt.Sprintf("insert %d lines at %d", len(op.Lines), op.Line+1)
+	case OpReplaceLines:
+		return fmt.Sprintf("change lines %d-%d", op.Line+1, op.Line+max(len(op.Lines), 1))
+	case OpInsertText:
+		return fmt.Sprintf("paste %s", quoteSnippet(op.Text))
+	case OpDeleteText:
+		return fmt.Sprintf("delete %s", quoteSnippet(op.Text))
+	case OpGroup:
+		return "change: " + op.Ops[len(op.Ops)-1].describe()
+	}
+	return "edit"
+}
+
+// quoteSnippet quotes text for an undo summary, shortening long inserts.
+func quoteSnippet(text string) string {
+	runes := []rune(text)
+	if len(runes) > 20 {
+		return strconv.Quote(string(runes[:20])) + "…"
+	}
+	return strconv.Quote(text)
+}
@@ -1,6 +1,9 @@
 package editor
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestUndoInsertChar(t *testing.T) {
 	buf := NewBuffer("")
@@ -451,3 +454,195 @@ func TestMultipleUndoRedo(t *testing.T) {
 		t.Errorf("after second redo: %q", buf.Lines[0])
 	}
 }
+
+func TestUndoRedoReplaceLines(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{"keep", "a", "b", "tail"}
+	undo := NewUndoStack()
+
+	old := []string{"a", "b"}
+	repl := []string{"x", "y", "z"}
+	undo.PushReplaceLines(1, old, repl, 1, 0)
+	replaceLines(buf, 1, len(old), repl)
+
+	if _, _, ok := undo.Undo(buf); !ok {
+		t.Fatal("undo should succeed")
+	}
+	if got := strings.Join(buf.Lines, ","); got != "keep,a,b,tail" {
+		t.Errorf("after undo: %q", got)
+	}
+
+	if _, _, ok := undo.Redo(buf); !ok {
+		t.Fatal("redo should succeed")
+	}
+	if got := strings.Join(buf.Lines, ","); got != "keep,x,y,z,tail" {
+		t.Errorf("after redo: %q", got)
+	}
+}
+
+func TestUndoRedoText(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{"one two", "three four"}
+	undo := NewUndoStack()
+
+	removed := buf.DeleteText(0, 4, 1, 6)
+	undo.PushDeleteText(0, 4, removed, 0, 4)
+	if got := strings.Join(buf.Lines, ","); got != "one four" {
+		t.Fatalf("after delete: %q", got)
+	}
+	if _, _, ok := undo.Undo(buf); !ok {
+		t.Fatal("undo should succeed")
+	}
+	if got := strings.Join(buf.Lines, ","); got != "one two,three four" {
+		t.Errorf("after undo: %q", got)
+	}
+	if line, col, _ := undo.Redo(buf); line != 0 || col != 4 {
+		t.Errorf("redo cursor = %d,%d, want 0,4", line, col)
+	}
+	if got := strings.Join(buf.Lines, ","); got != "one four" {
+		t.Errorf("after redo: %q", got)
+	}
+
+	buf.InsertText(0, 4, "two\nthree ")
+	undo.PushInsertText(0, 4, "two\nthree ", 0, 4)
+	undo.Undo(buf)
+	if got := strings.Join(buf.Lines, ","); got != "one four" {
+		t.Errorf("after undoing insert: %q", got)
+	}
+}
+
+func TestUndoThenEditKeepsBranch(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{"hello"}
+	undo := NewUndoStack()
+
+	buf.InsertChar(0, 5, '!')
+	undo.PushInsertChar(0, 5, '!')
+	undo.Undo(buf)
+
+	buf.InsertChar(0, 5, '?')
+	undo.PushInsertChar(0, 5, '?')
+	branch := undo.Current()
+
+	// The "!" branch survives: jump back to it.
+	if _, _, ok := undo.JumpTo(buf, 1); !ok {
+		t.Fatal("JumpTo(1) failed")
+	}
+	if buf.Lines[0] != "hello!" {
+		t.Errorf("after jump to first branch: %q", buf.Lines[0])
+	}
+
+	// And across to the "?" branch again.
+	undo.JumpTo(buf, branch)
+	if buf.Lines[0] != "hello?" {
+		t.Errorf("after jump to second branch: %q", buf.Lines[0])
+	}
+
+	// Back to the original text.
+	undo.JumpTo(buf, 0)
+	if buf.Lines[0] != "hello" {
+		t.Errorf("after jump to root: %q", buf.Lines[0])
+	}
+}
+
+func TestRedoFollowsLastVisitedBranch(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{""}
+	undo := NewUndoStack()
+
+	buf.InsertChar(0, 0, 'a')
+	undo.PushInsertChar(0, 0, 'a')
+	undo.Undo(buf)
+	buf.InsertChar(0, 0, 'b')
+	undo.PushInsertChar(0, 0, 'b')
+
+	// Return to the "a" branch, then undo and redo: redo should stay on it.
+	undo.JumpTo(buf, 1)
+	undo.Undo(buf)
+	undo.Redo(buf)
+	if buf.Lines[0] != "a" {
+		t.Errorf("redo after jump: %q, want %q", buf.Lines[0], "a")
+	}
+}
+
+func TestUndoTreeEntries(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{""}
+	undo := NewUndoStack()
+
+	for _, r := range "ab" {
+		col := len(buf.Lines[0])
+		buf.InsertChar(0, col, r)
+		undo.PushInsertChar(0, col, r)
+	}
+	undo.flushCoalesce() // Node 1: "ab"
+	buf.InsertNewline(0, 2)
+	undo.PushInsertLine(0, 2, 0, 2) // Node 2
+	undo.Undo(buf)
+	buf.DeleteLine(0)
+	undo.PushDeleteWholeLine(0, "ab", 0, 0) // Node 3, a branch from node 1
+
+	got := undo.Tree()
+	want := []UndoTreeEntry{
+		{Node: 0, Depth: 0, Label: "original"},
+		{Node: 1, Depth: 0, Label: `insert "ab"`},
+		{Node: 3, Depth: 1, Label: "delete line 1", Current: true},
+		{Node: 2, Depth: 0, Label: "split line 1"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Tree() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		got[i].Time = want[i].Time
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUndoOpDescribeTruncates(t *testing.T) {
+	op := UndoOp{Type: OpInsertChars, Text: "a very long run of typed text"}
+	if got := op.describe(); got != `insert "a very long run of t"…` {
+		t.Errorf("describe = %q", got)
+	}
+}
+
+func TestUndoGroup(t *testing.T) {
+	buf := NewBuffer("")
+	buf.Lines = []string{"one two"}
+	undo := NewUndoStack()
+
+	undo.BeginGroup()
+	undo.PushDeleteText(0, 4, "two", 0, 4)
+	buf.DeleteText(0, 4, 0, 7)
+	for i, ch := range "2!" {
+		buf.InsertChar(0, 4+i, ch)
+		undo.PushInsertChar(0, 4+i, ch)
+	}
+	undo.EndGroup()
+
+	if undo.Len() != 1 {
+		t.Fatalf("group should be one undo step, got %d", undo.Len())
+	}
+	if _, _, ok := undo.Undo(buf); !ok {
+		t.Fatal("undo should succeed")
+	}
+	if buf.Lines[0] != "one two" {
+		t.Errorf("after undo: %q", buf.Lines[0])
+	}
+	if _, _, ok := undo.Redo(buf); !ok {
+		t.Fatal("redo should succeed")
+	}
+	if buf.Lines[0] != "one 2!" {
+		t.Errorf("after redo: %q", buf.Lines[0])
+	}
+}
+
+func TestUndoGroupEmpty(t *testing.T) {
+	undo := NewUndoStack()
+	undo.BeginGroup()
+	undo.EndGroup()
+	if undo.Len() != 0 {
+		t.Errorf("empty group should record nothing, got %d", undo.Len())
+	}
+}
@@ -0,0 +1,213 @@
+package editor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// undoFileVersion is bumped whenever the history format changes; files with
+// another version are ignored.
+const undoFileVersion = 1
+
+// undoHistory is the on-disk form of a buffer's undo tree, saved alongside
+// each write so that reopening the file restores its history.
+type undoHistory struct {
+	Version int            `json:"version"`
+	Hash    string         `json:"hash"` // SHA-256 of the file content the history ends at
+	Current int            `json:"current"`
+	Nodes   []undoFileNode `json:"nodes"`
+}
+
+type undoFileNode struct {
+	Op     UndoOp    `json:"op"`
+	Parent int       `json:"parent"`
+	Next   int       `json:"next"`
+	Time   time.Time `json:"time"`
+}
+
+// undoDir returns the directory holding saved undo histories.
+func undoDir() string {
+	return filepath.Join(stateDir(), "undo")
+}
+
+// undoPath returns the history file for a document.
+func undoPath(filename string) string {
+	return filepath.Join(undoDir(), pathKey(filename)+".json")
+}
+
+// contentHash returns the hash of lines as they are written to disk.
+func contentHash(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n") + "\n"))
+	return hex.EncodeToString(sum[:])
+}
+
+// history returns the undo tree in its on-disk form. A change still being
+// made, such as typing not yet coalesced or a group not yet ended, is saved
+// as if it were finished but stays open in u, so saving in the middle of a
+// change doesn't split it.
+func (u *UndoStack) history(lines []string) undoHistory {
+	snap := &UndoStack{nodes: slices.Clone(u.nodes), cur: u.cur, coalesce: u.coalesce, group: u.group}
+	snap.EndGroup()
+	snap.flushCoalesce()
+	h := undoHistory{
+		Version: undoFileVersion,
+		Hash:    contentHash(lines),
+		Current: snap.cur,
+		Nodes:   make([]undoFileNode, len(snap.nodes)),
+	}
+	for i, n := range snap.nodes {
+		h.Nodes[i] = undoFileNode{Op: n.op, Parent: n.parent, Next: n.next, Time: n.time}
+	}
+	return h
+}
+
+// restoreHistory replaces the undo tree with a saved one. The history must
+// end at the given content and be a well-formed tree whose every operation
+// replays on the text it meets; otherwise the stack is left alone and an
+// error is returned.
+func (u *UndoStack) restoreHistory(h undoHistory, lines []string) error {
+	if h.Version != undoFileVersion {
+		return errors.New("unknown undo history version")
+	}
+	if h.Hash != contentHash(lines) {
+		return errors.New("file changed since undo history was saved")
+	}
+	if len(h.Nodes) == 0 || h.Nodes[0].Parent != -1 || h.Current < 0 || h.Current >= len(h.Nodes) {
+		return errors.New("malformed undo history")
+	}
+	for i, n := range h.Nodes {
+		if i > 0 && (n.Parent < 0 || n.Parent >= i) {
+			return errors.New("malformed undo history")
+		}
+		if n.Next != -1 && (n.Next <= i || n.Next >= len(h.Nodes) || h.Nodes[n.Next].Parent != i) {
+			return errors.New("malformed undo history")
+		}
+		if i > 0 && (n.Op.Type < OpInsertChar || n.Op.Type > OpGroup) {
+			return errors.New("malformed undo history")
+		}
+	}
+	if err := replayHistory(h, lines); err != nil {
+		return err
+	}
+
+	nodes := make([]undoNode, len(h.Nodes))
+	for i, n := range h.Nodes {
+		nodes[i] = undoNode{op: n.Op, parent: n.Parent, next: n.Next, time: n.Time}
+	}
+	u.nodes = nodes
+	u.cur = h.Current
+	u.coalesce = nil
+	return nil
+}
+
+// replayHistory checks that every operation in h can be undone and redone
+// on the text it would meet, by replaying the whole tree on a copy of lines:
+// back from the current node to the root, then down every branch and back.
+func replayHistory(h undoHistory, lines []string) error {
+	buf := &Buffer{Lines: slices.Clone(lines)}
+	for n := h.Current; n != 0; n = h.Nodes[n].Parent {
+		if err := replayOp(buf, h.Nodes[n].Op, false); err != nil {
+			return err
+		}
+	}
+	children := make([][]int, len(h.Nodes))
+	for i := 1; i < len(h.Nodes); i++ {
+		children[h.Nodes[i].Parent] = append(children[h.Nodes[i].Parent], i)
+	}
+	var visit func(n int) error
+	visit = func(n int) error {
+		for _, c := range children[n] {
+			if err := replayOp(buf, h.Nodes[c].Op, true); err != nil {
+				return err
+			}
+			if err := visit(c); err != nil {
+				return err
+			}
+			if err := replayOp(buf, h.Nodes[c].Op, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(0)
+}
+
+// replayOp redoes op on buf, or undoes it, if its positions fit buf's
+// lines. A group's operations are checked one by one as they are replayed.
+func replayOp(buf *Buffer, op UndoOp, redo bool) error {
+	if !op.fits(buf.Lines, redo) {
+		return errors.New("malformed undo history")
+	}
+	if op.Type == OpGroup {
+		for i := range op.Ops {
+			sub := op.Ops[i]
+			if !redo {
+				sub = op.Ops[len(op.Ops)-1-i]
+			}
+			if err := replayOp(buf, sub, redo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if redo {
+		applyOp(buf, op)
+	} else {
+		revertOp(buf, op)
+	}
+	return nil
+}
+
+// Save writes the buffer to disk, then records its undo history so it can be
+// restored next time the file is opened. Failing to record the history does
+// not fail the save.
+func (eb *EditorBuffer) Save(filename string) error {
+	if err := eb.buf.Save(filename); err != nil {
+		return err
+	}
+	if eb.buf.Filename != "" && !eb.isScratch {
+		saveUndoHistory(eb.buf.Filename, eb.undo.history(eb.buf.Lines))
+	}
+	return nil
+}
+
+// saveUndoHistory writes a document's undo history file.
+func saveUndoHistory(filename string, h undoHistory) error {
+	if err := os.MkdirAll(undoDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(undoPath(filename), data, 0600)
+}
+
+// loadUndoHistory restores the buffer's undo history from its last save, if
+// the file hasn't changed since. Returns whether history was restored.
+func (eb *EditorBuffer) loadUndoHistory() bool {
+	if eb.buf.Filename == "" || eb.isScratch {
+		return false
+	}
+	data, err := os.ReadFile(undoPath(eb.buf.Filename))
+	if err != nil {
+		return false
+	}
+	var h undoHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return false
+	}
+	return eb.undo.restoreHistory(h, eb.buf.Lines) == nil
+}
+
+// moveUndoHistory follows a rename, so the history stays with the file.
+func moveUndoHistory(oldName, newName string) {
+	os.Rename(undoPath(oldName), undoPath(newName))
+}
@@ -0,0 +1,197 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// editAndSave opens path, types text at the start of the first line and saves.
+func editAndSave(t *testing.T, path, text string) *App {
+	t.Helper()
+	a := newTestApp(path)
+	eb := a.currentBuf()
+	if err := eb.buf.Load(); err != nil {
+		t.Fatal(err)
+	}
+	eb.loadUndoHistory()
+	for _, r := range text {
+		a.insertChar(r)
+	}
+	if err := eb.Save(""); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// reopen loads path into a fresh buffer, restoring undo history.
+func reopen(t *testing.T, path string) (*EditorBuffer, bool) {
+	t.Helper()
+	eb := NewEditorBuffer(path)
+	if err := eb.buf.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return eb, eb.loadUndoHistory()
+}
+
+func TestUndoHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "draft.md")
+	os.WriteFile(path, []byte("world\n"), 0644)
+
+	editAndSave(t, path, "hello ")
+
+	eb, ok := reopen(t, path)
+	if !ok {
+		t.Fatal("expected undo history to be restored")
+	}
+	if _, _, ok := eb.undo.Undo(eb.buf); !ok {
+		t.Fatal("undo after reopen failed")
+	}
+	if eb.buf.Lines[0] != "world" {
+		t.Errorf("after undo: %q, want %q", eb.buf.Lines[0], "world")
+	}
+	eb.undo.Redo(eb.buf)
+	if eb.buf.Lines[0] != "hello world" {
+		t.Errorf("after redo: %q", eb.buf.Lines[0])
+	}
+}
+
+func TestUndoHistoryKeepsBranches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "draft.md")
+	os.WriteFile(path, []byte("\n"), 0644)
+
+	a := newTestApp(path)
+	eb := a.currentBuf()
+	eb.buf.Load()
+	a.insertChar('a')
+	a.undoAction()
+	a.insertChar('b')
+	eb.Save("")
+
+	reopened, ok := reopen(t, path)
+	if !ok {
+		t.Fatal("expected undo history to be restored")
+	}
+	reopened.undo.JumpTo(reopened.buf, 1)
+	if reopened.buf.Lines[0] != "a" {
+		t.Errorf("alternate branch after reopen: %q, want %q", reopened.buf.Lines[0], "a")
+	}
+}
+
+func TestUndoHistoryIgnoredWhenFileChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "draft.md")
+	os.WriteFile(path, []byte("world\n"), 0644)
+	editAndSave(t, path, "hello ")
+
+	// Another program edits the file.
+	os.WriteFile(path, []byte("hello world!\n"), 0644)
+
+	eb, ok := reopen(t, path)
+	if ok {
+		t.Error("history for different content should be ignored")
+	}
+	if eb.undo.Len() != 0 {
+		t.Errorf("undo stack should be empty, has %d ops", eb.undo.Len())
+	}
+}
+
+func TestRestoreHistoryRejectsMalformed(t *testing.T) {
+	lines := []string{"x"}
+	valid := func() undoHistory {
+		return undoHistory{
+			Version: undoFileVersion,
+			Hash:    contentHash(lines),
+			Current: 1,
+			Nodes: []undoFileNode{
+				{Parent: -1, Next: 1},
+				{Op: UndoOp{Type: OpInsertChar, Char: 'x'}, Parent: 0, Next: -1},
+			},
+		}
+	}
+	if err := NewUndoStack().restoreHistory(valid(), lines); err != nil {
+		t.Fatalf("valid history rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(h *undoHistory)
+	}{
+		{"version", func(h *undoHistory) { h.Version = 99 }},
+		{"hash", func(h *undoHistory) { h.Hash = "0" }},
+		{"current out of range", func(h *undoHistory) { h.Current = 5 }},
+		{"parent after child", func(h *undoHistory) { h.Nodes[1].Parent = 1 }},
+		{"next not a child", func(h *undoHistory) { h.Nodes[0].Next = 0 }},
+		{"unknown op", func(h *undoHistory) { h.Nodes[1].Op.Type = 99 }},
+		{"no root", func(h *undoHistory) { h.Nodes = nil }},
+		{"empty group", func(h *undoHistory) { h.Nodes[1].Op = UndoOp{Type: OpGroup} }},
+		{"line out of range", func(h *undoHistory) { h.Nodes[1].Op.Line = 3 }},
+		{"lines past the end", func(h *undoHistory) {
+			h.Nodes[1].Op = UndoOp{Type: OpInsertMultipleLines, Lines: []string{"a", "b"}}
+		}},
+		{"bad op in a group", func(h *undoHistory) {
+			h.Nodes[1].Op = UndoOp{Type: OpGroup, Ops: []UndoOp{{Type: OpInsertChars, Col: 5, Text: "ab"}}}
+		}},
+		{"bad op on another branch", func(h *undoHistory) {
+			h.Nodes = append(h.Nodes, undoFileNode{Op: UndoOp{Type: OpDeleteMultipleLines, EndLine: 4}, Parent: 0, Next: -1})
+		}},
+	}
+	for _, tt := range tests {
+		h := valid()
+		tt.mutate(&h)
+		u := NewUndoStack()
+		if err := u.restoreHistory(h, lines); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+		if u.Len() != 0 {
+			t.Errorf("%s: stack changed despite the error", tt.name)
+		}
+	}
+}
+
+func TestSaveKeepsUndoGroupOpen(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "draft.md")
+	os.WriteFile(path, []byte("world\n"), 0644)
+
+	a := newTestApp(path)
+	eb := a.currentBuf()
+	eb.buf.Load()
+	eb.undo.BeginGroup()
+	a.insertChar('a')
+	a.insertNewline()
+	if err := eb.Save(""); err != nil {
+		t.Fatal(err)
+	}
+	a.insertChar('b')
+	eb.undo.EndGroup()
+
+	// The change, saved halfway, is still undone in one step.
+	eb.undo.Undo(eb.buf)
+	if len(eb.buf.Lines) != 1 || eb.buf.Lines[0] != "world" {
+		t.Errorf("undo should take back the whole change, got %q", eb.buf.Lines)
+	}
+
+	// What was saved is the change so far, as one step.
+	reopened, ok := reopen(t, path)
+	if !ok {
+		t.Fatal("expected undo history to be restored")
+	}
+	reopened.undo.Undo(reopened.buf)
+	if len(reopened.buf.Lines) != 1 || reopened.buf.Lines[0] != "world" || reopened.undo.Len() != 0 {
+		t.Errorf("the saved history should undo in one step, got %q", reopened.buf.Lines)
+	}
+}
+
+func TestRenameMovesUndoHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "old.md")
+	os.WriteFile(path, []byte("text\n"), 0644)
+	a := editAndSave(t, path, "more ")
+
+	newPath := filepath.Join(dir, "new.md")
+	a.executeCommand("rename " + newPath)
+
+	if _, ok := reopen(t, newPath); !ok {
+		t.Error("undo history should follow the renamed file")
+	}
+}
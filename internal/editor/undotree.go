@@ -0,0 +1,101 @@
+package editor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// UndoTreeView manages the :undotree overlay, which lists every state in the
+// current buffer's undo history.
+type UndoTreeView struct {
+	ListView[UndoTreeEntry]
+}
+
+// Show activates the overlay with the current state selected.
+func (v *UndoTreeView) Show(items []UndoTreeEntry) {
+	v.ListView.Show(items)
+	for i, item := range items {
+		if item.Current {
+			v.Selected = i
+		}
+	}
+}
+
+// undoTreeLabel formats an entry for the overlay, e.g. `● 12 insert "the"  3m ago`.
+func undoTreeLabel(e UndoTreeEntry, now time.Time) string {
+	marker := "○"
+	if e.Current {
+		marker = "●"
+	}
+	indent := ""
+	for range e.Depth {
+		indent += "  "
+	}
+	text := fmt.Sprintf("%s%s %d %s", indent, marker, e.Node, e.Label)
+	if !e.Time.IsZero() {
+		text += "  " + formatAge(now.Sub(e.Time))
+	}
+	return text
+}
+
+// formatAge returns a compact description of how long ago something was.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < 5*time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+// showUndoTree opens the undo tree overlay for the current buffer.
+func (a *App) showUndoTree() {
+	a.undoTree.Show(a.currentBuf().undo.Tree())
+}
+
+// jumpToUndoState restores the current buffer to the selected state.
+func (a *App) jumpToUndoState() {
+	if a.undoTree.Selected < 0 || a.undoTree.Selected >= len(a.undoTree.Items) {
+		return
+	}
+	if a.guardReadOnly() {
+		return
+	}
+	node := a.undoTree.Items[a.undoTree.Selected].Node
+	eb := a.currentBuf()
+	if line, col, ok := eb.undo.JumpTo(eb.buf, node); ok {
+		eb.cursorLine = line
+		eb.cursorCol = col
+		eb.clampCursor()
+		eb.ScheduleSpellCheck()
+		a.statusBar.SetMessage(fmt.Sprintf("Undo state %d", node))
+	}
+}
+
+func (a *App) handleUndoTreeKey(key terminal.Key) {
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.undoTree.Hide()
+	case terminal.KeyUp:
+		a.undoTree.MoveUp()
+	case terminal.KeyDown:
+		a.undoTree.MoveDown()
+	case terminal.KeyRune:
+		switch key.Rune {
+		case 'k':
+			a.undoTree.MoveUp()
+		case 'j':
+			a.undoTree.MoveDown()
+		}
+	case terminal.KeyEnter:
+		a.jumpToUndoState()
+		a.undoTree.Hide()
+	}
+}
@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestUndoTreeCommandRestoresBranch(t *testing.T) {
+	a := newTestApp("draft.md")
+	a.mode = ModeEdit
+	eb := a.currentBuf()
+
+	a.insertChar('x')
+	a.undoAction()
+	a.insertChar('y')
+	if eb.buf.Lines[0] != "y" {
+		t.Fatalf("setup: %q", eb.buf.Lines[0])
+	}
+
+	a.executeCommand("undotree")
+	if !a.undoTree.Active {
+		t.Fatal("undo tree should be open")
+	}
+	if got := a.undoTree.Items[a.undoTree.Selected]; !got.Current {
+		t.Errorf("selection should start on the current state, got %+v", got)
+	}
+
+	// Entries: original, x, y (branch). Select the "x" state.
+	for i, item := range a.undoTree.Items {
+		if item.Node == 1 {
+			a.undoTree.Selected = i
+		}
+	}
+	a.handleUndoTreeKey(terminal.Key{Type: terminal.KeyEnter})
+	if a.undoTree.Active {
+		t.Error("undo tree should close after Enter")
+	}
+	if eb.buf.Lines[0] != "x" {
+		t.Errorf("after jump: %q, want %q", eb.buf.Lines[0], "x")
+	}
+}
+
+func TestUndoTreeLabel(t *testing.T) {
+	now := time.Now()
+	e := UndoTreeEntry{Node: 4, Depth: 1, Label: `insert "hi"`, Time: now.Add(-3 * time.Minute), Current: true}
+	if got := undoTreeLabel(e, now); got != `  ● 4 insert "hi"  3m ago` {
+		t.Errorf("undoTreeLabel = %q", got)
+	}
+	root := UndoTreeEntry{Label: "original"}
+	if got := undoTreeLabel(root, now); !strings.HasPrefix(got, "○ 0 original") || strings.Contains(got, "ago") {
+		t.Errorf("root label = %q", got)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{time.Second, "just now"},
+		{42 * time.Second, "42s ago"},
+		{5 * time.Minute, "5m ago"},
+		{3 * time.Hour, "3h ago"},
+		{50 * time.Hour, "2d ago"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
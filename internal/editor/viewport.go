@@ -1,86 +1,189 @@
 package editor
 
+import "unicode/utf8"
+
 var DefaultColumnWidth = 60
 
+// Smallest window prose will lay out. Below this a "terminal too small"
+// screen is shown until the terminal is resized.
+const (
+	MinWidth  = 20
+	MinHeight = 5
+)
+
 // DisplayLine represents one visual line on screen, mapped back to its source.
 type DisplayLine struct {
-	BufferLine int    // Index into Buffer.Lines
-	Offset     int    // Rune offset within the buffer line where this display line starts
-	Text       string // The display text for this line
+	BufferLine int        // Index into Buffer.Lines
+	Offset     int        // Rune offset within the buffer line where this display line starts
+	Text       string     // The display text for this line
+	Block      BlockState // Multi-line context of the buffer line (code fences)
+	Folded     int        // Lines hidden under this heading when its section is folded
+	Conceal    []ConcealSpan
+	Styles     []StyleSpan
+}
+
+// StyleSpan applies extra styling to a run of a display line's text, on top
+// of syntax highlighting. Offsets are runes within DisplayLine.Text.
+type StyleSpan struct {
+	Start, End int
+	Code       string // ANSI code switching the style on
+	Reset      string // ANSI code switching it off again
+}
+
+// span clips the buffer-line rune range [start, end) to this display line,
+// returning offsets relative to its Text.
+func (dl DisplayLine) span(start, end int) (int, int, bool) {
+	lineEnd := dl.Offset + len([]rune(dl.Text))
+	if end <= dl.Offset || start >= lineEnd || start >= end {
+		return 0, 0, false
+	}
+	return max(start, dl.Offset) - dl.Offset, min(end, lineEnd) - dl.Offset, true
+}
+
+// ConcealSpan hides a run of a display line's text behind a shorter
+// replacement (such as a marker for an annotation). Offsets are runes within
+// DisplayLine.Text.
+type ConcealSpan struct {
+	Start, End  int
+	Replacement string // May contain ANSI codes; empty hides the text
+}
+
+// TextCol maps a screen column within the display line to a rune offset in
+// its Text, allowing for concealed spans and wide characters. A column on a
+// replacement maps to the start of the hidden text, and either column of a
+// wide character to the character.
+func (dl DisplayLine) TextCol(col int) int {
+	runes := []rune(dl.Text)
+	spans := dl.Conceal
+	i, shown := 0, 0
+	for {
+		if len(spans) > 0 && i >= spans[0].Start {
+			w := visibleLen(spans[0].Replacement)
+			if col < shown+w {
+				return spans[0].Start
+			}
+			shown += w
+			i = max(i, spans[0].End)
+			spans = spans[1:]
+			continue
+		}
+		if i >= len(runes) {
+			break
+		}
+		w := runeWidth(runes[i])
+		if col < shown+w {
+			return i
+		}
+		shown += w
+		i++
+	}
+	return i + col - shown
 }
 
 // WrapLine soft-wraps a single hard line into display lines at word boundaries.
-// maxWidth is the column width (typically DefaultColumnWidth).
+// maxWidth is the column width in terminal columns (typically
+// DefaultColumnWidth), so wide characters fill a line sooner.
 func WrapLine(line string, maxWidth int, bufferLine int) []DisplayLine {
+	return appendWrapped(nil, line, maxWidth, bufferLine, BlockState{})
+}
+
+// appendWrapped appends the display lines of a wrapped hard line to dst. It
+// walks the line's bytes and slices its Text from the line, so that wrapping
+// a long document doesn't allocate a copy of every line.
+func appendWrapped(dst []DisplayLine, line string, maxWidth, bufferLine int, block BlockState) []DisplayLine {
 	if maxWidth <= 0 {
 		maxWidth = DefaultColumnWidth
 	}
-	runes := []rune(line)
-	if len(runes) == 0 {
-		return []DisplayLine{{BufferLine: bufferLine, Offset: 0, Text: ""}}
+	if line == "" {
+		return append(dst, DisplayLine{BufferLine: bufferLine, Block: block})
 	}
 
-	var result []DisplayLine
-	offset := 0
-
-	for offset < len(runes) {
-		remaining := runes[offset:]
-		if len(remaining) <= maxWidth {
-			result = append(result, DisplayLine{
-				BufferLine: bufferLine,
-				Offset:     offset,
-				Text:       string(remaining),
-			})
-			break
-		}
-
-		// Find the last space within maxWidth characters.
-		breakAt := -1
-		for i := maxWidth; i > 0; i-- {
-			if remaining[i] == ' ' {
-				breakAt = i
+	offset, pos := 0, 0 // Start of the next display line, in runes and bytes
+	for pos < len(line) {
+		// Walk the runes that fit in maxWidth columns and the one after,
+		// noting where the fitting runes end and the last space after the
+		// first rune. A line always takes at least one rune, however wide.
+		n, w, b := 0, 0, pos
+		cut, cutAt, space, spaceAt := -1, 0, -1, 0
+		for b < len(line) {
+			r, size := utf8.DecodeRuneInString(line[b:])
+			if r == ' ' && n > 0 && w <= maxWidth {
+				space, spaceAt = b, n
+			}
+			rw := runeWidth(r)
+			if n > 0 && w+rw > maxWidth {
+				cut, cutAt = b, n
 				break
 			}
+			b += size
+			n++
+			w += rw
 		}
 
-		if breakAt <= 0 {
-			// No word boundary found — hard-break at maxWidth.
-			result = append(result, DisplayLine{
-				BufferLine: bufferLine,
-				Offset:     offset,
-				Text:       string(remaining[:maxWidth]),
-			})
-			offset += maxWidth
-		} else {
-			result = append(result, DisplayLine{
-				BufferLine: bufferLine,
-				Offset:     offset,
-				Text:       string(remaining[:breakAt]),
-			})
-			// Skip the space at the break point.
-			offset += breakAt + 1
+		dl := DisplayLine{BufferLine: bufferLine, Offset: offset, Block: block}
+		switch {
+		case cut < 0:
+			dl.Text = line[pos:]
+			pos = len(line)
+		case space >= 0:
+			// Break at the space and skip it.
+			dl.Text = line[pos:space]
+			offset += spaceAt + 1
+			pos = space + 1
+		default:
+			// No word boundary found — hard-break at maxWidth columns.
+			dl.Text = line[pos:cut]
+			offset += cutAt
+			pos = cut
 		}
+		dst = append(dst, dl)
 	}
-
-	return result
+	return dst
 }
 
-// WrapBuffer wraps all lines in the buffer into display lines.
+// WrapBuffer wraps all lines in the buffer into display lines, tagging each
+// with the block state of its buffer line.
 func WrapBuffer(buf *Buffer, maxWidth int) []DisplayLine {
-	var all []DisplayLine
+	states := ComputeBlockStates(buf.Lines)
+	all := make([]DisplayLine, 0, wrappedSize(buf.Lines, maxWidth))
 	for i, line := range buf.Lines {
-		all = append(all, WrapLine(line, maxWidth, i)...)
+		all = appendWrapped(all, line, maxWidth, i, states[i])
 	}
 	return all
 }
 
+// wrappedSize estimates how many display lines lines wrap to: a row per
+// line and another per column width of text, which is usually enough since
+// bytes overcount runes.
+func wrappedSize(lines []string, maxWidth int) int {
+	size := len(lines)
+	if maxWidth > 0 {
+		for _, line := range lines {
+			size += len(line) / maxWidth
+		}
+	}
+	return size
+}
+
 // Viewport manages the visible window into the display lines.
 type Viewport struct {
 	Width          int // Terminal width
 	Height         int // Terminal height (status bar uses 1 row, so visible = Height-1)
 	ColWidth       int // Text column width (capped at TargetColWidth or terminal width)
-	LeftMargin     int // Left margin for centring
+	LeftMargin     int // Left margin for centring; includes the gutter
 	TargetColWidth int // User-adjustable target column width
+	Gutter         int // Line-number gutter just left of the text column; 0 when off
+
+	// Page layout (configurable).
+	TopPadding    int // Blank rows above the text at the top of the document
+	BottomPadding int // Blank rows between the text and the status bar
+	FixedMargin   int // Left margin in columns; negative centres the text column
+
+	// Placement of a split pane on screen. A full-screen viewport has Top and
+	// Left of 0 and Pane unset.
+	Top  int  // Screen rows above the viewport
+	Left int  // Screen columns left of the viewport
+	Pane bool // Shares its rows with another pane
 }
 
 func NewViewport(termWidth, termHeight int) *Viewport {
@@ -88,6 +191,8 @@ func NewViewport(termWidth, termHeight int) *Viewport {
 		Width:          termWidth,
 		Height:         termHeight,
 		TargetColWidth: DefaultColumnWidth,
+		TopPadding:     1,
+		FixedMargin:    -1,
 	}
 	v.recalcLayout()
 	return v
@@ -98,15 +203,51 @@ func (v *Viewport) recalcLayout() {
 	if target <= 0 {
 		target = DefaultColumnWidth
 	}
-	if v.Width >= target {
+	// The gutter sits in the margin, narrowing the column only when the
+	// margin is too small to hold it.
+	if v.Width >= target+v.Gutter {
 		v.ColWidth = target
-		v.LeftMargin = (v.Width - target) / 2
+		v.LeftMargin = max((v.Width-target)/2, v.Gutter)
 	} else {
-		v.ColWidth = v.Width
-		v.LeftMargin = 0
+		v.ColWidth = max(v.Width-v.Gutter, 1)
+		v.LeftMargin = v.Width - v.ColWidth
+	}
+	// A fixed margin shifts the column off centre, as far as the width allows.
+	if v.FixedMargin >= 0 {
+		v.LeftMargin = max(min(v.FixedMargin, v.Width-v.ColWidth), v.Gutter)
+	}
+}
+
+// SetGutter sets the width of the line-number gutter, laying the text
+// column out again if it changed.
+func (v *Viewport) SetGutter(width int) {
+	if width != v.Gutter {
+		v.Gutter = width
+		v.recalcLayout()
 	}
 }
 
+// CopyLayout copies the user's page layout settings from another viewport.
+func (v *Viewport) CopyLayout(from *Viewport) {
+	v.TargetColWidth = from.TargetColWidth
+	v.TopPadding = from.TopPadding
+	v.BottomPadding = from.BottomPadding
+	v.FixedMargin = from.FixedMargin
+	v.recalcLayout()
+}
+
+// Padding returns the blank rows above and below the text. Top padding only
+// applies at the top of the document (scrollOffset == 0), giving breathing
+// room from terminal chrome. At least one text row is always left.
+func (v *Viewport) Padding(scrollOffset int) (top, bottom int) {
+	avail := v.Height - 1 // Rows above the status bar
+	bottom = max(0, min(v.BottomPadding, avail-1))
+	if scrollOffset == 0 {
+		top = max(0, min(v.TopPadding, avail-bottom-1))
+	}
+	return top, bottom
+}
+
 // Resize updates the viewport for new terminal dimensions.
 func (v *Viewport) Resize(termWidth, termHeight int) {
 	v.Width = termWidth
@@ -114,15 +255,24 @@ func (v *Viewport) Resize(termWidth, termHeight int) {
 	v.recalcLayout()
 }
 
-// VisibleLines returns the number of text lines visible (excluding status bar).
-// When at the top of the document (scrollOffset == 0), one line is reserved
-// for top padding, giving breathing room from terminal chrome.
+// TooSmall reports whether the viewport is below the minimum usable size.
+func (v *Viewport) TooSmall() bool {
+	return v.Width < MinWidth || v.Height < MinHeight
+}
+
+// OverlayMaxItems returns how many list rows an overlay may show: up to 20,
+// fewer on short terminals so the box and its borders stay on screen.
+func (v *Viewport) OverlayMaxItems() int {
+	n := min(20, v.Height-6)
+	n = max(n, 3)
+	return max(min(n, v.Height-3), 1)
+}
+
+// VisibleLines returns the number of text lines visible (excluding status bar
+// and padding).
 func (v *Viewport) VisibleLines(scrollOffset int) int {
-	vis := v.Height - 1
-	if scrollOffset == 0 && vis > 1 {
-		vis--
-	}
-	return vis
+	top, bottom := v.Padding(scrollOffset)
+	return v.Height - 1 - top - bottom
 }
 
 // EnsureEndOfFileVisible adjusts scrollOffset to show the end of the file
@@ -136,9 +286,9 @@ func (v *Viewport) EnsureEndOfFileVisible(totalDisplayLines int, cursorDisplayLi
 		return // Already visible.
 	}
 	// Scroll down to put lastDL at the bottom. Since we're scrolling down
-	// past the initial position, scrollOffset will be > 0, giving us
-	// the full Height-1 visible lines.
-	newVis := v.Height - 1
+	// past the initial position, scrollOffset will be > 0, so there is no
+	// top padding.
+	newVis := v.VisibleLines(1)
 	if newVis <= 0 {
 		return
 	}
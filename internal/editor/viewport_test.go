@@ -54,6 +54,27 @@ func TestWrapLineHardBreak(t *testing.T) {
 	}
 }
 
+func TestWrapLineMultibyte(t *testing.T) {
+	// Widths and offsets count runes, not bytes.
+	dls := WrapLine("café naïve ééééééé", 10, 0)
+	want := []struct {
+		text   string
+		offset int
+	}{{"café naïve", 0}, {"ééééééé", 11}}
+	if len(dls) != len(want) {
+		t.Fatalf("expected %d display lines, got %d: %v", len(want), len(dls), dls)
+	}
+	for i, w := range want {
+		if dls[i].Text != w.text || dls[i].Offset != w.offset {
+			t.Errorf("line %d: %q at %d, want %q at %d", i, dls[i].Text, dls[i].Offset, w.text, w.offset)
+		}
+	}
+	dls = WrapLine("ééééééééééé", 10, 0)
+	if len(dls) != 2 || dls[0].Text != "éééééééééé" || dls[1].Text != "é" || dls[1].Offset != 10 {
+		t.Errorf("hard break: %v", dls)
+	}
+}
+
 func TestWrapLineMultipleBreaks(t *testing.T) {
 	// 30 chars, maxWidth=10. "aaa bbb ccc ddd eee fff ggg"
 	line := "aaa bbb ccc ddd eee fff ggg"
@@ -341,3 +362,127 @@ func formatDLs(dls []DisplayLine) []string {
 	}
 	return out
 }
+
+func TestOverlayMaxItems(t *testing.T) {
+	tests := []struct {
+		height int
+		want   int
+	}{
+		{40, 20}, // Capped at 20
+		{24, 18},
+		{8, 3}, // Minimum of 3 when it fits
+		{5, 2}, // Never taller than the terminal
+		{2, 1},
+	}
+	for _, tt := range tests {
+		vp := NewViewport(80, tt.height)
+		if got := vp.OverlayMaxItems(); got != tt.want {
+			t.Errorf("OverlayMaxItems() at height %d = %d, want %d", tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestRelayoutKeepsTopLine(t *testing.T) {
+	a := newTestApp("doc.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = nil
+	for i := 0; i < 40; i++ {
+		eb.buf.Lines = append(eb.buf.Lines, "Fifty characters of text, which wraps below fifty.")
+	}
+	a.viewport = NewViewport(100, 20)
+	eb.scrollOffset = 10 // Buffer line 10 at the top, no wrapping at width 60
+
+	a.relayout(40, 20) // Narrower: every line now wraps to two display lines
+	dls := eb.DisplayLines(a.viewport.ColWidth)
+	if got := dls[eb.scrollOffset].BufferLine; got != 10 {
+		t.Errorf("top buffer line after resize = %d, want 10", got)
+	}
+
+	a.relayout(100, 20)
+	if eb.scrollOffset != 10 {
+		t.Errorf("scrollOffset after widening = %d, want 10", eb.scrollOffset)
+	}
+}
+
+func TestViewportTooSmall(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          bool
+	}{
+		{80, 24, false},
+		{MinWidth, MinHeight, false},
+		{MinWidth - 1, 24, true},
+		{80, MinHeight - 1, true},
+		{0, 0, true},
+	}
+	for _, tt := range tests {
+		if got := NewViewport(tt.width, tt.height).TooSmall(); got != tt.want {
+			t.Errorf("TooSmall() at %dx%d = %v, want %v", tt.width, tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestViewportFixedMarginClamped(t *testing.T) {
+	vp := NewViewport(70, 24)
+	vp.FixedMargin = 30
+	vp.recalcLayout()
+	if vp.LeftMargin != 10 {
+		t.Errorf("LeftMargin = %d, want 10 (clamped so the column fits)", vp.LeftMargin)
+	}
+}
+
+func TestViewportPaddingLeavesOneLine(t *testing.T) {
+	vp := NewViewport(80, 6)
+	vp.TopPadding = 10
+	vp.BottomPadding = 10
+	if got := vp.VisibleLines(0); got != 1 {
+		t.Errorf("VisibleLines(0) = %d, want 1", got)
+	}
+	top, bottom := vp.Padding(0)
+	if top+bottom+1 != vp.Height-1 {
+		t.Errorf("padding %d+%d leaves the wrong number of rows", top, bottom)
+	}
+}
+
+func TestWrapLineWideCharacters(t *testing.T) {
+	// Each CJK character takes two columns, so five fit in ten.
+	dls := WrapLine("一二三四五六七", 10, 0)
+	if len(dls) != 2 {
+		t.Fatalf("expected 2 display lines, got %d: %v", len(dls), dls)
+	}
+	if dls[0].Text != "一二三四五" || dls[1].Text != "六七" {
+		t.Errorf("lines: %q, %q", dls[0].Text, dls[1].Text)
+	}
+	if dls[1].Offset != 5 {
+		t.Errorf("line 1 offset: %d (expected 5)", dls[1].Offset)
+	}
+}
+
+func TestWrapLineWideCharacterAtEdge(t *testing.T) {
+	// A wide character that would straddle the edge moves to the next line.
+	dls := WrapLine("abc中", 4, 0)
+	if len(dls) != 2 || dls[0].Text != "abc" || dls[1].Text != "中" {
+		t.Fatalf("got %v", dls)
+	}
+}
+
+func TestWrapLineWideWordBreak(t *testing.T) {
+	dls := WrapLine("日本語 テキスト", 8, 0)
+	if len(dls) != 2 {
+		t.Fatalf("expected 2 display lines, got %d: %v", len(dls), dls)
+	}
+	if dls[0].Text != "日本語" || dls[1].Text != "テキスト" {
+		t.Errorf("lines: %q, %q", dls[0].Text, dls[1].Text)
+	}
+	if dls[1].Offset != 4 {
+		t.Errorf("line 1 offset: %d (expected 4)", dls[1].Offset)
+	}
+}
+
+func TestWrapLineWiderThanColumn(t *testing.T) {
+	// A character wider than the column still gets a line of its own.
+	dls := WrapLine("中文", 1, 0)
+	if len(dls) != 2 || dls[0].Text != "中" || dls[1].Text != "文" {
+		t.Fatalf("got %v", dls)
+	}
+}
@@ -0,0 +1,247 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// startVisual enters character-wise visual mode with the selection anchored
+// at the cursor.
+func (a *App) startVisual() {
+	eb := a.currentBuf()
+	a.mode = ModeVisual
+	a.visualAnchorLine = eb.cursorLine
+	a.visualAnchorCol = eb.cursorCol
+}
+
+// getVisualRange returns the character-wise selection in document order. The
+// end is exclusive; the character under the cursor (or anchor) is included.
+func (a *App) getVisualRange() (startLine, startCol, endLine, endCol int) {
+	eb := a.currentBuf()
+	startLine, startCol = a.visualAnchorLine, a.visualAnchorCol
+	endLine, endCol = eb.cursorLine, eb.cursorCol
+	if endLine < startLine || (endLine == startLine && endCol < startCol) {
+		startLine, startCol, endLine, endCol = endLine, endCol, startLine, startCol
+	}
+	startLine = min(startLine, eb.buf.LineCount()-1)
+	endLine = min(endLine, eb.buf.LineCount()-1)
+	startCol = min(startCol, eb.buf.LineLen(startLine))
+	endCol = min(endCol+1, eb.buf.LineLen(endLine))
+	return startLine, startCol, endLine, endCol
+}
+
+// selectedText returns the text of the character-wise selection, with line
+// breaks as "\n".
+func (a *App) selectedText() string {
+	eb := a.currentBuf()
+	sl, sc, el, ec := a.getVisualRange()
+	if sl == el {
+		return string([]rune(eb.buf.Lines[sl])[sc:max(sc, ec)])
+	}
+	parts := []string{string([]rune(eb.buf.Lines[sl])[sc:])}
+	parts = append(parts, eb.buf.Lines[sl+1:el]...)
+	parts = append(parts, string([]rune(eb.buf.Lines[el])[:ec]))
+	return strings.Join(parts, "\n")
+}
+
+// yankSelection copies the selection to the yank buffer.
+func (a *App) yankSelection() {
+	text := a.selectedText()
+	a.yankBuffer = text
+	a.yankCharwise = true
+	a.statusBar.SetMessage(fmt.Sprintf("Yanked %d character(s)", len([]rune(text))))
+}
+
+// deleteSelection cuts the selection to the yank buffer. With track changes
+// on, the text is marked as deleted instead.
+func (a *App) deleteSelection() {
+	eb := a.currentBuf()
+	sl, sc, el, ec := a.getVisualRange()
+	text := a.selectedText()
+	if text == "" {
+		return
+	}
+	a.yankBuffer = text
+	a.yankCharwise = true
+
+	if eb.trackChanges {
+		a.trackedDeleteRange(sl, sc, el, ec)
+	} else {
+		eb.undo.PushDeleteText(sl, sc, text, sl, sc)
+		eb.buf.DeleteText(sl, sc, el, ec)
+	}
+	eb.cursorLine = sl
+	eb.cursorCol = sc
+	eb.ScheduleSpellCheck()
+	a.statusBar.SetMessage(fmt.Sprintf("Deleted %d character(s)", len([]rune(text))))
+}
+
+// trackedDeleteRange marks the text from (sl, sc) to (el, ec) as deleted,
+// wrapping the selected part of each line in its own deletion mark.
+func (a *App) trackedDeleteRange(sl, sc, el, ec int) {
+	eb := a.currentBuf()
+	lines := make([]string, 0, el-sl+1)
+	for i := sl; i <= el; i++ {
+		runes := []rune(eb.buf.Lines[i])
+		start, end := 0, len(runes)
+		if i == sl {
+			start = sc
+		}
+		if i == el {
+			end = ec
+		}
+		if start >= end {
+			lines = append(lines, string(runes))
+			continue
+		}
+		lines = append(lines, string(runes[:start])+"{--"+string(runes[start:end])+"--}"+string(runes[end:]))
+	}
+	a.replaceLines(sl, len(lines), lines)
+}
+
+// sendSelectionToScratch appends the selection to the scratch buffer.
+func (a *App) sendSelectionToScratch() {
+	text := a.selectedText()
+	a.appendToScratch(text)
+	a.statusBar.SetMessage(fmt.Sprintf("Sent %d character(s) to scratch", len([]rune(text))))
+}
+
+// pasteText inserts a character-wise yank at the cursor, or just after the
+// character under it, leaving the cursor on the last pasted character.
+func (a *App) pasteText(after bool) {
+	eb := a.currentBuf()
+	col := eb.cursorCol
+	if after && col < eb.buf.LineLen(eb.cursorLine) {
+		col++
+	}
+	eb.undo.PushInsertText(eb.cursorLine, col, a.yankBuffer, eb.cursorLine, eb.cursorCol)
+	endLine, endCol := eb.buf.InsertText(eb.cursorLine, col, a.yankBuffer)
+	eb.cursorLine = endLine
+	eb.cursorCol = max(endCol-1, 0)
+	eb.ScheduleSpellCheck()
+}
+
+// styleSelection highlights the character-wise selection in the display
+// lines with the given style. The end column is exclusive.
+func styleSelection(dls []DisplayLine, selectionStyle StyleSpan, sl, sc, el, ec int) {
+	for i := range dls {
+		dl := &dls[i]
+		if dl.Folded > 0 || dl.BufferLine < sl || dl.BufferLine > el {
+			continue
+		}
+		start, end := 0, dl.Offset+len([]rune(dl.Text))
+		if dl.BufferLine == sl {
+			start = sc
+		}
+		if dl.BufferLine == el {
+			end = ec
+		}
+		if s, e, ok := dl.span(start, end); ok {
+			style := selectionStyle
+			style.Start, style.End = s, e
+			dl.Styles = append(dl.Styles, style)
+		}
+	}
+}
+
+func (a *App) handleVisualKey(key terminal.Key) {
+	eb := a.currentBuf()
+
+	// gg operator: 'g' followed by 'g'.
+	if a.gPending {
+		a.gPending = false
+		if key.Type == terminal.KeyRune && key.Rune == 'g' {
+			a.jumpToTop()
+		}
+		return
+	}
+
+	switch key.Type {
+	case terminal.KeyEscape:
+		a.mode = ModeDefault
+	case terminal.KeyRune:
+		if close, ok := a.surroundWith(key.Rune); ok {
+			if !a.guardReadOnly() {
+				a.surroundSelection(key.Rune, close)
+			}
+			a.mode = ModeDefault
+			return
+		}
+		switch key.Rune {
+		case 'h':
+			a.moveCursor(terminal.KeyLeft)
+		case 'j':
+			a.moveCursor(terminal.KeyDown)
+		case 'k':
+			a.moveCursor(terminal.KeyUp)
+		case 'l':
+			a.moveCursor(terminal.KeyRight)
+		case 'w':
+			a.jumpToNextWord()
+		case 'b':
+			a.jumpToPrevWord()
+		case '}':
+			a.jumpToNextParagraph()
+		case '{':
+			a.jumpToPrevParagraph()
+		case 'y':
+			a.yankSelection()
+			a.mode = ModeDefault
+		case 'd':
+			if !a.guardReadOnly() {
+				a.deleteSelection()
+			}
+			a.mode = ModeDefault
+		case 'c':
+			if a.guardReadOnly() {
+				a.mode = ModeDefault
+				return
+			}
+			a.changeSelection()
+		case 's':
+			a.sendSelectionToScratch()
+			a.mode = ModeDefault
+		case 'v':
+			a.mode = ModeDefault
+		case 'V':
+			a.mode = ModeLineSelect
+			a.lineSelectAnchor = a.visualAnchorLine
+		case 'g':
+			a.gPending = true
+		case 'G':
+			a.jumpToBottom()
+		case '^':
+			// Jump to first non-whitespace character.
+			runes := []rune(eb.buf.Lines[eb.cursorLine])
+			for i, r := range runes {
+				if r != ' ' && r != '\t' {
+					eb.cursorCol = i
+					return
+				}
+			}
+			eb.cursorCol = 0
+		case '$':
+			eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+		}
+	case terminal.KeyUp, terminal.KeyDown, terminal.KeyLeft, terminal.KeyRight:
+		a.moveCursor(key.Type)
+	case terminal.KeyHome:
+		eb.cursorCol = 0
+	case terminal.KeyEnd:
+		eb.cursorCol = eb.buf.LineLen(eb.cursorLine)
+	case terminal.KeyCtrlD:
+		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
+		a.scrollDown(visibleLines / 2)
+	case terminal.KeyCtrlU:
+		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
+		a.scrollUp(visibleLines / 2)
+	case terminal.KeyPgDn:
+		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
+		a.scrollDown(visibleLines)
+	case terminal.KeyPgUp:
+		visibleLines := a.viewport.VisibleLines(eb.scrollOffset)
+		a.scrollUp(visibleLines)
+	}
+}
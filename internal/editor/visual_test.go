@@ -0,0 +1,177 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+// visualApp returns a test app with the given lines, a selection anchored at
+// (anchorLine, anchorCol) and the cursor at (line, col).
+func visualApp(lines []string, anchorLine, anchorCol, line, col int) (*App, *EditorBuffer) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = lines
+	eb.cursorLine, eb.cursorCol = anchorLine, anchorCol
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: 'v'})
+	eb.cursorLine, eb.cursorCol = line, col
+	return a, eb
+}
+
+func visualKey(a *App, r rune) {
+	a.handleVisualKey(terminal.Key{Type: terminal.KeyRune, Rune: r})
+}
+
+func TestVisualRange(t *testing.T) {
+	tests := []struct {
+		name                           string
+		anchorLine, anchorCol          int
+		line, col                      int
+		wantSL, wantSC, wantEL, wantEC int
+		wantText                       string
+	}{
+		{"forward", 0, 4, 0, 8, 0, 4, 0, 9, "quick"},
+		{"backward", 0, 8, 0, 4, 0, 4, 0, 9, "quick"},
+		{"single char", 0, 0, 0, 0, 0, 0, 0, 1, "T"},
+		{"across lines", 0, 10, 1, 4, 0, 10, 1, 5, "brown\njump "},
+		{"end of line", 1, 5, 1, 20, 1, 5, 1, 17, "over the dog"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := visualApp([]string{"The quick brown", "jump over the dog"}, tt.anchorLine, tt.anchorCol, tt.line, tt.col)
+			sl, sc, el, ec := a.getVisualRange()
+			if sl != tt.wantSL || sc != tt.wantSC || el != tt.wantEL || ec != tt.wantEC {
+				t.Errorf("range = %d,%d-%d,%d, want %d,%d-%d,%d", sl, sc, el, ec, tt.wantSL, tt.wantSC, tt.wantEL, tt.wantEC)
+			}
+			if got := a.selectedText(); got != tt.wantText {
+				t.Errorf("selectedText = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestVisualModeEntryAndExit(t *testing.T) {
+	a, _ := visualApp([]string{"text"}, 0, 0, 0, 0)
+	if a.mode != ModeVisual {
+		t.Fatalf("mode = %v, want VISUAL", a.mode)
+	}
+	a.handleVisualKey(terminal.Key{Type: terminal.KeyEscape})
+	if a.mode != ModeDefault {
+		t.Errorf("Esc should leave visual mode, got %v", a.mode)
+	}
+}
+
+func TestVisualYankAndPaste(t *testing.T) {
+	a, eb := visualApp([]string{"The quick brown fox"}, 0, 4, 0, 9)
+	visualKey(a, 'y')
+	if a.mode != ModeDefault || a.yankBuffer != "quick " || !a.yankCharwise {
+		t.Fatalf("after y: mode %v, yank %q (charwise %v)", a.mode, a.yankBuffer, a.yankCharwise)
+	}
+
+	// p pastes inline after the cursor.
+	eb.cursorCol = 15
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: 'p'})
+	if got := eb.buf.Lines[0]; got != "The quick brown quick fox" {
+		t.Errorf("after p: %q", got)
+	}
+	if eb.cursorCol != 21 {
+		t.Errorf("cursor = %d, want 21", eb.cursorCol)
+	}
+	a.undoAction()
+	if got := eb.buf.Lines[0]; got != "The quick brown fox" {
+		t.Errorf("after undo: %q", got)
+	}
+
+	// A line yank switches p back to line-wise pasting.
+	a.yankLine()
+	a.handleDefaultKey(terminal.Key{Type: terminal.KeyRune, Rune: 'p'})
+	if len(eb.buf.Lines) != 2 {
+		t.Errorf("yy then p should paste a line: %q", eb.buf.Lines)
+	}
+}
+
+func TestVisualDeleteAcrossLines(t *testing.T) {
+	a, eb := visualApp([]string{"first line", "second line", "third line"}, 0, 6, 2, 5)
+	visualKey(a, 'd')
+	if got := strings.Join(eb.buf.Lines, "|"); got != "first line" {
+		t.Errorf("after d: %q", got)
+	}
+	if a.yankBuffer != "line\nsecond line\nthird " {
+		t.Errorf("yank = %q", a.yankBuffer)
+	}
+	if eb.cursorLine != 0 || eb.cursorCol != 6 {
+		t.Errorf("cursor = %d,%d, want 0,6", eb.cursorLine, eb.cursorCol)
+	}
+
+	a.undoAction()
+	if got := strings.Join(eb.buf.Lines, "|"); got != "first line|second line|third line" {
+		t.Errorf("after undo: %q", got)
+	}
+	a.redoAction()
+	if got := strings.Join(eb.buf.Lines, "|"); got != "first line" {
+		t.Errorf("after redo: %q", got)
+	}
+}
+
+func TestVisualDeleteTracked(t *testing.T) {
+	a, eb := visualApp([]string{"one two", "three four"}, 0, 4, 1, 4)
+	eb.trackChanges = true
+	visualKey(a, 'd')
+	if got := strings.Join(eb.buf.Lines, "|"); got != "one {--two--}|{--three--} four" {
+		t.Errorf("tracked d: %q", got)
+	}
+}
+
+func TestVisualSendToScratch(t *testing.T) {
+	a, eb := visualApp([]string{"keep this bit"}, 0, 5, 0, 8)
+	visualKey(a, 's')
+	if eb.buf.Lines[0] != "keep this bit" {
+		t.Errorf("s should not change the buffer: %q", eb.buf.Lines[0])
+	}
+	scratch := a.buffers[a.ensureScratchBuffer()]
+	if scratch.buf.Lines[0] != "this" {
+		t.Errorf("scratch = %q", scratch.buf.Lines)
+	}
+}
+
+func TestStyleSelection(t *testing.T) {
+	buf := &Buffer{Lines: []string{"abcdef", "ghij", "klm"}}
+	dls := WrapBuffer(buf, 40)
+	styleSelection(dls, palettes["default"].selectionSpan(), 0, 2, 2, 1)
+	want := [][2]int{{2, 6}, {0, 4}, {0, 1}}
+	for i, w := range want {
+		if len(dls[i].Styles) != 1 || dls[i].Styles[0].Start != w[0] || dls[i].Styles[0].End != w[1] {
+			t.Errorf("line %d styles = %+v, want %v", i, dls[i].Styles, w)
+		}
+	}
+}
+
+func TestShiftArrowsSelect(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The quick brown"}
+	eb.cursorCol = 4
+	a.mode = ModeEdit
+
+	shiftRight := terminal.Key{Type: terminal.KeyRight, Mod: terminal.ModShift}
+	for range 4 {
+		a.handleKey(shiftRight)
+	}
+	if a.mode != ModeVisual {
+		t.Fatalf("Shift-Right should start Visual mode, got %v", a.mode)
+	}
+	if got := a.selectedText(); got != "quick" {
+		t.Errorf("selectedText = %q, want %q", got, "quick")
+	}
+	a.handleKey(terminal.Key{Type: terminal.KeyEnd, Mod: terminal.ModShift})
+	if got := a.selectedText(); got != "quick brown" {
+		t.Errorf("Shift-End should extend the selection, got %q", got)
+	}
+
+	a.handleKey(terminal.Key{Type: terminal.KeyEscape})
+	a.handleKey(terminal.Key{Type: terminal.KeyLeft, Mod: terminal.ModCtrl})
+	if a.mode != ModeDefault || eb.cursorCol != eb.buf.LineLen(0)-1 {
+		t.Errorf("other modified arrows should move as usual, got %v at %d", a.mode, eb.cursorCol)
+	}
+}
@@ -0,0 +1,59 @@
+package editor
+
+import "testing"
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{'é', 1},
+		{'́', 0}, // Combining acute accent
+		{'‍', 0}, // Zero-width joiner
+		{'中', 2},
+		{'ｱ', 1}, // Halfwidth katakana
+		{'Ａ', 2}, // Fullwidth A
+		{'한', 2},
+		{'😀', 2},
+		{'→', 1},
+	}
+	for _, tt := range tests {
+		if got := runeWidth(tt.r); got != tt.want {
+			t.Errorf("runeWidth(%q) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	if got := stringWidth("a中b😀"); got != 6 {
+		t.Errorf("stringWidth = %d, want 6", got)
+	}
+	if got := stringWidth("é"); got != 1 {
+		t.Errorf("stringWidth with combining mark = %d, want 1", got)
+	}
+}
+
+func TestDisplayLineScreenCol(t *testing.T) {
+	dl := DisplayLine{Text: "a中文b"}
+	for textCol, want := range []int{0, 1, 3, 5, 6, 7} {
+		if got := dl.screenCol(textCol); got != want {
+			t.Errorf("screenCol(%d) = %d, want %d", textCol, got, want)
+		}
+	}
+}
+
+func TestDisplayLineTextColWide(t *testing.T) {
+	dl := DisplayLine{Text: "a中文b"}
+	for col, want := range []int{0, 1, 1, 2, 2, 3, 4} {
+		if got := dl.TextCol(col); got != want {
+			t.Errorf("TextCol(%d) = %d, want %d", col, got, want)
+		}
+	}
+}
+
+func TestVisibleLenWide(t *testing.T) {
+	if got := visibleLen("\x1b[1m中文\x1b[0m"); got != 4 {
+		t.Errorf("visibleLen = %d, want 4", got)
+	}
+}
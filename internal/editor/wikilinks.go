@@ -0,0 +1,165 @@
+package editor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// [[Note Name]], [[Note Name#Heading]] or [[Note Name|shown text]].
+var reWikiLink = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|[^\[\]]*)?\]\]`)
+
+// notePaths returns the Markdown files under dir, relative to it, in
+// order. Hidden files and directories are skipped, as :grep skips them.
+func notePaths(dir string) []string {
+	var notes []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !IsMarkdownFile(path) {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			notes = append(notes, rel)
+		}
+		return nil
+	})
+	sort.Strings(notes)
+	return notes
+}
+
+// noteName is the name a wiki link uses for a note: its file name without
+// the extension.
+func noteName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// resolveWikiLink returns the note named name among notes, the Markdown
+// files under dir, ignoring case. A name with a slash in it is a path from
+// dir; otherwise the note may be in any directory. It returns "" if there
+// is no such note.
+func resolveWikiLink(dir string, notes []string, name string) string {
+	name = filepath.FromSlash(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	for _, rel := range notes {
+		if strings.EqualFold(strings.TrimSuffix(rel, filepath.Ext(rel)), name) || strings.EqualFold(rel, name) {
+			return filepath.Join(dir, rel)
+		}
+	}
+	if strings.ContainsRune(name, filepath.Separator) {
+		return ""
+	}
+	for _, rel := range notes {
+		if strings.EqualFold(noteName(rel), name) || strings.EqualFold(filepath.Base(rel), name) {
+			return filepath.Join(dir, rel)
+		}
+	}
+	return ""
+}
+
+// followWikiLink opens the note a [[wiki link]] names, creating an empty
+// one in the current directory if there is none yet.
+func (a *App) followWikiLink(target string) {
+	name, fragment, _ := strings.Cut(target, "#")
+	if strings.TrimSpace(name) == "" {
+		a.openNoteAt("", fragment)
+		return
+	}
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		a.statusBar.SetError("Can't open link: " + err.Error())
+		return
+	}
+	path := resolveWikiLink(dir, notePaths(dir), name)
+	if path == "" {
+		path = filepath.Join(dir, filepath.FromSlash(strings.TrimSpace(name)))
+		if !IsMarkdownFile(path) {
+			path += ".md"
+		}
+		if err := createNote(path); err != nil {
+			a.statusBar.SetError("Can't create note: " + err.Error())
+			return
+		}
+		a.statusBar.SetMessage("Created " + filepath.Base(path))
+	}
+	a.openNoteAt(path, fragment)
+}
+
+// createNote creates an empty note at path, and its directory, unless it
+// already exists.
+func createNote(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// wikiPrefix finds the note name being typed in a wiki link before col,
+// returning the column it starts at and what is typed so far.
+func wikiPrefix(line string, col int) (start int, prefix string, ok bool) {
+	runes := []rune(line)
+	col = min(col, len(runes))
+	for start = col; start > 0; start-- {
+		switch runes[start-1] {
+		case ']', '|', '#':
+			return 0, "", false
+		case '[':
+			if start >= 2 && runes[start-2] == '[' {
+				return start, string(runes[start:col]), true
+			}
+			return 0, "", false
+		}
+	}
+	return 0, "", false
+}
+
+// completeWikiLink lists the notes under the current directory whose names
+// match the wiki link being typed before the cursor. Typing [[ opens the
+// list quietly; a failure then closes it without a message, and a single
+// match waits to be chosen rather than going in at once.
+func (a *App) completeWikiLink(quiet bool) bool {
+	eb := a.currentBuf()
+	start, prefix, ok := wikiPrefix(eb.buf.Lines[eb.cursorLine], eb.cursorCol)
+	if !ok {
+		return false
+	}
+	var items []CompletionItem
+	for _, rel := range notePaths(".") {
+		if _, ok := fuzzyMatch(prefix, noteName(rel)); ok {
+			items = append(items, CompletionItem{Text: noteName(rel), Detail: rel, Suffix: "]]"})
+		}
+	}
+	switch {
+	case len(items) == 0:
+		a.completion.Hide()
+		if !quiet {
+			a.statusBar.SetMessage("No notes match [[" + prefix)
+		}
+	case len(items) == 1 && !quiet && !a.completion.Active:
+		a.acceptCompletion(start, items[0])
+	default:
+		a.completion.Show("Link to Note", eb.cursorLine, start, items)
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func TestFindWikiLinks(t *testing.T) {
+	got := findLinks("See [[The Harbour]], [[ch2#The storm|the storm]] and [x](x.md).")
+	want := []noteLink{
+		{Start: 4, End: 19, Target: "The Harbour", Wiki: true},
+		{Start: 21, End: 48, Target: "ch2#The storm", Wiki: true},
+		{Start: 53, End: 62, Target: "x.md"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findLinks = %+v, want %+v", got, want)
+	}
+}
+
+func writeWikiTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"The Harbour.md":   "# The Harbour\n\nSee [[Storm]].",
+		"notes/storm.md":   "# Storm\n\n## Landfall\n\nBack to [[the harbour]].",
+		"notes/ideas.txt":  "[[Storm]]",
+		".drafts/storm.md": "Old",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestResolveWikiLink(t *testing.T) {
+	dir := writeWikiTree(t)
+	notes := notePaths(dir)
+	if want := []string{"The Harbour.md", filepath.Join("notes", "storm.md")}; !reflect.DeepEqual(notes, want) {
+		t.Fatalf("notePaths = %q, want %q", notes, want)
+	}
+
+	tests := map[string]string{
+		"the harbour":    filepath.Join(dir, "The Harbour.md"),
+		"Storm":          filepath.Join(dir, "notes", "storm.md"),
+		"notes/storm":    filepath.Join(dir, "notes", "storm.md"),
+		"storm.md":       filepath.Join(dir, "notes", "storm.md"),
+		"other/storm":    "",
+		"The Lighthouse": "",
+	}
+	for name, want := range tests {
+		if got := resolveWikiLink(dir, notes, name); got != want {
+			t.Errorf("resolveWikiLink(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWikiPrefix(t *testing.T) {
+	tests := []struct {
+		line   string
+		col    int
+		start  int
+		prefix string
+		ok     bool
+	}{
+		{"See [[", 6, 6, "", true},
+		{"See [[The Ha", 12, 6, "The Ha", true},
+		{"See [[The Harbour]]", 19, 0, "", false},
+		{"See [[ch2#Sto", 13, 0, "", false},
+		{"See [x", 6, 0, "", false},
+	}
+	for _, tt := range tests {
+		start, prefix, ok := wikiPrefix(tt.line, tt.col)
+		if start != tt.start || prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("wikiPrefix(%q, %d) = %d, %q, %v", tt.line, tt.col, start, prefix, ok)
+		}
+	}
+}
+
+func TestTypingWikiLinkCompletes(t *testing.T) {
+	t.Chdir(writeWikiTree(t))
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"See "}
+	eb.cursorCol = 4
+
+	sendKeys(a, "i[[")
+	if !a.completion.Active || len(a.completion.Items) != 2 {
+		t.Fatalf("[[ should list both notes, got %+v", a.completion.Items)
+	}
+	sendKeys(a, "st")
+	if len(a.completion.Items) != 1 || a.completion.Items[0].Text != "storm" {
+		t.Fatalf("typing should narrow the list, got %+v", a.completion.Items)
+	}
+	sendKey(a, terminal.KeyEnter)
+	if got := eb.buf.Lines[0]; got != "See [[storm]]" || a.completion.Active || eb.cursorCol != 13 {
+		t.Errorf("Enter should complete the link, got %q at %d", got, eb.cursorCol)
+	}
+
+	eb.buf.Lines = []string{"See [[]]"}
+	eb.cursorCol = 6
+	sendKey(a, terminal.KeyTab)
+	if !a.completion.Active {
+		t.Fatal("Tab inside [[]] should list the notes")
+	}
+	sendKey(a, terminal.KeyEnter)
+	if got := eb.buf.Lines[0]; got != "See [[The Harbour]]" || eb.cursorCol != 19 {
+		t.Errorf("the closing ]] should be stepped over, got %q at %d", got, eb.cursorCol)
+	}
+}
+
+func TestFollowWikiLink(t *testing.T) {
+	dir := writeWikiTree(t)
+	t.Chdir(dir)
+	a := newTestApp("")
+	a.viewport = NewViewport(80, 24)
+	a.currentBuf().buf.Lines = []string{"[[Storm#Landfall]] and [[The Lighthouse]]"}
+
+	sendKey(a, terminal.KeyEnter)
+	eb := a.currentBuf()
+	if filepath.Base(eb.Filename()) != "storm.md" || eb.cursorLine != 2 {
+		t.Errorf("Enter should open storm.md at Landfall, got %s at line %d", eb.Filename(), eb.cursorLine)
+	}
+
+	sendKey(a, terminal.KeyCtrlO)
+	a.currentBuf().cursorCol = 25
+	sendKey(a, terminal.KeyEnter)
+	if eb = a.currentBuf(); filepath.Base(eb.Filename()) != "The Lighthouse.md" {
+		t.Errorf("a missing note should be opened, got %s", eb.Filename())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "The Lighthouse.md")); err != nil {
+		t.Errorf("a missing note should be created: %v", err)
+	}
+}
+
+func TestBacklinksFollowWikiLinks(t *testing.T) {
+	dir := writeWikiTree(t)
+	index := Backlinks(dir, nil)
+	got := index[filepath.Join(dir, "notes", "storm.md")]
+	if len(got) != 2 || got[0].Path != "The Harbour.md" || got[1].Path != filepath.Join("notes", "ideas.txt") {
+		t.Errorf("backlinks of storm.md = %+v", got)
+	}
+	if got := index[filepath.Join(dir, "The Harbour.md")]; len(got) != 1 {
+		t.Errorf("backlinks of The Harbour.md = %+v", got)
+	}
+}
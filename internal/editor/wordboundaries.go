@@ -26,6 +26,9 @@ func extractWordBoundariesFromLine(lineNum int, line string) []WordBoundary {
 	var startCol int
 
 	for i, r := range runes {
+		if i > 0 && extendsGrapheme(runes[i-1], r) {
+			continue // Part of the character before, in or out of a word
+		}
 		isWordChar := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
 		if isWordChar {
 			if !inWord {
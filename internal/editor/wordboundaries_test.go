@@ -47,6 +47,15 @@ func TestExtractWordBoundariesFromLine(t *testing.T) {
 			},
 			desc: "underscores with numbers: one word",
 		},
+		{
+			line: "cafe\u0301 au lait",
+			expected: []WordBoundary{
+				{Line: 0, StartCol: 0, EndCol: 5},
+				{Line: 0, StartCol: 6, EndCol: 8},
+				{Line: 0, StartCol: 9, EndCol: 13},
+			},
+			desc: "combining mark: part of the word",
+		},
 		{
 			line:     "",
 			expected: []WordBoundary{},
@@ -0,0 +1,124 @@
+package editor
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JackWReid/prose/internal/spell"
+)
+
+// wordCompletionLimit caps how many dictionary words Ctrl-N lists after
+// the words from the open buffers.
+const wordCompletionLimit = 50
+
+// isCompletionWordRune reports whether r can be part of a word Ctrl-N
+// completes: letters, and apostrophes within a word.
+func isCompletionWordRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '\'' || r == '’'
+}
+
+// wordPrefix finds the word being typed before col, returning the column
+// it starts at and the letters typed so far.
+func wordPrefix(line string, col int) (start int, prefix string, ok bool) {
+	runes := []rune(line)
+	col = min(col, len(runes))
+	start = col
+	for start > 0 && isCompletionWordRune(runes[start-1]) {
+		start--
+	}
+	// An apostrophe opens a quotation rather than a word.
+	for start < col && !unicode.IsLetter(runes[start]) {
+		start++
+	}
+	if start == col {
+		return 0, "", false
+	}
+	return start, string(runes[start:col]), true
+}
+
+// matchCase gives word the case of what was typed: a capital first letter,
+// or all capitals.
+func matchCase(typed, word string) string {
+	first, _ := utf8.DecodeRuneInString(typed)
+	switch {
+	case utf8.RuneCountInString(typed) > 1 && strings.ToUpper(typed) == typed && strings.ToLower(typed) != typed:
+		return strings.ToUpper(word)
+	case unicode.IsUpper(first):
+		r, size := utf8.DecodeRuneInString(word)
+		return string(unicode.ToUpper(r)) + word[size:]
+	}
+	return word
+}
+
+// wordCandidates returns the words starting with prefix, ignoring case,
+// and longer than it: first those of the current buffer from the cursor
+// on, wrapping round, then those of the other open buffers, then the
+// dictionary's. Each is given the case of the prefix.
+func (a *App) wordCandidates(prefix string) []CompletionItem {
+	lower := strings.ToLower(prefix)
+	seen := make(map[string]bool)
+	var items []CompletionItem
+	add := func(word, detail string) {
+		if len(word) <= len(prefix) || !strings.HasPrefix(strings.ToLower(word), lower) {
+			return
+		}
+		word = matchCase(prefix, word)
+		if !seen[word] {
+			seen[word] = true
+			items = append(items, CompletionItem{Text: word, Detail: detail})
+		}
+	}
+	addLine := func(line, detail string) {
+		for _, word := range strings.FieldsFunc(line, func(r rune) bool { return !isCompletionWordRune(r) }) {
+			add(strings.Trim(word, "'’"), detail)
+		}
+	}
+
+	eb := a.currentBuf()
+	lines := eb.buf.Lines
+	for i := range lines {
+		addLine(lines[(eb.cursorLine+i)%len(lines)], "")
+	}
+	for _, other := range a.buffers {
+		if other != eb {
+			for _, line := range other.buf.Lines {
+				addLine(line, filepath.Base(other.Filename()))
+			}
+		}
+	}
+	for _, word := range spell.Completions(prefix, wordCompletionLimit) {
+		add(word, "dictionary")
+	}
+	return items
+}
+
+// completeWord lists the words that complete the one being typed before
+// the cursor, by the cursor rather than in the middle of the screen.
+// Ctrl-N starts it. A single match is inserted at once. When quiet, as
+// while narrowing the list, a failure closes it without a message.
+func (a *App) completeWord(quiet bool) bool {
+	eb := a.currentBuf()
+	start, prefix, ok := wordPrefix(eb.buf.Lines[eb.cursorLine], eb.cursorCol)
+	if !ok {
+		if !quiet {
+			a.statusBar.SetMessage("No word to complete")
+		}
+		return false
+	}
+	items := a.wordCandidates(prefix)
+	switch {
+	case len(items) == 0:
+		a.completion.Hide()
+		if !quiet {
+			a.statusBar.SetMessage("No words start with " + prefix)
+		}
+	case len(items) == 1 && !quiet && !a.completion.Active:
+		a.acceptCompletion(start, items[0])
+	default:
+		a.completion.Show("Words", eb.cursorLine, start, items)
+		a.completion.Words = true
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/JackWReid/prose/internal/terminal"
+)
+
+func ctrlKey(a *App, r rune) {
+	a.handleKey(terminal.Key{Type: terminal.KeyCombo, Rune: r, Mod: terminal.ModCtrl})
+}
+
+func TestWordPrefix(t *testing.T) {
+	tests := []struct {
+		line   string
+		col    int
+		start  int
+		prefix string
+		ok     bool
+	}{
+		{"The harb", 8, 4, "harb", true},
+		{"The harb", 6, 4, "ha", true},
+		{"It's", 4, 0, "It's", true},
+		{"'harb", 5, 1, "harb", true},
+		{"The ", 4, 0, "", false},
+		{"", 0, 0, "", false},
+	}
+	for _, tt := range tests {
+		start, prefix, ok := wordPrefix(tt.line, tt.col)
+		if start != tt.start || prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("wordPrefix(%q, %d) = %d, %q, %v", tt.line, tt.col, start, prefix, ok)
+		}
+	}
+}
+
+func TestMatchCase(t *testing.T) {
+	tests := []struct{ typed, word, want string }{
+		{"har", "harbour", "harbour"},
+		{"Har", "harbour", "Harbour"},
+		{"HAR", "harbour", "HARBOUR"},
+		{"H", "harbour", "Harbour"},
+		{"har", "Harbour", "Harbour"},
+	}
+	for _, tt := range tests {
+		if got := matchCase(tt.typed, tt.word); got != tt.want {
+			t.Errorf("matchCase(%q, %q) = %q, want %q", tt.typed, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestWordCandidates(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"The harbourmaster slept.", "Then the harb", "The harbours froze."}
+	eb.cursorLine, eb.cursorCol = 1, 13
+	other := NewEditorBuffer("notes.md")
+	other.buf.Lines = []string{"harbingers of spring, harbours"}
+	a.buffers = append(a.buffers, other)
+
+	items := a.wordCandidates("harb")
+	want := []CompletionItem{
+		{Text: "harbours"},
+		{Text: "harbourmaster"},
+		{Text: "harbingers", Detail: "notes.md"},
+	}
+	if len(items) <= len(want) {
+		t.Fatalf("dictionary words should follow the buffers', got %+v", items)
+	}
+	for i, w := range want {
+		if items[i] != w {
+			t.Errorf("candidate %d = %+v, want %+v", i, items[i], w)
+		}
+	}
+	for _, item := range items[len(want):] {
+		if item.Detail != "dictionary" || item.Text == "harbours" {
+			t.Errorf("only new dictionary words should follow, got %+v", item)
+		}
+	}
+}
+
+func TestCtrlNCompletesWord(t *testing.T) {
+	a := newTestApp("draft.md")
+	eb := a.currentBuf()
+	eb.buf.Lines = []string{"Zqathel and Zqorn met. Zq"}
+	eb.cursorCol = 25
+	a.mode = ModeEdit
+
+	ctrlKey(a, 'n')
+	if !a.completion.Active || !a.completion.Words || len(a.completion.Items) != 2 {
+		t.Fatalf("Ctrl-N should list both names, got %+v", a.completion.Items)
+	}
+	ctrlKey(a, 'n')
+	if a.completion.Selected != 1 {
+		t.Errorf("Ctrl-N in the list should move down, got %d", a.completion.Selected)
+	}
+	a.handleKey(terminal.Key{Type: terminal.KeyRune, Rune: 'a'})
+	if len(a.completion.Items) != 1 || a.completion.Items[0].Text != "Zqathel" {
+		t.Fatalf("typing should narrow the list, got %+v", a.completion.Items)
+	}
+	a.handleKey(terminal.Key{Type: terminal.KeyEnter})
+	if got := eb.buf.Lines[0]; got != "Zqathel and Zqorn met. Zqathel" || a.completion.Active {
+		t.Errorf("Enter should insert the word, got %q", got)
+	}
+
+	eb.buf.Lines = []string{"Zqathel and Zqorn met. Zqo"}
+	eb.cursorCol = 26
+	ctrlKey(a, 'p')
+	if got := eb.buf.Lines[0]; got != "Zqathel and Zqorn met. Zqorn" || a.completion.Active {
+		t.Errorf("a single match should be inserted at once, got %q", got)
+	}
+
+	eb.buf.Lines = []string{"Zqathel and Zqorn met. Z"}
+	eb.cursorCol = 24
+	ctrlKey(a, 'p')
+	if !a.completion.Active || a.completion.Selected != len(a.completion.Items)-1 {
+		t.Errorf("Ctrl-P should start from the last match, got %d of %d", a.completion.Selected, len(a.completion.Items))
+	}
+	a.handleKey(terminal.Key{Type: terminal.KeyEscape})
+
+	eb.buf.Lines = []string{"Zqathel and "}
+	eb.cursorCol = 12
+	ctrlKey(a, 'n')
+	if a.completion.Active || a.statusBar.StatusMessage != "No word to complete" {
+		t.Errorf("after a space: active %v, message %q", a.completion.Active, a.statusBar.StatusMessage)
+	}
+}
+
+func TestRenderWordCompletionByCursor(t *testing.T) {
+	r := NewRenderer()
+	vp := NewViewport(80, 24)
+	c := &Completion{}
+	c.Show("Words", 0, 0, []CompletionItem{{Text: "harbour"}, {Text: "harbourmaster"}})
+	c.Words = true
+
+	out := r.RenderWordCompletion(c, 5, 30, 26, vp)
+	if box := r.overlay; box.top != 6 || box.left != 24 || box.height != 4 || box.width != 17 {
+		t.Errorf("the list should sit under the word, got %+v", box)
+	}
+	if want := "\x1b[5;30H"; out[len(out)-len(want):] != want {
+		t.Errorf("the cursor should be left in place, got %q", out[len(out)-len(want):])
+	}
+
+	r.RenderWordCompletion(c, 22, 30, 26, vp)
+	if box := r.overlay; box.top != 18 {
+		t.Errorf("near the bottom the list should sit above the cursor, got row %d", box.top)
+	}
+}
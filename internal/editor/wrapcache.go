@@ -0,0 +1,56 @@
+package editor
+
+// Each buffer keeps the wrapped rows of its lines, keyed by their text, so a
+// keystroke wraps again only the line it changed. Lines that merely move,
+// as when a line is inserted above them, find their rows under the same
+// text. Rows of text no longer in the buffer are dropped once they build up.
+
+// wrapCacheSlack is how many entries beyond the buffer's line count may
+// pile up, as a line is typed, before unused ones are dropped.
+const wrapCacheSlack = 256
+
+// wrapCache holds the rows of a buffer's lines wrapped at one width.
+type wrapCache struct {
+	width int
+	gen   int // Counts wraps, marking the entries each one used
+	lines map[string]*wrapEntry
+}
+
+// wrapEntry is the rows of one line of text, with BufferLine and Block
+// left for the line they are used for.
+type wrapEntry struct {
+	rows []DisplayLine
+	gen  int
+}
+
+// wrap wraps buf like WrapBuffer, reusing the rows of lines it has wrapped
+// before at the same width.
+func (c *wrapCache) wrap(buf *Buffer, maxWidth int) []DisplayLine {
+	if c.lines == nil || c.width != maxWidth {
+		c.lines = make(map[string]*wrapEntry, len(buf.Lines))
+		c.width = maxWidth
+	}
+	c.gen++
+	states := ComputeBlockStates(buf.Lines)
+	all := make([]DisplayLine, 0, wrappedSize(buf.Lines, maxWidth))
+	for i, line := range buf.Lines {
+		e := c.lines[line]
+		if e == nil {
+			e = &wrapEntry{rows: appendWrapped(nil, line, maxWidth, 0, BlockState{})}
+			c.lines[line] = e
+		}
+		e.gen = c.gen
+		for _, dl := range e.rows {
+			dl.BufferLine, dl.Block = i, states[i]
+			all = append(all, dl)
+		}
+	}
+	if len(c.lines) > len(buf.Lines)+wrapCacheSlack {
+		for text, e := range c.lines {
+			if e.gen != c.gen {
+				delete(c.lines, text)
+			}
+		}
+	}
+	return all
+}
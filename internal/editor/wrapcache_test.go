@@ -0,0 +1,50 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrapCacheMatchesWrapBuffer(t *testing.T) {
+	buf := &Buffer{Lines: []string{
+		"A paragraph long enough that it wraps onto a second row.",
+		"```",
+		"code that wraps as well as the paragraph above it does",
+		"```",
+		"",
+	}}
+	var c wrapCache
+	check := func(step string, width int) {
+		t.Helper()
+		if got, want := c.wrap(buf, width), WrapBuffer(buf, width); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v\nwant %+v", step, got, want)
+		}
+	}
+	check("first wrap", 20)
+	check("unchanged", 20)
+
+	buf.Lines[0] = "Edited."
+	check("edited line", 20)
+
+	// Lines moved down keep their rows, with their new line numbers.
+	buf.Lines = append([]string{"```"}, buf.Lines...)
+	check("line inserted above, turning the rest inside out", 20)
+
+	check("another width", 30)
+}
+
+func TestWrapCacheDropsOldLines(t *testing.T) {
+	buf := &Buffer{Lines: []string{""}}
+	var c wrapCache
+	for i := range 2 * wrapCacheSlack {
+		buf.Lines[0] = strings.Repeat("x", i)
+		c.wrap(buf, 60)
+	}
+	if len(c.lines) > 1+wrapCacheSlack {
+		t.Errorf("cache kept %d entries for a 1-line buffer", len(c.lines))
+	}
+	if _, ok := c.lines[buf.Lines[0]]; !ok {
+		t.Error("the current line's rows should be kept")
+	}
+}
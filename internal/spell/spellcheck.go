@@ -2,7 +2,9 @@ package spell
 
 import (
 	_ "embed"
+	"sort"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/sajari/fuzzy"
@@ -60,6 +62,46 @@ func (sc *SpellChecker) CheckWord(word string) bool {
 	return correction != "" && correction == lowerWord
 }
 
+// AddWords adds words, such as a project's character names, to the dictionary
+func (sc *SpellChecker) AddWords(words []string) {
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			sc.model.TrainWord(strings.ToLower(word))
+		}
+	}
+}
+
+var (
+	dictionaryOnce  sync.Once
+	dictionaryWords []string // Sorted, for Completions
+)
+
+// Completions returns up to limit dictionary words that start with prefix
+// and are longer than it, ignoring case, in alphabetical order. The
+// dictionary is only split into words the first time it is needed.
+func Completions(prefix string, limit int) []string {
+	dictionaryOnce.Do(func() {
+		for _, word := range strings.Split(dictionaryData, "\n") {
+			if word = strings.TrimSpace(word); word != "" {
+				dictionaryWords = append(dictionaryWords, word)
+			}
+		}
+		sort.Strings(dictionaryWords)
+	})
+	prefix = strings.ToLower(prefix)
+	var words []string
+	for i := sort.SearchStrings(dictionaryWords, prefix); i < len(dictionaryWords) && len(words) < limit; i++ {
+		word := dictionaryWords[i]
+		if !strings.HasPrefix(word, prefix) {
+			break
+		}
+		if len(word) > len(prefix) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
 // wordPosition represents a word and its position in a line
 type wordPosition struct {
 	word     string
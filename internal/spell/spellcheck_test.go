@@ -236,3 +236,48 @@ func TestCheckLinePositions(t *testing.T) {
 			errors[1].Word, errors[1].Line, errors[1].StartCol, errors[1].EndCol, "wrold")
 	}
 }
+
+func TestAddWords(t *testing.T) {
+	sc, err := NewSpellChecker()
+	if err != nil {
+		t.Fatalf("NewSpellChecker() failed: %v", err)
+	}
+	if sc.CheckWord("Zarathel") {
+		t.Fatal("Zarathel should not be in the dictionary yet")
+	}
+	sc.AddWords([]string{"Zarathel", " "})
+	if !sc.CheckWord("Zarathel") || !sc.CheckWord("zarathel") {
+		t.Error("added word should be spelled correctly in any case")
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	got := Completions("Harbou", 3)
+	want := []string{"harbour", "harbour's", "harbourage"}
+	if len(got) != len(want) {
+		t.Fatalf("Completions(Harbou) = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Completions(Harbou) = %q, want %q", got, want)
+			break
+		}
+	}
+	if got := Completions("harbour", 100); len(got) == 0 || got[0] == "harbour" {
+		t.Errorf("the prefix itself should not be offered, got %q", got)
+	}
+	if got := Completions("zzqx", 5); len(got) != 0 {
+		t.Errorf("Completions(zzqx) = %q, want none", got)
+	}
+}
+
+func BenchmarkCheckLine(b *testing.B) {
+	sc, err := NewSpellChecker()
+	if err != nil {
+		b.Fatal(err)
+	}
+	line := "The harbour lay quiet under a grey sky, and the recieved wisdom about safe anchorage looked forlorn."
+	for i := 0; i < b.N; i++ {
+		sc.CheckLine(0, line)
+	}
+}
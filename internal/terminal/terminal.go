@@ -3,7 +3,12 @@ package terminal
 import (
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/term"
 )
@@ -11,33 +16,64 @@ import (
 // Terminal manages raw mode, alternate screen buffer, and terminal dimensions.
 type Terminal struct {
 	oldState *term.State
+	in, out  *os.File // The terminal: stdin and stdout unless they are piped
+	tty      *os.File // /dev/tty if opened for piped stdin or stdout, closed on Restore
 	width    int
 	height   int
 	sigwinch chan os.Signal
+	sigterm  chan os.Signal
+	sigtstp  chan os.Signal
+	input    chan readResult // Events read from stdin, in order
+	readErr  error           // Read error met by PendingEvent, for ReadEvent to return
+	restored bool
+
+	cursorStyle int // Set by SetCursorStyle; put back to the default on Restore
 }
 
+// Cursor styles for SetCursorStyle, in DECSCUSR order.
+const (
+	CursorDefault           = iota // The terminal's own cursor
+	CursorBlinkingBlock            // Blinking block
+	CursorBlock                    // Steady block
+	CursorBlinkingUnderline        // Blinking underline
+	CursorUnderline                // Steady underline
+	CursorBlinkingBar              // Blinking vertical bar
+	CursorBar                      // Steady vertical bar
+)
+
 func NewTerminal() (*Terminal, error) {
-	t := &Terminal{}
+	t := &Terminal{in: os.Stdin, out: os.Stdout}
+
+	// With text piped in or out, as in cat notes.md | prose -, talk to the
+	// terminal itself.
+	if !IsTerminal(os.Stdin) || !IsTerminal(os.Stdout) {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+		t.tty = tty
+		if !IsTerminal(os.Stdin) {
+			t.in = tty
+		}
+		if !IsTerminal(os.Stdout) {
+			t.out = tty
+		}
+	}
 
 	// Switch to raw mode.
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	oldState, err := term.MakeRaw(int(t.in.Fd()))
 	if err != nil {
+		if t.tty != nil {
+			t.tty.Close()
+		}
 		return nil, err
 	}
 	t.oldState = oldState
 
-	// Enter alternate screen buffer.
-	os.Stdout.WriteString("\x1b[?1049h")
-
-	// Hide cursor during setup.
-	os.Stdout.WriteString("\x1b[?25l")
-
-	// Enable SGR mouse protocol: button events + extended coordinates.
-	os.Stdout.WriteString("\x1b[?1000h") // Button events
-	os.Stdout.WriteString("\x1b[?1006h") // SGR extended mode
+	t.enter()
 
 	// Query size.
-	t.width, t.height, err = term.GetSize(int(os.Stdout.Fd()))
+	t.width, t.height, err = term.GetSize(int(t.out.Fd()))
 	if err != nil {
 		t.Restore()
 		return nil, err
@@ -47,12 +83,25 @@ func NewTerminal() (*Terminal, error) {
 	t.sigwinch = make(chan os.Signal, 1)
 	signal.Notify(t.sigwinch, syscall.SIGWINCH)
 
+	// Listen for termination (kill, closed terminal window) so the editor can
+	// save unsaved work instead of dying silently.
+	t.sigterm = make(chan os.Signal, 1)
+	signal.Notify(t.sigterm, syscall.SIGTERM, syscall.SIGHUP)
+
+	// Catch a stop signal sent from outside, so the screen can be put
+	// right before stopping. Ctrl-Z itself arrives as a key in raw mode.
+	t.sigtstp = make(chan os.Signal, 1)
+	signal.Notify(t.sigtstp, syscall.SIGTSTP)
+
+	t.input = make(chan readResult, inputQueue)
+	go t.readInput()
+
 	return t, nil
 }
 
 // Resize re-queries terminal dimensions. Returns true if the size changed.
 func (t *Terminal) Resize() bool {
-	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	w, h, err := term.GetSize(int(t.out.Fd()))
 	if err != nil {
 		return false
 	}
@@ -62,36 +111,159 @@ func (t *Terminal) Resize() bool {
 	return changed
 }
 
+// SetCursorStyle sets the cursor's shape and whether it blinks (DECSCUSR).
+// Terminals without support ignore it.
+func (t *Terminal) SetCursorStyle(style int) {
+	t.cursorStyle = style
+	t.out.WriteString(cursorStyleSequence(style))
+}
+
+// cursorStyleSequence returns the DECSCUSR sequence for a cursor style.
+func cursorStyleSequence(style int) string {
+	return "\x1b[" + strconv.Itoa(style) + " q"
+}
+
+// ColorDepth is how many colours a terminal can draw.
+type ColorDepth int
+
+const (
+	Colors16   ColorDepth = iota // The eight ANSI colours and their bright versions
+	Colors256                    // The xterm 256-colour palette
+	ColorsTrue                   // 24-bit colour
+)
+
+// DetectColorDepth guesses the colours a terminal draws from the
+// environment, read with getenv. Terminals with 24-bit colour set COLORTERM
+// to "truecolor" or "24bit"; otherwise the TERM name tells, as in
+// "xterm-256color", or "xterm-direct" for terminfo's 24-bit entries.
+// Anything else gets the 16 colours every colour terminal has.
+func DetectColorDepth(getenv func(string) string) ColorDepth {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorsTrue
+	}
+	term := strings.ToLower(getenv("TERM"))
+	switch {
+	case strings.HasSuffix(term, "-direct") || strings.Contains(term, "truecolor") || strings.Contains(term, "24bit"):
+		return ColorsTrue
+	case strings.Contains(term, "256col"):
+		return Colors256
+	}
+	return Colors16
+}
+
+// WriteString writes s, usually a frame, to the terminal.
+func (t *Terminal) WriteString(s string) {
+	t.out.WriteString(s)
+}
+
+// IsTerminal reports whether f is a terminal rather than a file or pipe.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
 // Width returns the current terminal width.
 func (t *Terminal) Width() int { return t.width }
 
 // Height returns the current terminal height.
 func (t *Terminal) Height() int { return t.height }
 
-// Restore returns the terminal to its original state.
+// Restore returns the terminal to its original state. It is safe to call
+// more than once (e.g. from a panic handler and a deferred cleanup).
 func (t *Terminal) Restore() {
+	if t.restored {
+		return
+	}
+	t.restored = true
+	t.leave()
+	if t.oldState != nil {
+		term.Restore(int(t.in.Fd()), t.oldState)
+	}
+	signal.Stop(t.sigwinch)
+	signal.Stop(t.sigterm)
+	signal.Stop(t.sigtstp)
+	if t.tty != nil {
+		t.tty.Close()
+	}
+}
+
+// enter switches to the alternate screen and turns on the mouse and
+// keyboard reporting the editor reads.
+func (t *Terminal) enter() {
+	// Enter alternate screen buffer.
+	t.out.WriteString("\x1b[?1049h")
+
+	// Hide cursor during setup.
+	t.out.WriteString("\x1b[?25l")
+
+	// Enable SGR mouse protocol: button events + extended coordinates.
+	t.out.WriteString("\x1b[?1000h") // Button events
+	t.out.WriteString("\x1b[?1006h") // SGR extended mode
+
+	// Ask for modified keys to be reported unambiguously, so Ctrl-S or
+	// Shift-Left can be told apart: the kitty keyboard protocol where it is
+	// supported, else xterm's modifyOtherKeys. Terminals ignore what they
+	// don't know.
+	t.out.WriteString("\x1b[>1u")   // Kitty: disambiguate escape codes
+	t.out.WriteString("\x1b[>4;2m") // modifyOtherKeys level 2
+	if t.cursorStyle != CursorDefault {
+		t.out.WriteString(cursorStyleSequence(t.cursorStyle))
+	}
+}
+
+// leave undoes enter, returning to the normal screen.
+func (t *Terminal) leave() {
+	// Return to plain keyboard reporting.
+	t.out.WriteString("\x1b[>4m") // modifyOtherKeys off
+	t.out.WriteString("\x1b[<u")  // Kitty: pop our flags
 	// Disable mouse protocols.
-	os.Stdout.WriteString("\x1b[?1006l") // SGR extended mode
-	os.Stdout.WriteString("\x1b[?1000l") // Button events
-	// Show cursor.
-	os.Stdout.WriteString("\x1b[?25h")
+	t.out.WriteString("\x1b[?1006l") // SGR extended mode
+	t.out.WriteString("\x1b[?1000l") // Button events
+	// Show cursor, in the terminal's own style.
+	t.out.WriteString("\x1b[?25h")
+	if t.cursorStyle != CursorDefault {
+		t.out.WriteString(cursorStyleSequence(CursorDefault))
+	}
 	// Leave alternate screen buffer.
-	os.Stdout.WriteString("\x1b[?1049l")
+	t.out.WriteString("\x1b[?1049l")
+}
+
+// Suspend puts the terminal back as it was before the editor started, on
+// its normal screen and out of raw mode, so another program can use it.
+// Resume takes it back.
+func (t *Terminal) Suspend() {
+	t.leave()
 	if t.oldState != nil {
-		term.Restore(int(os.Stdin.Fd()), t.oldState)
+		term.Restore(int(t.in.Fd()), t.oldState)
 	}
-	signal.Stop(t.sigwinch)
 }
 
-// ReadKey reads a single input event from stdin in raw mode.
-// Returns an InputEvent which may contain a Key or MouseEvent.
-func (t *Terminal) ReadKey() (InputEvent, error) {
-	buf := make([]byte, 32) // Larger buffer for SGR mouse sequences
-	n, err := os.Stdin.Read(buf)
-	if err != nil {
-		return InputEvent{}, err
+// Resume returns to raw mode and the alternate screen after Suspend. The
+// screen is blank, so everything must be drawn again, and its size may
+// have changed.
+func (t *Terminal) Resume() error {
+	if _, err := term.MakeRaw(int(t.in.Fd())); err != nil {
+		return err
 	}
-	return parseInput(buf[:n]), nil
+	t.enter()
+	t.Resize()
+	return nil
+}
+
+// Stop suspends the process as a shell's job, as Ctrl-Z does in cooked
+// mode, with the terminal put back as it was. It returns when the shell
+// continues the job, with the terminal taken back as Resume does.
+func (t *Terminal) Stop() error {
+	t.Suspend()
+	// Stop the whole process group, as the shell expects, with the
+	// signal's default action rather than our handler.
+	signal.Reset(syscall.SIGTSTP)
+	err := syscall.Kill(0, syscall.SIGTSTP)
+	signal.Notify(t.sigtstp, syscall.SIGTSTP)
+	if rerr := t.Resume(); err == nil {
+		err = rerr
+	}
+	return err
 }
 
 // readResult is an internal type for passing stdin reads through a channel.
@@ -100,27 +272,161 @@ type readResult struct {
 	err   error
 }
 
-// ReadEvent reads the next input event, responding immediately to terminal
-// resize signals (SIGWINCH) even while blocked on stdin. Returns an
-// EventResize event when the terminal is resized.
-func (t *Terminal) ReadEvent() (InputEvent, error) {
-	// Start a goroutine to read from stdin without blocking the select.
-	ch := make(chan readResult, 1)
-	go func() {
-		buf := make([]byte, 32)
-		n, err := os.Stdin.Read(buf)
+// inputQueue is how many events can wait to be handled. The reader blocks
+// once it is full, so a huge paste is read no faster than it is handled.
+const inputQueue = 256
+
+// escapeTimeout is how long a read that ends in an unfinished escape
+// sequence waits for the rest. A lone ESC is the Escape key only once it
+// has passed, so an arrow key split across reads is never read as Escape
+// followed by "[A".
+const escapeTimeout = 50 * time.Millisecond
+
+// readInput reads stdin for as long as the terminal is open, parsing it
+// into events: a paste or a burst of key repeats arrives as many keys in
+// one read, and an escape sequence may arrive over several.
+func (t *Terminal) readInput() {
+	chunks := make(chan readChunk)
+	go readStdin(t.in, chunks)
+	parseStream(chunks, t.input, escapeTimeout)
+}
+
+// readChunk is the bytes returned by one read of stdin.
+type readChunk struct {
+	data []byte
+	err  error
+}
+
+// readStdin sends each read of in to chunks until a read fails.
+func readStdin(in *os.File, chunks chan<- readChunk) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := in.Read(buf)
 		if err != nil {
-			ch <- readResult{err: err}
+			chunks <- readChunk{err: err}
 			return
 		}
-		ch <- readResult{event: parseInput(buf[:n])}
-	}()
+		chunks <- readChunk{data: append([]byte(nil), buf[:n]...)}
+	}
+}
+
+// parseStream parses chunks into events on out. Bytes that may begin a
+// longer sequence are held until more arrive or timeout passes, when they
+// are sent as they are. It returns after passing on a read error.
+func parseStream(chunks <-chan readChunk, out chan<- readResult, timeout time.Duration) {
+	var p inputParser
+	for {
+		var timer *time.Timer
+		var expired <-chan time.Time // Never fires unless bytes are held
+		if len(p.pending) > 0 {
+			timer = time.NewTimer(timeout)
+			expired = timer.C
+		}
+		var events []InputEvent
+		select {
+		case c := <-chunks:
+			if c.err != nil {
+				for _, event := range p.flush() {
+					out <- readResult{event: event}
+				}
+				out <- readResult{err: c.err}
+				return
+			}
+			events = p.feed(c.data)
+		case <-expired:
+			events = p.flush()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		for _, event := range events {
+			out <- readResult{event: event}
+		}
+	}
+}
+
+// inputParser splits a stream of input bytes into events. A read may end
+// partway through an escape sequence or UTF-8 character, so the bytes of
+// an unfinished event are kept until the next read.
+type inputParser struct {
+	pending []byte
+}
+
+// feed adds bytes to the stream and returns the events they finish.
+func (p *inputParser) feed(data []byte) []InputEvent {
+	p.pending = append(p.pending, data...)
+	var events []InputEvent
+	for len(p.pending) > 0 {
+		n, done := inputLength(p.pending)
+		if !done {
+			break
+		}
+		events = append(events, parseInput(p.pending[:n]))
+		p.pending = p.pending[n:]
+	}
+	if len(p.pending) == 0 {
+		p.pending = nil
+	}
+	return events
+}
+
+// flush returns the events of the bytes held, taking them as complete: a
+// lone ESC becomes the Escape key.
+func (p *inputParser) flush() []InputEvent {
+	events := splitInput(p.pending)
+	p.pending = nil
+	return events
+}
+
+// ReadEvent reads the next input event, responding immediately to terminal
+// resize signals (SIGWINCH) even while blocked on stdin. Returns an
+// EventResize event when the terminal is resized, EventTerminate when
+// SIGTERM or SIGHUP is received, and EventSuspend when SIGTSTP is.
+func (t *Terminal) ReadEvent() (InputEvent, error) {
+	event, _, err := t.ReadEventTimeout(0)
+	return event, err
+}
 
+// ReadEventTimeout is ReadEvent giving up after d, when it returns false.
+// The editor uses it to wake for work that is due, such as a spell check
+// waiting for typing to pause. A d of zero or less waits as ReadEvent does.
+func (t *Terminal) ReadEventTimeout(d time.Duration) (InputEvent, bool, error) {
+	if t.readErr != nil {
+		return InputEvent{}, true, t.readErr
+	}
+	var timeout <-chan time.Time // Never fires unless set
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
 	select {
 	case <-t.sigwinch:
-		return InputEvent{Type: EventResize}, nil
-	case res := <-ch:
-		return res.event, res.err
+		return InputEvent{Type: EventResize}, true, nil
+	case sig := <-t.sigterm:
+		return InputEvent{Type: EventTerminate, Signal: sig}, true, nil
+	case <-t.sigtstp:
+		return InputEvent{Type: EventSuspend}, true, nil
+	case res := <-t.input:
+		return res.event, true, res.err
+	case <-timeout:
+		return InputEvent{}, false, nil
+	}
+}
+
+// PendingEvent returns an input event that has already arrived, without
+// waiting. The editor handles every pending event before drawing, so a
+// burst of input costs one frame rather than one per key.
+func (t *Terminal) PendingEvent() (InputEvent, bool) {
+	select {
+	case res := <-t.input:
+		if res.err != nil {
+			t.readErr = res.err
+			return InputEvent{}, false
+		}
+		return res.event, true
+	default:
+		return InputEvent{}, false
 	}
 }
 
@@ -139,17 +445,31 @@ const (
 	KeyCtrlR            // Ctrl+R
 	KeyCtrlD            // Ctrl+D
 	KeyCtrlU            // Ctrl+U
+	KeyCtrlO            // Ctrl+O
 	KeyHome             // Home
 	KeyEnd              // End
 	KeyDelete           // Delete/Forward-delete
 	KeyPgUp             // Page Up
 	KeyPgDn             // Page Down
+	KeyTab              // Tab
+	KeyShiftTab         // Shift+Tab (back-tab)
 	KeyUnknown          // Unrecognised sequence
+	KeyCombo            // A character with Ctrl or Alt held, such as Ctrl-S
+)
+
+// Modifier is a set of modifier keys held with a key.
+type Modifier int
+
+const (
+	ModShift Modifier = 1 << iota
+	ModAlt
+	ModCtrl
 )
 
 type Key struct {
 	Type int
-	Rune rune
+	Rune rune     // The character, for KeyRune and KeyCombo
+	Mod  Modifier // Modifiers held, where the terminal reports them
 }
 
 // Event types.
@@ -157,6 +477,8 @@ const (
 	EventKey = iota
 	EventMouse
 	EventResize
+	EventTerminate
+	EventSuspend
 )
 
 // MouseButton types.
@@ -184,10 +506,70 @@ type InputEvent struct {
 	Type  int // EventKey or EventMouse
 	Key   Key
 	Mouse MouseEvent
+	Raw   []byte // Bytes read from stdin (for debug logging)
+
+	Signal os.Signal // Signal received, for EventTerminate
+}
+
+// splitInput splits bytes read in one go into events, one per key, escape
+// sequence or mouse report. Unfinished events at the end are taken as
+// they are.
+func splitInput(buf []byte) []InputEvent {
+	var events []InputEvent
+	for len(buf) > 0 {
+		n, _ := inputLength(buf)
+		events = append(events, parseInput(buf[:n]))
+		buf = buf[n:]
+	}
+	return events
+}
+
+// maxSequence caps the length of a CSI sequence, so a stray ESC [ can't
+// hold back the input that follows it.
+const maxSequence = 64
+
+// inputLength returns the length of the first event in buf: a CSI sequence
+// (ESC [ parameters final-byte, mouse reports included), an SS3 sequence
+// (ESC O x), or one UTF-8 character. An escape followed by anything else is
+// the Escape key on its own. done is false when buf ends before the event
+// does, or may: a lone ESC could begin a sequence. The length is then that
+// of the event taken as it is; an unfinished CSI sequence takes the rest
+// of buf, so its bytes are never typed as text.
+func inputLength(buf []byte) (n int, done bool) {
+	if buf[0] != 27 {
+		_, size := utf8.DecodeRune(buf)
+		return size, utf8.FullRune(buf)
+	}
+	if len(buf) == 1 {
+		return 1, false
+	}
+	switch buf[1] {
+	case '[':
+		i := 2
+		for i < len(buf) && buf[i] >= 0x20 && buf[i] <= 0x3F {
+			i++
+		}
+		if i < len(buf) && buf[i] >= 0x40 && buf[i] <= 0x7E {
+			return i + 1, true
+		}
+		if i < len(buf) {
+			return i, true // Not a sequence; what follows is read on its own
+		}
+		return len(buf), len(buf) >= maxSequence
+	case 'O':
+		return min(3, len(buf)), len(buf) >= 3
+	}
+	return 1, true
 }
 
-// parseInput determines whether the input is a key or mouse event.
 func parseInput(buf []byte) InputEvent {
+	event := classifyInput(buf)
+	event.Raw = append([]byte(nil), buf...)
+	return event
+}
+
+// classifyInput parses raw bytes into a key or mouse event.
+func classifyInput(buf []byte) InputEvent {
 	if len(buf) == 0 {
 		return InputEvent{Type: EventKey, Key: Key{Type: KeyUnknown}}
 	}
@@ -217,6 +599,8 @@ func parseKey(buf []byte) Key {
 			return Key{Type: KeyEscape}
 		case b == 13:
 			return Key{Type: KeyEnter}
+		case b == 9:
+			return Key{Type: KeyTab}
 		case b == 127 || b == 8:
 			return Key{Type: KeyBackspace}
 		case b == 26: // Ctrl+Z
@@ -229,6 +613,12 @@ func parseKey(buf []byte) Key {
 			return Key{Type: KeyCtrlD}
 		case b == 21: // Ctrl+U
 			return Key{Type: KeyCtrlU}
+		case b == 15: // Ctrl+O
+			return Key{Type: KeyCtrlO}
+		case b >= 1 && b <= 26: // Other Ctrl+letter keys
+			return Key{Type: KeyCombo, Rune: rune('a' + b - 1), Mod: ModCtrl}
+		case b == 31: // Ctrl+_, which most terminals send for Ctrl+/ too
+			return Key{Type: KeyCombo, Rune: '_', Mod: ModCtrl}
 		case b >= 32 && b < 127:
 			return Key{Type: KeyRune, Rune: rune(b)}
 		default:
@@ -238,48 +628,136 @@ func parseKey(buf []byte) Key {
 
 	// Escape sequences.
 	if buf[0] == 27 && len(buf) >= 3 && buf[1] == '[' {
-		// CSI 3-byte sequences.
-		switch buf[2] {
-		case 'A':
-			return Key{Type: KeyUp}
-		case 'B':
-			return Key{Type: KeyDown}
-		case 'C':
-			return Key{Type: KeyRight}
-		case 'D':
-			return Key{Type: KeyLeft}
-		case 'H':
-			return Key{Type: KeyHome}
-		case 'F':
-			return Key{Type: KeyEnd}
-		}
-
-		// CSI 4-byte sequences: ESC [ <n> ~
-		if len(buf) >= 4 && buf[3] == '~' {
-			switch buf[2] {
-			case '1':
-				return Key{Type: KeyHome}
-			case '3':
-				return Key{Type: KeyDelete}
-			case '4':
-				return Key{Type: KeyEnd}
-			case '5':
-				return Key{Type: KeyPgUp}
-			case '6':
-				return Key{Type: KeyPgDn}
-			}
-		}
+		return parseCSIKey(string(buf[2:len(buf)-1]), buf[len(buf)-1])
 	}
 
-	// Multi-byte UTF-8 character.
+	// Multi-byte UTF-8 character. Invalid bytes and control characters,
+	// such as the C1 range, are never typed into the document.
 	r := decodeUTF8(buf)
-	if r >= 32 {
+	if r != utf8.RuneError && !unicode.IsControl(r) {
 		return Key{Type: KeyRune, Rune: r}
 	}
 
 	return Key{Type: KeyUnknown}
 }
 
+// parseCSIKey parses the parameters and final byte of a CSI key sequence:
+// ESC [ A for Up, ESC [ 3 ~ for Delete, and their modified forms such as
+// ESC [ 1 ; 2 A for Shift-Up. It also reads the kitty keyboard protocol's
+// ESC [ code ; modifiers u and modifyOtherKeys' ESC [ 27 ; modifiers ; code ~.
+func parseCSIKey(params string, final byte) Key {
+	var nums []int
+	if params != "" {
+		for _, field := range strings.Split(params, ";") {
+			// Kitty adds :-separated details, such as the shifted key.
+			field, _, _ = strings.Cut(field, ":")
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 0 {
+				return Key{Type: KeyUnknown}
+			}
+			nums = append(nums, n)
+		}
+	}
+	param := func(i, def int) int {
+		if i < len(nums) && nums[i] > 0 {
+			return nums[i]
+		}
+		return def
+	}
+	// Modifiers are sent as one more than a bit set: 1 Shift, 2 Alt, 4 Ctrl.
+	mod := Modifier(param(1, 1)-1) & (ModShift | ModAlt | ModCtrl)
+
+	switch final {
+	case 'A', 'B', 'C', 'D', 'H', 'F', 'Z':
+		if param(0, 1) != 1 {
+			return Key{Type: KeyUnknown}
+		}
+		types := map[byte]int{'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft, 'H': KeyHome, 'F': KeyEnd, 'Z': KeyShiftTab}
+		if final == 'Z' {
+			mod &^= ModShift
+		}
+		return Key{Type: types[final], Mod: mod}
+	case '~':
+		if len(nums) == 3 && nums[0] == 27 {
+			return keyFromCode(nums[2], mod)
+		}
+		types := map[int]int{1: KeyHome, 7: KeyHome, 3: KeyDelete, 4: KeyEnd, 8: KeyEnd, 5: KeyPgUp, 6: KeyPgDn}
+		if t, ok := types[param(0, 0)]; ok {
+			return Key{Type: t, Mod: mod}
+		}
+	case 'u':
+		return keyFromCode(param(0, 0), mod)
+	}
+	return Key{Type: KeyUnknown}
+}
+
+// kittyKeypad maps the codes the kitty protocol gives the numeric keypad's
+// keys to the keys they type.
+var kittyKeypad = map[int]Key{
+	57399: {Type: KeyRune, Rune: '0'}, 57400: {Type: KeyRune, Rune: '1'},
+	57401: {Type: KeyRune, Rune: '2'}, 57402: {Type: KeyRune, Rune: '3'},
+	57403: {Type: KeyRune, Rune: '4'}, 57404: {Type: KeyRune, Rune: '5'},
+	57405: {Type: KeyRune, Rune: '6'}, 57406: {Type: KeyRune, Rune: '7'},
+	57407: {Type: KeyRune, Rune: '8'}, 57408: {Type: KeyRune, Rune: '9'},
+	57409: {Type: KeyRune, Rune: '.'}, 57410: {Type: KeyRune, Rune: '/'},
+	57411: {Type: KeyRune, Rune: '*'}, 57412: {Type: KeyRune, Rune: '-'},
+	57413: {Type: KeyRune, Rune: '+'}, 57414: {Type: KeyEnter},
+	57415: {Type: KeyRune, Rune: '='}, 57416: {Type: KeyRune, Rune: ','},
+	57417: {Type: KeyLeft}, 57418: {Type: KeyRight},
+	57419: {Type: KeyUp}, 57420: {Type: KeyDown},
+	57421: {Type: KeyPgUp}, 57422: {Type: KeyPgDn},
+	57423: {Type: KeyHome}, 57424: {Type: KeyEnd},
+	57426: {Type: KeyDelete},
+}
+
+// keyFromCode returns the key for a character code reported with
+// modifiers. Ctrl with the letters that have their own key types, and the
+// control characters Tab, Enter, Backspace and Escape stand for, give the
+// same keys as the plain terminal sends.
+func keyFromCode(code int, mod Modifier) Key {
+	switch code {
+	case 27:
+		return Key{Type: KeyEscape, Mod: mod}
+	case 13:
+		return Key{Type: KeyEnter, Mod: mod}
+	case 9:
+		if mod&ModShift != 0 {
+			return Key{Type: KeyShiftTab, Mod: mod &^ ModShift}
+		}
+		return Key{Type: KeyTab, Mod: mod}
+	case 127, 8:
+		return Key{Type: KeyBackspace, Mod: mod}
+	}
+	if kp, ok := kittyKeypad[code]; ok {
+		if kp.Type == KeyRune && mod&(ModCtrl|ModAlt) == 0 {
+			return kp
+		}
+		if kp.Type != KeyRune {
+			return Key{Type: kp.Type, Mod: mod}
+		}
+		code = int(kp.Rune)
+	}
+	r := rune(code)
+	if code > unicode.MaxRune || !utf8.ValidRune(r) || unicode.IsControl(r) || code >= 0xE000 && code <= 0xF8FF {
+		// Kitty numbers keys with no character, such as F13, in the
+		// private use area.
+		return Key{Type: KeyUnknown}
+	}
+	if mod&(ModCtrl|ModAlt) == 0 {
+		return Key{Type: KeyRune, Rune: r}
+	}
+	if mod == ModCtrl {
+		legacy := map[rune]int{
+			'z': KeyCtrlZ, 'y': KeyCtrlY, 'r': KeyCtrlR, 'd': KeyCtrlD, 'u': KeyCtrlU, 'o': KeyCtrlO,
+			'i': KeyTab, 'm': KeyEnter, 'h': KeyBackspace, '[': KeyEscape,
+		}
+		if t, ok := legacy[unicode.ToLower(r)]; ok {
+			return Key{Type: t}
+		}
+	}
+	return Key{Type: KeyCombo, Rune: r, Mod: mod}
+}
+
 // parseMouseEvent parses an SGR mouse sequence: ESC [ < Cb ; Cx ; Cy M|m
 // Returns the MouseEvent and true if parsing succeeded.
 func parseMouseEvent(buf []byte) (MouseEvent, bool) {
@@ -294,41 +772,21 @@ func parseMouseEvent(buf []byte) (MouseEvent, bool) {
 		return MouseEvent{}, false
 	}
 
-	// Find the semicolons and terminator.
+	// Parse button, column and row, each ended by its separator.
 	i := 3 // Start after ESC[<
-	button := 0
-	col := 0
-	row := 0
-	press := false
-
-	// Parse button.
-	for i < len(buf) && buf[i] >= '0' && buf[i] <= '9' {
-		button = button*10 + int(buf[i]-'0')
-		i++
-	}
-	if i >= len(buf) || buf[i] != ';' {
+	button, i, ok := parseMouseNumber(buf, i, ';')
+	if !ok {
 		return MouseEvent{}, false
 	}
-	i++ // Skip semicolon
-
-	// Parse column.
-	for i < len(buf) && buf[i] >= '0' && buf[i] <= '9' {
-		col = col*10 + int(buf[i]-'0')
-		i++
-	}
-	if i >= len(buf) || buf[i] != ';' {
+	col, i, ok := parseMouseNumber(buf, i, ';')
+	if !ok || col < 1 {
 		return MouseEvent{}, false
 	}
-	i++ // Skip semicolon
-
-	// Parse row.
-	for i < len(buf) && buf[i] >= '0' && buf[i] <= '9' {
-		row = row*10 + int(buf[i]-'0')
-		i++
-	}
-	if i >= len(buf) {
+	row, i, ok := parseMouseNumber(buf, i, 0)
+	if !ok || row < 1 {
 		return MouseEvent{}, false
 	}
+	press := false
 
 	// Check terminator: M for press, m for release.
 	switch buf[i] {
@@ -370,23 +828,42 @@ func parseMouseEvent(buf []byte) (MouseEvent, bool) {
 	}, true
 }
 
+// maxMouseDigits bounds the numbers in a mouse sequence, well past any real
+// screen, so garbage can't overflow them.
+const maxMouseDigits = 5
+
+// parseMouseNumber reads the decimal number at buf[i:] and, unless sep is 0,
+// the separator after it. It returns the number and the index after it, or
+// false if there are no digits, too many, or the separator is missing.
+func parseMouseNumber(buf []byte, i int, sep byte) (int, int, bool) {
+	start := i
+	n := 0
+	for i < len(buf) && buf[i] >= '0' && buf[i] <= '9' {
+		if i-start == maxMouseDigits {
+			return 0, i, false
+		}
+		n = n*10 + int(buf[i]-'0')
+		i++
+	}
+	if i == start || i >= len(buf) {
+		return 0, i, false
+	}
+	if sep != 0 {
+		if buf[i] != sep {
+			return 0, i, false
+		}
+		i++
+	}
+	return n, i, true
+}
+
+// decodeUTF8 returns the character at the start of buf, or 0xFFFD if the
+// bytes there aren't valid UTF-8: stray continuation bytes, truncated or
+// overlong sequences, surrogates and values past U+10FFFF.
 func decodeUTF8(buf []byte) rune {
 	if len(buf) == 0 {
 		return 0
 	}
-	// Simple UTF-8 decode for 1–4 byte sequences.
-	b := buf[0]
-	switch {
-	case b < 0x80:
-		return rune(b)
-	case b < 0xC0:
-		return 0xFFFD
-	case b < 0xE0 && len(buf) >= 2:
-		return rune(b&0x1F)<<6 | rune(buf[1]&0x3F)
-	case b < 0xF0 && len(buf) >= 3:
-		return rune(b&0x0F)<<12 | rune(buf[1]&0x3F)<<6 | rune(buf[2]&0x3F)
-	case b < 0xF8 && len(buf) >= 4:
-		return rune(b&0x07)<<18 | rune(buf[1]&0x3F)<<12 | rune(buf[2]&0x3F)<<6 | rune(buf[3]&0x3F)
-	}
-	return 0xFFFD
+	r, _ := utf8.DecodeRune(buf)
+	return r
 }
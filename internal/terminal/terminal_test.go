@@ -1,6 +1,13 @@
 package terminal
 
-import "testing"
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
 
 func TestParseKeyRune(t *testing.T) {
 	k := parseKey([]byte{'a'})
@@ -34,6 +41,17 @@ func TestParseKeyBackspace(t *testing.T) {
 	}
 }
 
+func TestParseKeyTab(t *testing.T) {
+	k := parseKey([]byte{9})
+	if k.Type != KeyTab {
+		t.Errorf("expected tab, got type=%d", k.Type)
+	}
+	k = parseKey([]byte{27, '[', 'Z'})
+	if k.Type != KeyShiftTab {
+		t.Errorf("expected shift-tab, got type=%d", k.Type)
+	}
+}
+
 func TestParseKeyCtrlZ(t *testing.T) {
 	k := parseKey([]byte{26})
 	if k.Type != KeyCtrlZ {
@@ -82,9 +100,18 @@ func TestParseKeyEmpty(t *testing.T) {
 
 func TestParseKeyControlChar(t *testing.T) {
 	// Control char that isn't specifically handled.
-	k := parseKey([]byte{1}) // Ctrl+A
+	k := parseKey([]byte{28}) // Ctrl+\
 	if k.Type != KeyUnknown {
-		t.Errorf("expected unknown for ctrl-a, got type=%d", k.Type)
+		t.Errorf("expected unknown for ctrl-\\, got type=%d", k.Type)
+	}
+	// Other Ctrl+letter keys are combos.
+	k = parseKey([]byte{19}) // Ctrl+S
+	if k != (Key{Type: KeyCombo, Rune: 's', Mod: ModCtrl}) {
+		t.Errorf("expected ctrl-s, got %+v", k)
+	}
+	k = parseKey([]byte{31}) // Ctrl+_
+	if k != (Key{Type: KeyCombo, Rune: '_', Mod: ModCtrl}) {
+		t.Errorf("expected ctrl-_, got %+v", k)
 	}
 }
 
@@ -133,6 +160,45 @@ func TestParseKeyCtrlU(t *testing.T) {
 	}
 }
 
+func TestParseKeyCtrlO(t *testing.T) {
+	k := parseKey([]byte{15})
+	if k.Type != KeyCtrlO {
+		t.Errorf("expected ctrl-o, got type=%d", k.Type)
+	}
+}
+
+func TestCursorStyleSequence(t *testing.T) {
+	if got := cursorStyleSequence(CursorBlinkingBar); got != "\x1b[5 q" {
+		t.Errorf("blinking bar = %q", got)
+	}
+	if got := cursorStyleSequence(CursorDefault); got != "\x1b[0 q" {
+		t.Errorf("default = %q", got)
+	}
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		colorterm, term string
+		want            ColorDepth
+	}{
+		{"truecolor", "xterm-256color", ColorsTrue},
+		{"24bit", "screen", ColorsTrue},
+		{"", "xterm-direct", ColorsTrue},
+		{"", "xterm-256color", Colors256},
+		{"", "tmux-256color", Colors256},
+		{"", "rxvt-unicode-256color", Colors256},
+		{"yes", "xterm", Colors16},
+		{"", "linux", Colors16},
+		{"", "", Colors16},
+	}
+	for _, tt := range tests {
+		env := map[string]string{"COLORTERM": tt.colorterm, "TERM": tt.term}
+		if got := DetectColorDepth(func(k string) string { return env[k] }); got != tt.want {
+			t.Errorf("COLORTERM=%q TERM=%q: got %d, want %d", tt.colorterm, tt.term, got, tt.want)
+		}
+	}
+}
+
 func TestParseKeyHomeEnd3Byte(t *testing.T) {
 	// Home: ESC [ H
 	k := parseKey([]byte{27, '[', 'H'})
@@ -166,6 +232,45 @@ func TestParseKeyCSI4Byte(t *testing.T) {
 	}
 }
 
+func TestParseKeyModified(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want Key
+	}{
+		// xterm modified cursor and editing keys.
+		{"\x1b[1;2A", Key{Type: KeyUp, Mod: ModShift}},
+		{"\x1b[1;5D", Key{Type: KeyLeft, Mod: ModCtrl}},
+		{"\x1b[1;6C", Key{Type: KeyRight, Mod: ModShift | ModCtrl}},
+		{"\x1b[1;3H", Key{Type: KeyHome, Mod: ModAlt}},
+		{"\x1b[3;5~", Key{Type: KeyDelete, Mod: ModCtrl}},
+		{"\x1b[2A", Key{Type: KeyUnknown}},
+		// Kitty keyboard protocol.
+		{"\x1b[115;5u", Key{Type: KeyCombo, Rune: 's', Mod: ModCtrl}},
+		{"\x1b[115;7u", Key{Type: KeyCombo, Rune: 's', Mod: ModCtrl | ModAlt}},
+		{"\x1b[122;5u", Key{Type: KeyCtrlZ}},
+		{"\x1b[105;5u", Key{Type: KeyTab}},
+		{"\x1b[27u", Key{Type: KeyEscape}},
+		{"\x1b[13;2u", Key{Type: KeyEnter, Mod: ModShift}},
+		{"\x1b[9;2u", Key{Type: KeyShiftTab}},
+		{"\x1b[97;69u", Key{Type: KeyCombo, Rune: 'a', Mod: ModCtrl}}, // Caps Lock on
+		{"\x1b[97:65;6u", Key{Type: KeyCombo, Rune: 'a', Mod: ModShift | ModCtrl}},
+		{"\x1b[57376u", Key{Type: KeyUnknown}}, // F13
+		{"\x1b[57401u", Key{Type: KeyRune, Rune: '2'}},
+		{"\x1b[57414u", Key{Type: KeyEnter}},
+		{"\x1b[57417;2u", Key{Type: KeyLeft, Mod: ModShift}},
+		// xterm modifyOtherKeys.
+		{"\x1b[27;5;115~", Key{Type: KeyCombo, Rune: 's', Mod: ModCtrl}},
+		{"\x1b[27;2;33~", Key{Type: KeyRune, Rune: '!'}},
+		{"\x1b[27;5;13~", Key{Type: KeyEnter, Mod: ModCtrl}},
+		{"\x1b[27;x;1~", Key{Type: KeyUnknown}},
+	}
+	for _, tt := range tests {
+		if got := parseKey([]byte(tt.seq)); got != tt.want {
+			t.Errorf("parseKey(%q) = %+v, want %+v", tt.seq, got, tt.want)
+		}
+	}
+}
+
 func TestParseMouseEvent(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -255,6 +360,9 @@ func TestEventResizeType(t *testing.T) {
 	if EventResize == EventKey || EventResize == EventMouse {
 		t.Errorf("EventResize must be distinct: key=%d mouse=%d resize=%d", EventKey, EventMouse, EventResize)
 	}
+	if EventTerminate == EventResize || EventTerminate == EventKey || EventTerminate == EventMouse {
+		t.Errorf("EventTerminate must be distinct: terminate=%d", EventTerminate)
+	}
 	// Verify an EventResize InputEvent can be constructed.
 	ev := InputEvent{Type: EventResize}
 	if ev.Type != EventResize {
@@ -311,3 +419,242 @@ func TestParseInput(t *testing.T) {
 		})
 	}
 }
+
+func TestParseInputKeepsRawBytes(t *testing.T) {
+	input := []byte("\x1b[A")
+	event := parseInput(input)
+	if string(event.Raw) != "\x1b[A" {
+		t.Errorf("Raw = %q, want %q", event.Raw, input)
+	}
+	// Raw must be a copy, not an alias of the read buffer.
+	input[2] = 'B'
+	if event.Raw[2] != 'A' {
+		t.Error("Raw should not alias the input buffer")
+	}
+}
+
+func TestParseMouseEventMalformed(t *testing.T) {
+	for _, input := range []string{
+		"\x1b[<;10;5M",                     // No button
+		"\x1b[<0;;5M",                      // No column
+		"\x1b[<0;10;M",                     // No row
+		"\x1b[<0;0;5M",                     // Columns start at 1
+		"\x1b[<0;10;5",                     // No terminator
+		"\x1b[<0;10;5X",                    // Wrong terminator
+		"\x1b[<0;10;99999999999999999999M", // Would overflow
+		"\x1b[<0;10;123456M",               // Past any real screen
+	} {
+		if mouse, ok := parseMouseEvent([]byte(input)); ok {
+			t.Errorf("parseMouseEvent(%q) = %+v, want failure", input, mouse)
+		}
+	}
+}
+
+func TestParseKeyRejectsInvalidRunes(t *testing.T) {
+	for _, input := range [][]byte{
+		{0xC2, 0x85},             // C1 control (NEL)
+		{0xC3},                   // Truncated
+		{0xC0, 0x80},             // Overlong NUL
+		{0xED, 0xA0, 0x80},       // Surrogate
+		{0xF7, 0xBF, 0xBF, 0xBF}, // Past U+10FFFF
+		{0xE6, 0x41, 0x41},       // Bad continuation bytes
+	} {
+		if key := parseKey(input); key.Type != KeyUnknown {
+			t.Errorf("parseKey(% x) = %+v, want KeyUnknown", input, key)
+		}
+	}
+}
+
+func FuzzParseInput(f *testing.F) {
+	for _, seed := range []string{"a", "\x1b", "\x1b[A", "\x1b[5~", "\x1b[<0;10;5M", "\x1b[<64;1;1m", "é", "日本", "\x1b[<0;1"} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		event := parseInput(buf)
+		if string(event.Raw) != string(buf) {
+			t.Errorf("Raw = %q, want %q", event.Raw, buf)
+		}
+		var joined []byte
+		for _, e := range splitInput(buf) {
+			if len(e.Raw) == 0 {
+				t.Fatalf("splitInput(%q) made an empty event", buf)
+			}
+			joined = append(joined, e.Raw...)
+		}
+		if string(joined) != string(buf) {
+			t.Errorf("splitInput(%q) events join to %q", buf, joined)
+		}
+	})
+}
+
+func FuzzParseKey(f *testing.F) {
+	for _, seed := range []string{"a", "\r", "\x1b[3~", "\x1b[Z", "ß", "\xc2\x85", "\xff"} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		key := parseKey(buf)
+		if key.Type == KeyRune && (!utf8.ValidRune(key.Rune) || key.Rune == utf8.RuneError || unicode.IsControl(key.Rune)) {
+			t.Errorf("parseKey(%q) typed %U", buf, key.Rune)
+		}
+	})
+}
+
+func FuzzParseMouseEvent(f *testing.F) {
+	for _, seed := range []string{"\x1b[<0;10;5M", "\x1b[<2;30;25m", "\x1b[<65;1;1M", "\x1b[<0;1;1", "\x1b[<;;M"} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		mouse, ok := parseMouseEvent(buf)
+		if !ok {
+			return
+		}
+		if mouse.Row < 1 || mouse.Col < 1 || mouse.Row > 99999 || mouse.Col > 99999 {
+			t.Errorf("parseMouseEvent(%q) = %+v, out of range", buf, mouse)
+		}
+	})
+}
+
+func FuzzDecodeUTF8(f *testing.F) {
+	for _, seed := range []string{"", "A", "é", "日", "🙂", "\x80", "\xf4\x90\x80\x80"} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		if r := decodeUTF8(buf); !utf8.ValidRune(r) {
+			t.Errorf("decodeUTF8(%q) = %U, not a valid rune", buf, r)
+		}
+	})
+}
+
+func TestSplitInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // Raw bytes of each event
+	}{
+		{"pasted text", "ab é", []string{"a", "b", " ", "é"}},
+		{"keys and arrows", "j\x1b[Ak\x1b[3~", []string{"j", "\x1b[A", "k", "\x1b[3~"}},
+		{"mouse drag", "\x1b[<32;10;5M\x1b[<32;11;5M", []string{"\x1b[<32;10;5M", "\x1b[<32;11;5M"}},
+		{"escape then a key", "\x1bdd", []string{"\x1b", "d", "d"}},
+		{"lone escape", "\x1b", []string{"\x1b"}},
+		{"SS3 arrow", "\x1bOAx", []string{"\x1bOA", "x"}},
+		{"unfinished sequence", "x\x1b[12;", []string{"x", "\x1b[12;"}},
+		{"invalid byte", "\xffa", []string{"\xff", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := splitInput([]byte(tt.input))
+			var got []string
+			for _, e := range events {
+				got = append(got, string(e.Raw))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitInput(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("splitInput(%q) = %q, want %q", tt.input, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	events := splitInput([]byte("a\x1b[<0;3;4M"))
+	if events[0].Key.Rune != 'a' || events[1].Type != EventMouse || events[1].Mouse.Col != 3 {
+		t.Errorf("events should be parsed: %+v", events)
+	}
+}
+
+func TestInputParserFeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string // Raw bytes of each event
+		held   string   // Bytes still waiting for more
+	}{
+		{"whole keys", []string{"ab"}, []string{"a", "b"}, ""},
+		{"arrow split after escape", []string{"\x1b", "[A"}, []string{"\x1b[A"}, ""},
+		{"arrow split in parameters", []string{"j\x1b[1;", "2A"}, []string{"j", "\x1b[1;2A"}, ""},
+		{"mouse report split", []string{"\x1b[<0;1", "0;5M"}, []string{"\x1b[<0;10;5M"}, ""},
+		{"SS3 split", []string{"\x1bO", "Ax"}, []string{"\x1bOA", "x"}, ""},
+		{"UTF-8 split", []string{"\xc3", "\xa9"}, []string{"é"}, ""},
+		{"lone escape held", []string{"x\x1b"}, []string{"x"}, "\x1b"},
+		{"escape then a key", []string{"\x1bd"}, []string{"\x1b", "d"}, ""},
+		{"not a sequence", []string{"\x1b[1\xffa"}, []string{"\x1b[1", "\xff", "a"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p inputParser
+			var got []string
+			for _, chunk := range tt.chunks {
+				for _, e := range p.feed([]byte(chunk)) {
+					got = append(got, string(e.Raw))
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("feed(%q) = %q, want %q", tt.chunks, got, tt.want)
+			}
+			if string(p.pending) != tt.held {
+				t.Errorf("feed(%q) held %q, want %q", tt.chunks, p.pending, tt.held)
+			}
+		})
+	}
+
+	var p inputParser
+	p.feed([]byte("\x1b["))
+	p.feed([]byte(strings.Repeat("1", maxSequence)))
+	if len(p.pending) != 0 {
+		t.Errorf("an overlong sequence should not be held, got %q", p.pending)
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	chunks := make(chan readChunk)
+	out := make(chan readResult, 8)
+	go parseStream(chunks, out, 10*time.Millisecond)
+	next := func() readResult {
+		t.Helper()
+		select {
+		case res := <-out:
+			return res
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+			return readResult{}
+		}
+	}
+
+	// An escape sequence split over reads is one key.
+	chunks <- readChunk{data: []byte("\x1b")}
+	chunks <- readChunk{data: []byte("[B")}
+	if res := next(); res.event.Key.Type != KeyDown {
+		t.Errorf("split arrow = %+v, want KeyDown", res.event.Key)
+	}
+
+	// A lone escape is Escape once the timeout passes.
+	chunks <- readChunk{data: []byte("\x1b")}
+	if res := next(); res.event.Key.Type != KeyEscape {
+		t.Errorf("lone escape = %+v, want KeyEscape", res.event.Key)
+	}
+
+	// Held bytes are sent before a read error.
+	chunks <- readChunk{data: []byte("\x1b")}
+	chunks <- readChunk{err: io.EOF}
+	if res := next(); res.event.Key.Type != KeyEscape {
+		t.Errorf("escape before error = %+v, want KeyEscape", res.event.Key)
+	}
+	if res := next(); res.err != io.EOF {
+		t.Errorf("err = %v, want EOF", res.err)
+	}
+}
+
+func TestReadEventTimeout(t *testing.T) {
+	term := &Terminal{input: make(chan readResult, 1)}
+	if _, ok, err := term.ReadEventTimeout(time.Millisecond); ok || err != nil {
+		t.Errorf("no input should time out, got ok %v, err %v", ok, err)
+	}
+	term.input <- readResult{event: parseInput([]byte("j"))}
+	event, ok, err := term.ReadEventTimeout(time.Second)
+	if !ok || err != nil || event.Key.Rune != 'j' {
+		t.Errorf("queued input should be read, got %+v, ok %v, err %v", event, ok, err)
+	}
+}